@@ -1,19 +1,70 @@
 package main
 
 import (
+	"context"
 	"flag"
+	"fmt"
 	"log"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/grafana/loki/v3/pkg/dataobj/tools"
 )
 
+const usage = `Usage: dataobj-inspect [command] [flags] <file>...
+
+Commands:
+  stats    Print summary statistics of the sections of objects (default).
+  streams  Print the streams of objects as a table.
+  dump     Print the logs of objects as JSON lines.
+  query    Print the logs of objects matching a LogQL query as JSON lines.
+  verify   Check the page checksums and metadata of objects.
+
+Run 'dataobj-inspect <command> -h' for the flags of a command.
+`
+
 func main() {
+	log.SetFlags(0)
+	flag.Usage = func() { fmt.Fprint(flag.CommandLine.Output(), usage) }
 	flag.Parse()
 
-	for _, f := range flag.Args() {
+	args := flag.Args()
+	if len(args) == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	var err error
+	switch args[0] {
+	case "stats":
+		err = runStats(args[1:])
+	case "streams":
+		err = runStreams(args[1:])
+	case "dump":
+		err = runDump(args[1:])
+	case "query":
+		err = runQuery(args[1:])
+	case "verify":
+		err = runVerify(args[1:])
+	default:
+		// Without a command, print statistics like previous versions.
+		err = runStats(args)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+func runStats(args []string) error {
+	fs := flag.NewFlagSet("stats", flag.ExitOnError)
+	_ = fs.Parse(args)
+
+	for _, f := range fs.Args() {
 		printFile(f)
 	}
+	return nil
 }
 
 func printFile(filename string) {
@@ -32,3 +83,135 @@ func printFile(filename string) {
 
 	tools.Inspect(f, fi.Size())
 }
+
+func runStreams(args []string) error {
+	fs := flag.NewFlagSet("streams", flag.ExitOnError)
+	_ = fs.Parse(args)
+
+	return forEachFile(fs.Args(), true, func(f *os.File, size int64) error {
+		return tools.PrintStreams(context.Background(), os.Stdout, f, size)
+	})
+}
+
+func runDump(args []string) error {
+	var (
+		fs        = flag.NewFlagSet("dump", flag.ExitOnError)
+		streamIDs = fs.String("stream-ids", "", "Comma-separated IDs of the streams to dump. Dumps all streams if empty.")
+		from      = fs.String("from", "", "Start of the time range of logs to dump (inclusive), in RFC3339 format.")
+		to        = fs.String("to", "", "End of the time range of logs to dump (exclusive), in RFC3339 format.")
+	)
+	_ = fs.Parse(args)
+
+	var opts tools.DumpOptions
+	if *streamIDs != "" {
+		for _, s := range strings.Split(*streamIDs, ",") {
+			id, err := strconv.ParseInt(strings.TrimSpace(s), 10, 64)
+			if err != nil {
+				return fmt.Errorf("invalid stream ID %q: %w", s, err)
+			}
+			opts.StreamIDs = append(opts.StreamIDs, id)
+		}
+	}
+
+	var err error
+	if opts.Start, opts.End, err = parseTimeRange(*from, *to); err != nil {
+		return err
+	}
+
+	return forEachFile(fs.Args(), false, func(f *os.File, size int64) error {
+		return tools.DumpLogs(context.Background(), os.Stdout, f, size, opts)
+	})
+}
+
+func runQuery(args []string) error {
+	var (
+		fs    = flag.NewFlagSet("query", flag.ExitOnError)
+		query = fs.String("query", "", "LogQL stream selector, optionally followed by line filters, such as '{app=\"foo\"} |= \"error\"'.")
+		from  = fs.String("from", "", "Start of the time range of logs to query (inclusive), in RFC3339 format.")
+		to    = fs.String("to", "", "End of the time range of logs to query (exclusive), in RFC3339 format.")
+	)
+	_ = fs.Parse(args)
+
+	if *query == "" {
+		return fmt.Errorf("query: -query is required")
+	}
+	start, end, err := parseTimeRange(*from, *to)
+	if err != nil {
+		return err
+	}
+
+	return forEachFile(fs.Args(), false, func(f *os.File, size int64) error {
+		return tools.Query(context.Background(), os.Stdout, f, size, *query, start, end)
+	})
+}
+
+func runVerify(args []string) error {
+	fs := flag.NewFlagSet("verify", flag.ExitOnError)
+	_ = fs.Parse(args)
+
+	// Verify all objects, even if some of them fail verification.
+	var failed int
+	for _, filename := range fs.Args() {
+		fmt.Printf("==> %s <==\n", filename)
+		err := withFile(filename, func(f *os.File, size int64) error {
+			return tools.Verify(context.Background(), os.Stdout, f, size)
+		})
+		if err != nil {
+			failed++
+			fmt.Printf("FAILED: %v\n\n", err)
+		} else {
+			fmt.Println()
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d objects failed verification", failed, len(fs.Args()))
+	}
+	return nil
+}
+
+// forEachFile calls f for each of the named files. If header is true and
+// there are multiple files, the name of each file is printed before calling
+// f.
+func forEachFile(filenames []string, header bool, f func(f *os.File, size int64) error) error {
+	for i, filename := range filenames {
+		if header && len(filenames) > 1 {
+			if i > 0 {
+				fmt.Println()
+			}
+			fmt.Printf("==> %s <==\n", filename)
+		}
+
+		if err := withFile(filename, f); err != nil {
+			return fmt.Errorf("%s: %w", filename, err)
+		}
+	}
+	return nil
+}
+
+func withFile(filename string, f func(f *os.File, size int64) error) error {
+	file, err := os.Open(filename)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = file.Close() }()
+
+	fi, err := file.Stat()
+	if err != nil {
+		return err
+	}
+	return f(file, fi.Size())
+}
+
+func parseTimeRange(from, to string) (start, end time.Time, err error) {
+	if from != "" {
+		if start, err = time.Parse(time.RFC3339Nano, from); err != nil {
+			return start, end, fmt.Errorf("invalid start time: %w", err)
+		}
+	}
+	if to != "" {
+		if end, err = time.Parse(time.RFC3339Nano, to); err != nil {
+			return start, end, fmt.Errorf("invalid end time: %w", err)
+		}
+	}
+	return start, end, nil
+}
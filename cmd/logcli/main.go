@@ -1,6 +1,7 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 	"io"
 	"log"
@@ -8,6 +9,7 @@ import (
 	"net/url"
 	"os"
 	"runtime/pprof"
+	"strconv"
 	"strings"
 	"time"
 
@@ -17,6 +19,8 @@ import (
 
 	"github.com/grafana/loki/v3/pkg/logcli/client"
 	"github.com/grafana/loki/v3/pkg/logcli/detected"
+	"github.com/grafana/loki/v3/pkg/logcli/diff"
+	"github.com/grafana/loki/v3/pkg/logcli/explore"
 	"github.com/grafana/loki/v3/pkg/logcli/index"
 	"github.com/grafana/loki/v3/pkg/logcli/labelquery"
 	"github.com/grafana/loki/v3/pkg/logcli/output"
@@ -35,10 +39,13 @@ var (
 		Short('q').
 		Bool()
 	statistics = app.Flag("stats", "Show query statistics").Default("false").Bool()
-	outputMode = app.Flag("output", "Specify output mode [default, raw, jsonl]. raw suppresses log labels and timestamp.").
+	outputMode = app.Flag("output", "Specify output mode [default, raw, jsonl, csv, parquet, arrow]. raw suppresses log labels and timestamp. csv, parquet and arrow write a table with a column per label and structured metadata.").
 			Default("default").
 			Short('o').
-			Enum("default", "raw", "jsonl")
+			Enum("default", "raw", "jsonl", "csv", "parquet", "arrow")
+	tableBufferRows = app.Flag("table-buffer-rows", "Number of rows buffered by the csv, parquet and arrow output modes to determine the columns of the table, which are written before any row. The query fails if a later row has a column that the buffered rows don't have. When merging part files, the columns of all parts are used.").
+			Default(strconv.Itoa(output.DefaultTableBufferRows)).
+			Int()
 	timezone = app.Flag("timezone", "Specify the timezone to use when formatting output timestamps [Local, UTC]").
 			Default("Local").
 			Short('z').
@@ -52,7 +59,13 @@ var (
 	memProfile = app.Flag("memprofile", "Specify the location for writing a memory profile.").
 			Default("").
 			String()
-	stdin = app.Flag("stdin", "Take input logs from stdin").Bool()
+	stdin             = app.Flag("stdin", "Take input logs from stdin").Bool()
+	stdinFormat       = app.Flag("stdin-format", "Format of the logs taken from stdin: raw log lines, or the JSON lines written by --output=jsonl, whose timestamps and labels are kept.").Default(client.FileFormatRaw).Enum(client.FileFormatRaw, client.FileFormatJSONL)
+	stdinLabelsRegexp = app.Flag("stdin-labels-regexp", "Regexp whose named groups are extracted as labels of the logs taken from stdin. Each line is routed into the stream of its labels.").Regexp()
+	stdinJSONLabels   = app.Flag("stdin-labels-json", "Key of JSON logs taken from stdin to extract as a label. Can be repeated. Each line is routed into the stream of its labels.").Strings()
+	stdinLogfmtLabels = app.Flag("stdin-labels-logfmt", "Key of logfmt logs taken from stdin to extract as a label. Can be repeated. Each line is routed into the stream of its labels.").Strings()
+	stdinTimestamp    = app.Flag("stdin-timestamp", "Named group of --stdin-labels-regexp, or key of --stdin-labels-json or --stdin-labels-logfmt logs, with the timestamp of the logs taken from stdin. Lines without it have the timestamp of the previous line.").Default("").String()
+	stdinTimestampFmt = app.Flag("stdin-timestamp-format", "Go time layout of --stdin-timestamp, or one of Unix, UnixMs, UnixUs and UnixNs.").Default(time.RFC3339Nano).String()
 
 	queryClient = newQueryClient(app)
 
@@ -64,6 +77,16 @@ returned in a few output modes:
 	raw: log line
 	default: log timestamp + log labels + log line
 	jsonl: JSON response from Loki API of log line
+	csv: CSV with a header row
+	parquet: Parquet file
+	arrow: Arrow IPC file, also known as Feather V2
+
+The csv, parquet and arrow output modes write a table with a timestamp and
+line column, and a column per label and structured metadata. For metric
+queries, they write a row per sample with a timestamp and value column, and
+a column per label. The columns are determined by the first rows, which are
+buffered up to --table-buffer-rows, and the following rows are written in
+batches. These output modes can't be used with --tail.
 
 The output of the log can be specified with the "-o" flag, for
 example, "-o raw" for the raw output format.
@@ -174,7 +197,36 @@ printed to the terminal. The lines will be printed as soon as the next part is
 complete, you don't have to wait for all the parts to download before getting
 output. The --merge-parts flag will remove the part files when it is done
 reading each of them. To change this, you can use the --keep-parts flag, and
-the part files will not be removed.`)
+the part files will not be removed.
+
+With the csv, parquet and arrow output modes, each part file is a complete file
+of that format. The --merge-parts flag combines the parts into a single file
+with the columns of all parts. Since the columns are written first, printing
+starts once all parts are complete, and the rows of each part are then read
+in batches.
+
+Local queries:
+
+The --local-dir flag runs the query against data on the local filesystem,
+without any Loki services. The directory is either a copy of a bucket of data
+objects, or the directory of a filesystem chunk store with a TSDB index, such
+as the storage_config.filesystem.directory of a single binary Loki.
+
+For data objects, the metastore of the tenant is used to find the objects of
+the query if there is one; otherwise all objects of the tenant are read. If the
+directory holds the objects of a single tenant, the tenant is used unless
+--org-id is set.
+
+For chunk stores, the schema is read from the schemaconfig file in the
+directory if there is one; otherwise a v13 TSDB schema is inferred from the
+names of the index tables.
+
+Example:
+
+	logcli query
+	   --local-dir=/var/lib/loki/chunks
+	   --since=24h
+	   'sum by (level) (count_over_time({app="foo"} | logfmt [5m]))'`)
 	rangeQuery = newQuery(false, queryCmd)
 	tail       = queryCmd.Flag("tail", "Tail the logs").Short('t').Default("false").Bool()
 	follow     = queryCmd.Flag("follow", "Alias for --tail").Short('f').Default("false").Bool()
@@ -315,6 +367,74 @@ The query is limited to processing 1000 lines per subquery; use --line-limit to
 `)
 
 	detectedFieldsQuery = newDetectedFieldsQuery(detectedFieldsCmd)
+
+	exploreCmd = app.Command("explore", `Interactively explore logs in the terminal.
+
+The "explore" command opens a terminal UI with a browser of label names and
+values, a LogQL query editor which validates the query while typing, and the
+results of the query. Log results are shown a page at a time below a histogram
+of the log volume of the query over the time range.
+
+Keys:
+
+	tab/shift+tab  move the focus between the query editor, labels and results
+	enter          run the query, or add the selected label value to the query
+	n/p            show the next or previous page of logs
+	ctrl+t         toggle between the log and metric views
+	ctrl+f         show the fields detected in the logs of the query
+	ctrl+c         quit
+
+The metric view shows the results of metric queries. Log queries are shown as
+the number of log lines per step in the metric view.
+
+By default we look over the last hour of data; use --since to modify
+or provide specific start and end times with --from and --to respectively.
+
+Example:
+
+	logcli explore --since=24h '{app="foo"} |= "error"'
+`)
+	exploreQuery = newExploreQuery(exploreCmd)
+
+	diffCmd = app.Command("diff", `Compare the results of a LogQL query between two Loki endpoints or two time ranges.
+
+The "diff" command runs the same range query against --addr and --other-addr,
+or against --addr over the time range and the time range moved back by
+--offset, and reports the differences between the results. Both can be set to
+compare the results of --other-addr at an offset.
+
+Metric results are compared sample by sample. Sample values are equal if they
+are within --tolerance of each other, as in querytee. Log results are compared
+entry by entry; the order of entries with the same timestamp is ignored.
+
+The command prints a summary of the comparison, the series or streams which are
+only part of one result, and the samples or entries which differ. It exits with
+status 1 if the results differ.
+
+The second endpoint is queried with the same credentials and TLS settings as
+--addr, and with the tenant of --other-org-id if set.
+
+By default we look over the last hour of data; use --since to modify
+or provide specific start and end times with --from and --to respectively.
+
+Example:
+
+	logcli diff
+	   --addr=http://loki-old:3100
+	   --other-addr=http://loki-new:3100
+	   --since=6h
+	   --step=1m
+	   --tolerance=0.000001
+	   'sum by (level) (count_over_time({app="foo"} | logfmt [1m]))'
+
+Example comparing today with yesterday:
+
+	logcli diff --offset=24h '{app="foo"} |= "error"'
+`)
+	diffQuery      = newDiffQuery(diffCmd)
+	diffOtherAddr  = diffCmd.Flag("other-addr", "Address of the server to compare --addr with.").Default("").String()
+	diffOtherOrgID = diffCmd.Flag("other-org-id", "Tenant ID of --other-addr. Defaults to --org-id.").Default("").String()
+	diffMaxDiffs   = diffCmd.Flag("max-mismatches", "Maximum number of mismatching samples or entries to print. Setting it to 0 prints all mismatches.").Default("20").Int()
 )
 
 func main() {
@@ -348,7 +468,18 @@ func main() {
 	}
 
 	if *stdin {
-		queryClient = client.NewFileClient(os.Stdin)
+		fileClient, err := client.NewFileClientWithConfig(os.Stdin, client.FileConfig{
+			Format:          *stdinFormat,
+			LabelsRegexp:    *stdinLabelsRegexp,
+			JSONLabels:      *stdinJSONLabels,
+			LogfmtLabels:    *stdinLogfmtLabels,
+			TimestampField:  *stdinTimestamp,
+			TimestampLayout: *stdinTimestampFmt,
+		})
+		if err != nil {
+			log.Fatalf("Invalid stdin flags: %s", err)
+		}
+		queryClient = fileClient
 		if rangeQuery.Step.Seconds() == 0 {
 			// Set default value for `step` based on `start` and `end`.
 			// In non-stdin case, this is set on Loki server side.
@@ -381,9 +512,10 @@ func main() {
 		}
 
 		outputOptions := &output.LogOutputOptions{
-			Timezone:      location,
-			NoLabels:      rangeQuery.NoLabels,
-			ColoredOutput: rangeQuery.ColoredOutput,
+			Timezone:        location,
+			NoLabels:        rangeQuery.NoLabels,
+			ColoredOutput:   rangeQuery.ColoredOutput,
+			TableBufferRows: *tableBufferRows,
 		}
 
 		switch *outputTimestampFmt {
@@ -410,6 +542,10 @@ func main() {
 			log.Fatalf("Unable to create log output: %s", err)
 		}
 
+		if (*tail || *follow) && output.IsTableMode(*outputMode) {
+			log.Fatalf("Output mode %s can't be used with --tail", *outputMode)
+		}
+
 		if *tail || *follow {
 			rangeQuery.TailQuery(time.Duration(*delayFor)*time.Second, queryClient, out)
 		} else if rangeQuery.ParallelMaxWorkers == 1 {
@@ -419,6 +555,9 @@ func main() {
 			rangeQuery.Limit = 0
 			rangeQuery.DoQueryParallel(queryClient, out, *statistics)
 		}
+		if err := output.Close(out); err != nil {
+			log.Fatalf("Unable to write output: %s", err)
+		}
 	case instantQueryCmd.FullCommand():
 		location, err := time.LoadLocation(*timezone)
 		if err != nil {
@@ -426,9 +565,10 @@ func main() {
 		}
 
 		outputOptions := &output.LogOutputOptions{
-			Timezone:      location,
-			NoLabels:      instantQuery.NoLabels,
-			ColoredOutput: instantQuery.ColoredOutput,
+			Timezone:        location,
+			NoLabels:        instantQuery.NoLabels,
+			ColoredOutput:   instantQuery.ColoredOutput,
+			TableBufferRows: *tableBufferRows,
 		}
 
 		out, err := output.NewLogOutput(os.Stdout, *outputMode, outputOptions)
@@ -437,6 +577,9 @@ func main() {
 		}
 
 		instantQuery.DoQuery(queryClient, out, *statistics)
+		if err := output.Close(out); err != nil {
+			log.Fatalf("Unable to write output: %s", err)
+		}
 	case labelsCmd.FullCommand():
 		labelsQuery.DoLabels(queryClient)
 	case seriesCmd.FullCommand():
@@ -454,9 +597,10 @@ func main() {
 		}
 
 		outputOptions := &output.LogOutputOptions{
-			Timezone:      location,
-			NoLabels:      rangeQuery.NoLabels,
-			ColoredOutput: rangeQuery.ColoredOutput,
+			Timezone:        location,
+			NoLabels:        rangeQuery.NoLabels,
+			ColoredOutput:   rangeQuery.ColoredOutput,
+			TableBufferRows: *tableBufferRows,
 		}
 
 		out, err := output.NewLogOutput(os.Stdout, *outputMode, outputOptions)
@@ -469,8 +613,38 @@ func main() {
 		} else {
 			index.GetVolume(volumeQuery, queryClient, out, *statistics)
 		}
+		if err := output.Close(out); err != nil {
+			log.Fatalf("Unable to write output: %s", err)
+		}
 	case detectedFieldsCmd.FullCommand():
 		detectedFieldsQuery.Do(queryClient, *outputMode)
+	case exploreCmd.FullCommand():
+		if err := explore.Run(queryClient, exploreQuery); err != nil {
+			log.Fatalf("Error running explorer: %s", err)
+		}
+	case diffCmd.FullCommand():
+		otherClient, err := newDiffClient(queryClient, *diffOtherAddr, *diffOtherOrgID)
+		if err != nil {
+			log.Fatalf("Unable to create client for --other-addr: %s", err)
+		}
+
+		diffQuery.NameA = clientName(queryClient)
+		diffQuery.NameB = clientName(otherClient)
+		if diffQuery.NameB == diffQuery.NameA && *diffOtherOrgID != "" {
+			diffQuery.NameB += " tenant " + *diffOtherOrgID
+		}
+		if diffQuery.Offset != 0 {
+			diffQuery.NameB += " offset " + diffQuery.Offset.String()
+		}
+
+		result, err := diffQuery.Do(queryClient, otherClient)
+		if err != nil {
+			log.Fatalf("Diff failed: %s", err)
+		}
+		result.Print(os.Stdout, *diffMaxDiffs)
+		if !result.Equal() {
+			os.Exit(1)
+		}
 	}
 }
 
@@ -490,6 +664,42 @@ func formatLogQL(r io.Reader, w io.Writer) error {
 	return nil
 }
 
+// clientName returns the address of c.
+func clientName(c client.Client) string {
+	if defaultClient, ok := c.(*client.DefaultClient); ok {
+		return defaultClient.Address
+	}
+	return "stdin"
+}
+
+// newDiffClient returns a copy of c for the server at addr with the tenant
+// orgID. The copy has the address and tenant of c if they are empty.
+func newDiffClient(c client.Client, addr, orgID string) (client.Client, error) {
+	if addr == "" && orgID == "" {
+		return c, nil
+	}
+	defaultClient, ok := c.(*client.DefaultClient)
+	if !ok {
+		return nil, errors.New("--other-addr and --other-org-id can't be used with --stdin")
+	}
+
+	other := *defaultClient
+	if orgID != "" {
+		other.OrgID = orgID
+	}
+	if addr != "" {
+		other.Address = addr
+		if other.ProxyURL == "" {
+			u, err := url.Parse(addr)
+			if err != nil {
+				return nil, err
+			}
+			other.TLSConfig.ServerName = strings.Split(u.Host, ":")[0]
+		}
+	}
+	return &other, nil
+}
+
 func newQueryClient(app *kingpin.Application) client.Client {
 
 	client := &client.DefaultClient{
@@ -523,6 +733,7 @@ func newQueryClient(app *kingpin.Application) client.Client {
 	app.Flag("org-id", "adds X-Scope-OrgID to API requests for representing tenant ID. Useful for requesting tenant data when bypassing an auth gateway. Can also be set using LOKI_ORG_ID env var.").Default("").Envar("LOKI_ORG_ID").StringVar(&client.OrgID)
 	app.Flag("query-tags", "adds X-Query-Tags http header to API requests. This header value will be part of `metrics.go` statistics. Useful for tracking the query. Can also be set using LOKI_QUERY_TAGS env var.").Default("").Envar("LOKI_QUERY_TAGS").StringVar(&client.QueryTags)
 	app.Flag("nocache", "adds Cache-Control: no-cache http header to API requests. Can also be set using LOKI_NO_CACHE env var.").Default("false").Envar("LOKI_NO_CACHE").BoolVar(&client.NoCache)
+	app.Flag("explain-analyze", "adds X-Loki-Explain-Analyze http header to query requests. Queries executed by the new query engine return their query plans annotated with runtime statistics of each node, which are printed to stderr. Implies --nocache. Can also be set using LOKI_EXPLAIN_ANALYZE env var.").Default("false").Envar("LOKI_EXPLAIN_ANALYZE").BoolVar(&client.ExplainAnalyze)
 	app.Flag("bearer-token", "adds the Authorization header to API requests for authentication purposes. Can also be set using LOKI_BEARER_TOKEN env var.").Default("").Envar("LOKI_BEARER_TOKEN").StringVar(&client.BearerToken)
 	app.Flag("bearer-token-file", "adds the Authorization header to API requests for authentication purposes. Can also be set using LOKI_BEARER_TOKEN_FILE env var.").Default("").Envar("LOKI_BEARER_TOKEN_FILE").StringVar(&client.BearerTokenFile)
 	app.Flag("retries", "How many times to retry each query when getting an error response from Loki. Can also be set using LOKI_CLIENT_RETRIES env var.").Default("0").Envar("LOKI_CLIENT_RETRIES").IntVar(&client.Retries)
@@ -649,6 +860,7 @@ func newQuery(instant bool, cmd *kingpin.CmdClause) *query.Query {
 	cmd.Flag("store-config", "Execute the current query using a configured storage from a given Loki configuration file.").Default("").StringVar(&q.LocalConfig)
 	cmd.Flag("remote-schema", "Execute the current query using a remote schema retrieved from the configured -schema-store.").Default("false").BoolVar(&q.FetchSchemaFromStorage)
 	cmd.Flag("schema-store", "Store used for retrieving remote schema.").Default("").StringVar(&q.SchemaStore)
+	cmd.Flag("local-dir", "Execute the current query against a local directory of data objects, or a filesystem chunk store with a TSDB index, without any Loki services.").Default("").StringVar(&q.LocalDir)
 	cmd.Flag("colored-output", "Show output with colored labels").Default("false").BoolVar(&q.ColoredOutput)
 
 	return q
@@ -781,3 +993,71 @@ func newDetectedFieldsQuery(cmd *kingpin.CmdClause) *detected.FieldsQuery {
 
 	return q
 }
+
+func newDiffQuery(cmd *kingpin.CmdClause) *diff.Query {
+	// calculate query range from cli params
+	var from, to string
+	var since time.Duration
+
+	q := &diff.Query{}
+
+	// executed after all command flags are parsed
+	cmd.Action(func(_ *kingpin.ParseContext) error {
+		defaultEnd := time.Now()
+		defaultStart := defaultEnd.Add(-since)
+
+		q.Start = mustParse(from, defaultStart)
+		q.End = mustParse(to, defaultEnd)
+
+		if *diffOtherAddr == "" && *diffOtherOrgID == "" && q.Offset == 0 {
+			return errors.New("one of --other-addr, --other-org-id or --offset is required")
+		}
+		return nil
+	})
+
+	cmd.Arg("query", "eg '{foo=\"bar\",baz=~\".*blip\"} |~ \".*error.*\"'").Required().StringVar(&q.QueryString)
+	cmd.Flag("since", "Lookback window.").Default("1h").DurationVar(&since)
+	cmd.Flag("from", "Start looking for logs at this absolute time (inclusive)").StringVar(&from)
+	cmd.Flag("to", "Stop looking for logs at this absolute time (exclusive)").StringVar(&to)
+	cmd.Flag("limit", "Limit on number of entries of log queries, and on number of series of metric queries.").Default("1000").IntVar(&q.Limit)
+	cmd.Flag("forward", "Scan forwards through logs.").Default("false").BoolVar(&q.Forward)
+	cmd.Flag("step", "Query resolution step width, for metric queries. Evaluate the query at the specified step over the time range.").DurationVar(&q.Step)
+	cmd.Flag("interval", "Query interval, for log queries. Return entries at the specified interval, ignoring those between. **This parameter is experimental, please see Issue 1779**").DurationVar(&q.Interval)
+	cmd.Flag("offset", "Compare with the results of the query over the time range moved back by this duration.").DurationVar(&q.Offset)
+	cmd.Flag("tolerance", "Tolerance of the comparison of sample values.").Default("0.000001").Float64Var(&q.Options.Tolerance)
+	cmd.Flag("use-relative-error", "Use the relative error of sample values instead of the absolute error when comparing them with --tolerance.").Default("false").BoolVar(&q.Options.UseRelativeError)
+	cmd.Flag("skip-recent-samples", "Ignore samples and entries more recent than this duration before the end of the time range.").Default("0").DurationVar(&q.Options.SkipRecentSamples)
+
+	return q
+}
+
+func newExploreQuery(cmd *kingpin.CmdClause) *explore.Query {
+	// calculate query range from cli params
+	var from, to string
+	var since time.Duration
+
+	q := &explore.Query{}
+
+	// executed after all command flags are parsed
+	cmd.Action(func(_ *kingpin.ParseContext) error {
+		defaultEnd := time.Now()
+		defaultStart := defaultEnd.Add(-since)
+
+		q.Start = mustParse(from, defaultStart)
+		q.End = mustParse(to, defaultEnd)
+
+		return nil
+	})
+
+	cmd.Arg("query", "Initial query, eg '{foo=\"bar\",baz=~\".*blip\"} |~ \".*error.*\"'").StringVar(&q.QueryString)
+	cmd.Flag("since", "Lookback window.").Default("1h").DurationVar(&since)
+	cmd.Flag("from", "Start looking for logs at this absolute time (inclusive)").StringVar(&from)
+	cmd.Flag("to", "Stop looking for logs at this absolute time (exclusive)").StringVar(&to)
+	cmd.Flag("limit", "Number of log entries per page, and limit on number of series of metric queries.").Default("100").IntVar(&q.Limit)
+	cmd.Flag("forward", "Page through logs in forward order, oldest first.").Default("false").BoolVar(&q.Forward)
+	cmd.Flag("step", "Resolution of metric queries and of the volume histogram. Defaults to a hundredth of the time range.").DurationVar(&q.Step)
+	cmd.Flag("field-limit", "Limit on number of detected fields or values to return.").Default("100").IntVar(&q.FieldLimit)
+	cmd.Flag("line-limit", "Limit the number of lines each subquery is allowed to process when detecting fields.").Default("1000").IntVar(&q.LineLimit)
+
+	return q
+}
@@ -93,6 +93,7 @@ type Compactor struct {
 	running                   bool
 	wg                        sync.WaitGroup
 	indexCompactors           map[string]IndexCompactor
+	retentionAppliers         map[string]RetentionApplier
 	schemaConfig              config.SchemaConfig
 	limits                    Limits
 	JobQueue                  *jobqueue.Queue
@@ -142,11 +143,12 @@ func NewCompactor(
 	}
 
 	compactor := &Compactor{
-		cfg:             cfg,
-		ringPollPeriod:  5 * time.Second,
-		indexCompactors: map[string]IndexCompactor{},
-		schemaConfig:    schemaConfig,
-		limits:          limits,
+		cfg:               cfg,
+		ringPollPeriod:    5 * time.Second,
+		indexCompactors:   map[string]IndexCompactor{},
+		retentionAppliers: map[string]RetentionApplier{},
+		schemaConfig:      schemaConfig,
+		limits:            limits,
 	}
 
 	ringStore, err := kv.NewClient(
@@ -292,7 +294,7 @@ func (c *Compactor) init(
 	}
 
 	c.metrics = newMetrics(r)
-	c.tablesManager = newTablesManager(c.cfg, c.storeContainers, c.indexCompactors, c.schemaConfig, c.expirationChecker, c.metrics)
+	c.tablesManager = newTablesManager(c.cfg, c.storeContainers, c.indexCompactors, c.retentionAppliers, c.schemaConfig, c.expirationChecker, c.metrics)
 
 	if c.cfg.RetentionEnabled {
 		if err := c.deleteRequestsManager.Init(c.tablesManager, r); err != nil {
@@ -508,6 +510,22 @@ func (c *Compactor) RegisterIndexCompactor(indexType string, indexCompactor Inde
 	c.indexCompactors[indexType] = indexCompactor
 }
 
+// RetentionApplier applies retention and delete requests to data which isn't
+// referenced by the index tables.
+type RetentionApplier interface {
+	// ApplyRetention removes the data which expirationChecker reports as
+	// expired. It is called once per retention phase, after the index tables
+	// were processed.
+	ApplyRetention(ctx context.Context, expirationChecker retention.ExpirationChecker) error
+}
+
+// RegisterRetentionApplier registers a RetentionApplier which is called
+// during each retention phase. Registered appliers are called in the order of
+// their names.
+func (c *Compactor) RegisterRetentionApplier(name string, applier RetentionApplier) {
+	c.retentionAppliers[name] = applier
+}
+
 func (c *Compactor) TablesManager() TablesManager {
 	return c.tablesManager
 }
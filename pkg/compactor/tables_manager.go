@@ -3,7 +3,9 @@ package compactor
 import (
 	"context"
 	"fmt"
+	"maps"
 	"path/filepath"
+	"slices"
 	"sync"
 	"time"
 
@@ -27,6 +29,7 @@ type tablesManager struct {
 	expirationChecker retention.ExpirationChecker
 	storeContainers   map[config.DayTime]storeContainer
 	indexCompactors   map[string]IndexCompactor
+	retentionAppliers map[string]RetentionApplier
 	schemaConfig      config.SchemaConfig
 	metrics           *metrics
 
@@ -38,6 +41,7 @@ func newTablesManager(
 	cfg Config,
 	storeContainers map[config.DayTime]storeContainer,
 	indexCompactors map[string]IndexCompactor,
+	retentionAppliers map[string]RetentionApplier,
 	schemaConfig config.SchemaConfig,
 	expirationChecker retention.ExpirationChecker,
 	metrics *metrics,
@@ -46,6 +50,7 @@ func newTablesManager(
 		cfg:               cfg,
 		storeContainers:   storeContainers,
 		indexCompactors:   indexCompactors,
+		retentionAppliers: retentionAppliers,
 		schemaConfig:      schemaConfig,
 		expirationChecker: expirationChecker,
 		metrics:           metrics,
@@ -316,6 +321,16 @@ func (c *tablesManager) runCompaction(ctx context.Context, applyRetention bool)
 		return firstErr
 	}
 
+	if applyRetention {
+		for _, name := range slices.Sorted(maps.Keys(c.retentionAppliers)) {
+			level.Info(util_log.Logger).Log("msg", "applying retention", "applier", name)
+			if err := c.retentionAppliers[name].ApplyRetention(ctx, c.expirationChecker); err != nil {
+				return fmt.Errorf("applying retention to %s: %w", name, err)
+			}
+			level.Info(util_log.Logger).Log("msg", "finished applying retention", "applier", name)
+		}
+	}
+
 	return ctx.Err()
 }
 
@@ -16,6 +16,7 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/grafana/loki/v3/pkg/compactor/deletion"
+	"github.com/grafana/loki/v3/pkg/compactor/retention"
 	"github.com/grafana/loki/v3/pkg/storage/chunk/client"
 	"github.com/grafana/loki/v3/pkg/storage/chunk/client/local"
 	"github.com/grafana/loki/v3/pkg/storage/config"
@@ -509,3 +510,34 @@ func (d *dummyStorageUpdatesIterator) Err() error {
 func (d *dummyStorageUpdatesIterator) ForEachSeries(_ func(_ string, _ []string, _ []string, _ []deletion.Chunk) error) error {
 	return nil
 }
+
+type mockRetentionApplier struct {
+	calls int
+	err   error
+}
+
+func (m *mockRetentionApplier) ApplyRetention(_ context.Context, _ retention.ExpirationChecker) error {
+	m.calls++
+	return m.err
+}
+
+func TestTablesManager_RetentionAppliers(t *testing.T) {
+	var (
+		ok     = &mockRetentionApplier{}
+		failed = &mockRetentionApplier{err: errors.New("failed")}
+
+		appliers = map[string]RetentionApplier{"ok": ok}
+		checker  = retention.NeverExpiringExpirationChecker(nil)
+	)
+	tm := newTablesManager(Config{MaxCompactionParallelism: 1}, nil, nil, appliers, config.SchemaConfig{}, checker, newMetrics(nil))
+
+	require.NoError(t, tm.runCompaction(context.Background(), false))
+	require.Equal(t, 0, ok.calls, "appliers must only be called when applying retention")
+
+	require.NoError(t, tm.runCompaction(context.Background(), true))
+	require.Equal(t, 1, ok.calls)
+
+	appliers["failed"] = failed
+	require.Error(t, tm.runCompaction(context.Background(), true))
+	require.Equal(t, 1, failed.calls)
+}
@@ -0,0 +1,348 @@
+// Package compactor merges small data objects of a tenant into larger ones.
+//
+// Low-volume tenants produce many small data objects per metastore window,
+// which makes listing objects and planning queries slow. The compactor
+// periodically merges the small objects of each window into objects close to
+// the target object size, replaces them in the metastore object of the window
+// in a single update, and deletes them once no query can still be reading
+// them.
+//
+// For tenants with indexes built by the index builder, the index objects
+// referencing replaced data objects are rebuilt and replaced along with them.
+package compactor
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/grafana/dskit/flagext"
+	"github.com/grafana/dskit/services"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/thanos-io/objstore"
+
+	"github.com/grafana/loki/v3/pkg/compactor/retention"
+	"github.com/grafana/loki/v3/pkg/dataobj/consumer/logsobj"
+	"github.com/grafana/loki/v3/pkg/dataobj/index"
+	"github.com/grafana/loki/v3/pkg/dataobj/metastore"
+	"github.com/grafana/loki/v3/pkg/dataobj/uploader"
+)
+
+type Config struct {
+	logsobj.BuilderConfig `yaml:",inline"`
+	UploaderConfig        uploader.Config `yaml:"uploader"`
+
+	CompactionInterval time.Duration `yaml:"compaction_interval"`
+	CompactionDelay    time.Duration `yaml:"compaction_delay"`
+	SmallObjectSize    flagext.Bytes `yaml:"small_object_size"`
+	MinObjects         int           `yaml:"min_objects"`
+	DeleteDelay        time.Duration `yaml:"delete_delay"`
+	RetentionEnabled   bool          `yaml:"retention_enabled"`
+}
+
+func (cfg *Config) RegisterFlags(f *flag.FlagSet) {
+	cfg.RegisterFlagsWithPrefix("dataobj-compactor.", f)
+}
+
+func (cfg *Config) RegisterFlagsWithPrefix(prefix string, f *flag.FlagSet) {
+	cfg.BuilderConfig.RegisterFlagsWithPrefix(prefix, f)
+	cfg.UploaderConfig.RegisterFlagsWithPrefix(prefix, f)
+
+	_ = cfg.SmallObjectSize.Set("64MB")
+
+	f.DurationVar(&cfg.CompactionInterval, prefix+"compaction-interval", time.Hour, "Experimental: How often to look for small data objects to compact.")
+	f.DurationVar(&cfg.CompactionDelay, prefix+"compaction-delay", 2*time.Hour, "Experimental: How long to wait after the end of a metastore window before compacting its data objects or applying retention to them. Must be longer than the idle flush timeout of the consumer, so that no more objects are written to the window.")
+	f.Var(&cfg.SmallObjectSize, prefix+"small-object-size", "Experimental: Data objects smaller than this size are merged with other small objects of the same metastore window.")
+	f.IntVar(&cfg.MinObjects, prefix+"min-objects", 4, "Experimental: The minimum number of small data objects in a metastore window required to compact them.")
+	f.DurationVar(&cfg.DeleteDelay, prefix+"delete-delay", 2*time.Hour, "Experimental: How long to wait after compacting or rewriting data objects before deleting them, so that queries which started before the metastore was updated can still read them.")
+	f.BoolVar(&cfg.RetentionEnabled, prefix+"retention-enabled", false, "Experimental: Apply retention and delete requests to data objects during the retention phase of the compactor. Requires the compactor to run in the same process, with retention enabled.")
+}
+
+func (cfg *Config) Validate() error {
+	var errs []error
+	if err := cfg.BuilderConfig.Validate(); err != nil {
+		errs = append(errs, err)
+	}
+	if err := cfg.UploaderConfig.Validate(); err != nil {
+		errs = append(errs, err)
+	}
+	if cfg.CompactionInterval <= 0 {
+		errs = append(errs, errors.New("CompactionInterval must be greater than 0"))
+	}
+	if cfg.SmallObjectSize <= 0 {
+		errs = append(errs, errors.New("SmallObjectSize must be greater than 0"))
+	}
+	if cfg.MinObjects < 2 {
+		errs = append(errs, errors.New("MinObjects must be greater than 1"))
+	}
+	return errors.Join(errs...)
+}
+
+// Compactor periodically merges the small data objects of all tenants in a
+// bucket. Only one Compactor may run against a bucket at a time.
+type Compactor struct {
+	services.Service
+
+	cfg      Config
+	mCfg     metastore.Config
+	indexCfg index.Config
+	limits   retention.Limits
+	bucket   objstore.Bucket
+	builder  *logsobj.Builder
+	buf      *bytes.Buffer
+	metrics  *compactorMetrics
+	logger   log.Logger
+
+	// indexCalculator builds index objects for tenants with indexes. It's
+	// created on first use.
+	indexCalculator *index.Calculator
+
+	// mtx serializes compactions and the application of retention, which
+	// both replace data objects of the same windows.
+	mtx sync.Mutex
+	now func() time.Time
+}
+
+// New creates a new Compactor which compacts the data objects in bucket.
+// indexCfg is the config of the index builder, which determines the tenants
+// whose data objects are indexed, and limits holds the retention rules of the
+// tenants.
+func New(cfg Config, mCfg metastore.Config, indexCfg index.Config, limits retention.Limits, bucket objstore.Bucket, reg prometheus.Registerer, logger log.Logger) (*Compactor, error) {
+	builder, err := logsobj.NewBuilder(cfg.BuilderConfig)
+	if err != nil {
+		return nil, fmt.Errorf("creating builder: %w", err)
+	}
+	if err := builder.RegisterMetrics(reg); err != nil {
+		return nil, fmt.Errorf("registering builder metrics: %w", err)
+	}
+
+	metrics := newCompactorMetrics()
+	if err := metrics.register(reg); err != nil {
+		return nil, fmt.Errorf("registering compactor metrics: %w", err)
+	}
+
+	c := &Compactor{
+		cfg:      cfg,
+		mCfg:     mCfg,
+		indexCfg: indexCfg,
+		limits:   limits,
+		bucket:   bucket,
+		builder:  builder,
+		buf:      bytes.NewBuffer(make([]byte, 0, cfg.TargetObjectSize)),
+		metrics:  metrics,
+		logger:   logger,
+
+		now: time.Now,
+	}
+	c.Service = services.NewTimerService(cfg.CompactionInterval, nil, c.iteration, nil)
+	return c, nil
+}
+
+func (c *Compactor) iteration(ctx context.Context) error {
+	if err := c.RunOnce(ctx); err != nil {
+		level.Error(c.logger).Log("msg", "failed to compact data objects", "err", err)
+	}
+	return nil
+}
+
+// RunOnce deletes the compacted data objects whose delete delay has passed,
+// and then compacts the small data objects of the windows of all tenants
+// which are old enough.
+func (c *Compactor) RunOnce(ctx context.Context) error {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	tenants, err := c.listTenants(ctx)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, tenant := range tenants {
+		if err := c.compactTenant(ctx, tenant); err != nil {
+			errs = append(errs, fmt.Errorf("compacting tenant %s: %w", tenant, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// listTenants returns the IDs of the tenants with objects in the bucket.
+func (c *Compactor) listTenants(ctx context.Context) ([]string, error) {
+	var tenants []string
+	err := c.bucket.Iter(ctx, "", func(name string) error {
+		if tenant, ok := strings.CutPrefix(strings.TrimSuffix(name, "/"), "tenant-"); ok {
+			tenants = append(tenants, tenant)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("listing tenants: %w", err)
+	}
+	return tenants, nil
+}
+
+func (c *Compactor) compactTenant(ctx context.Context, tenant string) error {
+	logger := log.With(c.logger, "tenant", tenant)
+	now := c.now()
+
+	if err := c.deleteExpired(ctx, tenant, now); err != nil {
+		return err
+	}
+	if err := c.resumeReplacements(ctx, tenant); err != nil {
+		return err
+	}
+
+	indexed, err := c.hasIndexes(ctx, tenant)
+	if err != nil {
+		return err
+	}
+
+	windows, err := metastore.Windows(ctx, c.bucket, tenant)
+	if err != nil {
+		return err
+	}
+
+	for _, window := range windows {
+		if !c.windowClosed(window, now) {
+			// Windows are sorted, so no later window is old enough either.
+			break
+		}
+		if err := c.compactWindow(ctx, logger, tenant, window, indexed); err != nil {
+			c.metrics.compactionsTotal.WithLabelValues(statusFailure).Inc()
+			return fmt.Errorf("compacting window %s: %w", window.Format(time.RFC3339), err)
+		}
+	}
+	return nil
+}
+
+// windowClosed returns true if no more data objects are written to the
+// given window at now. Until then, the metastore object of the window must
+// not be emptied, as deleting it could lose concurrently added entries.
+func (c *Compactor) windowClosed(window, now time.Time) bool {
+	return !window.Add(metastore.WindowSize + c.cfg.CompactionDelay).After(now)
+}
+
+// compactWindow merges the small data objects of the given window of a
+// tenant, if there are enough of them. If indexed is true, the index
+// objects of the tenant are rewritten too.
+func (c *Compactor) compactWindow(ctx context.Context, logger log.Logger, tenant string, window time.Time, indexed bool) error {
+	candidates, err := c.findCandidates(ctx, tenant, window)
+	if err != nil {
+		return err
+	} else if len(candidates) < c.cfg.MinObjects {
+		return nil
+	}
+
+	logger = log.With(logger, "window", window.Format(time.RFC3339))
+	level.Info(logger).Log("msg", "compacting data objects", "objects", len(candidates))
+	start := c.now()
+
+	merged, err := c.merge(ctx, uploader.New(c.cfg.UploaderConfig, c.bucket, tenant, logger), candidates, nil)
+	if err != nil {
+		c.deleteUnreferenced(ctx, candidates, merged)
+		return err
+	}
+
+	// Merging objects which were already merged may reproduce one of them,
+	// which must then be kept.
+	var r replacement
+	for _, candidate := range candidates {
+		if !slices.ContainsFunc(merged, func(e metastore.Entry) bool { return e.Path == candidate.Path }) {
+			r.Removed = append(r.Removed, candidate)
+		}
+	}
+	for _, entry := range merged {
+		if !slices.ContainsFunc(candidates, func(e metastore.Entry) bool { return e.Path == entry.Path }) {
+			r.Added = append(r.Added, entry)
+		}
+	}
+
+	if indexed {
+		err := c.rewriteIndexes(ctx, logger, tenant, &r)
+		if errors.Is(err, errNotIndexed) {
+			level.Info(logger).Log("msg", "skipping compaction of data objects which aren't indexed yet")
+			c.metrics.skippedUnindexedTotal.WithLabelValues(phaseCompaction).Inc()
+			c.deleteUnreferenced(ctx, candidates, merged)
+			return nil
+		} else if err != nil {
+			c.deleteUnreferenced(ctx, candidates, merged)
+			return fmt.Errorf("rewriting indexes: %w", err)
+		}
+	}
+
+	if err := c.replace(ctx, tenant, r); err != nil {
+		return fmt.Errorf("replacing data objects: %w", err)
+	}
+
+	c.metrics.compactionsTotal.WithLabelValues(statusSuccess).Inc()
+	c.metrics.compactedObjects.Add(float64(len(r.Removed)))
+	level.Info(logger).Log("msg", "compacted data objects", "objects", len(candidates), "merged_objects", len(merged), "duration", c.now().Sub(start))
+	return nil
+}
+
+// findCandidates returns the small data objects referenced by the metastore
+// object of the given window whose logs are entirely contained in the window,
+// sorted by time. Objects spanning multiple windows are referenced by the
+// metastore objects of all of them and can't be replaced in a single update.
+func (c *Compactor) findCandidates(ctx context.Context, tenant string, window time.Time) ([]metastore.Entry, error) {
+	entries, err := metastore.ReadWindow(ctx, c.bucket, tenant, window)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		candidates []metastore.Entry
+		seen       = make(map[string]struct{}, len(entries))
+		windowEnd  = window.Add(metastore.WindowSize)
+	)
+	for _, entry := range entries {
+		if entry.Start.Before(window) || !entry.End.Before(windowEnd) {
+			continue
+		}
+		if _, ok := seen[entry.Path]; ok {
+			continue
+		}
+		seen[entry.Path] = struct{}{}
+
+		attrs, err := c.bucket.Attributes(ctx, entry.Path)
+		if c.bucket.IsObjNotFoundErr(err) {
+			continue
+		} else if err != nil {
+			return nil, fmt.Errorf("getting attributes of %s: %w", entry.Path, err)
+		}
+		if attrs.Size >= int64(c.cfg.SmallObjectSize) {
+			continue
+		}
+		candidates = append(candidates, entry)
+	}
+
+	slices.SortFunc(candidates, func(a, b metastore.Entry) int {
+		if n := a.Start.Compare(b.Start); n != 0 {
+			return n
+		}
+		return strings.Compare(a.Path, b.Path)
+	})
+	return candidates, nil
+}
+
+// deleteUnreferenced deletes the merged objects of a failed compaction, which
+// aren't referenced by the metastore. Failures are only logged, as the objects
+// are never read.
+func (c *Compactor) deleteUnreferenced(ctx context.Context, candidates, merged []metastore.Entry) {
+	for _, entry := range merged {
+		if slices.ContainsFunc(candidates, func(e metastore.Entry) bool { return e.Path == entry.Path }) {
+			continue
+		}
+		if err := c.bucket.Delete(ctx, entry.Path); err != nil {
+			level.Warn(c.logger).Log("msg", "failed to delete unreferenced data object", "path", entry.Path, "err", err)
+		}
+	}
+}
@@ -0,0 +1,341 @@
+package compactor
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/require"
+	"github.com/thanos-io/objstore"
+
+	"github.com/grafana/loki/v3/pkg/dataobj"
+	"github.com/grafana/loki/v3/pkg/dataobj/consumer/logsobj"
+	"github.com/grafana/loki/v3/pkg/dataobj/index"
+	"github.com/grafana/loki/v3/pkg/dataobj/index/indexobj"
+	"github.com/grafana/loki/v3/pkg/dataobj/metastore"
+	"github.com/grafana/loki/v3/pkg/dataobj/uploader"
+	"github.com/grafana/loki/v3/pkg/logproto"
+	"github.com/grafana/loki/v3/pkg/validation"
+)
+
+var testConfig = Config{
+	BuilderConfig: logsobj.BuilderConfig{
+		TargetPageSize:    2048,
+		TargetObjectSize:  1 << 22, // 4 MiB
+		TargetSectionSize: 1 << 21, // 2 MiB
+
+		BufferSize: 2048 * 8,
+
+		SectionStripeMergeLimit: 2,
+	},
+	UploaderConfig: uploader.Config{SHAPrefixSize: 2},
+
+	CompactionInterval: time.Hour,
+	CompactionDelay:    time.Hour,
+	SmallObjectSize:    1 << 20, // 1 MiB
+	MinObjects:         2,
+	DeleteDelay:        time.Hour,
+}
+
+var testIndexConfig = index.Config{
+	BuilderConfig: indexobj.BuilderConfig{
+		TargetPageSize:    2048,
+		TargetObjectSize:  1 << 22, // 4 MiB
+		TargetSectionSize: 1 << 21, // 2 MiB
+
+		BufferSize: 2048 * 8,
+
+		SectionStripeMergeLimit: 2,
+	},
+	IndexStoragePrefix: "index/v0/",
+}
+
+func TestCompactor(t *testing.T) {
+	var (
+		ctx    = context.Background()
+		tenant = "test"
+		bucket = objstore.NewInMemBucket()
+		window = time.Unix(0, 0).Add(100 * metastore.WindowSize).UTC()
+	)
+
+	// Write objects with interleaved streams and timestamps, so that merging
+	// them requires sorting. The last object spans two windows and must not
+	// be compacted.
+	var originals []string
+	for i := range 4 {
+		var streams []logproto.Stream
+		for _, app := range []string{"foo", "bar"} {
+			stream := logproto.Stream{Labels: fmt.Sprintf(`{app=%q}`, app)}
+			for j := range 10 {
+				stream.Entries = append(stream.Entries, logproto.Entry{
+					Timestamp: window.Add(time.Duration(j*4+i+1) * time.Minute),
+					Line:      fmt.Sprintf("%s line %d of object %d", app, j, i),
+				})
+			}
+			streams = append(streams, stream)
+		}
+		originals = append(originals, writeObject(t, bucket, tenant, streams...))
+	}
+	spanning := writeObject(t, bucket, tenant, logproto.Stream{
+		Labels: `{app="foo"}`,
+		Entries: []logproto.Entry{
+			{Timestamp: window.Add(metastore.WindowSize - time.Minute), Line: "end of window"},
+			{Timestamp: window.Add(metastore.WindowSize + time.Minute), Line: "start of next window"},
+		},
+	})
+
+	c, err := New(testConfig, metastore.Config{}, testIndexConfig, testLimits(t, validation.Limits{}), bucket, prometheus.NewPedanticRegistry(), log.NewNopLogger())
+	require.NoError(t, err)
+
+	t.Run("window not old enough", func(t *testing.T) {
+		c.now = func() time.Time { return window.Add(metastore.WindowSize) }
+		require.NoError(t, c.RunOnce(ctx))
+		require.Len(t, readEntries(t, bucket, tenant, window), 5)
+	})
+
+	c.now = func() time.Time { return window.Add(metastore.WindowSize + 2*time.Hour) }
+	require.NoError(t, c.RunOnce(ctx))
+
+	entries := readEntries(t, bucket, tenant, window)
+	require.Len(t, entries, 2)
+	require.Contains(t, entries, spanning)
+
+	merged := entries[slices.IndexFunc(entries, func(path string) bool { return path != spanning })]
+	obj, err := dataobj.FromBucket(ctx, bucket, merged)
+	require.NoError(t, err)
+
+	var (
+		count      int
+		lastLabels string
+		lastTs     time.Time
+	)
+	err = forEachStream(ctx, obj, func(stream logproto.Stream) error {
+		count++
+		for _, entry := range stream.Entries {
+			if stream.Labels == lastLabels {
+				require.False(t, entry.Timestamp.Before(lastTs), "entries of a stream must be sorted")
+			}
+			lastLabels, lastTs = stream.Labels, entry.Timestamp
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, 80, count)
+
+	t.Run("originals kept until delete delay", func(t *testing.T) {
+		for _, path := range originals {
+			exists, err := bucket.Exists(ctx, path)
+			require.NoError(t, err)
+			require.True(t, exists)
+		}
+	})
+
+	t.Run("originals deleted after delete delay", func(t *testing.T) {
+		c.now = func() time.Time { return window.Add(metastore.WindowSize + 4*time.Hour) }
+		require.NoError(t, c.RunOnce(ctx))
+
+		for _, path := range originals {
+			exists, err := bucket.Exists(ctx, path)
+			require.NoError(t, err)
+			require.False(t, exists)
+		}
+		for _, path := range []string{merged, spanning} {
+			exists, err := bucket.Exists(ctx, path)
+			require.NoError(t, err)
+			require.True(t, exists)
+		}
+		require.Len(t, readEntries(t, bucket, tenant, window), 2)
+	})
+}
+
+func TestCompactor_RewritesIndexes(t *testing.T) {
+	var (
+		ctx         = context.Background()
+		tenant      = "test"
+		bucket      = objstore.NewInMemBucket()
+		indexBucket = objstore.NewPrefixedBucket(bucket, testIndexConfig.IndexStoragePrefix)
+		window      = time.Unix(0, 0).Add(100 * metastore.WindowSize).UTC()
+	)
+
+	var small []string
+	for i := range 3 {
+		small = append(small, writeObject(t, bucket, tenant, logproto.Stream{
+			Labels:  `{app="foo"}`,
+			Entries: []logproto.Entry{{Timestamp: window.Add(time.Duration(i+1) * time.Minute), Line: fmt.Sprintf("line %d", i)}},
+		}))
+	}
+	spanning := writeObject(t, bucket, tenant, logproto.Stream{
+		Labels: `{app="foo"}`,
+		Entries: []logproto.Entry{
+			{Timestamp: window.Add(metastore.WindowSize - time.Minute), Line: "end of window"},
+			{Timestamp: window.Add(metastore.WindowSize + time.Minute), Line: "start of next window"},
+		},
+	})
+
+	// The first index only references compacted objects, while the second
+	// one also references an object which isn't compacted.
+	var (
+		first  = writeIndex(t, bucket, tenant, small[0], small[1])
+		second = writeIndex(t, bucket, tenant, small[2], spanning)
+	)
+
+	c, err := New(testConfig, metastore.Config{}, testIndexConfig, testLimits(t, validation.Limits{}), bucket, prometheus.NewPedanticRegistry(), log.NewNopLogger())
+	require.NoError(t, err)
+	c.now = func() time.Time { return window.Add(metastore.WindowSize + 2*time.Hour) }
+	require.NoError(t, c.RunOnce(ctx))
+
+	entries := readEntries(t, bucket, tenant, window)
+	require.Len(t, entries, 2)
+	require.Contains(t, entries, spanning)
+	merged := entries[slices.IndexFunc(entries, func(path string) bool { return path != spanning })]
+
+	// Every data object is referenced by exactly one index object.
+	indexes := readEntries(t, indexBucket, tenant, window)
+	require.Len(t, indexes, 2)
+	require.NotContains(t, indexes, first)
+	require.NotContains(t, indexes, second)
+
+	var indexed []string
+	for _, path := range indexes {
+		paths, err := indexedObjects(ctx, indexBucket, path)
+		require.NoError(t, err)
+		indexed = append(indexed, paths...)
+	}
+	require.ElementsMatch(t, []string{merged, spanning}, indexed)
+
+	// The second window only references the rebuilt index of the spanning
+	// object.
+	nextIndexes := readEntries(t, indexBucket, tenant, window.Add(metastore.WindowSize))
+	require.Len(t, nextIndexes, 1)
+	require.Contains(t, indexes, nextIndexes[0])
+
+	t.Run("replaced indexes deleted after delete delay", func(t *testing.T) {
+		for _, path := range []string{first, second} {
+			exists, err := indexBucket.Exists(ctx, path)
+			require.NoError(t, err)
+			require.True(t, exists)
+		}
+
+		c.now = func() time.Time { return window.Add(metastore.WindowSize + 4*time.Hour) }
+		require.NoError(t, c.RunOnce(ctx))
+
+		for _, path := range []string{first, second} {
+			exists, err := indexBucket.Exists(ctx, path)
+			require.NoError(t, err)
+			require.False(t, exists)
+		}
+	})
+}
+
+func TestCompactor_SkipsUnindexedObjects(t *testing.T) {
+	var (
+		ctx    = context.Background()
+		bucket = objstore.NewInMemBucket()
+		window = time.Unix(0, 0).Add(100 * metastore.WindowSize).UTC()
+	)
+
+	// Index building is enabled for the tenant, but the index builder
+	// didn't index its objects yet.
+	var paths []string
+	for i := range 2 {
+		paths = append(paths, writeObject(t, bucket, "enabled", logproto.Stream{
+			Labels:  `{app="foo"}`,
+			Entries: []logproto.Entry{{Timestamp: window.Add(time.Duration(i+1) * time.Minute), Line: "line"}},
+		}))
+	}
+
+	indexCfg := testIndexConfig
+	indexCfg.EnabledTenantIDs = []string{"enabled"}
+
+	c, err := New(testConfig, metastore.Config{}, indexCfg, testLimits(t, validation.Limits{}), bucket, prometheus.NewPedanticRegistry(), log.NewNopLogger())
+	require.NoError(t, err)
+	c.now = func() time.Time { return window.Add(metastore.WindowSize + 2*time.Hour) }
+	require.NoError(t, c.RunOnce(ctx))
+
+	require.ElementsMatch(t, paths, readEntries(t, bucket, "enabled", window))
+
+	// Only the objects of the tenant are left in the bucket, apart from
+	// the metastore object.
+	var objects []string
+	require.NoError(t, bucket.Iter(ctx, "", func(name string) error {
+		objects = append(objects, name)
+		return nil
+	}, objstore.WithRecursiveIter()))
+	require.Len(t, objects, len(paths)+1)
+}
+
+// writeObject writes a data object with the given streams and adds it to the
+// metastore, like the consumer does.
+func writeObject(t *testing.T, bucket objstore.Bucket, tenant string, streams ...logproto.Stream) string {
+	t.Helper()
+
+	builder, err := logsobj.NewBuilder(testConfig.BuilderConfig)
+	require.NoError(t, err)
+	for _, stream := range streams {
+		require.NoError(t, builder.Append(stream))
+	}
+
+	var buf bytes.Buffer
+	stats, err := builder.Flush(&buf)
+	require.NoError(t, err)
+
+	path, err := uploader.New(testConfig.UploaderConfig, bucket, tenant, log.NewNopLogger()).Upload(context.Background(), &buf)
+	require.NoError(t, err)
+
+	updater := metastore.NewUpdater(metastore.UpdaterConfig{}, bucket, tenant, log.NewNopLogger())
+	require.NoError(t, updater.Update(context.Background(), path, stats.MinTimestamp, stats.MaxTimestamp))
+	return path
+}
+
+// writeIndex builds an index object for the data objects at the given
+// paths and adds it to the index metastore, like the index builder does.
+func writeIndex(t *testing.T, bucket objstore.Bucket, tenant string, paths ...string) string {
+	t.Helper()
+
+	builder, err := indexobj.NewBuilder(testIndexConfig.BuilderConfig)
+	require.NoError(t, err)
+	calculator := index.NewCalculator(builder)
+	for _, path := range paths {
+		obj, err := dataobj.FromBucket(context.Background(), bucket, path)
+		require.NoError(t, err)
+		require.NoError(t, calculator.Calculate(context.Background(), log.NewNopLogger(), obj, path))
+	}
+
+	var buf bytes.Buffer
+	stats, err := calculator.Flush(&buf)
+	require.NoError(t, err)
+
+	indexBucket := objstore.NewPrefixedBucket(bucket, testIndexConfig.IndexStoragePrefix)
+	key := index.ObjectKey(tenant, &buf)
+	require.NoError(t, indexBucket.Upload(context.Background(), key, &buf))
+
+	updater := metastore.NewUpdater(metastore.UpdaterConfig{}, indexBucket, tenant, log.NewNopLogger())
+	require.NoError(t, updater.Update(context.Background(), key, stats.MinTimestamp, stats.MaxTimestamp))
+	return key
+}
+
+func testLimits(t *testing.T, limits validation.Limits) *validation.Overrides {
+	t.Helper()
+
+	overrides, err := validation.NewOverrides(limits, nil)
+	require.NoError(t, err)
+	return overrides
+}
+
+func readEntries(t *testing.T, bucket objstore.Bucket, tenant string, window time.Time) []string {
+	t.Helper()
+
+	entries, err := metastore.ReadWindow(context.Background(), bucket, tenant, window)
+	require.NoError(t, err)
+
+	paths := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		paths = append(paths, entry.Path)
+	}
+	return paths
+}
@@ -0,0 +1,106 @@
+package compactor
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-kit/log/level"
+)
+
+// Compacted data objects are deleted in two steps: after the metastore
+// stopped referencing them, a deletion marker listing their paths is
+// uploaded, and once the deadline encoded in the name of the marker has
+// passed, the objects and the marker are deleted. Storing markers in the
+// bucket ensures objects are deleted even if the compactor restarts in
+// between.
+
+// deletesDir returns the directory holding the deletion markers of a tenant.
+func deletesDir(tenant string) string {
+	return fmt.Sprintf("tenant-%s/compactor/deletes/", tenant)
+}
+
+// markForDeletion uploads a deletion marker for the given paths, which are
+// deleted by [Compactor.deleteExpired] after the deadline.
+func (c *Compactor) markForDeletion(ctx context.Context, tenant string, paths []string, deadline time.Time) error {
+	if len(paths) == 0 {
+		return nil
+	}
+
+	content := []byte(strings.Join(paths, "\n"))
+	sum := sha256.Sum224(content)
+	name := fmt.Sprintf("%s%d-%s", deletesDir(tenant), deadline.Unix(), hex.EncodeToString(sum[:8]))
+
+	if err := c.bucket.Upload(ctx, name, bytes.NewReader(content)); err != nil {
+		return fmt.Errorf("uploading deletion marker: %w", err)
+	}
+	return nil
+}
+
+// deleteExpired deletes the objects of the deletion markers of a tenant whose
+// deadline is before now, along with the markers.
+func (c *Compactor) deleteExpired(ctx context.Context, tenant string, now time.Time) error {
+	dir := deletesDir(tenant)
+
+	var expired []string
+	err := c.bucket.Iter(ctx, dir, func(name string) error {
+		deadline, _, ok := strings.Cut(strings.TrimPrefix(name, dir), "-")
+		if !ok {
+			return nil
+		}
+		unix, err := strconv.ParseInt(deadline, 10, 64)
+		if err != nil {
+			return nil
+		}
+		if time.Unix(unix, 0).Before(now) {
+			expired = append(expired, name)
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("listing deletion markers: %w", err)
+	}
+
+	for _, marker := range expired {
+		if err := c.deleteMarked(ctx, marker); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// deleteMarked deletes the objects listed in the given deletion marker, and
+// then the marker itself.
+func (c *Compactor) deleteMarked(ctx context.Context, marker string) error {
+	rc, err := c.bucket.Get(ctx, marker)
+	if err != nil {
+		return fmt.Errorf("getting deletion marker %s: %w", marker, err)
+	}
+	content, err := io.ReadAll(rc)
+	_ = rc.Close()
+	if err != nil {
+		return fmt.Errorf("reading deletion marker %s: %w", marker, err)
+	}
+
+	for _, path := range strings.Split(string(content), "\n") {
+		if path == "" {
+			continue
+		}
+		if err := c.bucket.Delete(ctx, path); err != nil && !c.bucket.IsObjNotFoundErr(err) {
+			return fmt.Errorf("deleting %s: %w", path, err)
+		}
+		c.metrics.deletedObjects.Inc()
+	}
+
+	if err := c.bucket.Delete(ctx, marker); err != nil {
+		return fmt.Errorf("deleting deletion marker %s: %w", marker, err)
+	}
+	level.Info(c.logger).Log("msg", "deleted compacted data objects", "marker", marker)
+	return nil
+}
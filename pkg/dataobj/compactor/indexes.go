@@ -0,0 +1,206 @@
+package compactor
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/thanos-io/objstore"
+
+	"github.com/grafana/loki/v3/pkg/dataobj"
+	"github.com/grafana/loki/v3/pkg/dataobj/index"
+	"github.com/grafana/loki/v3/pkg/dataobj/index/indexobj"
+	"github.com/grafana/loki/v3/pkg/dataobj/metastore"
+	"github.com/grafana/loki/v3/pkg/dataobj/sections/pointers"
+)
+
+// Index objects built by the index builder reference data objects by path.
+// When data objects of a tenant with indexes are replaced, the index objects
+// referencing them are rebuilt without them, and their replacements are
+// indexed in a new index object. The index objects are replaced along with
+// the data objects, so that indexes never reference deleted data objects
+// and queries using the indexes find the replacements.
+
+// errNotIndexed is returned when a data object to replace isn't indexed yet.
+// The index builder could still index it after it was replaced, so the
+// object must be replaced in a later run instead.
+var errNotIndexed = errors.New("data object not indexed yet")
+
+// indexBucket returns the bucket holding the index objects and their
+// metastore objects.
+func (c *Compactor) indexBucket() objstore.Bucket {
+	return objstore.NewPrefixedBucket(c.bucket, c.indexCfg.IndexStoragePrefix)
+}
+
+// hasIndexes returns true if the data objects of tenant are indexed by the
+// index builder, either because index building is enabled for the tenant or
+// because indexes were built for it before.
+func (c *Compactor) hasIndexes(ctx context.Context, tenant string) (bool, error) {
+	if slices.Contains(c.indexCfg.EnabledTenantIDs, tenant) {
+		return true, nil
+	}
+
+	windows, err := metastore.Windows(ctx, c.indexBucket(), tenant)
+	if err != nil {
+		return false, fmt.Errorf("listing index metastore objects: %w", err)
+	}
+	return len(windows) > 0, nil
+}
+
+// rewriteIndexes sets the index objects of r for replacing the removed data
+// objects of r with the added ones: the index objects referencing removed
+// data objects are rebuilt without them, and the added data objects are
+// indexed in a new index object. The new index objects are uploaded, but
+// not referenced by the index metastore until r is applied.
+//
+// rewriteIndexes returns [errNotIndexed] if a removed data object isn't
+// referenced by any index object.
+func (c *Compactor) rewriteIndexes(ctx context.Context, logger log.Logger, tenant string, r *replacement) (err error) {
+	defer func() {
+		if err != nil {
+			c.deleteUnreferencedIndexes(ctx, r.AddedIndexes)
+			r.RemovedIndexes, r.AddedIndexes = nil, nil
+		}
+	}()
+
+	removed := make(map[string]struct{}, len(r.Removed))
+	unindexed := make(map[string]struct{}, len(r.Removed))
+	for _, entry := range r.Removed {
+		removed[entry.Path] = struct{}{}
+		unindexed[entry.Path] = struct{}{}
+	}
+
+	// Index objects referencing a data object are referenced by the index
+	// metastore objects of all windows the data object overlaps.
+	var (
+		indexBucket = c.indexBucket()
+		seen        = make(map[string]struct{})
+	)
+	for _, window := range overlappedWindows(r.Removed) {
+		entries, err := metastore.ReadWindow(ctx, indexBucket, tenant, window)
+		if err != nil {
+			return fmt.Errorf("reading index metastore: %w", err)
+		}
+
+		for _, entry := range entries {
+			if _, ok := seen[entry.Path]; ok {
+				continue
+			}
+			seen[entry.Path] = struct{}{}
+
+			paths, err := indexedObjects(ctx, indexBucket, entry.Path)
+			if err != nil {
+				return err
+			}
+
+			var kept []string
+			for _, path := range paths {
+				if _, ok := removed[path]; ok {
+					delete(unindexed, path)
+				} else {
+					kept = append(kept, path)
+				}
+			}
+			if len(kept) == len(paths) {
+				continue
+			}
+
+			r.RemovedIndexes = append(r.RemovedIndexes, entry)
+			if len(kept) == 0 {
+				continue
+			}
+			rebuilt, err := c.buildIndex(ctx, logger, tenant, kept)
+			if err != nil {
+				return fmt.Errorf("rebuilding index %s: %w", entry.Path, err)
+			}
+			r.AddedIndexes = append(r.AddedIndexes, rebuilt)
+		}
+	}
+	if len(unindexed) > 0 {
+		return errNotIndexed
+	}
+
+	if len(r.Added) > 0 {
+		added := make([]string, 0, len(r.Added))
+		for _, entry := range r.Added {
+			added = append(added, entry.Path)
+		}
+		built, err := c.buildIndex(ctx, logger, tenant, added)
+		if err != nil {
+			return fmt.Errorf("indexing replacements: %w", err)
+		}
+		r.AddedIndexes = append(r.AddedIndexes, built)
+	}
+	return nil
+}
+
+// indexedObjects returns the paths of the data objects referenced by the
+// index object at path.
+func indexedObjects(ctx context.Context, indexBucket objstore.Bucket, path string) ([]string, error) {
+	obj, err := dataobj.FromBucket(ctx, indexBucket, path)
+	if err != nil {
+		return nil, fmt.Errorf("opening index %s: %w", path, err)
+	}
+
+	var paths []string
+	for result := range pointers.Iter(ctx, obj) {
+		pointer, err := result.Value()
+		if err != nil {
+			return nil, fmt.Errorf("reading index %s: %w", path, err)
+		}
+		if !slices.Contains(paths, pointer.Path) {
+			paths = append(paths, pointer.Path)
+		}
+	}
+	return paths, nil
+}
+
+// buildIndex builds and uploads an index object for the data objects at
+// the given paths, like the index builder does.
+func (c *Compactor) buildIndex(ctx context.Context, logger log.Logger, tenant string, paths []string) (metastore.Entry, error) {
+	if c.indexCalculator == nil {
+		builder, err := indexobj.NewBuilder(c.indexCfg.BuilderConfig)
+		if err != nil {
+			return metastore.Entry{}, fmt.Errorf("creating index builder: %w", err)
+		}
+		c.indexCalculator = index.NewCalculator(builder)
+	}
+	c.indexCalculator.Reset()
+
+	for _, path := range paths {
+		obj, err := dataobj.FromBucket(ctx, c.bucket, path)
+		if err != nil {
+			return metastore.Entry{}, fmt.Errorf("opening object %s: %w", path, err)
+		}
+		if err := c.indexCalculator.Calculate(ctx, log.With(logger, "object_path", path), obj, path); err != nil {
+			return metastore.Entry{}, fmt.Errorf("calculating index of %s: %w", path, err)
+		}
+	}
+
+	var buf bytes.Buffer
+	stats, err := c.indexCalculator.Flush(&buf)
+	if err != nil {
+		return metastore.Entry{}, fmt.Errorf("flushing index: %w", err)
+	}
+
+	key := index.ObjectKey(tenant, &buf)
+	if err := c.indexBucket().Upload(ctx, key, &buf); err != nil {
+		return metastore.Entry{}, fmt.Errorf("uploading index: %w", err)
+	}
+	return metastore.Entry{Path: key, Start: stats.MinTimestamp.UTC(), End: stats.MaxTimestamp.UTC()}, nil
+}
+
+// deleteUnreferencedIndexes deletes index objects built for a failed
+// replacement, which aren't referenced by the index metastore. Failures are
+// only logged, as the objects are never read.
+func (c *Compactor) deleteUnreferencedIndexes(ctx context.Context, entries []metastore.Entry) {
+	for _, entry := range entries {
+		if err := c.indexBucket().Delete(ctx, entry.Path); err != nil {
+			level.Warn(c.logger).Log("msg", "failed to delete unreferenced index object", "path", entry.Path, "err", err)
+		}
+	}
+}
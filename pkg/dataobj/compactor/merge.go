@@ -0,0 +1,160 @@
+package compactor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/grafana/loki/v3/pkg/dataobj"
+	"github.com/grafana/loki/v3/pkg/dataobj/consumer/logsobj"
+	"github.com/grafana/loki/v3/pkg/dataobj/metastore"
+	"github.com/grafana/loki/v3/pkg/dataobj/sections/logs"
+	"github.com/grafana/loki/v3/pkg/dataobj/sections/streams"
+	"github.com/grafana/loki/v3/pkg/dataobj/uploader"
+	"github.com/grafana/loki/v3/pkg/logproto"
+)
+
+// merge appends the logs of the given data objects to the builder, uploading
+// a new object each time the builder is full, and returns the entries of the
+// uploaded objects. The builder sorts the logs of each new object by stream
+// and timestamp.
+//
+// If keep is non-nil, only the log records for which it returns true are
+// merged. Each record is passed to keep as a single-entry stream.
+func (c *Compactor) merge(ctx context.Context, up *uploader.Uploader, objects []metastore.Entry, keep func(logproto.Stream) bool) ([]metastore.Entry, error) {
+	c.builder.Reset()
+	defer c.builder.Reset()
+
+	var merged []metastore.Entry
+
+	flush := func() error {
+		c.buf.Reset()
+		stats, err := c.builder.Flush(c.buf)
+		if err != nil {
+			return fmt.Errorf("flushing builder: %w", err)
+		}
+		path, err := up.Upload(ctx, c.buf)
+		if err != nil {
+			return err
+		}
+		merged = append(merged, metastore.Entry{Path: path, Start: stats.MinTimestamp, End: stats.MaxTimestamp})
+		return nil
+	}
+
+	appendStream := func(stream logproto.Stream) error {
+		if keep != nil && !keep(stream) {
+			return nil
+		}
+		err := c.builder.Append(stream)
+		if !errors.Is(err, logsobj.ErrBuilderFull) {
+			return err
+		}
+		if err := flush(); err != nil {
+			return err
+		}
+		return c.builder.Append(stream)
+	}
+
+	for _, object := range objects {
+		obj, err := dataobj.FromBucket(ctx, c.bucket, object.Path)
+		if err != nil {
+			return merged, fmt.Errorf("opening object %s: %w", object.Path, err)
+		}
+		if err := forEachStream(ctx, obj, appendStream); err != nil {
+			return merged, fmt.Errorf("reading object %s: %w", object.Path, err)
+		}
+	}
+
+	if err := flush(); err != nil && !errors.Is(err, logsobj.ErrBuilderEmpty) {
+		return merged, err
+	}
+	return merged, nil
+}
+
+// forEachStream calls f with a single-entry stream for each log record in
+// obj.
+func forEachStream(ctx context.Context, obj *dataobj.Object, f func(logproto.Stream) error) error {
+	streamLabels, err := readStreamLabels(ctx, obj)
+	if err != nil {
+		return err
+	}
+
+	var reader logs.RowReader
+	defer reader.Close()
+
+	buf := make([]logs.Record, 1024)
+	for i, section := range obj.Sections().Filter(logs.CheckSection) {
+		sec, err := logs.Open(ctx, section)
+		if err != nil {
+			return fmt.Errorf("opening logs section %d: %w", i, err)
+		}
+
+		reader.Reset(sec)
+		for {
+			n, err := reader.Read(ctx, buf)
+			if err != nil && !errors.Is(err, io.EOF) {
+				return fmt.Errorf("reading logs section %d: %w", i, err)
+			} else if n == 0 && errors.Is(err, io.EOF) {
+				break
+			}
+
+			for _, record := range buf[:n] {
+				ls, ok := streamLabels[record.StreamID]
+				if !ok {
+					return fmt.Errorf("unknown stream ID %d in logs section %d", record.StreamID, i)
+				}
+
+				err := f(logproto.Stream{
+					Labels: ls,
+					Entries: []logproto.Entry{{
+						Timestamp:          record.Timestamp,
+						Line:               string(record.Line),
+						StructuredMetadata: logproto.FromLabelsToLabelAdapters(record.Metadata),
+					}},
+				})
+				if err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// readStreamLabels returns the labels of the streams of obj by stream ID.
+func readStreamLabels(ctx context.Context, obj *dataobj.Object) (map[int64]string, error) {
+	streamLabels := make(map[int64]string)
+	err := forEachStreamInfo(ctx, obj, func(stream streams.Stream) {
+		streamLabels[stream.ID] = stream.Labels.String()
+	})
+	return streamLabels, err
+}
+
+// forEachStreamInfo calls f for each stream of obj.
+func forEachStreamInfo(ctx context.Context, obj *dataobj.Object, f func(streams.Stream)) error {
+	var reader streams.RowReader
+	defer reader.Close()
+
+	buf := make([]streams.Stream, 1024)
+	for i, section := range obj.Sections().Filter(streams.CheckSection) {
+		sec, err := streams.Open(ctx, section)
+		if err != nil {
+			return fmt.Errorf("opening streams section %d: %w", i, err)
+		}
+
+		reader.Reset(sec)
+		for {
+			n, err := reader.Read(ctx, buf)
+			if err != nil && !errors.Is(err, io.EOF) {
+				return fmt.Errorf("reading streams section %d: %w", i, err)
+			} else if n == 0 && errors.Is(err, io.EOF) {
+				break
+			}
+			for _, stream := range buf[:n] {
+				f(stream)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+package compactor
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	statusSuccess = "success"
+	statusFailure = "failure"
+
+	phaseCompaction = "compaction"
+	phaseRetention  = "retention"
+)
+
+type compactorMetrics struct {
+	compactionsTotal *prometheus.CounterVec
+	compactedObjects prometheus.Counter
+	deletedObjects   prometheus.Counter
+
+	retentionObjectsTotal *prometheus.CounterVec
+	skippedUnindexedTotal *prometheus.CounterVec
+}
+
+func newCompactorMetrics() *compactorMetrics {
+	return &compactorMetrics{
+		compactionsTotal: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "loki_dataobj_compactor_compactions_total",
+			Help: "Total number of compactions of the data objects of a metastore window.",
+		}, []string{"status"}),
+		compactedObjects: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "loki_dataobj_compactor_compacted_objects_total",
+			Help: "Total number of data objects merged into larger ones.",
+		}),
+		deletedObjects: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "loki_dataobj_compactor_deleted_objects_total",
+			Help: "Total number of compacted or rewritten data objects deleted after the delete delay.",
+		}),
+		retentionObjectsTotal: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "loki_dataobj_compactor_retention_objects_total",
+			Help: "Total number of data objects removed or rewritten when applying retention and delete requests.",
+		}, []string{"action"}),
+		skippedUnindexedTotal: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "loki_dataobj_compactor_skipped_unindexed_total",
+			Help: "Total number of times data objects of a tenant with indexes were skipped because they weren't indexed yet.",
+		}, []string{"phase"}),
+	}
+}
+
+func (m *compactorMetrics) register(reg prometheus.Registerer) error {
+	collectors := []prometheus.Collector{
+		m.compactionsTotal,
+		m.compactedObjects,
+		m.deletedObjects,
+		m.retentionObjectsTotal,
+		m.skippedUnindexedTotal,
+	}
+
+	for _, collector := range collectors {
+		if err := reg.Register(collector); err != nil {
+			if _, ok := err.(prometheus.AlreadyRegisteredError); !ok {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,180 @@
+package compactor
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"time"
+
+	"github.com/go-kit/log/level"
+
+	"github.com/grafana/loki/v3/pkg/dataobj/metastore"
+)
+
+// Replacing data objects updates several objects which can't be updated
+// atomically together: the metastore objects of all windows overlapped by
+// the replaced objects and, for tenants with indexes, the metastore objects
+// of the index objects referencing them. Before the first update, an intent
+// marker describing the whole replacement is uploaded. Applying a
+// replacement is idempotent, so the replacements of markers left behind by
+// a failure are applied again before anything else is done for the tenant.
+
+// replacement is the content of an intent marker.
+type replacement struct {
+	// Removed holds the replaced data objects, and Added their replacements.
+	Removed []metastore.Entry `json:"removed"`
+	Added   []metastore.Entry `json:"added,omitempty"`
+
+	// RemovedIndexes holds the index objects referencing removed data
+	// objects, and AddedIndexes the index objects replacing them. Paths are
+	// relative to the index storage prefix.
+	RemovedIndexes []metastore.Entry `json:"removed_indexes,omitempty"`
+	AddedIndexes   []metastore.Entry `json:"added_indexes,omitempty"`
+}
+
+// replacementsDir returns the directory holding the intent markers of a
+// tenant.
+func replacementsDir(tenant string) string {
+	return fmt.Sprintf("tenant-%s/compactor/replacements/", tenant)
+}
+
+// replace uploads an intent marker for r and then applies it. The objects
+// added by r must already be uploaded. Once replace returned, the added
+// objects must not be deleted even if it failed, as the replacement is
+// applied again by [Compactor.resumeReplacements].
+func (c *Compactor) replace(ctx context.Context, tenant string, r replacement) error {
+	content, err := json.Marshal(r)
+	if err != nil {
+		return fmt.Errorf("encoding intent marker: %w", err)
+	}
+	sum := sha256.Sum224(content)
+	marker := replacementsDir(tenant) + hex.EncodeToString(sum[:])
+
+	if err := c.bucket.Upload(ctx, marker, bytes.NewReader(content)); err != nil {
+		return fmt.Errorf("uploading intent marker: %w", err)
+	}
+	return c.applyReplacement(ctx, tenant, marker, r)
+}
+
+// resumeReplacements applies the replacements of the intent markers of a
+// tenant which weren't applied completely.
+func (c *Compactor) resumeReplacements(ctx context.Context, tenant string) error {
+	var markers []string
+	err := c.bucket.Iter(ctx, replacementsDir(tenant), func(name string) error {
+		markers = append(markers, name)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("listing intent markers: %w", err)
+	}
+
+	for _, marker := range markers {
+		rc, err := c.bucket.Get(ctx, marker)
+		if err != nil {
+			return fmt.Errorf("getting intent marker %s: %w", marker, err)
+		}
+		content, err := io.ReadAll(rc)
+		_ = rc.Close()
+		if err != nil {
+			return fmt.Errorf("reading intent marker %s: %w", marker, err)
+		}
+
+		var r replacement
+		if err := json.Unmarshal(content, &r); err != nil {
+			return fmt.Errorf("decoding intent marker %s: %w", marker, err)
+		}
+
+		level.Info(c.logger).Log("msg", "resuming replacement of data objects", "tenant", tenant, "marker", marker)
+		if err := c.applyReplacement(ctx, tenant, marker, r); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// applyReplacement updates the metastore objects for r, marks the removed
+// objects for deletion, and then deletes the intent marker.
+func (c *Compactor) applyReplacement(ctx context.Context, tenant, marker string, r replacement) error {
+	updater := metastore.NewUpdater(c.mCfg.Updater, c.bucket, tenant, c.logger)
+	if err := replaceEntries(ctx, updater, r.Removed, r.Added); err != nil {
+		return fmt.Errorf("updating metastore: %w", err)
+	}
+
+	// The index objects are replaced last, so that the added index objects
+	// only reference data objects which are already in the metastore.
+	indexUpdater := metastore.NewUpdater(c.mCfg.Updater, c.indexBucket(), tenant, c.logger)
+	if err := replaceEntries(ctx, indexUpdater, r.RemovedIndexes, r.AddedIndexes); err != nil {
+		return fmt.Errorf("updating index metastore: %w", err)
+	}
+
+	// Removed objects are only deleted after the metastore stopped
+	// referencing them, and after a delay, so that running queries can
+	// still read them.
+	removed := make([]string, 0, len(r.Removed)+len(r.RemovedIndexes))
+	for _, entry := range r.Removed {
+		removed = append(removed, entry.Path)
+	}
+	for _, entry := range r.RemovedIndexes {
+		removed = append(removed, c.indexCfg.IndexStoragePrefix+entry.Path)
+	}
+	if err := c.markForDeletion(ctx, tenant, removed, c.now().Add(c.cfg.DeleteDelay)); err != nil {
+		return err
+	}
+
+	if err := c.bucket.Delete(ctx, marker); err != nil && !c.bucket.IsObjNotFoundErr(err) {
+		return fmt.Errorf("deleting intent marker %s: %w", marker, err)
+	}
+	return nil
+}
+
+// replaceEntries replaces the removed entries with the added entries in the
+// metastore objects of all windows overlapped by either of them.
+func replaceEntries(ctx context.Context, updater *metastore.Updater, removed, added []metastore.Entry) error {
+	removedPaths := make([]string, 0, len(removed))
+	for _, entry := range removed {
+		removedPaths = append(removedPaths, entry.Path)
+	}
+
+	for _, window := range overlappedWindows(append(removed[:len(removed):len(removed)], added...)) {
+		var windowAdded []metastore.Entry
+		for _, entry := range added {
+			if overlapsWindow(entry, window) {
+				windowAdded = append(windowAdded, entry)
+			}
+		}
+		if err := updater.Replace(ctx, window, removedPaths, windowAdded); err != nil {
+			return fmt.Errorf("replacing entries of window %s: %w", window.Format(time.RFC3339), err)
+		}
+	}
+	return nil
+}
+
+// overlappedWindows returns the windows overlapped by the given entries, in
+// ascending order.
+func overlappedWindows(entries []metastore.Entry) []time.Time {
+	var (
+		windows []time.Time
+		seen    = make(map[time.Time]struct{})
+	)
+	for _, entry := range entries {
+		for window := entry.Start.Truncate(metastore.WindowSize).UTC(); !window.After(entry.End); window = window.Add(metastore.WindowSize) {
+			if _, ok := seen[window]; ok {
+				continue
+			}
+			seen[window] = struct{}{}
+			windows = append(windows, window)
+		}
+	}
+	slices.SortFunc(windows, func(a, b time.Time) int { return a.Compare(b) })
+	return windows
+}
+
+// overlapsWindow returns true if the logs of entry overlap the given window.
+func overlapsWindow(entry metastore.Entry, window time.Time) bool {
+	return entry.Start.Before(window.Add(metastore.WindowSize)) && !entry.End.Before(window)
+}
@@ -0,0 +1,345 @@
+package compactor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/prometheus/common/model"
+
+	"github.com/grafana/loki/v3/pkg/compactor/retention"
+	"github.com/grafana/loki/v3/pkg/dataobj"
+	"github.com/grafana/loki/v3/pkg/dataobj/metastore"
+	"github.com/grafana/loki/v3/pkg/dataobj/sections/streams"
+	"github.com/grafana/loki/v3/pkg/dataobj/uploader"
+	"github.com/grafana/loki/v3/pkg/logproto"
+	"github.com/grafana/loki/v3/pkg/util/filter"
+)
+
+// ApplyRetention removes the logs which expirationChecker reports as expired
+// from the data objects of all tenants. It implements
+// [github.com/grafana/loki/v3/pkg/compactor.RetentionApplier], so that
+// retention and delete requests are applied to data objects during the
+// retention phase of the compactor, which also handles the bookkeeping of
+// delete requests.
+//
+// Each stream of a data object is checked like a chunk spanning the time
+// range of the stream in the object. Objects whose streams are all expired
+// are removed from the metastore, while objects with some expired streams or
+// with logs matching a delete request are rewritten without them. Replaced
+// objects are deleted after the delete delay, like compacted objects.
+//
+// After a window has been processed, a progress marker records when the
+// first of its remaining logs expires. Unless the tenant has pending delete
+// requests or its retention rules changed, the window is skipped until then.
+func (c *Compactor) ApplyRetention(ctx context.Context, expirationChecker retention.ExpirationChecker) error {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	tenants, err := c.listTenants(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, tenant := range tenants {
+		if err := c.applyTenantRetention(ctx, expirationChecker, tenant); err != nil {
+			return fmt.Errorf("applying retention to tenant %s: %w", tenant, err)
+		}
+	}
+	return nil
+}
+
+// tenantRetention holds the state of applying retention to the data objects
+// of a tenant.
+type tenantRetention struct {
+	tenant            string
+	expirationChecker retention.ExpirationChecker
+	periods           *retention.TenantRetentionSnapshot
+	rules             string
+	now               model.Time
+	indexed           bool // Whether the index objects of the tenant must be rewritten too.
+
+	up     *uploader.Uploader
+	logger log.Logger
+
+	// nextExpiry holds the time at which the first log of each processed
+	// object expires, or the zero time if none of its logs expire. Objects
+	// spanning multiple windows are referenced by all of them, but must only
+	// be processed once.
+	nextExpiry map[string]time.Time
+}
+
+func (c *Compactor) applyTenantRetention(ctx context.Context, expirationChecker retention.ExpirationChecker, tenant string) error {
+	logger := log.With(c.logger, "tenant", tenant)
+
+	if err := c.resumeReplacements(ctx, tenant); err != nil {
+		return err
+	}
+
+	indexed, err := c.hasIndexes(ctx, tenant)
+	if err != nil {
+		return err
+	}
+
+	windows, err := metastore.Windows(ctx, c.bucket, tenant)
+	if err != nil {
+		return err
+	}
+
+	tr := &tenantRetention{
+		tenant:            tenant,
+		expirationChecker: expirationChecker,
+		periods:           retention.NewTenantRetentionSnapshot(c.limits, tenant),
+		rules:             retentionRules(c.limits, tenant),
+		now:               model.TimeFromUnixNano(c.now().UnixNano()),
+		indexed:           indexed,
+
+		up:     uploader.New(c.cfg.UploaderConfig, c.bucket, tenant, logger),
+		logger: logger,
+
+		nextExpiry: make(map[string]time.Time),
+	}
+
+	// Retention never expires logs after now, so an interval starting now is
+	// only reported as having expired logs if the tenant has pending delete
+	// requests, which may match logs of any window.
+	deletesPending := expirationChecker.IntervalMayHaveExpiredChunks(model.Interval{Start: tr.now, End: tr.now}, tenant)
+
+	for _, window := range windows {
+		interval := model.Interval{
+			Start: model.TimeFromUnixNano(window.UnixNano()),
+			End:   model.TimeFromUnixNano(window.Add(metastore.WindowSize).UnixNano()),
+		}
+		if !expirationChecker.IntervalMayHaveExpiredChunks(interval, tenant) {
+			continue
+		}
+
+		if !deletesPending {
+			progress, err := c.readRetentionProgress(ctx, tenant, window)
+			if err != nil {
+				return err
+			}
+			if progress.upToDate(tr.rules, tr.now.Time()) {
+				continue
+			}
+		}
+
+		if err := c.applyWindowRetention(ctx, tr, window); err != nil {
+			return fmt.Errorf("applying retention to window %s: %w", window.Format(time.RFC3339), err)
+		}
+	}
+	return nil
+}
+
+// applyWindowRetention applies retention to the data objects of a window
+// and records the progress of the window.
+func (c *Compactor) applyWindowRetention(ctx context.Context, tr *tenantRetention, window time.Time) error {
+	entries, err := metastore.ReadWindow(ctx, c.bucket, tr.tenant, window)
+	if err != nil {
+		return err
+	}
+
+	var nextExpiry time.Time
+	for _, entry := range entries {
+		expiry, ok := tr.nextExpiry[entry.Path]
+		if !ok {
+			expiry, err = c.applyObjectRetention(ctx, tr, entry)
+			if err != nil {
+				return fmt.Errorf("applying retention to %s: %w", entry.Path, err)
+			}
+		}
+		nextExpiry = earliestExpiry(nextExpiry, expiry)
+	}
+
+	return c.writeRetentionProgress(ctx, tr.tenant, window, retentionProgress{
+		Rules:      tr.rules,
+		NextExpiry: nextExpiry,
+	})
+}
+
+// applyObjectRetention removes the expired logs of a single data object,
+// replacing it in the metastore objects of all windows it overlaps. It
+// returns the time at which the first of the remaining logs expires.
+func (c *Compactor) applyObjectRetention(ctx context.Context, tr *tenantRetention, entry metastore.Entry) (time.Time, error) {
+	obj, err := dataobj.FromBucket(ctx, c.bucket, entry.Path)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("opening object: %w", err)
+	}
+
+	var (
+		userID  = []byte(tr.tenant)
+		expired bool
+
+		// kept holds the streams with logs that may remain in the object.
+		kept []streams.Stream
+
+		// Filters of streams with logs matching a delete request, by
+		// labels. Fully expired streams have a nil filter.
+		filters = make(map[string]filter.Func)
+	)
+	err = forEachStreamInfo(ctx, obj, func(stream streams.Stream) {
+		seriesID := streamSeriesID(stream)
+		streamStart := model.TimeFromUnixNano(stream.MinTimestamp.UnixNano())
+		if tr.expirationChecker.CanSkipSeries(userID, stream.Labels, seriesID, streamStart, entry.Path, tr.now) {
+			kept = append(kept, stream)
+			return
+		}
+
+		chunk := retention.Chunk{
+			ChunkID: entry.Path,
+			From:    streamStart,
+			Through: model.TimeFromUnixNano(stream.MaxTimestamp.UnixNano()),
+		}
+		isExpired, filterFunc := tr.expirationChecker.Expired(userID, chunk, stream.Labels, seriesID, entry.Path, tr.now)
+		if !isExpired {
+			kept = append(kept, stream)
+			return
+		}
+		expired = true
+		if filterFunc != nil {
+			kept = append(kept, stream)
+		}
+		filters[stream.Labels.String()] = filterFunc
+	})
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	nextExpiry := tr.nextExpiryOf(kept)
+	if !expired {
+		tr.nextExpiry[entry.Path] = nextExpiry
+		return nextExpiry, tr.markProcessed(kept, entry.Path)
+	}
+
+	// Replacing the object may empty the metastore objects of its windows,
+	// so objects of windows which still receive new objects are processed
+	// once the windows are closed. Their streams aren't marked as
+	// processed, so delete requests stay pending until then.
+	if lastWindow := entry.End.Truncate(metastore.WindowSize); !c.windowClosed(lastWindow, tr.now.Time()) {
+		closed := lastWindow.Add(metastore.WindowSize + c.cfg.CompactionDelay)
+		level.Debug(tr.logger).Log("msg", "deferring retention of data object of open window", "path", entry.Path, "until", closed)
+		tr.nextExpiry[entry.Path] = closed
+		return closed, nil
+	}
+
+	var (
+		rewritten []metastore.Entry
+		deleted   int
+	)
+	if len(kept) > 0 {
+		rewritten, err = c.merge(ctx, tr.up, []metastore.Entry{entry}, func(stream logproto.Stream) bool {
+			filterFunc, ok := filters[stream.Labels]
+			if !ok {
+				return true
+			}
+			entry := stream.Entries[0]
+			if filterFunc == nil || filterFunc(entry.Timestamp, entry.Line, logproto.FromLabelAdaptersToLabels(entry.StructuredMetadata)) {
+				deleted++
+				return false
+			}
+			return true
+		})
+		if err != nil {
+			c.deleteUnreferenced(ctx, []metastore.Entry{entry}, rewritten)
+			return time.Time{}, err
+		}
+		if deleted == 0 {
+			// The delete requests matched the streams, but none of their logs.
+			c.deleteUnreferenced(ctx, []metastore.Entry{entry}, rewritten)
+			tr.nextExpiry[entry.Path] = nextExpiry
+			return nextExpiry, tr.markProcessed(kept, entry.Path)
+		}
+	}
+
+	// The rewritten objects only contain logs of the original object, so
+	// they can only overlap the windows of the original object. The object
+	// is replaced in all of them, and the replacement is resumed if it
+	// fails in between.
+	r := replacement{Removed: []metastore.Entry{entry}, Added: rewritten}
+	if tr.indexed {
+		err := c.rewriteIndexes(ctx, tr.logger, tr.tenant, &r)
+		if errors.Is(err, errNotIndexed) {
+			// The object is processed again once it's indexed. Its streams
+			// aren't marked as processed, so delete requests stay pending
+			// until then.
+			level.Info(tr.logger).Log("msg", "deferring retention of data object which isn't indexed yet", "path", entry.Path)
+			c.metrics.skippedUnindexedTotal.WithLabelValues(phaseRetention).Inc()
+			c.deleteUnreferenced(ctx, []metastore.Entry{entry}, rewritten)
+			tr.nextExpiry[entry.Path] = tr.now.Time()
+			return tr.now.Time(), nil
+		} else if err != nil {
+			c.deleteUnreferenced(ctx, []metastore.Entry{entry}, rewritten)
+			return time.Time{}, fmt.Errorf("rewriting indexes: %w", err)
+		}
+	}
+	if err := c.replace(ctx, tr.tenant, r); err != nil {
+		return time.Time{}, fmt.Errorf("replacing object: %w", err)
+	}
+
+	// The rewritten objects were already filtered by the delete requests
+	// and must not be processed again when reading the later windows they
+	// overlap.
+	tr.nextExpiry[entry.Path] = nextExpiry
+	for _, e := range rewritten {
+		tr.nextExpiry[e.Path] = nextExpiry
+		if err := tr.markProcessed(kept, e.Path); err != nil {
+			return time.Time{}, err
+		}
+	}
+
+	c.metrics.retentionObjectsTotal.WithLabelValues(retentionActionFor(rewritten)).Inc()
+	level.Info(tr.logger).Log("msg", "applied retention to data object", "path", entry.Path, "rewritten_objects", len(rewritten), "deleted_lines", deleted)
+	return nextExpiry, nil
+}
+
+// nextExpiryOf returns the time at which the first of the given streams
+// expires, or the zero time if none of them expire.
+func (tr *tenantRetention) nextExpiryOf(kept []streams.Stream) time.Time {
+	var nextExpiry time.Time
+	for _, stream := range kept {
+		period := tr.periods.RetentionPeriodFor(stream.Labels)
+		if period <= 0 {
+			continue
+		}
+		nextExpiry = earliestExpiry(nextExpiry, stream.MaxTimestamp.Add(period))
+	}
+	return nextExpiry
+}
+
+// markProcessed records that the pending delete requests were applied to
+// the given streams of the object at path.
+func (tr *tenantRetention) markProcessed(kept []streams.Stream, path string) error {
+	userID := []byte(tr.tenant)
+	for _, stream := range kept {
+		if err := tr.expirationChecker.MarkSeriesAsProcessed(userID, streamSeriesID(stream), stream.Labels, path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// streamSeriesID returns the ID of a stream used to track the progress of
+// delete requests, like the series ID of chunks.
+func streamSeriesID(stream streams.Stream) []byte {
+	return []byte(strconv.FormatUint(stream.Labels.Hash(), 16))
+}
+
+// earliestExpiry returns the earlier of two expiry times, where the zero
+// time means that nothing expires.
+func earliestExpiry(a, b time.Time) time.Time {
+	if a.IsZero() || (!b.IsZero() && b.Before(a)) {
+		return b
+	}
+	return a
+}
+
+func retentionActionFor(rewritten []metastore.Entry) string {
+	if len(rewritten) == 0 {
+		return "removed"
+	}
+	return "rewritten"
+}
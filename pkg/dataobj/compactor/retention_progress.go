@@ -0,0 +1,92 @@
+package compactor
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/grafana/loki/v3/pkg/compactor/retention"
+)
+
+// Applying retention reads every data object of the windows which may have
+// expired logs. To avoid reading the same objects on every run, a progress
+// marker is uploaded for each processed window, holding the time at which
+// the first of its remaining logs expires. The window is only processed
+// again after that time, if the retention rules of the tenant changed, or if
+// the tenant has pending delete requests.
+
+// retentionProgress is the content of the progress marker of a window.
+type retentionProgress struct {
+	// Rules identifies the retention rules of the tenant the window was
+	// processed with.
+	Rules string `json:"rules"`
+
+	// NextExpiry is the time at which the first of the remaining logs of the
+	// window expires. The zero time means that none of them expire.
+	NextExpiry time.Time `json:"next_expiry"`
+}
+
+// upToDate returns true if the window doesn't need to be processed again at
+// now with the given retention rules.
+func (p retentionProgress) upToDate(rules string, now time.Time) bool {
+	if p.Rules == "" || p.Rules != rules {
+		return false
+	}
+	return p.NextExpiry.IsZero() || !now.After(p.NextExpiry)
+}
+
+// retentionProgressPath returns the path of the progress marker of a window.
+func retentionProgressPath(tenant string, window time.Time) string {
+	return fmt.Sprintf("tenant-%s/compactor/retention/%d", tenant, window.Unix())
+}
+
+// readRetentionProgress returns the progress marker of a window, or the zero
+// value if the window was never processed.
+func (c *Compactor) readRetentionProgress(ctx context.Context, tenant string, window time.Time) (retentionProgress, error) {
+	var progress retentionProgress
+
+	rc, err := c.bucket.Get(ctx, retentionProgressPath(tenant, window))
+	if c.bucket.IsObjNotFoundErr(err) {
+		return progress, nil
+	} else if err != nil {
+		return progress, fmt.Errorf("getting retention progress: %w", err)
+	}
+	defer rc.Close()
+
+	content, err := io.ReadAll(rc)
+	if err != nil {
+		return progress, fmt.Errorf("reading retention progress: %w", err)
+	}
+	if err := json.Unmarshal(content, &progress); err != nil {
+		return progress, fmt.Errorf("decoding retention progress: %w", err)
+	}
+	return progress, nil
+}
+
+// writeRetentionProgress uploads the progress marker of a window.
+func (c *Compactor) writeRetentionProgress(ctx context.Context, tenant string, window time.Time, progress retentionProgress) error {
+	content, err := json.Marshal(progress)
+	if err != nil {
+		return fmt.Errorf("encoding retention progress: %w", err)
+	}
+	if err := c.bucket.Upload(ctx, retentionProgressPath(tenant, window), bytes.NewReader(content)); err != nil {
+		return fmt.Errorf("uploading retention progress: %w", err)
+	}
+	return nil
+}
+
+// retentionRules returns a hash of the retention rules of a tenant, which
+// changes whenever the retention period of any stream may change.
+func retentionRules(limits retention.Limits, tenant string) string {
+	h := fnv.New64a()
+	fmt.Fprintf(h, "%d", limits.RetentionPeriod(tenant))
+	for _, rule := range limits.StreamRetention(tenant) {
+		fmt.Fprintf(h, "\n%d %d %s", rule.Period, rule.Priority, rule.Selector)
+	}
+	return strconv.FormatUint(h.Sum64(), 16)
+}
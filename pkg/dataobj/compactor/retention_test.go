@@ -0,0 +1,397 @@
+package compactor
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/stretchr/testify/require"
+	"github.com/thanos-io/objstore"
+
+	"github.com/grafana/loki/v3/pkg/compactor/retention"
+	"github.com/grafana/loki/v3/pkg/dataobj"
+	"github.com/grafana/loki/v3/pkg/dataobj/metastore"
+	"github.com/grafana/loki/v3/pkg/logproto"
+	"github.com/grafana/loki/v3/pkg/util/filter"
+	"github.com/grafana/loki/v3/pkg/validation"
+)
+
+// mockExpirationChecker expires all streams with app="expired", and deletes
+// the lines containing "deleted" from streams with app="filtered", like
+// pending delete requests.
+type mockExpirationChecker struct {
+	retention.ExpirationChecker
+	mayHaveExpired bool
+
+	// processed holds the streams marked as processed, by object path and
+	// series ID.
+	processed map[string]struct{}
+}
+
+func (m *mockExpirationChecker) Expired(_ []byte, _ retention.Chunk, lbls labels.Labels, seriesID []byte, tableName string, _ model.Time) (bool, filter.Func) {
+	if _, ok := m.processed[tableName+"/"+string(seriesID)]; ok {
+		return false, nil
+	}
+	switch lbls.Get("app") {
+	case "expired":
+		return true, nil
+	case "filtered":
+		return true, func(_ time.Time, s string, _ labels.Labels) bool {
+			return strings.Contains(s, "deleted")
+		}
+	}
+	return false, nil
+}
+
+func (m *mockExpirationChecker) IntervalMayHaveExpiredChunks(_ model.Interval, _ string) bool {
+	return m.mayHaveExpired
+}
+
+func (m *mockExpirationChecker) CanSkipSeries(_ []byte, _ labels.Labels, _ []byte, _ model.Time, _ string, _ model.Time) bool {
+	return false
+}
+
+func (m *mockExpirationChecker) MarkSeriesAsProcessed(_, seriesID []byte, _ labels.Labels, tableName string) error {
+	if m.processed == nil {
+		m.processed = make(map[string]struct{})
+	}
+	m.processed[tableName+"/"+string(seriesID)] = struct{}{}
+	return nil
+}
+
+func TestCompactor_ApplyRetention(t *testing.T) {
+	var (
+		ctx    = context.Background()
+		tenant = "test"
+		bucket = objstore.NewInMemBucket()
+		window = time.Unix(0, 0).Add(100 * metastore.WindowSize).UTC()
+	)
+
+	stream := func(app string, lines ...string) logproto.Stream {
+		s := logproto.Stream{Labels: fmt.Sprintf(`{app=%q}`, app)}
+		for i, line := range lines {
+			s.Entries = append(s.Entries, logproto.Entry{Timestamp: window.Add(time.Duration(i+1) * time.Minute), Line: line})
+		}
+		return s
+	}
+
+	var (
+		expired   = writeObject(t, bucket, tenant, stream("expired", "a", "b"))
+		filtered  = writeObject(t, bucket, tenant, stream("expired", "c"), stream("filtered", "kept", "deleted"), stream("other", "kept"))
+		untouched = writeObject(t, bucket, tenant, stream("other", "a"), stream("filtered", "kept"))
+	)
+
+	c, err := New(testConfig, metastore.Config{}, testIndexConfig, testLimits(t, validation.Limits{}), bucket, prometheus.NewPedanticRegistry(), log.NewNopLogger())
+	require.NoError(t, err)
+	t.Run("objects of open windows are deferred", func(t *testing.T) {
+		c.now = func() time.Time { return window.Add(metastore.WindowSize) }
+
+		checker := &mockExpirationChecker{mayHaveExpired: true}
+		require.NoError(t, c.ApplyRetention(ctx, checker))
+		require.ElementsMatch(t, []string{expired, filtered, untouched}, readEntries(t, bucket, tenant, window))
+		require.Empty(t, checker.processed, "delete requests must stay pending")
+
+		progress, err := c.readRetentionProgress(ctx, tenant, window)
+		require.NoError(t, err)
+		require.Equal(t, window.Add(metastore.WindowSize+testConfig.CompactionDelay), progress.NextExpiry.UTC())
+	})
+
+	c.now = func() time.Time { return window.Add(metastore.WindowSize + testConfig.CompactionDelay) }
+
+	t.Run("intervals without expired logs are skipped", func(t *testing.T) {
+		require.NoError(t, c.ApplyRetention(ctx, &mockExpirationChecker{}))
+		require.ElementsMatch(t, []string{expired, filtered, untouched}, readEntries(t, bucket, tenant, window))
+	})
+
+	checker := &mockExpirationChecker{mayHaveExpired: true}
+	require.NoError(t, c.ApplyRetention(ctx, checker))
+
+	entries := readEntries(t, bucket, tenant, window)
+	require.Len(t, entries, 2)
+	require.Contains(t, entries, untouched)
+
+	var rewritten string
+	for _, path := range entries {
+		if path != untouched {
+			rewritten = path
+		}
+	}
+	require.NotEqual(t, filtered, rewritten)
+
+	obj, err := dataobj.FromBucket(ctx, bucket, rewritten)
+	require.NoError(t, err)
+
+	var actual []string
+	err = forEachStream(ctx, obj, func(stream logproto.Stream) error {
+		actual = append(actual, stream.Labels+" "+stream.Entries[0].Line)
+		return nil
+	})
+	require.NoError(t, err)
+	require.ElementsMatch(t, []string{`{app="filtered"} kept`, `{app="other"} kept`}, actual)
+
+	t.Run("processed objects aren't rewritten again", func(t *testing.T) {
+		require.NoError(t, c.ApplyRetention(ctx, checker))
+		require.ElementsMatch(t, []string{untouched, rewritten}, readEntries(t, bucket, tenant, window))
+	})
+
+	t.Run("replaced objects deleted after delete delay", func(t *testing.T) {
+		c.now = func() time.Time { return window.Add(metastore.WindowSize + 3*time.Hour) }
+		require.NoError(t, c.RunOnce(ctx))
+
+		for path, exists := range map[string]bool{expired: false, filtered: false, untouched: true, rewritten: true} {
+			actual, err := bucket.Exists(ctx, path)
+			require.NoError(t, err)
+			require.Equal(t, exists, actual, path)
+		}
+	})
+}
+
+func TestCompactor_ApplyRetention_RewritesIndexes(t *testing.T) {
+	var (
+		ctx         = context.Background()
+		bucket      = objstore.NewInMemBucket()
+		indexBucket = objstore.NewPrefixedBucket(bucket, testIndexConfig.IndexStoragePrefix)
+		window      = time.Unix(0, 0).Add(100 * metastore.WindowSize).UTC()
+	)
+
+	write := func(tenant string) (expired, filtered string) {
+		expired = writeObject(t, bucket, tenant, logproto.Stream{
+			Labels:  `{app="expired"}`,
+			Entries: []logproto.Entry{{Timestamp: window.Add(time.Minute), Line: "a"}},
+		})
+		filtered = writeObject(t, bucket, tenant, logproto.Stream{
+			Labels: `{app="filtered"}`,
+			Entries: []logproto.Entry{
+				{Timestamp: window.Add(2 * time.Minute), Line: "kept"},
+				{Timestamp: window.Add(3 * time.Minute), Line: "deleted"},
+			},
+		})
+		return expired, filtered
+	}
+
+	var (
+		expired, filtered = write("indexed")
+		index             = writeIndex(t, bucket, "indexed", expired, filtered)
+
+		// Index building is enabled for this tenant, but its objects aren't
+		// indexed yet.
+		unindexedExpired, unindexedFiltered = write("enabled")
+	)
+
+	indexCfg := testIndexConfig
+	indexCfg.EnabledTenantIDs = []string{"enabled"}
+
+	c, err := New(testConfig, metastore.Config{}, indexCfg, testLimits(t, validation.Limits{}), bucket, prometheus.NewPedanticRegistry(), log.NewNopLogger())
+	require.NoError(t, err)
+	c.now = func() time.Time { return window.Add(metastore.WindowSize + 2*time.Hour) }
+
+	checker := &mockExpirationChecker{mayHaveExpired: true}
+	require.NoError(t, c.ApplyRetention(ctx, checker))
+
+	entries := readEntries(t, bucket, "indexed", window)
+	require.Len(t, entries, 1)
+	rewritten := entries[0]
+	require.NotEqual(t, filtered, rewritten)
+
+	// The index is replaced by an index of the rewritten object only.
+	indexes := readEntries(t, indexBucket, "indexed", window)
+	require.Len(t, indexes, 1)
+	require.NotEqual(t, index, indexes[0])
+
+	indexed, err := indexedObjects(ctx, indexBucket, indexes[0])
+	require.NoError(t, err)
+	require.Equal(t, []string{rewritten}, indexed)
+
+	t.Run("unindexed objects deferred", func(t *testing.T) {
+		require.ElementsMatch(t, []string{unindexedExpired, unindexedFiltered}, readEntries(t, bucket, "enabled", window))
+		for path := range checker.processed {
+			require.NotContains(t, path, unindexedFiltered, "delete requests must stay pending")
+		}
+
+		// The objects are processed once they're indexed.
+		writeIndex(t, bucket, "enabled", unindexedExpired, unindexedFiltered)
+		c.now = func() time.Time { return window.Add(metastore.WindowSize + 3*time.Hour) }
+		require.NoError(t, c.ApplyRetention(ctx, checker))
+
+		entries := readEntries(t, bucket, "enabled", window)
+		require.Len(t, entries, 1)
+		require.NotContains(t, []string{unindexedExpired, unindexedFiltered}, entries[0])
+	})
+}
+
+func TestCompactor_ApplyRetention_ResumesReplacement(t *testing.T) {
+	var (
+		tenant = "test"
+		inner  = objstore.NewInMemBucket()
+		window = time.Unix(0, 0).Add(100 * metastore.WindowSize).UTC()
+		next   = window.Add(metastore.WindowSize)
+	)
+
+	original := writeObject(t, inner, tenant, logproto.Stream{
+		Labels: `{app="filtered"}`,
+		Entries: []logproto.Entry{
+			{Timestamp: window.Add(metastore.WindowSize - time.Minute), Line: "kept"},
+			{Timestamp: next.Add(time.Minute), Line: "deleted"},
+			{Timestamp: next.Add(2 * time.Minute), Line: "kept"},
+		},
+	})
+
+	// Updating the metastore object of the second window fails once, and
+	// the compactor stops, like on shutdown.
+	ctx, cancel := context.WithCancel(context.Background())
+	bucket := &failingBucket{
+		Bucket:   inner,
+		failPath: fmt.Sprintf("tenant-%s/metastore/%s.store", tenant, next.Format(time.RFC3339)),
+		onFail:   cancel,
+	}
+
+	c, err := New(testConfig, metastore.Config{}, testIndexConfig, testLimits(t, validation.Limits{}), bucket, prometheus.NewPedanticRegistry(), log.NewNopLogger())
+	require.NoError(t, err)
+	c.now = func() time.Time { return next.Add(metastore.WindowSize + testConfig.CompactionDelay) }
+
+	checker := &mockExpirationChecker{mayHaveExpired: true}
+	require.Error(t, c.ApplyRetention(ctx, checker))
+
+	// The first window already references the rewritten object.
+	rewritten := readEntries(t, bucket, tenant, window)
+	require.Len(t, rewritten, 1)
+	require.NotEqual(t, original, rewritten[0])
+	require.Equal(t, []string{original}, readEntries(t, bucket, tenant, next))
+
+	require.NoError(t, c.ApplyRetention(context.Background(), checker))
+	require.Equal(t, rewritten, readEntries(t, bucket, tenant, window))
+	require.Equal(t, rewritten, readEntries(t, bucket, tenant, next))
+
+	obj, err := dataobj.FromBucket(context.Background(), bucket, rewritten[0])
+	require.NoError(t, err)
+
+	var lines []string
+	err = forEachStream(context.Background(), obj, func(stream logproto.Stream) error {
+		for _, entry := range stream.Entries {
+			lines = append(lines, entry.Line)
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, []string{"kept", "kept"}, lines)
+
+	t.Run("original deleted after delete delay", func(t *testing.T) {
+		c.now = func() time.Time { return next.Add(metastore.WindowSize + 3*time.Hour) }
+		require.NoError(t, c.RunOnce(context.Background()))
+
+		exists, err := bucket.Exists(context.Background(), original)
+		require.NoError(t, err)
+		require.False(t, exists)
+	})
+}
+
+// failingBucket fails the first update of the object at failPath and calls
+// onFail.
+type failingBucket struct {
+	objstore.Bucket
+	failPath string
+	onFail   func()
+}
+
+func (b *failingBucket) GetAndReplace(ctx context.Context, name string, f func(io.Reader) (io.Reader, error)) error {
+	if name == b.failPath {
+		b.failPath = ""
+		b.onFail()
+		return errors.New("injected failure")
+	}
+	return b.Bucket.GetAndReplace(ctx, name, f)
+}
+
+func TestCompactor_ApplyRetention_Progress(t *testing.T) {
+	var (
+		ctx    = context.Background()
+		tenant = "test"
+		bucket = objstore.NewInMemBucket()
+		window = time.Unix(0, 0).Add(100 * metastore.WindowSize).UTC()
+	)
+
+	limits := testLimits(t, validation.Limits{
+		RetentionPeriod: model.Duration(24 * time.Hour),
+		StreamRetention: []validation.StreamRetention{{
+			Period:   model.Duration(48 * time.Hour),
+			Selector: `{app="long"}`,
+			Matchers: []*labels.Matcher{labels.MustNewMatcher(labels.MatchEqual, "app", "long")},
+		}},
+	})
+	path := writeObject(t, bucket, tenant,
+		logproto.Stream{Labels: `{app="short"}`, Entries: []logproto.Entry{{Timestamp: window.Add(time.Minute), Line: "short"}}},
+		logproto.Stream{Labels: `{app="long"}`, Entries: []logproto.Entry{{Timestamp: window.Add(time.Minute), Line: "long"}}},
+	)
+
+	c, err := New(testConfig, metastore.Config{}, testIndexConfig, limits, bucket, prometheus.NewPedanticRegistry(), log.NewNopLogger())
+	require.NoError(t, err)
+
+	expirationChecker := clockExpirationChecker{
+		ExpirationChecker: retention.NewExpirationChecker(limits),
+		now:               func() time.Time { return c.now() },
+		minPeriod:         24 * time.Hour,
+	}
+
+	// The window may have expired logs, but none of them expired yet.
+	c.now = func() time.Time { return window.Add(24*time.Hour + 30*time.Second) }
+	require.NoError(t, c.ApplyRetention(ctx, expirationChecker))
+	require.Equal(t, []string{path}, readEntries(t, bucket, tenant, window))
+
+	progress, err := c.readRetentionProgress(ctx, tenant, window)
+	require.NoError(t, err)
+	require.Equal(t, window.Add(time.Minute+24*time.Hour), progress.NextExpiry.UTC())
+
+	t.Run("window skipped before next expiry", func(t *testing.T) {
+		// Reading the object would fail if the window was processed again.
+		content := readObject(t, bucket, path)
+		require.NoError(t, bucket.Upload(ctx, path, strings.NewReader("invalid")))
+		defer func() { require.NoError(t, bucket.Upload(ctx, path, bytes.NewReader(content))) }()
+
+		c.now = func() time.Time { return window.Add(24*time.Hour + time.Minute) }
+		require.NoError(t, c.ApplyRetention(ctx, expirationChecker))
+	})
+
+	c.now = func() time.Time { return window.Add(25 * time.Hour) }
+	require.NoError(t, c.ApplyRetention(ctx, expirationChecker))
+
+	entries := readEntries(t, bucket, tenant, window)
+	require.Len(t, entries, 1)
+	require.NotEqual(t, path, entries[0])
+
+	progress, err = c.readRetentionProgress(ctx, tenant, window)
+	require.NoError(t, err)
+	require.Equal(t, window.Add(time.Minute+48*time.Hour), progress.NextExpiry.UTC())
+}
+
+// clockExpirationChecker reports the intervals which may have expired logs
+// based on now instead of the wall clock.
+type clockExpirationChecker struct {
+	retention.ExpirationChecker
+	now       func() time.Time
+	minPeriod time.Duration
+}
+
+func (e clockExpirationChecker) IntervalMayHaveExpiredChunks(interval model.Interval, _ string) bool {
+	return interval.Start.Time().Before(e.now().Add(-e.minPeriod))
+}
+
+func readObject(t *testing.T, bucket objstore.Bucket, path string) []byte {
+	t.Helper()
+
+	rc, err := bucket.Get(context.Background(), path)
+	require.NoError(t, err)
+	defer rc.Close()
+
+	content, err := io.ReadAll(rc)
+	require.NoError(t, err)
+	return content
+}
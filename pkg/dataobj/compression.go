@@ -0,0 +1,34 @@
+package dataobj
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/grafana/loki/v3/pkg/dataobj/internal/dataset"
+)
+
+// CompressionConfig configures the codec and level used to compress the pages
+// of a section. The zero value compresses pages with zstd at its default
+// level.
+type CompressionConfig struct {
+	// Codec is the name of the compression codec: one of none, snappy, lz4 or
+	// zstd.
+	Codec string `yaml:"codec"`
+
+	// Level is the compression level of the codec. A level of 0 uses the
+	// default level of the codec.
+	Level int `yaml:"level"`
+}
+
+// RegisterFlagsWithPrefix registers flags with the given prefix.
+func (cfg *CompressionConfig) RegisterFlagsWithPrefix(prefix string, f *flag.FlagSet) {
+	f.StringVar(&cfg.Codec, prefix+"codec", dataset.CodecZstd, fmt.Sprintf("The compression codec to use for pages. Supported values: %s.", strings.Join(dataset.Codecs, ", ")))
+	f.IntVar(&cfg.Level, prefix+"level", 0, "The compression level to use for pages. 0 uses the default level of the codec. zstd supports levels from 1 (fastest) to 22 (best compression), and lz4 supports levels from 1 to 9. Other codecs don't support levels.")
+}
+
+// Validate validates the CompressionConfig.
+func (cfg *CompressionConfig) Validate() error {
+	_, _, err := dataset.ParseCompression(cfg.Codec, cfg.Level)
+	return err
+}
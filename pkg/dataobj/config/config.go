@@ -3,6 +3,7 @@ package config
 import (
 	"flag"
 
+	"github.com/grafana/loki/v3/pkg/dataobj/compactor"
 	"github.com/grafana/loki/v3/pkg/dataobj/consumer"
 	"github.com/grafana/loki/v3/pkg/dataobj/index"
 	"github.com/grafana/loki/v3/pkg/dataobj/metastore"
@@ -14,6 +15,7 @@ type Config struct {
 	Index     index.Config     `yaml:"index"`
 	Metastore metastore.Config `yaml:"metastore"`
 	Querier   querier.Config   `yaml:"querier"`
+	Compactor compactor.Config `yaml:"compactor"`
 	// StorageBucketPrefix is the prefix to use for the storage bucket.
 	StorageBucketPrefix string `yaml:"storage_bucket_prefix"`
 }
@@ -23,6 +25,7 @@ func (cfg *Config) RegisterFlags(f *flag.FlagSet) {
 	cfg.Index.RegisterFlags(f)
 	cfg.Metastore.RegisterFlags(f)
 	cfg.Querier.RegisterFlags(f)
+	cfg.Compactor.RegisterFlags(f)
 	f.StringVar(&cfg.StorageBucketPrefix, "dataobj-storage-bucket-prefix", "dataobj/", "The prefix to use for the storage bucket.")
 }
 
@@ -36,5 +39,8 @@ func (cfg *Config) Validate() error {
 	if err := cfg.Metastore.Validate(); err != nil {
 		return err
 	}
+	if err := cfg.Compactor.Validate(); err != nil {
+		return err
+	}
 	return nil
 }
@@ -4,6 +4,7 @@ import (
 	"flag"
 	"time"
 
+	"github.com/grafana/loki/v3/pkg/dataobj"
 	"github.com/grafana/loki/v3/pkg/dataobj/consumer/logsobj"
 	"github.com/grafana/loki/v3/pkg/dataobj/uploader"
 )
@@ -31,3 +32,27 @@ func (cfg *Config) RegisterFlagsWithPrefix(prefix string, f *flag.FlagSet) {
 
 	f.DurationVar(&cfg.IdleFlushTimeout, prefix+"idle-flush-timeout", 60*60*time.Second, "The maximum amount of time to wait in seconds before flushing an object that is no longer receiving new writes")
 }
+
+// Limits are the per-tenant limits of the consumer.
+type Limits interface {
+	// DataObjLogsCompression returns the compression of the logs sections of
+	// the tenant, or a config with an empty codec to use the global config.
+	DataObjLogsCompression(userID string) dataobj.CompressionConfig
+
+	// DataObjStreamsCompression returns the compression of the streams
+	// sections of the tenant, or a config with an empty codec to use the
+	// global config.
+	DataObjStreamsCompression(userID string) dataobj.CompressionConfig
+}
+
+// tenantBuilderConfig returns cfg with the compression overrides of the
+// tenant applied.
+func tenantBuilderConfig(cfg logsobj.BuilderConfig, limits Limits, tenant string) logsobj.BuilderConfig {
+	if compression := limits.DataObjLogsCompression(tenant); compression.Codec != "" {
+		cfg.LogsCompression = compression
+	}
+	if compression := limits.DataObjStreamsCompression(tenant); compression.Codec != "" {
+		cfg.StreamsCompression = compression
+	}
+	return cfg
+}
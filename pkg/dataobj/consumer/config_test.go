@@ -0,0 +1,50 @@
+package consumer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/loki/v3/pkg/dataobj"
+)
+
+type mockLimits struct {
+	logs, streams map[string]dataobj.CompressionConfig
+}
+
+func (m mockLimits) DataObjLogsCompression(userID string) dataobj.CompressionConfig {
+	return m.logs[userID]
+}
+
+func (m mockLimits) DataObjStreamsCompression(userID string) dataobj.CompressionConfig {
+	return m.streams[userID]
+}
+
+func Test_tenantBuilderConfig(t *testing.T) {
+	cfg := testBuilderConfig
+	cfg.LogsCompression = dataobj.CompressionConfig{Codec: "zstd"}
+	cfg.StreamsCompression = dataobj.CompressionConfig{Codec: "zstd"}
+
+	limits := mockLimits{
+		logs: map[string]dataobj.CompressionConfig{
+			"tenant-a": {Codec: "lz4", Level: 9},
+		},
+		streams: map[string]dataobj.CompressionConfig{
+			"tenant-b": {Codec: "snappy"},
+		},
+	}
+
+	tenantA := tenantBuilderConfig(cfg, limits, "tenant-a")
+	require.Equal(t, dataobj.CompressionConfig{Codec: "lz4", Level: 9}, tenantA.LogsCompression)
+	require.Equal(t, cfg.StreamsCompression, tenantA.StreamsCompression)
+
+	tenantB := tenantBuilderConfig(cfg, limits, "tenant-b")
+	require.Equal(t, cfg.LogsCompression, tenantB.LogsCompression)
+	require.Equal(t, dataobj.CompressionConfig{Codec: "snappy"}, tenantB.StreamsCompression)
+
+	// Tenants without overrides use the global config.
+	require.Equal(t, cfg, tenantBuilderConfig(cfg, limits, "tenant-c"))
+
+	// The global config is never modified.
+	require.Equal(t, dataobj.CompressionConfig{Codec: "zstd"}, cfg.LogsCompression)
+}
@@ -7,6 +7,7 @@ import (
 	"errors"
 	"flag"
 	"fmt"
+	"iter"
 	"time"
 
 	"github.com/grafana/dskit/flagext"
@@ -19,6 +20,7 @@ import (
 	"github.com/grafana/loki/v3/pkg/dataobj"
 	"github.com/grafana/loki/v3/pkg/dataobj/sections/logs"
 	"github.com/grafana/loki/v3/pkg/dataobj/sections/streams"
+	"github.com/grafana/loki/v3/pkg/dataobj/sections/tokens"
 	"github.com/grafana/loki/v3/pkg/logproto"
 	"github.com/grafana/loki/v3/pkg/logql/syntax"
 )
@@ -61,6 +63,29 @@ type BuilderConfig struct {
 	// values of MergeSize trade off lower memory overhead for higher time spent
 	// merging.
 	SectionStripeMergeLimit int `yaml:"section_stripe_merge_limit"`
+
+	// LogsCompression configures the compression of the log lines and
+	// metadata of logs sections.
+	LogsCompression dataobj.CompressionConfig `yaml:"logs_compression"`
+
+	// StreamsCompression configures the compression of the labels of streams
+	// sections.
+	StreamsCompression dataobj.CompressionConfig `yaml:"streams_compression"`
+
+	// MetadataDictionaryEncoding enables dictionary encoding of the structured
+	// metadata columns of logs sections.
+	MetadataDictionaryEncoding bool `yaml:"metadata_dictionary_encoding"`
+
+	// MetadataBloomFilters enables storing bloom filters of the values of
+	// plain-encoded pages of the structured metadata columns of logs sections.
+	// Older readers can't decode pages with bloom filters, so all readers must
+	// be upgraded before enabling it.
+	MetadataBloomFilters bool `yaml:"metadata_bloom_filters"`
+
+	// TokenIndexEnabled enables building a tokens section, which indexes the
+	// trigrams of the log lines of each logs section so that queries with line
+	// filters can skip rows which can't match.
+	TokenIndexEnabled bool `yaml:"token_index_enabled"`
 }
 
 // RegisterFlagsWithPrefix registers flags with the given prefix.
@@ -75,6 +100,11 @@ func (cfg *BuilderConfig) RegisterFlagsWithPrefix(prefix string, f *flag.FlagSet
 	f.Var(&cfg.TargetSectionSize, prefix+"target-section-size", "The target maximum amount of uncompressed data to hold in sections, for sections that support being limited by size. Uncompressed size is used for consistent I/O and planning.")
 	f.Var(&cfg.BufferSize, prefix+"buffer-size", "The size of logs to buffer in memory before adding into columnar builders, used to reduce CPU load of sorting.")
 	f.IntVar(&cfg.SectionStripeMergeLimit, prefix+"section-stripe-merge-limit", 2, "The maximum number of log section stripes to merge into a section at once. Must be greater than 1.")
+	cfg.LogsCompression.RegisterFlagsWithPrefix(prefix+"logs-compression.", f)
+	cfg.StreamsCompression.RegisterFlagsWithPrefix(prefix+"streams-compression.", f)
+	f.BoolVar(&cfg.MetadataDictionaryEncoding, prefix+"metadata-dictionary-encoding", false, "Experimental: Dictionary encode pages of structured metadata columns with few distinct values, allowing queries to skip pages and rows without a matching value. Pages with many distinct values are plain-encoded.")
+	f.BoolVar(&cfg.MetadataBloomFilters, prefix+"metadata-bloom-filters", false, "Experimental: Store a bloom filter of the values of plain-encoded pages of structured metadata columns, allowing queries for high-cardinality values such as trace IDs to skip pages. Versions of Loki without support for bloom filters can't read pages with bloom filters, so all components reading data objects must be upgraded before enabling this.")
+	f.BoolVar(&cfg.TokenIndexEnabled, prefix+"token-index-enabled", false, "Experimental: Build an index of the trigrams of log lines in each data object, used to skip rows that can't match line filters when querying.")
 }
 
 // Validate validates the BuilderConfig.
@@ -103,6 +133,14 @@ func (cfg *BuilderConfig) Validate() error {
 		errs = append(errs, errors.New("LogsMergeStripesMax must be greater than 1"))
 	}
 
+	if err := cfg.LogsCompression.Validate(); err != nil {
+		errs = append(errs, fmt.Errorf("LogsCompression: %w", err))
+	}
+
+	if err := cfg.StreamsCompression.Validate(); err != nil {
+		errs = append(errs, fmt.Errorf("StreamsCompression: %w", err))
+	}
+
 	return errors.Join(errs...)
 }
 
@@ -123,6 +161,9 @@ type Builder struct {
 	builder *dataobj.Builder // Inner builder for accumulating sections.
 	streams *streams.Builder
 	logs    *logs.Builder
+	tokens  *tokens.Builder // Only set when the token index is enabled.
+
+	logsSections int // Number of logs sections appended to builder.
 
 	state builderState
 }
@@ -153,20 +194,45 @@ func NewBuilder(cfg BuilderConfig) (*Builder, error) {
 	metrics := newBuilderMetrics()
 	metrics.ObserveConfig(cfg)
 
-	return &Builder{
+	streamsBuilder := streams.NewBuilder(metrics.streams, int(cfg.TargetPageSize))
+	streamsBuilder.SetCompression(cfg.StreamsCompression)
+
+	b := &Builder{
 		cfg:     cfg,
 		metrics: metrics,
 
 		labelCache: labelCache,
 
 		builder: dataobj.NewBuilder(),
-		streams: streams.NewBuilder(metrics.streams, int(cfg.TargetPageSize)),
-		logs: logs.NewBuilder(metrics.logs, logs.BuilderOptions{
-			PageSizeHint:     int(cfg.TargetPageSize),
-			BufferSize:       int(cfg.BufferSize),
-			StripeMergeLimit: cfg.SectionStripeMergeLimit,
-		}),
-	}, nil
+		streams: streamsBuilder,
+	}
+
+	logsOpts := logs.BuilderOptions{
+		PageSizeHint:     int(cfg.TargetPageSize),
+		BufferSize:       int(cfg.BufferSize),
+		StripeMergeLimit: cfg.SectionStripeMergeLimit,
+		Compression:      cfg.LogsCompression,
+
+		DictionaryEncodeMetadata: cfg.MetadataDictionaryEncoding,
+		BloomFilterMetadata:      cfg.MetadataBloomFilters,
+	}
+	if cfg.TokenIndexEnabled {
+		b.tokens = tokens.NewBuilder(int(cfg.TargetPageSize))
+		logsOpts.IndexLines = b.indexLines
+	}
+	b.logs = logs.NewBuilder(metrics.logs, logsOpts)
+
+	return b, nil
+}
+
+// indexLines adds the lines of the logs section being flushed to the tokens
+// section. It's called once for each logs section, in the order the sections
+// are appended to the data object.
+func (b *Builder) indexLines(lines iter.Seq2[int, []byte]) {
+	for row, line := range lines {
+		b.tokens.AppendLine(b.logsSections, row, line)
+	}
+	b.logsSections++
 }
 
 func (b *Builder) GetEstimatedSize() int {
@@ -288,6 +354,9 @@ func (b *Builder) estimatedSize() int {
 	var size int
 	size += b.streams.EstimatedSize()
 	size += b.logs.EstimatedSize()
+	if b.tokens != nil {
+		size += b.tokens.EstimatedSize()
+	}
 	size += b.builder.Bytes()
 	b.metrics.sizeEstimate.Set(float64(size))
 	return size
@@ -319,6 +388,11 @@ func (b *Builder) Flush(output *bytes.Buffer) (FlushStats, error) {
 
 	flushErrors = append(flushErrors, b.builder.Append(b.streams))
 	flushErrors = append(flushErrors, b.builder.Append(b.logs))
+	if b.tokens != nil {
+		// The tokens section must be appended after the last logs section, so
+		// that the lines of all logs sections are indexed.
+		flushErrors = append(flushErrors, b.builder.Append(b.tokens))
+	}
 
 	if err := errors.Join(flushErrors...); err != nil {
 		b.metrics.flushFailures.Inc()
@@ -385,6 +459,10 @@ func (b *Builder) Reset() {
 	b.builder.Reset()
 	b.logs.Reset()
 	b.streams.Reset()
+	if b.tokens != nil {
+		b.tokens.Reset()
+	}
+	b.logsSections = 0
 
 	b.metrics.sizeEstimate.Set(0)
 	b.currentSizeEstimate = 0
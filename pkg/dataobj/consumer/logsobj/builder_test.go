@@ -4,6 +4,9 @@ import (
 	"bytes"
 	"context"
 	"errors"
+	"fmt"
+	"io"
+	"slices"
 	"strings"
 	"testing"
 	"time"
@@ -15,6 +18,7 @@ import (
 	"github.com/grafana/loki/v3/pkg/dataobj"
 	"github.com/grafana/loki/v3/pkg/dataobj/sections/logs"
 	"github.com/grafana/loki/v3/pkg/dataobj/sections/streams"
+	"github.com/grafana/loki/v3/pkg/dataobj/sections/tokens"
 	"github.com/grafana/loki/v3/pkg/logproto"
 )
 
@@ -141,3 +145,112 @@ func TestBuilder_Append(t *testing.T) {
 		require.NoError(t, err)
 	}
 }
+
+func TestBuilder_Compression(t *testing.T) {
+	cfg := testBuilderConfig
+	cfg.LogsCompression = dataobj.CompressionConfig{Codec: "lz4", Level: 9}
+	cfg.StreamsCompression = dataobj.CompressionConfig{Codec: "snappy"}
+
+	builder, err := NewBuilder(cfg)
+	require.NoError(t, err)
+	require.NoError(t, builder.Append(logproto.Stream{
+		Labels:  `{cluster="test",app="foo"}`,
+		Entries: []push.Entry{{Timestamp: time.Unix(10, 0).UTC(), Line: "hello"}},
+	}))
+
+	buf := bytes.NewBuffer(nil)
+	_, err = builder.Flush(buf)
+	require.NoError(t, err)
+
+	obj, err := dataobj.FromReaderAt(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	require.NoError(t, err)
+
+	for _, section := range obj.Sections() {
+		switch {
+		case logs.CheckSection(section):
+			logsSection, err := logs.Open(context.Background(), section)
+			require.NoError(t, err)
+
+			records := make([]logs.Record, 1)
+			n, err := logs.NewRowReader(logsSection).Read(context.Background(), records)
+			require.NoError(t, err)
+			require.Equal(t, 1, n)
+			require.Equal(t, "hello", string(records[0].Line))
+
+		case streams.CheckSection(section):
+			streamsSection, err := streams.Open(context.Background(), section)
+			require.NoError(t, err)
+
+			rows := make([]streams.Stream, 1)
+			n, err := streams.NewRowReader(streamsSection).Read(context.Background(), rows)
+			require.NoError(t, err)
+			require.Equal(t, 1, n)
+			require.Equal(t, `{app="foo", cluster="test"}`, rows[0].Labels.String())
+		}
+	}
+
+	cfg.LogsCompression = dataobj.CompressionConfig{Codec: "gzip"}
+	_, err = NewBuilder(cfg)
+	require.Error(t, err, "unknown codecs should be rejected")
+}
+
+func TestBuilder_TokenIndex(t *testing.T) {
+	cfg := testBuilderConfig
+	cfg.TokenIndexEnabled = true
+	cfg.TargetSectionSize = 4096 // Split lines into multiple logs sections.
+
+	builder, err := NewBuilder(cfg)
+	require.NoError(t, err)
+
+	var entries []push.Entry
+	for i := range 500 {
+		line := fmt.Sprintf("msg=request handled id=%d", i)
+		if i%100 == 0 {
+			line = fmt.Sprintf("msg=needle found id=%d", i)
+		}
+		entries = append(entries, push.Entry{Timestamp: time.Unix(int64(i+1), 0).UTC(), Line: line})
+	}
+	require.NoError(t, builder.Append(logproto.Stream{Labels: `{cluster="test",app="foo"}`, Entries: entries}))
+
+	buf := bytes.NewBuffer(nil)
+	_, err = builder.Flush(buf)
+	require.NoError(t, err)
+
+	obj, err := dataobj.FromReaderAt(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	require.NoError(t, err)
+	require.Equal(t, 1, obj.Sections().Count(tokens.CheckSection))
+
+	var sections, needles int
+	for i, section := range obj.Sections().Filter(logs.CheckSection) {
+		sections++
+
+		ranges, err := tokens.RowRanges(context.Background(), obj, i, []string{"needle"})
+		require.NoError(t, err)
+		require.NotNil(t, ranges, "logs section %d should be indexed", i)
+
+		logsSection, err := logs.Open(context.Background(), section)
+		require.NoError(t, err)
+		reader := logs.NewRowReader(logsSection)
+
+		var row uint64
+		records := make([]logs.Record, 128)
+		for {
+			n, err := reader.Read(context.Background(), records)
+			for _, record := range records[:n] {
+				if strings.Contains(string(record.Line), "needle") {
+					needles++
+					require.True(t, slices.ContainsFunc(ranges, func(rr logs.RowRange) bool {
+						return row >= rr.Start && row < rr.End
+					}), "row %d of logs section %d must not be pruned", row, i)
+				}
+				row++
+			}
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			require.NoError(t, err)
+		}
+	}
+	require.Greater(t, sections, 1)
+	require.Equal(t, 5, needles)
+}
@@ -39,6 +39,7 @@ type Service struct {
 
 	cfg    Config
 	mCfg   metastore.Config
+	limits Limits
 	bucket objstore.Bucket
 	codec  distributor.TenantPrefixCodec
 
@@ -49,11 +50,12 @@ type Service struct {
 	bufPool *sync.Pool
 }
 
-func New(kafkaCfg kafka.Config, cfg Config, mCfg metastore.Config, topicPrefix string, bucket objstore.Bucket, instanceID string, partitionRing ring.PartitionRingReader, reg prometheus.Registerer, logger log.Logger) *Service {
+func New(kafkaCfg kafka.Config, cfg Config, mCfg metastore.Config, limits Limits, topicPrefix string, bucket objstore.Bucket, instanceID string, partitionRing ring.PartitionRingReader, reg prometheus.Registerer, logger log.Logger) *Service {
 	s := &Service{
 		logger:            log.With(logger, "component", groupName),
 		cfg:               cfg,
 		mCfg:              mCfg,
+		limits:            limits,
 		bucket:            bucket,
 		codec:             distributor.TenantPrefixCodec(topicPrefix),
 		partitionHandlers: make(map[string]map[int32]*partitionProcessor),
@@ -116,8 +118,9 @@ func (s *Service) handlePartitionsAssigned(ctx context.Context, client *kgo.Clie
 			s.partitionHandlers[topic] = make(map[int32]*partitionProcessor)
 		}
 
+		builderCfg := tenantBuilderConfig(s.cfg.BuilderConfig, s.limits, tenant)
 		for _, partition := range parts {
-			processor := newPartitionProcessor(ctx, client, s.cfg.BuilderConfig, s.cfg.UploaderConfig, s.mCfg, s.bucket, tenant, virtualShard, topic, partition, s.logger, s.reg, s.bufPool, s.cfg.IdleFlushTimeout, s.eventsProducerClient)
+			processor := newPartitionProcessor(ctx, client, builderCfg, s.cfg.UploaderConfig, s.mCfg, s.bucket, tenant, virtualShard, topic, partition, s.logger, s.reg, s.bufPool, s.cfg.IdleFlushTimeout, s.eventsProducerClient)
 			s.partitionHandlers[topic][partition] = processor
 			processor.start()
 		}
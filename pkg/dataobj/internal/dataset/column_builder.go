@@ -4,6 +4,7 @@ import (
 	"fmt"
 
 	"github.com/klauspost/compress/zstd"
+	"github.com/pierrec/lz4/v4"
 
 	"github.com/grafana/loki/v3/pkg/dataobj/internal/metadata/datasetmd"
 )
@@ -40,6 +41,11 @@ type StatisticsOptions struct {
 	// StoreCardinalityStats indicates whether to store cardinality estimations,
 	// facilitated by hyperloglog
 	StoreCardinalityStats bool
+
+	// StoreBloomFilters indicates whether to store a bloom filter of the values
+	// of each page of a byte array column. Bloom filters are omitted for
+	// dictionary-encoded pages, whose dictionary already lists their values.
+	StoreBloomFilters bool
 }
 
 // CompressionOptions customizes the compressor used when building pages.
@@ -47,6 +53,10 @@ type CompressionOptions struct {
 	// Zstd holds encoding options for Zstd compression. Only used for
 	// [datasetmd.COMPRESSION_TYPE_ZSTD].
 	Zstd []zstd.EOption
+
+	// LZ4 holds encoding options for LZ4 compression. Only used for
+	// [datasetmd.COMPRESSION_TYPE_LZ4].
+	LZ4 []lz4.Option
 }
 
 // A ColumnBuilder builds a sequence of [Value] entries of a common type into a
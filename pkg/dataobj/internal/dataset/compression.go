@@ -0,0 +1,70 @@
+package dataset
+
+import (
+	"fmt"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/pierrec/lz4/v4"
+
+	"github.com/grafana/loki/v3/pkg/dataobj/internal/metadata/datasetmd"
+)
+
+// Names of the compression codecs accepted by [ParseCompression].
+const (
+	CodecNone   = "none"
+	CodecSnappy = "snappy"
+	CodecLZ4    = "lz4"
+	CodecZstd   = "zstd"
+)
+
+// Codecs lists the names of all compression codecs accepted by
+// [ParseCompression].
+var Codecs = []string{CodecNone, CodecSnappy, CodecLZ4, CodecZstd}
+
+// ParseCompression returns the compression type and options that compress
+// pages with the named codec at the given level. An empty codec name selects
+// [CodecZstd].
+//
+// A level of 0 selects the default level of the codec. Otherwise, zstd
+// accepts levels from 1 (fastest) to 22 (best compression), which are mapped
+// to the closest level supported by the encoder, and lz4 accepts levels from
+// 1 to 9. Other codecs don't support levels.
+func ParseCompression(codec string, level int) (datasetmd.CompressionType, CompressionOptions, error) {
+	switch codec {
+	case CodecNone, CodecSnappy:
+		if level != 0 {
+			return datasetmd.COMPRESSION_TYPE_UNSPECIFIED, CompressionOptions{}, fmt.Errorf("compression codec %q does not support levels", codec)
+		}
+		if codec == CodecNone {
+			return datasetmd.COMPRESSION_TYPE_NONE, CompressionOptions{}, nil
+		}
+		return datasetmd.COMPRESSION_TYPE_SNAPPY, CompressionOptions{}, nil
+
+	case CodecLZ4:
+		if level < 0 || level > 9 {
+			return datasetmd.COMPRESSION_TYPE_UNSPECIFIED, CompressionOptions{}, fmt.Errorf("invalid lz4 compression level %d: must be between 0 and 9", level)
+		}
+		lz4Level := lz4.Fast
+		if level > 0 {
+			lz4Level = lz4.Level1 << (level - 1)
+		}
+		return datasetmd.COMPRESSION_TYPE_LZ4, CompressionOptions{
+			LZ4: []lz4.Option{lz4.CompressionLevelOption(lz4Level)},
+		}, nil
+
+	case "", CodecZstd:
+		if level < 0 || level > 22 {
+			return datasetmd.COMPRESSION_TYPE_UNSPECIFIED, CompressionOptions{}, fmt.Errorf("invalid zstd compression level %d: must be between 0 and 22", level)
+		}
+		zstdLevel := zstd.SpeedDefault
+		if level > 0 {
+			zstdLevel = zstd.EncoderLevelFromZstd(level)
+		}
+		return datasetmd.COMPRESSION_TYPE_ZSTD, CompressionOptions{
+			Zstd: []zstd.EOption{zstd.WithEncoderLevel(zstdLevel)},
+		}, nil
+
+	default:
+		return datasetmd.COMPRESSION_TYPE_UNSPECIFIED, CompressionOptions{}, fmt.Errorf("unknown compression codec %q", codec)
+	}
+}
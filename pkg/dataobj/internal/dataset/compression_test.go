@@ -0,0 +1,118 @@
+package dataset
+
+import (
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/loki/v3/pkg/dataobj/internal/metadata/datasetmd"
+)
+
+// compressionSettings are the codec and level combinations compared by
+// [Benchmark_Compression].
+var compressionSettings = []struct {
+	codec string
+	level int
+}{
+	{codec: CodecNone},
+	{codec: CodecSnappy},
+	{codec: CodecLZ4, level: 0},
+	{codec: CodecLZ4, level: 9},
+	{codec: CodecZstd, level: 1},
+	{codec: CodecZstd, level: 3},
+	{codec: CodecZstd, level: 7},
+	{codec: CodecZstd, level: 11},
+}
+
+func TestParseCompression(t *testing.T) {
+	tt := []struct {
+		codec     string
+		level     int
+		expect    datasetmd.CompressionType
+		expectErr bool
+	}{
+		{codec: "", expect: datasetmd.COMPRESSION_TYPE_ZSTD},
+		{codec: CodecNone, expect: datasetmd.COMPRESSION_TYPE_NONE},
+		{codec: CodecSnappy, expect: datasetmd.COMPRESSION_TYPE_SNAPPY},
+		{codec: CodecLZ4, expect: datasetmd.COMPRESSION_TYPE_LZ4},
+		{codec: CodecLZ4, level: 9, expect: datasetmd.COMPRESSION_TYPE_LZ4},
+		{codec: CodecZstd, level: 22, expect: datasetmd.COMPRESSION_TYPE_ZSTD},
+
+		{codec: CodecSnappy, level: 1, expectErr: true},
+		{codec: CodecLZ4, level: 10, expectErr: true},
+		{codec: CodecZstd, level: -1, expectErr: true},
+		{codec: "gzip", expectErr: true},
+	}
+
+	for _, tc := range tt {
+		t.Run(fmt.Sprintf("%s/%d", tc.codec, tc.level), func(t *testing.T) {
+			actual, _, err := ParseCompression(tc.codec, tc.level)
+			if tc.expectErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.expect, actual)
+		})
+	}
+}
+
+func Test_pageBuilder_Compression(t *testing.T) {
+	for _, setting := range compressionSettings {
+		t.Run(fmt.Sprintf("%s/%d", setting.codec, setting.level), func(t *testing.T) {
+			compression, compressionOpts, err := ParseCompression(setting.codec, setting.level)
+			require.NoError(t, err)
+
+			expect := logsTestPageWithCompression(t, datasetmd.COMPRESSION_TYPE_NONE, CompressionOptions{})
+			page := logsTestPageWithCompression(t, compression, compressionOpts)
+			require.Equal(t, expect.Info.UncompressedSize, page.Info.UncompressedSize)
+
+			_, expectValues, err := expect.reader(datasetmd.COMPRESSION_TYPE_NONE)
+			require.NoError(t, err)
+			defer expectValues.Close()
+
+			_, values, err := page.reader(compression)
+			require.NoError(t, err)
+			defer values.Close()
+
+			expectData, err := io.ReadAll(expectValues)
+			require.NoError(t, err)
+			data, err := io.ReadAll(values)
+			require.NoError(t, err)
+			require.Equal(t, expectData, data)
+		})
+	}
+}
+
+// Benchmark_Compression reports the compression ratio and the decode
+// throughput of a page of access logs for each of the compressionSettings.
+//
+// Run with:
+//
+//	go test -run=^$ -bench=Benchmark_Compression ./pkg/dataobj/internal/dataset
+func Benchmark_Compression(b *testing.B) {
+	for _, setting := range compressionSettings {
+		b.Run(fmt.Sprintf("codec=%s/level=%d", setting.codec, setting.level), func(b *testing.B) {
+			compression, compressionOpts, err := ParseCompression(setting.codec, setting.level)
+			require.NoError(b, err)
+
+			page := logsTestPageWithCompression(b, compression, compressionOpts)
+			b.SetBytes(int64(page.Info.UncompressedSize))
+
+			for b.Loop() {
+				_, values, err := page.reader(compression)
+				if err != nil {
+					b.Fatal(err)
+				} else if _, err := io.Copy(io.Discard, values); err != nil {
+					b.Fatal(err)
+				} else if err := values.Close(); err != nil {
+					b.Fatal(err)
+				}
+			}
+
+			b.ReportMetric(float64(page.Info.UncompressedSize)/float64(page.Info.CompressedSize), "ratio")
+		})
+	}
+}
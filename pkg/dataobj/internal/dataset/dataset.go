@@ -31,6 +31,18 @@ type Dataset interface {
 	ReadPages(ctx context.Context, pages []Page) result.Seq[PageData]
 }
 
+// A BloomFilterReader is a [Dataset] which can read the bloom filters of
+// pages without reading the rest of their data.
+type BloomFilterReader interface {
+	Dataset
+
+	// ReadBloomFilters returns the bloom filter data for the specified slice
+	// of pages, each of which must have a non-zero
+	// [PageInfo.BloomFilterSize]. The order of data in the returned sequence
+	// must match the order of the pages argument.
+	ReadBloomFilters(ctx context.Context, pages []Page) result.Seq[[]byte]
+}
+
 // FromMemory returns an in-memory [Dataset] from the given list of
 // [MemColumn]s.
 func FromMemory(columns []*MemColumn) Dataset {
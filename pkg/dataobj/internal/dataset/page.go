@@ -10,8 +10,10 @@ import (
 	"runtime"
 	"sync"
 
+	"github.com/bits-and-blooms/bloom/v3"
 	"github.com/golang/snappy"
 	"github.com/klauspost/compress/zstd"
+	"github.com/pierrec/lz4/v4"
 
 	"github.com/grafana/loki/v3/pkg/dataobj/internal/metadata/datasetmd"
 	"github.com/grafana/loki/v3/pkg/dataobj/internal/util/bufpool"
@@ -21,7 +23,7 @@ import (
 type (
 	// PageData holds the raw data for a page. Data is formatted as:
 	//
-	//   <uvarint(presence-bitmap-size)> <presence-bitmap> <values-data>
+	//   <uvarint(presence-bitmap-size)> <presence-bitmap> <values-data> <bloom-filter>
 	//
 	// The presence-bitmap is a bitmap-encoded sequence of booleans, where values
 	// describe which rows are present (1) or nil (0). The presence bitmap is
@@ -29,6 +31,12 @@ type (
 	//
 	// values-data is then the encoded and optionally compressed sequence of
 	// non-NULL values.
+	//
+	// bloom-filter is an optional, uncompressed bloom filter of the non-NULL
+	// values, whose size is stored in [PageInfo.BloomFilterSize]. Readers
+	// which don't support bloom filters read it as part of values-data and
+	// fail to decompress it, so bloom filters must only be written once all
+	// readers support them.
 	PageData []byte
 
 	// PageInfo describes a page.
@@ -41,6 +49,9 @@ type (
 
 		Encoding datasetmd.EncodingType // Encoding used for values in the page.
 		Stats    *datasetmd.Statistics  // Optional statistics for the page.
+
+		BloomFilterSize  int    // BloomFilterSize is the size of the optional bloom filter at the end of the page data.
+		BloomFilterCRC32 uint32 // BloomFilterCRC32 is the checksum of the bloom filter.
 	}
 
 	// Pages is a set of [Page]s.
@@ -78,6 +89,31 @@ func (p *MemPage) ReadPage(_ context.Context) (PageData, error) {
 
 var checksumTable = crc32.MakeTable(crc32.Castagnoli)
 
+// decodeBloomFilter decodes the bloom filter of a page described by info from
+// data, which holds the last info.BloomFilterSize bytes of the page data.
+// decodeBloomFilter returns an error if the CRC32 fails to validate.
+func decodeBloomFilter(info *PageInfo, data []byte) (*bloom.BloomFilter, error) {
+	if len(data) != info.BloomFilterSize {
+		return nil, fmt.Errorf("bloom filter has %d bytes, expected %d", len(data), info.BloomFilterSize)
+	} else if actual := crc32.Checksum(data, checksumTable); info.BloomFilterCRC32 != actual {
+		return nil, fmt.Errorf("invalid bloom filter CRC32 checksum %x, expected %x", actual, info.BloomFilterCRC32)
+	}
+
+	var filter bloom.BloomFilter
+	if err := filter.UnmarshalBinary(data); err != nil {
+		return nil, fmt.Errorf("decoding bloom filter: %w", err)
+	}
+	return &filter, nil
+}
+
+// bloomFilterData returns the bloom filter bytes at the end of the page data.
+func bloomFilterData(info *PageInfo, data PageData) ([]byte, error) {
+	if info.BloomFilterSize > len(data) {
+		return nil, fmt.Errorf("bloom filter of %d bytes exceeds page data of %d bytes", info.BloomFilterSize, len(data))
+	}
+	return data[len(data)-info.BloomFilterSize:], nil
+}
+
 // reader returns a reader for decompressed page data. Reader returns an error
 // if the CRC32 fails to validate.
 func (p *MemPage) reader(compression datasetmd.CompressionType) (presence io.Reader, values io.ReadCloser, err error) {
@@ -90,9 +126,14 @@ func (p *MemPage) reader(compression datasetmd.CompressionType) (presence io.Rea
 		return nil, nil, fmt.Errorf("reading presence bitmap size: %w", err)
 	}
 
+	valuesEnd := len(p.Data) - p.Info.BloomFilterSize
+	if n+int(bitmapSize) > valuesEnd {
+		return nil, nil, fmt.Errorf("page data of %d bytes too small for presence bitmap and bloom filter", len(p.Data))
+	}
+
 	var (
 		bitmapData           = p.Data[n : n+int(bitmapSize)]
-		compressedValuesData = p.Data[n+int(bitmapSize):]
+		compressedValuesData = p.Data[n+int(bitmapSize) : valuesEnd]
 
 		bitmapReader           = bytes.NewReader(bitmapData)
 		compressedValuesReader = bytes.NewReader(compressedValuesData)
@@ -111,6 +152,15 @@ func (p *MemPage) reader(compression datasetmd.CompressionType) (presence io.Rea
 			return nil
 		}}, nil
 
+	case datasetmd.COMPRESSION_TYPE_LZ4:
+		lr := lz4Pool.Get().(*lz4.Reader)
+		lr.Reset(compressedValuesReader)
+		return bitmapReader, &closerFunc{Reader: lr, onClose: func() error {
+			lr.Reset(nil) // Allow releasing the buffer.
+			lz4Pool.Put(lr)
+			return nil
+		}}, nil
+
 	case datasetmd.COMPRESSION_TYPE_ZSTD:
 		zr := zstdPool.Get().(*zstdWrapper)
 		if err := zr.Reset(compressedValuesReader); err != nil {
@@ -159,6 +209,12 @@ var snappyPool = sync.Pool{
 	},
 }
 
+var lz4Pool = sync.Pool{
+	New: func() any {
+		return lz4.NewReader(nil)
+	},
+}
+
 type closerFunc struct {
 	io.Reader
 	onClose func() error
@@ -6,6 +6,8 @@ import (
 	"fmt"
 	"hash/crc32"
 
+	"github.com/bits-and-blooms/bloom/v3"
+
 	"github.com/grafana/loki/v3/pkg/dataobj/internal/metadata/datasetmd"
 	"github.com/grafana/loki/v3/pkg/dataobj/internal/streamio"
 )
@@ -50,6 +52,10 @@ type pageBuilder struct {
 	// minValue and maxValue track the minimum and maximum values appended to the
 	// page. These are used to compute statistics for the page if requested.
 	minValue, maxValue Value
+
+	// bloomValues tracks the distinct values appended to the page, from which
+	// the bloom filter of the page is built if requested.
+	bloomValues map[string]struct{}
 }
 
 // newPageBuilder creates a new pageBuilder that stores a sequence of [Value]s.
@@ -69,7 +75,7 @@ func newPageBuilder(opts BuilderOptions) (*pageBuilder, error) {
 		return nil, fmt.Errorf("no encoder available for %s/%s", opts.Value, opts.Encoding)
 	}
 
-	return &pageBuilder{
+	b := &pageBuilder{
 		opts: opts,
 
 		presenceBuffer: presenceBuffer,
@@ -79,7 +85,18 @@ func newPageBuilder(opts BuilderOptions) (*pageBuilder, error) {
 
 		presenceEnc: presenceEnc,
 		valuesEnc:   valuesEnc,
-	}, nil
+	}
+
+	// Dictionary-encoded pages don't get a bloom filter, so values are only
+	// tracked for the bloom filter once a page falls back to plain encoding.
+	if enc, ok := valuesEnc.(*dictionaryEncoder); ok && opts.Statistics.StoreBloomFilters {
+		enc.onFallback = func(dict [][]byte) {
+			for _, entry := range dict {
+				b.addBloomValue(entry)
+			}
+		}
+	}
+	return b, nil
 }
 
 // Append appends value into the pageBuilder. Append returns true if the data
@@ -113,6 +130,13 @@ func (b *pageBuilder) Append(value Value) bool {
 		panic(fmt.Sprintf("pageBuilder.Append: encoding value: %v", err))
 	}
 
+	// Values are tracked for the bloom filter after encoding them, as encoding
+	// may make a dictionary-encoded page fall back to plain encoding.
+	if b.opts.Statistics.StoreBloomFilters && value.Type() == datasetmd.VALUE_TYPE_BYTE_ARRAY &&
+		b.valuesEnc.EncodingType() != datasetmd.ENCODING_TYPE_DICTIONARY {
+		b.addBloomValue(value.ByteArray())
+	}
+
 	b.rows++
 	b.values++
 	return true
@@ -171,6 +195,14 @@ func (b *pageBuilder) accumulateStatistics(value Value) {
 	}
 }
 
+// addBloomValue tracks value for the bloom filter of the page.
+func (b *pageBuilder) addBloomValue(value []byte) {
+	if b.bloomValues == nil {
+		b.bloomValues = make(map[string]struct{})
+	}
+	b.bloomValues[string(value)] = struct{}{}
+}
+
 func (b *pageBuilder) updateMinMax(value Value) {
 	// We'll init minValue/maxValue if this is our first non-NULL value (b.values == 0).
 	// This allows us to only avoid comparing against NULL values, which would lead to
@@ -207,7 +239,14 @@ func (b *pageBuilder) EstimatedSize() int {
 	// This estimate doesn't account for any values in encoders which haven't
 	// been flushed yet. However, encoder buffers are usually small enough that
 	// we wouldn't massively overshoot our estimate.
-	return b.presenceBuffer.Len() + b.valuesWriter.BytesWritten()
+	//
+	// The exception is dictionary encoding, which buffers all values of the
+	// page until flushing.
+	size := b.presenceBuffer.Len() + b.valuesWriter.BytesWritten()
+	if enc, ok := b.valuesEnc.(*dictionaryEncoder); ok {
+		size += enc.BufferedSize()
+	}
+	return size
 }
 
 // Rows returns the number of rows appended to the pageBuilder.
@@ -240,16 +279,29 @@ func (b *pageBuilder) Flush() (*MemPage, error) {
 		return nil, fmt.Errorf("flushing values writer: %w", err)
 	}
 
-	// The final data of our page is the combination of the presence bitmap and
-	// the values. To denote when one ends and the other begins, we prepend the
-	// data with the size of the presence bitmap as a uvarint. See the doc
-	// comment of [PageData] for more information.
+	// Dictionary-encoded pages may fall back to plain encoding, so we use the
+	// encoding reported by the encoder rather than the configured one.
+	encoding := b.valuesEnc.EncodingType()
+
+	// The dictionary of a dictionary-encoded page lists all of its values, so
+	// a bloom filter wouldn't allow skipping any more pages.
+	var bloomFilter []byte
+	if len(b.bloomValues) > 0 && encoding != datasetmd.ENCODING_TYPE_DICTIONARY {
+		bloomFilter = b.buildBloomFilter()
+	}
+
+	// The final data of our page is the combination of the presence bitmap,
+	// the values, and the optional bloom filter. To denote when the presence
+	// bitmap ends and the values begin, we prepend the data with the size of
+	// the presence bitmap as a uvarint. The size of the bloom filter is stored
+	// in the page info. See the doc comment of [PageData] for more
+	// information.
 	var (
 		headerSize   = streamio.UvarintSize(uint64(b.presenceBuffer.Len()))
 		presenceSize = b.presenceBuffer.Len()
 		valuesSize   = b.valuesBuffer.Len()
 
-		finalData = bytes.NewBuffer(make([]byte, 0, headerSize+presenceSize+valuesSize))
+		finalData = bytes.NewBuffer(make([]byte, 0, headerSize+presenceSize+valuesSize+len(bloomFilter)))
 	)
 
 	if err := streamio.WriteUvarint(finalData, uint64(b.presenceBuffer.Len())); err != nil {
@@ -258,20 +310,25 @@ func (b *pageBuilder) Flush() (*MemPage, error) {
 		return nil, fmt.Errorf("writing presence buffer: %w", err)
 	} else if _, err := b.valuesBuffer.WriteTo(finalData); err != nil {
 		return nil, fmt.Errorf("writing values buffer: %w", err)
+	} else if _, err := finalData.Write(bloomFilter); err != nil {
+		return nil, fmt.Errorf("writing bloom filter: %w", err)
 	}
 
 	checksum := crc32.Checksum(finalData.Bytes(), checksumTable)
 
 	page := MemPage{
 		Info: PageInfo{
-			UncompressedSize: headerSize + presenceSize + b.valuesWriter.BytesWritten(),
+			UncompressedSize: headerSize + presenceSize + b.valuesWriter.BytesWritten() + len(bloomFilter),
 			CompressedSize:   finalData.Len(),
 			CRC32:            checksum,
 			RowCount:         b.rows,
 			ValuesCount:      b.values,
 
-			Encoding: b.opts.Encoding,
+			Encoding: encoding,
 			Stats:    b.buildStats(),
+
+			BloomFilterSize:  len(bloomFilter),
+			BloomFilterCRC32: crc32.Checksum(bloomFilter, checksumTable),
 		},
 
 		Data: finalData.Bytes(),
@@ -304,6 +361,25 @@ func (b *pageBuilder) buildRangeStats(dst *datasetmd.Statistics) {
 	dst.MaxValue = maxValueBytes
 }
 
+// bloomFilterFalsePositiveRate is the target false positive rate of the bloom
+// filters of pages.
+const bloomFilterFalsePositiveRate = 1.0 / 128.0
+
+// buildBloomFilter returns the encoded bloom filter of the tracked values of
+// the page.
+func (b *pageBuilder) buildBloomFilter() []byte {
+	filter := bloom.NewWithEstimates(uint(len(b.bloomValues)), bloomFilterFalsePositiveRate)
+	for value := range b.bloomValues {
+		filter.AddString(value)
+	}
+
+	filterBytes, err := filter.MarshalBinary()
+	if err != nil {
+		panic(fmt.Sprintf("pageBuilder.buildBloomFilter: failed to marshal bloom filter: %s", err))
+	}
+	return filterBytes
+}
+
 // Reset resets the pageBuilder to a fresh state, allowing it to be reused.
 func (b *pageBuilder) Reset() {
 	b.presenceBuffer.Reset()
@@ -315,4 +391,5 @@ func (b *pageBuilder) Reset() {
 	b.values = 0
 	b.minValue = Value{}
 	b.maxValue = Value{}
+	clear(b.bloomValues)
 }
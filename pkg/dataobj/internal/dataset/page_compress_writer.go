@@ -7,6 +7,7 @@ import (
 
 	"github.com/golang/snappy"
 	"github.com/klauspost/compress/zstd"
+	"github.com/pierrec/lz4/v4"
 
 	"github.com/grafana/loki/v3/pkg/dataobj/internal/metadata/datasetmd"
 	"github.com/grafana/loki/v3/pkg/dataobj/internal/streamio"
@@ -93,6 +94,13 @@ func (c *compressWriter) Reset(w io.Writer) {
 			}
 			compressedWriter = zw
 
+		case datasetmd.COMPRESSION_TYPE_LZ4:
+			zw := lz4.NewWriter(w)
+			if err := zw.Apply(c.opts.LZ4...); err != nil {
+				panic(fmt.Sprintf("compressWriter.Reset: creating lz4 writer: %v", err))
+			}
+			compressedWriter = zw
+
 		default:
 			panic(fmt.Sprintf("compressWriter.Reset: unknown compression type %v", c.compression))
 		}
@@ -1,9 +1,11 @@
 package dataset
 
 import (
+	"bufio"
 	"compress/gzip"
 	"context"
 	"errors"
+	"fmt"
 	"io"
 	"math/rand"
 	"os"
@@ -94,6 +96,13 @@ func benchmarkPageDecodeParallel(b *testing.B, workers int) {
 
 func logsTestPage(t testing.TB) *MemPage {
 	t.Helper()
+	return logsTestPageWithCompression(t, datasetmd.COMPRESSION_TYPE_ZSTD, CompressionOptions{})
+}
+
+// logsTestPageWithCompression builds a page of the access logs in testdata,
+// compressed with the given compression type and options.
+func logsTestPageWithCompression(t testing.TB, compression datasetmd.CompressionType, compressionOpts CompressionOptions) *MemPage {
+	t.Helper()
 
 	f, err := os.Open("testdata/access_logs.gz")
 	require.NoError(t, err)
@@ -110,8 +119,10 @@ func logsTestPage(t testing.TB) *MemPage {
 	opts := BuilderOptions{
 		PageSizeHint: sb.Len() * 2,
 		Value:        datasetmd.VALUE_TYPE_BYTE_ARRAY,
-		Compression:  datasetmd.COMPRESSION_TYPE_ZSTD,
+		Compression:  compression,
 		Encoding:     datasetmd.ENCODING_TYPE_PLAIN,
+
+		CompressionOptions: compressionOpts,
 	}
 	builder, err := newPageBuilder(opts)
 	require.NoError(t, err)
@@ -185,6 +196,144 @@ func Test_pageBuilder_WriteRead(t *testing.T) {
 	require.Equal(t, in, actual)
 }
 
+func Test_pageBuilder_Dictionary(t *testing.T) {
+	levels := []string{"debug", "info", "warn", "error"}
+
+	var in []string
+	for i := range 1000 {
+		if i%10 == 0 {
+			in = append(in, "") // NULL
+			continue
+		}
+		in = append(in, levels[i%len(levels)])
+	}
+
+	opts := BuilderOptions{
+		PageSizeHint: 1 << 20,
+		Value:        datasetmd.VALUE_TYPE_BYTE_ARRAY,
+		Compression:  datasetmd.COMPRESSION_TYPE_ZSTD,
+		Encoding:     datasetmd.ENCODING_TYPE_DICTIONARY,
+	}
+	b, err := newPageBuilder(opts)
+	require.NoError(t, err)
+
+	for _, s := range in {
+		require.True(t, b.Append(ByteArrayValue([]byte(s))))
+	}
+	require.Positive(t, b.EstimatedSize(), "estimated size should include buffered values")
+
+	page, err := b.Flush()
+	require.NoError(t, err)
+	require.Equal(t, datasetmd.ENCODING_TYPE_DICTIONARY, page.Info.Encoding)
+	require.ElementsMatch(t, levels, func() []string {
+		_, values, err := page.reader(opts.Compression)
+		require.NoError(t, err)
+		defer values.Close()
+
+		dict, err := readDictionary(bufio.NewReader(values), nil)
+		require.NoError(t, err)
+
+		var entries []string
+		for _, entry := range dict {
+			entries = append(entries, string(entry))
+		}
+		return entries
+	}(), "dictionary should be stored at the start of the values")
+
+	var actual []string
+
+	r := newPageReader(page, opts.Value, opts.Compression)
+	values := make([]Value, 64)
+	for {
+		n, err := r.Read(context.Background(), values)
+		for _, val := range values[:n] {
+			if val.IsNil() || val.IsZero() {
+				actual = append(actual, "")
+			} else {
+				actual = append(actual, string(val.ByteArray()))
+			}
+		}
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		require.NoError(t, err)
+	}
+	require.Equal(t, in, actual)
+}
+
+func Test_pageBuilder_BloomFilter(t *testing.T) {
+	opts := BuilderOptions{
+		PageSizeHint: 1 << 20,
+		Value:        datasetmd.VALUE_TYPE_BYTE_ARRAY,
+		Compression:  datasetmd.COMPRESSION_TYPE_NONE,
+		Encoding:     datasetmd.ENCODING_TYPE_DICTIONARY,
+		Statistics:   StatisticsOptions{StoreBloomFilters: true},
+	}
+
+	for _, count := range []int{100, 2 * maxDictionaryEntries} {
+		// Unique values make the page fall back to plain encoding, either when
+		// flushing or once the dictionary is full.
+		t.Run(fmt.Sprintf("plain page with %d values", count), func(t *testing.T) {
+			b, err := newPageBuilder(opts)
+			require.NoError(t, err)
+
+			var in []string
+			for i := range count {
+				in = append(in, fmt.Sprintf("trace-%d", i))
+				require.True(t, b.Append(ByteArrayValue([]byte(in[i]))))
+			}
+			require.True(t, b.Append(ByteArrayValue(nil))) // NULL
+
+			page, err := b.Flush()
+			require.NoError(t, err)
+			require.Equal(t, datasetmd.ENCODING_TYPE_PLAIN, page.Info.Encoding)
+			require.Nil(t, page.Info.Stats, "range stats should not be stored")
+			require.Positive(t, page.Info.BloomFilterSize)
+
+			data, err := bloomFilterData(&page.Info, page.Data)
+			require.NoError(t, err)
+			filter, err := decodeBloomFilter(&page.Info, data)
+			require.NoError(t, err)
+			for _, v := range in {
+				require.True(t, filter.TestString(v))
+			}
+
+			// The bloom filter at the end of the page data must not be read as
+			// values.
+			var actual []string
+			r := newPageReader(page, opts.Value, opts.Compression)
+			values := make([]Value, 64)
+			for {
+				n, err := r.Read(context.Background(), values)
+				for _, val := range values[:n] {
+					if !val.IsNil() {
+						actual = append(actual, string(val.ByteArray()))
+					}
+				}
+				if errors.Is(err, io.EOF) {
+					break
+				}
+				require.NoError(t, err)
+			}
+			require.Equal(t, in, actual)
+		})
+	}
+
+	t.Run("dictionary page", func(t *testing.T) {
+		b, err := newPageBuilder(opts)
+		require.NoError(t, err)
+
+		for i := range 100 {
+			require.True(t, b.Append(ByteArrayValue(fmt.Appendf(nil, "level-%d", i%4))))
+		}
+
+		page, err := b.Flush()
+		require.NoError(t, err)
+		require.Equal(t, datasetmd.ENCODING_TYPE_DICTIONARY, page.Info.Encoding)
+		require.Zero(t, page.Info.BloomFilterSize, "dictionary pages should not store bloom filters")
+	})
+}
+
 func Test_pageBuilder_Fill(t *testing.T) {
 	opts := BuilderOptions{
 		PageSizeHint: 1_500_000,
@@ -1,12 +1,16 @@
 package dataset
 
 import (
+	"bufio"
+	"bytes"
 	"context"
 	"errors"
 	"fmt"
 	"io"
 	"iter"
 
+	"github.com/bits-and-blooms/bloom/v3"
+
 	"github.com/grafana/loki/v3/pkg/dataobj/internal/metadata/datasetmd"
 	"github.com/grafana/loki/v3/pkg/dataobj/internal/util/bitmask"
 	"github.com/grafana/loki/v3/pkg/dataobj/internal/util/sliceclear"
@@ -39,6 +43,28 @@ type ReaderOptions struct {
 	// of when they're needed. If TargetCacheSize is 0, only immediately required
 	// pages are cached.
 	TargetCacheSize int
+
+	// StartRow and EndRow limit the rows of the Dataset that are read to the
+	// half-open range [StartRow, EndRow). All rows are read if EndRow is 0.
+	//
+	// Disjoint row ranges of the same Dataset can be read concurrently with
+	// separate Readers.
+	StartRow, EndRow uint64
+
+	// RowRanges limits the rows of the Dataset that are read to the union of
+	// the given ranges, in addition to StartRow and EndRow. All rows are read
+	// if RowRanges is nil, while no rows are read if RowRanges is empty but
+	// non-nil.
+	//
+	// RowRanges is typically derived from an index over the Dataset, allowing
+	// pages that can't hold matching rows to be skipped without being
+	// downloaded.
+	RowRanges []RowRange
+}
+
+// RowRange is a half-open range of rows [Start, End) of a [Dataset].
+type RowRange struct {
+	Start, End uint64
 }
 
 // A Reader reads [Row]s from a [Dataset].
@@ -53,6 +79,8 @@ type Reader struct {
 	row    int64             // The current row being read.
 	inner  *basicReader      // Underlying reader that reads from columns.
 	ranges rowRanges         // Valid ranges to read across the entire dataset.
+
+	pagesPruned int // Number of pages excluded by the statistics, dictionaries or bloom filters of predicate columns.
 }
 
 // NewReader creates a new Reader from the provided options.
@@ -397,6 +425,14 @@ func buildMask(full rowRange, s []Row) iter.Seq[rowRange] {
 	}
 }
 
+// PagesPruned returns the number of pages of predicate columns that have been
+// excluded from reading because their statistics, dictionaries or bloom
+// filters ruled out a match. A page
+// is counted once for each predicate that excludes it.
+func (r *Reader) PagesPruned() int {
+	return r.pagesPruned
+}
+
 // Close closes the Reader. Closed Readers can be reused by calling
 // [Reader.Reset].
 func (r *Reader) Close() error {
@@ -424,6 +460,7 @@ func (r *Reader) Reset(opts ReaderOptions) {
 	}
 
 	r.row = 0
+	r.pagesPruned = 0
 	r.ranges = sliceclear.Clear(r.ranges)
 	r.primaryColumnIndexes = sliceclear.Clear(r.primaryColumnIndexes)
 	r.ready = false
@@ -544,6 +581,19 @@ func (r *Reader) initDownloader(ctx context.Context) error {
 		}
 	}
 
+	if r.opts.EndRow > 0 {
+		ranges = intersectRanges(nil, ranges, rowRanges{{Start: r.opts.StartRow, End: r.opts.EndRow - 1}})
+	}
+	if r.opts.RowRanges != nil {
+		var allowed rowRanges
+		for _, rr := range r.opts.RowRanges {
+			if rr.End > rr.Start {
+				allowed.Add(rowRange{Start: rr.Start, End: rr.End - 1})
+			}
+		}
+		ranges = intersectRanges(nil, ranges, allowed)
+	}
+
 	r.dl.SetDatasetRanges(ranges)
 	r.ranges = ranges
 
@@ -551,6 +601,9 @@ func (r *Reader) initDownloader(ctx context.Context) error {
 	for _, column := range r.dl.AllColumns() {
 		rowsCount = max(rowsCount, uint64(column.ColumnInfo().RowsCount))
 	}
+	if r.opts.EndRow > 0 {
+		rowsCount = min(rowsCount, r.opts.EndRow) - min(rowsCount, r.opts.StartRow)
+	}
 	statistics := stats.FromContext(ctx)
 	statistics.AddTotalRowsAvailable(int64(rowsCount))
 
@@ -758,6 +811,38 @@ func (r *Reader) buildColumnPredicateRanges(ctx context.Context, c Column, p Pre
 
 	var ranges rowRanges
 
+	// Bloom filters are read on their own, in batches bounded by the target
+	// cache size, to rule out pages before anything else is downloaded.
+	// Dictionaries are stored in the data of pages, so the remaining pages
+	// with a dictionary are downloaded in a single batch so that they can rule
+	// out rows of pages, even if the pages don't have range statistics.
+	indexable := isIndexablePredicate(p)
+	var prunedPages map[*PageInfo]struct{}
+	if indexable {
+		err := r.dl.ReadBloomFilters(ctx, c, func(page Page, filter *bloom.BloomFilter) error {
+			if !bloomFilterMatches(filter, p) {
+				if prunedPages == nil {
+					prunedPages = make(map[*PageInfo]struct{})
+				}
+				prunedPages[page.PageInfo()] = struct{}{}
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("reading page bloom filters: %w", err)
+		}
+
+		err = r.dl.DownloadPages(ctx, c, func(info *PageInfo) bool {
+			if _, pruned := prunedPages[info]; pruned {
+				return false
+			}
+			return info.Encoding == datasetmd.ENCODING_TYPE_DICTIONARY && info.ValuesCount > 0
+		})
+		if err != nil {
+			return nil, fmt.Errorf("downloading indexed pages: %w", err)
+		}
+	}
+
 	var (
 		pageStart    int
 		lastPageSize int
@@ -778,6 +863,24 @@ func (r *Reader) buildColumnPredicateRanges(ctx context.Context, c Column, p Pre
 			End:   uint64(pageStart + pageInfo.RowCount - 1),
 		}
 
+		if _, pruned := prunedPages[pageInfo]; pruned {
+			r.pagesPruned++
+			continue
+		}
+
+		if indexable && pageInfo.Encoding == datasetmd.ENCODING_TYPE_DICTIONARY {
+			matching, err := dictionaryPageRanges(ctx, page, c.ColumnInfo().Compression, pageRange, p)
+			if err != nil {
+				return nil, fmt.Errorf("reading page dictionary: %w", err)
+			} else if len(matching) == 0 {
+				r.pagesPruned++
+			}
+			for _, rr := range matching {
+				ranges.Add(rr)
+			}
+			continue
+		}
+
 		minValue, maxValue, err := readMinMax(pageInfo.Stats)
 		if err != nil {
 			return nil, fmt.Errorf("failed to read page stats: %w", err)
@@ -810,12 +913,167 @@ func (r *Reader) buildColumnPredicateRanges(ctx context.Context, c Column, p Pre
 
 		if include {
 			ranges.Add(pageRange)
+		} else {
+			r.pagesPruned++
 		}
 	}
 
 	return ranges, nil
 }
 
+// isIndexablePredicate reports whether p can be checked against the
+// dictionary or bloom filter of a page: an [EqualPredicate] or [InPredicate]
+// for values which can be entries of a dictionary or bloom filter.
+//
+// NULLs are not part of dictionaries or bloom filters, so predicates for NULL
+// values can't be checked.
+func isIndexablePredicate(p Predicate) bool {
+	switch p := p.(type) {
+	case EqualPredicate:
+		return isIndexableValue(p.Value)
+	case InPredicate:
+		for v := range p.Values.Iter() {
+			if !isIndexableValue(v) {
+				return false
+			}
+		}
+		return true
+	default:
+		return false
+	}
+}
+
+// dictionaryPageRanges returns the ranges of rows of a dictionary-encoded
+// page whose values match p, which must be a predicate for which
+// [isIndexablePredicate] returns true. pageRange is the range of rows of the
+// page within its column.
+//
+// p is resolved to codes of the dictionary of the page once, after which the
+// codes of the page are compared without decoding their values. An empty set
+// of ranges is returned if no entry of the dictionary matches p.
+func dictionaryPageRanges(ctx context.Context, page Page, compression datasetmd.CompressionType, pageRange rowRange, p Predicate) (rowRanges, error) {
+	info := page.PageInfo()
+	if info.ValuesCount == 0 {
+		return nil, nil // The page only holds NULLs.
+	}
+
+	data, err := page.ReadPage(ctx)
+	if err != nil {
+		return nil, err
+	}
+	memPage := &MemPage{Info: *info, Data: data}
+
+	presenceReader, valuesReader, err := memPage.reader(compression)
+	if err != nil {
+		return nil, err
+	}
+	defer valuesReader.Close()
+
+	values := bufio.NewReader(valuesReader)
+	dict, err := readDictionary(values, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	matches := make([]bool, len(dict)) // Whether the entry of each code matches p.
+	var anyMatches bool
+	for code, entry := range dict {
+		switch p := p.(type) {
+		case EqualPredicate:
+			matches[code] = bytes.Equal(entry, p.Value.ByteArray())
+		case InPredicate:
+			matches[code] = p.Values.Contains(ByteArrayValue(entry))
+		}
+		anyMatches = anyMatches || matches[code]
+	}
+	if !anyMatches {
+		return nil, nil
+	}
+
+	var (
+		presenceDec = newBitmapDecoder(bufio.NewReader(presenceReader))
+		codesDec    = newBitmapDecoder(values)
+
+		presence = make([]Value, 256)
+		codes    = make([]Value, len(presence))
+
+		ranges     rowRanges
+		row        = pageRange.Start
+		matchStart uint64
+		inMatch    bool
+	)
+
+	for row <= pageRange.End {
+		n, err := presenceDec.Decode(presence[:min(len(presence), int(pageRange.End-row+1))])
+		if errors.Is(err, io.EOF) && n == 0 {
+			break
+		} else if err != nil && !errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("decoding presence: %w", err)
+		}
+
+		var present int
+		for _, v := range presence[:n] {
+			if v.Uint64() == 1 {
+				present++
+			}
+		}
+		if present > 0 {
+			if count, err := codesDec.Decode(codes[:present]); err != nil && !errors.Is(err, io.EOF) {
+				return nil, fmt.Errorf("decoding codes: %w", err)
+			} else if count != present {
+				return nil, fmt.Errorf("unexpected number of codes: %d", count)
+			}
+		}
+
+		var codeIndex int
+		for _, v := range presence[:n] {
+			var match bool
+			if v.Uint64() == 1 {
+				code := codes[codeIndex].Uint64()
+				codeIndex++
+				match = code < uint64(len(matches)) && matches[code]
+			}
+
+			switch {
+			case match && !inMatch:
+				matchStart, inMatch = row, true
+			case !match && inMatch:
+				ranges.Add(rowRange{Start: matchStart, End: row - 1})
+				inMatch = false
+			}
+			row++
+		}
+	}
+	if inMatch {
+		ranges.Add(rowRange{Start: matchStart, End: row - 1})
+	}
+	return ranges, nil
+}
+
+// bloomFilterMatches reports whether filter may contain a value matching p,
+// which must be a predicate for which [isIndexablePredicate] returns true.
+func bloomFilterMatches(filter *bloom.BloomFilter, p Predicate) bool {
+	switch p := p.(type) {
+	case EqualPredicate:
+		return filter.Test(p.Value.ByteArray())
+	case InPredicate:
+		for v := range p.Values.Iter() {
+			if filter.Test(v.ByteArray()) {
+				return true
+			}
+		}
+		return false
+	default:
+		return true
+	}
+}
+
+// isIndexableValue reports whether v can be an entry of a dictionary or a
+// bloom filter.
+func isIndexableValue(v Value) bool {
+	return !v.IsNil() && !v.IsZero() && v.Type() == datasetmd.VALUE_TYPE_BYTE_ARRAY
+}
+
 // readMinMax reads the minimum and maximum values from the provided
 // statistics. If either minValue or maxValue is NULL, the value is not present
 // in the statistics.
@@ -3,6 +3,8 @@ package dataset
 import (
 	"context"
 
+	"github.com/bits-and-blooms/bloom/v3"
+
 	"github.com/grafana/loki/v3/pkg/dataobj/internal/result"
 	"github.com/grafana/loki/v3/pkg/dataobj/internal/util/sliceclear"
 	"github.com/grafana/loki/v3/pkg/logqlmodel/stats"
@@ -216,6 +218,153 @@ func (dl *readerDownloader) downloadBatch(ctx context.Context, requestor *reader
 	return nil
 }
 
+// DownloadPages downloads the uncached pages of col for which include returns
+// true in a single batch, ignoring the target cache size. col must be a
+// column returned by [readerDownloader.AllColumns].
+//
+// DownloadPages is used for pages which must be read to build the dataset
+// ranges, before a read range is set.
+func (dl *readerDownloader) DownloadPages(ctx context.Context, col Column, include func(*PageInfo) bool) error {
+	var batch []*readerPage
+	for result := range col.ListPages(ctx) {
+		page, err := result.Value()
+		if err != nil {
+			return err
+		}
+		if page := page.(*readerPage); page.data == nil && include(page.PageInfo()) {
+			batch = append(batch, page)
+		}
+	}
+	if len(batch) == 0 {
+		return nil
+	}
+
+	var (
+		innerPages = make([]Page, len(batch))
+		batchSize  int
+	)
+	for i, page := range batch {
+		innerPages[i] = page.inner
+		batchSize += page.PageInfo().CompressedSize
+	}
+
+	var i int
+	for result := range dl.inner.ReadPages(ctx, innerPages) {
+		data, err := result.Value()
+		if err != nil {
+			return err
+		}
+
+		batch[i].data = data
+		i++
+	}
+
+	statistics := stats.FromContext(ctx)
+	statistics.AddPageBatches(1)
+	statistics.AddPagesDownloaded(int64(len(batch)))
+	statistics.AddPagesDownloadedBytes(int64(batchSize))
+	return nil
+}
+
+// ReadBloomFilters reads the bloom filters of the pages of col which have
+// one, calling fn with each page and its decoded filter in page order. col
+// must be a column returned by [readerDownloader.AllColumns].
+//
+// If the inner dataset is a [BloomFilterReader], only the bloom filters are
+// read; otherwise, the data of the pages is read and the filters are taken
+// from its end. Pages are read in batches bounded by the target cache size,
+// and the data read isn't cached.
+func (dl *readerDownloader) ReadBloomFilters(ctx context.Context, col Column, fn func(Page, *bloom.BloomFilter) error) error {
+	filterReader, readFilters := dl.inner.(BloomFilterReader)
+
+	var (
+		batch     []*readerPage
+		batchSize int
+	)
+
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+
+		innerPages := make([]Page, len(batch))
+		for i, page := range batch {
+			innerPages[i] = page.inner
+		}
+
+		var i int
+		if readFilters {
+			for result := range filterReader.ReadBloomFilters(ctx, innerPages) {
+				data, err := result.Value()
+				if err != nil {
+					return err
+				} else if err := dl.decodeBloomFilter(batch[i], data, fn); err != nil {
+					return err
+				}
+				i++
+			}
+		} else {
+			for result := range dl.inner.ReadPages(ctx, innerPages) {
+				pageData, err := result.Value()
+				if err != nil {
+					return err
+				}
+				data, err := bloomFilterData(batch[i].PageInfo(), pageData)
+				if err != nil {
+					return err
+				} else if err := dl.decodeBloomFilter(batch[i], data, fn); err != nil {
+					return err
+				}
+				i++
+			}
+		}
+
+		statistics := stats.FromContext(ctx)
+		statistics.AddPageBatches(1)
+		statistics.AddPagesDownloadedBytes(int64(batchSize))
+
+		batch, batchSize = batch[:0], 0
+		return nil
+	}
+
+	for result := range col.ListPages(ctx) {
+		page, err := result.Value()
+		if err != nil {
+			return err
+		}
+
+		info := page.PageInfo()
+		if info.BloomFilterSize == 0 {
+			continue
+		}
+
+		size := info.CompressedSize
+		if readFilters {
+			size = info.BloomFilterSize
+		}
+
+		// Always permit at least one page per batch, even if it exceeds the
+		// target size on its own.
+		if len(batch) > 0 && batchSize+size > dl.targetCacheSize {
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+		batch = append(batch, page.(*readerPage))
+		batchSize += size
+	}
+
+	return flush()
+}
+
+func (dl *readerDownloader) decodeBloomFilter(page *readerPage, data []byte, fn func(Page, *bloom.BloomFilter) error) error {
+	filter, err := decodeBloomFilter(page.PageInfo(), data)
+	if err != nil {
+		return err
+	}
+	return fn(page, filter)
+}
+
 func (dl *readerDownloader) buildDownloadBatch(ctx context.Context, requestor *readerPage) ([]*readerPage, error) {
 	var pageBatch []*readerPage
 
@@ -11,6 +11,7 @@ import (
 	"strconv"
 	"testing"
 
+	"github.com/bits-and-blooms/bloom/v3"
 	"github.com/dustin/go-humanize"
 	"github.com/stretchr/testify/require"
 
@@ -94,6 +95,92 @@ func Test_Reader_ReadWithPageFiltering(t *testing.T) {
 	require.Equal(t, expected, convertToTestPersons(actualRows))
 }
 
+func Test_Reader_ReadRowRange(t *testing.T) {
+	dset, columns := buildTestDataset(t)
+
+	readRange := func(t *testing.T, start, end uint64, predicates []Predicate) []testPerson {
+		r := NewReader(ReaderOptions{
+			Dataset:    dset,
+			Columns:    columns,
+			Predicates: predicates,
+			StartRow:   start,
+			EndRow:     end,
+		})
+		defer r.Close()
+
+		rows, err := readDataset(r, 3)
+		require.NoError(t, err)
+		return convertToTestPersons(rows)
+	}
+
+	t.Run("single range", func(t *testing.T) {
+		require.Equal(t, basicReaderTestData[2:7], readRange(t, 2, 7, nil))
+	})
+
+	t.Run("range beyond dataset", func(t *testing.T) {
+		n := uint64(len(basicReaderTestData))
+		require.Equal(t, basicReaderTestData[n-2:], readRange(t, n-2, n+10, nil))
+		require.Empty(t, readRange(t, n, n+10, nil))
+	})
+
+	t.Run("disjoint ranges with predicate", func(t *testing.T) {
+		predicates := []Predicate{
+			GreaterThanPredicate{
+				Column: columns[3], // birth_year column
+				Value:  Int64Value(1985),
+			},
+		}
+
+		var expected []testPerson
+		for _, p := range basicReaderTestData {
+			if p.birthYear > 1985 {
+				expected = append(expected, p)
+			}
+		}
+
+		var actual []testPerson
+		for start := uint64(0); start < uint64(len(basicReaderTestData)); start += 4 {
+			actual = append(actual, readRange(t, start, start+4, predicates)...)
+		}
+		require.Equal(t, expected, actual)
+	})
+}
+
+func Test_Reader_ReadRowRanges(t *testing.T) {
+	dset, columns := buildTestDataset(t)
+
+	readRanges := func(t *testing.T, ranges []RowRange) []testPerson {
+		r := NewReader(ReaderOptions{
+			Dataset:   dset,
+			Columns:   columns,
+			RowRanges: ranges,
+		})
+		defer r.Close()
+
+		rows, err := readDataset(r, 3)
+		require.NoError(t, err)
+		return convertToTestPersons(rows)
+	}
+
+	t.Run("nil ranges", func(t *testing.T) {
+		require.Equal(t, basicReaderTestData, readRanges(t, nil))
+	})
+
+	t.Run("empty ranges", func(t *testing.T) {
+		require.Empty(t, readRanges(t, []RowRange{}))
+	})
+
+	t.Run("disjoint ranges", func(t *testing.T) {
+		var expected []testPerson
+		expected = append(expected, basicReaderTestData[1:3]...)
+		expected = append(expected, basicReaderTestData[5:6]...)
+		expected = append(expected, basicReaderTestData[8:]...)
+
+		n := uint64(len(basicReaderTestData))
+		require.Equal(t, expected, readRanges(t, []RowRange{{1, 3}, {5, 6}, {8, n + 10}}))
+	})
+}
+
 func Test_Reader_ReadWithPredicate_NoSecondary(t *testing.T) {
 	dset, columns := buildTestDataset(t)
 
@@ -389,6 +476,359 @@ func Test_BuildPredicateRanges(t *testing.T) {
 	}
 }
 
+// Test_Reader_ReadWithDictionaryPruning tests that a Reader skips
+// dictionary-encoded pages without a matching dictionary entry.
+func Test_Reader_ReadWithDictionaryPruning(t *testing.T) {
+	levels := []string{"debug", "info", "warn", "error"}
+
+	// Each page of the level column only holds a single level, and the column
+	// has no range statistics.
+	column := &MemColumn{
+		Info: ColumnInfo{
+			Name:        "level",
+			Type:        datasetmd.VALUE_TYPE_BYTE_ARRAY,
+			Compression: datasetmd.COMPRESSION_TYPE_NONE,
+		},
+	}
+	for _, level := range levels {
+		b, err := newPageBuilder(BuilderOptions{
+			PageSizeHint: 1 << 20,
+			Value:        datasetmd.VALUE_TYPE_BYTE_ARRAY,
+			Compression:  datasetmd.COMPRESSION_TYPE_NONE,
+			Encoding:     datasetmd.ENCODING_TYPE_DICTIONARY,
+		})
+		require.NoError(t, err)
+		for range 100 {
+			require.True(t, b.Append(ByteArrayValue([]byte(level))))
+		}
+
+		page, err := b.Flush()
+		require.NoError(t, err)
+		require.Equal(t, datasetmd.ENCODING_TYPE_DICTIONARY, page.Info.Encoding)
+
+		column.Pages = append(column.Pages, page)
+		column.Info.RowsCount += page.Info.RowCount
+		column.Info.ValuesCount += page.Info.ValuesCount
+	}
+
+	dset := FromMemory([]*MemColumn{column})
+	columns, err := result.Collect(dset.ListColumns(context.Background()))
+	require.NoError(t, err)
+
+	tt := []struct {
+		name      string
+		predicate Predicate
+		expected  []string
+		pruned    int
+	}{
+		{
+			name:      "equal predicate",
+			predicate: EqualPredicate{Column: columns[0], Value: ByteArrayValue([]byte("warn"))},
+			expected:  []string{"warn"},
+			pruned:    3,
+		},
+		{
+			name: "in predicate",
+			predicate: InPredicate{Column: columns[0], Values: NewByteArrayValueSet([]Value{
+				ByteArrayValue([]byte("debug")),
+				ByteArrayValue([]byte("error")),
+				ByteArrayValue([]byte("fatal")),
+			})},
+			expected: []string{"debug", "error"},
+			pruned:   2,
+		},
+		{
+			name:      "no matching entry",
+			predicate: EqualPredicate{Column: columns[0], Value: ByteArrayValue([]byte("fatal"))},
+			pruned:    4,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			r := NewReader(ReaderOptions{
+				Dataset:    dset,
+				Columns:    columns,
+				Predicates: []Predicate{tc.predicate},
+			})
+			defer r.Close()
+
+			rows, err := readDataset(r, 64)
+			require.NoError(t, err)
+
+			var expected []string
+			for _, level := range tc.expected {
+				for range 100 {
+					expected = append(expected, level)
+				}
+			}
+
+			var actual []string
+			for _, row := range rows {
+				actual = append(actual, string(row.Values[0].ByteArray()))
+			}
+			require.Equal(t, expected, actual)
+			require.Equal(t, tc.pruned, r.PagesPruned())
+		})
+	}
+}
+
+// Test_Reader_buildPredicateRanges_Dictionary tests that the codes of a
+// dictionary-encoded page narrow the predicate ranges down to the matching
+// rows of the page.
+func Test_Reader_buildPredicateRanges_Dictionary(t *testing.T) {
+	levels := []string{"debug", "info", "warn", "error"}
+
+	b, err := newPageBuilder(BuilderOptions{
+		PageSizeHint: 1 << 20,
+		Value:        datasetmd.VALUE_TYPE_BYTE_ARRAY,
+		Compression:  datasetmd.COMPRESSION_TYPE_ZSTD,
+		Encoding:     datasetmd.ENCODING_TYPE_DICTIONARY,
+	})
+	require.NoError(t, err)
+	for i := range 80 {
+		if i%10 == 9 {
+			require.True(t, b.Append(Value{})) // NULL
+			continue
+		}
+		require.True(t, b.Append(ByteArrayValue([]byte(levels[i/10%len(levels)]))))
+	}
+	page, err := b.Flush()
+	require.NoError(t, err)
+	require.Equal(t, datasetmd.ENCODING_TYPE_DICTIONARY, page.Info.Encoding)
+
+	dset := FromMemory([]*MemColumn{{
+		Info: ColumnInfo{
+			Name:        "level",
+			Type:        datasetmd.VALUE_TYPE_BYTE_ARRAY,
+			Compression: datasetmd.COMPRESSION_TYPE_ZSTD,
+			RowsCount:   page.Info.RowCount,
+			ValuesCount: page.Info.ValuesCount,
+		},
+		Pages: []*MemPage{page},
+	}})
+	columns, err := result.Collect(dset.ListColumns(context.Background()))
+	require.NoError(t, err)
+
+	tt := []struct {
+		name      string
+		predicate Predicate
+		want      rowRanges
+	}{
+		{
+			name:      "equal predicate",
+			predicate: EqualPredicate{Column: columns[0], Value: ByteArrayValue([]byte("info"))},
+			want:      rowRanges{{Start: 10, End: 18}, {Start: 50, End: 58}},
+		},
+		{
+			name: "in predicate",
+			predicate: InPredicate{Column: columns[0], Values: NewByteArrayValueSet([]Value{
+				ByteArrayValue([]byte("debug")),
+				ByteArrayValue([]byte("info")),
+			})},
+			want: rowRanges{{Start: 0, End: 8}, {Start: 10, End: 18}, {Start: 40, End: 48}, {Start: 50, End: 58}},
+		},
+		{
+			name:      "no matching entry",
+			predicate: EqualPredicate{Column: columns[0], Value: ByteArrayValue([]byte("fatal"))},
+			want:      nil,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			r := NewReader(ReaderOptions{
+				Dataset:    dset,
+				Columns:    columns,
+				Predicates: []Predicate{tc.predicate},
+			})
+			defer r.Close()
+
+			ctx := context.Background()
+			require.NoError(t, r.initDownloader(ctx))
+
+			got, err := r.buildPredicateRanges(ctx, tc.predicate)
+			require.NoError(t, err)
+			require.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func Test_readerDownloader_ReadBloomFilters(t *testing.T) {
+	column := &MemColumn{
+		Info: ColumnInfo{Name: "trace_id", Type: datasetmd.VALUE_TYPE_BYTE_ARRAY},
+	}
+	for page := range 4 {
+		b, err := newPageBuilder(BuilderOptions{
+			PageSizeHint: 1 << 20,
+			Value:        datasetmd.VALUE_TYPE_BYTE_ARRAY,
+			Compression:  datasetmd.COMPRESSION_TYPE_NONE,
+			Encoding:     datasetmd.ENCODING_TYPE_PLAIN,
+			Statistics:   StatisticsOptions{StoreBloomFilters: true},
+		})
+		require.NoError(t, err)
+		for i := range 100 {
+			require.True(t, b.Append(ByteArrayValue(fmt.Appendf(nil, "trace-%d-%d", page, i))))
+		}
+
+		page, err := b.Flush()
+		require.NoError(t, err)
+		column.Pages = append(column.Pages, page)
+		column.Info.RowsCount += page.Info.RowCount
+	}
+	filterSize := column.Pages[0].Info.BloomFilterSize
+
+	readFilters := func(t *testing.T, dset Dataset) []string {
+		columns, err := result.Collect(dset.ListColumns(context.Background()))
+		require.NoError(t, err)
+
+		// The target cache size permits two bloom filters per batch.
+		dl := newReaderDownloader(dset, 2*filterSize)
+		dl.AddColumn(columns[0], true)
+
+		var matches []string
+		err = dl.ReadBloomFilters(context.Background(), dl.AllColumns()[0], func(page Page, filter *bloom.BloomFilter) error {
+			require.Nil(t, page.(*readerPage).data, "page data should not be cached")
+			for p := range 4 {
+				if value := fmt.Sprintf("trace-%d-42", p); filter.TestString(value) {
+					matches = append(matches, value)
+				}
+			}
+			return nil
+		})
+		require.NoError(t, err)
+		return matches
+	}
+
+	t.Run("bloom filter reader", func(t *testing.T) {
+		dset := &bloomFilterDataset{Dataset: FromMemory([]*MemColumn{column})}
+		require.Equal(t, []string{"trace-0-42", "trace-1-42", "trace-2-42", "trace-3-42"}, readFilters(t, dset))
+		require.Equal(t, []int{2, 2}, dset.batches, "bloom filters should be read in batches bounded by the cache size")
+	})
+
+	t.Run("page data fallback", func(t *testing.T) {
+		dset := FromMemory([]*MemColumn{column})
+		require.Equal(t, []string{"trace-0-42", "trace-1-42", "trace-2-42", "trace-3-42"}, readFilters(t, dset))
+	})
+}
+
+// bloomFilterDataset is a [BloomFilterReader] which fails to read pages, to
+// check that bloom filters are read on their own.
+type bloomFilterDataset struct {
+	Dataset
+	batches []int // Number of bloom filters read per call to ReadBloomFilters.
+}
+
+func (d *bloomFilterDataset) ReadPages(_ context.Context, _ []Page) result.Seq[PageData] {
+	return result.Iter(func(_ func(PageData) bool) error {
+		return errors.New("unexpected page read")
+	})
+}
+
+func (d *bloomFilterDataset) ReadBloomFilters(ctx context.Context, pages []Page) result.Seq[[]byte] {
+	d.batches = append(d.batches, len(pages))
+
+	return result.Iter(func(yield func([]byte) bool) error {
+		for _, page := range pages {
+			data, err := page.ReadPage(ctx)
+			if err != nil {
+				return err
+			}
+			filter, err := bloomFilterData(page.PageInfo(), data)
+			if err != nil || !yield(filter) {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func Test_Reader_ReadWithBloomFilterPruning(t *testing.T) {
+	// Each page of the trace_id column holds unique values, so pages are
+	// plain-encoded and store a bloom filter instead of a dictionary.
+	column := &MemColumn{
+		Info: ColumnInfo{
+			Name:        "trace_id",
+			Type:        datasetmd.VALUE_TYPE_BYTE_ARRAY,
+			Compression: datasetmd.COMPRESSION_TYPE_NONE,
+		},
+	}
+	for page := range 4 {
+		b, err := newPageBuilder(BuilderOptions{
+			PageSizeHint: 1 << 20,
+			Value:        datasetmd.VALUE_TYPE_BYTE_ARRAY,
+			Compression:  datasetmd.COMPRESSION_TYPE_NONE,
+			Encoding:     datasetmd.ENCODING_TYPE_PLAIN,
+			Statistics:   StatisticsOptions{StoreBloomFilters: true},
+		})
+		require.NoError(t, err)
+		for i := range 100 {
+			require.True(t, b.Append(ByteArrayValue(fmt.Appendf(nil, "trace-%d-%d", page, i))))
+		}
+
+		page, err := b.Flush()
+		require.NoError(t, err)
+		require.Positive(t, page.Info.BloomFilterSize)
+
+		column.Pages = append(column.Pages, page)
+		column.Info.RowsCount += page.Info.RowCount
+		column.Info.ValuesCount += page.Info.ValuesCount
+	}
+
+	dset := FromMemory([]*MemColumn{column})
+	columns, err := result.Collect(dset.ListColumns(context.Background()))
+	require.NoError(t, err)
+
+	tt := []struct {
+		name      string
+		predicate Predicate
+		expected  []string
+		pruned    int
+	}{
+		{
+			name:      "equal predicate",
+			predicate: EqualPredicate{Column: columns[0], Value: ByteArrayValue([]byte("trace-2-42"))},
+			expected:  []string{"trace-2-42"},
+			pruned:    3,
+		},
+		{
+			name: "in predicate",
+			predicate: InPredicate{Column: columns[0], Values: NewByteArrayValueSet([]Value{
+				ByteArrayValue([]byte("trace-0-1")),
+				ByteArrayValue([]byte("trace-3-99")),
+			})},
+			expected: []string{"trace-0-1", "trace-3-99"},
+			pruned:   2,
+		},
+		{
+			name:      "no matching value",
+			predicate: EqualPredicate{Column: columns[0], Value: ByteArrayValue([]byte("unknown"))},
+			pruned:    4,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			r := NewReader(ReaderOptions{
+				Dataset:    dset,
+				Columns:    columns,
+				Predicates: []Predicate{tc.predicate},
+			})
+			defer r.Close()
+
+			rows, err := readDataset(r, 64)
+			require.NoError(t, err)
+
+			var actual []string
+			for _, row := range rows {
+				actual = append(actual, string(row.Values[0].ByteArray()))
+			}
+			require.Equal(t, tc.expected, actual)
+			require.Equal(t, tc.pruned, r.PagesPruned())
+		})
+	}
+}
+
 // buildMemDatasetWithStats creates a test dataset with only column and page stats.
 func buildMemDatasetWithStats(t *testing.T) (Dataset, []Column) {
 	t.Helper()
@@ -0,0 +1,310 @@
+package dataset
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math/bits"
+	"slices"
+
+	"github.com/grafana/loki/v3/pkg/dataobj/internal/metadata/datasetmd"
+	"github.com/grafana/loki/v3/pkg/dataobj/internal/streamio"
+	"github.com/grafana/loki/v3/pkg/dataobj/internal/util/slicegrow"
+)
+
+func init() {
+	// Register the encoding so instances of it can be dynamically created.
+	registerValueEncoding(
+		datasetmd.VALUE_TYPE_BYTE_ARRAY,
+		datasetmd.ENCODING_TYPE_DICTIONARY,
+		func(w streamio.Writer) valueEncoder { return newDictionaryEncoder(w) },
+		func(r streamio.Reader) valueDecoder { return newDictionaryDecoder(r) },
+	)
+}
+
+// maxDictionaryEntries is the maximum number of distinct values in the
+// dictionary of a page. Pages with more distinct values fall back to plain
+// encoding.
+const maxDictionaryEntries = 1024
+
+var errDictionaryFlushed = errors.New("dictionary: cannot encode values after flushing")
+
+// A dictionaryEncoder encodes byte array values as indexes (codes) into a
+// dictionary of the distinct values of a page. When flushed, the dictionary
+// is written to the underlying [streamio.Writer] followed by the codes:
+//
+//	<uvarint(entries)> <plain-encoded entries> <bitmap-encoded codes>
+//
+// Storing the dictionary at the start of the values of a page lets readers
+// resolve predicates to codes once per page and skip rows, or entire pages,
+// without decoding every value.
+//
+// Dictionary encoding is only worthwhile for values with low cardinality. To
+// decide whether it pays off, codes are buffered in memory until the encoder
+// is flushed. If the dictionary grows beyond maxDictionaryEntries, or if the
+// page would be smaller using plain encoding, the values of the page are
+// written with plain encoding instead, and [dictionaryEncoder.EncodingType]
+// reports [datasetmd.ENCODING_TYPE_PLAIN] until the encoder is reset.
+//
+// As the dictionary precedes the codes, no values can be encoded after the
+// encoder has been flushed until it is reset.
+type dictionaryEncoder struct {
+	w        streamio.Writer
+	plainEnc *plainBytesEncoder
+	codesEnc *bitmapEncoder
+
+	lookup map[string]uint64 // Code of each entry in dict.
+	dict   [][]byte          // Distinct values of the page.
+	codes  []uint64          // Buffered codes of the page.
+
+	dictSize  int // Size of the entries in dict.
+	plainSize int // Size of the buffered values if they were plain-encoded.
+
+	plain   bool // Whether the page fell back to plain encoding.
+	flushed bool // Whether the dictionary has been written to the underlying writer.
+
+	// onFallback, if set, is called with the dictionary of the page when the
+	// page falls back to plain encoding. The dictionary must not be retained.
+	onFallback func(dict [][]byte)
+}
+
+var _ valueEncoder = (*dictionaryEncoder)(nil)
+
+// newDictionaryEncoder creates a dictionaryEncoder that writes encoded codes
+// to w.
+func newDictionaryEncoder(w streamio.Writer) *dictionaryEncoder {
+	return &dictionaryEncoder{
+		w:        w,
+		plainEnc: newPlainBytesEncoder(w),
+		codesEnc: newBitmapEncoder(w),
+		lookup:   make(map[string]uint64),
+	}
+}
+
+// ValueType returns [datasetmd.VALUE_TYPE_BYTE_ARRAY].
+func (enc *dictionaryEncoder) ValueType() datasetmd.ValueType {
+	return datasetmd.VALUE_TYPE_BYTE_ARRAY
+}
+
+// EncodingType returns [datasetmd.ENCODING_TYPE_DICTIONARY], or
+// [datasetmd.ENCODING_TYPE_PLAIN] if the current page fell back to plain
+// encoding.
+func (enc *dictionaryEncoder) EncodingType() datasetmd.EncodingType {
+	if enc.plain {
+		return datasetmd.ENCODING_TYPE_PLAIN
+	}
+	return datasetmd.ENCODING_TYPE_DICTIONARY
+}
+
+// BufferedSize returns the estimated number of bytes of the values that have
+// been encoded but not yet written to the underlying [streamio.Writer],
+// including the dictionary.
+func (enc *dictionaryEncoder) BufferedSize() int {
+	if enc.plain {
+		return 0
+	}
+	return min(enc.dictSize+enc.codesSize(), enc.plainSize)
+}
+
+// codesSize returns the estimated size of the buffered codes once
+// bitpacked.
+func (enc *dictionaryEncoder) codesSize() int {
+	width := max(1, bits.Len(uint(len(enc.dict))))
+	return (width*len(enc.codes) + 7) / 8
+}
+
+// Encode encodes an individual byte array value.
+func (enc *dictionaryEncoder) Encode(v Value) error {
+	if v.Type() != datasetmd.VALUE_TYPE_BYTE_ARRAY {
+		return fmt.Errorf("dictionary: invalid value type %v", v.Type())
+	} else if enc.plain {
+		return enc.plainEnc.Encode(v)
+	} else if enc.flushed {
+		return errDictionaryFlushed
+	}
+
+	entry := v.ByteArray()
+	code, ok := enc.lookup[string(entry)]
+	if !ok && len(enc.dict) == maxDictionaryEntries {
+		if err := enc.fallback(); err != nil {
+			return err
+		}
+		return enc.plainEnc.Encode(v)
+	} else if !ok {
+		code = uint64(len(enc.dict))
+		entry = slices.Clone(entry)
+		enc.lookup[string(entry)] = code
+		enc.dict = append(enc.dict, entry)
+		enc.dictSize += plainSize(entry)
+	}
+
+	enc.codes = append(enc.codes, code)
+	enc.plainSize += plainSize(entry)
+	return nil
+}
+
+// fallback writes the buffered values with plain encoding and switches the
+// current page to plain encoding.
+func (enc *dictionaryEncoder) fallback() error {
+	for _, code := range enc.codes {
+		if err := enc.plainEnc.Encode(ByteArrayValue(enc.dict[code])); err != nil {
+			return err
+		}
+	}
+
+	if enc.onFallback != nil {
+		enc.onFallback(enc.dict)
+	}
+
+	enc.plain = true
+	enc.dict = nil
+	enc.codes = enc.codes[:0]
+	clear(enc.lookup)
+	return nil
+}
+
+// Flush writes the dictionary and the buffered codes to the underlying
+// [streamio.Writer], or falls back to plain encoding if it results in a
+// smaller page.
+func (enc *dictionaryEncoder) Flush() error {
+	if enc.plain || enc.flushed || len(enc.codes) == 0 {
+		return nil
+	} else if enc.dictSize+enc.codesSize() >= enc.plainSize {
+		return enc.fallback()
+	}
+
+	if err := streamio.WriteUvarint(enc.w, uint64(len(enc.dict))); err != nil {
+		return err
+	}
+	for _, entry := range enc.dict {
+		if err := enc.plainEnc.Encode(ByteArrayValue(entry)); err != nil {
+			return err
+		}
+	}
+
+	for _, code := range enc.codes {
+		if err := enc.codesEnc.Encode(Uint64Value(code)); err != nil {
+			return err
+		}
+	}
+	enc.codes = enc.codes[:0]
+	enc.flushed = true
+	return enc.codesEnc.Flush()
+}
+
+// Reset implements [valueEncoder]. It resets the encoder to write to w.
+func (enc *dictionaryEncoder) Reset(w streamio.Writer) {
+	enc.w = w
+	enc.plainEnc.Reset(w)
+	enc.codesEnc.Reset(w)
+
+	// Entries of the dictionary are keys of the lookup map, so we allocate a
+	// new dictionary rather than reusing its memory.
+	clear(enc.lookup)
+	enc.dict = nil
+	enc.codes = enc.codes[:0]
+	enc.dictSize = 0
+	enc.plainSize = 0
+	enc.plain = false
+	enc.flushed = false
+}
+
+// plainSize returns the size of b when plain-encoded.
+func plainSize(b []byte) int {
+	return streamio.UvarintSize(uint64(len(b))) + len(b)
+}
+
+// dictionaryDecoder decodes byte arrays from a stream written by a
+// [dictionaryEncoder]. The dictionary is read from the stream on the first
+// call to [dictionaryDecoder.Decode] after a reset.
+type dictionaryDecoder struct {
+	r        streamio.Reader
+	codesDec *bitmapDecoder
+
+	dict     [][]byte
+	dictRead bool // Whether dict holds the dictionary of the current stream.
+	codes    []Value
+}
+
+var _ valueDecoder = (*dictionaryDecoder)(nil)
+
+// newDictionaryDecoder creates a dictionaryDecoder that reads the dictionary
+// and encoded codes from r.
+func newDictionaryDecoder(r streamio.Reader) *dictionaryDecoder {
+	return &dictionaryDecoder{r: r, codesDec: newBitmapDecoder(r)}
+}
+
+// ValueType returns [datasetmd.VALUE_TYPE_BYTE_ARRAY].
+func (dec *dictionaryDecoder) ValueType() datasetmd.ValueType {
+	return datasetmd.VALUE_TYPE_BYTE_ARRAY
+}
+
+// EncodingType returns [datasetmd.ENCODING_TYPE_DICTIONARY].
+func (dec *dictionaryDecoder) EncodingType() datasetmd.EncodingType {
+	return datasetmd.ENCODING_TYPE_DICTIONARY
+}
+
+// Decode decodes up to len(s) values, storing the results into s. The
+// number of decoded values is returned, followed by an error (if any).
+// At the end of the stream, Decode returns 0, [io.EOF].
+func (dec *dictionaryDecoder) Decode(s []Value) (int, error) {
+	if !dec.dictRead {
+		var err error
+		if dec.dict, err = readDictionary(dec.r, dec.dict); err != nil {
+			return 0, err
+		}
+		dec.dictRead = true
+	}
+
+	dec.codes = slicegrow.GrowToCap(dec.codes, len(s))
+	dec.codes = dec.codes[:len(s)]
+
+	n, err := dec.codesDec.Decode(dec.codes)
+	for i, code := range dec.codes[:n] {
+		if code.Uint64() >= uint64(len(dec.dict)) {
+			return i, fmt.Errorf("dictionary: code %d out of range for dictionary of %d entries", code.Uint64(), len(dec.dict))
+		}
+
+		// Entries are copied into the memory of s to allow the caller to retain
+		// ownership of it.
+		entry := dec.dict[code.Uint64()]
+		dst := slicegrow.GrowToCap(s[i].Buffer(), len(entry))
+		dst = dst[:len(entry)]
+		copy(dst, entry)
+		s[i] = ByteArrayValue(dst)
+	}
+	return n, err
+}
+
+// Reset implements [valueDecoder]. It resets the decoder to read from r.
+func (dec *dictionaryDecoder) Reset(r streamio.Reader) {
+	dec.r = r
+	dec.codesDec.Reset(r)
+	dec.dictRead = false
+}
+
+// readDictionary reads the dictionary written by a [dictionaryEncoder] from
+// the start of r, reusing the memory of dst.
+func readDictionary(r streamio.Reader, dst [][]byte) ([][]byte, error) {
+	count, err := streamio.ReadUvarint(r)
+	if err != nil {
+		return dst[:0], fmt.Errorf("dictionary: reading size: %w", err)
+	} else if count > maxDictionaryEntries {
+		return dst[:0], fmt.Errorf("dictionary: %d entries exceed the maximum of %d", count, maxDictionaryEntries)
+	}
+
+	dst = slicegrow.GrowToCap(dst, int(count))
+	dst = dst[:count]
+
+	for i := range dst {
+		size, err := streamio.ReadUvarint(r)
+		if err != nil {
+			return dst[:0], fmt.Errorf("dictionary: reading entry: %w", err)
+		}
+		dst[i] = slicegrow.GrowToCap(dst[i], int(size))[:size]
+		if _, err := io.ReadFull(r, dst[i]); err != nil {
+			return dst[:0], fmt.Errorf("dictionary: reading entry: %w", err)
+		}
+	}
+	return dst, nil
+}
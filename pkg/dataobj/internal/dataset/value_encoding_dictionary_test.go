@@ -0,0 +1,121 @@
+package dataset
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/loki/v3/pkg/dataobj/internal/metadata/datasetmd"
+	"github.com/grafana/loki/v3/pkg/dataobj/internal/streamio"
+)
+
+func Test_dictionaryEncoder(t *testing.T) {
+	var in []string
+	for i := range 100 {
+		in = append(in, testStrings[i%len(testStrings)])
+	}
+
+	var buf bytes.Buffer
+
+	enc := newDictionaryEncoder(&buf)
+	for _, v := range in {
+		require.NoError(t, enc.Encode(ByteArrayValue([]byte(v))))
+	}
+	require.NoError(t, enc.Flush())
+
+	require.Equal(t, datasetmd.ENCODING_TYPE_DICTIONARY, enc.EncodingType())
+	require.Less(t, buf.Len(), plainEncodedSize(in), "dictionary encoding should be smaller than plain encoding")
+	require.ErrorIs(t, enc.Encode(ByteArrayValue([]byte("hello"))), errDictionaryFlushed)
+
+	dec := newDictionaryDecoder(&oneByteReader{&buf})
+	require.Equal(t, in, decodeAllStrings(t, dec))
+}
+
+func Test_dictionaryEncoder_fallbackOnSize(t *testing.T) {
+	// Each value is unique, so the dictionary doesn't pay off.
+	in := []string{"hello", "world", "foo", "bar", "baz"}
+
+	var buf bytes.Buffer
+
+	enc := newDictionaryEncoder(&buf)
+	for _, v := range in {
+		require.NoError(t, enc.Encode(ByteArrayValue([]byte(v))))
+	}
+	require.NoError(t, enc.Flush())
+
+	require.Equal(t, datasetmd.ENCODING_TYPE_PLAIN, enc.EncodingType())
+	require.Equal(t, in, decodeAllStrings(t, newPlainBytesDecoder(&buf)))
+}
+
+func Test_dictionaryEncoder_fallbackOnEntries(t *testing.T) {
+	var in []string
+	for i := range maxDictionaryEntries * 2 {
+		in = append(in, fmt.Sprintf("value-%d", i))
+	}
+
+	var buf bytes.Buffer
+
+	enc := newDictionaryEncoder(&buf)
+	for _, v := range in {
+		require.NoError(t, enc.Encode(ByteArrayValue([]byte(v))))
+	}
+	require.Equal(t, datasetmd.ENCODING_TYPE_PLAIN, enc.EncodingType(), "encoder should fall back before flushing")
+	require.Zero(t, enc.BufferedSize())
+	require.NoError(t, enc.Flush())
+
+	require.Equal(t, in, decodeAllStrings(t, newPlainBytesDecoder(&buf)))
+
+	// After a reset, the encoder uses dictionary encoding again.
+	buf.Reset()
+	enc.Reset(&buf)
+	require.Equal(t, datasetmd.ENCODING_TYPE_DICTIONARY, enc.EncodingType())
+}
+
+func Test_dictionaryDecoder_invalidCode(t *testing.T) {
+	var buf bytes.Buffer
+
+	// A dictionary with a single entry, followed by a code past the end of
+	// the dictionary.
+	require.NoError(t, streamio.WriteUvarint(&buf, 1))
+	require.NoError(t, newPlainBytesEncoder(&buf).Encode(ByteArrayValue([]byte("hello"))))
+	codesEnc := newBitmapEncoder(&buf)
+	require.NoError(t, codesEnc.Encode(Uint64Value(5)))
+	require.NoError(t, codesEnc.Flush())
+
+	dec := newDictionaryDecoder(&buf)
+	_, err := dec.Decode(make([]Value, batchSize))
+	require.ErrorContains(t, err, "out of range")
+}
+
+func plainEncodedSize(in []string) int {
+	var size int
+	for _, v := range in {
+		size += plainSize([]byte(v))
+	}
+	return size
+}
+
+func decodeAllStrings(t *testing.T, dec valueDecoder) []string {
+	t.Helper()
+
+	var (
+		out    []string
+		decBuf = make([]Value, batchSize)
+	)
+
+	for {
+		n, err := dec.Decode(decBuf[:batchSize])
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		require.NoError(t, err)
+		for _, v := range decBuf[:n] {
+			out = append(out, string(v.ByteArray()))
+		}
+	}
+	return out
+}
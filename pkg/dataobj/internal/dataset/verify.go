@@ -0,0 +1,154 @@
+package dataset
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/grafana/loki/v3/pkg/dataobj/internal/result"
+)
+
+// VerifyColumns verifies all columns of dset with [VerifyColumn]. names holds
+// the name of each column used in errors. If rowCheck is non-nil, it is
+// called with the index and number of rows of each column, and its error is
+// returned for the column.
+func VerifyColumns(ctx context.Context, dset Dataset, names []string, rowCheck func(i, rows int) error) error {
+	columns, err := result.Collect(dset.ListColumns(ctx))
+	if err != nil {
+		return fmt.Errorf("listing columns: %w", err)
+	} else if len(columns) != len(names) {
+		return fmt.Errorf("dataset has %d columns, expected %d", len(columns), len(names))
+	}
+
+	for i, column := range columns {
+		if err := VerifyColumn(ctx, column); err != nil {
+			return fmt.Errorf("column %d (%s): %w", i, names[i], err)
+		}
+		if rowCheck == nil {
+			continue
+		}
+		if err := rowCheck(i, column.ColumnInfo().RowsCount); err != nil {
+			return fmt.Errorf("column %d (%s): %w", i, names[i], err)
+		}
+	}
+	return nil
+}
+
+// EqualRows returns a row check for [VerifyColumns] which requires all
+// columns to have the same number of rows.
+func EqualRows() func(i, rows int) error {
+	expected := -1
+	return func(_, rows int) error {
+		if expected == -1 {
+			expected = rows
+		} else if rows != expected {
+			return fmt.Errorf("has %d rows, expected %d", rows, expected)
+		}
+		return nil
+	}
+}
+
+// VerifyColumn reads and decodes all pages of column. VerifyColumn returns an
+// error if the data of a page fails to validate against its CRC32 checksum or
+// can't be decoded, or if the pages don't match the metadata of the column or
+// their own metadata.
+func VerifyColumn(ctx context.Context, column Column) error {
+	info := column.ColumnInfo()
+
+	pages, err := result.Collect(column.ListPages(ctx))
+	if err != nil {
+		return fmt.Errorf("listing pages: %w", err)
+	}
+
+	var rows, values, compressedSize, uncompressedSize int
+	for i, page := range pages {
+		if err := verifyPage(ctx, info, page); err != nil {
+			return fmt.Errorf("page %d: %w", i, err)
+		}
+
+		pageInfo := page.PageInfo()
+		rows += pageInfo.RowCount
+		values += pageInfo.ValuesCount
+		compressedSize += pageInfo.CompressedSize
+		uncompressedSize += pageInfo.UncompressedSize
+	}
+
+	switch {
+	case rows != info.RowsCount:
+		return fmt.Errorf("pages have %d rows, column has %d", rows, info.RowsCount)
+	case values != info.ValuesCount:
+		return fmt.Errorf("pages have %d values, column has %d", values, info.ValuesCount)
+	case compressedSize != info.CompressedSize:
+		return fmt.Errorf("pages have compressed size %d, column has %d", compressedSize, info.CompressedSize)
+	case uncompressedSize != info.UncompressedSize:
+		return fmt.Errorf("pages have uncompressed size %d, column has %d", uncompressedSize, info.UncompressedSize)
+	}
+	return nil
+}
+
+// verifyPage decodes all values of page, checking the number of rows and
+// values and the range of values against the metadata of the page.
+func verifyPage(ctx context.Context, column *ColumnInfo, page Page) error {
+	info := page.PageInfo()
+
+	data, err := page.ReadPage(ctx)
+	if err != nil {
+		return fmt.Errorf("reading page: %w", err)
+	} else if len(data) != info.CompressedSize {
+		return fmt.Errorf("page data has size %d, expected %d", len(data), info.CompressedSize)
+	}
+
+	var minValue, maxValue *Value
+	if stats := info.Stats; stats != nil && stats.MinValue != nil && stats.MaxValue != nil {
+		minValue, maxValue = new(Value), new(Value)
+		if err := minValue.UnmarshalBinary(stats.MinValue); err != nil {
+			return fmt.Errorf("unmarshalling min value: %w", err)
+		} else if err := maxValue.UnmarshalBinary(stats.MaxValue); err != nil {
+			return fmt.Errorf("unmarshalling max value: %w", err)
+		}
+	}
+
+	// The page data has already been read, so avoid reading it again when
+	// decoding.
+	reader := newPageReader(&MemPage{Info: *info, Data: data}, column.Type, column.Compression)
+	defer reader.Close()
+
+	var (
+		buf = make([]Value, 1024)
+
+		rows, values int
+	)
+	for {
+		n, err := reader.Read(ctx, buf)
+		for _, value := range buf[:n] {
+			rows++
+			if value.IsNil() {
+				continue
+			}
+			values++
+
+			if minValue == nil {
+				continue
+			} else if value.Type() != minValue.Type() {
+				return fmt.Errorf("row %d has type %s, expected %s", rows-1, value.Type(), minValue.Type())
+			} else if CompareValues(&value, minValue) < 0 || CompareValues(&value, maxValue) > 0 {
+				return fmt.Errorf("row %d is outside of the range of the page statistics", rows-1)
+			}
+		}
+
+		if errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return fmt.Errorf("decoding page: %w", err)
+		}
+	}
+
+	switch {
+	case rows != info.RowCount:
+		return fmt.Errorf("decoded %d rows, page has %d", rows, info.RowCount)
+	case values != info.ValuesCount:
+		return fmt.Errorf("decoded %d values, page has %d", values, info.ValuesCount)
+	}
+	return nil
+}
@@ -0,0 +1,110 @@
+package dataset
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/loki/v3/pkg/dataobj/internal/metadata/datasetmd"
+)
+
+func TestVerifyColumn(t *testing.T) {
+	tt := []struct {
+		name   string
+		modify func(col *MemColumn)
+		errMsg string
+	}{
+		{
+			name:   "valid",
+			modify: func(*MemColumn) {},
+		},
+		{
+			name: "corrupted page data",
+			modify: func(col *MemColumn) {
+				data := append(PageData(nil), col.Pages[1].Data...)
+				data[len(data)-1] ^= 0xff
+				col.Pages[1].Data = data
+			},
+			errMsg: "invalid CRC32 checksum",
+		},
+		{
+			name:   "page values count",
+			modify: func(col *MemColumn) { col.Pages[0].Info.ValuesCount++ },
+			errMsg: "page 0: decoded",
+		},
+		{
+			name:   "column rows count",
+			modify: func(col *MemColumn) { col.Info.RowsCount++ },
+			errMsg: "column has",
+		},
+		{
+			name: "page statistics",
+			modify: func(col *MemColumn) {
+				maxValue, err := Int64Value(0).MarshalBinary()
+				require.NoError(t, err)
+				col.Pages[1].Info.Stats.MaxValue = maxValue
+			},
+			errMsg: "outside of the range",
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			col := buildVerifyColumn(t, 100)
+			tc.modify(col)
+
+			err := VerifyColumn(context.Background(), col)
+			if tc.errMsg == "" {
+				require.NoError(t, err)
+				return
+			}
+			require.ErrorContains(t, err, tc.errMsg)
+		})
+	}
+}
+
+func TestVerifyColumns(t *testing.T) {
+	var (
+		ctx   = context.Background()
+		names = []string{"a", "b"}
+	)
+
+	dset := FromMemory([]*MemColumn{buildVerifyColumn(t, 100), buildVerifyColumn(t, 100)})
+	require.NoError(t, VerifyColumns(ctx, dset, names, EqualRows()))
+
+	dset = FromMemory([]*MemColumn{buildVerifyColumn(t, 100), buildVerifyColumn(t, 200)})
+	require.NoError(t, VerifyColumns(ctx, dset, names, nil))
+	require.ErrorContains(t, VerifyColumns(ctx, dset, names, EqualRows()), "column 1 (b): has 200 rows, expected 100")
+
+	corrupted := buildVerifyColumn(t, 100)
+	corrupted.Info.RowsCount++
+	dset = FromMemory([]*MemColumn{buildVerifyColumn(t, 100), corrupted})
+	require.ErrorContains(t, VerifyColumns(ctx, dset, names, nil), "column 1 (b): pages have")
+}
+
+func buildVerifyColumn(t *testing.T, rows int) *MemColumn {
+	t.Helper()
+
+	opts := BuilderOptions{
+		PageSizeHint: 64,
+		Value:        datasetmd.VALUE_TYPE_INT64,
+		Compression:  datasetmd.COMPRESSION_TYPE_ZSTD,
+		Encoding:     datasetmd.ENCODING_TYPE_DELTA,
+		Statistics:   StatisticsOptions{StoreRangeStats: true},
+	}
+	b, err := NewColumnBuilder("", opts)
+	require.NoError(t, err)
+
+	for i := range rows {
+		if i%7 == 0 {
+			continue // Leave some rows NULL.
+		}
+		require.NoError(t, b.Append(i, Int64Value(int64(i))))
+	}
+
+	col, err := b.Flush()
+	require.NoError(t, err)
+	require.Greater(t, len(col.Pages), 1)
+	return col
+}
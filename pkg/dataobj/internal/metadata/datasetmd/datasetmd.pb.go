@@ -71,6 +71,8 @@ const (
 	COMPRESSION_TYPE_SNAPPY CompressionType = 2
 	// Zstd compression.
 	COMPRESSION_TYPE_ZSTD CompressionType = 3
+	// LZ4 compression, using the LZ4 frame format.
+	COMPRESSION_TYPE_LZ4 CompressionType = 4
 )
 
 var CompressionType_name = map[int32]string{
@@ -78,6 +80,7 @@ var CompressionType_name = map[int32]string{
 	1: "COMPRESSION_TYPE_NONE",
 	2: "COMPRESSION_TYPE_SNAPPY",
 	3: "COMPRESSION_TYPE_ZSTD",
+	4: "COMPRESSION_TYPE_LZ4",
 }
 
 var CompressionType_value = map[string]int32{
@@ -85,6 +88,7 @@ var CompressionType_value = map[string]int32{
 	"COMPRESSION_TYPE_NONE":        1,
 	"COMPRESSION_TYPE_SNAPPY":      2,
 	"COMPRESSION_TYPE_ZSTD":        3,
+	"COMPRESSION_TYPE_LZ4":         4,
 }
 
 func (CompressionType) EnumDescriptor() ([]byte, []int) {
@@ -106,6 +110,10 @@ const (
 	// Bitmap encoding. Bitmaps effiently store repeating sequences of unsigned
 	// integers using a combination of run-length encoding and bitpacking.
 	ENCODING_TYPE_BITMAP EncodingType = 3
+	// Dictionary encoding. Each distinct value of the page is stored once in a
+	// dictionary at the start of the page data, followed by the values of the
+	// page as bitmap-encoded indexes into the dictionary.
+	ENCODING_TYPE_DICTIONARY EncodingType = 4
 )
 
 var EncodingType_name = map[int32]string{
@@ -113,6 +121,7 @@ var EncodingType_name = map[int32]string{
 	1: "ENCODING_TYPE_PLAIN",
 	2: "ENCODING_TYPE_DELTA",
 	3: "ENCODING_TYPE_BITMAP",
+	4: "ENCODING_TYPE_DICTIONARY",
 }
 
 var EncodingType_value = map[string]int32{
@@ -120,6 +129,7 @@ var EncodingType_value = map[string]int32{
 	"ENCODING_TYPE_PLAIN":       1,
 	"ENCODING_TYPE_DELTA":       2,
 	"ENCODING_TYPE_BITMAP":      3,
+	"ENCODING_TYPE_DICTIONARY":  4,
 }
 
 func (EncodingType) EnumDescriptor() ([]byte, []int) {
@@ -385,6 +395,18 @@ type PageInfo struct {
 	Statistics *Statistics `protobuf:"bytes,8,opt,name=statistics,proto3" json:"statistics,omitempty"`
 	// Total number of non-NULL values in the page.
 	ValuesCount uint64 `protobuf:"varint,9,opt,name=values_count,json=valuesCount,proto3" json:"values_count,omitempty"`
+	// Size of the optional bloom filter of the values of the page in bytes. The
+	// bloom filter is stored at the end of the page data, encoded in the binary
+	// format of github.com/bits-and-blooms/bloom/v3.
+	//
+	// Applications can use the bloom filter to skip reading the rows of a page
+	// that doesn't contain a value without decoding the page. The bloom filter
+	// can be read on its own, as the last bloom_filter_size bytes of the page
+	// data.
+	BloomFilterSize uint64 `protobuf:"varint,10,opt,name=bloom_filter_size,json=bloomFilterSize,proto3" json:"bloom_filter_size,omitempty"`
+	// CRC32 checksum of the bloom filter, allowing it to be validated when it's
+	// read without the rest of the page data.
+	BloomFilterCrc32 uint32 `protobuf:"varint,11,opt,name=bloom_filter_crc32,json=bloomFilterCrc32,proto3" json:"bloom_filter_crc32,omitempty"`
 }
 
 func (m *PageInfo) Reset()      { *m = PageInfo{} }
@@ -482,6 +504,20 @@ func (m *PageInfo) GetValuesCount() uint64 {
 	return 0
 }
 
+func (m *PageInfo) GetBloomFilterSize() uint64 {
+	if m != nil {
+		return m.BloomFilterSize
+	}
+	return 0
+}
+
+func (m *PageInfo) GetBloomFilterCrc32() uint32 {
+	if m != nil {
+		return m.BloomFilterCrc32
+	}
+	return 0
+}
+
 // SectionSortInfo represents the sort order information for the records
 // in a section.
 //
@@ -601,61 +637,65 @@ func init() {
 }
 
 var fileDescriptor_7ab9d5b21b743868 = []byte{
-	// 860 bytes of a gzipped FileDescriptorProto
+	// 918 bytes of a gzipped FileDescriptorProto
 	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xac, 0x55, 0x4d, 0x73, 0xda, 0x46,
-	0x18, 0x66, 0x81, 0xa4, 0xe8, 0x05, 0xdb, 0xf2, 0xd6, 0x6e, 0x64, 0x3b, 0x51, 0xa9, 0x3b, 0xd3,
-	0x50, 0xd2, 0x81, 0x29, 0xee, 0x34, 0x67, 0x0c, 0x6a, 0xaa, 0x19, 0x47, 0x30, 0x92, 0x92, 0x19,
-	0xfb, 0xa2, 0x91, 0x85, 0x4c, 0xd5, 0xa0, 0x5d, 0x46, 0x5a, 0x5c, 0x93, 0x53, 0x4f, 0x39, 0xf7,
-	0x67, 0xf4, 0xa7, 0xf4, 0xe8, 0x63, 0x8e, 0x35, 0x9e, 0xe9, 0xe4, 0x98, 0x9f, 0xd0, 0xd1, 0x0a,
-	0x81, 0xf8, 0x28, 0x93, 0x43, 0x6f, 0xbb, 0xef, 0xf3, 0xbc, 0x1f, 0xec, 0xf3, 0xf0, 0x0a, 0x9e,
-	0x0f, 0xdf, 0xf4, 0xeb, 0x3d, 0x9b, 0xd9, 0xf4, 0xf2, 0xd7, 0xba, 0x47, 0x98, 0x1b, 0x10, 0x7b,
-	0x50, 0xf7, 0x5d, 0x66, 0x47, 0x41, 0x8e, 0x84, 0x2e, 0xf3, 0x7b, 0xf3, 0x53, 0x6d, 0x18, 0x50,
-	0x46, 0xf1, 0xd1, 0x34, 0xa9, 0x96, 0x70, 0x6b, 0x53, 0x46, 0xed, 0xfa, 0xfb, 0xe3, 0x7f, 0x72,
-	0x00, 0x2d, 0x3a, 0x18, 0xf9, 0x44, 0x25, 0x57, 0x14, 0x63, 0xc8, 0x13, 0xdb, 0x77, 0x25, 0x54,
-	0x46, 0x15, 0x41, 0xe7, 0x67, 0xac, 0x00, 0x5c, 0xdb, 0x83, 0x91, 0x6b, 0xb1, 0xf1, 0xd0, 0x95,
-	0xb2, 0x65, 0x54, 0xd9, 0x6e, 0x7c, 0x53, 0xdb, 0x50, 0xb4, 0xf6, 0x3a, 0xa2, 0x9b, 0xe3, 0xa1,
-	0xab, 0x0b, 0xd7, 0xc9, 0x11, 0x3f, 0x01, 0x08, 0xe8, 0x6f, 0xa1, 0xe5, 0xd0, 0x11, 0x61, 0x52,
-	0xae, 0x8c, 0x2a, 0x79, 0x5d, 0x88, 0x22, 0xad, 0x28, 0x80, 0x35, 0x28, 0x3a, 0xd4, 0x1f, 0x06,
-	0x6e, 0x18, 0x7a, 0x94, 0x48, 0x79, 0xde, 0xe6, 0xbb, 0x8d, 0x6d, 0x5a, 0x73, 0x3e, 0x6f, 0x96,
-	0x2e, 0x80, 0x9f, 0xc1, 0xee, 0x88, 0x24, 0x01, 0xb7, 0x67, 0x85, 0xde, 0x5b, 0x57, 0x7a, 0xc0,
-	0xbb, 0x8a, 0x69, 0xc0, 0xf0, 0xde, 0xba, 0xf8, 0x29, 0xec, 0x2c, 0x53, 0x1f, 0x72, 0xea, 0xf6,
-	0x2a, 0x31, 0x99, 0xc4, 0xa2, 0x57, 0x57, 0xa1, 0xcb, 0xa4, 0xcf, 0x62, 0x62, 0x12, 0xee, 0xf0,
-	0x28, 0xfe, 0x1a, 0xb6, 0x66, 0x44, 0x5e, 0xaf, 0xc0, 0x69, 0xa5, 0x24, 0xc8, 0xab, 0xbd, 0x00,
-	0x08, 0x99, 0xcd, 0xbc, 0x90, 0x79, 0x4e, 0x28, 0x09, 0x65, 0x54, 0x29, 0x36, 0x9e, 0x6e, 0xfc,
-	0xc9, 0xc6, 0x8c, 0xae, 0xa7, 0x52, 0xf1, 0x57, 0x50, 0xe2, 0x0f, 0x9d, 0xbc, 0x2e, 0xf0, 0x66,
-	0xc5, 0x38, 0xc6, 0xdf, 0xf7, 0x38, 0x04, 0x98, 0x27, 0xe3, 0x23, 0x10, 0x7c, 0x8f, 0x58, 0x9c,
-	0xc0, 0xc5, 0x2e, 0xe9, 0x05, 0xdf, 0x23, 0x5c, 0x38, 0x0e, 0xda, 0x37, 0x53, 0x30, 0x3b, 0x05,
-	0xed, 0x9b, 0x18, 0x7c, 0x06, 0xbb, 0x8e, 0x1d, 0xf4, 0x3c, 0x62, 0x0f, 0x3c, 0x36, 0x5e, 0x50,
-	0x53, 0x4c, 0x01, 0x71, 0xd3, 0x77, 0x39, 0x28, 0x74, 0xed, 0xbe, 0xcb, 0xbd, 0xb5, 0x56, 0x11,
-	0xf4, 0xe9, 0x8a, 0x64, 0xd7, 0x2a, 0xb2, 0x07, 0x0f, 0x9c, 0xc0, 0x39, 0x69, 0xf0, 0x19, 0xb6,
-	0xf4, 0xf8, 0xb2, 0x64, 0xb6, 0xfc, 0xb2, 0xd9, 0x14, 0x28, 0xb8, 0xc4, 0xa1, 0x3d, 0x8f, 0xf4,
-	0xb9, 0x27, 0xb6, 0x1b, 0xdf, 0x6e, 0x7c, 0x76, 0x65, 0x4a, 0xe6, 0x36, 0x9b, 0xa5, 0xe2, 0x2f,
-	0xa1, 0x98, 0x76, 0x42, 0x6c, 0x19, 0x48, 0xb9, 0xe0, 0x08, 0x84, 0xb9, 0x03, 0x62, 0xa3, 0x14,
-	0xfe, 0x43, 0xfd, 0xc2, 0xff, 0xa7, 0xbe, 0xb0, 0xaa, 0xfe, 0x07, 0x04, 0x3b, 0x86, 0xeb, 0x30,
-	0x8f, 0x12, 0x83, 0x06, 0x8c, 0xeb, 0x71, 0x01, 0x25, 0x87, 0xff, 0xf3, 0xad, 0x90, 0x06, 0x2c,
-	0x94, 0x50, 0x39, 0x57, 0x29, 0x36, 0x9e, 0x6f, 0x9e, 0x60, 0xb1, 0x46, 0x2d, 0x5e, 0x1d, 0xd1,
-	0x35, 0xfa, 0xf7, 0x25, 0xe7, 0xf0, 0x70, 0x9c, 0x6c, 0x95, 0xe8, 0x1a, 0x0d, 0x38, 0xed, 0xe4,
-	0x91, 0x9e, 0x7b, 0xc3, 0x45, 0xdf, 0x4a, 0x12, 0xd4, 0x28, 0x84, 0x7f, 0x06, 0xa1, 0xe7, 0x05,
-	0x71, 0xf5, 0xe9, 0x8e, 0xa9, 0x6e, 0x9e, 0x84, 0x06, 0xac, 0x9d, 0x64, 0xe8, 0xf3, 0xe4, 0xea,
-	0x08, 0x84, 0xd9, 0xfe, 0xc1, 0x87, 0xf0, 0xc5, 0xeb, 0xe6, 0xd9, 0x2b, 0xc5, 0x32, 0xcf, 0xbb,
-	0x8a, 0xf5, 0x4a, 0x33, 0xba, 0x4a, 0x4b, 0xfd, 0x49, 0x55, 0xda, 0x62, 0x06, 0xef, 0x81, 0x98,
-	0xc2, 0x54, 0xcd, 0xfc, 0xf1, 0x07, 0x11, 0xe1, 0x7d, 0xd8, 0x4d, 0x67, 0xc4, 0xe1, 0x2c, 0x3e,
-	0x80, 0xfd, 0x54, 0xf8, 0xf4, 0xdc, 0x54, 0xac, 0xa6, 0xae, 0x37, 0xcf, 0xc5, 0xfc, 0x71, 0xbe,
-	0x90, 0x13, 0x73, 0xd5, 0x77, 0x08, 0x76, 0x96, 0x16, 0x12, 0x2e, 0xc3, 0xe3, 0x56, 0xe7, 0x65,
-	0x57, 0x57, 0x0c, 0x43, 0xed, 0x68, 0xeb, 0x66, 0x38, 0x80, 0xfd, 0x15, 0x86, 0xd6, 0xd1, 0x14,
-	0x11, 0xe1, 0x23, 0x78, 0xb4, 0x02, 0x19, 0x5a, 0xb3, 0xdb, 0x3d, 0x8f, 0xc7, 0x59, 0x01, 0x2f,
-	0x0c, 0xb3, 0x2d, 0xe6, 0xaa, 0x63, 0x28, 0xa5, 0xed, 0x8a, 0x9f, 0xc0, 0x81, 0xa2, 0xb5, 0x3a,
-	0x6d, 0x55, 0x7b, 0xb1, 0x6e, 0x82, 0x47, 0xf0, 0xf9, 0x22, 0xdc, 0x3d, 0x6b, 0xaa, 0x9a, 0x88,
-	0x56, 0x81, 0xb6, 0x72, 0x66, 0x36, 0xc5, 0x2c, 0x96, 0x60, 0x6f, 0x11, 0x38, 0x55, 0xcd, 0x97,
-	0xcd, 0xae, 0x98, 0xab, 0x0e, 0x60, 0x6b, 0x41, 0x16, 0x2c, 0xc3, 0xa1, 0xd1, 0xd1, 0x4d, 0xab,
-	0xad, 0xea, 0x4a, 0xcb, 0x8c, 0x26, 0x5d, 0x6c, 0xfe, 0x18, 0xa4, 0x25, 0xbc, 0x69, 0xb4, 0x14,
-	0x2d, 0x2a, 0x2d, 0xa2, 0x68, 0xf2, 0x25, 0xb4, 0xad, 0xcc, 0xe0, 0xec, 0xe9, 0xcd, 0xed, 0x9d,
-	0x9c, 0x79, 0x7f, 0x27, 0x67, 0x3e, 0xde, 0xc9, 0xe8, 0xf7, 0x89, 0x8c, 0xfe, 0x9c, 0xc8, 0xe8,
-	0xaf, 0x89, 0x8c, 0x6e, 0x27, 0x32, 0xfa, 0x7b, 0x22, 0xa3, 0x0f, 0x13, 0x39, 0xf3, 0x71, 0x22,
-	0xa3, 0x3f, 0xee, 0xe5, 0xcc, 0xed, 0xbd, 0x9c, 0x79, 0x7f, 0x2f, 0x67, 0x2e, 0x4e, 0xfb, 0x1e,
-	0xfb, 0x65, 0x74, 0x59, 0x73, 0xa8, 0x5f, 0xef, 0x07, 0xf6, 0x95, 0x4d, 0xec, 0xfa, 0x80, 0xbe,
-	0xf1, 0xea, 0xd7, 0x27, 0xf5, 0x4f, 0xfc, 0xc2, 0x5e, 0x3e, 0xe4, 0x1f, 0xd6, 0x93, 0x7f, 0x03,
-	0x00, 0x00, 0xff, 0xff, 0x2a, 0x2b, 0x5b, 0xb1, 0x93, 0x07, 0x00, 0x00,
+	0x18, 0x66, 0x81, 0xa4, 0xf0, 0x82, 0x6d, 0x79, 0x6b, 0x37, 0xf2, 0x47, 0x54, 0xea, 0xce, 0x34,
+	0x94, 0x64, 0x60, 0x8a, 0x33, 0xcd, 0x59, 0x16, 0x4a, 0xaa, 0x19, 0x47, 0x30, 0x92, 0x92, 0x19,
+	0x7c, 0xd1, 0xc8, 0x42, 0x50, 0x35, 0x48, 0xcb, 0x48, 0x8b, 0x6b, 0xe7, 0xd4, 0x9f, 0xd0, 0x5b,
+	0xaf, 0x3d, 0xf6, 0xa7, 0xf4, 0xe8, 0x63, 0x8e, 0x31, 0x9e, 0xe9, 0xe4, 0x98, 0x9f, 0xd0, 0xd1,
+	0x0a, 0x81, 0x00, 0x97, 0xf1, 0xa1, 0xb7, 0xdd, 0xe7, 0x79, 0xde, 0x8f, 0xdd, 0xf7, 0xd1, 0x0a,
+	0x5e, 0x8c, 0xde, 0x0d, 0x1a, 0x3d, 0x8b, 0x5a, 0xe4, 0xfc, 0x97, 0x86, 0xeb, 0x53, 0x27, 0xf0,
+	0xad, 0x61, 0xc3, 0x73, 0xa8, 0x15, 0x81, 0x8c, 0x09, 0x1d, 0xea, 0xf5, 0xe6, 0xab, 0xfa, 0x28,
+	0x20, 0x94, 0xe0, 0x83, 0x69, 0x50, 0x3d, 0xd1, 0xd6, 0xa7, 0x8a, 0xfa, 0xc5, 0x0f, 0x47, 0xff,
+	0xe4, 0x00, 0x24, 0x32, 0x1c, 0x7b, 0xbe, 0xe2, 0xf7, 0x09, 0xc6, 0x90, 0xf7, 0x2d, 0xcf, 0xe1,
+	0x51, 0x05, 0x55, 0x8b, 0x1a, 0x5b, 0x63, 0x19, 0xe0, 0xc2, 0x1a, 0x8e, 0x1d, 0x93, 0x5e, 0x8d,
+	0x1c, 0x3e, 0x5b, 0x41, 0xd5, 0xcd, 0xe6, 0x77, 0xf5, 0x35, 0x49, 0xeb, 0x6f, 0x23, 0xb9, 0x71,
+	0x35, 0x72, 0xb4, 0xe2, 0x45, 0xb2, 0xc4, 0x8f, 0x01, 0x02, 0xf2, 0x6b, 0x68, 0xda, 0x64, 0xec,
+	0x53, 0x3e, 0x57, 0x41, 0xd5, 0xbc, 0x56, 0x8c, 0x10, 0x29, 0x02, 0xb0, 0x0a, 0x25, 0x9b, 0x78,
+	0xa3, 0xc0, 0x09, 0x43, 0x97, 0xf8, 0x7c, 0x9e, 0x95, 0x79, 0xb6, 0xb6, 0x8c, 0x34, 0xd7, 0xb3,
+	0x62, 0xe9, 0x04, 0xf8, 0x29, 0x6c, 0x8f, 0xfd, 0x04, 0x70, 0x7a, 0x66, 0xe8, 0xbe, 0x77, 0xf8,
+	0x07, 0xac, 0x2a, 0x97, 0x26, 0x74, 0xf7, 0xbd, 0x83, 0x9f, 0xc0, 0xd6, 0xb2, 0xf4, 0x21, 0x93,
+	0x6e, 0xae, 0x0a, 0x93, 0x4e, 0x4c, 0xd2, 0xef, 0x87, 0x0e, 0xe5, 0xbf, 0x88, 0x85, 0x09, 0xdc,
+	0x66, 0x28, 0xfe, 0x16, 0x36, 0x66, 0x42, 0x96, 0xaf, 0xc0, 0x64, 0xe5, 0x04, 0x64, 0xd9, 0x5e,
+	0x01, 0x84, 0xd4, 0xa2, 0x6e, 0x48, 0x5d, 0x3b, 0xe4, 0x8b, 0x15, 0x54, 0x2d, 0x35, 0x9f, 0xac,
+	0x3d, 0xb2, 0x3e, 0x93, 0x6b, 0xa9, 0x50, 0xfc, 0x0d, 0x94, 0xd9, 0x45, 0x27, 0xb7, 0x0b, 0xac,
+	0x58, 0x29, 0xc6, 0xd8, 0xfd, 0x1e, 0x85, 0x00, 0xf3, 0x60, 0x7c, 0x00, 0x45, 0xcf, 0xf5, 0x4d,
+	0x26, 0x60, 0xc3, 0x2e, 0x6b, 0x05, 0xcf, 0xf5, 0xd9, 0xe0, 0x18, 0x69, 0x5d, 0x4e, 0xc9, 0xec,
+	0x94, 0xb4, 0x2e, 0x63, 0xf2, 0x29, 0x6c, 0xdb, 0x56, 0xd0, 0x73, 0x7d, 0x6b, 0xe8, 0xd2, 0xab,
+	0x85, 0x69, 0x72, 0x29, 0x22, 0x2e, 0xfa, 0x31, 0x07, 0x85, 0x8e, 0x35, 0x70, 0x98, 0xb7, 0xee,
+	0x9c, 0x08, 0xba, 0xff, 0x44, 0xb2, 0x77, 0x4e, 0x64, 0x07, 0x1e, 0xd8, 0x81, 0x7d, 0xdc, 0x64,
+	0x3d, 0x6c, 0x68, 0xf1, 0x66, 0xc9, 0x6c, 0xf9, 0x65, 0xb3, 0xc9, 0x50, 0x70, 0x7c, 0x9b, 0xf4,
+	0x5c, 0x7f, 0xc0, 0x3c, 0xb1, 0xd9, 0xfc, 0x7e, 0xed, 0xb5, 0xcb, 0x53, 0x31, 0xb3, 0xd9, 0x2c,
+	0x14, 0x7f, 0x0d, 0xa5, 0xb4, 0x13, 0x62, 0xcb, 0x40, 0xca, 0x05, 0x07, 0x50, 0x9c, 0x3b, 0x20,
+	0x36, 0x4a, 0xe1, 0x3f, 0xa6, 0x5f, 0xf8, 0xff, 0xa6, 0x5f, 0x5c, 0x99, 0x3e, 0xae, 0xc1, 0xf6,
+	0xf9, 0x90, 0x10, 0xcf, 0xec, 0xbb, 0x43, 0xea, 0x04, 0x71, 0x43, 0xb1, 0x4b, 0xb6, 0x18, 0xf1,
+	0x92, 0xe1, 0xac, 0xaf, 0x67, 0x80, 0x17, 0xb4, 0xf1, 0xf5, 0x96, 0xd8, 0xf5, 0x72, 0x29, 0xb1,
+	0x14, 0xe1, 0x47, 0x9f, 0x10, 0x6c, 0xe9, 0x8e, 0x4d, 0x5d, 0xe2, 0xeb, 0x24, 0xa0, 0x6c, 0xd2,
+	0x67, 0x50, 0xb6, 0xd9, 0x9b, 0x62, 0x86, 0x24, 0xa0, 0x21, 0x8f, 0x2a, 0xb9, 0x6a, 0xa9, 0xf9,
+	0x62, 0xfd, 0xd9, 0x16, 0x73, 0xd4, 0xe3, 0x47, 0x29, 0xda, 0x46, 0xdf, 0x75, 0xb2, 0x0e, 0xf7,
+	0xaf, 0x92, 0xf7, 0x2a, 0xda, 0x46, 0x47, 0x9f, 0x56, 0x72, 0xfd, 0x9e, 0x73, 0xc9, 0xec, 0xb4,
+	0x91, 0x04, 0x28, 0x11, 0x84, 0x7f, 0x82, 0x62, 0xcf, 0x0d, 0xe2, 0xec, 0xd3, 0xd7, 0xab, 0xb6,
+	0xbe, 0x13, 0x12, 0xd0, 0x56, 0x12, 0xa1, 0xcd, 0x83, 0x6b, 0x63, 0x28, 0xce, 0x5e, 0x36, 0xbc,
+	0x0f, 0x5f, 0xbd, 0x15, 0x4f, 0xdf, 0xc8, 0xa6, 0xd1, 0xed, 0xc8, 0xe6, 0x1b, 0x55, 0xef, 0xc8,
+	0x92, 0xf2, 0x52, 0x91, 0x5b, 0x5c, 0x06, 0xef, 0x00, 0x97, 0xe2, 0x14, 0xd5, 0xf8, 0xf1, 0x39,
+	0x87, 0xf0, 0x2e, 0x6c, 0xa7, 0x23, 0x62, 0x38, 0x8b, 0xf7, 0x60, 0x37, 0x05, 0x9f, 0x74, 0x0d,
+	0xd9, 0x14, 0x35, 0x4d, 0xec, 0x72, 0xf9, 0xa3, 0x7c, 0x21, 0xc7, 0xe5, 0x6a, 0x7f, 0x22, 0xd8,
+	0x5a, 0x7a, 0xea, 0x70, 0x05, 0x0e, 0xa5, 0xf6, 0xeb, 0x8e, 0x26, 0xeb, 0xba, 0xd2, 0x56, 0xef,
+	0xea, 0x61, 0x0f, 0x76, 0x57, 0x14, 0x6a, 0x5b, 0x95, 0x39, 0x84, 0x0f, 0xe0, 0xd1, 0x0a, 0xa5,
+	0xab, 0x62, 0xa7, 0xd3, 0x8d, 0xdb, 0x59, 0x21, 0xcf, 0x74, 0xa3, 0xc5, 0xe5, 0x30, 0x0f, 0x3b,
+	0x2b, 0xd4, 0xe9, 0xd9, 0x73, 0x2e, 0x5f, 0xfb, 0x03, 0x41, 0x39, 0xfd, 0x8d, 0xe0, 0xc7, 0xb0,
+	0x27, 0xab, 0x52, 0xbb, 0xa5, 0xa8, 0xaf, 0xee, 0x6a, 0xee, 0x11, 0x7c, 0xb9, 0x48, 0x77, 0x4e,
+	0x45, 0x45, 0xe5, 0xd0, 0x2a, 0xd1, 0x92, 0x4f, 0x0d, 0x91, 0xcb, 0x46, 0xb5, 0x17, 0x89, 0x13,
+	0xc5, 0x78, 0x2d, 0x76, 0xb8, 0x1c, 0x3e, 0x04, 0x7e, 0x29, 0x44, 0x91, 0x0c, 0xa5, 0xad, 0x8a,
+	0x5a, 0x97, 0xcb, 0xd7, 0x86, 0xb0, 0xb1, 0x30, 0x4f, 0x2c, 0xc0, 0xbe, 0xde, 0xd6, 0x0c, 0xb3,
+	0xa5, 0x68, 0x32, 0xd3, 0x2d, 0xb5, 0x76, 0x08, 0xfc, 0x12, 0x2f, 0xea, 0x92, 0xac, 0x46, 0xe9,
+	0x39, 0x14, 0x9d, 0x6b, 0x89, 0x6d, 0xc9, 0x33, 0x3a, 0x7b, 0x72, 0x79, 0x7d, 0x23, 0x64, 0x3e,
+	0xdc, 0x08, 0x99, 0xcf, 0x37, 0x02, 0xfa, 0x6d, 0x22, 0xa0, 0xbf, 0x26, 0x02, 0xfa, 0x7b, 0x22,
+	0xa0, 0xeb, 0x89, 0x80, 0x3e, 0x4e, 0x04, 0xf4, 0x69, 0x22, 0x64, 0x3e, 0x4f, 0x04, 0xf4, 0xfb,
+	0xad, 0x90, 0xb9, 0xbe, 0x15, 0x32, 0x1f, 0x6e, 0x85, 0xcc, 0xd9, 0xc9, 0xc0, 0xa5, 0x3f, 0x8f,
+	0xcf, 0xeb, 0x36, 0xf1, 0x1a, 0x83, 0xc0, 0xea, 0x5b, 0xbe, 0xd5, 0x18, 0x92, 0x77, 0x6e, 0xe3,
+	0xe2, 0xb8, 0x71, 0xcf, 0x9f, 0xfe, 0xf9, 0x43, 0xf6, 0xaf, 0x3f, 0xfe, 0x77, 0x00, 0xa0, 0x43,
+	0x4e, 0x44, 0x26, 0x08, 0x00, 0x00,
 }
 
 func (x ValueType) String() string {
@@ -813,6 +853,12 @@ func (this *PageInfo) Equal(that interface{}) bool {
 	if this.ValuesCount != that1.ValuesCount {
 		return false
 	}
+	if this.BloomFilterSize != that1.BloomFilterSize {
+		return false
+	}
+	if this.BloomFilterCrc32 != that1.BloomFilterCrc32 {
+		return false
+	}
 	return true
 }
 func (this *SectionSortInfo) Equal(that interface{}) bool {
@@ -908,7 +954,7 @@ func (this *PageInfo) GoString() string {
 	if this == nil {
 		return "nil"
 	}
-	s := make([]string, 0, 13)
+	s := make([]string, 0, 15)
 	s = append(s, "&datasetmd.PageInfo{")
 	s = append(s, "UncompressedSize: "+fmt.Sprintf("%#v", this.UncompressedSize)+",\n")
 	s = append(s, "CompressedSize: "+fmt.Sprintf("%#v", this.CompressedSize)+",\n")
@@ -921,6 +967,8 @@ func (this *PageInfo) GoString() string {
 		s = append(s, "Statistics: "+fmt.Sprintf("%#v", this.Statistics)+",\n")
 	}
 	s = append(s, "ValuesCount: "+fmt.Sprintf("%#v", this.ValuesCount)+",\n")
+	s = append(s, "BloomFilterSize: "+fmt.Sprintf("%#v", this.BloomFilterSize)+",\n")
+	s = append(s, "BloomFilterCrc32: "+fmt.Sprintf("%#v", this.BloomFilterCrc32)+",\n")
 	s = append(s, "}")
 	return strings.Join(s, "")
 }
@@ -1099,6 +1147,16 @@ func (m *PageInfo) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	_ = i
 	var l int
 	_ = l
+	if m.BloomFilterCrc32 != 0 {
+		i = encodeVarintDatasetmd(dAtA, i, uint64(m.BloomFilterCrc32))
+		i--
+		dAtA[i] = 0x58
+	}
+	if m.BloomFilterSize != 0 {
+		i = encodeVarintDatasetmd(dAtA, i, uint64(m.BloomFilterSize))
+		i--
+		dAtA[i] = 0x50
+	}
 	if m.ValuesCount != 0 {
 		i = encodeVarintDatasetmd(dAtA, i, uint64(m.ValuesCount))
 		i--
@@ -1330,6 +1388,12 @@ func (m *PageInfo) Size() (n int) {
 	if m.ValuesCount != 0 {
 		n += 1 + sovDatasetmd(uint64(m.ValuesCount))
 	}
+	if m.BloomFilterSize != 0 {
+		n += 1 + sovDatasetmd(uint64(m.BloomFilterSize))
+	}
+	if m.BloomFilterCrc32 != 0 {
+		n += 1 + sovDatasetmd(uint64(m.BloomFilterCrc32))
+	}
 	return n
 }
 
@@ -1414,6 +1478,8 @@ func (this *PageInfo) String() string {
 		`DataSize:` + fmt.Sprintf("%v", this.DataSize) + `,`,
 		`Statistics:` + strings.Replace(this.Statistics.String(), "Statistics", "Statistics", 1) + `,`,
 		`ValuesCount:` + fmt.Sprintf("%v", this.ValuesCount) + `,`,
+		`BloomFilterSize:` + fmt.Sprintf("%v", this.BloomFilterSize) + `,`,
+		`BloomFilterCrc32:` + fmt.Sprintf("%v", this.BloomFilterCrc32) + `,`,
 		`}`,
 	}, "")
 	return s
@@ -2082,6 +2148,44 @@ func (m *PageInfo) Unmarshal(dAtA []byte) error {
 					break
 				}
 			}
+		case 10:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field BloomFilterSize", wireType)
+			}
+			m.BloomFilterSize = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowDatasetmd
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.BloomFilterSize |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 11:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field BloomFilterCrc32", wireType)
+			}
+			m.BloomFilterCrc32 = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowDatasetmd
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.BloomFilterCrc32 |= uint32(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
 		default:
 			iNdEx = preIndex
 			skippy, err := skipDatasetmd(dAtA[iNdEx:])
@@ -50,6 +50,17 @@ var metastoreBuilderCfg = logsobj.BuilderConfig{
 	SectionStripeMergeLimit: 2,
 }
 
+// errEmptyWindow is returned when no entries are left in a metastore object
+// after replacing entries.
+var errEmptyWindow = errors.New("no entries left in metastore window")
+
+// Entry is a data object referenced by a metastore object, along with the
+// time range of its logs.
+type Entry struct {
+	Path       string
+	Start, End time.Time
+}
+
 type Updater struct {
 	cfg              UpdaterConfig
 	builder          *indexobj.Builder // New index pointer based builder.
@@ -127,78 +138,139 @@ func (m *Updater) Update(ctx context.Context, dataobjPath string, minTimestamp,
 		return err
 	}
 
+	added := []Entry{{Path: dataobjPath, Start: minTimestamp, End: maxTimestamp}}
+
 	// Work our way through the metastore objects window by window, updating & creating them as needed.
 	// Each one handles its own retries in order to keep making progress in the event of a failure.
 	for metastorePath := range iterStorePaths(m.tenantID, minTimestamp, maxTimestamp) {
-		m.backoff.Reset()
-		for m.backoff.Ongoing() {
-			err = m.bucket.GetAndReplace(ctx, metastorePath, func(existing io.Reader) (io.Reader, error) {
-				m.buf.Reset()
-				if existing != nil {
-					level.Debug(m.logger).Log("msg", "found existing metastore, updating", "path", metastorePath)
-					_, err := io.Copy(m.buf, existing)
-					if err != nil {
-						return nil, errors.Wrap(err, "copying to local buffer")
-					}
-				} else {
-					level.Debug(m.logger).Log("msg", "no existing metastore found, creating new one", "path", metastorePath)
+		err = m.updateWindow(ctx, metastorePath, nil, added)
+	}
+	return err
+}
+
+// Replace atomically replaces the entries with the removed paths in the
+// metastore object of the window containing the given time with the added
+// entries. Entries of other windows are not modified, so callers must only
+// replace objects whose time range is contained in the window, or replace
+// them in all windows they overlap.
+//
+// Existing entries with the path of an added entry are replaced too, so
+// calling Replace again with the same arguments leaves the metastore object
+// unchanged. The metastore object is deleted if no entries are left in it.
+func (m *Updater) Replace(ctx context.Context, window time.Time, removed []string, added []Entry) error {
+	processingTime := prometheus.NewTimer(m.metrics.metastoreProcessingTime)
+	defer processingTime.ObserveDuration()
+
+	if err := m.initBuilder(); err != nil {
+		return err
+	}
+
+	removedPaths := make(map[string]struct{}, len(removed)+len(added))
+	for _, path := range removed {
+		removedPaths[path] = struct{}{}
+	}
+	for _, entry := range added {
+		removedPaths[entry.Path] = struct{}{}
+	}
+
+	metastorePath := metastorePath(m.tenantID, window.Truncate(metastoreWindowSize).UTC())
+	return m.updateWindow(ctx, metastorePath, removedPaths, added)
+}
+
+// updateWindow replays the entries of the metastore object at metastorePath,
+// except for those with removed paths, and appends the added entries to it.
+// The object is created if it doesn't exist yet.
+func (m *Updater) updateWindow(ctx context.Context, metastorePath string, removed map[string]struct{}, added []Entry) error {
+	var err error
+
+	m.backoff.Reset()
+	for m.backoff.Ongoing() {
+		err = m.bucket.GetAndReplace(ctx, metastorePath, func(existing io.Reader) (io.Reader, error) {
+			m.buf.Reset()
+			if existing != nil {
+				level.Debug(m.logger).Log("msg", "found existing metastore, updating", "path", metastorePath)
+				_, err := io.Copy(m.buf, existing)
+				if err != nil {
+					return nil, errors.Wrap(err, "copying to local buffer")
 				}
+			} else {
+				level.Debug(m.logger).Log("msg", "no existing metastore found, creating new one", "path", metastorePath)
+			}
 
-				m.metastoreBuilder.Reset()
-				m.builder.Reset()
-				ty := m.cfg.StorageFormat
+			m.metastoreBuilder.Reset()
+			m.builder.Reset()
+			ty, kept := m.cfg.StorageFormat, 0
 
-				if m.buf.Len() > 0 {
-					replayDuration := prometheus.NewTimer(m.metrics.metastoreReplayTime)
-					object, err := dataobj.FromReaderAt(bytes.NewReader(m.buf.Bytes()), int64(m.buf.Len()))
-					if err != nil {
-						return nil, errors.Wrap(err, "creating object from buffer")
-					}
-					ty, err = m.readFromExisting(ctx, object)
-					if err != nil {
-						return nil, errors.Wrap(err, "reading existing metastore version")
-					}
-					replayDuration.ObserveDuration()
+			if m.buf.Len() > 0 {
+				replayDuration := prometheus.NewTimer(m.metrics.metastoreReplayTime)
+				object, err := dataobj.FromReaderAt(bytes.NewReader(m.buf.Bytes()), int64(m.buf.Len()))
+				if err != nil {
+					return nil, errors.Wrap(err, "creating object from buffer")
 				}
+				ty, kept, err = m.readFromExisting(ctx, object, removed)
+				if err != nil {
+					return nil, errors.Wrap(err, "reading existing metastore version")
+				}
+				replayDuration.ObserveDuration()
+			}
+			if kept == 0 && len(added) == 0 {
+				return nil, errEmptyWindow
+			}
 
-				encodingDuration := prometheus.NewTimer(m.metrics.metastoreEncodingTime)
-				err = m.append(ty, dataobjPath, minTimestamp, maxTimestamp)
+			encodingDuration := prometheus.NewTimer(m.metrics.metastoreEncodingTime)
+			for _, entry := range added {
+				err = m.append(ty, entry.Path, entry.Start, entry.End)
 				if err != nil {
 					return nil, errors.Wrap(err, "appending to metastore builder")
 				}
+			}
 
-				m.buf.Reset()
+			m.buf.Reset()
 
-				switch ty {
-				case StorageFormatTypeV1:
-					_, err = m.metastoreBuilder.Flush(m.buf)
-					if err != nil {
-						return nil, errors.Wrap(err, "flushing metastore builder")
-					}
-				case StorageFormatTypeV2:
-					_, err = m.builder.Flush(m.buf)
-					if err != nil {
-						return nil, errors.Wrap(err, "flushing metastore builder")
-					}
-				default:
-					return nil, errors.New("unknown metastore top-level object type")
+			switch ty {
+			case StorageFormatTypeV1:
+				_, err = m.metastoreBuilder.Flush(m.buf)
+				if err != nil {
+					return nil, errors.Wrap(err, "flushing metastore builder")
+				}
+			case StorageFormatTypeV2:
+				_, err = m.builder.Flush(m.buf)
+				if err != nil {
+					return nil, errors.Wrap(err, "flushing metastore builder")
 				}
+			default:
+				return nil, errors.New("unknown metastore top-level object type")
+			}
 
-				encodingDuration.ObserveDuration()
-				return m.buf, nil
-			})
-			if err == nil {
-				level.Info(m.logger).Log("msg", "successfully merged & updated metastore", "metastore", metastorePath)
-				m.metrics.incMetastoreWrites(statusSuccess)
+			encodingDuration.ObserveDuration()
+			return m.buf, nil
+		})
+		if errors.Is(err, errEmptyWindow) {
+			// Metastore objects can't be empty, so the object is deleted instead.
+			// Entries appended concurrently between reading and deleting the
+			// object are lost, so only windows which no longer receive new
+			// objects may be emptied.
+			err = m.bucket.Delete(ctx, metastorePath)
+			if err == nil || m.bucket.IsObjNotFoundErr(err) {
+				level.Info(m.logger).Log("msg", "deleted empty metastore", "metastore", metastorePath)
+				err = nil
 				break
 			}
-			level.Error(m.logger).Log("msg", "failed to get and replace metastore object", "err", err, "metastore", metastorePath)
-			m.metrics.incMetastoreWrites(statusFailure)
-			m.backoff.Wait()
+		} else if err == nil {
+			level.Info(m.logger).Log("msg", "successfully merged & updated metastore", "metastore", metastorePath)
+			m.metrics.incMetastoreWrites(statusSuccess)
+			break
+		}
+		level.Error(m.logger).Log("msg", "failed to get and replace metastore object", "err", err, "metastore", metastorePath)
+		m.metrics.incMetastoreWrites(statusFailure)
+		if ctx.Err() != nil {
+			// Stop retrying once the caller gave up, e.g. on shutdown.
+			break
 		}
-		// Reset at the end too so we don't leave our memory hanging around between calls.
-		m.metastoreBuilder.Reset()
+		m.backoff.Wait()
 	}
+	// Reset at the end too so we don't leave our memory hanging around between calls.
+	m.metastoreBuilder.Reset()
 	return err
 }
 
@@ -233,7 +305,10 @@ func (m *Updater) append(ty StorageFormatType, dataobjPath string, minTimestamp,
 }
 
 // readFromExisting reads the provided metastore object and appends the streams to the builder so it can be later modified.
-func (m *Updater) readFromExisting(ctx context.Context, object *dataobj.Object) (StorageFormatType, error) {
+// Entries with a path in removed are skipped. The number of appended entries is returned along with the format of the object.
+func (m *Updater) readFromExisting(ctx context.Context, object *dataobj.Object, removed map[string]struct{}) (StorageFormatType, int, error) {
+	var kept int
+
 	var streamsReader streams.RowReader
 	defer streamsReader.Close()
 
@@ -254,48 +329,56 @@ func (m *Updater) readFromExisting(ctx context.Context, object *dataobj.Object)
 		case streams.CheckSection(section):
 			sec, err := streams.Open(ctx, section)
 			if err != nil {
-				return StorageFormatTypeV1, errors.Wrap(err, "opening section")
+				return StorageFormatTypeV1, 0, errors.Wrap(err, "opening section")
 			}
 
 			streamsReader.Reset(sec)
 			for n, err := streamsReader.Read(ctx, buf); n > 0; n, err = streamsReader.Read(ctx, buf) {
 				if err != nil && err != io.EOF {
-					return StorageFormatTypeV1, errors.Wrap(err, "reading streams")
+					return StorageFormatTypeV1, 0, errors.Wrap(err, "reading streams")
 				}
 				for _, stream := range buf[:n] {
+					if _, ok := removed[stream.Labels.Get(labelNamePath)]; ok {
+						continue
+					}
 					err = m.metastoreBuilder.Append(logproto.Stream{
 						Labels:  stream.Labels.String(),
 						Entries: []logproto.Entry{{Line: ""}},
 					})
 					if err != nil {
-						return StorageFormatTypeV1, errors.Wrap(err, "appending streams")
+						return StorageFormatTypeV1, 0, errors.Wrap(err, "appending streams")
 					}
+					kept++
 				}
 			}
 
-			return StorageFormatTypeV1, nil
+			return StorageFormatTypeV1, kept, nil
 		// New standard approach for metastore top-level objects.
 		case indexpointers.CheckSection(section):
 			sec, err := indexpointers.Open(ctx, section)
 			if err != nil {
-				return StorageFormatTypeV2, errors.Wrap(err, "opening section")
+				return StorageFormatTypeV2, 0, errors.Wrap(err, "opening section")
 			}
 			indexPointersReader.Reset(sec)
 			for n, err := indexPointersReader.Read(ctx, pbuf); n > 0; n, err = indexPointersReader.Read(ctx, pbuf) {
 				if err != nil && err != io.EOF {
-					return StorageFormatTypeV2, errors.Wrap(err, "reading index pointers")
+					return StorageFormatTypeV2, 0, errors.Wrap(err, "reading index pointers")
 				}
 				for _, indexPointer := range pbuf[:n] {
+					if _, ok := removed[indexPointer.Path]; ok {
+						continue
+					}
 					err = m.builder.AppendIndexPointer(indexPointer.Path, indexPointer.StartTs, indexPointer.EndTs)
 					if err != nil {
-						return StorageFormatTypeV2, errors.Wrap(err, "appending index pointers")
+						return StorageFormatTypeV2, 0, errors.Wrap(err, "appending index pointers")
 					}
+					kept++
 				}
 			}
 
-			return StorageFormatTypeV2, nil
+			return StorageFormatTypeV2, kept, nil
 		}
 	}
 
-	return m.cfg.StorageFormat, nil
+	return m.cfg.StorageFormat, kept, nil
 }
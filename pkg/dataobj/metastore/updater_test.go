@@ -3,6 +3,7 @@ package metastore
 import (
 	"bytes"
 	"context"
+	"fmt"
 	io "io"
 	"strconv"
 	"sync"
@@ -104,12 +105,60 @@ func TestUpdater(t *testing.T) {
 		dobj, err := dataobj.FromReaderAt(bytes.NewReader(object), int64(len(object)))
 		require.NoError(t, err)
 
-		ty, err := updater.readFromExisting(context.Background(), dobj)
+		ty, _, err := updater.readFromExisting(context.Background(), dobj, nil)
 		require.NoError(t, err)
 		require.Equal(t, StorageFormatTypeV1, ty)
 	})
 }
 
+func TestUpdater_Replace(t *testing.T) {
+	for _, format := range []StorageFormatType{StorageFormatTypeV1, StorageFormatTypeV2} {
+		t.Run(fmt.Sprintf("format %d", format), func(t *testing.T) {
+			var (
+				ctx      = context.Background()
+				tenantID = "test"
+				bucket   = newInMemoryBucket(t, tenantID, unixTime(0), nil)
+				updater  = NewUpdater(UpdaterConfig{StorageFormat: format}, bucket, tenantID, log.NewNopLogger())
+			)
+
+			for i, path := range []string{"a", "b", "c"} {
+				err := updater.Update(ctx, path, unixTime(int64(10*i+1)), unixTime(int64(10*i+10)))
+				require.NoError(t, err)
+			}
+
+			err := updater.Replace(ctx, unixTime(0), []string{"a", "c"}, []Entry{{Path: "ac", Start: unixTime(1), End: unixTime(30)}})
+			require.NoError(t, err)
+
+			entries, err := ReadWindow(ctx, bucket, tenantID, unixTime(0))
+			require.NoError(t, err)
+			require.ElementsMatch(t, []Entry{
+				{Path: "b", Start: unixTime(11), End: unixTime(20)},
+				{Path: "ac", Start: unixTime(1), End: unixTime(30)},
+			}, entries)
+
+			// Replacing again doesn't duplicate the added entry.
+			err = updater.Replace(ctx, unixTime(0), []string{"a", "c"}, []Entry{{Path: "ac", Start: unixTime(1), End: unixTime(30)}})
+			require.NoError(t, err)
+
+			entries, err = ReadWindow(ctx, bucket, tenantID, unixTime(0))
+			require.NoError(t, err)
+			require.Len(t, entries, 2)
+
+			windows, err := Windows(ctx, bucket, tenantID)
+			require.NoError(t, err)
+			require.Equal(t, []time.Time{unixTime(0)}, windows)
+
+			// Removing all entries deletes the metastore object.
+			err = updater.Replace(ctx, unixTime(0), []string{"ac", "b"}, nil)
+			require.NoError(t, err)
+
+			windows, err = Windows(ctx, bucket, tenantID)
+			require.NoError(t, err)
+			require.Empty(t, windows)
+		})
+	}
+}
+
 func newUpdater(t *testing.T, tenantID string, bucket objstore.Bucket, v1 *logsobj.Builder, v2 *indexobj.Builder) *Updater {
 	t.Helper()
 
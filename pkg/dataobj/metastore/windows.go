@@ -0,0 +1,123 @@
+package metastore
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/thanos-io/objstore"
+
+	"github.com/grafana/loki/v3/pkg/dataobj"
+	"github.com/grafana/loki/v3/pkg/dataobj/sections/indexpointers"
+	"github.com/grafana/loki/v3/pkg/dataobj/sections/streams"
+)
+
+// WindowSize is the duration of the time window covered by each metastore
+// object. Windows start at multiples of WindowSize since the Unix epoch.
+const WindowSize = metastoreWindowSize
+
+// Windows returns the start times of the windows of all metastore objects of
+// the given tenant in bucket, in ascending order.
+func Windows(ctx context.Context, bucket objstore.BucketReader, tenantID string) ([]time.Time, error) {
+	dir := path.Dir(metastorePath(tenantID, time.Time{})) + "/"
+
+	var windows []time.Time
+	err := bucket.Iter(ctx, dir, func(name string) error {
+		window, err := time.Parse(time.RFC3339, strings.TrimSuffix(strings.TrimPrefix(name, dir), ".store"))
+		if err != nil {
+			// Skip over unrelated objects.
+			return nil
+		}
+		windows = append(windows, window.UTC())
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("listing metastore objects: %w", err)
+	}
+
+	slices.SortFunc(windows, func(a, b time.Time) int { return a.Compare(b) })
+	return windows, nil
+}
+
+// ReadWindow returns the entries of the metastore object of the given tenant
+// for the window containing the given time. ReadWindow returns an empty slice
+// if the metastore object doesn't exist.
+func ReadWindow(ctx context.Context, bucket objstore.Bucket, tenantID string, window time.Time) ([]Entry, error) {
+	storePath := metastorePath(tenantID, window.Truncate(metastoreWindowSize).UTC())
+
+	rc, err := bucket.Get(ctx, storePath)
+	if bucket.IsObjNotFoundErr(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("getting metastore object: %w", err)
+	}
+	defer rc.Close()
+
+	var buf bytes.Buffer
+	if _, err := buf.ReadFrom(rc); err != nil {
+		return nil, fmt.Errorf("reading metastore object: %w", err)
+	}
+	object, err := dataobj.FromReaderAt(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		return nil, fmt.Errorf("getting object from reader: %w", err)
+	}
+
+	var (
+		entries  []Entry
+		parseErr error
+	)
+
+	// Entries of the old format are stored as streams.
+	err = forEachStream(ctx, object, nil, func(stream streams.Stream) {
+		entry, err := entryFromLabels(stream.Labels)
+		if err != nil {
+			parseErr = err
+			return
+		}
+		entries = append(entries, entry)
+	})
+	if err != nil {
+		return nil, err
+	} else if parseErr != nil {
+		return nil, parseErr
+	}
+
+	// Entries of the new format are stored as index pointers.
+	err = forEachIndexPointer(ctx, object, nil, func(indexPointer indexpointers.IndexPointer) {
+		entries = append(entries, Entry{
+			Path:  indexPointer.Path,
+			Start: indexPointer.StartTs.UTC(),
+			End:   indexPointer.EndTs.UTC(),
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
+
+// entryFromLabels returns the entry stored in the labels of a stream of a
+// metastore object of the old format.
+func entryFromLabels(lbs labels.Labels) (Entry, error) {
+	start, err := strconv.ParseInt(lbs.Get(labelNameStart), 10, 64)
+	if err != nil {
+		return Entry{}, fmt.Errorf("parsing %s label: %w", labelNameStart, err)
+	}
+	end, err := strconv.ParseInt(lbs.Get(labelNameEnd), 10, 64)
+	if err != nil {
+		return Entry{}, fmt.Errorf("parsing %s label: %w", labelNameEnd, err)
+	}
+
+	return Entry{
+		Path:  lbs.Get(labelNamePath),
+		Start: time.Unix(0, start).UTC(),
+		End:   time.Unix(0, end).UTC(),
+	}, nil
+}
@@ -0,0 +1,27 @@
+package indexpointers
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/grafana/loki/v3/pkg/dataobj/internal/dataset"
+)
+
+// Verify reads and decodes all pages of the indexpointers section with
+// [dataset.VerifyColumns]. All columns must have the same number of rows.
+func Verify(ctx context.Context, section *Section) error {
+	dset, err := newColumnsDataset(section.Columns())
+	if err != nil {
+		return fmt.Errorf("creating dataset: %w", err)
+	}
+
+	names := make([]string, len(section.Columns()))
+	for i, col := range section.Columns() {
+		names[i] = col.Type.String()
+		if col.Name != "" {
+			names[i] += "[" + col.Name + "]"
+		}
+	}
+
+	return dataset.VerifyColumns(ctx, dset, names, dataset.EqualRows())
+}
@@ -4,6 +4,8 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
+	"iter"
 	"time"
 
 	"github.com/klauspost/compress/zstd"
@@ -40,6 +42,28 @@ type BuilderOptions struct {
 	// increase time spent merging. Higher values of StripeMergeLimit increase
 	// memory overhead but reduce time spent merging.
 	StripeMergeLimit int
+
+	// Compression configures the compression of the log lines and metadata of
+	// the section. Stripes are always compressed with the fastest zstd level,
+	// as they're only held in memory until they're merged into a section.
+	Compression dataobj.CompressionConfig
+
+	// DictionaryEncodeMetadata enables dictionary encoding of the metadata
+	// columns of the section. Pages of metadata columns with too many distinct
+	// values fall back to plain encoding. Stripes are always plain-encoded.
+	DictionaryEncodeMetadata bool
+
+	// BloomFilterMetadata enables storing a bloom filter of the values of
+	// plain-encoded pages of metadata columns, after the values of each page.
+	// Readers which don't support bloom filters fail to decode these pages.
+	BloomFilterMetadata bool
+
+	// IndexLines, if set, is called once for each section when it's flushed,
+	// with a sequence of the row numbers and log lines of the section in row
+	// order. It allows indexes over the lines of a section, such as the tokens
+	// section, to be built alongside it. The lines must not be retained after
+	// the sequence advances.
+	IndexLines func(lines iter.Seq2[int, []byte])
 }
 
 // Builder accumulate a set of [Record]s within a data object.
@@ -83,6 +107,11 @@ func NewBuilder(metrics *Metrics, opts BuilderOptions) *Builder {
 	return &Builder{
 		metrics: metrics,
 		opts:    opts,
+
+		sectionBuffer: tableBuffer{
+			dictionaryMetadata:  opts.DictionaryEncodeMetadata,
+			bloomFilterMetadata: opts.BloomFilterMetadata,
+		},
 	}
 }
 
@@ -137,15 +166,12 @@ func (b *Builder) flushRecords() {
 	b.recordsSize = 0
 }
 
-func (b *Builder) flushSection() *table {
+func (b *Builder) flushSection(compression datasetmd.CompressionType, compressionOpts dataset.CompressionOptions) *table {
 	if len(b.stripes) == 0 {
 		return nil
 	}
 
-	compressionOpts := dataset.CompressionOptions{
-		Zstd: []zstd.EOption{zstd.WithEncoderLevel(zstd.SpeedDefault)},
-	}
-
+	b.sectionBuffer.compression = compression
 	section, err := mergeTablesIncremental(&b.sectionBuffer, b.opts.PageSizeHint, compressionOpts, b.stripes, b.opts.StripeMergeLimit)
 	if err != nil {
 		// We control the input to mergeTables, so this should never happen.
@@ -186,10 +212,15 @@ func (b *Builder) Flush(w dataobj.SectionWriter) (n int64, err error) {
 	timer := prometheus.NewTimer(b.metrics.encodeSeconds)
 	defer timer.ObserveDuration()
 
+	compression, compressionOpts, err := dataset.ParseCompression(b.opts.Compression.Codec, b.opts.Compression.Level)
+	if err != nil {
+		return 0, fmt.Errorf("invalid compression: %w", err)
+	}
+
 	// Flush any remaining buffered data.
 	b.flushRecords()
 
-	section := b.flushSection()
+	section := b.flushSection(compression, compressionOpts)
 	if section == nil {
 		return 0, nil
 	}
@@ -203,6 +234,12 @@ func (b *Builder) Flush(w dataobj.SectionWriter) (n int64, err error) {
 	// column. This will reduce the number of columns in the section and thus the
 	// metadata size.
 
+	if b.opts.IndexLines != nil {
+		if err := indexLines(section, b.opts.IndexLines); err != nil {
+			return 0, fmt.Errorf("indexing lines: %w", err)
+		}
+	}
+
 	var logsEnc encoder
 	if err := b.encodeSection(&logsEnc, section); err != nil {
 		return 0, fmt.Errorf("encoding section: %w", err)
@@ -231,6 +268,40 @@ func (b *Builder) Flush(w dataobj.SectionWriter) (n int64, err error) {
 	return n, err
 }
 
+// indexLines passes the log lines of section to fn in row order.
+func indexLines(section *table, fn func(lines iter.Seq2[int, []byte])) error {
+	r := dataset.NewReader(dataset.ReaderOptions{
+		Dataset: section,
+		Columns: []dataset.Column{section.Message},
+	})
+	defer r.Close()
+
+	var err error
+	fn(func(yield func(int, []byte) bool) {
+		rows := make([]dataset.Row, 128)
+		for {
+			// Our section is in memory, so we don't need a "real" context here.
+			n, readErr := r.Read(context.Background(), rows)
+			if readErr != nil && !errors.Is(readErr, io.EOF) {
+				err = readErr
+				return
+			} else if n == 0 && errors.Is(readErr, io.EOF) {
+				return
+			}
+
+			for _, row := range rows[:n] {
+				line := row.Values[0]
+				if line.IsNil() {
+					continue
+				} else if !yield(row.Index, line.ByteArray()) {
+					return
+				}
+			}
+		}
+	})
+	return err
+}
+
 func (b *Builder) encodeSection(enc *encoder, section *table) error {
 	{
 		errs := make([]error, 0, len(section.Metadatas)+3)
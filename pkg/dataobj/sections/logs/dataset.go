@@ -15,7 +15,7 @@ type columnsDataset struct {
 	cols []dataset.Column
 }
 
-var _ dataset.Dataset = (*columnsDataset)(nil)
+var _ dataset.BloomFilterReader = (*columnsDataset)(nil)
 
 // newColumnsDataset returns a new [columnsDataset] from a set of [Column]s.
 // newColumnsDataset returns an error if not all columns are from the same
@@ -112,6 +112,28 @@ func (ds *columnsDataset) ReadPages(ctx context.Context, pages []dataset.Page) r
 	})
 }
 
+func (ds *columnsDataset) ReadBloomFilters(ctx context.Context, pages []dataset.Page) result.Seq[[]byte] {
+	return result.Iter(func(yield func([]byte) bool) error {
+		pageDescs := make([]*logsmd.PageDesc, len(pages))
+		for i, page := range pages {
+			page, ok := page.(*datasetPage)
+			if !ok {
+				return fmt.Errorf("unexpected page type: got=%T want=*datasetPage", page)
+			}
+			pageDescs[i] = page.desc
+		}
+
+		for result := range ds.dec.ReadBloomFilters(ctx, pageDescs) {
+			data, err := result.Value()
+			if err != nil || !yield(data) {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
 type columnDataset struct {
 	dec *decoder
 
@@ -187,6 +209,9 @@ func newDatasetPage(dec *decoder, desc *logsmd.PageDesc) *datasetPage {
 
 			Encoding: info.Encoding,
 			Stats:    info.Statistics,
+
+			BloomFilterSize:  int(info.BloomFilterSize),
+			BloomFilterCRC32: info.BloomFilterCrc32,
 		},
 	}
 }
@@ -112,23 +112,49 @@ func readAndClose(rc io.ReadCloser, size uint64) ([]byte, error) {
 // matching the argument order. If an error is encountered while retrieving
 // pages, an error is emitted from the sequence and iteration stops.
 func (rd *decoder) ReadPages(ctx context.Context, pages []*logsmd.PageDesc) result.Seq[dataset.PageData] {
-	return result.Iter(func(yield func(dataset.PageData) bool) error {
-		results := make([]dataset.PageData, len(pages))
+	pageInfo := func(p *logsmd.PageDesc) (uint64, uint64) {
+		return p.GetInfo().DataOffset, p.GetInfo().DataSize
+	}
 
-		pageInfo := func(p *logsmd.PageDesc) (uint64, uint64) {
-			return p.GetInfo().DataOffset, p.GetInfo().DataSize
+	return result.Iter(func(yield func(dataset.PageData) bool) error {
+		for result := range rd.readRanges(ctx, pages, pageInfo) {
+			data, err := result.Value()
+			if err != nil || !yield(dataset.PageData(data)) {
+				return err
+			}
 		}
+		return nil
+	})
+}
+
+// ReadBloomFilters reads the bloom filters of the provided set of pages,
+// iterating over their data matching the argument order. Bloom filters are
+// stored at the end of the page data, so only those bytes are retrieved.
+func (rd *decoder) ReadBloomFilters(ctx context.Context, pages []*logsmd.PageDesc) result.Seq[[]byte] {
+	filterInfo := func(p *logsmd.PageDesc) (uint64, uint64) {
+		info := p.GetInfo()
+		return info.DataOffset + info.DataSize - info.BloomFilterSize, info.BloomFilterSize
+	}
+	return rd.readRanges(ctx, pages, filterInfo)
+}
+
+// readRanges reads the byte range returned by rangeFunc for each of the
+// provided pages, iterating over the data matching the argument order.
+func (rd *decoder) readRanges(ctx context.Context, pages []*logsmd.PageDesc, rangeFunc func(*logsmd.PageDesc) (uint64, uint64)) result.Seq[[]byte] {
+	return result.Iter(func(yield func([]byte) bool) error {
+		results := make([][]byte, len(pages))
 
 		// TODO(rfratto): If there are many windows, it may make sense to read them
 		// in parallel.
-		for window := range windowing.Iter(pages, pageInfo, windowing.S3WindowSize) {
+		for window := range windowing.Iter(pages, rangeFunc, windowing.S3WindowSize) {
 			if len(window) == 0 {
 				continue
 			}
 
 			var (
-				windowOffset = window.Start().GetInfo().DataOffset
-				windowSize   = (window.End().GetInfo().DataOffset + window.End().GetInfo().DataSize) - windowOffset
+				windowOffset, _ = rangeFunc(window.Start())
+				endOffset, size = rangeFunc(window.End())
+				windowSize      = (endOffset + size) - windowOffset
 			)
 
 			rc, err := rd.sr.DataRange(ctx, int64(windowOffset), int64(windowSize))
@@ -146,8 +172,8 @@ func (rd *decoder) ReadPages(ctx context.Context, pages []*logsmd.PageDesc) resu
 			for _, wp := range window {
 				// Find the slice in the data for this page.
 				var (
-					pageOffset = wp.Data.GetInfo().DataOffset
-					dataOffset = pageOffset - windowOffset
+					offset, size = rangeFunc(wp.Data)
+					dataOffset   = offset - windowOffset
 				)
 
 				// wp.Index is the position of the page in the original pages slice;
@@ -155,7 +181,7 @@ func (rd *decoder) ReadPages(ctx context.Context, pages []*logsmd.PageDesc) resu
 				//
 				// We need to make a copy here of the slice since data is pooled (and
 				// we don't want to hold on to the entire window if we don't need to).
-				results[wp.Index] = dataset.PageData(bytes.Clone(data[dataOffset : dataOffset+wp.Data.GetInfo().DataSize]))
+				results[wp.Index] = bytes.Clone(data[dataOffset : dataOffset+size])
 			}
 
 			bufpool.Put(buffer)
@@ -75,6 +75,15 @@ func (s *Section) init(ctx context.Context) error {
 // sections) are skipped.
 func (s *Section) Columns() []*Column { return s.columns }
 
+// RowCount returns the number of rows in the section.
+func (s *Section) RowCount() int {
+	var rows int
+	for _, col := range s.columns {
+		rows = max(rows, int(col.desc.GetInfo().GetRowsCount()))
+	}
+	return rows
+}
+
 // PrimarySortOrder returns the primary sort order information of the section
 // as a tuple of [ColumnType] and [SortDirection].
 func (s *Section) PrimarySortOrder() (ColumnType, SortDirection, error) {
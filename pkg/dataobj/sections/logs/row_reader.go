@@ -25,6 +25,9 @@ type RowReader struct {
 	matchIDs   map[int64]struct{}
 	predicates []RowPredicate
 
+	startRow, endRow uint64
+	rowRanges        []dataset.RowRange
+
 	buf []dataset.Row
 
 	reader     *dataset.Reader
@@ -76,6 +79,56 @@ func (r *RowReader) SetPredicates(p []RowPredicate) error {
 	return nil
 }
 
+// SetRowRange limits the rows of the section that are read to the half-open
+// range [start, end). An end of 0 reads all rows of the section. Disjoint row
+// ranges of the same section can be read concurrently with separate readers.
+//
+// The row range may only be set before reading begins or after a call to
+// [RowReader.Reset].
+func (r *RowReader) SetRowRange(start, end uint64) error {
+	if r.ready {
+		return fmt.Errorf("cannot change row range after reading has started")
+	} else if end > 0 && start >= end {
+		return fmt.Errorf("invalid row range [%d, %d)", start, end)
+	}
+
+	r.startRow, r.endRow = start, end
+	return nil
+}
+
+// RowRange is a half-open range of rows [Start, End) of a logs section.
+type RowRange struct {
+	Start, End uint64
+}
+
+// SetRowRanges limits the rows of the section that are read to the union of
+// the given ranges, in addition to the range set by [RowReader.SetRowRange].
+// Ranges are typically found with an index over the section, such as the
+// tokens section. All rows are read if ranges is nil, while no rows are read
+// if ranges is empty but non-nil.
+//
+// The row ranges may only be set before reading begins or after a call to
+// [RowReader.Reset].
+func (r *RowReader) SetRowRanges(ranges []RowRange) error {
+	if r.ready {
+		return fmt.Errorf("cannot change row ranges after reading has started")
+	}
+
+	if ranges == nil {
+		r.rowRanges = nil
+		return nil
+	}
+
+	r.rowRanges = make([]dataset.RowRange, 0, len(ranges))
+	for _, rr := range ranges {
+		if rr.Start > rr.End {
+			return fmt.Errorf("invalid row range [%d, %d)", rr.Start, rr.End)
+		}
+		r.rowRanges = append(r.rowRanges, dataset.RowRange{Start: rr.Start, End: rr.End})
+	}
+	return nil
+}
+
 // Read reads up to the next len(s) records from the reader and stores them
 // into s. It returns the number of records read and any error encountered. At
 // the end of the logs section, Read returns 0, io.EOF.
@@ -111,6 +164,16 @@ func (r *RowReader) Read(ctx context.Context, s []Record) (int, error) {
 	return n, nil
 }
 
+// PagesPruned returns the number of pages that have been skipped so far
+// because the predicates of the reader ruled them out based on page
+// statistics.
+func (r *RowReader) PagesPruned() int {
+	if !r.ready || r.reader == nil {
+		return 0
+	}
+	return r.reader.PagesPruned()
+}
+
 func unsafeSlice(data string, capacity int) []byte {
 	if capacity <= 0 {
 		capacity = len(data)
@@ -156,6 +219,10 @@ func (r *RowReader) initReader(ctx context.Context) error {
 		Predicates: orderPredicates(predicates),
 
 		TargetCacheSize: 16_000_000, // Permit up to 16MB of cache pages.
+
+		StartRow:  r.startRow,
+		EndRow:    r.endRow,
+		RowRanges: r.rowRanges,
 	}
 
 	if r.reader == nil {
@@ -189,6 +256,8 @@ func (r *RowReader) Reset(sec *Section) {
 
 	clear(r.matchIDs)
 	r.predicates = nil
+	r.startRow, r.endRow = 0, 0
+	r.rowRanges = nil
 
 	r.columns = nil
 	r.columnDesc = nil
@@ -246,6 +315,12 @@ func translateLogsPredicate(p RowPredicate, columns []dataset.Column, columnDesc
 		}
 
 	case OrRowPredicate:
+		// Alternative values for the same metadata key are translated into a
+		// single InPredicate, so that pages can be skipped by checking all values
+		// against their dictionary or bloom filter at once.
+		if key, values, ok := metadataMatcherValues(p); ok {
+			return translateMetadataInPredicate(key, values, columns, columnDesc)
+		}
 		return dataset.OrPredicate{
 			Left:  translateLogsPredicate(p.Left, columns, columnDesc),
 			Right: translateLogsPredicate(p.Right, columns, columnDesc),
@@ -316,6 +391,53 @@ func translateLogsPredicate(p RowPredicate, columns []dataset.Column, columnDesc
 	}
 }
 
+// metadataMatcherValues returns the key and the values of p if p is a tree of
+// [OrRowPredicate]s whose leaves are [MetadataMatcherRowPredicate]s for the
+// same key with non-empty values.
+func metadataMatcherValues(p RowPredicate) (key string, values []string, ok bool) {
+	switch p := p.(type) {
+	case MetadataMatcherRowPredicate:
+		if p.Value == "" {
+			// Empty values match rows without the key, which aren't part of
+			// the values of the column.
+			return "", nil, false
+		}
+		return p.Key, []string{p.Value}, true
+
+	case OrRowPredicate:
+		leftKey, leftValues, ok := metadataMatcherValues(p.Left)
+		if !ok {
+			return "", nil, false
+		}
+		rightKey, rightValues, ok := metadataMatcherValues(p.Right)
+		if !ok || leftKey != rightKey {
+			return "", nil, false
+		}
+		return leftKey, append(leftValues, rightValues...), true
+
+	default:
+		return "", nil, false
+	}
+}
+
+func translateMetadataInPredicate(key string, values []string, columns []dataset.Column, columnDesc []*logsmd.ColumnDesc) dataset.Predicate {
+	metadataColumn := findColumnFromDesc(columns, columnDesc, func(desc *logsmd.ColumnDesc) bool {
+		return desc.Type == logsmd.COLUMN_TYPE_METADATA && desc.Info.Name == key
+	})
+	if metadataColumn == nil {
+		return dataset.FalsePredicate{}
+	}
+
+	set := make([]dataset.Value, 0, len(values))
+	for _, value := range values {
+		set = append(set, dataset.ByteArrayValue(unsafeSlice(value, 0)))
+	}
+	return dataset.InPredicate{
+		Column: metadataColumn,
+		Values: dataset.NewByteArrayValueSet(set),
+	}
+}
+
 func convertLogsTimePredicate(p TimeRangeRowPredicate, column dataset.Column) dataset.Predicate {
 	var start dataset.Predicate = dataset.GreaterThanPredicate{
 		Column: column,
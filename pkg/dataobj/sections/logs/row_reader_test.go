@@ -3,13 +3,18 @@ package logs
 import (
 	"bytes"
 	"context"
+	"errors"
+	"fmt"
+	"io"
 	"slices"
 	"testing"
 	"time"
 
+	"github.com/prometheus/prometheus/model/labels"
 	"github.com/stretchr/testify/require"
 
 	"github.com/grafana/loki/v3/pkg/dataobj"
+	"github.com/grafana/loki/v3/pkg/dataobj/internal/dataset"
 )
 
 func TestRowReader_NoPredicates(t *testing.T) {
@@ -35,6 +40,82 @@ func TestRowReader_StreamIDPredicate(t *testing.T) {
 	require.Equal(t, 1, n)
 }
 
+func TestRowReader_RowRange(t *testing.T) {
+	logsSection := buildSection(t)
+	require.Equal(t, 2, logsSection.RowCount())
+
+	var lines []string
+	for start := range uint64(logsSection.RowCount()) {
+		readBuf := make([]Record, 3)
+		rowReader := NewRowReader(logsSection)
+
+		err := rowReader.SetRowRange(start, start+1)
+		require.NoError(t, err)
+		n, err := rowReader.Read(context.Background(), readBuf)
+		require.NoError(t, err)
+		require.Equal(t, 1, n)
+		lines = append(lines, string(readBuf[0].Line))
+	}
+	require.ElementsMatch(t, []string{"test", "test2"}, lines)
+
+	err := NewRowReader(logsSection).SetRowRange(1, 1)
+	require.Error(t, err)
+}
+
+func TestRowReader_MetadataInPredicate(t *testing.T) {
+	logsBuilder := NewBuilder(nil, BuilderOptions{
+		StripeMergeLimit:    2,
+		BloomFilterMetadata: true,
+	})
+	for i := range 10 {
+		logsBuilder.Append(Record{
+			StreamID:  1,
+			Timestamp: time.Unix(int64(i+1), 0),
+			Metadata:  labels.FromStrings("trace_id", fmt.Sprintf("trace-%d", i)),
+			Line:      fmt.Appendf(nil, "line %d", i),
+		})
+	}
+	logsSection := sectionFromBuilder(t, logsBuilder)
+
+	predicate := OrRowPredicate{
+		Left: MetadataMatcherRowPredicate{Key: "trace_id", Value: "trace-2"},
+		Right: OrRowPredicate{
+			Left:  MetadataMatcherRowPredicate{Key: "trace_id", Value: "trace-7"},
+			Right: MetadataMatcherRowPredicate{Key: "trace_id", Value: "trace-42"},
+		},
+	}
+
+	t.Run("translation", func(t *testing.T) {
+		metadata, err := newDecoder(logsSection.reader).Metadata(context.Background())
+		require.NoError(t, err)
+		dset, err := newColumnsDataset(logsSection.Columns())
+		require.NoError(t, err)
+
+		p := translateLogsPredicate(predicate, dset.Columns(), metadata.GetColumns())
+		require.IsType(t, dataset.InPredicate{}, p)
+		require.Equal(t, 3, p.(dataset.InPredicate).Values.Size())
+	})
+
+	t.Run("read", func(t *testing.T) {
+		rowReader := NewRowReader(logsSection)
+		require.NoError(t, rowReader.SetPredicates([]RowPredicate{predicate}))
+
+		var lines []string
+		readBuf := make([]Record, 10)
+		for {
+			n, err := rowReader.Read(context.Background(), readBuf)
+			for _, record := range readBuf[:n] {
+				lines = append(lines, string(record.Line))
+			}
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			require.NoError(t, err)
+		}
+		require.ElementsMatch(t, []string{"line 2", "line 7"}, lines)
+	})
+}
+
 func buildSection(t *testing.T) *Section {
 	logsBuilder := NewBuilder(nil, BuilderOptions{
 		StripeMergeLimit: 2,
@@ -49,6 +130,11 @@ func buildSection(t *testing.T) *Section {
 		Timestamp: time.Now(),
 		Line:      []byte("test2"),
 	})
+	return sectionFromBuilder(t, logsBuilder)
+}
+
+func sectionFromBuilder(t *testing.T, logsBuilder *Builder) *Section {
+	t.Helper()
 
 	out := bytes.NewBuffer(nil)
 	b := dataobj.NewBuilder()
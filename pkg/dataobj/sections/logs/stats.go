@@ -4,6 +4,8 @@ import (
 	"context"
 	"fmt"
 
+	"github.com/grafana/loki/v3/pkg/dataobj/internal/dataset"
+	"github.com/grafana/loki/v3/pkg/dataobj/internal/metadata/datasetmd"
 	"github.com/grafana/loki/v3/pkg/dataobj/internal/result"
 )
 
@@ -47,6 +49,44 @@ type (
 	}
 )
 
+// ColumnSummary summarizes a column of a logs section. Unlike [ColumnStats],
+// it is built from the metadata that is read when the section is opened, so
+// that it does not require reading the metadata of the pages of the column.
+type ColumnSummary struct {
+	RowsCount        uint64 // Number of rows in the column.
+	ValuesCount      uint64 // Number of non-NULL values in the column.
+	Cardinality      uint64 // Estimated number of distinct values, or 0 if unknown.
+	UncompressedSize uint64
+	CompressedSize   uint64
+
+	// MinInt64 and MaxInt64 are the smallest and largest values of columns
+	// with integer values, such as timestamp columns. They are only set if
+	// HasRange is true.
+	MinInt64, MaxInt64 int64
+	HasRange           bool
+}
+
+// Summary returns the summary of the column.
+func (c *Column) Summary() ColumnSummary {
+	info := c.desc.GetInfo()
+	summary := ColumnSummary{
+		RowsCount:        info.GetRowsCount(),
+		ValuesCount:      info.GetValuesCount(),
+		Cardinality:      info.GetStatistics().GetCardinalityCount(),
+		UncompressedSize: info.GetUncompressedSize(),
+		CompressedSize:   info.GetCompressedSize(),
+	}
+
+	if stats := info.GetStatistics(); stats != nil && stats.MinValue != nil && stats.MaxValue != nil {
+		var minValue, maxValue dataset.Value
+		if e1, e2 := minValue.UnmarshalBinary(stats.MinValue), maxValue.UnmarshalBinary(stats.MaxValue); e1 == nil && e2 == nil &&
+			minValue.Type() == datasetmd.VALUE_TYPE_INT64 && maxValue.Type() == datasetmd.VALUE_TYPE_INT64 {
+			summary.MinInt64, summary.MaxInt64, summary.HasRange = minValue.Int64(), maxValue.Int64(), true
+		}
+	}
+	return summary
+}
+
 // ReadStats returns statistics about the logs section. ReadStats returns an
 // error if the streams section couldn't be inspected or if the provided ctx is
 // canceled.
@@ -0,0 +1,59 @@
+package logs
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/loki/v3/pkg/dataobj"
+)
+
+func TestColumn_Summary(t *testing.T) {
+	logsBuilder := NewBuilder(nil, BuilderOptions{
+		StripeMergeLimit: 2,
+	})
+	for i, env := range []string{"prod", "prod", "dev", ""} {
+		record := Record{
+			StreamID:  int64(i%2 + 1),
+			Timestamp: time.Unix(int64(10+i), 0),
+			Line:      []byte("line"),
+		}
+		if env != "" {
+			record.Metadata = labels.FromStrings("env", env)
+		}
+		logsBuilder.Append(record)
+	}
+
+	var out bytes.Buffer
+	b := dataobj.NewBuilder()
+	require.NoError(t, b.Append(logsBuilder))
+	_, err := b.Flush(&out)
+	require.NoError(t, err)
+
+	obj, err := dataobj.FromReaderAt(bytes.NewReader(out.Bytes()), int64(out.Len()))
+	require.NoError(t, err)
+	sec, err := Open(context.Background(), obj.Sections()[0])
+	require.NoError(t, err)
+
+	summaries := make(map[ColumnType]ColumnSummary)
+	for _, col := range sec.Columns() {
+		summaries[col.Type] = col.Summary()
+	}
+
+	timestamp := summaries[ColumnTypeTimestamp]
+	require.Equal(t, uint64(4), timestamp.RowsCount)
+	require.True(t, timestamp.HasRange)
+	require.Equal(t, time.Unix(10, 0).UnixNano(), timestamp.MinInt64)
+	require.Equal(t, time.Unix(13, 0).UnixNano(), timestamp.MaxInt64)
+
+	metadata := summaries[ColumnTypeMetadata]
+	require.Equal(t, uint64(4), metadata.RowsCount)
+	require.Equal(t, uint64(3), metadata.ValuesCount)
+	require.Equal(t, uint64(2), metadata.Cardinality)
+	require.False(t, metadata.HasRange)
+	require.NotZero(t, metadata.UncompressedSize)
+}
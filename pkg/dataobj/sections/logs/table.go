@@ -121,6 +121,30 @@ type tableBuffer struct {
 	usedMetadatas  map[*dataset.ColumnBuilder]string // metadata with its name.
 
 	message *dataset.ColumnBuilder
+
+	// compression is the compression type of the metadata and message columns.
+	// COMPRESSION_TYPE_UNSPECIFIED defaults to zstd. compression must not be
+	// changed once these columns have been created.
+	compression datasetmd.CompressionType
+
+	// dictionaryMetadata enables dictionary encoding of metadata columns.
+	// dictionaryMetadata must not be changed once metadata columns have been
+	// created.
+	dictionaryMetadata bool
+
+	// bloomFilterMetadata enables bloom filters of plain-encoded pages of
+	// metadata columns. bloomFilterMetadata must not be changed once metadata
+	// columns have been created.
+	bloomFilterMetadata bool
+}
+
+// Compression returns the compression type of the metadata and message
+// columns of the buffer.
+func (b *tableBuffer) Compression() datasetmd.CompressionType {
+	if b.compression == datasetmd.COMPRESSION_TYPE_UNSPECIFIED {
+		return datasetmd.COMPRESSION_TYPE_ZSTD
+	}
+	return b.compression
 }
 
 // StreamID gets or creates a stream ID column for the buffer.
@@ -190,15 +214,25 @@ func (b *tableBuffer) Metadata(key string, pageSize int, compressionOpts dataset
 		return builder
 	}
 
+	// Metadata values usually have a low cardinality, so they may be
+	// dictionary encoded. Pages with too many distinct values fall back to
+	// plain encoding. Plain-encoded pages may get a bloom filter, so that
+	// lookups of high-cardinality values such as trace IDs can skip pages.
+	encoding := datasetmd.ENCODING_TYPE_PLAIN
+	if b.dictionaryMetadata {
+		encoding = datasetmd.ENCODING_TYPE_DICTIONARY
+	}
+
 	col, err := dataset.NewColumnBuilder(key, dataset.BuilderOptions{
 		PageSizeHint:       pageSize,
 		Value:              datasetmd.VALUE_TYPE_BYTE_ARRAY,
-		Encoding:           datasetmd.ENCODING_TYPE_PLAIN,
-		Compression:        datasetmd.COMPRESSION_TYPE_ZSTD,
+		Encoding:           encoding,
+		Compression:        b.Compression(),
 		CompressionOptions: compressionOpts,
 		Statistics: dataset.StatisticsOptions{
 			StoreRangeStats:       true,
 			StoreCardinalityStats: true,
+			StoreBloomFilters:     b.bloomFilterMetadata,
 		},
 	})
 	if err != nil {
@@ -228,7 +262,7 @@ func (b *tableBuffer) Message(pageSize int, compressionOpts dataset.CompressionO
 		PageSizeHint:       pageSize,
 		Value:              datasetmd.VALUE_TYPE_BYTE_ARRAY,
 		Encoding:           datasetmd.ENCODING_TYPE_PLAIN,
-		Compression:        datasetmd.COMPRESSION_TYPE_ZSTD,
+		Compression:        b.Compression(),
 		CompressionOptions: compressionOpts,
 
 		// We explicitly don't have range stats for the message column:
@@ -3,11 +3,13 @@ package logs
 import (
 	"context"
 	"errors"
+	"fmt"
 	"io"
 	"strings"
 	"testing"
 	"time"
 
+	"github.com/prometheus/prometheus/model/labels"
 	"github.com/stretchr/testify/require"
 
 	"github.com/grafana/loki/v3/pkg/dataobj/internal/dataset"
@@ -35,6 +37,58 @@ func Test_table_metadataCleanup(t *testing.T) {
 	require.Equal(t, "bar", table.Metadatas[0].Info.Name)
 }
 
+func Test_table_metadataDictionaryEncoding(t *testing.T) {
+	var records []Record
+	for i := range 100 {
+		records = append(records, Record{
+			StreamID:  1,
+			Timestamp: time.Unix(int64(i), 0),
+			Metadata:  labels.FromStrings("level", []string{"info", "warn"}[i%2]),
+			Line:      []byte("hello"),
+		})
+	}
+
+	for _, tc := range []struct {
+		dictionaryMetadata bool
+		expect             datasetmd.EncodingType
+	}{
+		{dictionaryMetadata: false, expect: datasetmd.ENCODING_TYPE_PLAIN},
+		{dictionaryMetadata: true, expect: datasetmd.ENCODING_TYPE_DICTIONARY},
+	} {
+		buf := tableBuffer{dictionaryMetadata: tc.dictionaryMetadata}
+		table := buildTable(&buf, 1024, dataset.CompressionOptions{}, records)
+		require.Len(t, table.Metadatas, 1)
+
+		for _, page := range table.Metadatas[0].Pages {
+			require.Equal(t, tc.expect, page.Info.Encoding)
+		}
+	}
+}
+
+func Test_table_metadataBloomFilters(t *testing.T) {
+	var records []Record
+	for i := range 100 {
+		records = append(records, Record{
+			StreamID:  1,
+			Timestamp: time.Unix(int64(i), 0),
+			Metadata:  labels.FromStrings("trace_id", fmt.Sprintf("trace-%d", i)),
+			Line:      []byte("hello"),
+		})
+	}
+
+	for _, bloomFilterMetadata := range []bool{false, true} {
+		buf := tableBuffer{bloomFilterMetadata: bloomFilterMetadata}
+		table := buildTable(&buf, 1024, dataset.CompressionOptions{}, records)
+		require.Len(t, table.Metadatas, 1)
+
+		for _, page := range table.Metadatas[0].Pages {
+			// Bloom filters are off by default, as readers which don't support
+			// them can't read pages with bloom filters.
+			require.Equal(t, bloomFilterMetadata, page.Info.BloomFilterSize > 0)
+		}
+	}
+}
+
 func initBuffer(buf *tableBuffer) {
 	buf.StreamID(1024)
 	buf.Timestamp(1024)
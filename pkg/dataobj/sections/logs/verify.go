@@ -0,0 +1,34 @@
+package logs
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/grafana/loki/v3/pkg/dataobj/internal/dataset"
+)
+
+// Verify reads and decodes all pages of the logs section with
+// [dataset.VerifyColumns]. All columns except metadata columns, which aren't
+// backfilled with NULLs, must have a row for each log record.
+func Verify(ctx context.Context, section *Section) error {
+	dset, err := newColumnsDataset(section.Columns())
+	if err != nil {
+		return fmt.Errorf("creating dataset: %w", err)
+	}
+
+	names := make([]string, len(section.Columns()))
+	for i, col := range section.Columns() {
+		names[i] = col.Type.String()
+		if col.Name != "" {
+			names[i] += "[" + col.Name + "]"
+		}
+	}
+
+	rows := section.RowCount()
+	return dataset.VerifyColumns(ctx, dset, names, func(i, count int) error {
+		if count != rows && section.Columns()[i].Type != ColumnTypeMetadata {
+			return fmt.Errorf("has %d rows, expected %d", count, rows)
+		}
+		return nil
+	})
+}
@@ -0,0 +1,28 @@
+package pointers
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/grafana/loki/v3/pkg/dataobj/internal/dataset"
+)
+
+// Verify reads and decodes all pages of the pointers section with
+// [dataset.VerifyColumns]. Columns are only populated for the kinds of
+// pointers which use them, so their numbers of rows aren't checked.
+func Verify(ctx context.Context, section *Section) error {
+	dset, err := newColumnsDataset(section.Columns())
+	if err != nil {
+		return fmt.Errorf("creating dataset: %w", err)
+	}
+
+	names := make([]string, len(section.Columns()))
+	for i, col := range section.Columns() {
+		names[i] = col.Type.String()
+		if col.Name != "" {
+			names[i] += "[" + col.Name + "]"
+		}
+	}
+
+	return dataset.VerifyColumns(ctx, dset, names, nil)
+}
@@ -57,10 +57,11 @@ var streamPool = sync.Pool{
 
 // Builder builds a streams section.
 type Builder struct {
-	metrics  *Metrics
-	pageSize int
-	lastID   atomic.Int64
-	lookup   map[uint64][]*Stream
+	metrics     *Metrics
+	pageSize    int
+	compression dataobj.CompressionConfig
+	lastID      atomic.Int64
+	lookup      map[uint64][]*Stream
 
 	// Size of all label values across all streams; used for
 	// [Streams.EstimatedSize]. Resets on [Streams.Reset].
@@ -88,6 +89,13 @@ func NewBuilder(metrics *Metrics, pageSize int) *Builder {
 	}
 }
 
+// SetCompression configures the compression of the label columns of the
+// section. By default, label columns are compressed with zstd at its default
+// level. An invalid configuration causes [Builder.Flush] to fail.
+func (b *Builder) SetCompression(cfg dataobj.CompressionConfig) {
+	b.compression = cfg
+}
+
 // Type returns the [dataobj.SectionType] of the streams builder.
 func (b *Builder) Type() dataobj.SectionType { return sectionType }
 
@@ -241,6 +249,11 @@ func (b *Builder) encodeTo(enc *encoder) error {
 	// 2. Move some columns into an aggregated column which holds multiple label
 	//    keys and values.
 
+	compression, compressionOpts, err := dataset.ParseCompression(b.compression.Codec, b.compression.Level)
+	if err != nil {
+		return fmt.Errorf("invalid compression: %w", err)
+	}
+
 	idBuilder, err := numberColumnBuilder(b.pageSize)
 	if err != nil {
 		return fmt.Errorf("creating ID column: %w", err)
@@ -274,10 +287,11 @@ func (b *Builder) encodeTo(enc *encoder) error {
 		}
 
 		builder, err := dataset.NewColumnBuilder(name, dataset.BuilderOptions{
-			PageSizeHint: b.pageSize,
-			Value:        datasetmd.VALUE_TYPE_BYTE_ARRAY,
-			Encoding:     datasetmd.ENCODING_TYPE_PLAIN,
-			Compression:  datasetmd.COMPRESSION_TYPE_ZSTD,
+			PageSizeHint:       b.pageSize,
+			Value:              datasetmd.VALUE_TYPE_BYTE_ARRAY,
+			Encoding:           datasetmd.ENCODING_TYPE_PLAIN,
+			Compression:        compression,
+			CompressionOptions: compressionOpts,
 			Statistics: dataset.StatisticsOptions{
 				StoreRangeStats: true,
 			},
@@ -187,6 +187,9 @@ func newDatasetPage(dec *decoder, desc *streamsmd.PageDesc) *datasetPage {
 
 			Encoding: info.Encoding,
 			Stats:    info.Statistics,
+
+			BloomFilterSize:  int(info.BloomFilterSize),
+			BloomFilterCRC32: info.BloomFilterCrc32,
 		},
 	}
 }
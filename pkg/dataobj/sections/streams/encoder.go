@@ -208,6 +208,9 @@ func (enc *columnEncoder) AppendPage(page *dataset.MemPage) error {
 			DataSize:   uint64(len(page.Data)),
 
 			Statistics: page.Info.Stats,
+
+			BloomFilterSize:  uint64(page.Info.BloomFilterSize),
+			BloomFilterCrc32: page.Info.BloomFilterCRC32,
 		},
 	})
 
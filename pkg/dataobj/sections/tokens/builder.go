@@ -0,0 +1,243 @@
+package tokens
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+
+	"github.com/grafana/loki/v3/pkg/dataobj"
+	"github.com/grafana/loki/v3/pkg/dataobj/internal/dataset"
+	"github.com/grafana/loki/v3/pkg/dataobj/internal/metadata/datasetmd"
+	"github.com/grafana/loki/v3/pkg/dataobj/internal/metadata/tokensmd"
+	"github.com/grafana/loki/v3/pkg/dataobj/sections/logs"
+)
+
+// blockRows is the number of consecutive rows of a logs section that share
+// an entry in the postings of a token. Larger blocks make the index smaller,
+// but prune fewer rows.
+const blockRows = 1024
+
+// sectionToken is a reserved token which is stored once for each indexed
+// logs section, with row ranges that cover all rows of the section. It allows
+// readers to tell apart sections that aren't indexed from tokens that don't
+// occur in a section. Indexed tokens are always trigrams, so sectionToken
+// can't collide with them.
+var sectionToken = []byte{0}
+
+// trigram is an indexed token: three consecutive bytes of a log line.
+type trigram [3]byte
+
+// sectionPostings holds the postings of the tokens of a single logs section.
+type sectionPostings struct {
+	rows     int                 // Number of rows of the section.
+	postings map[trigram][]int32 // Blocks of rows which contain each token, in ascending order.
+}
+
+// A Builder builds a tokens section, indexing the trigrams of the log lines
+// of one or more logs sections.
+type Builder struct {
+	pageSize int
+
+	sections map[int]*sectionPostings
+	size     int // Estimated encoded size of sections.
+}
+
+// NewBuilder creates a new tokens section builder. The pageSize argument
+// specifies how large pages should be.
+func NewBuilder(pageSize int) *Builder {
+	return &Builder{
+		pageSize: pageSize,
+		sections: make(map[int]*sectionPostings),
+	}
+}
+
+// Type returns the [dataobj.SectionType] of the tokens builder.
+func (b *Builder) Type() dataobj.SectionType { return sectionType }
+
+// AppendLine indexes the line of a row of the logs section with the given
+// index. The index of a logs section is its position among the logs sections
+// of the data object. Rows of a section must be appended in ascending order.
+func (b *Builder) AppendLine(section, row int, line []byte) {
+	sec, ok := b.sections[section]
+	if !ok {
+		sec = &sectionPostings{postings: make(map[trigram][]int32)}
+		b.sections[section] = sec
+		b.size += 2 * len(sectionToken) // Section and token of sectionToken.
+	}
+	sec.rows = max(sec.rows, row+1)
+
+	block := int32(row / blockRows)
+	for i := 0; i+len(trigram{}) <= len(line); i++ {
+		token := trigram(line[i : i+len(trigram{})])
+
+		blocks, ok := sec.postings[token]
+		if !ok {
+			// Tokens are sorted, so the section is delta-encoded to nearly
+			// nothing; we only count the token itself.
+			b.size += len(token)
+		}
+		if len(blocks) > 0 && blocks[len(blocks)-1] == block {
+			continue
+		}
+		sec.postings[token] = append(blocks, block)
+		b.size += 2 // Estimated uvarint size of a row range, after compression.
+	}
+}
+
+// EstimatedSize returns the estimated size of the tokens section in bytes.
+func (b *Builder) EstimatedSize() int { return b.size }
+
+// Flush flushes the tokens section to the provided writer.
+//
+// After successful encoding, b is reset to a fresh state and can be reused.
+func (b *Builder) Flush(w dataobj.SectionWriter) (n int64, err error) {
+	var enc encoder
+	defer enc.Reset()
+	if err := b.encodeTo(&enc); err != nil {
+		return 0, fmt.Errorf("building encoder: %w", err)
+	}
+
+	n, err = enc.Flush(w)
+	if err == nil {
+		b.Reset()
+	}
+	return n, err
+}
+
+// Reset resets all state, allowing the Builder to be reused.
+func (b *Builder) Reset() {
+	clear(b.sections)
+	b.size = 0
+}
+
+func (b *Builder) encodeTo(enc *encoder) error {
+	sectionBuilder, err := dataset.NewColumnBuilder("section", dataset.BuilderOptions{
+		PageSizeHint: b.pageSize,
+		Value:        datasetmd.VALUE_TYPE_INT64,
+		Encoding:     datasetmd.ENCODING_TYPE_DELTA,
+		Compression:  datasetmd.COMPRESSION_TYPE_NONE,
+		Statistics: dataset.StatisticsOptions{
+			StoreRangeStats: true,
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("creating section column: %w", err)
+	}
+
+	tokenBuilder, err := dataset.NewColumnBuilder("token", dataset.BuilderOptions{
+		PageSizeHint: b.pageSize,
+		Value:        datasetmd.VALUE_TYPE_BYTE_ARRAY,
+		Encoding:     datasetmd.ENCODING_TYPE_PLAIN,
+		Compression:  datasetmd.COMPRESSION_TYPE_ZSTD,
+		Statistics: dataset.StatisticsOptions{
+			StoreRangeStats: true,
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("creating token column: %w", err)
+	}
+
+	rowsBuilder, err := dataset.NewColumnBuilder("rows", dataset.BuilderOptions{
+		PageSizeHint: b.pageSize,
+		Value:        datasetmd.VALUE_TYPE_BYTE_ARRAY,
+		Encoding:     datasetmd.ENCODING_TYPE_PLAIN,
+		Compression:  datasetmd.COMPRESSION_TYPE_ZSTD,
+	})
+	if err != nil {
+		return fmt.Errorf("creating rows column: %w", err)
+	}
+
+	// Rows are sorted by section and then by token, so that the range
+	// statistics of the token column can be used to skip pages when looking up
+	// tokens.
+	var (
+		row    int
+		ranges []logs.RowRange
+		buf    []byte
+	)
+	for _, section := range slices.Sorted(maps.Keys(b.sections)) {
+		sec := b.sections[section]
+
+		ranges = append(ranges[:0], logs.RowRange{Start: 0, End: uint64(sec.rows)})
+		buf = appendRowRanges(buf[:0], ranges)
+		_ = sectionBuilder.Append(row, dataset.Int64Value(int64(section)))
+		_ = tokenBuilder.Append(row, dataset.ByteArrayValue(sectionToken))
+		_ = rowsBuilder.Append(row, dataset.ByteArrayValue(buf))
+		row++
+
+		tokens := slices.SortedFunc(maps.Keys(sec.postings), func(a, b trigram) int {
+			return bytes.Compare(a[:], b[:])
+		})
+		for _, token := range tokens {
+			ranges = blockRanges(ranges[:0], sec.postings[token], sec.rows)
+			buf = appendRowRanges(buf[:0], ranges)
+
+			_ = sectionBuilder.Append(row, dataset.Int64Value(int64(section)))
+			_ = tokenBuilder.Append(row, dataset.ByteArrayValue(token[:]))
+			_ = rowsBuilder.Append(row, dataset.ByteArrayValue(buf))
+			row++
+		}
+	}
+
+	// Encode our builders to sections. We ignore errors after enc.OpenStreams
+	// (which may fail due to a caller) since we guarantee correct usage of the
+	// encoding API.
+	{
+		var errs []error
+		errs = append(errs, encodeColumn(enc, tokensmd.COLUMN_TYPE_SECTION, sectionBuilder))
+		errs = append(errs, encodeColumn(enc, tokensmd.COLUMN_TYPE_TOKEN, tokenBuilder))
+		errs = append(errs, encodeColumn(enc, tokensmd.COLUMN_TYPE_ROWS, rowsBuilder))
+
+		if err := errors.Join(errs...); err != nil {
+			return fmt.Errorf("encoding columns: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// blockRanges appends the row ranges covered by the ascending blocks of a
+// section with the given number of rows to dst. Consecutive blocks are merged
+// into a single range.
+func blockRanges(dst []logs.RowRange, blocks []int32, rows int) []logs.RowRange {
+	for _, block := range blocks {
+		var (
+			start = uint64(block) * blockRows
+			end   = min(start+blockRows, uint64(rows))
+		)
+		if n := len(dst); n > 0 && dst[n-1].End == start {
+			dst[n-1].End = end
+			continue
+		}
+		dst = append(dst, logs.RowRange{Start: start, End: end})
+	}
+	return dst
+}
+
+func encodeColumn(enc *encoder, columnType tokensmd.ColumnType, builder *dataset.ColumnBuilder) error {
+	column, err := builder.Flush()
+	if err != nil {
+		return fmt.Errorf("flushing %s column: %w", columnType, err)
+	}
+
+	columnEnc, err := enc.OpenColumn(columnType, &column.Info)
+	if err != nil {
+		return fmt.Errorf("opening %s column encoder: %w", columnType, err)
+	}
+	defer func() {
+		// Discard on defer for safety. This will return an error if we
+		// successfully committed.
+		_ = columnEnc.Discard()
+	}()
+
+	for _, page := range column.Pages {
+		err := columnEnc.AppendPage(page)
+		if err != nil {
+			return fmt.Errorf("appending %s page: %w", columnType, err)
+		}
+	}
+
+	return columnEnc.Commit()
+}
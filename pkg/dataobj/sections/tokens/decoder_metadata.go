@@ -0,0 +1,34 @@
+package tokens
+
+import (
+	"fmt"
+
+	"github.com/grafana/loki/v3/pkg/dataobj/internal/metadata/tokensmd"
+	"github.com/grafana/loki/v3/pkg/dataobj/internal/streamio"
+	"github.com/grafana/loki/v3/pkg/dataobj/internal/util/protocodec"
+)
+
+// decodeTokensMetadata decodes tokens section metadata from r.
+func decodeTokensMetadata(r streamio.Reader) (*tokensmd.Metadata, error) {
+	gotVersion, err := streamio.ReadUvarint(r)
+	if err != nil {
+		return nil, fmt.Errorf("read tokens section format version: %w", err)
+	} else if gotVersion != tokensFormatVersion {
+		return nil, fmt.Errorf("unexpected tokens section format version: got=%d want=%d", gotVersion, tokensFormatVersion)
+	}
+
+	var md tokensmd.Metadata
+	if err := protocodec.Decode(r, &md); err != nil {
+		return nil, fmt.Errorf("tokens section metadata: %w", err)
+	}
+	return &md, nil
+}
+
+// decodeTokensColumnMetadata decodes tokens column metadata from r.
+func decodeTokensColumnMetadata(r streamio.Reader) (*tokensmd.ColumnMetadata, error) {
+	var metadata tokensmd.ColumnMetadata
+	if err := protocodec.Decode(r, &metadata); err != nil {
+		return nil, fmt.Errorf("tokens column metadata: %w", err)
+	}
+	return &metadata, nil
+}
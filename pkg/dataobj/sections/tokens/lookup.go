@@ -0,0 +1,132 @@
+package tokens
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/grafana/loki/v3/pkg/dataobj"
+	"github.com/grafana/loki/v3/pkg/dataobj/internal/dataset"
+	"github.com/grafana/loki/v3/pkg/dataobj/sections/logs"
+)
+
+// RowRanges returns the ranges of rows of the logs section with the given
+// index in obj whose log lines may contain all of the given substrings. The
+// index of a logs section is its position among the logs sections of obj.
+//
+// Rows outside of the returned ranges are guaranteed not to contain all
+// substrings, while rows inside of them must still be checked.
+//
+// RowRanges returns nil if rows can't be pruned: when obj has no tokens
+// section indexing the logs section, or when all substrings are shorter
+// than a trigram. An empty, non-nil slice is returned if no row can contain
+// all substrings.
+func RowRanges(ctx context.Context, obj *dataobj.Object, section int, substrings []string) ([]logs.RowRange, error) {
+	tokens := make(map[trigram]struct{})
+	for _, s := range substrings {
+		for i := 0; i+len(trigram{}) <= len(s); i++ {
+			tokens[trigram([]byte(s[i:i+len(trigram{})]))] = struct{}{}
+		}
+	}
+	if len(tokens) == 0 {
+		return nil, nil
+	}
+
+	values := make([]dataset.Value, 0, len(tokens)+1)
+	values = append(values, dataset.ByteArrayValue(sectionToken))
+	for token := range tokens {
+		values = append(values, dataset.ByteArrayValue(token[:]))
+	}
+
+	postings := make(map[string][]logs.RowRange, len(values))
+	for i, s := range obj.Sections().Filter(CheckSection) {
+		sec, err := Open(ctx, s)
+		if err != nil {
+			return nil, fmt.Errorf("opening section %d: %w", i, err)
+		}
+		if err := readPostings(ctx, sec, section, dataset.NewByteArrayValueSet(values), postings); err != nil {
+			return nil, fmt.Errorf("reading section %d: %w", i, err)
+		}
+	}
+
+	ranges, ok := postings[string(sectionToken)]
+	if !ok {
+		// The logs section isn't indexed.
+		return nil, nil
+	}
+
+	buf := make([]logs.RowRange, 0, len(ranges))
+	for token := range tokens {
+		buf = intersectRowRanges(buf[:0], ranges, postings[string(token[:])])
+		ranges, buf = buf, ranges
+		if len(ranges) == 0 {
+			break
+		}
+	}
+	return ranges, nil
+}
+
+// readPostings reads the row ranges of the given tokens of the logs section
+// with the given index from sec into postings.
+func readPostings(ctx context.Context, sec *Section, section int, tokens dataset.ValueSet, postings map[string][]logs.RowRange) error {
+	var sectionColumn, tokenColumn, rowsColumn *Column
+	for _, col := range sec.Columns() {
+		switch col.Type {
+		case ColumnTypeSection:
+			sectionColumn = col
+		case ColumnTypeToken:
+			tokenColumn = col
+		case ColumnTypeRows:
+			rowsColumn = col
+		}
+	}
+	if sectionColumn == nil || tokenColumn == nil || rowsColumn == nil {
+		return fmt.Errorf("missing columns in tokens section")
+	}
+
+	dset, err := newColumnsDataset([]*Column{sectionColumn, tokenColumn, rowsColumn})
+	if err != nil {
+		return fmt.Errorf("creating section dataset: %w", err)
+	}
+	columns := dset.Columns()
+
+	// Section indexes are delta-encoded with zeros stored as NULLs, which
+	// can't be used reliably in predicates. Instead, the predicate only
+	// matches tokens, and the section of matching rows is checked below.
+	r := dataset.NewReader(dataset.ReaderOptions{
+		Dataset: dset,
+		Columns: columns,
+		Predicates: []dataset.Predicate{
+			dataset.InPredicate{Column: columns[1], Values: tokens},
+		},
+	})
+	defer r.Close()
+
+	rows := make([]dataset.Row, 128)
+	for {
+		n, err := r.Read(ctx, rows)
+		if err != nil && !errors.Is(err, io.EOF) {
+			return err
+		} else if n == 0 && errors.Is(err, io.EOF) {
+			return nil
+		}
+
+		for _, row := range rows[:n] {
+			var rowSection int64
+			if v := row.Values[0]; !v.IsNil() {
+				rowSection = v.Int64()
+			}
+			if rowSection != int64(section) || row.Values[2].IsNil() {
+				continue
+			}
+
+			token := string(row.Values[1].ByteArray())
+			ranges, err := decodeRowRanges(postings[token], row.Values[2].ByteArray())
+			if err != nil {
+				return fmt.Errorf("decoding rows of token %q: %w", token, err)
+			}
+			postings[token] = ranges
+		}
+	}
+}
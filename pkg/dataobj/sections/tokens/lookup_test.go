@@ -0,0 +1,116 @@
+package tokens
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/loki/v3/pkg/dataobj"
+	"github.com/grafana/loki/v3/pkg/dataobj/sections/logs"
+)
+
+func TestRowRanges(t *testing.T) {
+	tb := NewBuilder(1024)
+	for row := range 3000 {
+		line := fmt.Sprintf("msg=request handled status=%d", 200+row%3)
+		if row == 1500 {
+			line = "msg=needle in a haystack"
+		}
+		tb.AppendLine(0, row, []byte(line))
+	}
+	tb.AppendLine(2, 0, []byte("a line of another logs section"))
+
+	var buf bytes.Buffer
+	b := dataobj.NewBuilder()
+	require.NoError(t, b.Append(tb))
+	_, err := b.Flush(&buf)
+	require.NoError(t, err)
+
+	obj, err := dataobj.FromReaderAt(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	require.NoError(t, err)
+
+	tt := []struct {
+		name       string
+		section    int
+		substrings []string
+		expect     []logs.RowRange
+	}{
+		{
+			name:       "token in one block",
+			substrings: []string{"needle"},
+			expect:     []logs.RowRange{{Start: 1024, End: 2048}},
+		},
+		{
+			name:       "token in all blocks",
+			substrings: []string{"status=201"},
+			expect:     []logs.RowRange{{Start: 0, End: 3000}},
+		},
+		{
+			name:       "all substrings must match",
+			substrings: []string{"status=201", "haystack"},
+			expect:     []logs.RowRange{{Start: 1024, End: 2048}},
+		},
+		{
+			name:       "missing token",
+			substrings: []string{"status=404"},
+			expect:     []logs.RowRange{},
+		},
+		{
+			name:       "token of another section",
+			substrings: []string{"another"},
+			expect:     []logs.RowRange{},
+		},
+		{
+			name:       "short substrings",
+			substrings: []string{"ms", "="},
+			expect:     nil,
+		},
+		{
+			name:       "section not indexed",
+			section:    1,
+			substrings: []string{"needle"},
+			expect:     nil,
+		},
+		{
+			name:       "other section",
+			section:    2,
+			substrings: []string{"another"},
+			expect:     []logs.RowRange{{Start: 0, End: 1}},
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			actual, err := RowRanges(context.Background(), obj, tc.section, tc.substrings)
+			require.NoError(t, err)
+			if tc.expect == nil {
+				require.Nil(t, actual)
+				return
+			}
+			require.NotNil(t, actual)
+			require.Equal(t, tc.expect, actual)
+		})
+	}
+}
+
+func Test_rowRangesEncoding(t *testing.T) {
+	ranges := []logs.RowRange{{Start: 0, End: 10}, {Start: 1024, End: 4096}, {Start: 5000, End: 5001}}
+
+	actual, err := decodeRowRanges(nil, appendRowRanges(nil, ranges))
+	require.NoError(t, err)
+	require.Equal(t, ranges, actual)
+
+	_, err = decodeRowRanges(nil, []byte{0x80})
+	require.Error(t, err)
+}
+
+func Test_intersectRowRanges(t *testing.T) {
+	a := []logs.RowRange{{Start: 0, End: 10}, {Start: 20, End: 30}, {Start: 40, End: 50}}
+	b := []logs.RowRange{{Start: 5, End: 25}, {Start: 30, End: 40}, {Start: 45, End: 100}}
+
+	expect := []logs.RowRange{{Start: 5, End: 10}, {Start: 20, End: 25}, {Start: 45, End: 50}}
+	require.Equal(t, expect, intersectRowRanges(nil, a, b))
+}
@@ -0,0 +1,71 @@
+package tokens
+
+import (
+	"encoding/binary"
+	"errors"
+
+	"github.com/grafana/loki/v3/pkg/dataobj/sections/logs"
+)
+
+var errInvalidRowRanges = errors.New("invalid row ranges")
+
+// appendRowRanges appends the encoding of the sorted, non-overlapping ranges
+// to dst. Each range is encoded as a pair of uvarints: the number of rows
+// between the end of the previous range and its start, followed by its
+// number of rows.
+func appendRowRanges(dst []byte, ranges []logs.RowRange) []byte {
+	var prevEnd uint64
+	for _, rr := range ranges {
+		dst = binary.AppendUvarint(dst, rr.Start-prevEnd)
+		dst = binary.AppendUvarint(dst, rr.End-rr.Start)
+		prevEnd = rr.End
+	}
+	return dst
+}
+
+// decodeRowRanges appends the ranges encoded in src by [appendRowRanges] to
+// dst.
+func decodeRowRanges(dst []logs.RowRange, src []byte) ([]logs.RowRange, error) {
+	var prevEnd uint64
+	for len(src) > 0 {
+		gap, n := binary.Uvarint(src)
+		if n <= 0 {
+			return nil, errInvalidRowRanges
+		}
+		src = src[n:]
+
+		length, n := binary.Uvarint(src)
+		if n <= 0 {
+			return nil, errInvalidRowRanges
+		}
+		src = src[n:]
+
+		start := prevEnd + gap
+		dst = append(dst, logs.RowRange{Start: start, End: start + length})
+		prevEnd = start + length
+	}
+	return dst, nil
+}
+
+// intersectRowRanges appends the intersection of the sorted,
+// non-overlapping ranges a and b to dst.
+func intersectRowRanges(dst, a, b []logs.RowRange) []logs.RowRange {
+	for i, j := 0, 0; i < len(a) && j < len(b); {
+		var (
+			start = max(a[i].Start, b[j].Start)
+			end   = min(a[i].End, b[j].End)
+		)
+		if start < end {
+			dst = append(dst, logs.RowRange{Start: start, End: end})
+		}
+
+		// Advance the range that ends first, as it can't overlap with any
+		// further range of the other set.
+		if a[i].End < b[j].End {
+			i++
+		} else {
+			j++
+		}
+	}
+	return dst
+}
@@ -0,0 +1,125 @@
+// Package tokens defines the tokens section of a data object. The tokens
+// section holds an inverted index of the trigrams of the log lines of the
+// logs sections of an object, used to find the rows of a logs section whose
+// lines may contain a substring without reading the lines.
+package tokens
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/grafana/loki/v3/pkg/dataobj"
+	"github.com/grafana/loki/v3/pkg/dataobj/internal/metadata/tokensmd"
+)
+
+var sectionType = dataobj.SectionType{
+	Namespace: "github.com/grafana/loki",
+	Kind:      "tokens",
+}
+
+// CheckSection returns true if section is a tokens section.
+func CheckSection(section *dataobj.Section) bool { return section.Type == sectionType }
+
+// Section represents an opened tokens section.
+type Section struct {
+	reader  dataobj.SectionReader
+	columns []*Column
+}
+
+// Open opens a Section from an underlying [dataobj.Section]. Open returns an
+// error if the section metadata could not be read or if the provided ctx is
+// canceled.
+func Open(ctx context.Context, section *dataobj.Section) (*Section, error) {
+	if !CheckSection(section) {
+		return nil, fmt.Errorf("section is not a tokens section")
+	}
+
+	sec := &Section{reader: section.Reader}
+	if err := sec.init(ctx); err != nil {
+		return nil, fmt.Errorf("intializing section: %w", err)
+	}
+	return sec, nil
+}
+
+func (s *Section) init(ctx context.Context) error {
+	dec := newDecoder(s.reader)
+	metadata, err := dec.Metadata(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to decode metadata: %w", err)
+	}
+
+	for _, col := range metadata.GetColumns() {
+		colType, ok := convertColumnType(col.Type)
+		if !ok {
+			// Skip over unrecognized columns.
+			continue
+		}
+
+		s.columns = append(s.columns, &Column{
+			Section: s,
+			Name:    col.Info.Name,
+			Type:    colType,
+
+			desc: col,
+		})
+	}
+
+	return nil
+}
+
+// Columns returns the set of Columns in the section. The slice of returned
+// sections must not be mutated.
+//
+// Unrecognized columns (e.g., when running older code against newer tokens
+// sections) are skipped.
+func (s *Section) Columns() []*Column { return s.columns }
+
+// ColumnType represents the kind of information stored in a [Column].
+type ColumnType int
+
+const (
+	ColumnTypeInvalid ColumnType = iota // ColumnTypeInvalid is an invalid column.
+	ColumnTypeSection                   // ColumnTypeSection is a column containing the index of the indexed logs section.
+	ColumnTypeToken                     // ColumnTypeToken is a column containing an indexed token.
+	ColumnTypeRows                      // ColumnTypeRows is a column containing the row ranges whose lines contain the token.
+)
+
+var columnTypeNames = map[ColumnType]string{
+	ColumnTypeInvalid: "invalid",
+	ColumnTypeSection: "section",
+	ColumnTypeToken:   "token",
+	ColumnTypeRows:    "rows",
+}
+
+// String returns the human-readable name of ct.
+func (ct ColumnType) String() string {
+	text, ok := columnTypeNames[ct]
+	if !ok {
+		return fmt.Sprintf("ColumnType(%d)", ct)
+	}
+	return text
+}
+
+// A Column represents one of the columns in the tokens section. Valid columns
+// can only be retrieved by calling [Section.Columns].
+type Column struct {
+	Section *Section
+	Name    string
+	Type    ColumnType
+
+	desc *tokensmd.ColumnDesc // Column description used for further decoding and reading.
+}
+
+func convertColumnType(protoType tokensmd.ColumnType) (ColumnType, bool) {
+	switch protoType {
+	case tokensmd.COLUMN_TYPE_UNSPECIFIED:
+		return ColumnTypeInvalid, true
+	case tokensmd.COLUMN_TYPE_SECTION:
+		return ColumnTypeSection, true
+	case tokensmd.COLUMN_TYPE_TOKEN:
+		return ColumnTypeToken, true
+	case tokensmd.COLUMN_TYPE_ROWS:
+		return ColumnTypeRows, true
+	}
+	return ColumnTypeInvalid, false
+}
@@ -0,0 +1,205 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"math"
+	"slices"
+	"time"
+
+	"github.com/prometheus/prometheus/model/labels"
+
+	"github.com/grafana/loki/v3/pkg/dataobj"
+	"github.com/grafana/loki/v3/pkg/dataobj/sections/logs"
+	"github.com/grafana/loki/v3/pkg/dataobj/sections/streams"
+	"github.com/grafana/loki/v3/pkg/logql/syntax"
+)
+
+// DumpOptions selects the logs written by [DumpLogs].
+type DumpOptions struct {
+	// StreamIDs of the streams to dump. If empty, logs of all streams are
+	// dumped.
+	StreamIDs []int64
+
+	// Start and End of the time range of the logs to dump. Start is
+	// inclusive and End is exclusive. Zero values leave the range open.
+	Start, End time.Time
+}
+
+// DumpLogs writes the logs of the data object in r selected by opts to w, as
+// one JSON object per line.
+func DumpLogs(ctx context.Context, w io.Writer, r io.ReaderAt, size int64, opts DumpOptions) error {
+	obj, err := dataobj.FromReaderAt(r, size)
+	if err != nil {
+		return fmt.Errorf("opening object: %w", err)
+	}
+
+	// Streams can't be filtered by ID with a predicate, so they are filtered
+	// after reading instead.
+	var keepStream func(streams.Stream) bool
+	if len(opts.StreamIDs) > 0 {
+		keepStream = func(stream streams.Stream) bool { return slices.Contains(opts.StreamIDs, stream.ID) }
+	}
+
+	return writeLogs(ctx, w, obj, nil, keepStream, nil, opts.Start, opts.End)
+}
+
+// Query writes the logs of the data object in r which match the LogQL log
+// query and the time range [start, end) to w, as one JSON object per line.
+// Zero values of start and end leave the time range open.
+//
+// The query is evaluated with the predicates used to read data objects, so
+// it may only consist of a stream selector and line filters.
+func Query(ctx context.Context, w io.Writer, r io.ReaderAt, size int64, query string, start, end time.Time) error {
+	expr, err := syntax.ParseLogSelector(query, true)
+	if err != nil {
+		return fmt.Errorf("parsing query: %w", err)
+	}
+
+	var logsPredicates []logs.RowPredicate
+	if pipeline, ok := expr.(*syntax.PipelineExpr); ok {
+		for _, stage := range pipeline.MultiStages {
+			filterExpr, ok := stage.(*syntax.LineFilterExpr)
+			if !ok {
+				return fmt.Errorf("unsupported pipeline stage %q: only line filters are supported", stage.String())
+			}
+			filter, err := filterExpr.Filter()
+			if err != nil {
+				return fmt.Errorf("building line filter: %w", err)
+			}
+			logsPredicates = append(logsPredicates, logs.LogMessageFilterRowPredicate{
+				Keep: func(line []byte) bool { return filter.Filter(line) },
+			})
+		}
+	}
+
+	obj, err := dataobj.FromReaderAt(r, size)
+	if err != nil {
+		return fmt.Errorf("opening object: %w", err)
+	}
+
+	return writeLogs(ctx, w, obj, matchersToPredicate(expr.Matchers()), nil, logsPredicates, start, end)
+}
+
+// logRecord is the JSON representation of a log record written by
+// [DumpLogs] and [Query].
+type logRecord struct {
+	StreamID  int64             `json:"stream_id"`
+	Labels    string            `json:"labels"`
+	Timestamp time.Time         `json:"timestamp"`
+	Metadata  map[string]string `json:"metadata,omitempty"`
+	Line      string            `json:"line"`
+}
+
+// writeLogs writes the logs of the streams of obj which pass
+// streamsPredicate and keepStream to w, as one JSON object per line. Only
+// logs within [start, end) which pass all logsPredicates are written. Nil
+// values of streamsPredicate and keepStream pass all streams.
+func writeLogs(ctx context.Context, w io.Writer, obj *dataobj.Object, streamsPredicate streams.RowPredicate, keepStream func(streams.Stream) bool, logsPredicates []logs.RowPredicate, start, end time.Time) error {
+	if start.IsZero() {
+		start = time.Unix(0, 0)
+	}
+	if end.IsZero() {
+		end = time.Unix(0, math.MaxInt64)
+	}
+	if !start.Before(end) {
+		return fmt.Errorf("start time %s must be before end time %s", start, end)
+	}
+
+	if streamsPredicate == nil {
+		streamsPredicate = streams.TimeRangeRowPredicate{StartTime: start, EndTime: end, IncludeStart: true}
+	} else {
+		streamsPredicate = streams.AndRowPredicate{
+			Left:  streams.TimeRangeRowPredicate{StartTime: start, EndTime: end, IncludeStart: true},
+			Right: streamsPredicate,
+		}
+	}
+
+	streamLabels := make(map[int64]string)
+	err := forEachStream(ctx, obj, streamsPredicate, func(stream streams.Stream) {
+		if keepStream == nil || keepStream(stream) {
+			streamLabels[stream.ID] = stream.Labels.String()
+		}
+	})
+	if err != nil {
+		return err
+	} else if len(streamLabels) == 0 {
+		return nil
+	}
+
+	predicates := append([]logs.RowPredicate{
+		logs.TimeRangeRowPredicate{StartTime: start, EndTime: end, IncludeStart: true},
+	}, logsPredicates...)
+
+	var reader logs.RowReader
+	defer reader.Close()
+
+	enc := json.NewEncoder(w)
+	buf := make([]logs.Record, 1024)
+	for i, section := range obj.Sections().Filter(logs.CheckSection) {
+		sec, err := logs.Open(ctx, section)
+		if err != nil {
+			return fmt.Errorf("opening logs section %d: %w", i, err)
+		}
+
+		reader.Reset(sec)
+		if err := reader.MatchStreams(maps.Keys(streamLabels)); err != nil {
+			return err
+		} else if err := reader.SetPredicates(predicates); err != nil {
+			return err
+		}
+
+		for {
+			n, err := reader.Read(ctx, buf)
+			if err != nil && !errors.Is(err, io.EOF) {
+				return fmt.Errorf("reading logs section %d: %w", i, err)
+			} else if n == 0 && errors.Is(err, io.EOF) {
+				break
+			}
+
+			for _, record := range buf[:n] {
+				out := logRecord{
+					StreamID:  record.StreamID,
+					Labels:    streamLabels[record.StreamID],
+					Timestamp: record.Timestamp.UTC(),
+					Line:      string(record.Line),
+				}
+				if !record.Metadata.IsEmpty() {
+					out.Metadata = record.Metadata.Map()
+				}
+				if err := enc.Encode(out); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// matchersToPredicate converts label matchers to a predicate which passes the
+// streams matching all of them.
+func matchersToPredicate(matchers []*labels.Matcher) streams.RowPredicate {
+	var predicate streams.RowPredicate
+	for _, matcher := range matchers {
+		var right streams.RowPredicate
+		switch matcher.Type {
+		case labels.MatchEqual:
+			right = streams.LabelMatcherRowPredicate{Name: matcher.Name, Value: matcher.Value}
+		default:
+			right = streams.LabelFilterRowPredicate{Name: matcher.Name, Keep: func(_, value string) bool {
+				return matcher.Matches(value)
+			}}
+		}
+
+		if predicate == nil {
+			predicate = right
+		} else {
+			predicate = streams.AndRowPredicate{Left: predicate, Right: right}
+		}
+	}
+	return predicate
+}
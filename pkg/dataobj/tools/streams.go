@@ -0,0 +1,76 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+
+	"github.com/dustin/go-humanize"
+
+	"github.com/grafana/loki/v3/pkg/dataobj"
+	"github.com/grafana/loki/v3/pkg/dataobj/sections/streams"
+)
+
+// PrintStreams writes the streams of the data object in r to w as a table.
+func PrintStreams(ctx context.Context, w io.Writer, r io.ReaderAt, size int64) error {
+	obj, err := dataobj.FromReaderAt(r, size)
+	if err != nil {
+		return fmt.Errorf("opening object: %w", err)
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tMIN TIME\tMAX TIME\tROWS\tUNCOMPRESSED\tLABELS")
+
+	err = forEachStream(ctx, obj, nil, func(stream streams.Stream) {
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%d\t%s\t%s\n",
+			stream.ID,
+			stream.MinTimestamp.UTC().Format(time.RFC3339Nano),
+			stream.MaxTimestamp.UTC().Format(time.RFC3339Nano),
+			stream.Rows,
+			humanize.Bytes(uint64(stream.UncompressedSize)),
+			stream.Labels,
+		)
+	})
+	if err != nil {
+		return err
+	}
+	return tw.Flush()
+}
+
+// forEachStream calls f for each stream of obj which passes predicate. If
+// predicate is nil, f is called for all streams.
+func forEachStream(ctx context.Context, obj *dataobj.Object, predicate streams.RowPredicate, f func(streams.Stream)) error {
+	var reader streams.RowReader
+	defer reader.Close()
+
+	buf := make([]streams.Stream, 1024)
+	for i, section := range obj.Sections().Filter(streams.CheckSection) {
+		sec, err := streams.Open(ctx, section)
+		if err != nil {
+			return fmt.Errorf("opening streams section %d: %w", i, err)
+		}
+
+		reader.Reset(sec)
+		if predicate != nil {
+			if err := reader.SetPredicate(predicate); err != nil {
+				return err
+			}
+		}
+
+		for {
+			n, err := reader.Read(ctx, buf)
+			if err != nil && !errors.Is(err, io.EOF) {
+				return fmt.Errorf("reading streams section %d: %w", i, err)
+			} else if n == 0 && errors.Is(err, io.EOF) {
+				break
+			}
+			for _, stream := range buf[:n] {
+				f(stream)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,149 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/loki/v3/pkg/dataobj/consumer/logsobj"
+	"github.com/grafana/loki/v3/pkg/logproto"
+)
+
+func TestTools(t *testing.T) {
+	var (
+		ctx  = context.Background()
+		now  = time.Unix(1000, 0).UTC()
+		data = buildObject(t, []logproto.Stream{
+			{
+				Labels: `{app="foo"}`,
+				Entries: []logproto.Entry{
+					{Timestamp: now.Add(1 * time.Second), Line: "foo info"},
+					{Timestamp: now.Add(2 * time.Second), Line: "foo error", StructuredMetadata: logproto.FromLabelsToLabelAdapters(labels.FromStrings("trace_id", "123"))},
+					{Timestamp: now.Add(3 * time.Second), Line: "foo error again"},
+				},
+			},
+			{
+				Labels: `{app="bar"}`,
+				Entries: []logproto.Entry{
+					{Timestamp: now.Add(1 * time.Second), Line: "bar error"},
+				},
+			},
+		})
+		r    = bytes.NewReader(data)
+		size = int64(len(data))
+	)
+
+	t.Run("streams", func(t *testing.T) {
+		var buf bytes.Buffer
+		require.NoError(t, PrintStreams(ctx, &buf, r, size))
+
+		lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+		require.Len(t, lines, 3)
+		require.True(t, strings.HasPrefix(lines[0], "ID"))
+		require.Contains(t, buf.String(), `{app="foo"}`)
+		require.Contains(t, buf.String(), `{app="bar"}`)
+	})
+
+	var fooID int64
+	t.Run("dump all", func(t *testing.T) {
+		var buf bytes.Buffer
+		require.NoError(t, DumpLogs(ctx, &buf, r, size, DumpOptions{}))
+
+		records := decodeRecords(t, &buf)
+		require.Len(t, records, 4)
+		for _, record := range records {
+			if record.Labels == `{app="foo"}` {
+				fooID = record.StreamID
+			}
+		}
+		require.NotZero(t, fooID)
+	})
+
+	t.Run("dump stream and time range", func(t *testing.T) {
+		var buf bytes.Buffer
+		require.NoError(t, DumpLogs(ctx, &buf, r, size, DumpOptions{
+			StreamIDs: []int64{fooID},
+			Start:     now.Add(2 * time.Second),
+			End:       now.Add(3 * time.Second),
+		}))
+
+		require.Equal(t, []logRecord{{
+			StreamID:  fooID,
+			Labels:    `{app="foo"}`,
+			Timestamp: now.Add(2 * time.Second),
+			Metadata:  map[string]string{"trace_id": "123"},
+			Line:      "foo error",
+		}}, decodeRecords(t, &buf))
+	})
+
+	t.Run("query", func(t *testing.T) {
+		var buf bytes.Buffer
+		require.NoError(t, Query(ctx, &buf, r, size, `{app=~"f.+"} |= "error" != "again"`, time.Time{}, time.Time{}))
+
+		records := decodeRecords(t, &buf)
+		require.Len(t, records, 1)
+		require.Equal(t, "foo error", records[0].Line)
+	})
+
+	t.Run("query with unsupported stage", func(t *testing.T) {
+		require.ErrorContains(t, Query(ctx, &bytes.Buffer{}, r, size, `{app="foo"} | json`, time.Time{}, time.Time{}), "only line filters are supported")
+	})
+
+	t.Run("verify", func(t *testing.T) {
+		var buf bytes.Buffer
+		require.NoError(t, Verify(ctx, &buf, r, size))
+		require.NotContains(t, buf.String(), "FAILED")
+		require.Contains(t, buf.String(), "streams: OK")
+	})
+
+	t.Run("verify corrupted object", func(t *testing.T) {
+		corrupted := bytes.Clone(data)
+		// Sections are written before the metadata of the object, so this
+		// corrupts the data or metadata of a section.
+		corrupted[len(corrupted)/4] ^= 0xff
+
+		var buf bytes.Buffer
+		require.Error(t, Verify(ctx, &buf, bytes.NewReader(corrupted), size))
+		require.Contains(t, buf.String(), "FAILED")
+	})
+}
+
+func buildObject(t *testing.T, streams []logproto.Stream) []byte {
+	t.Helper()
+
+	builder, err := logsobj.NewBuilder(logsobj.BuilderConfig{
+		TargetPageSize:          2048,
+		TargetObjectSize:        1 << 22, // 4 MiB
+		TargetSectionSize:       1 << 21, // 2 MiB
+		BufferSize:              2048 * 8,
+		SectionStripeMergeLimit: 2,
+	})
+	require.NoError(t, err)
+	for _, stream := range streams {
+		require.NoError(t, builder.Append(stream))
+	}
+
+	var buf bytes.Buffer
+	_, err = builder.Flush(&buf)
+	require.NoError(t, err)
+	return buf.Bytes()
+}
+
+func decodeRecords(t *testing.T, buf *bytes.Buffer) []logRecord {
+	t.Helper()
+
+	var records []logRecord
+	dec := json.NewDecoder(buf)
+	for dec.More() {
+		var record logRecord
+		require.NoError(t, dec.Decode(&record))
+		records = append(records, record)
+	}
+	return records
+}
@@ -0,0 +1,163 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/grafana/loki/v3/pkg/dataobj"
+	"github.com/grafana/loki/v3/pkg/dataobj/sections/indexpointers"
+	"github.com/grafana/loki/v3/pkg/dataobj/sections/logs"
+	"github.com/grafana/loki/v3/pkg/dataobj/sections/pointers"
+	"github.com/grafana/loki/v3/pkg/dataobj/sections/streams"
+	"github.com/grafana/loki/v3/pkg/dataobj/sections/tokens"
+)
+
+// Verify checks the data object in r for corruption, writing the result of
+// each check to w. Verify returns an error if any check fails.
+//
+// The pages of all known sections are read and decoded, checking their CRC32
+// checksums and that they match the metadata of the pages and columns. The
+// logs of data objects are then checked against the stream metadata: every
+// log record must belong to a known stream, and the row counts and time
+// ranges of streams must match their logs.
+func Verify(ctx context.Context, w io.Writer, r io.ReaderAt, size int64) error {
+	obj, err := dataobj.FromReaderAt(r, size)
+	if err != nil {
+		return fmt.Errorf("opening object: %w", err)
+	}
+
+	var failed int
+	for i, section := range obj.Sections() {
+		err := verifySection(ctx, section)
+		switch {
+		case errors.Is(err, errUnknownSection):
+			fmt.Fprintf(w, "section %d (%s): skipped, unknown section type\n", i, section.Type)
+		case err != nil:
+			failed++
+			fmt.Fprintf(w, "section %d (%s): FAILED: %v\n", i, section.Type, err)
+		default:
+			fmt.Fprintf(w, "section %d (%s): OK\n", i, section.Type)
+		}
+	}
+
+	if failed > 0 {
+		// Skip the consistency checks: reading corrupted sections would
+		// only report the same errors again.
+		return fmt.Errorf("%d of %d sections failed verification", failed, len(obj.Sections()))
+	}
+
+	if obj.Sections().Count(logs.CheckSection) > 0 {
+		if err := verifyStreams(ctx, obj); err != nil {
+			fmt.Fprintf(w, "streams: FAILED: %v\n", err)
+			return fmt.Errorf("streams failed verification: %w", err)
+		}
+		fmt.Fprintln(w, "streams: OK")
+	}
+	return nil
+}
+
+var errUnknownSection = errors.New("unknown section type")
+
+func verifySection(ctx context.Context, section *dataobj.Section) error {
+	switch {
+	case streams.CheckSection(section):
+		sec, err := streams.Open(ctx, section)
+		if err != nil {
+			return err
+		}
+		return streams.Verify(ctx, sec)
+
+	case logs.CheckSection(section):
+		sec, err := logs.Open(ctx, section)
+		if err != nil {
+			return err
+		}
+		return logs.Verify(ctx, sec)
+
+	case tokens.CheckSection(section):
+		sec, err := tokens.Open(ctx, section)
+		if err != nil {
+			return err
+		}
+		return tokens.Verify(ctx, sec)
+
+	case pointers.CheckSection(section):
+		sec, err := pointers.Open(ctx, section)
+		if err != nil {
+			return err
+		}
+		return pointers.Verify(ctx, sec)
+
+	case indexpointers.CheckSection(section):
+		sec, err := indexpointers.Open(ctx, section)
+		if err != nil {
+			return err
+		}
+		return indexpointers.Verify(ctx, sec)
+	}
+	return errUnknownSection
+}
+
+// verifyStreams checks that every log record of obj belongs to a known
+// stream, and that the row counts and time ranges of the streams match their
+// logs.
+func verifyStreams(ctx context.Context, obj *dataobj.Object) error {
+	type streamLogs struct {
+		stream streams.Stream
+		rows   int
+	}
+
+	byID := make(map[int64]*streamLogs)
+	var duplicate int64
+	err := forEachStream(ctx, obj, nil, func(stream streams.Stream) {
+		if _, ok := byID[stream.ID]; ok {
+			duplicate = stream.ID
+		}
+		byID[stream.ID] = &streamLogs{stream: stream}
+	})
+	if err != nil {
+		return err
+	} else if duplicate != 0 {
+		return fmt.Errorf("duplicate stream ID %d", duplicate)
+	}
+
+	var reader logs.RowReader
+	defer reader.Close()
+
+	buf := make([]logs.Record, 1024)
+	for i, section := range obj.Sections().Filter(logs.CheckSection) {
+		sec, err := logs.Open(ctx, section)
+		if err != nil {
+			return fmt.Errorf("opening logs section %d: %w", i, err)
+		}
+
+		reader.Reset(sec)
+		for {
+			n, err := reader.Read(ctx, buf)
+			if err != nil && !errors.Is(err, io.EOF) {
+				return fmt.Errorf("reading logs section %d: %w", i, err)
+			} else if n == 0 && errors.Is(err, io.EOF) {
+				break
+			}
+
+			for _, record := range buf[:n] {
+				s, ok := byID[record.StreamID]
+				if !ok {
+					return fmt.Errorf("logs section %d: unknown stream ID %d", i, record.StreamID)
+				} else if record.Timestamp.Before(s.stream.MinTimestamp) || record.Timestamp.After(s.stream.MaxTimestamp) {
+					return fmt.Errorf("logs section %d: record of stream %d at %s is outside of the time range of the stream", i, record.StreamID, record.Timestamp)
+				}
+				s.rows++
+			}
+		}
+	}
+
+	for id, s := range byID {
+		if s.rows != s.stream.Rows {
+			return fmt.Errorf("stream %d has %d rows, expected %d", id, s.rows, s.stream.Rows)
+		}
+	}
+	return nil
+}
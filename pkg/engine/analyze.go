@@ -0,0 +1,53 @@
+package engine
+
+import (
+	"fmt"
+
+	"github.com/grafana/loki/v3/pkg/engine/executor"
+	"github.com/grafana/loki/v3/pkg/engine/planner/physical"
+	"github.com/grafana/loki/v3/pkg/logql"
+	"github.com/grafana/loki/v3/pkg/logqlmodel/stats"
+)
+
+// analysisResult returns the plan of the query with the runtime statistics
+// of each node collected by analysis, as returned in the statistics of the
+// query response.
+func analysisResult(params logql.Params, plan *physical.Plan, analysis *executor.Analysis) *stats.Analysis {
+	result := &stats.Analysis{
+		Query: params.QueryString(),
+		Start: params.Start().UnixNano(),
+		End:   params.End().UnixNano(),
+	}
+	for _, root := range plan.Roots() {
+		result.Plan = append(result.Plan, analysisNode(plan, root, analysis))
+	}
+	return result
+}
+
+func analysisNode(plan *physical.Plan, n physical.Node, analysis *executor.Analysis) *stats.AnalysisNode {
+	nodeStats := analysis.Stats(plan, n)
+	node := &stats.AnalysisNode{
+		Type:        n.Type().String(),
+		RowsIn:      nodeStats.RowsIn,
+		RowsOut:     nodeStats.RowsOut,
+		Batches:     nodeStats.Batches,
+		BytesRead:   nodeStats.BytesRead,
+		PagesPruned: nodeStats.PagesPruned,
+		WallTime:    nodeStats.WallTime.Seconds(),
+	}
+	for _, p := range physical.NodeProperties(n) {
+		node.Properties = append(node.Properties, stats.AnalysisProperty{Key: p.Key, Value: fmt.Sprint(p.Value)})
+	}
+
+	for _, child := range plan.Children(n) {
+		node.Children = append(node.Children, analysisNode(plan, child, analysis))
+	}
+	// The fragment of an exchange is part of the tree, like when printing
+	// the plan.
+	if exchange, ok := n.(*physical.Exchange); ok && exchange.Fragment != nil {
+		for _, root := range exchange.Fragment.Roots() {
+			node.Children = append(node.Children, analysisNode(exchange.Fragment, root, analysis))
+		}
+	}
+	return node
+}
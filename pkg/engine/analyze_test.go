@@ -0,0 +1,66 @@
+package engine
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/loki/v3/pkg/engine/executor"
+	"github.com/grafana/loki/v3/pkg/engine/planner/logical"
+	"github.com/grafana/loki/v3/pkg/engine/planner/physical"
+	"github.com/grafana/loki/v3/pkg/logproto"
+	"github.com/grafana/loki/v3/pkg/logql"
+	"github.com/grafana/loki/v3/pkg/logqlmodel/stats"
+)
+
+func TestAnalysisResult(t *testing.T) {
+	start := time.Unix(0, 0).UTC()
+	end := start.Add(time.Minute)
+	params, err := logql.NewLiteralParams(`vector(1) * 2`, start, end, 30*time.Second, 0, logproto.FORWARD, 0, nil, nil)
+	require.NoError(t, err)
+
+	logicalPlan, err := logical.BuildPlan(params)
+	require.NoError(t, err)
+	planner := physical.NewPlanner(physical.NewContext(params.Start(), params.End()), nil)
+	plan, err := planner.Build(logicalPlan)
+	require.NoError(t, err)
+
+	ctx := t.Context()
+	analysis := executor.NewAnalysis()
+	pipeline := executor.Run(ctx, executor.Config{BatchSize: 100, Analysis: analysis}, plan, log.NewNopLogger())
+	defer pipeline.Close()
+	for {
+		err := pipeline.Read(ctx)
+		if errors.Is(err, executor.EOF) {
+			break
+		}
+		require.NoError(t, err)
+	}
+
+	result := analysisResult(params, plan, analysis)
+	require.Equal(t, `vector(1) * 2`, result.Query)
+	require.Equal(t, start.UnixNano(), result.Start)
+	require.Equal(t, end.UnixNano(), result.End)
+	require.Len(t, result.Plan, 1)
+
+	root := result.Plan[0]
+	require.Equal(t, "Join", root.Type)
+	require.Equal(t, []stats.AnalysisProperty{
+		{Key: "operator", Value: "MUL"},
+		{Key: "right_scalar", Value: "2"},
+	}, root.Properties)
+	require.Equal(t, int64(3), root.RowsIn)
+	require.Equal(t, int64(3), root.RowsOut)
+	require.Equal(t, int64(1), root.Batches)
+	require.Positive(t, root.WallTime)
+
+	require.Len(t, root.Children, 1)
+	leaf := root.Children[0]
+	require.Equal(t, "Vector", leaf.Type)
+	require.Equal(t, int64(0), leaf.RowsIn)
+	require.Equal(t, int64(3), leaf.RowsOut)
+	require.Empty(t, leaf.Children)
+}
@@ -27,6 +27,7 @@ type ResultBuilder interface {
 
 var _ ResultBuilder = &streamsResultBuilder{}
 var _ ResultBuilder = &vectorResultBuilder{}
+var _ ResultBuilder = &matrixResultBuilder{}
 
 func newStreamsResultBuilder() *streamsResultBuilder {
 	return &streamsResultBuilder{
@@ -66,6 +67,7 @@ func (b *streamsResultBuilder) collectRow(rec arrow.Record, i int) (labels.Label
 	var entry logproto.Entry
 	lbs := labels.NewBuilder(labels.EmptyLabels())
 	metadata := labels.NewBuilder(labels.EmptyLabels())
+	parsed := labels.NewBuilder(labels.EmptyLabels())
 
 	for colIdx := range int(rec.NumCols()) {
 		col := rec.Column(colIdx)
@@ -111,10 +113,26 @@ func (b *streamsResultBuilder) collectRow(rec arrow.Record, i int) (labels.Label
 			}
 			continue
 		}
+
+		// Extract parsed columns
+		if colType == types.ColumnTypeParsed.String() {
+			switch arr := col.(type) {
+			case *array.String:
+				// Parsed columns without value, such as __error__ of rows
+				// that were parsed successfully, are not part of the labels.
+				if arr.Value(i) == "" {
+					continue
+				}
+				parsed.Set(colName, arr.Value(i))
+				// include parsed labels in stream labels
+				lbs.Set(colName, arr.Value(i))
+			}
+			continue
+		}
 	}
 	entry.StructuredMetadata = logproto.FromLabelsToLabelAdapters(metadata.Labels())
 	// set to a non-nil value to match with existing engine.
-	entry.Parsed = logproto.FromLabelsToLabelAdapters(labels.Labels{})
+	entry.Parsed = logproto.FromLabelsToLabelAdapters(parsed.Labels())
 
 	return lbs.Labels(), entry
 }
@@ -136,12 +154,17 @@ func (b *streamsResultBuilder) Len() int {
 type vectorResultBuilder struct {
 	data        promql.Vector
 	lblsBuilder *labels.Builder
+	sortByValue bool
 }
 
-func newVectorResultBuilder() *vectorResultBuilder {
+// newVectorResultBuilder returns a builder for instant query results.
+// If sortByValue is true, the samples are returned in the order they were
+// collected instead of being sorted by their labels.
+func newVectorResultBuilder(sortByValue bool) *vectorResultBuilder {
 	return &vectorResultBuilder{
 		data:        promql.Vector{},
 		lblsBuilder: labels.NewBuilder(labels.EmptyLabels()),
+		sortByValue: sortByValue,
 	}
 }
 
@@ -157,8 +180,14 @@ func (b *vectorResultBuilder) CollectRecord(rec arrow.Record) {
 }
 
 func (b *vectorResultBuilder) collectRow(rec arrow.Record, i int) (promql.Sample, bool) {
+	return collectSampleFromRow(b.lblsBuilder, rec, i)
+}
+
+// collectSampleFromRow converts the row i of the record rec into a [promql.Sample].
+// It returns false if the row does not contain a timestamp or a value.
+func collectSampleFromRow(builder *labels.Builder, rec arrow.Record, i int) (promql.Sample, bool) {
 	var sample promql.Sample
-	b.lblsBuilder.Reset(labels.EmptyLabels())
+	builder.Reset(labels.EmptyLabels())
 
 	// TODO: we add a lot of overhead by reading row by row. Switch to vectorized conversion.
 	for colIdx := range int(rec.NumCols()) {
@@ -184,27 +213,32 @@ func (b *vectorResultBuilder) collectRow(rec arrow.Record, i int) (promql.Sample
 				return promql.Sample{}, false
 			}
 
-			col, ok := col.(*array.Int64)
-			if !ok {
+			switch col := col.(type) {
+			case *array.Float64:
+				sample.F = col.Value(i)
+			case *array.Int64:
+				sample.F = float64(col.Value(i))
+			default:
 				return promql.Sample{}, false
 			}
-			sample.F = float64(col.Value(i))
 		default:
 			// allow any string columns
 			if colDataType == datatype.Loki.String.String() {
-				b.lblsBuilder.Set(colName, col.(*array.String).Value(i))
+				builder.Set(colName, col.(*array.String).Value(i))
 			}
 		}
 	}
 
-	sample.Metric = b.lblsBuilder.Labels()
+	sample.Metric = builder.Labels()
 	return sample, true
 }
 
 func (b *vectorResultBuilder) Build(s stats.Result, md *metadata.Context) logqlmodel.Result {
-	sort.Slice(b.data, func(i, j int) bool {
-		return labels.Compare(b.data[i].Metric, b.data[j].Metric) < 0
-	})
+	if !b.sortByValue {
+		sort.Slice(b.data, func(i, j int) bool {
+			return labels.Compare(b.data[i].Metric, b.data[j].Metric) < 0
+		})
+	}
 	return logqlmodel.Result{
 		Data:       b.data,
 		Statistics: s,
@@ -216,3 +250,58 @@ func (b *vectorResultBuilder) Build(s stats.Result, md *metadata.Context) logqlm
 func (b *vectorResultBuilder) Len() int {
 	return len(b.data)
 }
+
+type matrixResultBuilder struct {
+	seriesIndex map[uint64]int
+	data        promql.Matrix
+	count       int
+	lblsBuilder *labels.Builder
+}
+
+func newMatrixResultBuilder() *matrixResultBuilder {
+	return &matrixResultBuilder{
+		seriesIndex: make(map[uint64]int),
+		data:        promql.Matrix{},
+		lblsBuilder: labels.NewBuilder(labels.EmptyLabels()),
+	}
+}
+
+func (b *matrixResultBuilder) CollectRecord(rec arrow.Record) {
+	for row := range int(rec.NumRows()) {
+		sample, ok := collectSampleFromRow(b.lblsBuilder, rec, row)
+		if !ok {
+			continue
+		}
+
+		// TODO: handle hash collisions
+		hash := sample.Metric.Hash()
+		idx, ok := b.seriesIndex[hash]
+		if !ok {
+			idx = len(b.data)
+			b.seriesIndex[hash] = idx
+			b.data = append(b.data, promql.Series{Metric: sample.Metric})
+		}
+		b.data[idx].Floats = append(b.data[idx].Floats, promql.FPoint{T: sample.T, F: sample.F})
+		b.count++
+	}
+}
+
+func (b *matrixResultBuilder) Build(s stats.Result, md *metadata.Context) logqlmodel.Result {
+	for _, series := range b.data {
+		sort.Slice(series.Floats, func(i, j int) bool {
+			return series.Floats[i].T < series.Floats[j].T
+		})
+	}
+	sort.Sort(b.data)
+
+	return logqlmodel.Result{
+		Data:       b.data,
+		Statistics: s,
+		Headers:    md.Headers(),
+		Warnings:   md.Warnings(),
+	}
+}
+
+func (b *matrixResultBuilder) Len() int {
+	return b.count
+}
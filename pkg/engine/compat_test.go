@@ -190,6 +190,54 @@ func TestStreamsResultBuilder(t *testing.T) {
 		}
 		require.Equal(t, expected, result.Data.(logqlmodel.Streams))
 	})
+
+	t.Run("parsed columns are included in stream labels", func(t *testing.T) {
+		mdTypeParsed := datatype.ColumnMetadata(types.ColumnTypeParsed, datatype.Loki.String)
+		schema := arrow.NewSchema(
+			[]arrow.Field{
+				{Name: types.ColumnNameBuiltinTimestamp, Type: arrow.FixedWidthTypes.Timestamp_ns, Metadata: datatype.ColumnMetadataBuiltinTimestamp},
+				{Name: types.ColumnNameBuiltinMessage, Type: arrow.BinaryTypes.String, Metadata: datatype.ColumnMetadataBuiltinMessage},
+				{Name: "env", Type: arrow.BinaryTypes.String, Metadata: mdTypeLabel},
+				{Name: "level", Type: arrow.BinaryTypes.String, Metadata: mdTypeParsed},
+				{Name: logqlmodel.ErrorLabel, Type: arrow.BinaryTypes.String, Metadata: mdTypeParsed},
+			},
+			nil,
+		)
+
+		data := [][]interface{}{
+			{arrow.Timestamp(1620000000000000001), "level=error", "prod", "error", ""},
+			{arrow.Timestamp(1620000000000000002), "{", "prod", nil, "JSONParserErr"},
+		}
+
+		record := createRecord(t, schema, data)
+		defer record.Release()
+
+		pipeline := executor.NewBufferedPipeline(record)
+		defer pipeline.Close()
+
+		builder := newStreamsResultBuilder()
+		err := collectResult(context.Background(), pipeline, builder)
+		require.NoError(t, err)
+
+		md, _ := metadata.NewContext(t.Context())
+		result := builder.Build(stats.Result{}, md)
+
+		expected := logqlmodel.Streams{
+			push.Stream{
+				Labels: labels.FromStrings(logqlmodel.ErrorLabel, "JSONParserErr", "env", "prod").String(),
+				Entries: []logproto.Entry{
+					{Line: "{", Timestamp: time.Unix(0, 1620000000000000002), StructuredMetadata: logproto.FromLabelsToLabelAdapters(labels.Labels{}), Parsed: logproto.FromLabelsToLabelAdapters(labels.FromStrings(logqlmodel.ErrorLabel, "JSONParserErr"))},
+				},
+			},
+			push.Stream{
+				Labels: labels.FromStrings("env", "prod", "level", "error").String(),
+				Entries: []logproto.Entry{
+					{Line: "level=error", Timestamp: time.Unix(0, 1620000000000000001), StructuredMetadata: logproto.FromLabelsToLabelAdapters(labels.Labels{}), Parsed: logproto.FromLabelsToLabelAdapters(labels.FromStrings("level", "error"))},
+				},
+			},
+		}
+		require.Equal(t, expected, result.Data.(logqlmodel.Streams))
+	})
 }
 
 func TestVectorResultBuilder(t *testing.T) {
@@ -218,7 +266,7 @@ func TestVectorResultBuilder(t *testing.T) {
 		pipeline := executor.NewBufferedPipeline(record)
 		defer pipeline.Close()
 
-		builder := newVectorResultBuilder()
+		builder := newVectorResultBuilder(false)
 		err := collectResult(context.Background(), pipeline, builder)
 
 		require.NoError(t, err)
@@ -267,10 +315,61 @@ func TestVectorResultBuilder(t *testing.T) {
 		pipeline := executor.NewBufferedPipeline(record)
 		defer pipeline.Close()
 
-		builder := newVectorResultBuilder()
+		builder := newVectorResultBuilder(false)
 		err := collectResult(context.Background(), pipeline, builder)
 
 		require.NoError(t, err)
 		require.Equal(t, 0, builder.Len(), "expected no samples to be collected")
 	})
 }
+
+func TestMatrixResultBuilder(t *testing.T) {
+	mdTypeString := datatype.ColumnMetadata(types.ColumnTypeAmbiguous, datatype.Loki.String)
+
+	t.Run("successful conversion of matrix data", func(t *testing.T) {
+		schema := arrow.NewSchema(
+			[]arrow.Field{
+				{Name: types.ColumnNameBuiltinTimestamp, Type: arrow.FixedWidthTypes.Timestamp_ns, Metadata: datatype.ColumnMetadataBuiltinTimestamp},
+				{Name: types.ColumnNameGeneratedValue, Type: arrow.PrimitiveTypes.Int64, Metadata: datatype.ColumnMetadata(types.ColumnTypeGenerated, datatype.Loki.Integer)},
+				{Name: "instance", Type: arrow.BinaryTypes.String, Metadata: mdTypeString},
+				{Name: "job", Type: arrow.BinaryTypes.String, Metadata: mdTypeString},
+			},
+			nil,
+		)
+
+		data := [][]any{
+			{arrow.Timestamp(1620000060000000000), int64(7), "localhost:9090", "prometheus"},
+			{arrow.Timestamp(1620000000000000000), int64(42), "localhost:9090", "prometheus"},
+			{arrow.Timestamp(1620000000000000000), int64(23), "localhost:9100", "node-exporter"},
+			{arrow.Timestamp(1620000060000000000), int64(15), "localhost:9100", "node-exporter"},
+		}
+
+		record := createRecord(t, schema, data)
+		defer record.Release()
+
+		pipeline := executor.NewBufferedPipeline(record)
+		defer pipeline.Close()
+
+		builder := newMatrixResultBuilder()
+		err := collectResult(context.Background(), pipeline, builder)
+
+		require.NoError(t, err)
+		require.Equal(t, 4, builder.Len())
+
+		md, _ := metadata.NewContext(t.Context())
+		result := builder.Build(stats.Result{}, md)
+		matrix := result.Data.(promql.Matrix)
+
+		expected := promql.Matrix{
+			{
+				Metric: labels.FromStrings("instance", "localhost:9090", "job", "prometheus"),
+				Floats: []promql.FPoint{{T: 1620000000000, F: 42}, {T: 1620000060000, F: 7}},
+			},
+			{
+				Metric: labels.FromStrings("instance", "localhost:9100", "job", "node-exporter"),
+				Floats: []promql.FPoint{{T: 1620000000000, F: 23}, {T: 1620000060000, F: 15}},
+			},
+		}
+		require.Equal(t, expected, matrix)
+	})
+}
@@ -3,11 +3,13 @@ package engine
 import (
 	"context"
 	"fmt"
+	"io"
 	"strings"
 	"time"
 
 	"github.com/go-kit/log"
 	"github.com/go-kit/log/level"
+	"github.com/grafana/dskit/tenant"
 	"github.com/pkg/errors"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/thanos-io/objstore"
@@ -21,14 +23,32 @@ import (
 	"github.com/grafana/loki/v3/pkg/logqlmodel"
 	"github.com/grafana/loki/v3/pkg/logqlmodel/metadata"
 	"github.com/grafana/loki/v3/pkg/logqlmodel/stats"
+	"github.com/grafana/loki/v3/pkg/querier/queryrange/queryrangebase/definitions"
 	"github.com/grafana/loki/v3/pkg/util/httpreq"
 	utillog "github.com/grafana/loki/v3/pkg/util/log"
+	"github.com/grafana/loki/v3/pkg/util/validation"
 )
 
 var ErrNotSupported = errors.New("feature not supported in new query engine")
 
+// HasDataObjectsAvailable returns whether the data objects of the time range
+// from start to end are available in object storage, so that queries for the
+// time range can be executed with the new query engine.
+func HasDataObjectsAvailable(_, end time.Time) bool {
+	// Data objects in object storage lag behind 20-30 minutes.
+	// We are generous and only enable v2 engine queries that end earlier than 1 hour ago, to ensure data objects are available.
+	return end.Before(time.Now().Add(-1 * time.Hour))
+}
+
+// Limits are the per-tenant limits of the query engine.
+type Limits interface {
+	logql.Limits
+	QueryEngineAggregationMemoryLimit(context.Context, string) int
+	QueryEngineAggregationSpillLimit(context.Context, string) int
+}
+
 // New creates a new instance of the query engine that implements the [logql.Engine] interface.
-func New(opts logql.EngineOpts, bucket objstore.Bucket, limits logql.Limits, reg prometheus.Registerer, logger log.Logger) *QueryEngine {
+func New(opts logql.EngineOpts, bucket objstore.Bucket, limits Limits, reg prometheus.Registerer, logger log.Logger) *QueryEngine {
 	var ms metastore.Metastore
 	if bucket != nil {
 		metastoreBucket := objstore.NewPrefixedBucket(bucket, opts.CataloguePath)
@@ -49,14 +69,24 @@ func New(opts logql.EngineOpts, bucket objstore.Bucket, limits logql.Limits, reg
 	}
 }
 
+// NewDistributed creates a new instance of the query engine that cuts the
+// physical plans of queries into fragments, which are executed by runner.
+// Only the results of the fragments are merged by the engine itself.
+func NewDistributed(opts logql.EngineOpts, bucket objstore.Bucket, limits Limits, runner executor.FragmentRunner, reg prometheus.Registerer, logger log.Logger) *QueryEngine {
+	e := New(opts, bucket, limits, reg, logger)
+	e.fragments = runner
+	return e
+}
+
 // QueryEngine combines logical planning, physical planning, and execution to evaluate LogQL queries.
 type QueryEngine struct {
 	logger    log.Logger
 	metrics   *metrics
-	limits    logql.Limits
+	limits    Limits
 	metastore metastore.Metastore
 	bucket    objstore.Bucket
 	opts      logql.EngineOpts
+	fragments executor.FragmentRunner
 }
 
 // Query implements [logql.Engine].
@@ -103,6 +133,9 @@ func (e *QueryEngine) Execute(ctx context.Context, params logql.Params) (logqlmo
 		catalogueType = physical.CatalogueTypeIndex
 	}
 	catalog := physical.NewMetastoreCatalog(ctx, e.metastore, catalogueType)
+	if e.opts.EnableCostBasedOptimizer && e.bucket != nil {
+		catalog = catalog.WithStatistics(e.bucket)
+	}
 	planner := physical.NewPlanner(physical.NewContext(params.Start(), params.End()), catalog)
 	plan, err := planner.Build(logicalPlan)
 	if err != nil {
@@ -116,6 +149,14 @@ func (e *QueryEngine) Execute(ctx context.Context, params logql.Params) (logqlmo
 		e.metrics.subqueries.WithLabelValues(statusFailure).Inc()
 		return logqlmodel.Result{}, ErrNotSupported
 	}
+	if e.fragments != nil {
+		plan, err = physical.Fragment(plan, e.opts.DistributedFragments)
+		if err != nil {
+			level.Warn(logger).Log("msg", "failed to fragment physical plan", "err", err)
+			e.metrics.subqueries.WithLabelValues(statusFailure).Inc()
+			return logqlmodel.Result{}, ErrNotSupported
+		}
+	}
 	e.metrics.physicalPlanning.Observe(time.Since(t).Seconds())
 	durPhysicalPlanning := time.Since(t)
 
@@ -130,20 +171,33 @@ func (e *QueryEngine) Execute(ctx context.Context, params logql.Params) (logqlmo
 	)
 
 	t = time.Now() // start stopwatch for execution
-	cfg := executor.Config{
-		BatchSize: int64(e.opts.BatchSize),
-		Bucket:    e.bucket,
+	var analysis *executor.Analysis
+	if httpreq.ExtractHeader(ctx, httpreq.LokiExplainAnalyzeHeader) == "true" {
+		analysis = executor.NewAnalysis()
 	}
+
+	cfg := e.executorConfig(ctx)
+	cfg.Analysis = analysis
+	cfg.Fragments = e.fragments
 	pipeline := executor.Run(ctx, cfg, plan, logger)
 	defer pipeline.Close()
 
 	var builder ResultBuilder
+	// Sample expressions are checked first, because some of them, such as
+	// vector(), also implement [syntax.LogSelectorExpr].
 	switch params.GetExpression().(type) {
+	case syntax.SampleExpr:
+		if logql.GetRangeType(params) == logql.InstantType {
+			sortByValue, err := logql.Sortable(params)
+			if err != nil {
+				return logqlmodel.Result{}, err
+			}
+			builder = newVectorResultBuilder(sortByValue)
+		} else {
+			builder = newMatrixResultBuilder()
+		}
 	case syntax.LogSelectorExpr:
 		builder = newStreamsResultBuilder()
-	case syntax.SampleExpr:
-		// assume instant query since logical planning would fail for range queries.
-		builder = newVectorResultBuilder()
 	default:
 		// should never happen as we already check the expression type in the logical planner
 		panic(fmt.Sprintf("failed to execute. Invalid exprression type (%T)", params.GetExpression()))
@@ -171,10 +225,67 @@ func (e *QueryEngine) Execute(ctx context.Context, params logql.Params) (logqlmo
 		"duration_full", durFull,
 	)
 
+	if analysis != nil {
+		level.Debug(logger).Log("msg", "finished analyzing query", "plan", analysis.PrintAsTree(plan))
+
+		// The analyzed plan is part of the statistics, which the query
+		// frontend merges from the responses of split and sharded queries.
+		stats.Analysis = append(stats.Analysis, analysisResult(params, plan, analysis))
+
+		// Responses with runtime statistics must not be stored in the results
+		// cache, otherwise later queries would return stale statistics.
+		_ = metadata.JoinHeaders(ctx, []*definitions.PrometheusResponseHeader{
+			{Name: "Cache-Control", Values: []string{"no-store"}},
+		})
+	}
+
 	metadataCtx.AddWarning("Query was executed using the new experimental query engine and dataobj storage.")
 	return builder.Build(stats, metadataCtx), nil
 }
 
+// ExecuteFragment executes a fragment of a physical plan that was cut by a
+// distributed query engine, and writes its results to w.
+func (e *QueryEngine) ExecuteFragment(ctx context.Context, fragment *physical.Plan, w io.Writer) error {
+	start := time.Now()
+	logger := utillog.WithContext(ctx, e.logger)
+
+	pipeline := executor.Run(ctx, e.executorConfig(ctx), fragment, logger)
+	defer pipeline.Close()
+
+	if err := executor.WriteFragmentResults(ctx, w, pipeline); err != nil {
+		e.metrics.subqueries.WithLabelValues(statusFailure).Inc()
+		return err
+	}
+	e.metrics.subqueries.WithLabelValues(statusSuccess).Inc()
+
+	level.Debug(logger).Log(
+		"msg", "finished executing fragment with new engine",
+		"nodes", fragment.Len(),
+		"duration_full", time.Since(start),
+	)
+	return nil
+}
+
+// executorConfig returns the configuration of the executor for the tenants of
+// the context.
+func (e *QueryEngine) executorConfig(ctx context.Context) executor.Config {
+	tenantIDs, _ := tenant.TenantIDs(ctx)
+
+	return executor.Config{
+		BatchSize:   int64(e.opts.BatchSize),
+		Bucket:      e.bucket,
+		ScanWorkers: e.opts.ScanWorkers,
+
+		AggregationMemoryLimit: int64(validation.SmallestPositiveNonZeroIntPerTenant(tenantIDs, func(id string) int {
+			return e.limits.QueryEngineAggregationMemoryLimit(ctx, id)
+		})),
+		AggregationSpillLimit: int64(validation.SmallestPositiveNonZeroIntPerTenant(tenantIDs, func(id string) int {
+			return e.limits.QueryEngineAggregationSpillLimit(ctx, id)
+		})),
+		SpillPath: e.opts.SpillPath,
+	}
+}
+
 func collectResult(ctx context.Context, pipeline executor.Pipeline, builder ResultBuilder) error {
 	for {
 		if err := pipeline.Read(ctx); err != nil {
@@ -0,0 +1,71 @@
+package executor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/apache/arrow-go/v18/arrow/array"
+
+	"github.com/grafana/loki/v3/pkg/engine/internal/types"
+	"github.com/grafana/loki/v3/pkg/engine/planner/physical"
+)
+
+// NewAbsentPipeline returns a pipeline that produces a single series with the
+// value 1 for each step of the query for which none of its inputs have rows.
+// The labels of the series are the labels of the absent node.
+func NewAbsentPipeline(absent *physical.Absent, inputs []Pipeline, evaluator expressionEvaluator) *GenericPipeline {
+	var (
+		tsEval = evaluator.newFunc(timestampColumnExpr)
+		done   bool
+	)
+
+	return newGenericPipeline(Local, func(ctx context.Context, inputs []Pipeline) state {
+		if done {
+			return Exhausted
+		}
+		done = true
+
+		present := make(map[int64]struct{}) // timestamps in nanoseconds
+		for _, input := range inputs {
+			for {
+				if err := input.Read(ctx); err != nil {
+					if errors.Is(err, EOF) {
+						break
+					}
+					return failureState(err)
+				}
+
+				batch, err := input.Value()
+				if err != nil {
+					return failureState(err)
+				}
+
+				vec, err := tsEval(batch)
+				if err != nil {
+					return failureState(err)
+				}
+				arr, ok := vec.ToArray().(*array.Timestamp)
+				if !ok {
+					return failureState(fmt.Errorf("unexpected type %s of column %s", vec.Type(), types.ColumnNameBuiltinTimestamp))
+				}
+				for row := range arr.Len() {
+					present[int64(arr.Value(row))] = struct{}{}
+				}
+			}
+		}
+
+		var missing []time.Time
+		for _, ts := range stepTimestamps(absent.Start, absent.End, absent.Step) {
+			if _, ok := present[ts.UnixNano()]; !ok {
+				missing = append(missing, ts)
+			}
+		}
+		if len(missing) == 0 {
+			return Exhausted
+		}
+
+		return successState(newSeriesRecord(missing, 1, absent.Labels))
+	}, inputs...)
+}
@@ -0,0 +1,58 @@
+package executor
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/loki/v3/pkg/engine/planner/physical"
+)
+
+func TestAbsentPipeline(t *testing.T) {
+	absent := &physical.Absent{
+		Labels: labels.FromStrings("env", "prod"),
+		Start:  joinTestT1.Add(-time.Minute),
+		End:    joinTestT2,
+		Step:   time.Minute,
+	}
+
+	t.Run("steps without rows produce a series", func(t *testing.T) {
+		input := newJoinInput(t,
+			"t1,1,prod,loki,",
+			"t1,2,dev,mimir,",
+		)
+
+		pipeline := NewAbsentPipeline(absent, []Pipeline{input}, expressionEvaluator{})
+		defer pipeline.Close()
+
+		require.Equal(t, []string{
+			`0 {env="prod"} 1`,
+			`120 {env="prod"} 1`,
+		}, joinResults(t, pipeline))
+	})
+
+	t.Run("no inputs produce a series for all steps", func(t *testing.T) {
+		pipeline := NewAbsentPipeline(absent, nil, expressionEvaluator{})
+		defer pipeline.Close()
+
+		require.Equal(t, []string{
+			`0 {env="prod"} 1`,
+			`60 {env="prod"} 1`,
+			`120 {env="prod"} 1`,
+		}, joinResults(t, pipeline))
+	})
+
+	t.Run("all steps with rows produce no results", func(t *testing.T) {
+		input := newJoinInput(t,
+			"t1,1,prod,loki,",
+			"t2,2,dev,mimir,",
+		)
+
+		pipeline := NewAbsentPipeline(&physical.Absent{Start: joinTestT1, End: joinTestT2, Step: time.Minute}, []Pipeline{input}, expressionEvaluator{})
+		defer pipeline.Close()
+
+		require.Empty(t, joinResults(t, pipeline))
+	})
+}
@@ -0,0 +1,176 @@
+package executor
+
+import (
+	"context"
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/dustin/go-humanize"
+	"github.com/thanos-io/objstore"
+
+	"github.com/grafana/loki/v3/pkg/engine/planner/physical"
+)
+
+// NodeStats holds the runtime statistics of a node of a physical plan.
+type NodeStats struct {
+	RowsIn      int64         // Number of rows returned by the children of the node.
+	RowsOut     int64         // Number of rows returned by the node.
+	Batches     int64         // Number of batches returned by the node.
+	BytesRead   int64         // Number of bytes read from object storage.
+	PagesPruned int64         // Number of pages skipped because of predicates.
+	WallTime    time.Duration // Time spent reading from the node, including the time spent in its children.
+}
+
+// Analysis collects the runtime statistics of the nodes of a physical plan
+// while the plan is executed. An Analysis is passed to [Run] with
+// [Config.Analysis].
+type Analysis struct {
+	mtx   sync.Mutex
+	nodes map[physical.Node]*nodeStats
+}
+
+// nodeStats holds the statistics of a node while it is executed. Pipelines
+// may be read from different goroutines, e.g. when they are pre-fetched, so
+// all counters are updated atomically.
+type nodeStats struct {
+	rowsOut     atomic.Int64
+	batches     atomic.Int64
+	bytesRead   atomic.Int64
+	pagesPruned atomic.Int64
+	wallTime    atomic.Int64
+}
+
+// NewAnalysis returns a new, empty [Analysis].
+func NewAnalysis() *Analysis {
+	return &Analysis{nodes: make(map[physical.Node]*nodeStats)}
+}
+
+func (a *Analysis) node(n physical.Node) *nodeStats {
+	a.mtx.Lock()
+	defer a.mtx.Unlock()
+
+	s, ok := a.nodes[n]
+	if !ok {
+		s = &nodeStats{}
+		a.nodes[n] = s
+	}
+	return s
+}
+
+// Stats returns the statistics of node n of plan. Nodes that have not been
+// executed have empty statistics.
+func (a *Analysis) Stats(plan *physical.Plan, n physical.Node) NodeStats {
+	s := a.node(n)
+	stats := NodeStats{
+		RowsOut:     s.rowsOut.Load(),
+		Batches:     s.batches.Load(),
+		BytesRead:   s.bytesRead.Load(),
+		PagesPruned: s.pagesPruned.Load(),
+		WallTime:    time.Duration(s.wallTime.Load()),
+	}
+	for _, child := range plan.Children(n) {
+		stats.RowsIn += a.node(child).rowsOut.Load()
+	}
+	return stats
+}
+
+// PrintAsTree returns the tree representation of plan, in which each node is
+// annotated with its runtime statistics.
+func (a *Analysis) PrintAsTree(plan *physical.Plan) string {
+	return physical.PrintAnnotatedAsTree(plan, "Analyze", func(n physical.Node) []physical.Annotation {
+		stats := a.Stats(plan, n)
+
+		var annotations []physical.Annotation
+		if len(plan.Children(n)) > 0 {
+			annotations = append(annotations, physical.Annotation{Key: "rows_in", Value: stats.RowsIn})
+		}
+		annotations = append(annotations,
+			physical.Annotation{Key: "rows_out", Value: stats.RowsOut},
+			physical.Annotation{Key: "batches", Value: stats.Batches},
+		)
+		if _, ok := n.(*physical.DataObjScan); ok {
+			annotations = append(annotations,
+				physical.Annotation{Key: "bytes_read", Value: humanize.Bytes(uint64(stats.BytesRead))},
+				physical.Annotation{Key: "pages_pruned", Value: stats.PagesPruned},
+			)
+		}
+		return append(annotations, physical.Annotation{Key: "wall_time", Value: stats.WallTime})
+	})
+}
+
+// observe wraps the pipeline of node n so that its statistics are recorded.
+func (a *Analysis) observe(n physical.Node, pipeline Pipeline) Pipeline {
+	return &observedPipeline{Pipeline: pipeline, stats: a.node(n)}
+}
+
+// bucket wraps bucket so that the bytes read by node n are recorded.
+func (a *Analysis) bucket(n physical.Node, bucket objstore.BucketReader) objstore.BucketReader {
+	return &countingBucket{BucketReader: bucket, bytesRead: &a.node(n).bytesRead}
+}
+
+// observedPipeline records the number of rows and batches returned by the
+// wrapped pipeline, as well as the time spent reading from it.
+type observedPipeline struct {
+	Pipeline
+	stats *nodeStats
+}
+
+var _ Pipeline = (*observedPipeline)(nil)
+
+// Read implements [Pipeline].
+func (p *observedPipeline) Read(ctx context.Context) error {
+	start := time.Now()
+	err := p.Pipeline.Read(ctx)
+	p.stats.wallTime.Add(int64(time.Since(start)))
+
+	if scan, ok := p.Pipeline.(interface{ pagesPruned() int }); ok {
+		p.stats.pagesPruned.Store(int64(scan.pagesPruned()))
+	}
+	if err != nil {
+		return err
+	}
+
+	if batch, _ := p.Pipeline.Value(); batch != nil {
+		p.stats.batches.Add(1)
+		p.stats.rowsOut.Add(batch.NumRows())
+	}
+	return nil
+}
+
+// countingBucket counts the bytes read from the objects of the wrapped
+// bucket.
+type countingBucket struct {
+	objstore.BucketReader
+	bytesRead *atomic.Int64
+}
+
+// Get implements [objstore.BucketReader].
+func (b *countingBucket) Get(ctx context.Context, name string) (io.ReadCloser, error) {
+	rc, err := b.BucketReader.Get(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	return &countingReader{ReadCloser: rc, bytesRead: b.bytesRead}, nil
+}
+
+// GetRange implements [objstore.BucketReader].
+func (b *countingBucket) GetRange(ctx context.Context, name string, off, length int64) (io.ReadCloser, error) {
+	rc, err := b.BucketReader.GetRange(ctx, name, off, length)
+	if err != nil {
+		return nil, err
+	}
+	return &countingReader{ReadCloser: rc, bytesRead: b.bytesRead}, nil
+}
+
+type countingReader struct {
+	io.ReadCloser
+	bytesRead *atomic.Int64
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	r.bytesRead.Add(int64(n))
+	return n, err
+}
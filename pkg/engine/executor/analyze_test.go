@@ -0,0 +1,90 @@
+package executor
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/stretchr/testify/require"
+	"github.com/thanos-io/objstore"
+
+	"github.com/grafana/loki/v3/pkg/engine/planner/logical"
+	"github.com/grafana/loki/v3/pkg/engine/planner/physical"
+	"github.com/grafana/loki/v3/pkg/logproto"
+	"github.com/grafana/loki/v3/pkg/logql"
+)
+
+func TestAnalysis(t *testing.T) {
+	params, err := logql.NewLiteralParams(`vector(1) * 2`, joinTestT1, joinTestT2, 30*time.Second, 0, logproto.FORWARD, 0, nil, nil)
+	require.NoError(t, err)
+
+	logicalPlan, err := logical.BuildPlan(params)
+	require.NoError(t, err)
+
+	planner := physical.NewPlanner(physical.NewContext(params.Start(), params.End()), nil)
+	plan, err := planner.Build(logicalPlan)
+	require.NoError(t, err)
+
+	ctx := t.Context()
+	analysis := NewAnalysis()
+	pipeline := Run(ctx, Config{BatchSize: 100, Analysis: analysis}, plan, log.NewNopLogger())
+	defer pipeline.Close()
+
+	for {
+		err := pipeline.Read(ctx)
+		if errors.Is(err, EOF) {
+			break
+		}
+		require.NoError(t, err)
+	}
+
+	root, err := plan.Root()
+	require.NoError(t, err)
+	require.IsType(t, &physical.Join{}, root)
+
+	stats := analysis.Stats(plan, root)
+	require.Equal(t, int64(3), stats.RowsIn)
+	require.Equal(t, int64(3), stats.RowsOut)
+	require.Equal(t, int64(1), stats.Batches)
+	require.Positive(t, stats.WallTime)
+
+	leaf := plan.Children(root)[0]
+	require.IsType(t, &physical.Vector{}, leaf)
+
+	stats = analysis.Stats(plan, leaf)
+	require.Equal(t, int64(0), stats.RowsIn)
+	require.Equal(t, int64(3), stats.RowsOut)
+	require.Equal(t, int64(1), stats.Batches)
+
+	repr := analysis.PrintAsTree(plan)
+	require.Contains(t, repr, "Analyze rows_in=3 rows_out=3 batches=1 wall_time=")
+	require.Contains(t, repr, "Analyze rows_out=3 batches=1 wall_time=")
+}
+
+func TestAnalysis_BytesRead(t *testing.T) {
+	ctx := t.Context()
+	bucket := objstore.NewInMemBucket()
+	require.NoError(t, bucket.Upload(ctx, "obj", bytes.NewReader([]byte("0123456789"))))
+
+	scan := &physical.DataObjScan{Location: "obj"}
+	plan := &physical.Plan{}
+	analysis := NewAnalysis()
+	counting := analysis.bucket(scan, bucket)
+
+	rc, err := counting.GetRange(ctx, "obj", 2, 5)
+	require.NoError(t, err)
+	_, err = io.ReadAll(rc)
+	require.NoError(t, err)
+	require.NoError(t, rc.Close())
+
+	rc, err = counting.Get(ctx, "obj")
+	require.NoError(t, err)
+	_, err = io.ReadAll(rc)
+	require.NoError(t, err)
+	require.NoError(t, rc.Close())
+
+	require.Equal(t, int64(15), analysis.Stats(plan, scan).BytesRead)
+}
@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"io"
 	"slices"
+	"sync/atomic"
 
 	"github.com/apache/arrow-go/v18/arrow"
 	"github.com/apache/arrow-go/v18/arrow/array"
@@ -18,6 +19,7 @@ import (
 	"github.com/grafana/loki/v3/pkg/dataobj"
 	"github.com/grafana/loki/v3/pkg/dataobj/sections/logs"
 	"github.com/grafana/loki/v3/pkg/dataobj/sections/streams"
+	"github.com/grafana/loki/v3/pkg/dataobj/sections/tokens"
 	"github.com/grafana/loki/v3/pkg/engine/internal/datatype"
 	"github.com/grafana/loki/v3/pkg/engine/internal/types"
 	"github.com/grafana/loki/v3/pkg/engine/planner/physical"
@@ -32,6 +34,17 @@ type dataobjScan struct {
 	streams     map[int64]labels.Labels
 	records     []logs.Record
 
+	// sorted holds the rows of the section in ascending order of their
+	// timestamps, and offset the number of rows of sorted that have already
+	// been emitted. They are only used for scans in ASC direction.
+	sortedInitialized bool
+	sorted            arrow.Record
+	offset            int64
+
+	// pruned is the number of pages pruned by reader, which is updated after
+	// each read, so that it can be retrieved while another goroutine reads.
+	pruned atomic.Int64
+
 	state state
 }
 
@@ -44,12 +57,19 @@ type dataobjScanOptions struct {
 	StreamIDs   []int64                     // Stream IDs to match from logs sections.
 	Section     int                         // Logs section to fetch.
 	Predicates  []logs.RowPredicate         // Predicate to apply to the logs.
+	Substrings  []string                    // Substrings that log lines must contain; used to skip rows with the token index of Object.
 	Projections []physical.ColumnExpression // Columns to include. An empty slice means all columns.
 
 	Direction physical.SortOrder // Order of timestamps to return (ASC=Forward, DESC=Backward)
 	Limit     uint32             // A limit on the number of rows to return (0=unlimited).
 
 	batchSize int64 // The buffer size for reading rows, derived from the engine batch size.
+
+	section          *logs.Section           // Opened logs section to read. Opened from Object if nil.
+	streams          map[int64]labels.Labels // Labels of StreamIDs. Read from Object if nil.
+	startRow, endRow uint64                  // Row range [startRow, endRow) of the section to read. All rows are read if endRow is 0.
+	rowRanges        []logs.RowRange         // Row ranges of the section that may contain Substrings. Looked up from Object if nil and Substrings is set.
+	workers          chan struct{}           // Limits the number of concurrent reads of scans. Unlimited if nil.
 }
 
 var _ Pipeline = (*dataobjScan)(nil)
@@ -58,22 +78,41 @@ var _ Pipeline = (*dataobjScan)(nil)
 // [arrow.Record] composed of all log sections in a data object. Rows in the
 // returned record are ordered by timestamp in the direction specified by
 // opts.Direction.
+//
+// Logs sections store their rows in descending order of timestamps. For scans
+// in ASC direction, all rows of the section (or the first opts.Limit rows in
+// ascending order, if set) are buffered before the first record is returned.
 func newDataobjScanPipeline(opts dataobjScanOptions, logger log.Logger) *dataobjScan {
-	if opts.Direction == physical.ASC {
-		// It's ok to panic here, because the validation of log query direction is performed in the logical planner.
-		panic("sorting by timestamp ASC is not supported by DataObjScan")
-	}
 	return &dataobjScan{opts: opts, logger: logger}
 }
 
 // Read retrieves the next [arrow.Record] from the dataobj.
 func (s *dataobjScan) Read(ctx context.Context) error {
+	if s.opts.workers != nil {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case s.opts.workers <- struct{}{}:
+		}
+		defer func() { <-s.opts.workers }()
+	}
+
 	if err := s.init(ctx); err != nil {
 		return err
 	}
 
-	rec, err := s.read(ctx)
+	var (
+		rec arrow.Record
+		err error
+	)
+	switch s.opts.Direction {
+	case physical.ASC:
+		rec, err = s.readAscending(ctx)
+	default:
+		rec, err = s.read(ctx)
+	}
 	s.state = newState(rec, err)
+	s.pruned.Store(int64(s.reader.PagesPruned()))
 
 	if err != nil {
 		return fmt.Errorf("reading data object: %w", err)
@@ -92,82 +131,130 @@ func (s *dataobjScan) init(ctx context.Context) error {
 		return fmt.Errorf("initializing streams: %w", err)
 	}
 
-	s.reader = nil
-
-	for idx, section := range s.opts.Object.Sections().Filter(logs.CheckSection) {
-		// Filter out sections that are not part of this shard
-		if s.opts.Section != idx {
-			continue
+	sec := s.opts.section
+	if sec == nil {
+		var err error
+		if sec, err = openLogsSection(ctx, s.opts.Object, s.opts.Section); err != nil {
+			return err
 		}
+	}
 
-		sec, err := logs.Open(ctx, section)
+	// TODO:(ashwanth): [dataobjscan] only supports reading logs sections
+	// that are sorted primarily by timestamp in DESC order.
+	//
+	// Scans in ASC direction re-sort the rows of the section with a
+	// [topkBatch]; other sort orders of sections should be supported the
+	// same way.
+	{
+		colType, sortOrder, err := sec.PrimarySortOrder()
 		if err != nil {
-			return fmt.Errorf("opening logs section: %w", err)
+			level.Warn(s.logger).Log("msg", "missing sort order information", "section", s.opts.Section)
+		} else if colType != logs.ColumnTypeTimestamp || sortOrder != logs.SortDirectionDescending {
+			level.Warn(s.logger).Log("msg", "section is not sorted by timestamp in DESC order",
+				"dataobj", s.opts.Section, "primaryColumnType", colType, "sortOrder", sortOrder)
 		}
+	}
 
-		// TODO:(ashwanth): [dataobjscan] only supports reading logs sections
-		// that are sorted primarily by timestamp in DESC order.
-		//
-		// Other sort orders should be supported by wrapping the scan with TopK
-		// either during planning or execution.
-		{
-			colType, sortOrder, err := sec.PrimarySortOrder()
-			if err != nil {
-				level.Warn(s.logger).Log("msg", "missing sort order information", "section", idx)
-			} else if colType != logs.ColumnTypeTimestamp || sortOrder != logs.SortDirectionDescending {
-				level.Warn(s.logger).Log("msg", "section is not sorted by timestamp in DESC order",
-					"dataobj", idx, "primaryColumnType", colType, "sortOrder", sortOrder)
-			}
+	// TODO(rfratto): There's a few problems with using LogsReader as it is:
+	//
+	// 1. LogsReader doesn't support providing a subset of columns to read
+	//    from, so we're applying projections after reading.
+	//
+	// 2. LogsReader is intended to be pooled to reduce memory, but we're
+	//    creating a new one every time here.
+	//
+	// For the sake of the initial implementation I'm ignoring these issues,
+	// but we'll absolutely need to solve this prior to production use.
+	lr := logs.NewRowReader(sec)
+
+	// The calls below can't fail because we're always using a brand new logs
+	// reader.
+	_ = lr.MatchStreams(slices.Values(s.opts.StreamIDs))
+	_ = lr.SetPredicates(s.opts.Predicates)
+	if err := lr.SetRowRange(s.opts.startRow, s.opts.endRow); err != nil {
+		return err
+	}
+
+	rowRanges := s.opts.rowRanges
+	if rowRanges == nil {
+		var err error
+		if rowRanges, err = findRowRanges(ctx, s.opts); err != nil {
+			return err
 		}
+	}
+	if err := lr.SetRowRanges(rowRanges); err != nil {
+		return err
+	}
 
-		// TODO(rfratto): There's a few problems with using LogsReader as it is:
-		//
-		// 1. LogsReader doesn't support providing a subset of columns to read
-		//    from, so we're applying projections after reading.
-		//
-		// 2. LogsReader is intended to be pooled to reduce memory, but we're
-		//    creating a new one every time here.
-		//
-		// For the sake of the initial implementation I'm ignoring these issues,
-		// but we'll absolutely need to solve this prior to production use.
-		lr := logs.NewRowReader(sec)
+	s.reader = lr
+	s.initialized = true
+	return nil
+}
 
-		// The calls below can't fail because we're always using a brand new logs
-		// reader.
-		_ = lr.MatchStreams(slices.Values(s.opts.StreamIDs))
-		_ = lr.SetPredicates(s.opts.Predicates)
+// openLogsSection opens the logs section with the given index of obj.
+func openLogsSection(ctx context.Context, obj *dataobj.Object, index int) (*logs.Section, error) {
+	for idx, section := range obj.Sections().Filter(logs.CheckSection) {
+		// Filter out sections that are not part of this shard
+		if index != idx {
+			continue
+		}
 
-		s.reader = lr
-		break
+		sec, err := logs.Open(ctx, section)
+		if err != nil {
+			return nil, fmt.Errorf("opening logs section: %w", err)
+		}
+		return sec, nil
 	}
+	return nil, fmt.Errorf("no logs section %d found", index)
+}
 
-	if s.reader == nil {
-		return fmt.Errorf("no logs section %d found", s.opts.Section)
+// findRowRanges returns the row ranges of the section of opts whose log lines
+// may contain all of opts.Substrings, according to the token index of
+// opts.Object. It returns nil if the index can't be used to skip rows.
+func findRowRanges(ctx context.Context, opts dataobjScanOptions) ([]logs.RowRange, error) {
+	if len(opts.Substrings) == 0 {
+		return nil, nil
 	}
 
-	s.initialized = true
-	return nil
+	ranges, err := tokens.RowRanges(ctx, opts.Object, opts.Section, opts.Substrings)
+	if err != nil {
+		return nil, fmt.Errorf("looking up token index: %w", err)
+	}
+	return ranges, nil
 }
 
 // initStreams retrieves all requested stream records from streams sections so
 // that emitted [arrow.Record]s can include stream labels in results.
 func (s *dataobjScan) initStreams(ctx context.Context) error {
+	if s.opts.streams != nil {
+		s.streams = s.opts.streams
+		return nil
+	}
+
+	var err error
+	s.streams, err = readStreams(ctx, s.opts.Object, s.opts.StreamIDs, s.opts.batchSize)
+	return err
+}
+
+// readStreams returns the labels of the streams with the given IDs from the
+// streams sections of obj.
+func readStreams(ctx context.Context, obj *dataobj.Object, ids []int64, batchSize int64) (map[int64]labels.Labels, error) {
 	var sr streams.RowReader
 	defer sr.Close()
 
-	streamsBuf := make([]streams.Stream, s.opts.batchSize)
+	streamsBuf := make([]streams.Stream, batchSize)
 
 	// Initialize entries in the map so we can do a presence test in the loop
 	// below.
-	s.streams = make(map[int64]labels.Labels, len(s.opts.StreamIDs))
-	for _, id := range s.opts.StreamIDs {
-		s.streams[id] = labels.EmptyLabels()
+	result := make(map[int64]labels.Labels, len(ids))
+	for _, id := range ids {
+		result[id] = labels.EmptyLabels()
 	}
 
-	for _, section := range s.opts.Object.Sections().Filter(streams.CheckSection) {
+	for _, section := range obj.Sections().Filter(streams.CheckSection) {
 		sec, err := streams.Open(ctx, section)
 		if err != nil {
-			return fmt.Errorf("opening streams section: %w", err)
+			return nil, fmt.Errorf("opening streams section: %w", err)
 		}
 
 		// TODO(rfratto): dataobj.StreamsPredicate is missing support for filtering
@@ -179,20 +266,20 @@ func (s *dataobjScan) initStreams(ctx context.Context) error {
 		for {
 			n, err := sr.Read(ctx, streamsBuf)
 			if n == 0 && errors.Is(err, io.EOF) {
-				return nil
+				return result, nil
 			} else if err != nil && !errors.Is(err, io.EOF) {
-				return err
+				return nil, err
 			}
 
 			for i, stream := range streamsBuf[:n] {
-				if _, found := s.streams[stream.ID]; !found {
+				if _, found := result[stream.ID]; !found {
 					continue
 				}
 
-				s.streams[stream.ID] = stream.Labels.Copy()
+				result[stream.ID] = stream.Labels.Copy()
 
 				// Zero out the stream entry from the slice so the next call to sr.Read
-				// doesn't overwrite any memory we just moved to s.streams.
+				// doesn't overwrite any memory we just moved to result.
 				streamsBuf[i] = streams.Stream{}
 			}
 		}
@@ -200,12 +287,12 @@ func (s *dataobjScan) initStreams(ctx context.Context) error {
 
 	// Check that all streams were populated.
 	var errs []error
-	for id, labels := range s.streams {
+	for id, labels := range result {
 		if labels.IsEmpty() {
 			errs = append(errs, fmt.Errorf("requested stream ID %d not found in any stream section", id))
 		}
 	}
-	return errors.Join(errs...)
+	return result, errors.Join(errs...)
 }
 
 // read reads the entire data object into memory and generates an arrow.Record
@@ -255,6 +342,64 @@ func (s *dataobjScan) read(ctx context.Context) (arrow.Record, error) {
 	return rb.NewRecord(), nil
 }
 
+// readAscending returns the next slice of the rows of the section in ascending
+// order of their timestamps. Upon the first call, readAscending reads the
+// entire section and sorts its rows using a [topkBatch], which retains at most
+// opts.Limit rows if a limit is set.
+func (s *dataobjScan) readAscending(ctx context.Context) (arrow.Record, error) {
+	if !s.sortedInitialized {
+		sorted, err := s.sortAscending(ctx)
+		if err != nil {
+			return nil, err
+		}
+		s.sorted = sorted
+		s.sortedInitialized = true
+	}
+
+	if s.sorted == nil || s.offset >= s.sorted.NumRows() {
+		return nil, EOF
+	}
+
+	end := min(s.offset+s.opts.batchSize, s.sorted.NumRows())
+	rec := s.sorted.NewSlice(s.offset, end)
+	s.offset = end
+	return rec, nil
+}
+
+// sortAscending reads all rows of the section and returns them in a single
+// record that is sorted by timestamp in ascending order. sortAscending returns
+// a nil record if the section has no matching rows.
+func (s *dataobjScan) sortAscending(ctx context.Context) (arrow.Record, error) {
+	alloc := memory.NewGoAllocator()
+
+	batch := &topkBatch{
+		Fields: []arrow.Field{{
+			Name:     types.ColumnNameBuiltinTimestamp,
+			Type:     arrow.FixedWidthTypes.Timestamp_ns,
+			Nullable: true,
+			Metadata: datatype.ColumnMetadataBuiltinTimestamp,
+		}},
+		Ascending: true,
+		K:         int(s.opts.Limit), // 0 retains all rows
+		MaxUnused: int(s.opts.batchSize),
+	}
+	defer batch.Reset()
+
+	for {
+		rec, err := s.read(ctx)
+		if errors.Is(err, EOF) {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+
+		batch.Put(alloc, rec)
+		rec.Release()
+	}
+
+	return batch.Compact(alloc), nil
+}
+
 // getLessFunc returns a "less comparison" function for records for the sort heap.
 // direction determines the search order:
 // BACKWARD is a backward search starting at the end of the time range.
@@ -550,11 +695,21 @@ func (s *dataobjScan) appendToBuilder(builder array.Builder, field *arrow.Field,
 // [dataobjScan.Read], or an error if the record cannot be read.
 func (s *dataobjScan) Value() (arrow.Record, error) { return s.state.batch, s.state.err }
 
+// pagesPruned returns the number of pages that the reader of s skipped so far
+// because of the predicates of the scan.
+func (s *dataobjScan) pagesPruned() int {
+	return int(s.pruned.Load())
+}
+
 // Close closes s and releases all resources.
 func (s *dataobjScan) Close() {
 	if s.reader != nil {
 		_ = s.reader.Close()
 	}
+	if s.sorted != nil {
+		s.sorted.Release()
+		s.sorted = nil
+	}
 }
 
 // Inputs implements Pipeline and returns nil, since DataObjScan accepts no
@@ -0,0 +1,142 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/apache/arrow-go/v18/arrow"
+	"github.com/go-kit/log"
+
+	"github.com/grafana/loki/v3/pkg/engine/internal/types"
+	"github.com/grafana/loki/v3/pkg/engine/planner/physical"
+)
+
+// minRowsPerScanRange is the minimum number of rows of the row ranges into
+// which a section is split. It avoids that small sections are split into
+// ranges whose reads are dominated by the overhead of opening a reader.
+const minRowsPerScanRange = 10_000
+
+// parallelScan is a pipeline that scans a logs section with multiple
+// [dataobjScan] pipelines, which each read a contiguous range of rows of the
+// section. The results of the ranges are merged with a [KWayMerge], which
+// reads its inputs concurrently.
+//
+// Logs sections are sorted by timestamp, so each range of rows is sorted as
+// well, and the merged results keep the sort order that is expected from a
+// single [dataobjScan].
+type parallelScan struct {
+	opts      dataobjScanOptions
+	ranges    int // maximum number of row ranges
+	evaluator expressionEvaluator
+	logger    log.Logger
+
+	scans  []*dataobjScan
+	merged Pipeline // pipeline that merges the scans once they are created
+}
+
+var _ Pipeline = (*parallelScan)(nil)
+
+// newParallelScanPipeline returns a pipeline that splits the section of opts
+// into at most ranges row ranges, which are scanned concurrently. Sections
+// are split into ranges of at least [minRowsPerScanRange] rows.
+func newParallelScanPipeline(opts dataobjScanOptions, ranges int, evaluator expressionEvaluator, logger log.Logger) *parallelScan {
+	return &parallelScan{
+		opts:      opts,
+		ranges:    ranges,
+		evaluator: evaluator,
+		logger:    logger,
+	}
+}
+
+// Read implements [Pipeline].
+func (p *parallelScan) Read(ctx context.Context) error {
+	if p.merged == nil {
+		if err := p.init(ctx); err != nil {
+			p.merged = errorPipeline(err)
+		}
+	}
+	return p.merged.Read(ctx)
+}
+
+func (p *parallelScan) init(ctx context.Context) error {
+	// The section and the streams are shared by the scans of all ranges, so
+	// that their metadata is only read once.
+	sec, err := openLogsSection(ctx, p.opts.Object, p.opts.Section)
+	if err != nil {
+		return err
+	}
+	streams, err := readStreams(ctx, p.opts.Object, p.opts.StreamIDs, p.opts.batchSize)
+	if err != nil {
+		return fmt.Errorf("initializing streams: %w", err)
+	}
+
+	// The token index is also only consulted once for all ranges. Substrings
+	// are cleared so that scans don't look up the index again when it can't
+	// be used to skip rows.
+	rowRanges, err := findRowRanges(ctx, p.opts)
+	if err != nil {
+		return err
+	}
+
+	rows := uint64(sec.RowCount())
+	n := uint64(max(1, min(p.ranges, int(rows/minRowsPerScanRange))))
+
+	p.scans = make([]*dataobjScan, 0, n)
+	inputs := make([]Pipeline, 0, n)
+	for i := range n {
+		opts := p.opts
+		opts.section = sec
+		opts.streams = streams
+		opts.Substrings, opts.rowRanges = nil, rowRanges
+		if n > 1 {
+			opts.startRow, opts.endRow = i*rows/n, (i+1)*rows/n
+		}
+
+		scan := newDataobjScanPipeline(opts, p.logger)
+		p.scans = append(p.scans, scan)
+		inputs = append(inputs, scan)
+	}
+
+	if len(inputs) == 1 {
+		p.merged = inputs[0]
+		return nil
+	}
+
+	timestamp := &physical.ColumnExpr{
+		Ref: types.ColumnRef{Column: types.ColumnNameBuiltinTimestamp, Type: types.ColumnTypeBuiltin},
+	}
+	p.merged, err = NewSortMergePipeline(inputs, p.opts.Direction, timestamp, p.evaluator)
+	return err
+}
+
+// Value implements [Pipeline].
+func (p *parallelScan) Value() (arrow.Record, error) {
+	if p.merged == nil {
+		return nil, nil
+	}
+	return p.merged.Value()
+}
+
+// pagesPruned returns the number of pages that the scans of all ranges
+// skipped so far because of the predicates of the scan.
+func (p *parallelScan) pagesPruned() int {
+	var pruned int
+	for _, scan := range p.scans {
+		pruned += scan.pagesPruned()
+	}
+	return pruned
+}
+
+// Close implements [Pipeline].
+func (p *parallelScan) Close() {
+	if p.merged != nil {
+		p.merged.Close()
+	}
+}
+
+// Inputs implements [Pipeline]. The scans of the ranges are not part of the
+// plan, so parallelScan has no inputs.
+func (p *parallelScan) Inputs() []Pipeline { return nil }
+
+// Transport implements [Pipeline].
+func (p *parallelScan) Transport() Transport { return Local }
@@ -0,0 +1,109 @@
+package executor
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/loki/v3/pkg/dataobj"
+	"github.com/grafana/loki/v3/pkg/dataobj/consumer/logsobj"
+	"github.com/grafana/loki/v3/pkg/engine/planner/physical"
+	"github.com/grafana/loki/v3/pkg/logproto"
+)
+
+func Test_parallelScan(t *testing.T) {
+	// A single section with enough rows to be split into 5 ranges.
+	builder, err := logsobj.NewBuilder(logsobj.BuilderConfig{
+		TargetPageSize:          8_000,
+		TargetObjectSize:        math.MaxInt,
+		TargetSectionSize:       math.MaxInt,
+		BufferSize:              math.MaxInt,
+		SectionStripeMergeLimit: 2,
+	})
+	require.NoError(t, err)
+
+	streams := []logproto.Stream{{Labels: `{app="a"}`}, {Labels: `{app="b"}`}}
+	for i := range 5 * minRowsPerScanRange {
+		streams[i%2].Entries = append(streams[i%2].Entries, logproto.Entry{Timestamp: time.Unix(int64(i), 0), Line: fmt.Sprintf("line %d", i)})
+	}
+	for _, stream := range streams {
+		require.NoError(t, builder.Append(stream))
+	}
+
+	var buf bytes.Buffer
+	_, err = builder.Flush(&buf)
+	require.NoError(t, err)
+	obj, err := dataobj.FromReaderAt(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	require.NoError(t, err)
+
+	for _, tt := range []struct {
+		name      string
+		direction physical.SortOrder
+		limit     uint32
+	}{
+		{name: "backward", direction: physical.DESC},
+		{name: "forward with limit", direction: physical.ASC, limit: 100},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := dataobjScanOptions{
+				Object:    obj,
+				StreamIDs: []int64{1, 2},
+				Section:   0,
+				Direction: tt.direction,
+				Limit:     tt.limit,
+				batchSize: 512,
+			}
+
+			scan := newDataobjScanPipeline(opts, log.NewNopLogger())
+			defer scan.Close()
+			expected := readLines(t, scan)
+			if tt.limit > 0 {
+				require.Len(t, expected, int(tt.limit))
+			} else {
+				require.Len(t, expected, 5*minRowsPerScanRange)
+			}
+
+			for _, ranges := range []int{3, 8} {
+				t.Run(fmt.Sprintf("ranges=%d", ranges), func(t *testing.T) {
+					// A single worker must not block the scans of the ranges.
+					opts := opts
+					opts.workers = make(chan struct{}, 1)
+
+					parallel := newParallelScanPipeline(opts, ranges, expressionEvaluator{}, log.NewNopLogger())
+					defer parallel.Close()
+
+					actual := readLines(t, parallel)
+					require.Len(t, parallel.scans, min(ranges, 5))
+					if tt.limit > 0 {
+						// Each range returns up to limit rows.
+						actual = actual[:tt.limit]
+					}
+					require.Equal(t, expected, actual)
+				})
+			}
+		})
+	}
+
+	t.Run("small section is not split", func(t *testing.T) {
+		obj := buildDataobj(t, []logproto.Stream{{
+			Labels:  `{app="a"}`,
+			Entries: []logproto.Entry{{Timestamp: time.Unix(1, 0), Line: "one"}, {Timestamp: time.Unix(2, 0), Line: "two"}},
+		}})
+
+		parallel := newParallelScanPipeline(dataobjScanOptions{
+			Object:    obj,
+			StreamIDs: []int64{1},
+			Direction: physical.DESC,
+			batchSize: 512,
+		}, 4, expressionEvaluator{}, log.NewNopLogger())
+		defer parallel.Close()
+
+		require.Equal(t, []string{"2 two", "1 one"}, readLines(t, parallel))
+		require.Len(t, parallel.scans, 1)
+	})
+}
@@ -56,6 +56,46 @@ func buildLogsPredicate(expr physical.Expression) (logs.RowPredicate, error) {
 	return mapInitiallySupportedPredicates(expr)
 }
 
+// lineFilterSubstrings returns the substrings that the log line of a row must
+// contain to pass all predicates: the literals of [types.BinaryOpMatchSubstr]
+// predicates on the log message, which are either predicates themselves or
+// operands of AND expressions. Other predicates are ignored, so the returned
+// substrings are only a necessary condition for a row to pass.
+//
+// The substrings can be used to skip rows with the token index of a data
+// object.
+func lineFilterSubstrings(predicates []physical.Expression) []string {
+	var substrings []string
+
+	var walk func(expr physical.Expression)
+	walk = func(expr physical.Expression) {
+		e, ok := expr.(*physical.BinaryExpr)
+		if !ok {
+			return
+		}
+
+		switch e.Op {
+		case types.BinaryOpAnd:
+			walk(e.Left)
+			walk(e.Right)
+
+		case types.BinaryOpMatchSubstr:
+			left, ok := e.Left.(*physical.ColumnExpr)
+			if !ok || left.Ref.Type != types.ColumnTypeBuiltin || left.Ref.Column != types.ColumnNameBuiltinMessage {
+				return
+			}
+			if val, err := rhsValue(e); err == nil {
+				substrings = append(substrings, val)
+			}
+		}
+	}
+	for _, p := range predicates {
+		walk(p)
+	}
+
+	return substrings
+}
+
 // Support for timestamp and metadata predicates has been implemented.
 // TODO(owen-d): this can go away when we use dataset.Reader & dataset.Predicate directly
 func mapInitiallySupportedPredicates(expr physical.Expression) (logs.RowPredicate, error) {
@@ -776,3 +776,29 @@ func TestMapMessagePredicate(t *testing.T) {
 		})
 	}
 }
+
+func TestLineFilterSubstrings(t *testing.T) {
+	message := &physical.ColumnExpr{Ref: types.ColumnRef{Column: types.ColumnNameBuiltinMessage, Type: types.ColumnTypeBuiltin}}
+	matchSubstr := func(val string) physical.Expression {
+		return &physical.BinaryExpr{Left: message, Right: physical.NewLiteral(val), Op: types.BinaryOpMatchSubstr}
+	}
+
+	predicates := []physical.Expression{
+		matchSubstr("foo"),
+		&physical.BinaryExpr{Left: matchSubstr("bar"), Right: matchSubstr("baz"), Op: types.BinaryOpAnd},
+
+		// Substrings which aren't required for all rows are ignored.
+		&physical.BinaryExpr{Left: matchSubstr("or1"), Right: matchSubstr("or2"), Op: types.BinaryOpOr},
+		&physical.UnaryExpr{Left: matchSubstr("not"), Op: types.UnaryOpNot},
+		&physical.BinaryExpr{Left: message, Right: physical.NewLiteral("negated"), Op: types.BinaryOpNotMatchSubstr},
+		&physical.BinaryExpr{Left: message, Right: physical.NewLiteral("re.*"), Op: types.BinaryOpMatchRe},
+		&physical.BinaryExpr{
+			Left:  &physical.ColumnExpr{Ref: types.ColumnRef{Column: "app", Type: types.ColumnTypeMetadata}},
+			Right: physical.NewLiteral("metadata"),
+			Op:    types.BinaryOpMatchSubstr,
+		},
+	}
+
+	require.Equal(t, []string{"foo", "bar", "baz"}, lineFilterSubstrings(predicates))
+	require.Empty(t, lineFilterSubstrings(nil))
+}
@@ -2,6 +2,7 @@ package executor
 
 import (
 	"bytes"
+	"fmt"
 	"math"
 	"testing"
 	"time"
@@ -12,6 +13,7 @@ import (
 
 	"github.com/grafana/loki/v3/pkg/dataobj"
 	"github.com/grafana/loki/v3/pkg/dataobj/consumer/logsobj"
+	"github.com/grafana/loki/v3/pkg/dataobj/sections/logs"
 	"github.com/grafana/loki/v3/pkg/engine/internal/datatype"
 	"github.com/grafana/loki/v3/pkg/engine/internal/types"
 	"github.com/grafana/loki/v3/pkg/engine/planner/physical"
@@ -122,6 +124,68 @@ prod,notloki,NULL,notloki-pod-1,1970-01-01 00:00:02,hello world`
 		AssertPipelinesEqual(t, pipeline, NewBufferedPipeline(expectRecord))
 	})
 
+	t.Run("Ascending order", func(t *testing.T) {
+		pipeline := newDataobjScanPipeline(dataobjScanOptions{
+			Object:      obj,
+			StreamIDs:   []int64{1, 2}, // All streams
+			Section:     0,             // First section.
+			Projections: nil,           // All columns
+			Direction:   physical.ASC,
+			Limit:       0, // No limit
+			batchSize:   512,
+		}, log.NewNopLogger())
+
+		expectFields := []arrow.Field{
+			{Name: "env", Type: arrow.BinaryTypes.String, Metadata: labelMD, Nullable: true},
+			{Name: "service", Type: arrow.BinaryTypes.String, Metadata: labelMD, Nullable: true},
+			{Name: "guid", Type: arrow.BinaryTypes.String, Metadata: metadataMD, Nullable: true},
+			{Name: "pod", Type: arrow.BinaryTypes.String, Metadata: metadataMD, Nullable: true},
+			{Name: "timestamp", Type: arrow.FixedWidthTypes.Timestamp_ns, Metadata: datatype.ColumnMetadataBuiltinTimestamp, Nullable: true},
+			{Name: "message", Type: arrow.BinaryTypes.String, Metadata: datatype.ColumnMetadataBuiltinMessage, Nullable: true},
+		}
+
+		expectCSV := `prod,notloki,NULL,notloki-pod-1,1970-01-01 00:00:02,hello world
+prod,notloki,NULL,notloki-pod-1,1970-01-01 00:00:03,goodbye world
+prod,loki,aaaa-bbbb-cccc-dddd,NULL,1970-01-01 00:00:05,hello world
+prod,loki,eeee-ffff-aaaa-bbbb,NULL,1970-01-01 00:00:10,goodbye world`
+
+		expectRecord, err := CSVToArrow(expectFields, expectCSV)
+		require.NoError(t, err)
+		defer expectRecord.Release()
+
+		AssertPipelinesEqual(t, pipeline, NewBufferedPipeline(expectRecord))
+	})
+
+	t.Run("Ascending order with limit and small batches", func(t *testing.T) {
+		pipeline := newDataobjScanPipeline(dataobjScanOptions{
+			Object:    obj,
+			StreamIDs: []int64{1, 2}, // All streams
+			Section:   0,             // First section.
+			Projections: []physical.ColumnExpression{
+				&physical.ColumnExpr{Ref: types.ColumnRef{Column: "timestamp", Type: types.ColumnTypeBuiltin}},
+				&physical.ColumnExpr{Ref: types.ColumnRef{Column: "service", Type: types.ColumnTypeLabel}},
+			},
+			Direction: physical.ASC,
+			Limit:     3,
+			batchSize: 1,
+		}, log.NewNopLogger())
+
+		expectFields := []arrow.Field{
+			{Name: "timestamp", Type: arrow.FixedWidthTypes.Timestamp_ns, Metadata: datatype.ColumnMetadataBuiltinTimestamp, Nullable: true},
+			{Name: "service", Type: arrow.BinaryTypes.String, Metadata: labelMD, Nullable: true},
+		}
+
+		expectCSV := `1970-01-01 00:00:02,notloki
+1970-01-01 00:00:03,notloki
+1970-01-01 00:00:05,loki`
+
+		expectRecord, err := CSVToArrow(expectFields, expectCSV)
+		require.NoError(t, err)
+		defer expectRecord.Release()
+
+		AssertPipelinesEqual(t, pipeline, NewBufferedPipeline(expectRecord))
+	})
+
 	t.Run("Unknown column", func(t *testing.T) {
 		// Here, we'll check for a column which only exists once in the dataobj but is
 		// ambiguous from the perspective of the caller.
@@ -284,9 +348,91 @@ NULL,NULL`
 	})
 }
 
+func Test_dataobjScan_TokenIndex(t *testing.T) {
+	builder, err := logsobj.NewBuilder(logsobj.BuilderConfig{
+		TargetPageSize:          8_000,
+		TargetObjectSize:        math.MaxInt,
+		TargetSectionSize:       math.MaxInt,
+		BufferSize:              math.MaxInt,
+		SectionStripeMergeLimit: 2,
+		TokenIndexEnabled:       true,
+	})
+	require.NoError(t, err)
+
+	// Rows are sorted by descending timestamp, so the needle at timestamp 1500
+	// is row 1500 of the section.
+	stream := logproto.Stream{Labels: `{app="a"}`}
+	for ts := 1; ts <= 3000; ts++ {
+		line := fmt.Sprintf("request handled %d", ts)
+		if ts == 1500 {
+			line = "needle found"
+		}
+		stream.Entries = append(stream.Entries, logproto.Entry{Timestamp: time.Unix(int64(ts), 0), Line: line})
+	}
+	require.NoError(t, builder.Append(stream))
+
+	var buf bytes.Buffer
+	_, err = builder.Flush(&buf)
+	require.NoError(t, err)
+	obj, err := dataobj.FromReaderAt(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	require.NoError(t, err)
+
+	opts := dataobjScanOptions{
+		Object:     obj,
+		StreamIDs:  []int64{1},
+		Section:    0,
+		Substrings: []string{"needle"},
+		Direction:  physical.DESC,
+		batchSize:  512,
+	}
+
+	t.Run("rows are pruned", func(t *testing.T) {
+		// Without a predicate, the scan returns all rows that the token index
+		// can't rule out: the block of rows containing the needle.
+		scan := newDataobjScanPipeline(opts, log.NewNopLogger())
+		defer scan.Close()
+		require.Len(t, readLines(t, scan), 1024)
+	})
+
+	t.Run("with predicate", func(t *testing.T) {
+		opts := opts
+		opts.Predicates = []logs.RowPredicate{logs.LogMessageFilterRowPredicate{
+			Keep: func(line []byte) bool { return bytes.Contains(line, []byte("needle")) },
+		}}
+
+		scan := newDataobjScanPipeline(opts, log.NewNopLogger())
+		defer scan.Close()
+		require.Equal(t, []string{"1500 needle found"}, readLines(t, scan))
+
+		parallel := newParallelScanPipeline(opts, 3, expressionEvaluator{}, log.NewNopLogger())
+		defer parallel.Close()
+		require.Equal(t, []string{"1500 needle found"}, readLines(t, parallel))
+	})
+
+	t.Run("missing token", func(t *testing.T) {
+		opts := opts
+		opts.Substrings = []string{"haystack"}
+
+		scan := newDataobjScanPipeline(opts, log.NewNopLogger())
+		defer scan.Close()
+		require.Empty(t, readLines(t, scan))
+	})
+}
+
 func buildDataobj(t testing.TB, streams []logproto.Stream) *dataobj.Object {
 	t.Helper()
 
+	r := bytes.NewReader(encodeDataobj(t, streams))
+
+	obj, err := dataobj.FromReaderAt(r, r.Size())
+	require.NoError(t, err)
+	return obj
+}
+
+// encodeDataobj returns the encoded data object with the given streams.
+func encodeDataobj(t testing.TB, streams []logproto.Stream) []byte {
+	t.Helper()
+
 	builder, err := logsobj.NewBuilder(logsobj.BuilderConfig{
 		TargetPageSize:          8_000,
 		TargetObjectSize:        math.MaxInt,
@@ -303,10 +449,5 @@ func buildDataobj(t testing.TB, streams []logproto.Stream) *dataobj.Object {
 	var buf bytes.Buffer
 	_, err = builder.Flush(&buf)
 	require.NoError(t, err)
-
-	r := bytes.NewReader(buf.Bytes())
-
-	obj, err := dataobj.FromReaderAt(r, r.Size())
-	require.NoError(t, err)
-	return obj
+	return buf.Bytes()
 }
@@ -0,0 +1,232 @@
+package executor
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/apache/arrow-go/v18/arrow"
+	"github.com/apache/arrow-go/v18/arrow/ipc"
+	"github.com/apache/arrow-go/v18/arrow/memory"
+
+	"github.com/grafana/loki/v3/pkg/engine/planner/physical"
+)
+
+// FragmentRunner executes the fragments of a distributed plan, which are
+// referenced by [physical.Exchange] nodes, for example by sending them to
+// other queriers.
+type FragmentRunner interface {
+	// RunFragment executes the fragment and returns its results, encoded
+	// with [WriteFragmentResults]. The caller must close the returned reader.
+	RunFragment(ctx context.Context, fragment *physical.Plan) (io.ReadCloser, error)
+}
+
+func (c *Context) executeExchange(ctx context.Context, exchange *physical.Exchange) Pipeline {
+	if exchange.Fragment == nil {
+		return errorPipeline(errors.New("exchange without fragment"))
+	}
+
+	if c.fragments != nil {
+		return newExchangePipeline(ctx, c.fragments, exchange.Fragment)
+	}
+
+	// Without runner, the fragment is executed as part of the plan.
+	root, err := exchange.Fragment.Root()
+	if err != nil {
+		return errorPipeline(err)
+	}
+	fragmentCtx := *c
+	fragmentCtx.plan = exchange.Fragment
+	return fragmentCtx.execute(ctx, root)
+}
+
+// exchangePipeline is a pipeline that reads the results of a fragment that is
+// executed by a [FragmentRunner]. The fragment is started immediately when
+// the pipeline is created, so that all fragments of a plan are executed
+// concurrently, regardless of the order in which their results are read.
+type exchangePipeline struct {
+	cancel  context.CancelFunc
+	results chan exchangeResult
+	stream  Pipeline // pipeline that decodes the results once they are available
+	state   state
+}
+
+type exchangeResult struct {
+	r   io.ReadCloser
+	err error
+}
+
+var _ Pipeline = (*exchangePipeline)(nil)
+
+func newExchangePipeline(ctx context.Context, runner FragmentRunner, fragment *physical.Plan) *exchangePipeline {
+	ctx, cancel := context.WithCancel(ctx)
+	p := &exchangePipeline{
+		cancel:  cancel,
+		results: make(chan exchangeResult, 1),
+	}
+	go func() {
+		r, err := runner.RunFragment(ctx, fragment)
+		p.results <- exchangeResult{r: r, err: err}
+	}()
+	return p
+}
+
+// Read implements [Pipeline].
+func (p *exchangePipeline) Read(ctx context.Context) error {
+	if p.stream == nil {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case res := <-p.results:
+			if res.err != nil {
+				p.stream = errorPipeline(fmt.Errorf("execute fragment: %w", res.err))
+			} else {
+				p.stream = ReadFragmentResults(res.r)
+			}
+		}
+	}
+
+	if err := p.stream.Read(ctx); err != nil {
+		p.state = failureState(err)
+		return err
+	}
+	p.state = newState(p.stream.Value())
+	return p.state.err
+}
+
+// Value implements [Pipeline].
+func (p *exchangePipeline) Value() (arrow.Record, error) {
+	return p.state.Value()
+}
+
+// Close implements [Pipeline].
+func (p *exchangePipeline) Close() {
+	p.cancel()
+	if p.stream == nil {
+		// wait for the runner to release its resources
+		if res := <-p.results; res.r != nil {
+			_ = res.r.Close()
+		}
+		p.stream = emptyPipeline()
+		return
+	}
+	p.stream.Close()
+}
+
+// Inputs implements [Pipeline].
+func (p *exchangePipeline) Inputs() []Pipeline {
+	return nil
+}
+
+// Transport implements [Pipeline].
+func (p *exchangePipeline) Transport() Transport {
+	return Remote
+}
+
+// WriteFragmentResults reads all records of the pipeline and writes them to
+// w, so that they can be read with [ReadFragmentResults].
+//
+// The records of a pipeline may have different schemas, while an Arrow IPC
+// stream has a single schema. Each record is therefore written as a separate
+// IPC stream, prefixed by its length.
+func WriteFragmentResults(ctx context.Context, w io.Writer, pipeline Pipeline) error {
+	var (
+		buf    bytes.Buffer
+		length [8]byte
+	)
+	for {
+		if err := pipeline.Read(ctx); err != nil {
+			if errors.Is(err, EOF) {
+				return nil
+			}
+			return err
+		}
+		record, err := pipeline.Value()
+		if err != nil {
+			return err
+		}
+
+		buf.Reset()
+		err = writeIPCStream(&buf, record)
+		record.Release()
+		if err != nil {
+			return err
+		}
+
+		binary.BigEndian.PutUint64(length[:], uint64(buf.Len()))
+		if _, err := w.Write(length[:]); err != nil {
+			return fmt.Errorf("write fragment results: %w", err)
+		}
+		if _, err := buf.WriteTo(w); err != nil {
+			return fmt.Errorf("write fragment results: %w", err)
+		}
+	}
+}
+
+func writeIPCStream(w io.Writer, record arrow.Record) error {
+	writer := ipc.NewWriter(w, ipc.WithSchema(record.Schema()), ipc.WithAllocator(memory.DefaultAllocator))
+	if err := writer.Write(record); err != nil {
+		return fmt.Errorf("encode record: %w", err)
+	}
+	if err := writer.Close(); err != nil {
+		return fmt.Errorf("encode record: %w", err)
+	}
+	return nil
+}
+
+// ReadFragmentResults returns a pipeline that reads the records written by
+// [WriteFragmentResults] from r. The pipeline closes r when it is closed.
+func ReadFragmentResults(r io.ReadCloser) Pipeline {
+	br := bufio.NewReader(r)
+	var length [8]byte
+	read := func(_ context.Context, _ []Pipeline) state {
+		if _, err := io.ReadFull(br, length[:]); err != nil {
+			if errors.Is(err, io.EOF) {
+				return Exhausted
+			}
+			return failureState(fmt.Errorf("read fragment results: %w", err))
+		}
+
+		frame := make([]byte, binary.BigEndian.Uint64(length[:]))
+		if _, err := io.ReadFull(br, frame); err != nil {
+			return failureState(fmt.Errorf("read fragment results: %w", err))
+		}
+
+		reader, err := ipc.NewReader(bytes.NewReader(frame), ipc.WithAllocator(memory.DefaultAllocator))
+		if err != nil {
+			return failureState(fmt.Errorf("decode record: %w", err))
+		}
+		defer reader.Release()
+
+		if !reader.Next() {
+			if err := reader.Err(); err != nil {
+				return failureState(fmt.Errorf("decode record: %w", err))
+			}
+			return failureState(errors.New("decode record: empty stream"))
+		}
+		record := reader.Record()
+		record.Retain()
+		return successState(record)
+	}
+	return &closingPipeline{
+		Pipeline: newGenericPipeline(Remote, read),
+		closer:   r,
+	}
+}
+
+// closingPipeline is a pipeline that closes an additional resource when it
+// is closed.
+type closingPipeline struct {
+	Pipeline
+	closer io.Closer
+}
+
+// Close implements [Pipeline].
+func (p *closingPipeline) Close() {
+	p.Pipeline.Close()
+	_ = p.closer.Close()
+}
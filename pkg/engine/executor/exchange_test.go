@@ -0,0 +1,187 @@
+package executor
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/apache/arrow-go/v18/arrow"
+	"github.com/apache/arrow-go/v18/arrow/array"
+	"github.com/go-kit/log"
+	"github.com/stretchr/testify/require"
+	"github.com/thanos-io/objstore"
+
+	"github.com/grafana/loki/v3/pkg/engine/internal/types"
+	"github.com/grafana/loki/v3/pkg/engine/planner/logical"
+	"github.com/grafana/loki/v3/pkg/engine/planner/physical"
+	"github.com/grafana/loki/v3/pkg/logproto"
+	"github.com/grafana/loki/v3/pkg/logql"
+)
+
+// exchangeTestCatalog resolves every query to all of its objects, which each
+// have two streams in a single section.
+type exchangeTestCatalog struct {
+	objects []physical.DataObjLocation
+}
+
+func (c *exchangeTestCatalog) ResolveDataObj(e physical.Expression, from, through time.Time) ([]physical.DataObjLocation, [][]int64, [][]int, error) {
+	return c.ResolveDataObjWithShard(e, nil, physical.ShardInfo{Shard: 0, Of: 1}, from, through)
+}
+
+func (c *exchangeTestCatalog) ResolveDataObjWithShard(_ physical.Expression, _ []physical.Expression, _ physical.ShardInfo, _, _ time.Time) ([]physical.DataObjLocation, [][]int64, [][]int, error) {
+	streams := make([][]int64, len(c.objects))
+	sections := make([][]int, len(c.objects))
+	for i := range c.objects {
+		streams[i] = []int64{1, 2}
+		sections[i] = []int{0}
+	}
+	return c.objects, streams, sections, nil
+}
+
+// encodingRunner is a [FragmentRunner] that encodes and decodes fragments and
+// their results, like they are when they are executed remotely.
+type encodingRunner struct {
+	bucket objstore.Bucket
+	calls  atomic.Int64
+}
+
+func (r *encodingRunner) RunFragment(ctx context.Context, fragment *physical.Plan) (io.ReadCloser, error) {
+	r.calls.Add(1)
+
+	data, err := physical.MarshalPlan(fragment)
+	if err != nil {
+		return nil, err
+	}
+	plan, err := physical.UnmarshalPlan(data)
+	if err != nil {
+		return nil, err
+	}
+
+	pipeline := Run(ctx, Config{BatchSize: 7, Bucket: r.bucket}, plan, log.NewNopLogger())
+	defer pipeline.Close()
+
+	var buf bytes.Buffer
+	if err := WriteFragmentResults(ctx, &buf, pipeline); err != nil {
+		return nil, err
+	}
+	return io.NopCloser(&buf), nil
+}
+
+type failingRunner struct{}
+
+func (failingRunner) RunFragment(context.Context, *physical.Plan) (io.ReadCloser, error) {
+	return nil, errors.New("querier is gone")
+}
+
+// readLines reads all records of the pipeline and returns the timestamps and
+// messages of their rows.
+func readLines(t *testing.T, pipeline Pipeline) []string {
+	t.Helper()
+
+	var lines []string
+	for {
+		err := pipeline.Read(t.Context())
+		if errors.Is(err, EOF) {
+			return lines
+		}
+		require.NoError(t, err)
+
+		record, _ := pipeline.Value()
+		ts := record.Column(record.Schema().FieldIndices(types.ColumnNameBuiltinTimestamp)[0]).(*array.Timestamp)
+		msg := record.Column(record.Schema().FieldIndices(types.ColumnNameBuiltinMessage)[0]).(*array.String)
+		for i := range int(record.NumRows()) {
+			lines = append(lines, fmt.Sprintf("%d %s", ts.Value(i).ToTime(arrow.Nanosecond).Unix(), msg.Value(i)))
+		}
+	}
+}
+
+func TestExchange(t *testing.T) {
+	ctx := t.Context()
+	bucket := objstore.NewInMemBucket()
+	start := time.Unix(1000, 0).UTC()
+
+	catalog := &exchangeTestCatalog{}
+	for obj := range 5 {
+		streams := []logproto.Stream{{Labels: `{app="a"}`}, {Labels: `{app="b"}`}}
+		for i := range 20 {
+			ts := start.Add(time.Duration(obj*20+i) * 7 * time.Second)
+			streams[i%2].Entries = append(streams[i%2].Entries, logproto.Entry{Timestamp: ts, Line: fmt.Sprintf("line %d-%d", obj, i)})
+		}
+		location := physical.DataObjLocation(fmt.Sprintf("objects/%d", obj))
+		require.NoError(t, bucket.Upload(ctx, string(location), bytes.NewReader(encodeDataobj(t, streams))))
+		catalog.objects = append(catalog.objects, location)
+	}
+
+	buildPlan := func(t *testing.T, query string, direction logproto.Direction, fragments int) *physical.Plan {
+		params, err := logql.NewLiteralParams(query, start, start.Add(15*time.Minute), time.Minute, 0, direction, 30, nil, nil)
+		require.NoError(t, err)
+
+		logicalPlan, err := logical.BuildPlan(params)
+		require.NoError(t, err)
+
+		planner := physical.NewPlanner(physical.NewContext(params.Start(), params.End()), catalog)
+		plan, err := planner.Build(logicalPlan)
+		require.NoError(t, err)
+		plan, err = planner.Optimize(plan)
+		require.NoError(t, err)
+
+		plan, err = physical.Fragment(plan, fragments)
+		require.NoError(t, err)
+		return plan
+	}
+
+	t.Run("metric query", func(t *testing.T) {
+		query := `sum by (app) (count_over_time({app=~"a|b"}[2m]))`
+
+		run := func(t *testing.T, fragments int, runner FragmentRunner) map[string]float64 {
+			pipeline := Run(ctx, Config{BatchSize: 7, Bucket: bucket, Fragments: runner}, buildPlan(t, query, logproto.FORWARD, fragments), log.NewNopLogger())
+			defer pipeline.Close()
+			return readSamples(t, pipeline)
+		}
+
+		expected := run(t, 1, nil)
+		require.NotEmpty(t, expected)
+
+		requireSamplesInDelta(t, expected, run(t, 2, nil))
+
+		runner := &encodingRunner{bucket: bucket}
+		requireSamplesInDelta(t, expected, run(t, 2, runner))
+		require.Equal(t, int64(2), runner.calls.Load())
+	})
+
+	t.Run("log query", func(t *testing.T) {
+		query := `{app=~"a|b"}`
+
+		for _, direction := range []logproto.Direction{logproto.FORWARD, logproto.BACKWARD} {
+			t.Run(direction.String(), func(t *testing.T) {
+				run := func(t *testing.T, fragments int, runner FragmentRunner) []string {
+					pipeline := Run(ctx, Config{BatchSize: 7, Bucket: bucket, Fragments: runner}, buildPlan(t, query, direction, fragments), log.NewNopLogger())
+					defer pipeline.Close()
+					return readLines(t, pipeline)
+				}
+
+				expected := run(t, 1, nil)
+				require.Len(t, expected, 30)
+
+				require.Equal(t, expected, run(t, 3, nil))
+
+				runner := &encodingRunner{bucket: bucket}
+				require.Equal(t, expected, run(t, 3, runner))
+				require.Equal(t, int64(3), runner.calls.Load())
+			})
+		}
+	})
+
+	t.Run("failing fragment", func(t *testing.T) {
+		pipeline := Run(ctx, Config{BatchSize: 7, Bucket: bucket, Fragments: failingRunner{}}, buildPlan(t, `{app="a"}`, logproto.FORWARD, 2), log.NewNopLogger())
+		defer pipeline.Close()
+
+		err := pipeline.Read(ctx)
+		require.ErrorContains(t, err, "execute fragment: querier is gone")
+	})
+}
@@ -16,6 +16,32 @@ import (
 type Config struct {
 	BatchSize int64
 	Bucket    objstore.Bucket
+
+	// ScanWorkers is the maximum number of [physical.DataObjScan] nodes of
+	// the plan that read concurrently. If it is greater than 1, large
+	// sections are split into row ranges that are scanned concurrently. 0
+	// means unlimited without splitting sections.
+	ScanWorkers int
+
+	// Analysis collects the runtime statistics of each node of the plan if
+	// it is non-nil.
+	Analysis *Analysis
+
+	// AggregationMemoryLimit is the maximum number of bytes the aggregations
+	// of the plan keep in memory for their state. Above the limit, the state
+	// is spilled to files in SpillPath. 0 disables spilling.
+	AggregationMemoryLimit int64
+	// AggregationSpillLimit is the maximum number of bytes the aggregations
+	// of the plan may spill to disk. 0 means unlimited.
+	AggregationSpillLimit int64
+	// SpillPath is the directory of the spill files. The default directory
+	// for temporary files is used if it is empty.
+	SpillPath string
+
+	// Fragments executes the fragments of the [physical.Exchange] nodes of
+	// the plan. Fragments are executed locally as part of the plan if it is
+	// nil.
+	Fragments FragmentRunner
 }
 
 func Run(ctx context.Context, cfg Config, plan *physical.Plan, logger log.Logger) Pipeline {
@@ -23,8 +49,14 @@ func Run(ctx context.Context, cfg Config, plan *physical.Plan, logger log.Logger
 		plan:      plan,
 		batchSize: cfg.BatchSize,
 		bucket:    cfg.Bucket,
+		analysis:  cfg.Analysis,
+		budget:    newMemoryBudget(cfg.AggregationMemoryLimit, cfg.AggregationSpillLimit, cfg.SpillPath),
+		fragments: cfg.Fragments,
 		logger:    logger,
 	}
+	if cfg.ScanWorkers > 0 {
+		c.scanWorkers = make(chan struct{}, cfg.ScanWorkers)
+	}
 	if plan == nil {
 		return errorPipeline(errors.New("plan is nil"))
 	}
@@ -42,6 +74,13 @@ type Context struct {
 	plan      *physical.Plan
 	evaluator expressionEvaluator
 	bucket    objstore.Bucket
+	analysis  *Analysis
+	budget    *memoryBudget
+	fragments FragmentRunner
+
+	// scanWorkers limits the number of concurrent reads of data object
+	// scans. Reads are unlimited if it is nil.
+	scanWorkers chan struct{}
 }
 
 func (c *Context) execute(ctx context.Context, node physical.Node) Pipeline {
@@ -51,6 +90,14 @@ func (c *Context) execute(ctx context.Context, node physical.Node) Pipeline {
 		inputs = append(inputs, c.execute(ctx, child))
 	}
 
+	pipeline := c.executeNode(ctx, node, inputs)
+	if c.analysis != nil {
+		return c.analysis.observe(node, pipeline)
+	}
+	return pipeline
+}
+
+func (c *Context) executeNode(ctx context.Context, node physical.Node, inputs []Pipeline) Pipeline {
 	switch n := node.(type) {
 	case *physical.DataObjScan:
 		return c.executeDataObjScan(ctx, n)
@@ -60,12 +107,30 @@ func (c *Context) execute(ctx context.Context, node physical.Node) Pipeline {
 		return c.executeLimit(ctx, n, inputs)
 	case *physical.Filter:
 		return c.executeFilter(ctx, n, inputs)
+	case *physical.Parse:
+		return c.executeParse(ctx, n, inputs)
+	case *physical.LineFormat:
+		return c.executeLineFormat(ctx, n, inputs)
+	case *physical.LabelFormat:
+		return c.executeLabelFormat(ctx, n, inputs)
+	case *physical.LabelProjection:
+		return c.executeLabelProjection(ctx, n, inputs)
 	case *physical.Projection:
 		return c.executeProjection(ctx, n, inputs)
 	case *physical.RangeAggregation:
 		return c.executeRangeAggregation(ctx, n, inputs)
 	case *physical.VectorAggregation:
 		return c.executeVectorAggregation(ctx, n, inputs)
+	case *physical.Join:
+		return c.executeJoin(ctx, n, inputs)
+	case *physical.LabelReplace:
+		return c.executeLabelReplace(ctx, n, inputs)
+	case *physical.Vector:
+		return c.executeVector(ctx, n)
+	case *physical.Absent:
+		return c.executeAbsent(ctx, n, inputs)
+	case *physical.Exchange:
+		return c.executeExchange(ctx, n)
 	default:
 		return errorPipeline(fmt.Errorf("invalid node type: %T", node))
 	}
@@ -86,23 +151,36 @@ func (c *Context) executeDataObjScan(ctx context.Context, node *physical.DataObj
 		predicates = append(predicates, conv)
 	}
 
-	obj, err := dataobj.FromBucket(ctx, c.bucket, string(node.Location))
+	var bucket objstore.BucketReader = c.bucket
+	if c.analysis != nil {
+		bucket = c.analysis.bucket(node, bucket)
+	}
+
+	obj, err := dataobj.FromBucket(ctx, bucket, string(node.Location))
 	if err != nil {
 		return errorPipeline(fmt.Errorf("creating data object: %w", err))
 	}
 
-	return newDataobjScanPipeline(dataobjScanOptions{
+	opts := dataobjScanOptions{
 		Object:      obj,
 		StreamIDs:   node.StreamIDs,
 		Section:     node.Section,
 		Predicates:  predicates,
+		Substrings:  lineFilterSubstrings(node.Predicates),
 		Projections: node.Projections,
 
 		Direction: node.Direction,
 		Limit:     node.Limit,
 
 		batchSize: c.batchSize,
-	}, log.With(c.logger, "location", string(node.Location)))
+		workers:   c.scanWorkers,
+	}
+	logger := log.With(c.logger, "location", string(node.Location))
+
+	if cap(c.scanWorkers) > 1 {
+		return newParallelScanPipeline(opts, cap(c.scanWorkers), c.evaluator, logger)
+	}
+	return newDataobjScanPipeline(opts, logger)
 }
 
 func (c *Context) executeSortMerge(_ context.Context, sortmerge *physical.SortMerge, inputs []Pipeline) Pipeline {
@@ -142,6 +220,66 @@ func (c *Context) executeFilter(_ context.Context, filter *physical.Filter, inpu
 	return NewFilterPipeline(filter, inputs[0], c.evaluator)
 }
 
+func (c *Context) executeParse(_ context.Context, parse *physical.Parse, inputs []Pipeline) Pipeline {
+	if len(inputs) == 0 {
+		return emptyPipeline()
+	}
+
+	if len(inputs) > 1 {
+		return errorPipeline(fmt.Errorf("parse expects exactly one input, got %d", len(inputs)))
+	}
+
+	pipeline, err := NewParsePipeline(parse, inputs[0])
+	if err != nil {
+		return errorPipeline(err)
+	}
+	return pipeline
+}
+
+func (c *Context) executeLineFormat(_ context.Context, format *physical.LineFormat, inputs []Pipeline) Pipeline {
+	if len(inputs) == 0 {
+		return emptyPipeline()
+	}
+
+	if len(inputs) > 1 {
+		return errorPipeline(fmt.Errorf("line format expects exactly one input, got %d", len(inputs)))
+	}
+
+	pipeline, err := NewLineFormatPipeline(format, inputs[0])
+	if err != nil {
+		return errorPipeline(err)
+	}
+	return pipeline
+}
+
+func (c *Context) executeLabelFormat(_ context.Context, format *physical.LabelFormat, inputs []Pipeline) Pipeline {
+	if len(inputs) == 0 {
+		return emptyPipeline()
+	}
+
+	if len(inputs) > 1 {
+		return errorPipeline(fmt.Errorf("label format expects exactly one input, got %d", len(inputs)))
+	}
+
+	pipeline, err := NewLabelFormatPipeline(format, inputs[0])
+	if err != nil {
+		return errorPipeline(err)
+	}
+	return pipeline
+}
+
+func (c *Context) executeLabelProjection(_ context.Context, projection *physical.LabelProjection, inputs []Pipeline) Pipeline {
+	if len(inputs) == 0 {
+		return emptyPipeline()
+	}
+
+	if len(inputs) > 1 {
+		return errorPipeline(fmt.Errorf("label projection expects exactly one input, got %d", len(inputs)))
+	}
+
+	return NewLabelProjectionPipeline(projection, inputs[0])
+}
+
 func (c *Context) executeProjection(_ context.Context, proj *physical.Projection, inputs []Pipeline) Pipeline {
 	if len(inputs) == 0 {
 		return emptyPipeline()
@@ -170,10 +308,15 @@ func (c *Context) executeRangeAggregation(_ context.Context, plan *physical.Rang
 
 	pipeline, err := NewRangeAggregationPipeline(inputs, c.evaluator, rangeAggregationOptions{
 		partitionBy:   plan.PartitionBy,
+		operation:     plan.Operation,
+		parameter:     plan.Parameter,
+		unwrap:        plan.Unwrap,
 		startTs:       plan.Start,
 		endTs:         plan.End,
 		rangeInterval: plan.Range,
 		step:          plan.Step,
+		offset:        plan.Offset,
+		budget:        c.budget,
 	})
 	if err != nil {
 		return errorPipeline(err)
@@ -187,10 +330,55 @@ func (c *Context) executeVectorAggregation(_ context.Context, plan *physical.Vec
 		return emptyPipeline()
 	}
 
-	pipeline, err := NewVectorAggregationPipeline(inputs, plan.GroupBy, c.evaluator)
+	pipeline, err := NewVectorAggregationPipeline(inputs, c.evaluator, vectorAggregationOptions{
+		operation: plan.Operation,
+		groupBy:   plan.GroupBy,
+		without:   plan.Without,
+		parameter: plan.Parameter,
+		budget:    c.budget,
+	})
+	if err != nil {
+		return errorPipeline(err)
+	}
+
+	return pipeline
+}
+
+func (c *Context) executeJoin(_ context.Context, join *physical.Join, inputs []Pipeline) Pipeline {
+	if len(inputs) == 0 {
+		return emptyPipeline()
+	}
+
+	pipeline, err := NewJoinPipeline(join, inputs, c.evaluator)
 	if err != nil {
 		return errorPipeline(err)
 	}
+	return pipeline
+}
+
+func (c *Context) executeLabelReplace(_ context.Context, replace *physical.LabelReplace, inputs []Pipeline) Pipeline {
+	if len(inputs) == 0 {
+		return emptyPipeline()
+	}
+
+	if len(inputs) > 1 {
+		return errorPipeline(fmt.Errorf("label replace expects exactly one input, got %d", len(inputs)))
+	}
 
+	pipeline, err := NewLabelReplacePipeline(replace, inputs[0])
+	if err != nil {
+		return errorPipeline(err)
+	}
 	return pipeline
 }
+
+func (c *Context) executeVector(_ context.Context, vector *physical.Vector) Pipeline {
+	return NewVectorPipeline(vector)
+}
+
+// executeAbsent returns the pipeline of an absent node. Unlike other nodes,
+// an absent node without inputs produces results, since all values are
+// missing.
+func (c *Context) executeAbsent(_ context.Context, absent *physical.Absent, inputs []Pipeline) Pipeline {
+	return NewAbsentPipeline(absent, inputs, c.evaluator)
+}
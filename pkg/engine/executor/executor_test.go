@@ -6,6 +6,7 @@ import (
 	"github.com/go-kit/log"
 	"github.com/stretchr/testify/require"
 
+	"github.com/grafana/loki/v3/pkg/engine/internal/types"
 	"github.com/grafana/loki/v3/pkg/engine/planner/physical"
 )
 
@@ -97,3 +98,22 @@ func TestExecutor_Projection(t *testing.T) {
 		require.ErrorContains(t, err, "projection expects exactly one input, got 2")
 	})
 }
+
+func TestExecutor_Join(t *testing.T) {
+	t.Run("no inputs result in empty pipeline", func(t *testing.T) {
+		ctx := t.Context()
+		c := &Context{}
+		pipeline := c.executeJoin(ctx, &physical.Join{}, nil)
+		err := pipeline.Read(ctx)
+		require.ErrorContains(t, err, EOF.Error())
+	})
+
+	t.Run("single input of join of two vectors results in error", func(t *testing.T) {
+		ctx := t.Context()
+		c := &Context{}
+		join := &physical.Join{Operator: types.BinaryOpDiv, VectorMatching: &physical.VectorMatching{}}
+		pipeline := c.executeJoin(ctx, join, []Pipeline{emptyPipeline()})
+		err := pipeline.Read(ctx)
+		require.ErrorContains(t, err, "join expects exactly two inputs, got 1")
+	})
+}
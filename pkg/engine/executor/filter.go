@@ -145,7 +145,13 @@ func filterBatch(batch arrow.Record, include func(int) bool) arrow.Record {
 	var ct int64
 	for i := 0; i < int(batch.NumRows()); i++ {
 		if include(i) {
-			for _, add := range additions {
+			for j, add := range additions {
+				// Preserve NULL values, as they denote that a row has no value
+				// for the column, e.g. a key that has not been parsed.
+				if batch.Column(j).IsNull(i) {
+					builders[j].AppendNull()
+					continue
+				}
 				add(i)
 			}
 			ct++
@@ -0,0 +1,45 @@
+package executor
+
+import (
+	"github.com/grafana/loki/v3/pkg/engine/planner/physical"
+	"github.com/grafana/loki/v3/pkg/logql/log"
+)
+
+// NewLineFormatPipeline returns a pipeline that replaces the log line of each
+// row of its input with the result of the text template of the
+// [physical.LineFormat] node.
+//
+// Formatting reuses the line_format stage of the old engine, so the template
+// has access to the same functions and the formatted lines are the same as in
+// the old engine.
+func NewLineFormatPipeline(format *physical.LineFormat, input Pipeline) (*GenericPipeline, error) {
+	stage, err := log.NewFormatter(format.Template)
+	if err != nil {
+		return nil, err
+	}
+	return newStagePipeline(newStageProcessor(stage, true), input), nil
+}
+
+// NewLabelFormatPipeline returns a pipeline that renames or formats columns
+// of each row of its input. Formatted columns are returned as columns of type
+// [types.ColumnTypeParsed].
+//
+// Formatting reuses the label_format stage of the old engine, so the templates
+// have access to the same functions and the formatted labels are the same as
+// in the old engine.
+func NewLabelFormatPipeline(format *physical.LabelFormat, input Pipeline) (*GenericPipeline, error) {
+	formats := make([]log.LabelFmt, 0, len(format.Formats))
+	for _, f := range format.Formats {
+		if f.Rename {
+			formats = append(formats, log.NewRenameLabelFmt(f.Name, f.Value))
+			continue
+		}
+		formats = append(formats, log.NewTemplateLabelFmt(f.Name, f.Value))
+	}
+
+	stage, err := log.NewLabelsFormatter(formats)
+	if err != nil {
+		return nil, err
+	}
+	return newStagePipeline(newStageProcessor(stage, false), input), nil
+}
@@ -0,0 +1,243 @@
+package executor
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/apache/arrow-go/v18/arrow"
+	"github.com/apache/arrow-go/v18/arrow/array"
+	"github.com/apache/arrow-go/v18/arrow/memory"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/loki/v3/pkg/engine/internal/datatype"
+	"github.com/grafana/loki/v3/pkg/engine/internal/types"
+	"github.com/grafana/loki/v3/pkg/engine/planner/physical"
+	"github.com/grafana/loki/v3/pkg/logql/syntax"
+)
+
+// stageTestRow is a row of the input of the stage pipelines.
+type stageTestRow struct {
+	line    string
+	traceID string // value of the trace_id metadata column
+}
+
+// stageTestTimestamp is the timestamp of all rows of the input of the stage
+// pipelines.
+var stageTestTimestamp = time.Unix(0, 1620000000000000001).UTC()
+
+// newStageInput returns a record with the builtin timestamp and message
+// columns, a stream label column "app" and a metadata column "trace_id".
+func newStageInput(app string, rows ...stageTestRow) arrow.Record {
+	fields := []arrow.Field{
+		{Name: types.ColumnNameBuiltinTimestamp, Type: datatype.Arrow.Timestamp, Metadata: datatype.ColumnMetadataBuiltinTimestamp},
+		{Name: "app", Type: datatype.Arrow.String, Nullable: true, Metadata: datatype.ColumnMetadata(types.ColumnTypeLabel, datatype.Loki.String)},
+		{Name: "trace_id", Type: datatype.Arrow.String, Nullable: true, Metadata: datatype.ColumnMetadata(types.ColumnTypeMetadata, datatype.Loki.String)},
+		{Name: types.ColumnNameBuiltinMessage, Type: datatype.Arrow.String, Metadata: datatype.ColumnMetadataBuiltinMessage},
+	}
+
+	var (
+		apps     = make([]string, len(rows))
+		traceIDs = make([]string, len(rows))
+		lines    = make([]string, len(rows))
+
+		timestamps = array.NewTimestampBuilder(memory.DefaultAllocator, arrow.FixedWidthTypes.Timestamp_ns.(*arrow.TimestampType))
+	)
+	defer timestamps.Release()
+
+	for i, row := range rows {
+		apps[i] = app
+		traceIDs[i] = row.traceID
+		lines[i] = row.line
+		timestamps.Append(arrow.Timestamp(stageTestTimestamp.UnixNano()))
+	}
+
+	arrays := []arrow.Array{timestamps.NewArray(), newStringArray(apps, nil), newStringArray(traceIDs, nil), newStringArray(lines, nil)}
+	defer func() {
+		for _, arr := range arrays {
+			arr.Release()
+		}
+	}()
+	return array.NewRecord(arrow.NewSchema(fields, nil), arrays, int64(len(rows)))
+}
+
+// stageTestResult is the log line and the labels of a row.
+type stageTestResult struct {
+	line   string
+	labels labels.Labels
+}
+
+// recordResults returns the log line and the non-empty label, metadata and
+// parsed columns of each row of the record.
+func recordResults(t *testing.T, record arrow.Record) []stageTestResult {
+	t.Helper()
+
+	results := make([]stageTestResult, record.NumRows())
+	for row := range results {
+		builder := labels.NewScratchBuilder(int(record.NumCols()))
+		for i, field := range record.Schema().Fields() {
+			ct, _ := field.Metadata.GetValue(types.MetadataKeyColumnType)
+
+			switch ct {
+			case types.ColumnTypeBuiltin.String():
+				if field.Name == types.ColumnNameBuiltinMessage {
+					results[row].line = stringValue(record, i, row)
+				}
+			case types.ColumnTypeLabel.String(), types.ColumnTypeMetadata.String(), types.ColumnTypeParsed.String():
+				if value := stringValue(record, i, row); value != "" {
+					builder.Add(field.Name, value)
+				}
+			}
+		}
+		builder.Sort()
+		results[row].labels = builder.Labels()
+	}
+	return results
+}
+
+// oldEngineResults returns the log line and labels of each row processed by
+// the pipeline of the old engine for the query.
+func oldEngineResults(t *testing.T, query string, app string, rows ...stageTestRow) []stageTestResult {
+	t.Helper()
+
+	expr, err := syntax.ParseLogSelector(query, true)
+	require.NoError(t, err)
+	pipeline, err := expr.Pipeline()
+	require.NoError(t, err)
+
+	stream := pipeline.ForStream(labels.FromStrings("app", app))
+
+	var results []stageTestResult
+	for _, row := range rows {
+		var metadata labels.Labels
+		if row.traceID != "" {
+			metadata = labels.FromStrings("trace_id", row.traceID)
+		}
+
+		line, lbs, ok := stream.Process(stageTestTimestamp.UnixNano(), []byte(row.line), metadata)
+		if !ok {
+			continue
+		}
+		results = append(results, stageTestResult{line: string(line), labels: lbs.Labels()})
+	}
+	return results
+}
+
+// runStagePipelines runs the pipelines created by the stages on top of each
+// other and returns the results of the output record.
+func runStagePipelines(t *testing.T, input arrow.Record, stages ...func(Pipeline) (Pipeline, error)) []stageTestResult {
+	t.Helper()
+
+	var pipeline Pipeline = NewBufferedPipeline(input)
+	for _, stage := range stages {
+		var err error
+		pipeline, err = stage(pipeline)
+		require.NoError(t, err)
+	}
+	defer pipeline.Close()
+
+	require.NoError(t, pipeline.Read(context.Background()))
+	record, err := pipeline.Value()
+	require.NoError(t, err)
+
+	return recordResults(t, record)
+}
+
+func parseStage(parse *physical.Parse) func(Pipeline) (Pipeline, error) {
+	return func(input Pipeline) (Pipeline, error) { return NewParsePipeline(parse, input) }
+}
+
+func lineFormatStage(format *physical.LineFormat) func(Pipeline) (Pipeline, error) {
+	return func(input Pipeline) (Pipeline, error) { return NewLineFormatPipeline(format, input) }
+}
+
+func labelFormatStage(format *physical.LabelFormat) func(Pipeline) (Pipeline, error) {
+	return func(input Pipeline) (Pipeline, error) { return NewLabelFormatPipeline(format, input) }
+}
+
+func TestFormatPipelines(t *testing.T) {
+	rows := []stageTestRow{
+		{line: `level=error msg="request failed" status=500 duration=1.5s`, traceID: "abc"},
+		{line: `level=info msg=done status=200`},
+		{line: `level=warn`},
+	}
+
+	for _, tt := range []struct {
+		name   string
+		query  string
+		stages []func(Pipeline) (Pipeline, error)
+	}{
+		{
+			name:  "line_format with labels and metadata",
+			query: `{app="loki"} | logfmt | line_format "{{.app}} {{.level | ToUpper}} {{.trace_id}} {{.msg}}"`,
+			stages: []func(Pipeline) (Pipeline, error){
+				parseStage(&physical.Parse{Parser: types.ParserTypeLogfmt}),
+				lineFormatStage(&physical.LineFormat{Template: `{{.app}} {{.level | ToUpper}} {{.trace_id}} {{.msg}}`}),
+			},
+		},
+		{
+			name:  "line_format with line and timestamp functions",
+			query: `{app="loki"} | line_format "{{ __timestamp__ | date \"2006-01-02T15:04:05Z\" }} {{ __line__ | trunc 9 }}"`,
+			stages: []func(Pipeline) (Pipeline, error){
+				lineFormatStage(&physical.LineFormat{Template: `{{ __timestamp__ | date "2006-01-02T15:04:05Z" }} {{ __line__ | trunc 9 }}`}),
+			},
+		},
+		{
+			name:  "line_format with simple key",
+			query: `{app="loki"} | logfmt | line_format "{{.msg}}"`,
+			stages: []func(Pipeline) (Pipeline, error){
+				parseStage(&physical.Parse{Parser: types.ParserTypeLogfmt}),
+				lineFormatStage(&physical.LineFormat{Template: `{{.msg}}`}),
+			},
+		},
+		{
+			name:  "line_format with invalid template execution",
+			query: `{app="loki"} | logfmt | line_format "{{ .status | div 0 }}"`,
+			stages: []func(Pipeline) (Pipeline, error){
+				parseStage(&physical.Parse{Parser: types.ParserTypeLogfmt}),
+				lineFormatStage(&physical.LineFormat{Template: `{{ .status | div 0 }}`}),
+			},
+		},
+		{
+			name:  "label_format rename and template",
+			query: `{app="loki"} | logfmt | label_format severity=level, summary="{{.app}}/{{.status}}", app="{{ .app | upper }}"`,
+			stages: []func(Pipeline) (Pipeline, error){
+				parseStage(&physical.Parse{Parser: types.ParserTypeLogfmt}),
+				labelFormatStage(&physical.LabelFormat{Formats: []physical.LabelFormatExpression{
+					{Name: "severity", Value: "level", Rename: true},
+					{Name: "summary", Value: "{{.app}}/{{.status}}"},
+					{Name: "app", Value: "{{ .app | upper }}"},
+				}}),
+			},
+		},
+		{
+			name:  "label_format of metadata",
+			query: `{app="loki"} | label_format trace=trace_id`,
+			stages: []func(Pipeline) (Pipeline, error){
+				labelFormatStage(&physical.LabelFormat{Formats: []physical.LabelFormatExpression{
+					{Name: "trace", Value: "trace_id", Rename: true},
+				}}),
+			},
+		},
+		{
+			name:  "label_format followed by line_format",
+			query: `{app="loki"} | logfmt | label_format duration="{{ .duration | default \"0s\" }}" | line_format "{{.level}} took {{.duration}}"`,
+			stages: []func(Pipeline) (Pipeline, error){
+				parseStage(&physical.Parse{Parser: types.ParserTypeLogfmt}),
+				labelFormatStage(&physical.LabelFormat{Formats: []physical.LabelFormatExpression{
+					{Name: "duration", Value: `{{ .duration | default "0s" }}`},
+				}}),
+				lineFormatStage(&physical.LineFormat{Template: `{{.level}} took {{.duration}}`}),
+			},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			input := newStageInput("loki", rows...)
+			defer input.Release()
+
+			expected := oldEngineResults(t, tt.query, "loki", rows...)
+			require.Equal(t, expected, runStagePipelines(t, input, tt.stages...))
+		})
+	}
+}
@@ -0,0 +1,604 @@
+package executor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"math"
+	"slices"
+	"strings"
+
+	"github.com/apache/arrow-go/v18/arrow"
+	"github.com/apache/arrow-go/v18/arrow/array"
+	"github.com/apache/arrow-go/v18/arrow/memory"
+	"github.com/prometheus/prometheus/model/labels"
+
+	"github.com/grafana/loki/v3/pkg/engine/internal/datatype"
+	"github.com/grafana/loki/v3/pkg/engine/internal/types"
+	"github.com/grafana/loki/v3/pkg/engine/planner/physical"
+)
+
+var (
+	timestampColumnExpr = &physical.ColumnExpr{
+		Ref: types.ColumnRef{Column: types.ColumnNameBuiltinTimestamp, Type: types.ColumnTypeBuiltin},
+	}
+	valueColumnExpr = &physical.ColumnExpr{
+		Ref: types.ColumnRef{Column: types.ColumnNameGeneratedValue, Type: types.ColumnTypeGenerated},
+	}
+)
+
+// NewJoinPipeline returns a pipeline that performs the binary operation of
+// the join on its inputs.
+//
+// A join of two vectors expects the left and the right operand as inputs, in
+// this order. A join of a vector and a scalar expects the vector as its only
+// input.
+func NewJoinPipeline(join *physical.Join, inputs []Pipeline, evaluator expressionEvaluator) (Pipeline, error) {
+	switch join.Operator {
+	case types.BinaryOpAdd, types.BinaryOpSub, types.BinaryOpMul, types.BinaryOpDiv, types.BinaryOpMod, types.BinaryOpPow,
+		types.BinaryOpEq, types.BinaryOpNeq, types.BinaryOpGt, types.BinaryOpGte, types.BinaryOpLt, types.BinaryOpLte:
+	case types.BinaryOpAnd, types.BinaryOpOr, types.BinaryOpUnless:
+		if join.LeftScalar != nil || join.RightScalar != nil {
+			return nil, fmt.Errorf("set operation %s is not supported with scalar operands", join.Operator)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported binary operation %s", join.Operator)
+	}
+
+	if join.LeftScalar != nil || join.RightScalar != nil {
+		if join.LeftScalar != nil && join.RightScalar != nil {
+			return nil, errors.New("join requires at least one vector operand")
+		}
+		if len(inputs) != 1 {
+			return nil, fmt.Errorf("join with scalar operand expects exactly one input, got %d", len(inputs))
+		}
+		return newScalarJoinPipeline(join, inputs[0], evaluator), nil
+	}
+
+	if len(inputs) != 2 {
+		return nil, fmt.Errorf("join expects exactly two inputs, got %d", len(inputs))
+	}
+	if join.VectorMatching == nil {
+		return nil, errors.New("join of two vectors requires vector matching")
+	}
+
+	names := func(columns []physical.ColumnExpression) ([]string, error) {
+		result := make([]string, 0, len(columns))
+		for _, column := range columns {
+			columnExpr, ok := column.(*physical.ColumnExpr)
+			if !ok {
+				return nil, fmt.Errorf("invalid column expression type %T", column)
+			}
+			result = append(result, columnExpr.Ref.Column)
+		}
+		return result, nil
+	}
+
+	matchingLabels, err := names(join.VectorMatching.MatchingLabels)
+	if err != nil {
+		return nil, err
+	}
+	include, err := names(join.VectorMatching.Include)
+	if err != nil {
+		return nil, err
+	}
+
+	j := &vectorJoin{
+		operator:       join.Operator,
+		returnBool:     join.ReturnBool,
+		cardinality:    join.VectorMatching.Cardinality,
+		on:             join.VectorMatching.On,
+		matchingLabels: matchingLabels,
+		include:        include,
+		tsEval:         evaluator.newFunc(timestampColumnExpr),
+		valueEval:      evaluator.newFunc(valueColumnExpr),
+		columnTypes:    make(map[string]types.ColumnType),
+	}
+	return newGenericPipeline(Local, j.read, inputs...), nil
+}
+
+// newScalarJoinPipeline returns a pipeline that applies the binary operation
+// of the join between the scalar operand and each row of its input.
+func newScalarJoinPipeline(join *physical.Join, input Pipeline, evaluator expressionEvaluator) *GenericPipeline {
+	valueEval := evaluator.newFunc(valueColumnExpr)
+
+	return newGenericPipeline(Local, func(ctx context.Context, inputs []Pipeline) state {
+		// Pull the next item from the input pipeline
+		input := inputs[0]
+		err := input.Read(ctx)
+		if err != nil {
+			return failureState(err)
+		}
+
+		batch, err := input.Value()
+		if err != nil {
+			return failureState(err)
+		}
+
+		vec, err := valueEval(batch)
+		if err != nil {
+			return failureState(err)
+		}
+		values, err := float64Values(vec)
+		if err != nil {
+			return failureState(err)
+		}
+
+		var (
+			numRows = int(batch.NumRows())
+			results = make([]float64, numRows)
+			include = make([]bool, numRows)
+			dropped bool
+		)
+		for row := range numRows {
+			value := values(row)
+
+			var result float64
+			if join.LeftScalar != nil {
+				result = evalBinaryOp(join.Operator, *join.LeftScalar, value)
+			} else {
+				result = evalBinaryOp(join.Operator, value, *join.RightScalar)
+			}
+
+			// Comparisons without bool filter the rows and keep the value of the
+			// vector.
+			if join.Operator.IsComparison() && !join.ReturnBool {
+				if result == 0 {
+					dropped = true
+					continue
+				}
+				result = value
+			}
+
+			results[row] = result
+			include[row] = true
+		}
+
+		record, err := replaceValueColumn(batch, results)
+		if err != nil {
+			return failureState(err)
+		}
+		if dropped {
+			defer record.Release()
+			return successState(filterBatch(record, func(i int) bool { return include[i] }))
+		}
+		return successState(record)
+	}, input)
+}
+
+// replaceValueColumn returns a copy of the batch with the values of the value
+// column replaced by values.
+func replaceValueColumn(batch arrow.Record, values []float64) (arrow.Record, error) {
+	indices := batch.Schema().FieldIndices(types.ColumnNameGeneratedValue)
+	if len(indices) == 0 {
+		return nil, fmt.Errorf("missing column %s", types.ColumnNameGeneratedValue)
+	}
+
+	builder := array.NewFloat64Builder(memory.NewGoAllocator())
+	defer builder.Release()
+	builder.AppendValues(values, nil)
+	valueArr := builder.NewArray()
+	defer valueArr.Release()
+
+	fields := slices.Clone(batch.Schema().Fields())
+	fields[indices[0]] = valueField()
+
+	arrays := slices.Clone(batch.Columns())
+	arrays[indices[0]] = valueArr
+
+	return array.NewRecord(arrow.NewSchema(fields, nil), arrays, batch.NumRows()), nil
+}
+
+func valueField() arrow.Field {
+	return arrow.Field{
+		Name:     types.ColumnNameGeneratedValue,
+		Type:     datatype.Arrow.Float,
+		Nullable: false,
+		Metadata: datatype.ColumnMetadata(types.ColumnTypeGenerated, datatype.Loki.Float),
+	}
+}
+
+// evalBinaryOp applies the arithmetic or comparison operation op to the
+// operands. Comparisons return 1 if they are true and 0 otherwise.
+//
+// Division and modulo by zero return NaN, which matches the behaviour of the
+// old engine.
+func evalBinaryOp(op types.BinaryOp, left, right float64) float64 {
+	boolValue := func(b bool) float64 {
+		if b {
+			return 1
+		}
+		return 0
+	}
+
+	switch op {
+	case types.BinaryOpAdd:
+		return left + right
+	case types.BinaryOpSub:
+		return left - right
+	case types.BinaryOpMul:
+		return left * right
+	case types.BinaryOpDiv:
+		if right == 0 {
+			return math.NaN()
+		}
+		return left / right
+	case types.BinaryOpMod:
+		if right == 0 {
+			return math.NaN()
+		}
+		return math.Mod(left, right)
+	case types.BinaryOpPow:
+		return math.Pow(left, right)
+	case types.BinaryOpEq:
+		return boolValue(left == right)
+	case types.BinaryOpNeq:
+		return boolValue(left != right)
+	case types.BinaryOpGt:
+		return boolValue(left > right)
+	case types.BinaryOpGte:
+		return boolValue(left >= right)
+	case types.BinaryOpLt:
+		return boolValue(left < right)
+	case types.BinaryOpLte:
+		return boolValue(left <= right)
+	default:
+		panic(fmt.Sprintf("unsupported binary operation %s", op))
+	}
+}
+
+// joinSample is a single sample of an operand of a vector join.
+type joinSample struct {
+	labels    labels.Labels
+	value     float64
+	signature uint64 // hash of the labels used for matching
+}
+
+// vectorJoin performs a binary operation between two vectors.
+//
+// It reads both inputs entirely and matches the samples of both sides at each
+// timestamp by their labels, following the vector matching rules of PromQL.
+type vectorJoin struct {
+	operator    types.BinaryOp
+	returnBool  bool
+	cardinality types.MatchCardinality
+
+	on             bool     // match on matchingLabels only, instead of ignoring them
+	matchingLabels []string // labels used for matching
+	include        []string // labels of the "one" side added by group_left and group_right
+
+	tsEval    evalFunc // used to evaluate the timestamp column
+	valueEval evalFunc // used to evaluate the value column
+
+	// columnTypes holds the column type of each label. Labels are
+	// identified by their name, so the first type seen for a name wins.
+	columnTypes map[string]types.ColumnType
+
+	done bool // set once the result has been returned
+}
+
+func (j *vectorJoin) read(ctx context.Context, inputs []Pipeline) state {
+	if j.done {
+		return Exhausted
+	}
+	j.done = true
+
+	left, err := j.readSamples(ctx, inputs[0])
+	if err != nil {
+		return failureState(err)
+	}
+	right, err := j.readSamples(ctx, inputs[1])
+	if err != nil {
+		return failureState(err)
+	}
+
+	timestamps := slices.Collect(maps.Keys(left))
+	for ts := range right {
+		if _, ok := left[ts]; !ok {
+			timestamps = append(timestamps, ts)
+		}
+	}
+	slices.Sort(timestamps)
+
+	var (
+		resultTimestamps []arrow.Timestamp
+		results          []joinSample
+	)
+	for _, ts := range timestamps {
+		samples, err := j.join(left[ts], right[ts])
+		if err != nil {
+			return failureState(err)
+		}
+		for range samples {
+			resultTimestamps = append(resultTimestamps, ts)
+		}
+		results = append(results, samples...)
+	}
+
+	if len(results) == 0 {
+		return Exhausted
+	}
+	return successState(j.buildRecord(resultTimestamps, results))
+}
+
+// readSamples reads all records of the input and returns their samples by
+// timestamp.
+func (j *vectorJoin) readSamples(ctx context.Context, input Pipeline) (map[arrow.Timestamp][]joinSample, error) {
+	samples := make(map[arrow.Timestamp][]joinSample)
+	builder := labels.NewScratchBuilder(0)
+
+	for {
+		if err := input.Read(ctx); err != nil {
+			if errors.Is(err, EOF) {
+				return samples, nil
+			}
+			return nil, err
+		}
+
+		record, err := input.Value()
+		if err != nil {
+			return nil, err
+		}
+
+		tsVec, err := j.tsEval(record)
+		if err != nil {
+			return nil, err
+		}
+		tsCol, ok := tsVec.ToArray().(*array.Timestamp)
+		if !ok {
+			return nil, fmt.Errorf("unexpected array type %T for column %s", tsVec.ToArray(), types.ColumnNameBuiltinTimestamp)
+		}
+
+		valueVec, err := j.valueEval(record)
+		if err != nil {
+			return nil, err
+		}
+		values, err := float64Values(valueVec)
+		if err != nil {
+			return nil, err
+		}
+
+		labelIdxs := j.labelColumns(record)
+		for row := range int(record.NumRows()) {
+			builder.Reset()
+			for _, idx := range labelIdxs {
+				if value := stringValue(record, idx, row); value != "" {
+					// copy the value as it is backed by the arrow array data buffer.
+					builder.Add(record.ColumnName(idx), strings.Clone(value))
+				}
+			}
+			builder.Sort()
+
+			lbls := builder.Labels()
+			ts := tsCol.Value(row)
+			samples[ts] = append(samples[ts], joinSample{
+				labels:    lbls,
+				value:     values(row),
+				signature: j.signature(lbls),
+			})
+		}
+	}
+}
+
+// labelColumns returns the indexes of the label columns of the record.
+func (j *vectorJoin) labelColumns(record arrow.Record) []int {
+	var idxs []int
+	for i, field := range record.Schema().Fields() {
+		if !isLabelField(field) {
+			continue
+		}
+
+		if _, ok := j.columnTypes[field.Name]; !ok {
+			ct, _ := field.Metadata.GetValue(types.MetadataKeyColumnType)
+			j.columnTypes[field.Name] = types.ColumnTypeFromString(ct)
+		}
+		idxs = append(idxs, i)
+	}
+	return idxs
+}
+
+// signature returns the hash of the labels that are used to match the
+// series of both sides.
+func (j *vectorJoin) signature(lbls labels.Labels) uint64 {
+	builder := labels.NewBuilder(lbls)
+	if j.on {
+		builder.Keep(j.matchingLabels...)
+	} else {
+		builder.Del(j.matchingLabels...)
+	}
+	return labels.StableHash(builder.Labels())
+}
+
+// join returns the result of the binary operation between the samples of
+// both sides at a single timestamp.
+func (j *vectorJoin) join(lhs, rhs []joinSample) ([]joinSample, error) {
+	switch j.operator {
+	case types.BinaryOpAnd:
+		if len(lhs) == 0 || len(rhs) == 0 {
+			return nil, nil // AND with nothing is nothing.
+		}
+		rightSigs := signatures(rhs)
+		return slices.DeleteFunc(lhs, func(s joinSample) bool {
+			_, ok := rightSigs[s.signature]
+			return !ok
+		}), nil
+
+	case types.BinaryOpOr:
+		leftSigs := signatures(lhs)
+		results := lhs
+		for _, s := range rhs {
+			if _, ok := leftSigs[s.signature]; !ok {
+				results = append(results, s)
+			}
+		}
+		return results, nil
+
+	case types.BinaryOpUnless:
+		rightSigs := signatures(rhs)
+		return slices.DeleteFunc(lhs, func(s joinSample) bool {
+			_, ok := rightSigs[s.signature]
+			return ok
+		}), nil
+
+	default:
+		return j.joinSamples(lhs, rhs)
+	}
+}
+
+func signatures(samples []joinSample) map[uint64]struct{} {
+	sigs := make(map[uint64]struct{}, len(samples))
+	for _, s := range samples {
+		sigs[s.signature] = struct{}{}
+	}
+	return sigs
+}
+
+// joinSamples applies an arithmetic or comparison operation between the
+// matching samples of both sides.
+func (j *vectorJoin) joinSamples(lhs, rhs []joinSample) ([]joinSample, error) {
+	// The samples of the "one" side are indexed by their signature. For
+	// one-to-many matching, the right side is the "many" side.
+	many, one := lhs, rhs
+	if j.cardinality == types.MatchOneToMany {
+		many, one = rhs, lhs
+	}
+
+	oneSigs := make(map[uint64]*joinSample, len(one))
+	for i := range one {
+		sig := one[i].signature
+		if _, ok := oneSigs[sig]; ok {
+			side := "right"
+			if j.cardinality == types.MatchOneToMany {
+				side = "left"
+			}
+			return nil, fmt.Errorf("found duplicate series on the %s hand-side"+
+				";many-to-many matching not allowed: matching labels must be unique on one side", side)
+		}
+		oneSigs[sig] = &one[i]
+	}
+
+	var (
+		results     []joinSample
+		matchedSigs = make(map[uint64]map[uint64]struct{})
+	)
+	for i := range many {
+		ms := &many[i]
+		os, found := oneSigs[ms.signature]
+		if !found {
+			continue
+		}
+
+		metric := j.resultLabels(ms.labels, os.labels)
+
+		insertedSigs, exists := matchedSigs[ms.signature]
+		if j.cardinality == types.MatchOneToOne {
+			if exists {
+				return nil, errors.New("multiple matches for labels: many-to-one matching must be explicit (group_left/group_right)")
+			}
+			matchedSigs[ms.signature] = nil
+		} else {
+			insertSig := labels.StableHash(metric)
+			if !exists {
+				insertedSigs = make(map[uint64]struct{})
+				matchedSigs[ms.signature] = insertedSigs
+			} else if _, duplicate := insertedSigs[insertSig]; duplicate {
+				return nil, errors.New("multiple matches for labels: grouping labels must ensure unique matches")
+			}
+			insertedSigs[insertSig] = struct{}{}
+		}
+
+		left, right := ms, os
+		if j.cardinality == types.MatchOneToMany {
+			left, right = os, ms
+		}
+
+		value := evalBinaryOp(j.operator, left.value, right.value)
+
+		// Comparisons without bool filter the samples and keep the value of
+		// the left side.
+		if j.operator.IsComparison() && !j.returnBool {
+			if value == 0 {
+				continue
+			}
+			value = left.value
+		}
+
+		results = append(results, joinSample{labels: metric, value: value})
+	}
+	return results, nil
+}
+
+// resultLabels returns the labels of the result of matching the series of
+// the "many" side with the series of the "one" side.
+func (j *vectorJoin) resultLabels(many, one labels.Labels) labels.Labels {
+	builder := labels.NewBuilder(many)
+
+	if j.cardinality == types.MatchOneToOne {
+		if j.on {
+			builder.Keep(j.matchingLabels...)
+		} else {
+			builder.Del(j.matchingLabels...)
+		}
+	}
+
+	// Included labels from the group_left and group_right modifiers are
+	// taken from the "one" side.
+	for _, name := range j.include {
+		if value := one.Get(name); value != "" {
+			builder.Set(name, value)
+		} else {
+			builder.Del(name)
+		}
+	}
+
+	return builder.Labels()
+}
+
+// buildRecord returns a record with the timestamp, value and label columns of
+// the samples. Label columns are sorted by name.
+func (j *vectorJoin) buildRecord(timestamps []arrow.Timestamp, samples []joinSample) arrow.Record {
+	names := make(map[string]struct{})
+	for _, s := range samples {
+		s.labels.Range(func(l labels.Label) { names[l.Name] = struct{}{} })
+	}
+	columns := slices.Sorted(maps.Keys(names))
+
+	fields := make([]arrow.Field, 0, len(columns)+2)
+	fields = append(fields,
+		arrow.Field{
+			Name:     types.ColumnNameBuiltinTimestamp,
+			Type:     datatype.Arrow.Timestamp,
+			Nullable: false,
+			Metadata: datatype.ColumnMetadataBuiltinTimestamp,
+		},
+		valueField(),
+	)
+	for _, name := range columns {
+		fields = append(fields, arrow.Field{
+			Name:     name,
+			Type:     datatype.Arrow.String,
+			Nullable: true,
+			Metadata: datatype.ColumnMetadata(j.columnTypes[name], datatype.Loki.String),
+		})
+	}
+
+	rb := array.NewRecordBuilder(memory.NewGoAllocator(), arrow.NewSchema(fields, nil))
+	defer rb.Release()
+
+	for i, s := range samples {
+		rb.Field(0).(*array.TimestampBuilder).Append(timestamps[i])
+		rb.Field(1).(*array.Float64Builder).Append(s.value)
+
+		for col, name := range columns {
+			builder := rb.Field(col + 2).(*array.StringBuilder) // offset by 2 as the first 2 fields are timestamp and value
+			if value := s.labels.Get(name); value != "" {
+				builder.Append(value)
+			} else {
+				builder.AppendNull()
+			}
+		}
+	}
+
+	return rb.NewRecord()
+}
@@ -0,0 +1,397 @@
+package executor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/apache/arrow-go/v18/arrow"
+	"github.com/apache/arrow-go/v18/arrow/array"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/loki/v3/pkg/engine/internal/datatype"
+	"github.com/grafana/loki/v3/pkg/engine/internal/types"
+	"github.com/grafana/loki/v3/pkg/engine/planner/physical"
+)
+
+var joinTestFields = []arrow.Field{
+	{Name: types.ColumnNameBuiltinTimestamp, Type: datatype.Arrow.Timestamp, Metadata: datatype.ColumnMetadataBuiltinTimestamp},
+	{Name: types.ColumnNameGeneratedValue, Type: datatype.Arrow.Float, Metadata: datatype.ColumnMetadata(types.ColumnTypeGenerated, datatype.Loki.Float)},
+	{Name: "env", Type: datatype.Arrow.String, Nullable: true, Metadata: datatype.ColumnMetadata(types.ColumnTypeLabel, datatype.Loki.String)},
+	{Name: "app", Type: datatype.Arrow.String, Nullable: true, Metadata: datatype.ColumnMetadata(types.ColumnTypeLabel, datatype.Loki.String)},
+	{Name: "team", Type: datatype.Arrow.String, Nullable: true, Metadata: datatype.ColumnMetadata(types.ColumnTypeMetadata, datatype.Loki.String)},
+}
+
+var (
+	joinTestT1 = time.Unix(60, 0).UTC()
+	joinTestT2 = time.Unix(120, 0).UTC()
+)
+
+// newJoinInput returns a pipeline of a record with the timestamp, value, env,
+// app and team columns. Each row is given as
+// "<timestamp>,<value>,<env>,<app>,<team>", where the timestamp is t1 or t2.
+func newJoinInput(t *testing.T, rows ...string) Pipeline {
+	t.Helper()
+
+	lines := make([]string, len(rows))
+	for i, row := range rows {
+		row = strings.Replace(row, "t1", joinTestT1.Format(arrowTimestampFormat), 1)
+		row = strings.Replace(row, "t2", joinTestT2.Format(arrowTimestampFormat), 1)
+		lines[i] = row
+	}
+
+	record, err := CSVToArrow(joinTestFields, strings.Join(lines, "\n"))
+	require.NoError(t, err)
+	t.Cleanup(record.Release)
+
+	return NewBufferedPipeline(record)
+}
+
+// joinResults reads all records of the pipeline and returns each row as
+// "<timestamp> <labels> <value>", with the timestamp in seconds.
+func joinResults(t *testing.T, pipeline Pipeline) []string {
+	t.Helper()
+
+	var results []string
+	for {
+		err := pipeline.Read(context.Background())
+		if errors.Is(err, EOF) {
+			return results
+		}
+		require.NoError(t, err)
+
+		record, err := pipeline.Value()
+		require.NoError(t, err)
+
+		for row := range int(record.NumRows()) {
+			var (
+				ts      arrow.Timestamp
+				value   float64
+				builder = labels.NewScratchBuilder(int(record.NumCols()))
+			)
+			for i, field := range record.Schema().Fields() {
+				switch field.Name {
+				case types.ColumnNameBuiltinTimestamp:
+					ts = record.Column(i).(*array.Timestamp).Value(row)
+				case types.ColumnNameGeneratedValue:
+					value = record.Column(i).(*array.Float64).Value(row)
+				default:
+					if v := stringValue(record, i, row); v != "" {
+						builder.Add(field.Name, v)
+					}
+				}
+			}
+			builder.Sort()
+			results = append(results, fmt.Sprintf("%d %s %v", ts.ToTime(arrow.Nanosecond).Unix(), builder.Labels(), value))
+		}
+	}
+}
+
+func onLabels(names ...string) []physical.ColumnExpression {
+	columns := make([]physical.ColumnExpression, 0, len(names))
+	for _, name := range names {
+		columns = append(columns, &physical.ColumnExpr{Ref: types.ColumnRef{Column: name, Type: types.ColumnTypeAmbiguous}})
+	}
+	return columns
+}
+
+func TestJoinPipeline_Vectors(t *testing.T) {
+	for _, tt := range []struct {
+		name     string
+		join     *physical.Join
+		left     []string
+		right    []string
+		expected []string
+	}{
+		{
+			name: "division matches series by all labels",
+			join: &physical.Join{Operator: types.BinaryOpDiv, VectorMatching: &physical.VectorMatching{}},
+			left: []string{
+				"t1,10,prod,loki,",
+				"t1,20,dev,loki,",
+				"t2,30,prod,loki,",
+				"t2,40,prod,mimir,",
+			},
+			right: []string{
+				"t1,5,prod,loki,",
+				"t1,0,dev,loki,",
+				"t2,10,prod,loki,",
+			},
+			expected: []string{
+				`60 {app="loki", env="prod"} 2`,
+				`60 {app="loki", env="dev"} NaN`,
+				`120 {app="loki", env="prod"} 3`,
+			},
+		},
+		{
+			name: "comparison filters series and keeps the left value",
+			join: &physical.Join{Operator: types.BinaryOpGt, VectorMatching: &physical.VectorMatching{}},
+			left: []string{
+				"t1,10,prod,loki,",
+				"t1,20,dev,loki,",
+			},
+			right: []string{
+				"t1,5,prod,loki,",
+				"t1,30,dev,loki,",
+			},
+			expected: []string{
+				`60 {app="loki", env="prod"} 10`,
+			},
+		},
+		{
+			name: "comparison with bool",
+			join: &physical.Join{Operator: types.BinaryOpGt, ReturnBool: true, VectorMatching: &physical.VectorMatching{}},
+			left: []string{
+				"t1,10,prod,loki,",
+				"t1,20,dev,loki,",
+			},
+			right: []string{
+				"t1,5,prod,loki,",
+				"t1,30,dev,loki,",
+			},
+			expected: []string{
+				`60 {app="loki", env="prod"} 1`,
+				`60 {app="loki", env="dev"} 0`,
+			},
+		},
+		{
+			name: "on matches series by the given labels only",
+			join: &physical.Join{Operator: types.BinaryOpAdd, VectorMatching: &physical.VectorMatching{On: true, MatchingLabels: onLabels("env")}},
+			left: []string{
+				"t1,10,prod,loki,",
+			},
+			right: []string{
+				"t1,5,prod,,",
+			},
+			expected: []string{
+				`60 {env="prod"} 15`,
+			},
+		},
+		{
+			name: "ignoring matches series by all other labels",
+			join: &physical.Join{Operator: types.BinaryOpSub, VectorMatching: &physical.VectorMatching{MatchingLabels: onLabels("app")}},
+			left: []string{
+				"t1,10,prod,loki,",
+			},
+			right: []string{
+				"t1,5,prod,mimir,",
+			},
+			expected: []string{
+				`60 {env="prod"} 5`,
+			},
+		},
+		{
+			name: "group_left matches many series of the left side",
+			join: &physical.Join{Operator: types.BinaryOpMul, VectorMatching: &physical.VectorMatching{
+				Cardinality:    types.MatchManyToOne,
+				On:             true,
+				MatchingLabels: onLabels("env"),
+			}},
+			left: []string{
+				"t1,10,prod,loki,",
+				"t1,20,prod,mimir,",
+				"t1,30,dev,loki,",
+			},
+			right: []string{
+				"t1,2,prod,,",
+			},
+			expected: []string{
+				`60 {app="loki", env="prod"} 20`,
+				`60 {app="mimir", env="prod"} 40`,
+			},
+		},
+		{
+			name: "group_left includes labels of the right side",
+			join: &physical.Join{Operator: types.BinaryOpMul, VectorMatching: &physical.VectorMatching{
+				Cardinality:    types.MatchManyToOne,
+				On:             true,
+				MatchingLabels: onLabels("env"),
+				Include:        onLabels("team"),
+			}},
+			left: []string{
+				"t1,10,prod,loki,",
+				"t1,20,prod,mimir,",
+			},
+			right: []string{
+				"t1,2,prod,,db",
+			},
+			expected: []string{
+				`60 {app="loki", env="prod", team="db"} 20`,
+				`60 {app="mimir", env="prod", team="db"} 40`,
+			},
+		},
+		{
+			name: "group_right matches many series of the right side",
+			join: &physical.Join{Operator: types.BinaryOpDiv, VectorMatching: &physical.VectorMatching{
+				Cardinality:    types.MatchOneToMany,
+				On:             true,
+				MatchingLabels: onLabels("env"),
+				Include:        onLabels("team"),
+			}},
+			left: []string{
+				"t1,100,prod,all,db",
+			},
+			right: []string{
+				"t1,10,prod,loki,",
+				"t1,20,prod,mimir,",
+			},
+			expected: []string{
+				`60 {app="loki", env="prod", team="db"} 10`,
+				`60 {app="mimir", env="prod", team="db"} 5`,
+			},
+		},
+		{
+			name: "and",
+			join: &physical.Join{Operator: types.BinaryOpAnd, VectorMatching: &physical.VectorMatching{}},
+			left: []string{
+				"t1,10,prod,loki,",
+				"t1,20,dev,loki,",
+				"t2,30,prod,loki,",
+			},
+			right: []string{
+				"t1,1,prod,loki,",
+			},
+			expected: []string{
+				`60 {app="loki", env="prod"} 10`,
+			},
+		},
+		{
+			name: "or",
+			join: &physical.Join{Operator: types.BinaryOpOr, VectorMatching: &physical.VectorMatching{}},
+			left: []string{
+				"t1,10,prod,loki,",
+			},
+			right: []string{
+				"t1,1,prod,loki,",
+				"t1,2,dev,loki,",
+				"t2,3,dev,loki,",
+			},
+			expected: []string{
+				`60 {app="loki", env="prod"} 10`,
+				`60 {app="loki", env="dev"} 2`,
+				`120 {app="loki", env="dev"} 3`,
+			},
+		},
+		{
+			name: "unless",
+			join: &physical.Join{Operator: types.BinaryOpUnless, VectorMatching: &physical.VectorMatching{On: true, MatchingLabels: onLabels("env")}},
+			left: []string{
+				"t1,10,prod,loki,",
+				"t1,20,dev,loki,",
+				"t2,30,prod,loki,",
+			},
+			right: []string{
+				"t1,1,prod,mimir,",
+			},
+			expected: []string{
+				`60 {app="loki", env="dev"} 20`,
+				`120 {app="loki", env="prod"} 30`,
+			},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			pipeline, err := NewJoinPipeline(tt.join, []Pipeline{newJoinInput(t, tt.left...), newJoinInput(t, tt.right...)}, expressionEvaluator{})
+			require.NoError(t, err)
+			defer pipeline.Close()
+
+			require.Equal(t, tt.expected, joinResults(t, pipeline))
+		})
+	}
+}
+
+func TestJoinPipeline_VectorsErrors(t *testing.T) {
+	for _, tt := range []struct {
+		name     string
+		join     *physical.Join
+		left     []string
+		right    []string
+		expected string
+	}{
+		{
+			name:     "duplicate series on the right side",
+			join:     &physical.Join{Operator: types.BinaryOpDiv, VectorMatching: &physical.VectorMatching{On: true, MatchingLabels: onLabels("env")}},
+			left:     []string{"t1,10,prod,loki,"},
+			right:    []string{"t1,1,prod,loki,", "t1,2,prod,mimir,"},
+			expected: "found duplicate series on the right hand-side",
+		},
+		{
+			name:     "many-to-one matching without group_left",
+			join:     &physical.Join{Operator: types.BinaryOpDiv, VectorMatching: &physical.VectorMatching{On: true, MatchingLabels: onLabels("env")}},
+			left:     []string{"t1,10,prod,loki,", "t1,20,prod,mimir,"},
+			right:    []string{"t1,1,prod,,"},
+			expected: "multiple matches for labels: many-to-one matching must be explicit (group_left/group_right)",
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			pipeline, err := NewJoinPipeline(tt.join, []Pipeline{newJoinInput(t, tt.left...), newJoinInput(t, tt.right...)}, expressionEvaluator{})
+			require.NoError(t, err)
+			defer pipeline.Close()
+
+			require.ErrorContains(t, pipeline.Read(context.Background()), tt.expected)
+		})
+	}
+}
+
+func TestJoinPipeline_Scalar(t *testing.T) {
+	scalar := func(v float64) *float64 { return &v }
+
+	input := []string{
+		"t1,10,prod,loki,",
+		"t1,20,dev,loki,",
+		"t2,30,prod,loki,",
+	}
+
+	for _, tt := range []struct {
+		name     string
+		join     *physical.Join
+		expected []string
+	}{
+		{
+			name: "scalar on the left side",
+			join: &physical.Join{Operator: types.BinaryOpSub, LeftScalar: scalar(100)},
+			expected: []string{
+				`60 {app="loki", env="prod"} 90`,
+				`60 {app="loki", env="dev"} 80`,
+				`120 {app="loki", env="prod"} 70`,
+			},
+		},
+		{
+			name: "scalar on the right side",
+			join: &physical.Join{Operator: types.BinaryOpDiv, RightScalar: scalar(10)},
+			expected: []string{
+				`60 {app="loki", env="prod"} 1`,
+				`60 {app="loki", env="dev"} 2`,
+				`120 {app="loki", env="prod"} 3`,
+			},
+		},
+		{
+			name: "comparison filters rows and keeps the vector value",
+			join: &physical.Join{Operator: types.BinaryOpLt, LeftScalar: scalar(15)},
+			expected: []string{
+				`60 {app="loki", env="dev"} 20`,
+				`120 {app="loki", env="prod"} 30`,
+			},
+		},
+		{
+			name: "comparison with bool",
+			join: &physical.Join{Operator: types.BinaryOpGte, ReturnBool: true, RightScalar: scalar(20)},
+			expected: []string{
+				`60 {app="loki", env="prod"} 0`,
+				`60 {app="loki", env="dev"} 1`,
+				`120 {app="loki", env="prod"} 1`,
+			},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			pipeline, err := NewJoinPipeline(tt.join, []Pipeline{newJoinInput(t, input...)}, expressionEvaluator{})
+			require.NoError(t, err)
+			defer pipeline.Close()
+
+			require.Equal(t, tt.expected, joinResults(t, pipeline))
+		})
+	}
+}
@@ -0,0 +1,29 @@
+package executor
+
+import (
+	"github.com/grafana/loki/v3/pkg/engine/planner/physical"
+	"github.com/grafana/loki/v3/pkg/logql/log"
+)
+
+// NewLabelProjectionPipeline returns a pipeline that keeps or drops the label,
+// metadata and parsed columns of each row of its input. The values of removed
+// columns are set to NULL.
+//
+// The projection reuses the keep and drop stages of the old engine, so the
+// __error__ and __error_details__ columns are handled the same way.
+func NewLabelProjectionPipeline(projection *physical.LabelProjection, input Pipeline) *GenericPipeline {
+	matchers := make([]log.NamedLabelMatcher, 0, len(projection.Labels))
+	for _, m := range projection.Labels {
+		if m.Matcher != nil {
+			matchers = append(matchers, log.NewNamedLabelMatcher(m.Matcher, ""))
+			continue
+		}
+		matchers = append(matchers, log.NewNamedLabelMatcher(nil, m.Name))
+	}
+
+	var stage log.Stage = log.NewDropLabels(matchers)
+	if projection.Keep {
+		stage = log.NewKeepLabels(matchers)
+	}
+	return newStagePipeline(newStageProcessor(stage, false), input)
+}
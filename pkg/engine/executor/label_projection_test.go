@@ -0,0 +1,79 @@
+package executor
+
+import (
+	"testing"
+
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/loki/v3/pkg/engine/internal/types"
+	"github.com/grafana/loki/v3/pkg/engine/planner/physical"
+)
+
+func labelProjectionStage(projection *physical.LabelProjection) func(Pipeline) (Pipeline, error) {
+	return func(input Pipeline) (Pipeline, error) { return NewLabelProjectionPipeline(projection, input), nil }
+}
+
+func TestLabelProjectionPipeline(t *testing.T) {
+	rows := []stageTestRow{
+		{line: `{"level":"error","status":500}`, traceID: "abc"},
+		{line: `{"level":"info","status":200}`},
+		{line: `not json`},
+	}
+
+	for _, tt := range []struct {
+		name   string
+		query  string
+		stages []func(Pipeline) (Pipeline, error)
+	}{
+		{
+			name:  "drop by name",
+			query: `{app="loki"} | json | drop app, trace_id, status`,
+			stages: []func(Pipeline) (Pipeline, error){
+				parseStage(&physical.Parse{Parser: types.ParserTypeJSON}),
+				labelProjectionStage(&physical.LabelProjection{Labels: []physical.LabelMatcher{
+					{Name: "app"}, {Name: "trace_id"}, {Name: "status"},
+				}}),
+			},
+		},
+		{
+			name:  "drop by matcher",
+			query: `{app="loki"} | json | drop level="error", __error__="JSONParserErr"`,
+			stages: []func(Pipeline) (Pipeline, error){
+				parseStage(&physical.Parse{Parser: types.ParserTypeJSON}),
+				labelProjectionStage(&physical.LabelProjection{Labels: []physical.LabelMatcher{
+					{Name: "level", Matcher: labels.MustNewMatcher(labels.MatchEqual, "level", "error")},
+					{Name: "__error__", Matcher: labels.MustNewMatcher(labels.MatchEqual, "__error__", "JSONParserErr")},
+				}}),
+			},
+		},
+		{
+			name:  "keep by name and matcher",
+			query: `{app="loki"} | json | keep trace_id, status=~"5.."`,
+			stages: []func(Pipeline) (Pipeline, error){
+				parseStage(&physical.Parse{Parser: types.ParserTypeJSON}),
+				labelProjectionStage(&physical.LabelProjection{Keep: true, Labels: []physical.LabelMatcher{
+					{Name: "trace_id"},
+					{Name: "status", Matcher: labels.MustNewMatcher(labels.MatchRegexp, "status", "5..")},
+				}}),
+			},
+		},
+		{
+			name:  "keep before line_format",
+			query: `{app="loki"} | json | keep level | line_format "{{.app}} {{.level}}"`,
+			stages: []func(Pipeline) (Pipeline, error){
+				parseStage(&physical.Parse{Parser: types.ParserTypeJSON}),
+				labelProjectionStage(&physical.LabelProjection{Keep: true, Labels: []physical.LabelMatcher{{Name: "level"}}}),
+				lineFormatStage(&physical.LineFormat{Template: `{{.app}} {{.level}}`}),
+			},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			input := newStageInput("loki", rows...)
+			defer input.Release()
+
+			expected := oldEngineResults(t, tt.query, "loki", rows...)
+			require.Equal(t, expected, runStagePipelines(t, input, tt.stages...))
+		})
+	}
+}
@@ -0,0 +1,175 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+
+	"github.com/apache/arrow-go/v18/arrow"
+	"github.com/apache/arrow-go/v18/arrow/array"
+
+	"github.com/grafana/loki/v3/pkg/engine/internal/datatype"
+	"github.com/grafana/loki/v3/pkg/engine/internal/types"
+	"github.com/grafana/loki/v3/pkg/engine/planner/physical"
+)
+
+// NewLabelReplacePipeline returns a pipeline that applies the label_replace
+// function to each row of its input.
+func NewLabelReplacePipeline(replace *physical.LabelReplace, input Pipeline) (*GenericPipeline, error) {
+	// The regular expression must match the whole value, as in the old engine.
+	re, err := regexp.Compile("^(?:" + replace.Regex + ")$")
+	if err != nil {
+		return nil, fmt.Errorf("invalid regex %q for label_replace: %w", replace.Regex, err)
+	}
+
+	r := &labelReplacer{
+		re:          re,
+		dst:         replace.Dst,
+		src:         replace.Src,
+		replacement: replace.Replacement,
+		cache:       make(map[string]labelReplacement),
+	}
+
+	return newGenericPipeline(Local, func(ctx context.Context, inputs []Pipeline) state {
+		// Pull the next item from the input pipeline
+		input := inputs[0]
+		err := input.Read(ctx)
+		if err != nil {
+			return failureState(err)
+		}
+
+		batch, err := input.Value()
+		if err != nil {
+			return failureState(err)
+		}
+
+		// The input batch is released by the input pipeline on its next read.
+		return successState(r.process(batch))
+	}, input), nil
+}
+
+// labelReplacer sets the dst column of each row whose src column matches re
+// to the expansion of the replacement template.
+type labelReplacer struct {
+	re          *regexp.Regexp
+	dst, src    string
+	replacement string
+
+	// cache holds the replacement of each src value seen so far.
+	cache map[string]labelReplacement
+}
+
+type labelReplacement struct {
+	value   string
+	matched bool
+}
+
+func (r *labelReplacer) replace(src string) labelReplacement {
+	if res, ok := r.cache[src]; ok {
+		return res
+	}
+
+	var res labelReplacement
+	if indexes := r.re.FindStringSubmatchIndex(src); indexes != nil {
+		res = labelReplacement{
+			value:   string(r.re.ExpandString(nil, r.replacement, src, indexes)),
+			matched: true,
+		}
+	}
+	r.cache[src] = res
+	return res
+}
+
+// process returns a record with the replaced dst column. If the input has
+// multiple label columns named dst, the first of them holds the result and
+// the others are removed. If it has none, a column of type
+// [types.ColumnTypeParsed] is added.
+func (r *labelReplacer) process(batch arrow.Record) arrow.Record {
+	var (
+		srcIdx  = -1
+		dstIdxs []int
+	)
+	for i, field := range batch.Schema().Fields() {
+		if !isLabelField(field) {
+			continue
+		}
+		if field.Name == r.src && srcIdx < 0 {
+			srcIdx = i
+		}
+		if field.Name == r.dst {
+			dstIdxs = append(dstIdxs, i)
+		}
+	}
+
+	numRows := int(batch.NumRows())
+	values := make([]string, numRows)
+	valid := make([]bool, numRows)
+	for row := range numRows {
+		var src string
+		if srcIdx >= 0 {
+			src = stringValue(batch, srcIdx, row)
+		}
+
+		res := r.replace(src)
+		if !res.matched {
+			// If there is no match, the existing value is kept.
+			for _, idx := range dstIdxs {
+				if res.value = stringValue(batch, idx, row); res.value != "" {
+					break
+				}
+			}
+		}
+		values[row], valid[row] = res.value, res.value != ""
+	}
+
+	dstField := arrow.Field{
+		Name:     r.dst,
+		Type:     datatype.Arrow.String,
+		Nullable: true,
+		Metadata: datatype.ColumnMetadata(types.ColumnTypeParsed, datatype.Loki.String),
+	}
+	if len(dstIdxs) > 0 {
+		dstField = batch.Schema().Field(dstIdxs[0])
+	}
+	dstArray := newStringArray(values, valid)
+	defer dstArray.Release()
+
+	fields := make([]arrow.Field, 0, int(batch.NumCols())+1)
+	arrays := make([]arrow.Array, 0, cap(fields))
+	for i, field := range batch.Schema().Fields() {
+		switch {
+		case len(dstIdxs) > 0 && i == dstIdxs[0]:
+			fields = append(fields, dstField)
+			arrays = append(arrays, dstArray)
+		case field.Name == r.dst && isLabelField(field):
+			continue
+		default:
+			fields = append(fields, field)
+			arrays = append(arrays, batch.Column(i))
+		}
+	}
+	if len(dstIdxs) == 0 {
+		fields = append(fields, dstField)
+		arrays = append(arrays, dstArray)
+	}
+
+	return array.NewRecord(arrow.NewSchema(fields, nil), arrays, int64(numRows))
+}
+
+// isLabelField returns true if the field holds the values of a label of a
+// series. Label columns are all string columns that are neither builtin nor
+// generated.
+func isLabelField(field arrow.Field) bool {
+	dt, ok := field.Metadata.GetValue(types.MetadataKeyColumnDataType)
+	if !ok || dt != datatype.Loki.String.String() {
+		return false
+	}
+
+	ct, ok := field.Metadata.GetValue(types.MetadataKeyColumnType)
+	if !ok {
+		return false
+	}
+
+	columnType := types.ColumnTypeFromString(ct)
+	return columnType != types.ColumnTypeBuiltin && columnType != types.ColumnTypeGenerated
+}
@@ -0,0 +1,82 @@
+package executor
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/loki/v3/pkg/engine/planner/physical"
+)
+
+func TestLabelReplacePipeline(t *testing.T) {
+	for _, tt := range []struct {
+		name     string
+		replace  *physical.LabelReplace
+		expected []string
+	}{
+		{
+			name:    "adds column with capture group of source column",
+			replace: &physical.LabelReplace{Dst: "service", Src: "app", Regex: "(.*)-.*", Replacement: "$1"},
+			expected: []string{
+				`60 {app="loki-read", env="prod", service="loki"} 1`,
+				`60 {app="mimir", env="dev"} 2`,
+				`120 {app="loki-write", env="prod", service="loki"} 3`,
+			},
+		},
+		{
+			name:    "replaces existing column",
+			replace: &physical.LabelReplace{Dst: "env", Src: "app", Regex: "loki-(.*)", Replacement: "${1}"},
+			expected: []string{
+				`60 {app="loki-read", env="read"} 1`,
+				`60 {app="mimir", env="dev"} 2`,
+				`120 {app="loki-write", env="write"} 3`,
+			},
+		},
+		{
+			name:    "empty replacement removes column",
+			replace: &physical.LabelReplace{Dst: "env", Src: "app", Regex: "loki-.*", Replacement: ""},
+			expected: []string{
+				`60 {app="loki-read"} 1`,
+				`60 {app="mimir", env="dev"} 2`,
+				`120 {app="loki-write"} 3`,
+			},
+		},
+		{
+			name:    "regex must match whole value",
+			replace: &physical.LabelReplace{Dst: "service", Src: "app", Regex: "loki", Replacement: "loki"},
+			expected: []string{
+				`60 {app="loki-read", env="prod"} 1`,
+				`60 {app="mimir", env="dev"} 2`,
+				`120 {app="loki-write", env="prod"} 3`,
+			},
+		},
+		{
+			name:    "missing source column matches empty value",
+			replace: &physical.LabelReplace{Dst: "service", Src: "missing", Regex: "", Replacement: "unknown"},
+			expected: []string{
+				`60 {app="loki-read", env="prod", service="unknown"} 1`,
+				`60 {app="mimir", env="dev", service="unknown"} 2`,
+				`120 {app="loki-write", env="prod", service="unknown"} 3`,
+			},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			input := newJoinInput(t,
+				"t1,1,prod,loki-read,",
+				"t1,2,dev,mimir,",
+				"t2,3,prod,loki-write,",
+			)
+
+			pipeline, err := NewLabelReplacePipeline(tt.replace, input)
+			require.NoError(t, err)
+			defer pipeline.Close()
+
+			require.Equal(t, tt.expected, joinResults(t, pipeline))
+		})
+	}
+}
+
+func TestLabelReplacePipeline_InvalidRegex(t *testing.T) {
+	_, err := NewLabelReplacePipeline(&physical.LabelReplace{Dst: "dst", Src: "src", Regex: "("}, emptyPipeline())
+	require.ErrorContains(t, err, "invalid regex")
+}
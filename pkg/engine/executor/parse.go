@@ -0,0 +1,57 @@
+package executor
+
+import (
+	"fmt"
+
+	"github.com/grafana/loki/v3/pkg/engine/internal/types"
+	"github.com/grafana/loki/v3/pkg/engine/planner/physical"
+	"github.com/grafana/loki/v3/pkg/logql/log"
+)
+
+// NewParsePipeline returns a pipeline that extracts columns from the log line
+// of each row of its input. The extracted columns are returned as columns of
+// type [types.ColumnTypeParsed], in addition to the columns of the input.
+//
+// Parsing reuses the parser stages of the old engine, so that the extracted
+// columns, including the __error__ and __error_details__ columns, are the same
+// as the parsed labels of the old engine.
+func NewParsePipeline(parse *physical.Parse, input Pipeline) (*GenericPipeline, error) {
+	stage, err := newParserStage(parse)
+	if err != nil {
+		return nil, err
+	}
+
+	// The unpack parser replaces the log line with the packed entry.
+	p := newStageProcessor(stage, parse.Parser == types.ParserTypeUnpack)
+	return newStagePipeline(p, input), nil
+}
+
+// newParserStage returns the stage of the old engine that implements the
+// parser of the [physical.Parse] node.
+func newParserStage(parse *physical.Parse) (log.Stage, error) {
+	expressions := make([]log.LabelExtractionExpr, 0, len(parse.Expressions))
+	for _, expr := range parse.Expressions {
+		expressions = append(expressions, log.NewLabelExtractionExpr(expr.Name, expr.Path))
+	}
+
+	switch parse.Parser {
+	case types.ParserTypeJSON:
+		if len(expressions) > 0 {
+			return log.NewJSONExpressionParser(expressions)
+		}
+		return log.NewJSONParser(false), nil
+	case types.ParserTypeLogfmt:
+		if len(expressions) > 0 {
+			return log.NewLogfmtExpressionParser(expressions, parse.Strict)
+		}
+		return log.NewLogfmtParser(parse.Strict, parse.KeepEmpty), nil
+	case types.ParserTypeRegexp:
+		return log.NewRegexpParser(parse.Pattern)
+	case types.ParserTypePattern:
+		return log.NewPatternParser(parse.Pattern)
+	case types.ParserTypeUnpack:
+		return log.NewUnpackParser(), nil
+	default:
+		return nil, fmt.Errorf("unsupported parser %s", parse.Parser)
+	}
+}
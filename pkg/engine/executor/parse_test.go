@@ -0,0 +1,230 @@
+package executor
+
+import (
+	"context"
+	"testing"
+
+	"github.com/apache/arrow-go/v18/arrow"
+	"github.com/apache/arrow-go/v18/arrow/array"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/loki/v3/pkg/engine/internal/datatype"
+	"github.com/grafana/loki/v3/pkg/engine/internal/types"
+	"github.com/grafana/loki/v3/pkg/engine/planner/physical"
+)
+
+// newParseInput returns a record with a stream label column "app" and the
+// message column holding the given lines.
+func newParseInput(app string, lines ...string) arrow.Record {
+	fields := []arrow.Field{
+		{Name: "app", Type: datatype.Arrow.String, Nullable: true, Metadata: datatype.ColumnMetadata(types.ColumnTypeLabel, datatype.Loki.String)},
+		{Name: types.ColumnNameBuiltinMessage, Type: datatype.Arrow.String, Metadata: datatype.ColumnMetadataBuiltinMessage},
+	}
+
+	apps := make([]string, len(lines))
+	for i := range apps {
+		apps[i] = app
+	}
+
+	arrays := []arrow.Array{newStringArray(apps, nil), newStringArray(lines, nil)}
+	defer func() {
+		for _, arr := range arrays {
+			arr.Release()
+		}
+	}()
+	return array.NewRecord(arrow.NewSchema(fields, nil), arrays, int64(len(lines)))
+}
+
+// parsedRows returns the values of the parsed columns of each row of the
+// record. Columns without value are omitted.
+func parsedRows(t *testing.T, record arrow.Record) []map[string]string {
+	t.Helper()
+
+	rows := make([]map[string]string, record.NumRows())
+	for i := range rows {
+		rows[i] = map[string]string{}
+	}
+
+	for i, field := range record.Schema().Fields() {
+		ct, _ := field.Metadata.GetValue(types.MetadataKeyColumnType)
+		if ct != types.ColumnTypeParsed.String() {
+			continue
+		}
+
+		arr := record.Column(i).(*array.String)
+		for row := range rows {
+			if arr.IsValid(row) && arr.Value(row) != "" {
+				rows[row][field.Name] = arr.Value(row)
+			}
+		}
+	}
+	return rows
+}
+
+func TestParsePipeline(t *testing.T) {
+	for _, tt := range []struct {
+		name     string
+		parse    *physical.Parse
+		lines    []string
+		expected []map[string]string
+	}{
+		{
+			name:  "json",
+			parse: &physical.Parse{Parser: types.ParserTypeJSON},
+			lines: []string{
+				`{"level":"error","app":"loki","request":{"status":500}}`,
+				`{"level":"info"}`,
+				`not json`,
+			},
+			expected: []map[string]string{
+				{"level": "error", "app_extracted": "loki", "request_status": "500"},
+				{"level": "info"},
+				{"__error__": "JSONParserErr", "__error_details__": "Value looks like object, but can't find closing '}' symbol"},
+			},
+		},
+		{
+			name: "json with expressions",
+			parse: &physical.Parse{
+				Parser:      types.ParserTypeJSON,
+				Expressions: []physical.ParseExpression{{Name: "status", Path: "request.status"}},
+			},
+			lines: []string{
+				`{"level":"error","request":{"status":500}}`,
+			},
+			expected: []map[string]string{
+				{"status": "500"},
+			},
+		},
+		{
+			name:  "logfmt",
+			parse: &physical.Parse{Parser: types.ParserTypeLogfmt},
+			lines: []string{
+				`level=error msg="request failed" duration=1s`,
+				`level=info empty=`,
+			},
+			expected: []map[string]string{
+				{"level": "error", "msg": "request failed", "duration": "1s"},
+				{"level": "info"},
+			},
+		},
+		{
+			name:  "regexp",
+			parse: &physical.Parse{Parser: types.ParserTypeRegexp, Pattern: `status=(?P<status>\d+)`},
+			lines: []string{
+				`GET /api status=200`,
+				`GET /api`,
+			},
+			expected: []map[string]string{
+				{"status": "200"},
+				{},
+			},
+		},
+		{
+			name:  "pattern",
+			parse: &physical.Parse{Parser: types.ParserTypePattern, Pattern: `<method> <path> <_>`},
+			lines: []string{
+				`GET /api status=200`,
+			},
+			expected: []map[string]string{
+				{"method": "GET", "path": "/api"},
+			},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			input := newParseInput("loki", tt.lines...)
+			defer input.Release()
+
+			pipeline, err := NewParsePipeline(tt.parse, NewBufferedPipeline(input))
+			require.NoError(t, err)
+			defer pipeline.Close()
+
+			require.NoError(t, pipeline.Read(context.Background()))
+			record, err := pipeline.Value()
+			require.NoError(t, err)
+
+			require.Equal(t, tt.expected, parsedRows(t, record))
+		})
+	}
+}
+
+func TestParsePipeline_Unpack(t *testing.T) {
+	input := newParseInput("loki",
+		`{"_entry":"original line","level":"error"}`,
+		`not packed`,
+	)
+	defer input.Release()
+
+	pipeline, err := NewParsePipeline(&physical.Parse{Parser: types.ParserTypeUnpack}, NewBufferedPipeline(input))
+	require.NoError(t, err)
+	defer pipeline.Close()
+
+	require.NoError(t, pipeline.Read(context.Background()))
+	record, err := pipeline.Value()
+	require.NoError(t, err)
+
+	require.Equal(t, []map[string]string{
+		{"level": "error"},
+		{"__error__": "JSONParserErr", "__error_details__": "expecting json object(6), but it is not"},
+	}, parsedRows(t, record))
+
+	// The unpacked log line replaces the message column.
+	idx := record.Schema().FieldIndices(types.ColumnNameBuiltinMessage)[0]
+	messages := record.Column(idx).(*array.String)
+	require.Equal(t, "original line", messages.Value(0))
+	require.Equal(t, "not packed", messages.Value(1))
+}
+
+func TestParsePipeline_ChainedParsers(t *testing.T) {
+	input := newParseInput("loki",
+		`level=error msg="{\"status\":500}"`,
+	)
+	defer input.Release()
+
+	logfmt, err := NewParsePipeline(&physical.Parse{Parser: types.ParserTypeLogfmt}, NewBufferedPipeline(input))
+	require.NoError(t, err)
+
+	// The json parser fails on the log line, but the columns of the previous
+	// parser are kept.
+	json, err := NewParsePipeline(&physical.Parse{Parser: types.ParserTypeJSON}, logfmt)
+	require.NoError(t, err)
+	defer json.Close()
+
+	require.NoError(t, json.Read(context.Background()))
+	record, err := json.Value()
+	require.NoError(t, err)
+
+	rows := parsedRows(t, record)
+	require.Len(t, rows, 1)
+	require.Equal(t, "error", rows[0]["level"])
+	require.Equal(t, `{"status":500}`, rows[0]["msg"])
+	require.Equal(t, "JSONParserErr", rows[0]["__error__"])
+}
+
+func TestParsePipeline_ErrorFilter(t *testing.T) {
+	input := newParseInput("loki",
+		`{"level":"error"}`,
+		`not json`,
+	)
+	defer input.Release()
+
+	pipeline, err := NewParsePipeline(&physical.Parse{Parser: types.ParserTypeJSON}, NewBufferedPipeline(input))
+	require.NoError(t, err)
+
+	// __error__="" must match rows that were parsed successfully.
+	filter := NewFilterPipeline(&physical.Filter{
+		Predicates: []physical.Expression{
+			&physical.BinaryExpr{
+				Left:  &physical.ColumnExpr{Ref: types.ColumnRef{Column: "__error__", Type: types.ColumnTypeAmbiguous}},
+				Right: physical.NewLiteral(""),
+				Op:    types.BinaryOpEq,
+			},
+		},
+	}, pipeline, expressionEvaluator{})
+	defer filter.Close()
+
+	require.NoError(t, filter.Read(context.Background()))
+	record, err := filter.Value()
+	require.NoError(t, err)
+
+	require.Equal(t, []map[string]string{{"level": "error"}}, parsedRows(t, record))
+}
@@ -4,54 +4,111 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"math"
+	"slices"
+	"strconv"
 	"strings"
 	"time"
+	"unsafe"
 
 	"github.com/apache/arrow-go/v18/arrow"
 	"github.com/apache/arrow-go/v18/arrow/array"
 	"github.com/apache/arrow-go/v18/arrow/memory"
 	"github.com/cespare/xxhash/v2"
+	"github.com/dustin/go-humanize"
+	"github.com/prometheus/prometheus/model/labels"
 
 	"github.com/grafana/loki/v3/pkg/engine/internal/datatype"
 	"github.com/grafana/loki/v3/pkg/engine/internal/types"
 	"github.com/grafana/loki/v3/pkg/engine/planner/physical"
+	"github.com/grafana/loki/v3/pkg/logqlmodel"
 )
 
 type rangeAggregationOptions struct {
 	partitionBy []physical.ColumnExpression
+	operation   types.RangeAggregationType
+	parameter   float64          // parameter of the aggregation, e.g. the quantile
+	unwrap      *physical.Unwrap // column from which sample values are extracted
 
 	// start and end timestamps are equal for instant queries.
 	startTs       time.Time     // start timestamp of the query
 	endTs         time.Time     // end timestamp of the query
 	rangeInterval time.Duration // range interval
 	step          time.Duration // step used for range queries
+	offset        time.Duration // offset by which the windows are shifted back in time
+
+	budget *memoryBudget // memory budget of the query, nil if spilling is disabled
+}
+
+// window is a time interval where start is exclusive and end is inclusive.
+// This matches the semantics of the range vector iterators of the old engine.
+type window struct {
+	start, end time.Time
+}
+
+// Contains returns whether the timestamp t is within the bounds of the window.
+func (w window) Contains(t time.Time) bool {
+	return t.After(w.start) && !t.After(w.end)
 }
 
 // RangeAggregationPipeline is a pipeline that performs aggregations over a time window.
 //
 // 1. It reads from the input pipelines
 // 2. Partitions the data by the specified columns
-// 3. Applies the aggregation function on each partition
+// 3. Applies the aggregation function on each partition and step
 //
-// Current version only supports counting for instant queries.
+// Each step of the query produces its own window with the length of the range interval.
+// Instant queries produce exactly one window that ends at the end timestamp of the query.
+//
+// When the aggregations of the query exceed their memory budget, the state of
+// the partitions is spilled to disk. The spilled state is merged one partition
+// at a time once all inputs are read, and each partition is returned as its
+// own record.
 type RangeAggregationPipeline struct {
 	state  state
 	inputs []Pipeline
 
+	windows    []window
 	aggregator *partitionAggregator
 	evaluator  expressionEvaluator // used to evaluate column expressions
 	opts       rangeAggregationOptions
+
+	spiller   *spiller // holds the spilled state of the partitions
+	partition int      // next spilled partition to return
 }
 
 func NewRangeAggregationPipeline(inputs []Pipeline, evaluator expressionEvaluator, opts rangeAggregationOptions) (*RangeAggregationPipeline, error) {
+	if opts.step < 0 {
+		return nil, fmt.Errorf("invalid step %s for range aggregation", opts.step)
+	}
+
+	// The windows of an aggregation with offset are shifted back in time, but
+	// the results are reported at the timestamps of the steps.
+	windows := newWindows(opts.startTs.Add(-opts.offset), opts.endTs.Add(-opts.offset), opts.step, opts.rangeInterval)
 	return &RangeAggregationPipeline{
 		inputs:     inputs,
 		evaluator:  evaluator,
-		aggregator: newPartitionAggregator(),
+		windows:    windows,
+		aggregator: newPartitionAggregator(opts.operation, len(windows), opts.partitionBy, opts.budget),
 		opts:       opts,
+		spiller:    newSpiller(opts.budget),
 	}, nil
 }
 
+// newWindows returns the windows for each step between start and end (both inclusive).
+// Instant queries (step = 0) return a single window that ends at end.
+func newWindows(start, end time.Time, step, rangeInterval time.Duration) []window {
+	if step == 0 {
+		return []window{{start: end.Add(-rangeInterval), end: end}}
+	}
+
+	windows := make([]window, 0, end.Sub(start)/step+1)
+	for ts := start; !ts.After(end); ts = ts.Add(step) {
+		windows = append(windows, window{start: ts.Add(-rangeInterval), end: ts})
+	}
+	return windows
+}
+
 // Read reads the next value into its state.
 // It returns an error if reading fails or when the pipeline is exhausted. In this case, the function returns EOF.
 // The implementation must retain the returned error in its state and return it with subsequent Value() calls.
@@ -75,12 +132,14 @@ func (r *RangeAggregationPipeline) Read(ctx context.Context) error {
 }
 
 // TODOs:
-// - Support implicit partitioning by all labels when partitionBy is empty
 // - Use columnar access pattern. Current approach is row-based which does not benefit from the storage format.
 // - Add toggle to return partial results on Read() call instead of returning only after exhausing all inputs.
 func (r *RangeAggregationPipeline) read(ctx context.Context) (arrow.Record, error) {
+	if r.spiller.Spilled() {
+		return r.readSpilled()
+	}
+
 	var (
-		isTSInRange  func(t time.Time) bool
 		tsColumnExpr = &physical.ColumnExpr{
 			Ref: types.ColumnRef{
 				Column: types.ColumnNameBuiltinTimestamp,
@@ -89,18 +148,9 @@ func (r *RangeAggregationPipeline) read(ctx context.Context) (arrow.Record, erro
 		} // timestamp column expression
 
 		// reused on each row read
-		labelValues = make([]string, len(r.opts.partitionBy))
+		labelValues []string
 	)
 
-	{
-		evalTs := r.opts.endTs
-		earliestTs := r.opts.endTs.Add(-r.opts.rangeInterval)
-		isTSInRange = func(t time.Time) bool {
-			// Aggregate entries that belong in [earliestTs, evalTs)
-			return t.Compare(earliestTs) >= 0 && t.Compare(evalTs) < 0
-		}
-	}
-
 	r.aggregator.Reset() // reset before reading new inputs
 	inputsExhausted := false
 	for !inputsExhausted {
@@ -119,48 +169,114 @@ func (r *RangeAggregationPipeline) read(ctx context.Context) (arrow.Record, erro
 			record, _ := input.Value()
 
 			// extract all the columns that are used for partitioning
-			arrays := make([]*array.String, 0, len(r.opts.partitionBy))
-			for _, columnExpr := range r.opts.partitionBy {
-				vec, err := r.evaluator.eval(columnExpr, record)
-				if err != nil {
-					return nil, err
-				}
-
-				if vec.Type() != datatype.Loki.String {
-					return nil, fmt.Errorf("unsupported datatype for partitioning %s", vec.Type())
-				}
-
-				arrays = append(arrays, vec.ToArray().(*array.String))
+			arrays, err := r.partitionArrays(record)
+			if err != nil {
+				return nil, err
 			}
 
-			// extract timestamp column to check if the entry is in range
+			// extract timestamp column to find the windows the entry belongs to
 			vec, err := r.evaluator.eval(tsColumnExpr, record)
 			if err != nil {
 				return nil, err
 			}
 			tsCol := vec.ToArray().(*array.Timestamp)
 
+			values, err := r.sampleValues(record)
+			if err != nil {
+				return nil, err
+			}
+
+			errorCol := errorArray(record)
+
+			if n := r.aggregator.NumOfColumns(); len(labelValues) < n {
+				labelValues = make([]string, n)
+			}
+
 			for row := range int(record.NumRows()) {
-				if !isTSInRange(tsCol.Value(row).ToTime(arrow.Nanosecond)) {
+				ts := tsCol.Value(row).ToTime(arrow.Nanosecond)
+				lo, hi := r.windowsForTimestamp(ts)
+				if lo >= hi {
+					continue
+				}
+
+				// Entries that failed in a previous stage are dropped by a __error__="" post filter.
+				if r.opts.unwrap != nil && r.opts.unwrap.DropErrors && errorCol != nil && errorCol.Value(row) != "" {
 					continue
 				}
 
-				// reset label values and hash for each row
+				// reset label values for each row
 				clear(labelValues)
-				for col, arr := range arrays {
-					labelValues[col] = arr.Value(row)
+				for _, arr := range arrays {
+					if arr.array.IsValid(row) {
+						labelValues[arr.index] = arr.array.Value(row)
+					}
 				}
-				r.aggregator.Add(labelValues)
+				if err := r.aggregator.PipelineError(labelValues); err != nil {
+					return nil, err
+				}
+
+				value, ok, err := values(row)
+				var convErr *conversionError
+				if errors.As(err, &convErr) {
+					// Like in the old engine, samples whose value cannot be
+					// converted get the __error__ label, which fails the query.
+					labelValues = r.aggregator.WithError(labelValues, errSampleExtraction, convErr.err.Error())
+					return nil, r.aggregator.PipelineError(labelValues)
+				} else if err != nil {
+					return nil, err
+				} else if !ok {
+					continue
+				}
+				r.aggregator.Add(lo, hi, labelValues, ts, value)
 			}
+
+			if r.opts.budget.exceeded() {
+				if err := r.aggregator.Spill(r.spiller); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+
+	if r.spiller.Spilled() {
+		// spill the remaining state so that all partitions are merged from disk
+		if err := r.aggregator.Spill(r.spiller); err != nil {
+			return nil, err
 		}
+		return r.readSpilled()
 	}
 
 	if r.aggregator.NumOfPartitions() == 0 {
 		return nil, EOF // no values to aggregate & reached EOF
 	}
 
-	// TODO: schema is same for each read call when partitionBy is defined, we can create it once and reuse.
-	fields := make([]arrow.Field, 0, len(r.opts.partitionBy)+2)
+	return r.buildRecord(), nil
+}
+
+// readSpilled merges the state of the next non-empty spilled partition and
+// returns its results.
+func (r *RangeAggregationPipeline) readSpilled() (arrow.Record, error) {
+	for r.partition < spillPartitions {
+		partition := r.partition
+		r.partition++
+
+		r.aggregator.Reset()
+		if err := r.spiller.Read(partition, r.aggregator.Merge); err != nil {
+			return nil, err
+		}
+		if r.aggregator.NumOfPartitions() > 0 {
+			return r.buildRecord(), nil
+		}
+	}
+
+	r.aggregator.Reset()
+	return nil, EOF
+}
+
+// buildRecord returns a record with one row per step and partition of the
+// aggregator.
+func (r *RangeAggregationPipeline) buildRecord() arrow.Record {
+	fields := make([]arrow.Field, 0, r.aggregator.NumOfColumns()+2)
 	fields = append(fields,
 		arrow.Field{
 			Name:     types.ColumnNameBuiltinTimestamp,
@@ -170,23 +286,18 @@ func (r *RangeAggregationPipeline) read(ctx context.Context) (arrow.Record, erro
 		},
 		arrow.Field{
 			Name:     types.ColumnNameGeneratedValue,
-			Type:     datatype.Arrow.Integer,
+			Type:     datatype.Arrow.Float,
 			Nullable: false,
-			Metadata: datatype.ColumnMetadata(types.ColumnTypeGenerated, datatype.Loki.Integer),
+			Metadata: datatype.ColumnMetadata(types.ColumnTypeGenerated, datatype.Loki.Float),
 		},
 	)
 
-	for _, column := range r.opts.partitionBy {
-		columnExpr, ok := column.(*physical.ColumnExpr)
-		if !ok {
-			panic(fmt.Sprintf("invalid column expression type %T", column))
-		}
-
+	for _, column := range r.aggregator.columns {
 		fields = append(fields, arrow.Field{
-			Name:     columnExpr.Ref.Column,
+			Name:     column.Column,
 			Type:     datatype.Arrow.String,
 			Nullable: true,
-			Metadata: datatype.ColumnMetadata(columnExpr.Ref.Type, datatype.Loki.String),
+			Metadata: datatype.ColumnMetadata(column.Type, datatype.Loki.String),
 		})
 	}
 
@@ -194,22 +305,237 @@ func (r *RangeAggregationPipeline) read(ctx context.Context) (arrow.Record, erro
 	rb := array.NewRecordBuilder(memory.NewGoAllocator(), schema)
 	defer rb.Release()
 
-	ts, _ := arrow.TimestampFromTime(r.opts.endTs, arrow.Nanosecond)
-	for _, entry := range r.aggregator.entries {
-		rb.Field(0).(*array.TimestampBuilder).Append(ts)
-		rb.Field(1).(*array.Int64Builder).Append(entry.count)
-
-		for col, val := range entry.labelValues {
-			builder := rb.Field(col + 2) // offset by 2 as the first 2 fields are timestamp and value
-			if val == "" {
-				builder.(*array.StringBuilder).AppendNull()
-			} else {
-				builder.(*array.StringBuilder).Append(val)
+	// emit one row per step and partition, in order of the steps.
+	for i, w := range r.windows {
+		ts, _ := arrow.TimestampFromTime(w.end.Add(r.opts.offset), arrow.Nanosecond)
+		for _, entry := range r.aggregator.entries {
+			state := &entry.states[i]
+			if state.count == 0 {
+				// partition has no entries in the window of this step
+				continue
+			}
+
+			rb.Field(0).(*array.TimestampBuilder).Append(ts)
+			rb.Field(1).(*array.Float64Builder).Append(state.result(r.opts.operation, r.opts.parameter, r.opts.rangeInterval))
+
+			for col := range r.aggregator.columns {
+				builder := rb.Field(col + 2).(*array.StringBuilder) // offset by 2 as the first 2 fields are timestamp and value
+
+				// entries created before a column was discovered have less label values
+				if col >= len(entry.labelValues) || entry.labelValues[col] == "" {
+					builder.AppendNull()
+				} else {
+					builder.Append(entry.labelValues[col])
+				}
+			}
+		}
+	}
+
+	return rb.NewRecord()
+}
+
+// partitionArray is a string column of a record that is used for partitioning,
+// along with the index of the partition column it maps to.
+type partitionArray struct {
+	index int
+	array *array.String
+}
+
+// partitionArrays returns the columns of the record that are used for
+// partitioning. If no partition columns are defined, all label, metadata and
+// parsed columns of the record are used, except for the unwrapped column.
+func (r *RangeAggregationPipeline) partitionArrays(record arrow.Record) ([]partitionArray, error) {
+	if len(r.opts.partitionBy) > 0 {
+		arrays := make([]partitionArray, 0, len(r.opts.partitionBy))
+		for i, columnExpr := range r.opts.partitionBy {
+			vec, err := r.evaluator.eval(columnExpr, record)
+			if err != nil {
+				return nil, err
+			}
+
+			if vec.Type() != datatype.Loki.String {
+				return nil, fmt.Errorf("unsupported datatype for partitioning %s", vec.Type())
+			}
+
+			arrays = append(arrays, partitionArray{index: i, array: vec.ToArray().(*array.String)})
+		}
+		return arrays, nil
+	}
+
+	var excluded string
+	if r.opts.unwrap != nil {
+		if columnExpr, ok := r.opts.unwrap.Column.(*physical.ColumnExpr); ok {
+			excluded = columnExpr.Ref.Column
+		}
+	}
+
+	var arrays []partitionArray
+	for i, field := range record.Schema().Fields() {
+		dt, ok := field.Metadata.GetValue(types.MetadataKeyColumnDataType)
+		if !ok || dt != datatype.Loki.String.String() || field.Name == excluded {
+			continue
+		}
+
+		ct, ok := field.Metadata.GetValue(types.MetadataKeyColumnType)
+		if !ok {
+			continue
+		}
+
+		switch columnType := types.ColumnTypeFromString(ct); columnType {
+		case types.ColumnTypeLabel, types.ColumnTypeMetadata, types.ColumnTypeParsed:
+			arr, ok := record.Column(i).(*array.String)
+			if !ok {
+				return nil, fmt.Errorf("unexpected array type %T for column %s", record.Column(i), field.Name)
+			}
+
+			index := r.aggregator.ColumnIndex(types.ColumnRef{Column: field.Name, Type: columnType})
+			arrays = append(arrays, partitionArray{index: index, array: arr})
+		}
+	}
+	return arrays, nil
+}
+
+// sampleValues returns a function that returns the sample value of a row of the record.
+// The returned function reports false for rows that need to be skipped.
+func (r *RangeAggregationPipeline) sampleValues(record arrow.Record) (func(row int) (float64, bool, error), error) {
+	switch {
+	case r.opts.unwrap != nil:
+		vec, err := r.evaluator.eval(r.opts.unwrap.Column, record)
+		if err != nil {
+			return nil, err
+		}
+
+		if vec.Type() != datatype.Loki.String {
+			return nil, fmt.Errorf("unsupported datatype for unwrap %s", vec.Type())
+		}
+
+		convert, err := conversionFunc(r.opts.unwrap.Conversion)
+		if err != nil {
+			return nil, err
+		}
+
+		arr := vec.ToArray().(*array.String)
+		return func(row int) (float64, bool, error) {
+			// Like the old engine, rows without the unwrapped label are
+			// skipped instead of failing the conversion.
+			if !arr.IsValid(row) || arr.Value(row) == "" {
+				return 0, false, nil
 			}
+
+			v, err := convert(arr.Value(row))
+			if err != nil {
+				if r.opts.unwrap.DropErrors {
+					return 0, false, nil
+				}
+				return 0, false, &conversionError{unwrap: r.opts.unwrap, err: err}
+			}
+			return v, true, nil
+		}, nil
+
+	case r.opts.operation == types.RangeAggregationTypeBytes, r.opts.operation == types.RangeAggregationTypeBytesRate:
+		vec, err := r.evaluator.eval(&physical.ColumnExpr{
+			Ref: types.ColumnRef{
+				Column: types.ColumnNameBuiltinMessage,
+				Type:   types.ColumnTypeBuiltin,
+			},
+		}, record)
+		if err != nil {
+			return nil, err
+		}
+
+		arr, ok := vec.ToArray().(*array.String)
+		if !ok {
+			return nil, fmt.Errorf("unsupported datatype for message column %s", vec.Type())
 		}
+		return func(row int) (float64, bool, error) {
+			return float64(arr.ValueLen(row)), true, nil
+		}, nil
+
+	default:
+		return func(int) (float64, bool, error) {
+			return 1, true, nil
+		}, nil
 	}
+}
+
+// errSampleExtraction is the __error__ label of samples whose unwrapped value
+// cannot be converted, the same as in the old engine.
+const errSampleExtraction = "SampleExtractionErr"
 
-	return rb.NewRecord(), nil
+// conversionError is returned for unwrapped values that cannot be converted.
+type conversionError struct {
+	unwrap *physical.Unwrap
+	err    error
+}
+
+func (e *conversionError) Error() string {
+	return fmt.Sprintf("unwrap %s: %s", e.unwrap, e.err)
+}
+
+func (e *conversionError) Unwrap() error { return e.err }
+
+// conversionFunc returns the function used to convert unwrapped values into floats.
+func conversionFunc(conversion types.ConversionType) (func(string) (float64, error), error) {
+	switch conversion {
+	case types.ConversionTypeFloat:
+		return func(v string) (float64, error) {
+			return strconv.ParseFloat(v, 64)
+		}, nil
+	case types.ConversionTypeBytes:
+		return func(v string) (float64, error) {
+			b, err := humanize.ParseBytes(v)
+			if err != nil {
+				return 0, err
+			}
+			return float64(b), nil
+		}, nil
+	case types.ConversionTypeDuration, types.ConversionTypeDurationSeconds:
+		return func(v string) (float64, error) {
+			d, err := time.ParseDuration(v)
+			if err != nil {
+				return 0, err
+			}
+			return d.Seconds(), nil
+		}, nil
+	default:
+		return nil, fmt.Errorf("unsupported conversion %s", conversion)
+	}
+}
+
+// windowsForTimestamp returns the half-open range [lo, hi) of indexes of the
+// windows that contain the timestamp t. If lo >= hi, t is not part of any window.
+func (r *RangeAggregationPipeline) windowsForTimestamp(t time.Time) (lo, hi int) {
+	if r.opts.step == 0 {
+		if r.windows[0].Contains(t) {
+			return 0, 1
+		}
+		return 0, 0
+	}
+
+	// A window with the end timestamp e contains t if e-range < t <= e,
+	// which is equivalent to t <= e < t+range.
+	var (
+		step = r.opts.step.Nanoseconds()
+		diff = t.Sub(r.opts.startTs.Add(-r.opts.offset)).Nanoseconds()
+	)
+	lo = int(ceilDiv(diff, step))
+	hi = int(floorDiv(diff+r.opts.rangeInterval.Nanoseconds()-1, step)) + 1
+
+	return max(lo, 0), min(hi, len(r.windows))
+}
+
+// floorDiv returns the largest integer less than or equal to a/b for b > 0.
+func floorDiv(a, b int64) int64 {
+	q := a / b
+	if a%b != 0 && a < 0 {
+		q--
+	}
+	return q
+}
+
+// ceilDiv returns the smallest integer greater than or equal to a/b for b > 0.
+func ceilDiv(a, b int64) int64 {
+	return -floorDiv(-a, b)
 }
 
 // Value returns the current value in state.
@@ -225,6 +551,9 @@ func (r *RangeAggregationPipeline) Close() {
 		r.state.batch.Release()
 	}
 
+	r.aggregator.Reset()
+	_ = r.spiller.Close()
+
 	for _, input := range r.inputs {
 		input.Close()
 	}
@@ -241,39 +570,143 @@ func (r *RangeAggregationPipeline) Transport() Transport {
 }
 
 type partitionAggregator struct {
-	digest  *xxhash.Digest // used to compute key for each partition
-	entries map[uint64]*partitionEntry
+	operation types.RangeAggregationType
+	windows   int            // number of windows for which values are aggregated
+	digest    *xxhash.Digest // used to compute key for each partition
+	entries   map[uint64]*partitionEntry
+
+	columns []types.ColumnRef       // columns used for partitioning
+	indexes map[types.ColumnRef]int // index of each column in columns
+
+	budget *memoryBudget // memory budget of the query, nil if spilling is disabled
+	size   int64         // estimated memory used by the partitions
 }
 
-func newPartitionAggregator() *partitionAggregator {
-	return &partitionAggregator{
-		digest: xxhash.New(),
+func newPartitionAggregator(operation types.RangeAggregationType, windows int, partitionBy []physical.ColumnExpression, budget *memoryBudget) *partitionAggregator {
+	a := &partitionAggregator{
+		operation: operation,
+		windows:   windows,
+		budget:    budget,
+		digest:    xxhash.New(),
 		// TODO: estimate size during planning
 		entries: make(map[uint64]*partitionEntry),
+		indexes: make(map[types.ColumnRef]int),
+	}
+
+	for _, column := range partitionBy {
+		columnExpr, ok := column.(*physical.ColumnExpr)
+		if !ok {
+			panic(fmt.Sprintf("invalid column expression type %T", column))
+		}
+		a.ColumnIndex(columnExpr.Ref)
+	}
+
+	return a
+}
+
+// PipelineError returns a [logqlmodel.PipelineError] if the partition of the
+// label values has a non-empty __error__ label. Like in the old engine, series
+// with errors fail the query unless the errors are filtered out.
+func (a *partitionAggregator) PipelineError(labelValues []string) error {
+	idx, ok := a.indexes[types.ColumnRef{Column: logqlmodel.ErrorLabel, Type: types.ColumnTypeParsed}]
+	if !ok {
+		idx, ok = a.indexes[types.ColumnRef{Column: logqlmodel.ErrorLabel, Type: types.ColumnTypeAmbiguous}]
+	}
+	if !ok || idx >= len(labelValues) || labelValues[idx] == "" {
+		return nil
+	}
+
+	builder := labels.NewScratchBuilder(len(a.columns))
+	for i, column := range a.columns {
+		if i < len(labelValues) && labelValues[i] != "" {
+			builder.Add(column.Column, labelValues[i])
+		}
+	}
+	builder.Sort()
+	return logqlmodel.NewPipelineErr(builder.Labels())
+}
+
+// WithError returns the label values with the __error__ and __error_details__
+// labels set, adding the columns of the labels if they are not known yet.
+func (a *partitionAggregator) WithError(labelValues []string, errType, details string) []string {
+	errIdx := a.ColumnIndex(types.ColumnRef{Column: logqlmodel.ErrorLabel, Type: types.ColumnTypeParsed})
+	detailsIdx := a.ColumnIndex(types.ColumnRef{Column: logqlmodel.ErrorDetailsLabel, Type: types.ColumnTypeParsed})
+	if n := len(a.columns); len(labelValues) < n {
+		labelValues = append(labelValues, make([]string, n-len(labelValues))...)
 	}
+	labelValues[errIdx] = errType
+	labelValues[detailsIdx] = details
+	return labelValues
+}
+
+// errorArray returns the __error__ column of the record or nil if the record
+// does not have one.
+func errorArray(record arrow.Record) *array.String {
+	for i, field := range record.Schema().Fields() {
+		if field.Name != logqlmodel.ErrorLabel {
+			continue
+		}
+		if ct, ok := field.Metadata.GetValue(types.MetadataKeyColumnType); ok && ct == types.ColumnTypeParsed.String() {
+			arr, _ := record.Column(i).(*array.String)
+			return arr
+		}
+	}
+	return nil
 }
 
 type partitionEntry struct {
-	count       int64
+	states      []rangeState // aggregation state for each window
 	labelValues []string
 }
 
-func (a *partitionAggregator) Add(partitionLabelValues []string) {
+// ColumnIndex returns the index of the partition column, adding it if it is not known yet.
+func (a *partitionAggregator) ColumnIndex(ref types.ColumnRef) int {
+	if idx, ok := a.indexes[ref]; ok {
+		return idx
+	}
+
+	a.columns = append(a.columns, ref)
+	a.indexes[ref] = len(a.columns) - 1
+	return len(a.columns) - 1
+}
+
+// Add adds the sample value of an entry with the given partition label values
+// at timestamp ts to the windows [lo, hi).
+func (a *partitionAggregator) Add(lo, hi int, partitionLabelValues []string, ts time.Time, value float64) {
+	entry := a.entry(a.partitionKey(partitionLabelValues), partitionLabelValues)
+	for i := lo; i < hi; i++ {
+		entry.states[i].add(a.operation, ts, value)
+	}
+
+	if a.operation == types.RangeAggregationTypeQuantile {
+		a.grow(int64(8 * (hi - lo))) // each window holds a copy of the value
+	}
+}
+
+// partitionKey returns the key of the partition identified by partitionLabelValues.
+func (a *partitionAggregator) partitionKey(partitionLabelValues []string) uint64 {
 	a.digest.Reset()
 
 	for i, val := range partitionLabelValues {
-		if i > 0 {
-			_, _ = a.digest.Write([]byte{0}) // separator for label values
+		if val == "" {
+			// empty values are equivalent to a missing label
+			continue
 		}
 
+		_, _ = a.digest.Write([]byte{byte(i >> 8), byte(i), 0})
 		_, _ = a.digest.WriteString(val)
+		_, _ = a.digest.Write([]byte{0}) // separator for label values
 	}
 
-	key := a.digest.Sum64()
-	if entry, ok := a.entries[key]; ok {
-		// TODO: handle hash collisions
-		entry.count++
-	} else {
+	return a.digest.Sum64()
+}
+
+// entry returns the entry of the partition with the given key, creating it if
+// it does not exist yet.
+func (a *partitionAggregator) entry(key uint64, partitionLabelValues []string) *partitionEntry {
+	entry, ok := a.entries[key]
+	// TODO: handle hash collisions
+	if !ok {
 		// create a new slice since partitionLabelValues is reused by the calling code
 		labelValues := make([]string, len(partitionLabelValues))
 		for i, v := range partitionLabelValues {
@@ -284,18 +717,352 @@ func (a *partitionAggregator) Add(partitionLabelValues []string) {
 		}
 
 		// TODO: add limits on number of partitions
-		a.entries[key] = &partitionEntry{
+		entry = &partitionEntry{
 			labelValues: labelValues,
-			count:       1,
+			states:      make([]rangeState, a.windows),
 		}
+		a.entries[key] = entry
+		a.grow(int64(unsafe.Sizeof(partitionEntry{})) + int64(a.windows)*int64(unsafe.Sizeof(rangeState{})) + labelValuesSize(labelValues))
 	}
+	return entry
+}
+
+// grow accounts n additional bytes of partition state.
+func (a *partitionAggregator) grow(n int64) {
+	a.size += n
+	a.budget.grow(n)
 }
 
 func (a *partitionAggregator) Reset() {
 	a.digest.Reset()
 	clear(a.entries)
+	a.budget.release(a.size)
+	a.size = 0
 }
 
 func (a *partitionAggregator) NumOfPartitions() int {
 	return len(a.entries)
 }
+
+func (a *partitionAggregator) NumOfColumns() int {
+	return len(a.columns)
+}
+
+// rangeState holds the aggregated state of a single partition in a single window.
+// Only the fields required by the aggregation operation are populated.
+type rangeState struct {
+	count     int64
+	sum       float64
+	mean, aux float64 // running mean and sum of squared differences (Welford's algorithm)
+	min, max  float64
+
+	firstTs, lastTs time.Time
+	first, last     float64
+
+	values []float64 // all values, only used for quantiles
+}
+
+func (s *rangeState) add(op types.RangeAggregationType, ts time.Time, value float64) {
+	s.count++
+
+	switch op {
+	case types.RangeAggregationTypeRate, types.RangeAggregationTypeBytes,
+		types.RangeAggregationTypeBytesRate, types.RangeAggregationTypeSum:
+		s.sum += value
+
+	case types.RangeAggregationTypeAvg:
+		count := float64(s.count)
+		if math.IsInf(s.mean, 0) {
+			if math.IsInf(value, 0) && (s.mean > 0) == (value > 0) {
+				// The mean and the value are Inf of the same sign. They
+				// can't be subtracted, but the value of mean is correct already.
+				return
+			}
+			if !math.IsInf(value, 0) && !math.IsNaN(value) {
+				// The mean is infinite and the value is neither Inf nor NaN,
+				// so we keep the mean, since Inf += x - Inf would result in NaN.
+				return
+			}
+		}
+		s.mean += value/count - s.mean/count
+
+	case types.RangeAggregationTypeMin:
+		if s.count == 1 || value < s.min || math.IsNaN(s.min) {
+			s.min = value
+		}
+
+	case types.RangeAggregationTypeMax:
+		if s.count == 1 || value > s.max || math.IsNaN(s.max) {
+			s.max = value
+		}
+
+	case types.RangeAggregationTypeFirst:
+		if s.count == 1 || ts.Before(s.firstTs) {
+			s.firstTs, s.first = ts, value
+		}
+
+	case types.RangeAggregationTypeLast:
+		if s.count == 1 || !ts.Before(s.lastTs) {
+			s.lastTs, s.last = ts, value
+		}
+
+	case types.RangeAggregationTypeStddev, types.RangeAggregationTypeStdvar:
+		delta := value - s.mean
+		s.mean += delta / float64(s.count)
+		s.aux += delta * (value - s.mean)
+
+	case types.RangeAggregationTypeQuantile:
+		s.values = append(s.values, value)
+	}
+}
+
+// merge merges the partial aggregation src of the same window into s.
+func (s *rangeState) merge(op types.RangeAggregationType, src *rangeState) {
+	if src.count == 0 {
+		return
+	}
+	count := s.count + src.count
+
+	switch op {
+	case types.RangeAggregationTypeRate, types.RangeAggregationTypeBytes,
+		types.RangeAggregationTypeBytesRate, types.RangeAggregationTypeSum:
+		s.sum += src.sum
+
+	case types.RangeAggregationTypeAvg:
+		if math.IsInf(s.mean, 0) || math.IsInf(src.mean, 0) {
+			// Inf means of the same sign are kept, while Inf means of
+			// different signs result in NaN, like in add.
+			s.mean += src.mean
+		} else {
+			s.mean += (src.mean - s.mean) * float64(src.count) / float64(count)
+		}
+
+	case types.RangeAggregationTypeMin:
+		if s.count == 0 || src.min < s.min || math.IsNaN(s.min) {
+			s.min = src.min
+		}
+
+	case types.RangeAggregationTypeMax:
+		if s.count == 0 || src.max > s.max || math.IsNaN(s.max) {
+			s.max = src.max
+		}
+
+	case types.RangeAggregationTypeFirst:
+		if s.count == 0 || src.firstTs.Before(s.firstTs) {
+			s.firstTs, s.first = src.firstTs, src.first
+		}
+
+	case types.RangeAggregationTypeLast:
+		if s.count == 0 || !src.lastTs.Before(s.lastTs) {
+			s.lastTs, s.last = src.lastTs, src.last
+		}
+
+	case types.RangeAggregationTypeStddev, types.RangeAggregationTypeStdvar:
+		// combine the partial results with the parallel algorithm of Chan et al.
+		delta := src.mean - s.mean
+		s.mean += delta * float64(src.count) / float64(count)
+		s.aux += src.aux + delta*delta*float64(s.count)*float64(src.count)/float64(count)
+
+	case types.RangeAggregationTypeQuantile:
+		s.values = append(s.values, src.values...)
+	}
+	s.count = count
+}
+
+// result returns the result of the aggregation operation for the window.
+func (s *rangeState) result(op types.RangeAggregationType, parameter float64, rangeInterval time.Duration) float64 {
+	switch op {
+	case types.RangeAggregationTypeCount:
+		return float64(s.count)
+	case types.RangeAggregationTypeRate, types.RangeAggregationTypeBytesRate:
+		return s.sum / rangeInterval.Seconds()
+	case types.RangeAggregationTypeBytes, types.RangeAggregationTypeSum:
+		return s.sum
+	case types.RangeAggregationTypeAvg:
+		return s.mean
+	case types.RangeAggregationTypeMin:
+		return s.min
+	case types.RangeAggregationTypeMax:
+		return s.max
+	case types.RangeAggregationTypeFirst:
+		return s.first
+	case types.RangeAggregationTypeLast:
+		return s.last
+	case types.RangeAggregationTypeStddev:
+		return math.Sqrt(s.aux / float64(s.count))
+	case types.RangeAggregationTypeStdvar:
+		return s.aux / float64(s.count)
+	case types.RangeAggregationTypeQuantile:
+		return quantile(parameter, s.values)
+	default:
+		return math.NaN()
+	}
+}
+
+// quantile calculates the q-quantile of the values using linear interpolation
+// between the closest ranks. The values are sorted in place.
+//
+// If q<0, -Inf is returned.
+// If q>1, +Inf is returned.
+func quantile(q float64, values []float64) float64 {
+	if len(values) == 0 {
+		return math.NaN()
+	}
+	if q < 0 {
+		return math.Inf(-1)
+	}
+	if q > 1 {
+		return math.Inf(+1)
+	}
+	slices.Sort(values)
+
+	n := float64(len(values))
+	// When the quantile lies between two values,
+	// we use a weighted average of the two values.
+	rank := q * (n - 1)
+
+	lowerIndex := math.Max(0, math.Floor(rank))
+	upperIndex := math.Min(n-1, lowerIndex+1)
+
+	weight := rank - math.Floor(rank)
+	return values[int(lowerIndex)]*(1-weight) + values[int(upperIndex)]*weight
+}
+
+// rangeSpillColumns is the number of columns of spilled partition state that
+// precede the label columns.
+const rangeSpillColumns = 13
+
+// Spill writes the state of all partitions to a new spill file of s and
+// resets the aggregator.
+//
+// Each partition is written as one row per window with values, holding the
+// index of the window, the key of the partition and the fields of its
+// [rangeState], followed by its label values.
+func (a *partitionAggregator) Spill(s *spiller) error {
+	fields := []arrow.Field{
+		{Name: "window", Type: arrow.PrimitiveTypes.Int64},
+		{Name: "key", Type: arrow.PrimitiveTypes.Uint64},
+		{Name: "count", Type: arrow.PrimitiveTypes.Int64},
+		{Name: "sum", Type: arrow.PrimitiveTypes.Float64},
+		{Name: "mean", Type: arrow.PrimitiveTypes.Float64},
+		{Name: "aux", Type: arrow.PrimitiveTypes.Float64},
+		{Name: "min", Type: arrow.PrimitiveTypes.Float64},
+		{Name: "max", Type: arrow.PrimitiveTypes.Float64},
+		{Name: "first_ts", Type: datatype.Arrow.Timestamp},
+		{Name: "last_ts", Type: datatype.Arrow.Timestamp},
+		{Name: "first", Type: arrow.PrimitiveTypes.Float64},
+		{Name: "last", Type: arrow.PrimitiveTypes.Float64},
+		{Name: "values", Type: arrow.ListOf(arrow.PrimitiveTypes.Float64)},
+	}
+	schema := arrow.NewSchema(append(fields, labelFields(a.columns)...), nil)
+
+	var builders [spillPartitions]*array.RecordBuilder
+	for i := range builders {
+		builders[i] = array.NewRecordBuilder(memory.NewGoAllocator(), schema)
+		defer builders[i].Release()
+	}
+
+	for key, entry := range a.entries {
+		rb := builders[spillPartition(key)]
+		for i := range entry.states {
+			state := &entry.states[i]
+			if state.count == 0 {
+				continue
+			}
+
+			firstTs, _ := arrow.TimestampFromTime(state.firstTs, arrow.Nanosecond)
+			lastTs, _ := arrow.TimestampFromTime(state.lastTs, arrow.Nanosecond)
+
+			rb.Field(0).(*array.Int64Builder).Append(int64(i))
+			rb.Field(1).(*array.Uint64Builder).Append(key)
+			rb.Field(2).(*array.Int64Builder).Append(state.count)
+			rb.Field(3).(*array.Float64Builder).Append(state.sum)
+			rb.Field(4).(*array.Float64Builder).Append(state.mean)
+			rb.Field(5).(*array.Float64Builder).Append(state.aux)
+			rb.Field(6).(*array.Float64Builder).Append(state.min)
+			rb.Field(7).(*array.Float64Builder).Append(state.max)
+			rb.Field(8).(*array.TimestampBuilder).Append(firstTs)
+			rb.Field(9).(*array.TimestampBuilder).Append(lastTs)
+			rb.Field(10).(*array.Float64Builder).Append(state.first)
+			rb.Field(11).(*array.Float64Builder).Append(state.last)
+
+			lb := rb.Field(12).(*array.ListBuilder)
+			lb.Append(true)
+			lb.ValueBuilder().(*array.Float64Builder).AppendValues(state.values, nil)
+
+			appendLabelValues(rb, rangeSpillColumns, len(a.columns), entry.labelValues)
+		}
+	}
+
+	var records [spillPartitions]arrow.Record
+	for i, rb := range builders {
+		records[i] = rb.NewRecord()
+	}
+
+	a.Reset()
+	return s.Spill(schema, records)
+}
+
+// Merge merges the partition state of a record written by [partitionAggregator.Spill].
+func (a *partitionAggregator) Merge(record arrow.Record) error {
+	labelArrays, err := spilledLabelArrays(record, rangeSpillColumns, a.ColumnIndex)
+	if err != nil {
+		return err
+	}
+
+	var (
+		windowCol  = record.Column(0).(*array.Int64)
+		keyCol     = record.Column(1).(*array.Uint64)
+		countCol   = record.Column(2).(*array.Int64)
+		sumCol     = record.Column(3).(*array.Float64)
+		meanCol    = record.Column(4).(*array.Float64)
+		auxCol     = record.Column(5).(*array.Float64)
+		minCol     = record.Column(6).(*array.Float64)
+		maxCol     = record.Column(7).(*array.Float64)
+		firstTsCol = record.Column(8).(*array.Timestamp)
+		lastTsCol  = record.Column(9).(*array.Timestamp)
+		firstCol   = record.Column(10).(*array.Float64)
+		lastCol    = record.Column(11).(*array.Float64)
+		valuesCol  = record.Column(12).(*array.List)
+		valuesData = valuesCol.ListValues().(*array.Float64)
+
+		labelValues = make([]string, a.NumOfColumns())
+	)
+
+	for row := range int(record.NumRows()) {
+		window := int(windowCol.Value(row))
+		if window < 0 || window >= a.windows {
+			return fmt.Errorf("invalid window %d of spilled state", window)
+		}
+
+		clear(labelValues)
+		for _, arr := range labelArrays {
+			if arr.array.IsValid(row) {
+				labelValues[arr.index] = arr.array.Value(row)
+			}
+		}
+
+		start, end := valuesCol.ValueOffsets(row)
+		var values []float64
+		if end > start {
+			values = slices.Clone(valuesData.Float64Values()[start:end])
+			a.grow(int64(8 * len(values)))
+		}
+
+		entry := a.entry(keyCol.Value(row), labelValues)
+		entry.states[window].merge(a.operation, &rangeState{
+			count:   countCol.Value(row),
+			sum:     sumCol.Value(row),
+			mean:    meanCol.Value(row),
+			aux:     auxCol.Value(row),
+			min:     minCol.Value(row),
+			max:     maxCol.Value(row),
+			firstTs: firstTsCol.Value(row).ToTime(arrow.Nanosecond),
+			lastTs:  lastTsCol.Value(row).ToTime(arrow.Nanosecond),
+			first:   firstCol.Value(row),
+			last:    lastCol.Value(row),
+			values:  values,
+		})
+	}
+	return nil
+}
@@ -2,17 +2,21 @@ package executor
 
 import (
 	"fmt"
+	"math"
 	"strings"
 	"testing"
 	"time"
 
 	"github.com/apache/arrow-go/v18/arrow"
 	"github.com/apache/arrow-go/v18/arrow/array"
+	"github.com/prometheus/prometheus/model/labels"
 	"github.com/stretchr/testify/require"
 
 	"github.com/grafana/loki/v3/pkg/engine/internal/datatype"
 	"github.com/grafana/loki/v3/pkg/engine/internal/types"
 	"github.com/grafana/loki/v3/pkg/engine/planner/physical"
+	"github.com/grafana/loki/v3/pkg/logql/syntax"
+	"github.com/grafana/loki/v3/pkg/logqlmodel"
 )
 
 const arrowTimestampFormat = "2006-01-02T15:04:05.000000000Z"
@@ -29,11 +33,11 @@ func TestRangeAggregationPipeline(t *testing.T) {
 	// test data for first input
 	now := time.Now().UTC()
 	input1CSV := strings.Join([]string{
-		fmt.Sprintf("%s,prod,app1,error", now.Format(arrowTimestampFormat)), // excluded, falls on the open interval
+		fmt.Sprintf("%s,prod,app1,error", now.Format(arrowTimestampFormat)), // included, falls on the closed interval
 		fmt.Sprintf("%s,prod,app1,info", now.Add(-5*time.Minute).Format(arrowTimestampFormat)),
 		fmt.Sprintf("%s,prod,app1,error", now.Add(-5*time.Minute).Format(arrowTimestampFormat)),
-		fmt.Sprintf("%s,prod,app2,error", now.Add(-10*time.Minute).Format(arrowTimestampFormat)), // included, falls on closed interval
-		fmt.Sprintf("%s,dev,,error", now.Add(-10*time.Minute).Format(arrowTimestampFormat)),
+		fmt.Sprintf("%s,prod,app2,error", now.Add(-10*time.Minute).Format(arrowTimestampFormat)), // excluded, falls on the open interval
+		fmt.Sprintf("%s,dev,,error", now.Add(-5*time.Minute).Format(arrowTimestampFormat)),
 	}, "\n")
 
 	// test data for second input
@@ -78,6 +82,7 @@ func TestRangeAggregationPipeline(t *testing.T) {
 				},
 			},
 		},
+		operation:     types.RangeAggregationTypeCount,
 		startTs:       now,
 		endTs:         now,
 		rangeInterval: 10 * time.Minute,
@@ -95,20 +100,20 @@ func TestRangeAggregationPipeline(t *testing.T) {
 	defer record.Release()
 
 	// Define expected results
-	expected := map[string]int64{
-		"prod,app1": 2,
-		"prod,app2": 3,
-		"prod,app3": 2,
+	expected := map[string]float64{
+		"prod,app1": 3,
+		"prod,app2": 1,
+		"prod,app3": 1,
 		"dev,":      1,
 	}
 
 	require.Equal(t, int64(len(expected)), record.NumRows(), "number of records should match")
 
-	actual := make(map[string]int64)
+	actual := make(map[string]float64)
 	for i := range int(record.NumRows()) {
 		require.Equal(t, record.Column(0).(*array.Timestamp).Value(i).ToTime(arrow.Nanosecond), now)
 
-		value := record.Column(1).(*array.Int64).Value(i)
+		value := record.Column(1).(*array.Float64).Value(i)
 		env := record.Column(2).(*array.String).Value(i)
 		service := record.Column(3).(*array.String).Value(i)
 		key := fmt.Sprintf("%s,%s", env, service)
@@ -117,3 +122,398 @@ func TestRangeAggregationPipeline(t *testing.T) {
 
 	require.EqualValues(t, expected, actual, "aggregation results should match")
 }
+
+func TestRangeAggregationPipeline_RangeQuery(t *testing.T) {
+	fields := []arrow.Field{
+		{Name: types.ColumnNameBuiltinTimestamp, Type: datatype.Arrow.Timestamp, Metadata: datatype.ColumnMetadataBuiltinTimestamp},
+		{Name: "env", Type: datatype.Arrow.String, Metadata: datatype.ColumnMetadata(types.ColumnTypeLabel, datatype.Loki.String)},
+	}
+
+	start := time.Unix(1000, 0).UTC()
+	end := start.Add(20 * time.Minute)
+
+	inputCSV := strings.Join([]string{
+		fmt.Sprintf("%s,prod", start.Add(-10*time.Minute).Format(arrowTimestampFormat)),
+		fmt.Sprintf("%s,prod", start.Add(-1*time.Minute).Format(arrowTimestampFormat)),
+		fmt.Sprintf("%s,prod", start.Add(5*time.Minute).Format(arrowTimestampFormat)),
+		fmt.Sprintf("%s,dev", start.Add(7*time.Minute).Format(arrowTimestampFormat)),
+		fmt.Sprintf("%s,prod", start.Add(20*time.Minute).Format(arrowTimestampFormat)),
+		fmt.Sprintf("%s,prod", start.Add(21*time.Minute).Format(arrowTimestampFormat)),
+	}, "\n")
+
+	for _, tt := range []struct {
+		name          string
+		step          time.Duration
+		rangeInterval time.Duration
+		offset        time.Duration
+		expected      map[string]float64 // keyed by offset of the step from start and env label
+	}{
+		{
+			// windows: (start-5m, start], (start+5m, start+10m], (start+15m, start+20m]
+			name:          "step larger than range",
+			step:          10 * time.Minute,
+			rangeInterval: 5 * time.Minute,
+			expected: map[string]float64{
+				"0s,prod":    1,
+				"10m0s,dev":  1,
+				"20m0s,prod": 1,
+			},
+		},
+		{
+			// windows: (start-10m, start], (start, start+10m], (start+10m, start+20m]
+			name:          "step equal to range",
+			step:          10 * time.Minute,
+			rangeInterval: 10 * time.Minute,
+			expected: map[string]float64{
+				"0s,prod":    1,
+				"10m0s,prod": 1,
+				"10m0s,dev":  1,
+				"20m0s,prod": 1,
+			},
+		},
+		{
+			// windows: (start-15m, start], (start-10m, start+5m], (start-5m, start+10m], ...
+			name:          "step smaller than range",
+			step:          5 * time.Minute,
+			rangeInterval: 15 * time.Minute,
+			expected: map[string]float64{
+				"0s,prod":    2,
+				"5m0s,prod":  2,
+				"10m0s,prod": 2,
+				"10m0s,dev":  1,
+				"15m0s,prod": 1,
+				"15m0s,dev":  1,
+				"20m0s,prod": 1,
+				"20m0s,dev":  1,
+			},
+		},
+		{
+			// windows: (start-15m, start-10m], (start-5m, start], (start+5m, start+10m]
+			// results are reported at the timestamps of the steps
+			name:          "offset shifts windows back in time",
+			step:          10 * time.Minute,
+			rangeInterval: 5 * time.Minute,
+			offset:        10 * time.Minute,
+			expected: map[string]float64{
+				"0s,prod":    1,
+				"10m0s,prod": 1,
+				"20m0s,dev":  1,
+			},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			record, err := CSVToArrow(fields, inputCSV)
+			require.NoError(t, err)
+			defer record.Release()
+
+			opts := rangeAggregationOptions{
+				partitionBy: []physical.ColumnExpression{
+					&physical.ColumnExpr{
+						Ref: types.ColumnRef{
+							Column: "env",
+							Type:   types.ColumnTypeAmbiguous,
+						},
+					},
+				},
+				operation:     types.RangeAggregationTypeCount,
+				startTs:       start,
+				endTs:         end,
+				rangeInterval: tt.rangeInterval,
+				step:          tt.step,
+				offset:        tt.offset,
+			}
+
+			pipeline, err := NewRangeAggregationPipeline([]Pipeline{NewBufferedPipeline(record)}, expressionEvaluator{}, opts)
+			require.NoError(t, err)
+			defer pipeline.Close()
+
+			err = pipeline.Read(t.Context())
+			require.NoError(t, err)
+			result, err := pipeline.Value()
+			require.NoError(t, err)
+
+			actual := make(map[string]float64)
+			for i := range int(result.NumRows()) {
+				ts := result.Column(0).(*array.Timestamp).Value(i).ToTime(arrow.Nanosecond)
+				value := result.Column(1).(*array.Float64).Value(i)
+				env := result.Column(2).(*array.String).Value(i)
+				actual[fmt.Sprintf("%s,%s", ts.Sub(start), env)] = value
+			}
+			require.Equal(t, tt.expected, actual)
+		})
+	}
+}
+
+func TestRangeAggregationPipeline_Operations(t *testing.T) {
+	fields := []arrow.Field{
+		{Name: types.ColumnNameBuiltinTimestamp, Type: datatype.Arrow.Timestamp, Metadata: datatype.ColumnMetadataBuiltinTimestamp},
+		{Name: types.ColumnNameBuiltinMessage, Type: datatype.Arrow.String, Metadata: datatype.ColumnMetadataBuiltinMessage},
+		{Name: "env", Type: datatype.Arrow.String, Metadata: datatype.ColumnMetadata(types.ColumnTypeLabel, datatype.Loki.String)},
+		{Name: "latency", Type: datatype.Arrow.String, Metadata: datatype.ColumnMetadata(types.ColumnTypeMetadata, datatype.Loki.String)},
+	}
+
+	end := time.Unix(1000, 0).UTC()
+	inputCSV := strings.Join([]string{
+		fmt.Sprintf("%s,line a,prod,1s", end.Add(-1*time.Minute).Format(arrowTimestampFormat)),
+		fmt.Sprintf("%s,line bb,prod,4s", end.Add(-2*time.Minute).Format(arrowTimestampFormat)),
+		fmt.Sprintf("%s,line ccc,prod,500ms", end.Add(-3*time.Minute).Format(arrowTimestampFormat)),
+		fmt.Sprintf("%s,line dddd,prod,2.5s", end.Add(-4*time.Minute).Format(arrowTimestampFormat)),
+		fmt.Sprintf("%s,line e,dev,3s", end.Add(-2*time.Minute).Format(arrowTimestampFormat)),
+	}, "\n")
+
+	latency := &physical.Unwrap{
+		Column:     &physical.ColumnExpr{Ref: types.ColumnRef{Column: "latency", Type: types.ColumnTypeAmbiguous}},
+		Conversion: types.ConversionTypeDuration,
+	}
+
+	for _, tt := range []struct {
+		operation types.RangeAggregationType
+		parameter float64
+		unwrap    *physical.Unwrap
+		expected  map[string]float64 // keyed by env label
+	}{
+		{operation: types.RangeAggregationTypeCount, expected: map[string]float64{"prod": 4, "dev": 1}},
+		{operation: types.RangeAggregationTypeRate, expected: map[string]float64{"prod": 4.0 / 300, "dev": 1.0 / 300}},
+		{operation: types.RangeAggregationTypeBytes, expected: map[string]float64{"prod": 30, "dev": 6}},
+		{operation: types.RangeAggregationTypeBytesRate, expected: map[string]float64{"prod": 30.0 / 300, "dev": 6.0 / 300}},
+		{operation: types.RangeAggregationTypeRate, unwrap: latency, expected: map[string]float64{"prod": 8.0 / 300, "dev": 3.0 / 300}},
+		{operation: types.RangeAggregationTypeSum, unwrap: latency, expected: map[string]float64{"prod": 8, "dev": 3}},
+		{operation: types.RangeAggregationTypeAvg, unwrap: latency, expected: map[string]float64{"prod": 2, "dev": 3}},
+		{operation: types.RangeAggregationTypeMin, unwrap: latency, expected: map[string]float64{"prod": 0.5, "dev": 3}},
+		{operation: types.RangeAggregationTypeMax, unwrap: latency, expected: map[string]float64{"prod": 4, "dev": 3}},
+		{operation: types.RangeAggregationTypeFirst, unwrap: latency, expected: map[string]float64{"prod": 2.5, "dev": 3}},
+		{operation: types.RangeAggregationTypeLast, unwrap: latency, expected: map[string]float64{"prod": 1, "dev": 3}},
+		{operation: types.RangeAggregationTypeStdvar, unwrap: latency, expected: map[string]float64{"prod": 1.875, "dev": 0}},
+		{operation: types.RangeAggregationTypeStddev, unwrap: latency, expected: map[string]float64{"prod": math.Sqrt(1.875), "dev": 0}},
+		{operation: types.RangeAggregationTypeQuantile, parameter: 0.5, unwrap: latency, expected: map[string]float64{"prod": 1.75, "dev": 3}},
+	} {
+		name := tt.operation.String()
+		if tt.unwrap != nil {
+			name += "/unwrap"
+		}
+
+		t.Run(name, func(t *testing.T) {
+			record, err := CSVToArrow(fields, inputCSV)
+			require.NoError(t, err)
+			defer record.Release()
+
+			opts := rangeAggregationOptions{
+				partitionBy: []physical.ColumnExpression{
+					&physical.ColumnExpr{Ref: types.ColumnRef{Column: "env", Type: types.ColumnTypeAmbiguous}},
+				},
+				operation:     tt.operation,
+				parameter:     tt.parameter,
+				unwrap:        tt.unwrap,
+				startTs:       end,
+				endTs:         end,
+				rangeInterval: 5 * time.Minute,
+			}
+
+			pipeline, err := NewRangeAggregationPipeline([]Pipeline{NewBufferedPipeline(record)}, expressionEvaluator{}, opts)
+			require.NoError(t, err)
+			defer pipeline.Close()
+
+			err = pipeline.Read(t.Context())
+			require.NoError(t, err)
+			result, err := pipeline.Value()
+			require.NoError(t, err)
+
+			actual := make(map[string]float64)
+			for i := range int(result.NumRows()) {
+				env := result.Column(2).(*array.String).Value(i)
+				actual[env] = result.Column(1).(*array.Float64).Value(i)
+			}
+
+			require.Len(t, actual, len(tt.expected))
+			for env, value := range tt.expected {
+				require.InDelta(t, value, actual[env], 1e-9, "env=%s", env)
+			}
+		})
+	}
+}
+
+func TestRangeAggregationPipeline_Unwrap(t *testing.T) {
+	fields := []arrow.Field{
+		{Name: types.ColumnNameBuiltinTimestamp, Type: datatype.Arrow.Timestamp, Metadata: datatype.ColumnMetadataBuiltinTimestamp},
+		{Name: "env", Type: datatype.Arrow.String, Metadata: datatype.ColumnMetadata(types.ColumnTypeLabel, datatype.Loki.String)},
+		{Name: "size", Type: datatype.Arrow.String, Metadata: datatype.ColumnMetadata(types.ColumnTypeMetadata, datatype.Loki.String)},
+	}
+
+	end := time.Unix(1000, 0).UTC()
+	inputCSV := strings.Join([]string{
+		fmt.Sprintf("%s,prod,1KB", end.Add(-1*time.Minute).Format(arrowTimestampFormat)),
+		fmt.Sprintf("%s,prod,invalid", end.Add(-2*time.Minute).Format(arrowTimestampFormat)),
+		fmt.Sprintf("%s,prod,2 MiB", end.Add(-3*time.Minute).Format(arrowTimestampFormat)),
+	}, "\n")
+
+	run := func(t *testing.T, dropErrors bool) (arrow.Record, error) {
+		record, err := CSVToArrow(fields, inputCSV)
+		require.NoError(t, err)
+		t.Cleanup(record.Release)
+
+		pipeline, err := NewRangeAggregationPipeline([]Pipeline{NewBufferedPipeline(record)}, expressionEvaluator{}, rangeAggregationOptions{
+			operation: types.RangeAggregationTypeSum,
+			unwrap: &physical.Unwrap{
+				Column:     &physical.ColumnExpr{Ref: types.ColumnRef{Column: "size", Type: types.ColumnTypeAmbiguous}},
+				Conversion: types.ConversionTypeBytes,
+				DropErrors: dropErrors,
+			},
+			startTs:       end,
+			endTs:         end,
+			rangeInterval: 5 * time.Minute,
+		})
+		require.NoError(t, err)
+		t.Cleanup(pipeline.Close)
+
+		if err := pipeline.Read(t.Context()); err != nil {
+			return nil, err
+		}
+		return pipeline.Value()
+	}
+
+	t.Run("conversion errors fail the query", func(t *testing.T) {
+		_, err := run(t, false)
+		require.ErrorIs(t, err, logqlmodel.ErrPipeline)
+		require.ErrorContains(t, err, "SampleExtractionErr")
+		require.ErrorContains(t, err, `env="prod"`)
+	})
+
+	t.Run("conversion errors are dropped", func(t *testing.T) {
+		result, err := run(t, true)
+		require.NoError(t, err)
+		require.Equal(t, int64(1), result.NumRows())
+
+		// the unwrapped column is not part of the implicit partitioning
+		require.Equal(t, 3, int(result.NumCols()))
+		require.Equal(t, "env", result.Schema().Field(2).Name)
+		require.Equal(t, "prod", result.Column(2).(*array.String).Value(0))
+		require.Equal(t, float64(1000+2*1024*1024), result.Column(1).(*array.Float64).Value(0))
+	})
+}
+
+func TestRangeAggregationPipeline_UnwrapMissingLabel(t *testing.T) {
+	fields := []arrow.Field{
+		{Name: types.ColumnNameBuiltinTimestamp, Type: datatype.Arrow.Timestamp, Metadata: datatype.ColumnMetadataBuiltinTimestamp},
+		{Name: "app", Type: datatype.Arrow.String, Metadata: datatype.ColumnMetadata(types.ColumnTypeLabel, datatype.Loki.String)},
+		{Name: "latency", Type: datatype.Arrow.String, Metadata: datatype.ColumnMetadata(types.ColumnTypeMetadata, datatype.Loki.String)},
+	}
+
+	end := time.Unix(1000, 0).UTC()
+	latencies := []string{"1.5", "", "2", ""}
+
+	var rows []string
+	for i, latency := range latencies {
+		rows = append(rows, fmt.Sprintf("%s,x,%s", end.Add(-time.Duration(i+1)*time.Minute).Format(arrowTimestampFormat), latency))
+	}
+	record, err := CSVToArrow(fields, strings.Join(rows, "\n"))
+	require.NoError(t, err)
+	defer record.Release()
+
+	pipeline, err := NewRangeAggregationPipeline([]Pipeline{NewBufferedPipeline(record)}, expressionEvaluator{}, rangeAggregationOptions{
+		operation: types.RangeAggregationTypeSum,
+		unwrap: &physical.Unwrap{
+			Column:     &physical.ColumnExpr{Ref: types.ColumnRef{Column: "latency", Type: types.ColumnTypeAmbiguous}},
+			Conversion: types.ConversionTypeFloat,
+		},
+		startTs:       end,
+		endTs:         end,
+		rangeInterval: 5 * time.Minute,
+	})
+	require.NoError(t, err)
+	defer pipeline.Close()
+
+	// Rows without the unwrapped label are skipped instead of failing the query.
+	require.NoError(t, pipeline.Read(t.Context()))
+	result, err := pipeline.Value()
+	require.NoError(t, err)
+	require.Equal(t, int64(1), result.NumRows())
+	actual := result.Column(1).(*array.Float64).Value(0)
+
+	// The old engine skips the same rows.
+	expr, err := syntax.ParseSampleExpr(`sum_over_time({app="x"} | unwrap latency [5m])`)
+	require.NoError(t, err)
+	extractors, err := expr.Extractors()
+	require.NoError(t, err)
+	require.Len(t, extractors, 1)
+	stream := extractors[0].ForStream(labels.FromStrings("app", "x"))
+
+	var expected float64
+	for i, latency := range latencies {
+		var metadata labels.Labels
+		if latency != "" {
+			metadata = labels.FromStrings("latency", latency)
+		}
+		samples, ok := stream.ProcessString(end.Add(-time.Duration(i+1)*time.Minute).UnixNano(), "line", metadata)
+		if !ok {
+			continue
+		}
+		for _, sample := range samples {
+			expected += sample.Value
+		}
+	}
+	require.Equal(t, 3.5, expected)
+	require.Equal(t, expected, actual)
+}
+
+func TestRangeAggregationPipeline_PipelineErrors(t *testing.T) {
+	fields := []arrow.Field{
+		{Name: types.ColumnNameBuiltinTimestamp, Type: datatype.Arrow.Timestamp, Metadata: datatype.ColumnMetadataBuiltinTimestamp},
+		{Name: "env", Type: datatype.Arrow.String, Metadata: datatype.ColumnMetadata(types.ColumnTypeLabel, datatype.Loki.String)},
+		{Name: "latency", Type: datatype.Arrow.String, Metadata: datatype.ColumnMetadata(types.ColumnTypeParsed, datatype.Loki.String)},
+		{Name: "__error__", Type: datatype.Arrow.String, Metadata: datatype.ColumnMetadata(types.ColumnTypeParsed, datatype.Loki.String)},
+	}
+
+	end := time.Unix(1000, 0).UTC()
+	inputCSV := strings.Join([]string{
+		fmt.Sprintf("%s,prod,10,", end.Add(-1*time.Minute).Format(arrowTimestampFormat)),
+		fmt.Sprintf("%s,prod,,JSONParserErr", end.Add(-2*time.Minute).Format(arrowTimestampFormat)),
+	}, "\n")
+
+	run := func(t *testing.T, opts rangeAggregationOptions) (arrow.Record, error) {
+		record, err := CSVToArrow(fields, inputCSV)
+		require.NoError(t, err)
+		t.Cleanup(record.Release)
+
+		opts.startTs = end
+		opts.endTs = end
+		opts.rangeInterval = 5 * time.Minute
+
+		pipeline, err := NewRangeAggregationPipeline([]Pipeline{NewBufferedPipeline(record)}, expressionEvaluator{}, opts)
+		require.NoError(t, err)
+		t.Cleanup(pipeline.Close)
+
+		if err := pipeline.Read(t.Context()); err != nil {
+			return nil, err
+		}
+		return pipeline.Value()
+	}
+
+	t.Run("series with errors fail the query", func(t *testing.T) {
+		_, err := run(t, rangeAggregationOptions{operation: types.RangeAggregationTypeCount})
+		require.ErrorIs(t, err, logqlmodel.ErrPipeline)
+	})
+
+	t.Run("errors are ignored if they are not part of the series", func(t *testing.T) {
+		result, err := run(t, rangeAggregationOptions{
+			operation:   types.RangeAggregationTypeCount,
+			partitionBy: []physical.ColumnExpression{&physical.ColumnExpr{Ref: types.ColumnRef{Column: "env", Type: types.ColumnTypeAmbiguous}}},
+		})
+		require.NoError(t, err)
+		require.Equal(t, int64(1), result.NumRows())
+		require.Equal(t, float64(2), result.Column(1).(*array.Float64).Value(0))
+	})
+
+	t.Run("errors are dropped by unwrap", func(t *testing.T) {
+		result, err := run(t, rangeAggregationOptions{
+			operation: types.RangeAggregationTypeSum,
+			unwrap: &physical.Unwrap{
+				Column:     &physical.ColumnExpr{Ref: types.ColumnRef{Column: "latency", Type: types.ColumnTypeAmbiguous}},
+				Conversion: types.ConversionTypeFloat,
+				DropErrors: true,
+			},
+		})
+		require.NoError(t, err)
+		require.Equal(t, int64(1), result.NumRows())
+		require.Equal(t, float64(10), result.Column(1).(*array.Float64).Value(0))
+	})
+}
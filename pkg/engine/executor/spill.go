@@ -0,0 +1,248 @@
+package executor
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sync/atomic"
+
+	"github.com/apache/arrow-go/v18/arrow"
+	"github.com/apache/arrow-go/v18/arrow/array"
+	"github.com/apache/arrow-go/v18/arrow/ipc"
+	"github.com/apache/arrow-go/v18/arrow/memory"
+	"github.com/dustin/go-humanize"
+
+	"github.com/grafana/loki/v3/pkg/engine/internal/datatype"
+	"github.com/grafana/loki/v3/pkg/engine/internal/types"
+)
+
+// spillPartitions is the number of partitions into which the state of an
+// aggregation is split by group key when it is spilled. The partitions are
+// merged one at a time when the results are finalized, so that only a
+// fraction of the spilled state is held in memory at once.
+const spillPartitions = 16
+
+// memoryBudget limits the memory that the aggregations of a query use for
+// their state. Aggregations that exceed the budget spill their state to local
+// disk. A memoryBudget is shared by all pipelines of a query and is safe for
+// concurrent use. A nil memoryBudget never spills.
+type memoryBudget struct {
+	limit      int64  // maximum number of bytes kept in memory
+	spillLimit int64  // maximum number of bytes spilled to disk, 0 means unlimited
+	dir        string // directory of the spill files, the default temporary directory if empty
+
+	used    atomic.Int64
+	spilled atomic.Int64
+}
+
+// newMemoryBudget returns a budget of limit bytes. It returns nil if limit is
+// not positive, which disables spilling.
+func newMemoryBudget(limit, spillLimit int64, dir string) *memoryBudget {
+	if limit <= 0 {
+		return nil
+	}
+	return &memoryBudget{limit: limit, spillLimit: spillLimit, dir: dir}
+}
+
+// grow accounts n additional bytes of aggregation state.
+func (b *memoryBudget) grow(n int64) {
+	if b != nil {
+		b.used.Add(n)
+	}
+}
+
+// release returns n bytes of aggregation state to the budget.
+func (b *memoryBudget) release(n int64) {
+	if b != nil {
+		b.used.Add(-n)
+	}
+}
+
+// exceeded returns whether the aggregations use more memory than the budget.
+func (b *memoryBudget) exceeded() bool {
+	return b != nil && b.used.Load() > b.limit
+}
+
+// addSpilled accounts n bytes written to disk. It returns an error if the
+// spill limit is exceeded.
+func (b *memoryBudget) addSpilled(n int64) error {
+	spilled := b.spilled.Add(n)
+	if b.spillLimit > 0 && spilled > b.spillLimit {
+		return fmt.Errorf("aggregations exceed the spill limit of %s", humanize.Bytes(uint64(b.spillLimit)))
+	}
+	return nil
+}
+
+// spiller writes the state of an aggregation to Arrow IPC files on disk.
+//
+// Each spill creates a new file holding one record per partition. Files are
+// written independently of each other, so that each file has its own schema
+// and can hold label columns that were discovered after previous spills.
+type spiller struct {
+	budget *memoryBudget
+	files  []string // paths of the spill files, in the order they were written
+}
+
+func newSpiller(budget *memoryBudget) *spiller {
+	return &spiller{budget: budget}
+}
+
+// Spilled returns whether any state has been spilled.
+func (s *spiller) Spilled() bool {
+	return len(s.files) > 0
+}
+
+// Spill writes a new spill file with the records of all partitions. The
+// records are released.
+func (s *spiller) Spill(schema *arrow.Schema, records [spillPartitions]arrow.Record) (err error) {
+	defer func() {
+		for _, record := range records {
+			record.Release()
+		}
+	}()
+
+	f, err := os.CreateTemp(s.budget.dir, "loki-spill-*.arrow")
+	if err != nil {
+		return fmt.Errorf("create spill file: %w", err)
+	}
+	s.files = append(s.files, f.Name())
+	defer func() {
+		if closeErr := f.Close(); err == nil && closeErr != nil {
+			err = fmt.Errorf("close spill file: %w", closeErr)
+		}
+	}()
+
+	w, err := ipc.NewFileWriter(f, ipc.WithSchema(schema), ipc.WithAllocator(memory.DefaultAllocator))
+	if err != nil {
+		return fmt.Errorf("create spill file writer: %w", err)
+	}
+	for _, record := range records {
+		if err := w.Write(record); err != nil {
+			return fmt.Errorf("write spill file: %w", err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("write spill file: %w", err)
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		return fmt.Errorf("stat spill file: %w", err)
+	}
+	return s.budget.addSpilled(info.Size())
+}
+
+// Read calls fn with the record of the given partition of each spill file.
+func (s *spiller) Read(partition int, fn func(arrow.Record) error) error {
+	for _, name := range s.files {
+		if err := s.readFile(name, partition, fn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *spiller) readFile(name string, partition int, fn func(arrow.Record) error) error {
+	f, err := os.Open(name)
+	if err != nil {
+		return fmt.Errorf("open spill file: %w", err)
+	}
+	defer f.Close()
+
+	r, err := ipc.NewFileReader(f, ipc.WithAllocator(memory.DefaultAllocator))
+	if err != nil {
+		return fmt.Errorf("read spill file: %w", err)
+	}
+	defer r.Close()
+
+	if r.NumRecords() != spillPartitions {
+		return fmt.Errorf("invalid spill file %s: expected %d records, got %d", name, spillPartitions, r.NumRecords())
+	}
+
+	record, err := r.RecordAt(partition)
+	if err != nil {
+		return fmt.Errorf("read spill file: %w", err)
+	}
+	defer record.Release()
+
+	return fn(record)
+}
+
+// Close removes all spill files.
+func (s *spiller) Close() error {
+	var errs []error
+	for _, name := range s.files {
+		if err := os.Remove(name); err != nil && !errors.Is(err, os.ErrNotExist) {
+			errs = append(errs, err)
+		}
+	}
+	s.files = nil
+	return errors.Join(errs...)
+}
+
+// spillPartition returns the partition of the group with the given key.
+func spillPartition(key uint64) int {
+	return int(key % spillPartitions)
+}
+
+// labelFields returns the fields of the label columns of spilled state. They
+// are stored with the same metadata as the label columns of the results, so
+// that they can be mapped back to their column references.
+func labelFields(columns []types.ColumnRef) []arrow.Field {
+	fields := make([]arrow.Field, 0, len(columns))
+	for _, column := range columns {
+		fields = append(fields, arrow.Field{
+			Name:     column.Column,
+			Type:     datatype.Arrow.String,
+			Nullable: true,
+			Metadata: datatype.ColumnMetadata(column.Type, datatype.Loki.String),
+		})
+	}
+	return fields
+}
+
+// appendLabelValues appends the label values to the label builders of a
+// record builder, starting at the field with the given offset.
+func appendLabelValues(rb *array.RecordBuilder, offset, columns int, labelValues []string) {
+	for col := range columns {
+		builder := rb.Field(col + offset).(*array.StringBuilder)
+
+		// state created before a column was discovered has less label values
+		if col >= len(labelValues) || labelValues[col] == "" {
+			builder.AppendNull()
+		} else {
+			builder.Append(labelValues[col])
+		}
+	}
+}
+
+// spilledLabelArrays returns the label columns of a spilled record, starting
+// at the field with the given offset, mapped to the column indexes returned
+// by columnIndex.
+func spilledLabelArrays(record arrow.Record, offset int, columnIndex func(types.ColumnRef) int) ([]partitionArray, error) {
+	fields := record.Schema().Fields()
+	arrays := make([]partitionArray, 0, len(fields)-offset)
+	for i := offset; i < len(fields); i++ {
+		ct, ok := fields[i].Metadata.GetValue(types.MetadataKeyColumnType)
+		if !ok {
+			return nil, fmt.Errorf("missing column type of spilled column %s", fields[i].Name)
+		}
+		arr, ok := record.Column(i).(*array.String)
+		if !ok {
+			return nil, fmt.Errorf("unexpected array type %T for spilled column %s", record.Column(i), fields[i].Name)
+		}
+
+		index := columnIndex(types.ColumnRef{Column: fields[i].Name, Type: types.ColumnTypeFromString(ct)})
+		arrays = append(arrays, partitionArray{index: index, array: arr})
+	}
+	return arrays, nil
+}
+
+// labelValuesSize returns the estimated memory used by a slice of label values.
+func labelValuesSize(labelValues []string) int64 {
+	size := int64(16 * len(labelValues)) // string headers
+	for _, v := range labelValues {
+		size += int64(len(v))
+	}
+	return size
+}
@@ -0,0 +1,239 @@
+package executor
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/apache/arrow-go/v18/arrow"
+	"github.com/apache/arrow-go/v18/arrow/array"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/loki/v3/pkg/engine/internal/datatype"
+	"github.com/grafana/loki/v3/pkg/engine/internal/types"
+	"github.com/grafana/loki/v3/pkg/engine/planner/physical"
+)
+
+// readSamples reads all records of the pipeline and returns their rows keyed
+// by timestamp and labels.
+func readSamples(t *testing.T, pipeline Pipeline) map[string]float64 {
+	t.Helper()
+
+	samples := make(map[string]float64)
+	for {
+		err := pipeline.Read(t.Context())
+		if errors.Is(err, EOF) {
+			return samples
+		}
+		require.NoError(t, err)
+
+		record, _ := pipeline.Value()
+		for i := range int(record.NumRows()) {
+			ts := record.Column(0).(*array.Timestamp).Value(i).ToTime(arrow.Nanosecond)
+
+			lbls := make([]string, 0, record.NumCols()-2)
+			for col := 2; col < int(record.NumCols()); col++ {
+				arr := record.Column(col).(*array.String)
+				if arr.IsValid(i) {
+					lbls = append(lbls, fmt.Sprintf("%s=%s", record.ColumnName(col), arr.Value(i)))
+				}
+			}
+
+			key := fmt.Sprintf("%d{%s}", ts.Unix(), strings.Join(lbls, ","))
+			require.NotContains(t, samples, key, "duplicate sample")
+			samples[key] = record.Column(1).(*array.Float64).Value(i)
+		}
+	}
+}
+
+// spillTestInput returns a pipeline that reads each row as a separate
+// record, so that the state of aggregations is spilled after every row.
+func spillTestInput(t *testing.T, fields []arrow.Field, rows []string) Pipeline {
+	t.Helper()
+
+	records := make([]arrow.Record, 0, len(rows))
+	for _, row := range rows {
+		record, err := CSVToArrow(fields, row)
+		require.NoError(t, err)
+		records = append(records, record)
+	}
+	return NewBufferedPipeline(records...)
+}
+
+func requireSamplesInDelta(t *testing.T, expected, actual map[string]float64) {
+	t.Helper()
+
+	require.Len(t, actual, len(expected))
+	for key, value := range expected {
+		require.Contains(t, actual, key)
+		require.InDelta(t, value, actual[key], 1e-9, key)
+	}
+}
+
+func TestVectorAggregationPipeline_Spill(t *testing.T) {
+	fields := []arrow.Field{
+		{Name: types.ColumnNameBuiltinTimestamp, Type: datatype.Arrow.Timestamp, Metadata: datatype.ColumnMetadataBuiltinTimestamp},
+		{Name: types.ColumnNameGeneratedValue, Type: datatype.Arrow.Float, Metadata: datatype.ColumnMetadata(types.ColumnTypeGenerated, datatype.Loki.Float)},
+		{Name: "env", Type: datatype.Arrow.String, Metadata: datatype.ColumnMetadata(types.ColumnTypeLabel, datatype.Loki.String)},
+		{Name: "pod", Type: datatype.Arrow.String, Metadata: datatype.ColumnMetadata(types.ColumnTypeLabel, datatype.Loki.String)},
+	}
+
+	var rows []string
+	for i := range 40 {
+		ts := time.Unix(int64(1000+60*(i%3)), 0).UTC()
+		env := []string{"prod", "dev"}[i%2]
+		rows = append(rows, fmt.Sprintf("%s,%d,%s,pod-%d", ts.Format(arrowTimestampFormat), i*7%11, env, i%13))
+	}
+
+	columns := func(names ...string) []physical.ColumnExpression {
+		exprs := make([]physical.ColumnExpression, 0, len(names))
+		for _, name := range names {
+			exprs = append(exprs, &physical.ColumnExpr{Ref: types.ColumnRef{Column: name, Type: types.ColumnTypeAmbiguous}})
+		}
+		return exprs
+	}
+
+	for _, opts := range []vectorAggregationOptions{
+		{operation: types.VectorAggregationTypeSum, groupBy: columns("pod")},
+		{operation: types.VectorAggregationTypeAvg, groupBy: columns("env")},
+		{operation: types.VectorAggregationTypeMin, groupBy: columns("pod"), without: true},
+		{operation: types.VectorAggregationTypeMax},
+		{operation: types.VectorAggregationTypeCount, groupBy: columns("env", "pod")},
+		{operation: types.VectorAggregationTypeStddev, groupBy: columns("env")},
+		{operation: types.VectorAggregationTypeStdvar, groupBy: columns("pod")},
+		{operation: types.VectorAggregationTypeTopK, groupBy: columns("env"), parameter: 3},
+		{operation: types.VectorAggregationTypeBottomK, groupBy: columns("pod"), without: true, parameter: 2},
+	} {
+		t.Run(opts.operation.String(), func(t *testing.T) {
+			run := func(budget *memoryBudget) map[string]float64 {
+				opts := opts
+				opts.budget = budget
+
+				pipeline, err := NewVectorAggregationPipeline([]Pipeline{spillTestInput(t, fields, rows)}, expressionEvaluator{}, opts)
+				require.NoError(t, err)
+				defer pipeline.Close()
+
+				samples := readSamples(t, pipeline)
+				if budget != nil {
+					require.True(t, pipeline.spiller.Spilled())
+					require.Zero(t, budget.used.Load())
+				}
+				return samples
+			}
+
+			dir := t.TempDir()
+			expected := run(nil)
+			requireSamplesInDelta(t, expected, run(newMemoryBudget(1, 0, dir)))
+
+			// spill files are removed when the pipeline is closed
+			entries, err := os.ReadDir(dir)
+			require.NoError(t, err)
+			require.Empty(t, entries)
+		})
+	}
+}
+
+func TestRangeAggregationPipeline_Spill(t *testing.T) {
+	fields := []arrow.Field{
+		{Name: types.ColumnNameBuiltinTimestamp, Type: datatype.Arrow.Timestamp, Metadata: datatype.ColumnMetadataBuiltinTimestamp},
+		{Name: "env", Type: datatype.Arrow.String, Metadata: datatype.ColumnMetadata(types.ColumnTypeLabel, datatype.Loki.String)},
+		{Name: "pod", Type: datatype.Arrow.String, Metadata: datatype.ColumnMetadata(types.ColumnTypeLabel, datatype.Loki.String)},
+		{Name: "latency", Type: datatype.Arrow.String, Metadata: datatype.ColumnMetadata(types.ColumnTypeMetadata, datatype.Loki.String)},
+	}
+
+	start := time.Unix(1000, 0).UTC()
+	end := start.Add(10 * time.Minute)
+
+	var rows []string
+	for i := range 60 {
+		ts := start.Add(time.Duration(i*10) * time.Second)
+		env := []string{"prod", "dev"}[i%2]
+		rows = append(rows, fmt.Sprintf("%s,%s,pod-%d,%dms", ts.Format(arrowTimestampFormat), env, i%7, i*37%101))
+	}
+
+	latency := &physical.Unwrap{
+		Column:     &physical.ColumnExpr{Ref: types.ColumnRef{Column: "latency", Type: types.ColumnTypeAmbiguous}},
+		Conversion: types.ConversionTypeDuration,
+	}
+
+	for _, tt := range []struct {
+		operation types.RangeAggregationType
+		parameter float64
+		unwrap    *physical.Unwrap
+	}{
+		{operation: types.RangeAggregationTypeCount},
+		{operation: types.RangeAggregationTypeRate},
+		{operation: types.RangeAggregationTypeSum, unwrap: latency},
+		{operation: types.RangeAggregationTypeAvg, unwrap: latency},
+		{operation: types.RangeAggregationTypeMin, unwrap: latency},
+		{operation: types.RangeAggregationTypeMax, unwrap: latency},
+		{operation: types.RangeAggregationTypeFirst, unwrap: latency},
+		{operation: types.RangeAggregationTypeLast, unwrap: latency},
+		{operation: types.RangeAggregationTypeStddev, unwrap: latency},
+		{operation: types.RangeAggregationTypeStdvar, unwrap: latency},
+		{operation: types.RangeAggregationTypeQuantile, parameter: 0.9, unwrap: latency},
+	} {
+		t.Run(tt.operation.String(), func(t *testing.T) {
+			run := func(budget *memoryBudget) map[string]float64 {
+				pipeline, err := NewRangeAggregationPipeline([]Pipeline{spillTestInput(t, fields, rows)}, expressionEvaluator{}, rangeAggregationOptions{
+					partitionBy: []physical.ColumnExpression{
+						&physical.ColumnExpr{Ref: types.ColumnRef{Column: "env", Type: types.ColumnTypeAmbiguous}},
+						&physical.ColumnExpr{Ref: types.ColumnRef{Column: "pod", Type: types.ColumnTypeAmbiguous}},
+					},
+					operation:     tt.operation,
+					parameter:     tt.parameter,
+					unwrap:        tt.unwrap,
+					startTs:       start,
+					endTs:         end,
+					rangeInterval: 2 * time.Minute,
+					step:          time.Minute,
+					budget:        budget,
+				})
+				require.NoError(t, err)
+				defer pipeline.Close()
+
+				samples := readSamples(t, pipeline)
+				if budget != nil {
+					require.True(t, pipeline.spiller.Spilled())
+					require.Zero(t, budget.used.Load())
+				}
+				return samples
+			}
+
+			dir := t.TempDir()
+			expected := run(nil)
+			requireSamplesInDelta(t, expected, run(newMemoryBudget(1, 0, dir)))
+
+			// spill files are removed when the pipeline is closed
+			entries, err := os.ReadDir(dir)
+			require.NoError(t, err)
+			require.Empty(t, entries)
+		})
+	}
+}
+
+func TestAggregationPipeline_SpillLimit(t *testing.T) {
+	fields := []arrow.Field{
+		{Name: types.ColumnNameBuiltinTimestamp, Type: datatype.Arrow.Timestamp, Metadata: datatype.ColumnMetadataBuiltinTimestamp},
+		{Name: types.ColumnNameGeneratedValue, Type: datatype.Arrow.Float, Metadata: datatype.ColumnMetadata(types.ColumnTypeGenerated, datatype.Loki.Float)},
+		{Name: "pod", Type: datatype.Arrow.String, Metadata: datatype.ColumnMetadata(types.ColumnTypeLabel, datatype.Loki.String)},
+	}
+
+	record, err := CSVToArrow(fields, fmt.Sprintf("%s,1,pod-1", time.Unix(1000, 0).UTC().Format(arrowTimestampFormat)))
+	require.NoError(t, err)
+	defer record.Release()
+
+	pipeline, err := NewVectorAggregationPipeline([]Pipeline{NewBufferedPipeline(record)}, expressionEvaluator{}, vectorAggregationOptions{
+		operation: types.VectorAggregationTypeSum,
+		groupBy:   []physical.ColumnExpression{&physical.ColumnExpr{Ref: types.ColumnRef{Column: "pod", Type: types.ColumnTypeAmbiguous}}},
+		budget:    newMemoryBudget(1, 1, t.TempDir()),
+	})
+	require.NoError(t, err)
+	defer pipeline.Close()
+
+	err = pipeline.Read(t.Context())
+	require.ErrorContains(t, err, "aggregations exceed the spill limit of 1 B")
+}
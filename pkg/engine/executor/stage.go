@@ -0,0 +1,354 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/apache/arrow-go/v18/arrow"
+	"github.com/apache/arrow-go/v18/arrow/array"
+	"github.com/apache/arrow-go/v18/arrow/memory"
+	"github.com/prometheus/prometheus/model/labels"
+
+	"github.com/grafana/loki/v3/pkg/engine/internal/datatype"
+	"github.com/grafana/loki/v3/pkg/engine/internal/types"
+	"github.com/grafana/loki/v3/pkg/logql/log"
+	"github.com/grafana/loki/v3/pkg/logqlmodel"
+)
+
+// newStagePipeline returns a pipeline that applies a stage of the old engine
+// to each row of its input.
+func newStagePipeline(p *stageProcessor, input Pipeline) *GenericPipeline {
+	return newGenericPipeline(Local, func(ctx context.Context, inputs []Pipeline) state {
+		// Pull the next item from the input pipeline
+		input := inputs[0]
+		err := input.Read(ctx)
+		if err != nil {
+			return failureState(err)
+		}
+
+		batch, err := input.Value()
+		if err != nil {
+			return failureState(err)
+		}
+
+		processed, err := p.process(batch)
+		batch.Release()
+		if err != nil {
+			return failureState(err)
+		}
+		return successState(processed)
+	}, input)
+}
+
+// stageProcessor applies a [log.Stage] of the old engine to the rows of a
+// record.
+//
+// For each row, the label, metadata and parsed columns are loaded into a
+// [log.LabelsBuilder] as stream labels, structured metadata and parsed labels.
+// After the stage has been processed, the labels of each category are written
+// back into the columns of the same type. Parsed labels that do not have a
+// column yet are added as new columns of type [types.ColumnTypeParsed].
+type stageProcessor struct {
+	stage log.Stage
+	base  *log.BaseLabelsBuilder
+
+	// rewriteLine is true if the stage may replace the log line.
+	rewriteLine bool
+
+	// reused for each row
+	lbls []labels.Label
+}
+
+func newStageProcessor(stage log.Stage, rewriteLine bool) *stageProcessor {
+	return &stageProcessor{
+		stage:       stage,
+		base:        log.NewBaseLabelsBuilder(),
+		rewriteLine: rewriteLine,
+	}
+}
+
+// stageColumn holds the values of a label, metadata or parsed column of a
+// processed record.
+type stageColumn struct {
+	values []string
+	valid  []bool
+}
+
+func newStageColumn(numRows int) *stageColumn {
+	return &stageColumn{
+		values: make([]string, numRows),
+		valid:  make([]bool, numRows),
+	}
+}
+
+func (p *stageProcessor) process(batch arrow.Record) (arrow.Record, error) {
+	var (
+		messageIdx   = -1
+		timestampIdx = -1
+
+		labelIdxs    []int // indexes of the stream label columns
+		metadataIdxs []int // indexes of the structured metadata columns
+		parsedIdxs   []int // indexes of the columns of previous stages
+	)
+
+	for i, field := range batch.Schema().Fields() {
+		ct, ok := field.Metadata.GetValue(types.MetadataKeyColumnType)
+		if !ok {
+			continue
+		}
+
+		switch {
+		case ct == types.ColumnTypeBuiltin.String() && field.Name == types.ColumnNameBuiltinMessage:
+			messageIdx = i
+		case ct == types.ColumnTypeBuiltin.String() && field.Name == types.ColumnNameBuiltinTimestamp:
+			timestampIdx = i
+		case ct == types.ColumnTypeLabel.String() && field.Type.ID() == arrow.STRING:
+			labelIdxs = append(labelIdxs, i)
+		case ct == types.ColumnTypeMetadata.String() && field.Type.ID() == arrow.STRING:
+			metadataIdxs = append(metadataIdxs, i)
+		case ct == types.ColumnTypeParsed.String() && field.Type.ID() == arrow.STRING:
+			parsedIdxs = append(parsedIdxs, i)
+		}
+	}
+
+	if messageIdx < 0 {
+		return nil, fmt.Errorf("missing column %s", types.ColumnNameBuiltinMessage)
+	}
+	messages, ok := batch.Column(messageIdx).(*array.String)
+	if !ok {
+		return nil, fmt.Errorf("unexpected array type %T for column %s", batch.Column(messageIdx), types.ColumnNameBuiltinMessage)
+	}
+
+	var timestamps *array.Timestamp
+	if timestampIdx >= 0 {
+		timestamps, _ = batch.Column(timestampIdx).(*array.Timestamp)
+	}
+
+	var (
+		numRows = int(batch.NumRows())
+		lines   []string
+		include = make([]bool, numRows)
+		dropped bool
+
+		labelColumns    = make(map[string]*stageColumn, len(labelIdxs))
+		metadataColumns = make(map[string]*stageColumn, len(metadataIdxs))
+		parsedColumns   = make(map[string]*stageColumn)
+	)
+	if p.rewriteLine {
+		lines = make([]string, numRows)
+	}
+	for _, idx := range labelIdxs {
+		labelColumns[batch.ColumnName(idx)] = newStageColumn(numRows)
+	}
+	for _, idx := range metadataIdxs {
+		metadataColumns[batch.ColumnName(idx)] = newStageColumn(numRows)
+	}
+
+	for row := range numRows {
+		lb := p.labelsBuilder(batch, labelIdxs, row)
+
+		for _, idx := range metadataIdxs {
+			name, value := batch.ColumnName(idx), stringValue(batch, idx, row)
+			switch {
+			case value == "":
+				continue
+			case lb.BaseHas(name):
+				// The old engine renames structured metadata that collides
+				// with stream labels, so the stage sees the stream label
+				// instead. The metadata column is kept as is.
+				metadataColumns[name].values[row] = value
+				metadataColumns[name].valid[row] = true
+			default:
+				lb.Set(log.StructuredMetadataLabel, name, value)
+			}
+		}
+
+		// Make columns of previous stages visible to the current stage.
+		for _, idx := range parsedIdxs {
+			value := stringValue(batch, idx, row)
+			if value == "" {
+				continue
+			}
+
+			switch name := batch.ColumnName(idx); name {
+			case logqlmodel.ErrorLabel:
+				lb.SetErr(value)
+			case logqlmodel.ErrorDetailsLabel:
+				lb.SetErrorDetails(value)
+			default:
+				lb.Set(log.ParsedLabel, name, value)
+			}
+		}
+
+		var ts int64
+		if timestamps != nil {
+			ts = int64(timestamps.Value(row))
+		}
+
+		var line string
+		if messages.IsValid(row) {
+			line = messages.Value(row)
+		}
+
+		result, ok := p.stage.Process(ts, []byte(line), lb)
+		include[row] = ok
+		dropped = dropped || !ok
+		if p.rewriteLine {
+			lines[row] = string(result)
+		}
+
+		// The labels result is the same as the result of the pipeline of the
+		// old engine, which also drops the __error_details__ label if the
+		// __error__ label has been removed.
+		res := lb.LabelsResult()
+		setStageColumns(labelColumns, res.Stream(), row, nil)
+		setStageColumns(metadataColumns, res.StructuredMetadata(), row, nil)
+		setStageColumns(parsedColumns, res.Parsed(), row, func(name string) *stageColumn {
+			if name == logqlmodel.PreserveErrorLabel {
+				return nil
+			}
+			return newStageColumn(numRows)
+		})
+	}
+
+	// Parsed columns of previous stages are replaced by the parsed columns of
+	// the current stage, which also hold their values.
+	fields := make([]arrow.Field, 0, int(batch.NumCols())-len(parsedIdxs)+len(parsedColumns))
+	arrays := make([]arrow.Array, 0, cap(fields))
+	for i, field := range batch.Schema().Fields() {
+		if slices.Contains(parsedIdxs, i) {
+			continue
+		}
+
+		var col *stageColumn
+		switch {
+		case slices.Contains(labelIdxs, i):
+			col = labelColumns[field.Name]
+		case slices.Contains(metadataIdxs, i):
+			col = metadataColumns[field.Name]
+		}
+
+		var arr arrow.Array
+		switch {
+		case i == messageIdx && p.rewriteLine:
+			arr = newStringArray(lines, nil)
+		case col != nil && col.changedFrom(batch, i):
+			arr = newStringArray(col.values, col.valid)
+		default:
+			arr = batch.Column(i)
+			arr.Retain()
+		}
+
+		fields = append(fields, field)
+		arrays = append(arrays, arr)
+	}
+
+	names := make([]string, 0, len(parsedColumns))
+	for name := range parsedColumns {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	for _, name := range names {
+		col := parsedColumns[name]
+		// Rows without an error have an empty error column, so that filters
+		// such as __error__="" match them.
+		if name == logqlmodel.ErrorLabel || name == logqlmodel.ErrorDetailsLabel {
+			col.valid = nil
+		}
+
+		fields = append(fields, arrow.Field{
+			Name:     name,
+			Type:     datatype.Arrow.String,
+			Nullable: true,
+			Metadata: datatype.ColumnMetadata(types.ColumnTypeParsed, datatype.Loki.String),
+		})
+		arrays = append(arrays, newStringArray(col.values, col.valid))
+	}
+
+	record := array.NewRecord(arrow.NewSchema(fields, nil), arrays, int64(numRows))
+	for _, arr := range arrays {
+		arr.Release()
+	}
+
+	if dropped {
+		defer record.Release()
+		return filterBatch(record, func(i int) bool { return include[i] }), nil
+	}
+	return record, nil
+}
+
+// setStageColumns sets the values of the given labels at the row of the
+// columns. If newColumn is nil, labels without column are ignored. Otherwise
+// newColumn is called to create missing columns, and labels are ignored if it
+// returns nil.
+func setStageColumns(columns map[string]*stageColumn, lbls labels.Labels, row int, newColumn func(name string) *stageColumn) {
+	lbls.Range(func(l labels.Label) {
+		col, ok := columns[l.Name]
+		if !ok {
+			if newColumn == nil {
+				return
+			}
+			if col = newColumn(l.Name); col == nil {
+				return
+			}
+			columns[l.Name] = col
+		}
+		col.values[row] = l.Value
+		col.valid[row] = true
+	})
+}
+
+// changedFrom reports whether the values of the column differ from the values
+// of the input column at index idx of batch. Empty values and NULL are treated
+// as equal, since both denote a missing label.
+func (c *stageColumn) changedFrom(batch arrow.Record, idx int) bool {
+	for row := range c.values {
+		var value string
+		if c.valid[row] {
+			value = c.values[row]
+		}
+		if value != stringValue(batch, idx, row) {
+			return true
+		}
+	}
+	return false
+}
+
+// labelsBuilder returns a reset [log.LabelsBuilder] with the stream labels of
+// the row as base labels. Parsers use the base labels to detect collisions of
+// extracted keys with existing labels.
+func (p *stageProcessor) labelsBuilder(batch arrow.Record, labelIdxs []int, row int) *log.LabelsBuilder {
+	p.lbls = p.lbls[:0]
+	for _, idx := range labelIdxs {
+		if value := stringValue(batch, idx, row); value != "" {
+			p.lbls = append(p.lbls, labels.Label{Name: batch.ColumnName(idx), Value: value})
+		}
+	}
+
+	lbs := labels.New(p.lbls...)
+	lb := p.base.ForLabels(lbs, lbs.Hash())
+	lb.Reset()
+	return lb
+}
+
+// stringValue returns the value of the string column at index idx of batch,
+// or an empty string if the value is NULL.
+func stringValue(batch arrow.Record, idx, row int) string {
+	arr := batch.Column(idx).(*array.String)
+	if !arr.IsValid(row) {
+		return ""
+	}
+	return arr.Value(row)
+}
+
+// newStringArray returns a string array of the given values. If valid is
+// non-nil, values at indexes that are not valid are set to NULL.
+func newStringArray(values []string, valid []bool) arrow.Array {
+	builder := array.NewStringBuilder(memory.NewGoAllocator())
+	defer builder.Release()
+
+	builder.AppendValues(values, valid)
+	return builder.NewArray()
+}
@@ -0,0 +1,85 @@
+package executor
+
+import (
+	"context"
+	"time"
+
+	"github.com/apache/arrow-go/v18/arrow"
+	"github.com/apache/arrow-go/v18/arrow/array"
+	"github.com/apache/arrow-go/v18/arrow/memory"
+	"github.com/prometheus/prometheus/model/labels"
+
+	"github.com/grafana/loki/v3/pkg/engine/internal/datatype"
+	"github.com/grafana/loki/v3/pkg/engine/internal/types"
+	"github.com/grafana/loki/v3/pkg/engine/planner/physical"
+)
+
+// NewVectorPipeline returns a pipeline that produces a single series without
+// labels, which has the value of the vector at each step of the query.
+func NewVectorPipeline(vector *physical.Vector) *GenericPipeline {
+	var done bool
+	return newGenericPipeline(Local, func(_ context.Context, _ []Pipeline) state {
+		if done {
+			return Exhausted
+		}
+		done = true
+
+		timestamps := stepTimestamps(vector.Start, vector.End, vector.Step)
+		return successState(newSeriesRecord(timestamps, vector.Value, labels.EmptyLabels()))
+	})
+}
+
+// stepTimestamps returns the timestamps of the steps between start and end
+// (both inclusive). Instant queries (step = 0) have a single step at end,
+// which is the same as the windows of range aggregations.
+func stepTimestamps(start, end time.Time, step time.Duration) []time.Time {
+	if step <= 0 {
+		return []time.Time{end}
+	}
+
+	timestamps := make([]time.Time, 0, end.Sub(start)/step+1)
+	for ts := start; !ts.After(end); ts = ts.Add(step) {
+		timestamps = append(timestamps, ts)
+	}
+	return timestamps
+}
+
+// newSeriesRecord returns a record of a single series with the given labels,
+// which has the same value at each of the timestamps.
+func newSeriesRecord(timestamps []time.Time, value float64, lbls labels.Labels) arrow.Record {
+	fields := make([]arrow.Field, 0, lbls.Len()+2)
+	fields = append(fields,
+		arrow.Field{
+			Name:     types.ColumnNameBuiltinTimestamp,
+			Type:     datatype.Arrow.Timestamp,
+			Nullable: false,
+			Metadata: datatype.ColumnMetadataBuiltinTimestamp,
+		},
+		valueField(),
+	)
+	lbls.Range(func(l labels.Label) {
+		fields = append(fields, arrow.Field{
+			Name:     l.Name,
+			Type:     datatype.Arrow.String,
+			Nullable: true,
+			Metadata: datatype.ColumnMetadata(types.ColumnTypeLabel, datatype.Loki.String),
+		})
+	})
+
+	rb := array.NewRecordBuilder(memory.NewGoAllocator(), arrow.NewSchema(fields, nil))
+	defer rb.Release()
+
+	for _, t := range timestamps {
+		ts, _ := arrow.TimestampFromTime(t, arrow.Nanosecond)
+		rb.Field(0).(*array.TimestampBuilder).Append(ts)
+		rb.Field(1).(*array.Float64Builder).Append(value)
+
+		col := 2 // offset by 2 as the first 2 fields are timestamp and value
+		lbls.Range(func(l labels.Label) {
+			rb.Field(col).(*array.StringBuilder).Append(l.Value)
+			col++
+		})
+	}
+
+	return rb.NewRecord()
+}
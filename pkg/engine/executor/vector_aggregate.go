@@ -1,13 +1,16 @@
 package executor
 
 import (
+	"container/heap"
 	"context"
 	"errors"
 	"fmt"
 	"maps"
+	"math"
 	"slices"
 	"strings"
 	"time"
+	"unsafe"
 
 	"github.com/apache/arrow-go/v18/arrow"
 	"github.com/apache/arrow-go/v18/arrow/array"
@@ -19,32 +22,74 @@ import (
 	"github.com/grafana/loki/v3/pkg/engine/planner/physical"
 )
 
+type vectorAggregationOptions struct {
+	operation types.VectorAggregationType
+	groupBy   []physical.ColumnExpression // columns to group by, or to exclude from grouping if without is set
+	without   bool                        // group by all labels except the groupBy columns
+	parameter int                         // parameter of the operation, e.g. k of topk
+
+	budget *memoryBudget // memory budget of the query, nil if spilling is disabled
+}
+
 // VectorAggregationPipeline is a pipeline that performs vector aggregations.
 //
 // It reads from the input pipeline, groups the data by specified columns,
 // and applies the aggregation function on each group.
+//
+// Operations that preserve series (topk, bottomk, sort, sort_desc) return the
+// selected input rows with all their labels instead of one row per group.
+//
+// When the aggregations of the query exceed their memory budget, the state of
+// the groups is spilled to disk. The spilled state is merged one partition at
+// a time once all inputs are read, and each partition is returned as its own
+// record.
 type VectorAggregationPipeline struct {
 	state  state
 	inputs []Pipeline
 
 	aggregator *vectorAggregator
 	evaluator  expressionEvaluator
-	groupBy    []physical.ColumnExpression
+	opts       vectorAggregationOptions
+
+	spiller   *spiller // holds the spilled state of the groups
+	partition int      // next spilled partition to return
+
+	excluded map[string]struct{} // names of the labels excluded from grouping when opts.without is set
 
 	tsEval    evalFunc // used to evaluate the timestamp column
 	valueEval evalFunc // used to evaluate the value column
 }
 
-func NewVectorAggregationPipeline(inputs []Pipeline, groupBy []physical.ColumnExpression, evaluator expressionEvaluator) (*VectorAggregationPipeline, error) {
+func NewVectorAggregationPipeline(inputs []Pipeline, evaluator expressionEvaluator, opts vectorAggregationOptions) (*VectorAggregationPipeline, error) {
 	if len(inputs) == 0 {
 		return nil, fmt.Errorf("vector aggregation expects at least one input")
 	}
 
+	excluded := make(map[string]struct{})
+	if opts.without {
+		for _, column := range opts.groupBy {
+			columnExpr, ok := column.(*physical.ColumnExpr)
+			if !ok {
+				return nil, fmt.Errorf("invalid column expression type %T", column)
+			}
+			excluded[columnExpr.Ref.Column] = struct{}{}
+		}
+	}
+
+	// The output labels of aggregations grouped by labels are known upfront.
+	// All other operations discover the output labels from their inputs.
+	var columns []physical.ColumnExpression
+	if !opts.without && !opts.operation.PreservesSeries() {
+		columns = opts.groupBy
+	}
+
 	return &VectorAggregationPipeline{
 		inputs:     inputs,
 		evaluator:  evaluator,
-		groupBy:    groupBy,
-		aggregator: newVectorAggregator(groupBy),
+		opts:       opts,
+		excluded:   excluded,
+		aggregator: newVectorAggregator(opts.operation, opts.parameter, columns, opts.budget),
+		spiller:    newSpiller(opts.budget),
 		tsEval: evaluator.newFunc(&physical.ColumnExpr{
 			Ref: types.ColumnRef{
 				Column: types.ColumnNameBuiltinTimestamp,
@@ -81,8 +126,14 @@ func (v *VectorAggregationPipeline) Read(ctx context.Context) error {
 }
 
 func (v *VectorAggregationPipeline) read(ctx context.Context) (arrow.Record, error) {
+	if v.spiller.Spilled() {
+		return v.readSpilled()
+	}
+
 	var (
-		labelValues = make([]string, len(v.groupBy))
+		// reused on each row read
+		labelValues []string
+		groupValues []string
 	)
 
 	v.aggregator.Reset() // reset before reading new inputs
@@ -114,35 +165,71 @@ func (v *VectorAggregationPipeline) read(ctx context.Context) (arrow.Record, err
 			if err != nil {
 				return nil, err
 			}
-			valueArr := valueVec.ToArray().(*array.Int64)
+			values, err := float64Values(valueVec)
+			if err != nil {
+				return nil, err
+			}
 
-			// extract all the columns that are used for grouping
-			arrays := make([]*array.String, 0, len(v.groupBy))
-			for _, columnExpr := range v.groupBy {
-				vec, err := v.evaluator.eval(columnExpr, record)
-				if err != nil {
-					return nil, err
-				}
+			// extract the columns that become the labels of the output
+			labelArrays, err := v.labelArrays(record)
+			if err != nil {
+				return nil, err
+			}
 
-				if vec.Type() != datatype.Loki.String {
-					return nil, fmt.Errorf("unsupported datatype for grouping %s", vec.Type())
-				}
+			// extract the columns that are used for grouping
+			groupArrays, err := v.groupArrays(record, labelArrays)
+			if err != nil {
+				return nil, err
+			}
 
-				arrays = append(arrays, vec.ToArray().(*array.String))
+			if n := v.aggregator.NumOfColumns(); len(labelValues) < n {
+				labelValues = make([]string, n)
+			}
+			if n := max(v.aggregator.NumOfColumns(), len(v.opts.groupBy)); len(groupValues) < n {
+				groupValues = make([]string, n)
 			}
 
 			for row := range int(record.NumRows()) {
 				// reset for each row
 				clear(labelValues)
-				for col, arr := range arrays {
-					labelValues[col] = arr.Value(row)
+				for _, arr := range labelArrays {
+					if arr.array.IsValid(row) {
+						labelValues[arr.index] = arr.array.Value(row)
+					}
 				}
 
-				v.aggregator.Add(tsCol.Value(row).ToTime(arrow.Nanosecond), valueArr.Value(row), labelValues)
+				// aggregations are grouped by the labels of their output
+				if !v.opts.operation.PreservesSeries() {
+					v.aggregator.Add(tsCol.Value(row).ToTime(arrow.Nanosecond), values(row), labelValues, labelValues)
+					continue
+				}
+
+				clear(groupValues)
+				for _, arr := range groupArrays {
+					if arr.array.IsValid(row) {
+						groupValues[arr.index] = arr.array.Value(row)
+					}
+				}
+
+				v.aggregator.Add(tsCol.Value(row).ToTime(arrow.Nanosecond), values(row), labelValues, groupValues)
+			}
+
+			if v.opts.budget.exceeded() {
+				if err := v.aggregator.Spill(v.spiller); err != nil {
+					return nil, err
+				}
 			}
 		}
 	}
 
+	if v.spiller.Spilled() {
+		// spill the remaining state so that all groups are merged from disk
+		if err := v.aggregator.Spill(v.spiller); err != nil {
+			return nil, err
+		}
+		return v.readSpilled()
+	}
+
 	if v.aggregator.NumOfPoints() == 0 {
 		return nil, EOF // no values to aggregate & reached EOF
 	}
@@ -150,6 +237,123 @@ func (v *VectorAggregationPipeline) read(ctx context.Context) (arrow.Record, err
 	return v.aggregator.buildRecord()
 }
 
+// readSpilled merges the state of the next non-empty spilled partition and
+// returns its results.
+func (v *VectorAggregationPipeline) readSpilled() (arrow.Record, error) {
+	for v.partition < spillPartitions {
+		partition := v.partition
+		v.partition++
+
+		v.aggregator.Reset()
+		if err := v.spiller.Read(partition, v.aggregator.Merge); err != nil {
+			return nil, err
+		}
+		if v.aggregator.NumOfPoints() > 0 {
+			return v.aggregator.buildRecord()
+		}
+	}
+
+	v.aggregator.Reset()
+	return nil, EOF
+}
+
+// labelArrays returns the columns of the record that become the labels of the
+// output rows.
+func (v *VectorAggregationPipeline) labelArrays(record arrow.Record) ([]partitionArray, error) {
+	switch {
+	case v.opts.operation.PreservesSeries():
+		// the output rows keep all labels of the input series
+		return v.seriesArrays(record, nil)
+	case v.opts.without:
+		return v.seriesArrays(record, v.excluded)
+	default:
+		arrays := make([]partitionArray, 0, len(v.opts.groupBy))
+		for i, columnExpr := range v.opts.groupBy {
+			vec, err := v.evaluator.eval(columnExpr, record)
+			if err != nil {
+				return nil, err
+			}
+
+			if vec.Type() != datatype.Loki.String {
+				return nil, fmt.Errorf("unsupported datatype for grouping %s", vec.Type())
+			}
+
+			arrays = append(arrays, partitionArray{index: i, array: vec.ToArray().(*array.String)})
+		}
+		return arrays, nil
+	}
+}
+
+// groupArrays returns the columns of the record that are used to compute the
+// group of a row for operations that preserve series. Aggregations are grouped
+// by the labels of their output rows instead.
+func (v *VectorAggregationPipeline) groupArrays(record arrow.Record, labelArrays []partitionArray) ([]partitionArray, error) {
+	if !v.opts.operation.PreservesSeries() {
+		return nil, nil
+	}
+
+	if v.opts.without {
+		arrays := make([]partitionArray, 0, len(labelArrays))
+		for _, arr := range labelArrays {
+			if _, ok := v.excluded[v.aggregator.columns[arr.index].Column]; !ok {
+				arrays = append(arrays, arr)
+			}
+		}
+		return arrays, nil
+	}
+
+	arrays := make([]partitionArray, 0, len(v.opts.groupBy))
+	for i, columnExpr := range v.opts.groupBy {
+		vec, err := v.evaluator.eval(columnExpr, record)
+		if err != nil {
+			return nil, err
+		}
+
+		if vec.Type() != datatype.Loki.String {
+			return nil, fmt.Errorf("unsupported datatype for grouping %s", vec.Type())
+		}
+
+		arrays = append(arrays, partitionArray{index: i, array: vec.ToArray().(*array.String)})
+	}
+	return arrays, nil
+}
+
+// seriesArrays returns all label columns of the record, except for the ones
+// with the excluded names. Label columns are all string columns that are
+// neither builtin nor generated.
+func (v *VectorAggregationPipeline) seriesArrays(record arrow.Record, excluded map[string]struct{}) ([]partitionArray, error) {
+	var arrays []partitionArray
+	for i, field := range record.Schema().Fields() {
+		if _, ok := excluded[field.Name]; ok {
+			continue
+		}
+
+		dt, ok := field.Metadata.GetValue(types.MetadataKeyColumnDataType)
+		if !ok || dt != datatype.Loki.String.String() {
+			continue
+		}
+
+		ct, ok := field.Metadata.GetValue(types.MetadataKeyColumnType)
+		if !ok {
+			continue
+		}
+
+		columnType := types.ColumnTypeFromString(ct)
+		if columnType == types.ColumnTypeBuiltin || columnType == types.ColumnTypeGenerated {
+			continue
+		}
+
+		arr, ok := record.Column(i).(*array.String)
+		if !ok {
+			return nil, fmt.Errorf("unexpected array type %T for column %s", record.Column(i), field.Name)
+		}
+
+		index := v.aggregator.ColumnIndex(types.ColumnRef{Column: field.Name, Type: columnType})
+		arrays = append(arrays, partitionArray{index: index, array: arr})
+	}
+	return arrays, nil
+}
+
 // Value returns the current value in state.
 func (v *VectorAggregationPipeline) Value() (arrow.Record, error) {
 	return v.state.Value()
@@ -161,6 +365,9 @@ func (v *VectorAggregationPipeline) Close() {
 		v.state.batch.Release()
 	}
 
+	v.aggregator.Reset()
+	_ = v.spiller.Close()
+
 	for _, input := range v.inputs {
 		input.Close()
 	}
@@ -176,65 +383,295 @@ func (v *VectorAggregationPipeline) Transport() Transport {
 	return Local
 }
 
+// float64Values returns a function to access the values of a numeric vector as float64.
+func float64Values(vec ColumnVector) (func(row int) float64, error) {
+	switch arr := vec.ToArray().(type) {
+	case *array.Float64:
+		return arr.Value, nil
+	case *array.Int64:
+		return func(row int) float64 { return float64(arr.Value(row)) }, nil
+	default:
+		return nil, fmt.Errorf("unsupported datatype for value column %s", vec.Type())
+	}
+}
+
 type groupState struct {
-	sum         int64
+	count       int64
+	value       float64 // sum, min or max, depending on the operation
+	mean, aux   float64 // running mean and sum of squared differences (Welford's algorithm)
+	labelValues []string
+
+	series []seriesSample // input series, only used by operations that preserve series
+}
+
+// seriesSample is a single input row of operations that preserve series.
+type seriesSample struct {
+	value       float64
 	labelValues []string
 }
 
 type vectorAggregator struct {
-	groupBy []physical.ColumnExpression          // columns to group by
-	digest  *xxhash.Digest                       // used to compute key for each group
-	points  map[time.Time]map[uint64]*groupState // holds the groupState for each point in time series
+	operation types.VectorAggregationType
+	parameter int
+
+	columns []types.ColumnRef       // columns of the output labels
+	indexes map[types.ColumnRef]int // index of each column in columns
+
+	digest *xxhash.Digest                       // used to compute key for each group
+	points map[time.Time]map[uint64]*groupState // holds the groupState for each point in time series
+
+	budget *memoryBudget // memory budget of the query, nil if spilling is disabled
+	size   int64         // estimated memory used by the groups
+}
+
+func newVectorAggregator(operation types.VectorAggregationType, parameter int, columns []physical.ColumnExpression, budget *memoryBudget) *vectorAggregator {
+	a := &vectorAggregator{
+		operation: operation,
+		parameter: parameter,
+		budget:    budget,
+		indexes:   make(map[types.ColumnRef]int),
+		digest:    xxhash.New(),
+		points:    make(map[time.Time]map[uint64]*groupState),
+	}
+
+	for _, column := range columns {
+		colExpr, ok := column.(*physical.ColumnExpr)
+		if !ok {
+			panic(fmt.Sprintf("invalid column expression type %T", column))
+		}
+		a.ColumnIndex(colExpr.Ref)
+	}
+
+	return a
 }
 
-func newVectorAggregator(groupBy []physical.ColumnExpression) *vectorAggregator {
-	return &vectorAggregator{
-		groupBy: groupBy,
-		digest:  xxhash.New(),
-		points:  make(map[time.Time]map[uint64]*groupState),
+// ColumnIndex returns the index of the output label column, adding it if it is not known yet.
+func (a *vectorAggregator) ColumnIndex(ref types.ColumnRef) int {
+	if idx, ok := a.indexes[ref]; ok {
+		return idx
 	}
+
+	a.columns = append(a.columns, ref)
+	a.indexes[ref] = len(a.columns) - 1
+	return len(a.columns) - 1
 }
 
-func (a *vectorAggregator) Add(ts time.Time, value int64, labelValues []string) {
-	point, ok := a.points[ts]
-	if !ok {
-		point = make(map[uint64]*groupState)
-		a.points[ts] = point
+// Add adds the value of a row with the given output label values to the
+// group identified by groupValues at timestamp ts.
+func (a *vectorAggregator) Add(ts time.Time, value float64, labelValues, groupValues []string) {
+	state := a.groupState(ts, a.groupKey(groupValues), labelValues)
+
+	state.count++
+	switch a.operation {
+	case types.VectorAggregationTypeSum:
+		state.value += value
+
+	case types.VectorAggregationTypeAvg:
+		if math.IsInf(state.mean, 0) {
+			if math.IsInf(value, 0) && (state.mean > 0) == (value > 0) {
+				// The mean and the value are Inf of the same sign. They
+				// can't be subtracted, but the value of mean is correct already.
+				return
+			}
+			if !math.IsInf(value, 0) && !math.IsNaN(value) {
+				// The mean is infinite and the value is neither Inf nor NaN,
+				// so we keep the mean, since Inf += x - Inf would result in NaN.
+				return
+			}
+		}
+		state.mean += (value - state.mean) / float64(state.count)
+
+	case types.VectorAggregationTypeMin:
+		if state.count == 1 || state.value > value || math.IsNaN(state.value) {
+			state.value = value
+		}
+
+	case types.VectorAggregationTypeMax:
+		if state.count == 1 || state.value < value || math.IsNaN(state.value) {
+			state.value = value
+		}
+
+	case types.VectorAggregationTypeStddev, types.VectorAggregationTypeStdvar:
+		delta := value - state.mean
+		state.mean += delta / float64(state.count)
+		state.aux += delta * (value - state.mean)
+
+	case types.VectorAggregationTypeTopK, types.VectorAggregationTypeBottomK,
+		types.VectorAggregationTypeSort, types.VectorAggregationTypeSortDesc:
+		a.addSeries(state, value, labelValues)
 	}
+}
 
+// groupKey returns the key of the group identified by groupValues.
+func (a *vectorAggregator) groupKey(groupValues []string) uint64 {
 	a.digest.Reset()
-	for i, val := range labelValues {
-		if i > 0 {
-			_, _ = a.digest.Write([]byte{0}) // separator
+	for i, val := range groupValues {
+		if val == "" {
+			// empty values are equivalent to a missing label
+			continue
 		}
 
+		_, _ = a.digest.Write([]byte{byte(i >> 8), byte(i), 0})
 		_, _ = a.digest.WriteString(val)
+		_, _ = a.digest.Write([]byte{0}) // separator
+	}
+	return a.digest.Sum64()
+}
+
+// groupState returns the state of the group with the given key at timestamp
+// ts, creating it if it does not exist yet.
+func (a *vectorAggregator) groupState(ts time.Time, key uint64, labelValues []string) *groupState {
+	point, ok := a.points[ts]
+	if !ok {
+		point = make(map[uint64]*groupState)
+		a.points[ts] = point
 	}
-	key := a.digest.Sum64()
-
-	if state, ok := point[key]; ok {
-		// TODO: handle hash collisions
-		state.sum += value
-	} else {
-		// create a new slice since labelValues is reused by the calling code
-		labelValuesCopy := make([]string, len(labelValues))
-		for i, v := range labelValues {
-			// copy the value as this is backed by the arrow array data buffer.
-			// We could retain the record to avoid this copy, but that would hold
-			// all other columns in memory for as long as the query is evaluated.
-			labelValuesCopy[i] = strings.Clone(v)
-		}
 
+	state, ok := point[key]
+	// TODO: handle hash collisions
+	if !ok {
 		// TODO: add limits on number of groups
-		point[key] = &groupState{
-			labelValues: labelValuesCopy,
-			sum:         value,
+		state = &groupState{}
+		if !a.operation.PreservesSeries() {
+			state.labelValues = cloneLabelValues(labelValues)
+		}
+		point[key] = state
+		a.grow(int64(unsafe.Sizeof(groupState{})) + labelValuesSize(state.labelValues))
+	}
+	return state
+}
+
+// addSeries adds an input row to the series of the group. For topk and
+// bottomk, only the k series ranked first are kept, in a heap whose top is
+// the series ranked last.
+func (a *vectorAggregator) addSeries(state *groupState, value float64, labelValues []string) {
+	bounded := a.operation == types.VectorAggregationTypeTopK || a.operation == types.VectorAggregationTypeBottomK
+	if !bounded || len(state.series) < a.parameter {
+		sample := seriesSample{
+			value:       value,
+			labelValues: cloneLabelValues(labelValues),
+		}
+		state.series = append(state.series, sample)
+		a.grow(int64(unsafe.Sizeof(sample)) + labelValuesSize(sample.labelValues))
+		if bounded {
+			h := a.seriesHeap(state)
+			heap.Fix(h, len(h.series)-1)
 		}
+		return
+	}
+
+	h := a.seriesHeap(state)
+	if a.parameter <= 0 || compareValues(value, h.series[0].value, h.descending) >= 0 {
+		// The series isn't ranked before the last of the k series.
+		return
+	}
+	replaced := h.series[0]
+	h.series[0] = seriesSample{
+		value:       value,
+		labelValues: cloneLabelValues(labelValues),
+	}
+	heap.Fix(h, 0)
+	a.grow(labelValuesSize(h.series[0].labelValues) - labelValuesSize(replaced.labelValues))
+}
+
+func (a *vectorAggregator) seriesHeap(state *groupState) *seriesHeap {
+	return &seriesHeap{series: state.series, descending: a.operation == types.VectorAggregationTypeTopK}
+}
+
+// seriesHeap is a heap of series whose top is the series ranked last in the
+// order of topk or bottomk. It only reorders the series of a group, so it
+// never grows or shrinks.
+type seriesHeap struct {
+	series     []seriesSample
+	descending bool
+}
+
+func (h *seriesHeap) Len() int { return len(h.series) }
+func (h *seriesHeap) Less(i, j int) bool {
+	return compareValues(h.series[i].value, h.series[j].value, h.descending) > 0
+}
+func (h *seriesHeap) Swap(i, j int) { h.series[i], h.series[j] = h.series[j], h.series[i] }
+func (h *seriesHeap) Push(any)      { panic("seriesHeap can't grow") }
+func (h *seriesHeap) Pop() any      { panic("seriesHeap can't shrink") }
+
+// grow accounts n additional bytes of group state.
+func (a *vectorAggregator) grow(n int64) {
+	a.size += n
+	a.budget.grow(n)
+}
+
+// cloneLabelValues creates a new slice since labelValues is reused by the calling code.
+func cloneLabelValues(labelValues []string) []string {
+	labelValuesCopy := make([]string, len(labelValues))
+	for i, v := range labelValues {
+		// copy the value as this is backed by the arrow array data buffer.
+		// We could retain the record to avoid this copy, but that would hold
+		// all other columns in memory for as long as the query is evaluated.
+		labelValuesCopy[i] = strings.Clone(v)
+	}
+	return labelValuesCopy
+}
+
+// result returns the aggregated value of the group.
+func (a *vectorAggregator) result(state *groupState) float64 {
+	switch a.operation {
+	case types.VectorAggregationTypeAvg:
+		return state.mean
+	case types.VectorAggregationTypeCount:
+		return float64(state.count)
+	case types.VectorAggregationTypeStddev:
+		return math.Sqrt(state.aux / float64(state.count))
+	case types.VectorAggregationTypeStdvar:
+		return state.aux / float64(state.count)
+	default:
+		return state.value
+	}
+}
+
+// selectSeries returns the series of the group in the order of the
+// operation, limited to the first k series for topk and bottomk.
+func (a *vectorAggregator) selectSeries(state *groupState) []seriesSample {
+	descending := a.operation == types.VectorAggregationTypeTopK || a.operation == types.VectorAggregationTypeSortDesc
+	slices.SortStableFunc(state.series, func(x, y seriesSample) int {
+		return compareValues(x.value, y.value, descending)
+	})
+
+	if a.operation == types.VectorAggregationTypeTopK || a.operation == types.VectorAggregationTypeBottomK {
+		return state.series[:max(0, min(a.parameter, len(state.series)))]
+	}
+	return state.series
+}
+
+// compareValues compares two sample values in ascending or descending order.
+// NaN values are always ordered last.
+func compareValues(x, y float64, descending bool) int {
+	switch {
+	case math.IsNaN(x) && math.IsNaN(y):
+		return 0
+	case math.IsNaN(x):
+		return 1
+	case math.IsNaN(y):
+		return -1
+	case descending:
+		return cmpFloat(y, x)
+	default:
+		return cmpFloat(x, y)
+	}
+}
+
+func cmpFloat(x, y float64) int {
+	switch {
+	case x < y:
+		return -1
+	case x > y:
+		return 1
+	default:
+		return 0
 	}
 }
 
 func (a *vectorAggregator) buildRecord() (arrow.Record, error) {
-	fields := make([]arrow.Field, 0, len(a.groupBy)+2)
+	fields := make([]arrow.Field, 0, len(a.columns)+2)
 	fields = append(fields,
 		arrow.Field{
 			Name:     types.ColumnNameBuiltinTimestamp,
@@ -244,23 +681,18 @@ func (a *vectorAggregator) buildRecord() (arrow.Record, error) {
 		},
 		arrow.Field{
 			Name:     types.ColumnNameGeneratedValue,
-			Type:     datatype.Arrow.Integer,
+			Type:     datatype.Arrow.Float,
 			Nullable: false,
-			Metadata: datatype.ColumnMetadata(types.ColumnTypeGenerated, datatype.Loki.Integer),
+			Metadata: datatype.ColumnMetadata(types.ColumnTypeGenerated, datatype.Loki.Float),
 		},
 	)
 
-	for _, column := range a.groupBy {
-		colExpr, ok := column.(*physical.ColumnExpr)
-		if !ok {
-			panic(fmt.Sprintf("invalid column expression type %T", column))
-		}
-
+	for _, column := range a.columns {
 		fields = append(fields, arrow.Field{
-			Name:     colExpr.Ref.Column,
+			Name:     column.Column,
 			Type:     datatype.Arrow.String,
 			Nullable: true,
-			Metadata: datatype.ColumnMetadata(colExpr.Ref.Type, datatype.Loki.String),
+			Metadata: datatype.ColumnMetadata(column.Type, datatype.Loki.String),
 		})
 	}
 
@@ -268,22 +700,35 @@ func (a *vectorAggregator) buildRecord() (arrow.Record, error) {
 	rb := array.NewRecordBuilder(memory.NewGoAllocator(), schema)
 	defer rb.Release()
 
+	appendRow := func(ts arrow.Timestamp, value float64, labelValues []string) {
+		rb.Field(0).(*array.TimestampBuilder).Append(ts)
+		rb.Field(1).(*array.Float64Builder).Append(value)
+
+		for col := range a.columns {
+			builder := rb.Field(col + 2).(*array.StringBuilder) // offset by 2 as the first 2 fields are timestamp and value
+
+			// rows added before a column was discovered have less label values
+			if col >= len(labelValues) || labelValues[col] == "" {
+				builder.AppendNull()
+			} else {
+				builder.Append(labelValues[col])
+			}
+		}
+	}
+
 	// emit aggregated results in sorted order of timestamp
 	for _, ts := range a.GetSortedTimestamps() {
 		entries := a.GetEntriesForTimestamp(ts)
 		tsValue, _ := arrow.TimestampFromTime(ts, arrow.Nanosecond)
 
 		for _, entry := range entries {
-			rb.Field(0).(*array.TimestampBuilder).Append(tsValue)
-			rb.Field(1).(*array.Int64Builder).Append(entry.sum)
-
-			for col, val := range entry.labelValues {
-				builder := rb.Field(col + 2) // offset by 2 as the first 2 fields are timestamp and value
-				if val == "" {
-					builder.(*array.StringBuilder).AppendNull()
-				} else {
-					builder.(*array.StringBuilder).Append(val)
-				}
+			if !a.operation.PreservesSeries() {
+				appendRow(tsValue, a.result(entry), entry.labelValues)
+				continue
+			}
+
+			for _, series := range a.selectSeries(entry) {
+				appendRow(tsValue, series.value, series.labelValues)
 			}
 		}
 	}
@@ -293,12 +738,18 @@ func (a *vectorAggregator) buildRecord() (arrow.Record, error) {
 
 func (a *vectorAggregator) Reset() {
 	clear(a.points)
+	a.budget.release(a.size)
+	a.size = 0
 }
 
 func (a *vectorAggregator) NumOfPoints() int {
 	return len(a.points)
 }
 
+func (a *vectorAggregator) NumOfColumns() int {
+	return len(a.columns)
+}
+
 // GetSortedTimestamps returns all timestamps in sorted order
 func (a *vectorAggregator) GetSortedTimestamps() []time.Time {
 	return slices.SortedFunc(maps.Keys(a.points), func(a, b time.Time) int {
@@ -310,3 +761,147 @@ func (a *vectorAggregator) GetSortedTimestamps() []time.Time {
 func (a *vectorAggregator) GetEntriesForTimestamp(ts time.Time) map[uint64]*groupState {
 	return a.points[ts]
 }
+
+// vectorSpillColumns is the number of columns of spilled group state that
+// precede the label columns.
+const vectorSpillColumns = 6
+
+// Spill writes the state of all groups to a new spill file of s and resets
+// the aggregator.
+//
+// Each group is written as a row with its timestamp, key, count, value, mean
+// and aux, followed by its label values. Operations that preserve series
+// write one row per series instead.
+func (a *vectorAggregator) Spill(s *spiller) error {
+	fields := []arrow.Field{
+		{Name: "ts", Type: datatype.Arrow.Timestamp},
+		{Name: "key", Type: arrow.PrimitiveTypes.Uint64},
+		{Name: "count", Type: arrow.PrimitiveTypes.Int64},
+		{Name: "value", Type: arrow.PrimitiveTypes.Float64},
+		{Name: "mean", Type: arrow.PrimitiveTypes.Float64},
+		{Name: "aux", Type: arrow.PrimitiveTypes.Float64},
+	}
+	schema := arrow.NewSchema(append(fields, labelFields(a.columns)...), nil)
+
+	var builders [spillPartitions]*array.RecordBuilder
+	for i := range builders {
+		builders[i] = array.NewRecordBuilder(memory.NewGoAllocator(), schema)
+		defer builders[i].Release()
+	}
+
+	appendRow := func(rb *array.RecordBuilder, ts arrow.Timestamp, key uint64, state *groupState, labelValues []string) {
+		rb.Field(0).(*array.TimestampBuilder).Append(ts)
+		rb.Field(1).(*array.Uint64Builder).Append(key)
+		rb.Field(2).(*array.Int64Builder).Append(state.count)
+		rb.Field(3).(*array.Float64Builder).Append(state.value)
+		rb.Field(4).(*array.Float64Builder).Append(state.mean)
+		rb.Field(5).(*array.Float64Builder).Append(state.aux)
+		appendLabelValues(rb, vectorSpillColumns, len(a.columns), labelValues)
+	}
+
+	for ts, point := range a.points {
+		tsValue, _ := arrow.TimestampFromTime(ts, arrow.Nanosecond)
+		for key, state := range point {
+			rb := builders[spillPartition(key)]
+			if !a.operation.PreservesSeries() {
+				appendRow(rb, tsValue, key, state, state.labelValues)
+				continue
+			}
+
+			for _, series := range state.series {
+				appendRow(rb, tsValue, key, &groupState{count: 1, value: series.value}, series.labelValues)
+			}
+		}
+	}
+
+	var records [spillPartitions]arrow.Record
+	for i, rb := range builders {
+		records[i] = rb.NewRecord()
+	}
+
+	a.Reset()
+	return s.Spill(schema, records)
+}
+
+// Merge merges the group state of a record written by [vectorAggregator.Spill].
+func (a *vectorAggregator) Merge(record arrow.Record) error {
+	labelArrays, err := spilledLabelArrays(record, vectorSpillColumns, a.ColumnIndex)
+	if err != nil {
+		return err
+	}
+
+	var (
+		tsCol    = record.Column(0).(*array.Timestamp)
+		keyCol   = record.Column(1).(*array.Uint64)
+		countCol = record.Column(2).(*array.Int64)
+		valueCol = record.Column(3).(*array.Float64)
+		meanCol  = record.Column(4).(*array.Float64)
+		auxCol   = record.Column(5).(*array.Float64)
+
+		labelValues = make([]string, a.NumOfColumns())
+	)
+
+	for row := range int(record.NumRows()) {
+		clear(labelValues)
+		for _, arr := range labelArrays {
+			if arr.array.IsValid(row) {
+				labelValues[arr.index] = arr.array.Value(row)
+			}
+		}
+
+		ts := tsCol.Value(row).ToTime(arrow.Nanosecond)
+		state := a.groupState(ts, keyCol.Value(row), labelValues)
+		if a.operation.PreservesSeries() {
+			state.count++
+			a.addSeries(state, valueCol.Value(row), labelValues)
+			continue
+		}
+
+		a.mergeState(state, &groupState{
+			count: countCol.Value(row),
+			value: valueCol.Value(row),
+			mean:  meanCol.Value(row),
+			aux:   auxCol.Value(row),
+		})
+	}
+	return nil
+}
+
+// mergeState merges the partial aggregation src into state.
+func (a *vectorAggregator) mergeState(state, src *groupState) {
+	count := state.count + src.count
+	if count == 0 {
+		return
+	}
+
+	switch a.operation {
+	case types.VectorAggregationTypeSum:
+		state.value += src.value
+
+	case types.VectorAggregationTypeAvg:
+		if math.IsInf(state.mean, 0) || math.IsInf(src.mean, 0) {
+			// Inf means of the same sign are kept, while Inf means of
+			// different signs result in NaN, like in Add.
+			state.mean += src.mean
+		} else {
+			state.mean += (src.mean - state.mean) * float64(src.count) / float64(count)
+		}
+
+	case types.VectorAggregationTypeMin:
+		if state.count == 0 || state.value > src.value || math.IsNaN(state.value) {
+			state.value = src.value
+		}
+
+	case types.VectorAggregationTypeMax:
+		if state.count == 0 || state.value < src.value || math.IsNaN(state.value) {
+			state.value = src.value
+		}
+
+	case types.VectorAggregationTypeStddev, types.VectorAggregationTypeStdvar:
+		// combine the partial results with the parallel algorithm of Chan et al.
+		delta := src.mean - state.mean
+		state.mean += delta * float64(src.count) / float64(count)
+		state.aux += src.aux + delta*delta*float64(state.count)*float64(src.count)/float64(count)
+	}
+	state.count = count
+}
@@ -2,6 +2,9 @@ package executor
 
 import (
 	"fmt"
+	"math"
+	"math/rand"
+	"strconv"
 	"strings"
 	"testing"
 	"time"
@@ -84,7 +87,10 @@ func TestVectorAggregationPipeline(t *testing.T) {
 		},
 	}
 
-	pipeline, err := NewVectorAggregationPipeline([]Pipeline{input1, input2}, groupBy, expressionEvaluator{})
+	pipeline, err := NewVectorAggregationPipeline([]Pipeline{input1, input2}, expressionEvaluator{}, vectorAggregationOptions{
+		operation: types.VectorAggregationTypeSum,
+		groupBy:   groupBy,
+	})
 	require.NoError(t, err)
 	defer pipeline.Close()
 
@@ -96,7 +102,7 @@ func TestVectorAggregationPipeline(t *testing.T) {
 	defer record.Release()
 
 	// Define expected results - sum of values for each group at each timestamp
-	expected := map[time.Time]map[string]int64{
+	expected := map[time.Time]map[string]float64{
 		t1: {
 			"prod,app1": 15, // 10 + 5
 			"prod,app2": 20, // 20
@@ -118,16 +124,16 @@ func TestVectorAggregationPipeline(t *testing.T) {
 	}
 
 	// Verify results
-	actual := make(map[time.Time]map[string]int64)
+	actual := make(map[time.Time]map[string]float64)
 	for i := range int(record.NumRows()) {
 		ts := record.Column(0).(*array.Timestamp).Value(i).ToTime(arrow.Nanosecond)
-		value := record.Column(1).(*array.Int64).Value(i)
+		value := record.Column(1).(*array.Float64).Value(i)
 		env := record.Column(2).(*array.String).Value(i)
 		service := record.Column(3).(*array.String).Value(i)
 		key := fmt.Sprintf("%s,%s", env, service)
 
 		if _, ok := actual[ts]; !ok {
-			actual[ts] = make(map[string]int64)
+			actual[ts] = make(map[string]float64)
 		}
 		actual[ts][key] = value
 	}
@@ -142,3 +148,215 @@ func TestVectorAggregationPipeline(t *testing.T) {
 		}
 	}
 }
+
+func TestVectorAggregationPipeline_Operations(t *testing.T) {
+	fields := []arrow.Field{
+		{Name: types.ColumnNameBuiltinTimestamp, Type: datatype.Arrow.Timestamp, Metadata: datatype.ColumnMetadataBuiltinTimestamp},
+		{Name: types.ColumnNameGeneratedValue, Type: datatype.Arrow.Float, Metadata: datatype.ColumnMetadata(types.ColumnTypeGenerated, datatype.Loki.Float)},
+		{Name: "env", Type: datatype.Arrow.String, Metadata: datatype.ColumnMetadata(types.ColumnTypeLabel, datatype.Loki.String)},
+		{Name: "service", Type: datatype.Arrow.String, Metadata: datatype.ColumnMetadata(types.ColumnTypeLabel, datatype.Loki.String)},
+	}
+
+	now := time.Unix(1000, 0).UTC()
+	inputCSV := strings.Join([]string{
+		fmt.Sprintf("%s,10,prod,app1", now.Format(arrowTimestampFormat)),
+		fmt.Sprintf("%s,20,prod,app2", now.Format(arrowTimestampFormat)),
+		fmt.Sprintf("%s,30,dev,app1", now.Format(arrowTimestampFormat)),
+		fmt.Sprintf("%s,40,dev,app2", now.Format(arrowTimestampFormat)),
+	}, "\n")
+
+	columns := func(names ...string) []physical.ColumnExpression {
+		exprs := make([]physical.ColumnExpression, 0, len(names))
+		for _, name := range names {
+			exprs = append(exprs, &physical.ColumnExpr{Ref: types.ColumnRef{Column: name, Type: types.ColumnTypeAmbiguous}})
+		}
+		return exprs
+	}
+
+	for _, tt := range []struct {
+		name     string
+		opts     vectorAggregationOptions
+		ordered  bool     // whether the order of the rows is part of the result
+		expected []string // rows formatted as {labels}=value
+	}{
+		{
+			name:     "sum",
+			opts:     vectorAggregationOptions{operation: types.VectorAggregationTypeSum},
+			expected: []string{"{}=100"},
+		},
+		{
+			name:     "avg by env",
+			opts:     vectorAggregationOptions{operation: types.VectorAggregationTypeAvg, groupBy: columns("env")},
+			expected: []string{"{env=prod}=15", "{env=dev}=35"},
+		},
+		{
+			name:     "min by env",
+			opts:     vectorAggregationOptions{operation: types.VectorAggregationTypeMin, groupBy: columns("env")},
+			expected: []string{"{env=prod}=10", "{env=dev}=30"},
+		},
+		{
+			name:     "max without service",
+			opts:     vectorAggregationOptions{operation: types.VectorAggregationTypeMax, groupBy: columns("service"), without: true},
+			expected: []string{"{env=prod}=20", "{env=dev}=40"},
+		},
+		{
+			name:     "count by service",
+			opts:     vectorAggregationOptions{operation: types.VectorAggregationTypeCount, groupBy: columns("service")},
+			expected: []string{"{service=app1}=2", "{service=app2}=2"},
+		},
+		{
+			name:     "stdvar by env",
+			opts:     vectorAggregationOptions{operation: types.VectorAggregationTypeStdvar, groupBy: columns("env")},
+			expected: []string{"{env=prod}=25", "{env=dev}=25"},
+		},
+		{
+			name:     "stddev by env",
+			opts:     vectorAggregationOptions{operation: types.VectorAggregationTypeStddev, groupBy: columns("env")},
+			expected: []string{"{env=prod}=5", "{env=dev}=5"},
+		},
+		{
+			name:     "topk by env",
+			opts:     vectorAggregationOptions{operation: types.VectorAggregationTypeTopK, groupBy: columns("env"), parameter: 1},
+			expected: []string{"{env=prod,service=app2}=20", "{env=dev,service=app2}=40"},
+		},
+		{
+			name:     "bottomk without env",
+			opts:     vectorAggregationOptions{operation: types.VectorAggregationTypeBottomK, groupBy: columns("env"), without: true, parameter: 1},
+			expected: []string{"{env=prod,service=app1}=10", "{env=prod,service=app2}=20"},
+		},
+		{
+			name:     "topk with k larger than series",
+			opts:     vectorAggregationOptions{operation: types.VectorAggregationTypeTopK, parameter: 10},
+			ordered:  true,
+			expected: []string{"{env=dev,service=app2}=40", "{env=dev,service=app1}=30", "{env=prod,service=app2}=20", "{env=prod,service=app1}=10"},
+		},
+		{
+			name:     "sort",
+			opts:     vectorAggregationOptions{operation: types.VectorAggregationTypeSort},
+			ordered:  true,
+			expected: []string{"{env=prod,service=app1}=10", "{env=prod,service=app2}=20", "{env=dev,service=app1}=30", "{env=dev,service=app2}=40"},
+		},
+		{
+			name:     "sort_desc",
+			opts:     vectorAggregationOptions{operation: types.VectorAggregationTypeSortDesc},
+			ordered:  true,
+			expected: []string{"{env=dev,service=app2}=40", "{env=dev,service=app1}=30", "{env=prod,service=app2}=20", "{env=prod,service=app1}=10"},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			record, err := CSVToArrow(fields, inputCSV)
+			require.NoError(t, err)
+			defer record.Release()
+
+			pipeline, err := NewVectorAggregationPipeline([]Pipeline{NewBufferedPipeline(record)}, expressionEvaluator{}, tt.opts)
+			require.NoError(t, err)
+			defer pipeline.Close()
+
+			err = pipeline.Read(t.Context())
+			require.NoError(t, err)
+			result, err := pipeline.Value()
+			require.NoError(t, err)
+
+			actual := make([]string, 0, result.NumRows())
+			for i := range int(result.NumRows()) {
+				require.Equal(t, now, result.Column(0).(*array.Timestamp).Value(i).ToTime(arrow.Nanosecond))
+
+				lbls := make([]string, 0, result.NumCols()-2)
+				for col := 2; col < int(result.NumCols()); col++ {
+					arr := result.Column(col).(*array.String)
+					if arr.IsValid(i) {
+						lbls = append(lbls, fmt.Sprintf("%s=%s", result.ColumnName(col), arr.Value(i)))
+					}
+				}
+				value := result.Column(1).(*array.Float64).Value(i)
+				actual = append(actual, fmt.Sprintf("{%s}=%v", strings.Join(lbls, ","), value))
+			}
+
+			if tt.ordered {
+				require.Equal(t, tt.expected, actual)
+			} else {
+				require.ElementsMatch(t, tt.expected, actual)
+			}
+		})
+	}
+}
+
+func TestVectorAggregator_AvgInf(t *testing.T) {
+	ts := time.Unix(1000, 0).UTC()
+
+	for _, tt := range []struct {
+		name     string
+		values   []float64
+		expected float64
+	}{
+		{name: "inf first", values: []float64{math.Inf(1), 1}, expected: math.Inf(1)},
+		{name: "inf last", values: []float64{1, math.Inf(1)}, expected: math.Inf(1)},
+		{name: "same signs", values: []float64{math.Inf(-1), 2, math.Inf(-1)}, expected: math.Inf(-1)},
+		{name: "different signs", values: []float64{math.Inf(1), math.Inf(-1)}, expected: math.NaN()},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			a := newVectorAggregator(types.VectorAggregationTypeAvg, 0, nil, nil)
+			for _, value := range tt.values {
+				a.Add(ts, value, nil, nil)
+			}
+			for _, state := range a.GetEntriesForTimestamp(ts) {
+				if math.IsNaN(tt.expected) {
+					require.True(t, math.IsNaN(a.result(state)))
+				} else {
+					require.Equal(t, tt.expected, a.result(state))
+				}
+			}
+
+			// Partial averages of spilled groups are merged the same way.
+			merged := newVectorAggregator(types.VectorAggregationTypeAvg, 0, nil, nil)
+			for _, value := range tt.values {
+				partial := newVectorAggregator(types.VectorAggregationTypeAvg, 0, nil, nil)
+				partial.Add(ts, value, nil, nil)
+				for key, state := range partial.GetEntriesForTimestamp(ts) {
+					merged.mergeState(merged.groupState(ts, key, nil), state)
+				}
+			}
+			for _, state := range merged.GetEntriesForTimestamp(ts) {
+				if math.IsNaN(tt.expected) {
+					require.True(t, math.IsNaN(merged.result(state)))
+				} else {
+					require.Equal(t, tt.expected, merged.result(state))
+				}
+			}
+		})
+	}
+}
+
+func TestVectorAggregator_TopKBounded(t *testing.T) {
+	ts := time.Unix(1000, 0).UTC()
+
+	for _, tt := range []struct {
+		operation types.VectorAggregationType
+		expected  []float64
+	}{
+		{operation: types.VectorAggregationTypeTopK, expected: []float64{99, 98, 97}},
+		{operation: types.VectorAggregationTypeBottomK, expected: []float64{0, 1, 2}},
+	} {
+		t.Run(tt.operation.String(), func(t *testing.T) {
+			a := newVectorAggregator(tt.operation, 3, nil, nil)
+			a.Add(ts, math.NaN(), []string{"nan"}, nil)
+			for _, i := range rand.Perm(100) {
+				a.Add(ts, float64(i), []string{strconv.Itoa(i)}, nil)
+			}
+
+			entries := a.GetEntriesForTimestamp(ts)
+			require.Len(t, entries, 1)
+			for _, state := range entries {
+				// Only k series are kept while adding rows.
+				require.Len(t, state.series, 3)
+
+				var values []float64
+				for _, series := range a.selectSeries(state) {
+					require.Equal(t, strconv.Itoa(int(series.value)), series.labelValues[0])
+					values = append(values, series.value)
+				}
+				require.Equal(t, tt.expected, values)
+			}
+		})
+	}
+}
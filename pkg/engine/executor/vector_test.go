@@ -0,0 +1,30 @@
+package executor
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/loki/v3/pkg/engine/planner/physical"
+)
+
+func TestVectorPipeline(t *testing.T) {
+	t.Run("range query", func(t *testing.T) {
+		pipeline := NewVectorPipeline(&physical.Vector{Value: 2.5, Start: joinTestT1, End: joinTestT2, Step: 30 * time.Second})
+		defer pipeline.Close()
+
+		require.Equal(t, []string{
+			`60 {} 2.5`,
+			`90 {} 2.5`,
+			`120 {} 2.5`,
+		}, joinResults(t, pipeline))
+	})
+
+	t.Run("instant query", func(t *testing.T) {
+		pipeline := NewVectorPipeline(&physical.Vector{Value: 1, Start: joinTestT2, End: joinTestT2})
+		defer pipeline.Close()
+
+		require.Equal(t, []string{`120 {} 1`}, joinResults(t, pipeline))
+	})
+}
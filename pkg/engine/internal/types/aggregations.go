@@ -6,13 +6,49 @@ type RangeAggregationType int
 const (
 	RangeAggregationTypeInvalid RangeAggregationType = iota
 
-	RangeAggregationTypeCount // Represents count_over_time range aggregation
+	RangeAggregationTypeCount     // Represents count_over_time range aggregation
+	RangeAggregationTypeRate      // Represents rate range aggregation
+	RangeAggregationTypeBytes     // Represents bytes_over_time range aggregation
+	RangeAggregationTypeBytesRate // Represents bytes_rate range aggregation
+	RangeAggregationTypeSum       // Represents sum_over_time range aggregation
+	RangeAggregationTypeAvg       // Represents avg_over_time range aggregation
+	RangeAggregationTypeMin       // Represents min_over_time range aggregation
+	RangeAggregationTypeMax       // Represents max_over_time range aggregation
+	RangeAggregationTypeFirst     // Represents first_over_time range aggregation
+	RangeAggregationTypeLast      // Represents last_over_time range aggregation
+	RangeAggregationTypeStddev    // Represents stddev_over_time range aggregation
+	RangeAggregationTypeStdvar    // Represents stdvar_over_time range aggregation
+	RangeAggregationTypeQuantile  // Represents quantile_over_time range aggregation
 )
 
 func (op RangeAggregationType) String() string {
 	switch op {
 	case RangeAggregationTypeCount:
 		return "count"
+	case RangeAggregationTypeRate:
+		return "rate"
+	case RangeAggregationTypeBytes:
+		return "bytes"
+	case RangeAggregationTypeBytesRate:
+		return "bytes_rate"
+	case RangeAggregationTypeSum:
+		return "sum"
+	case RangeAggregationTypeAvg:
+		return "avg"
+	case RangeAggregationTypeMin:
+		return "min"
+	case RangeAggregationTypeMax:
+		return "max"
+	case RangeAggregationTypeFirst:
+		return "first"
+	case RangeAggregationTypeLast:
+		return "last"
+	case RangeAggregationTypeStddev:
+		return "stddev"
+	case RangeAggregationTypeStdvar:
+		return "stdvar"
+	case RangeAggregationTypeQuantile:
+		return "quantile"
 	default:
 		return "invalid"
 	}
@@ -24,13 +60,83 @@ type VectorAggregationType int
 const (
 	VectorAggregationTypeInvalid VectorAggregationType = iota
 
-	VectorAggregationTypeSum // Represents sum vector aggregation
+	VectorAggregationTypeSum      // Represents sum vector aggregation
+	VectorAggregationTypeAvg      // Represents avg vector aggregation
+	VectorAggregationTypeMin      // Represents min vector aggregation
+	VectorAggregationTypeMax      // Represents max vector aggregation
+	VectorAggregationTypeCount    // Represents count vector aggregation
+	VectorAggregationTypeStddev   // Represents stddev vector aggregation
+	VectorAggregationTypeStdvar   // Represents stdvar vector aggregation
+	VectorAggregationTypeTopK     // Represents topk vector aggregation
+	VectorAggregationTypeBottomK  // Represents bottomk vector aggregation
+	VectorAggregationTypeSort     // Represents sort vector aggregation
+	VectorAggregationTypeSortDesc // Represents sort_desc vector aggregation
 )
 
 func (op VectorAggregationType) String() string {
 	switch op {
 	case VectorAggregationTypeSum:
 		return "sum"
+	case VectorAggregationTypeAvg:
+		return "avg"
+	case VectorAggregationTypeMin:
+		return "min"
+	case VectorAggregationTypeMax:
+		return "max"
+	case VectorAggregationTypeCount:
+		return "count"
+	case VectorAggregationTypeStddev:
+		return "stddev"
+	case VectorAggregationTypeStdvar:
+		return "stdvar"
+	case VectorAggregationTypeTopK:
+		return "topk"
+	case VectorAggregationTypeBottomK:
+		return "bottomk"
+	case VectorAggregationTypeSort:
+		return "sort"
+	case VectorAggregationTypeSortDesc:
+		return "sort_desc"
+	default:
+		return "invalid"
+	}
+}
+
+// PreservesSeries returns true if the vector aggregation returns the input
+// series with their labels instead of aggregating them into groups.
+func (op VectorAggregationType) PreservesSeries() bool {
+	switch op {
+	case VectorAggregationTypeTopK, VectorAggregationTypeBottomK,
+		VectorAggregationTypeSort, VectorAggregationTypeSortDesc:
+		return true
+	default:
+		return false
+	}
+}
+
+// ConversionType represents the conversion that is applied to the string
+// values of a column when they are unwrapped into sample values.
+type ConversionType int
+
+const (
+	ConversionTypeInvalid ConversionType = iota
+
+	ConversionTypeFloat           // Parses the value as floating point number (default)
+	ConversionTypeBytes           // Parses the value as humanized bytes, e.g. 12MB
+	ConversionTypeDuration        // Parses the value as duration in seconds, e.g. 1m30s
+	ConversionTypeDurationSeconds // Alias of ConversionTypeDuration
+)
+
+func (c ConversionType) String() string {
+	switch c {
+	case ConversionTypeFloat:
+		return "float"
+	case ConversionTypeBytes:
+		return "bytes"
+	case ConversionTypeDuration:
+		return "duration"
+	case ConversionTypeDurationSeconds:
+		return "duration_seconds"
 	default:
 		return "invalid"
 	}
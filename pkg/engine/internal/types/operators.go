@@ -46,11 +46,14 @@ const (
 	BinaryOpXor // Logical XOR operation (^).
 	BinaryOpNot // Logical NOT operation (!).
 
+	BinaryOpUnless // Set difference operation (unless). Used for binary operations between vectors.
+
 	BinaryOpAdd // Addition operation (+).
 	BinaryOpSub // Subtraction operation (-).
 	BinaryOpMul // Multiplication operation (*).
 	BinaryOpDiv // Division operation (/).
 	BinaryOpMod // Modulo operation (%).
+	BinaryOpPow // Power operation (^).
 
 	BinaryOpMatchSubstr     // Substring matching operation (|=). Used for string match filter.
 	BinaryOpNotMatchSubstr  // Substring non-matching operation (!=). Used for string match filter.
@@ -83,6 +86,8 @@ func (t BinaryOp) String() string {
 		return "XOR"
 	case BinaryOpNot:
 		return "NOT"
+	case BinaryOpUnless:
+		return "UNLESS"
 	case BinaryOpAdd:
 		return "ADD"
 	case BinaryOpSub:
@@ -93,6 +98,8 @@ func (t BinaryOp) String() string {
 		return "DIV"
 	case BinaryOpMod:
 		return "MOD"
+	case BinaryOpPow:
+		return "POW"
 	case BinaryOpMatchSubstr:
 		return "MATCH_STR"
 	case BinaryOpNotMatchSubstr:
@@ -109,3 +116,38 @@ func (t BinaryOp) String() string {
 		panic(fmt.Sprintf("unknown binary operator %d", t))
 	}
 }
+
+// IsComparison returns true if the operation is a comparison of two values.
+func (t BinaryOp) IsComparison() bool {
+	switch t {
+	case BinaryOpEq, BinaryOpNeq, BinaryOpGt, BinaryOpGte, BinaryOpLt, BinaryOpLte:
+		return true
+	default:
+		return false
+	}
+}
+
+// MatchCardinality denotes the cardinality of the matching of the series of
+// the two sides of a binary operation between vectors.
+type MatchCardinality uint32
+
+// Recognized values of [MatchCardinality].
+const (
+	MatchOneToOne  MatchCardinality = iota // Each series matches at most one series of the other side.
+	MatchManyToOne                         // Many series of the left side match one series of the right side (group_left).
+	MatchOneToMany                         // One series of the left side matches many series of the right side (group_right).
+)
+
+// String returns the string representation of the MatchCardinality.
+func (c MatchCardinality) String() string {
+	switch c {
+	case MatchOneToOne:
+		return "one-to-one"
+	case MatchManyToOne:
+		return "many-to-one"
+	case MatchOneToMany:
+		return "one-to-many"
+	default:
+		panic(fmt.Sprintf("unknown match cardinality %d", c))
+	}
+}
@@ -0,0 +1,32 @@
+package types
+
+// ParserType represents the type of a parser stage that extracts columns from
+// the log line.
+type ParserType int
+
+const (
+	ParserTypeInvalid ParserType = iota
+
+	ParserTypeJSON    // Represents the json parser
+	ParserTypeLogfmt  // Represents the logfmt parser
+	ParserTypeRegexp  // Represents the regexp parser
+	ParserTypePattern // Represents the pattern parser
+	ParserTypeUnpack  // Represents the unpack parser
+)
+
+func (p ParserType) String() string {
+	switch p {
+	case ParserTypeJSON:
+		return "json"
+	case ParserTypeLogfmt:
+		return "logfmt"
+	case ParserTypeRegexp:
+		return "regexp"
+	case ParserTypePattern:
+		return "pattern"
+	case ParserTypeUnpack:
+		return "unpack"
+	default:
+		return "invalid"
+	}
+}
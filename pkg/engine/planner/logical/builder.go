@@ -3,6 +3,8 @@ package logical
 import (
 	"time"
 
+	"github.com/prometheus/prometheus/model/labels"
+
 	"github.com/grafana/loki/v3/pkg/engine/internal/types"
 	"github.com/grafana/loki/v3/pkg/engine/planner/schema"
 )
@@ -28,6 +30,34 @@ func (b *Builder) Select(predicate Value) *Builder {
 	}
 }
 
+// Parse applies a [Parse] operation to the Builder. The table relation of
+// parse is set to the current value of the Builder.
+func (b *Builder) Parse(parse *Parse) *Builder {
+	parse.Table = b.val
+	return &Builder{val: parse}
+}
+
+// LineFormat applies a [LineFormat] operation to the Builder. The table
+// relation of format is set to the current value of the Builder.
+func (b *Builder) LineFormat(format *LineFormat) *Builder {
+	format.Table = b.val
+	return &Builder{val: format}
+}
+
+// LabelFormat applies a [LabelFormat] operation to the Builder. The table
+// relation of format is set to the current value of the Builder.
+func (b *Builder) LabelFormat(format *LabelFormat) *Builder {
+	format.Table = b.val
+	return &Builder{val: format}
+}
+
+// ProjectLabels applies a [LabelProjection] operation to the Builder. The
+// table relation of projection is set to the current value of the Builder.
+func (b *Builder) ProjectLabels(projection *LabelProjection) *Builder {
+	projection.Table = b.val
+	return &Builder{val: projection}
+}
+
 // Limit applies a [Limit] operation to the Builder.
 func (b *Builder) Limit(skip uint32, fetch uint32) *Builder {
 	return &Builder{
@@ -59,6 +89,7 @@ func (b *Builder) RangeAggregation(
 	startTS, endTS time.Time,
 	step time.Duration,
 	rangeInterval time.Duration,
+	offset time.Duration,
 ) *Builder {
 	return &Builder{
 		val: &RangeAggregation{
@@ -70,6 +101,36 @@ func (b *Builder) RangeAggregation(
 			End:           endTS,
 			Step:          step,
 			RangeInterval: rangeInterval,
+			Offset:        offset,
+		},
+	}
+}
+
+// UnwrapRangeAggregation applies a [RangeAggregation] operation over the
+// values of an unwrapped column to the Builder.
+func (b *Builder) UnwrapRangeAggregation(
+	partitionBy []ColumnRef,
+	operation types.RangeAggregationType,
+	parameter float64,
+	unwrap *Unwrap,
+	startTS, endTS time.Time,
+	step time.Duration,
+	rangeInterval time.Duration,
+	offset time.Duration,
+) *Builder {
+	return &Builder{
+		val: &RangeAggregation{
+			Table: b.val,
+
+			Operation:     operation,
+			Parameter:     parameter,
+			Unwrap:        unwrap,
+			PartitionBy:   partitionBy,
+			Start:         startTS,
+			End:           endTS,
+			Step:          step,
+			RangeInterval: rangeInterval,
+			Offset:        offset,
 		},
 	}
 }
@@ -88,6 +149,72 @@ func (b *Builder) VectorAggregation(
 	}
 }
 
+// GroupedVectorAggregation applies a [VectorAggregation] operation with
+// explicit grouping semantics to the Builder. If without is true, the
+// grouping columns are excluded from the labels of the result instead.
+// The parameter is used by operations that require one, such as topk.
+func (b *Builder) GroupedVectorAggregation(
+	grouping []ColumnRef,
+	without bool,
+	operation types.VectorAggregationType,
+	parameter int,
+) *Builder {
+	return &Builder{
+		val: &VectorAggregation{
+			Table:     b.val,
+			GroupBy:   grouping,
+			Without:   without,
+			Operation: operation,
+			Parameter: parameter,
+		},
+	}
+}
+
+// Join applies a [Join] operation between the current value of the Builder as
+// left operand and right as right operand. Scalar operands are represented by
+// a [Literal] and require matching to be nil.
+func (b *Builder) Join(
+	right Value,
+	op types.BinaryOp,
+	returnBool bool,
+	matching *VectorMatching,
+) *Builder {
+	return &Builder{
+		val: &Join{
+			Left:       b.val,
+			Right:      right,
+			Op:         op,
+			ReturnBool: returnBool,
+			Matching:   matching,
+		},
+	}
+}
+
+// LabelReplace applies a [LabelReplace] operation to the Builder. The table
+// relation of replace is set to the current value of the Builder.
+func (b *Builder) LabelReplace(replace *LabelReplace) *Builder {
+	replace.Table = b.val
+	return &Builder{val: replace}
+}
+
+// Absent applies an [Absent] operation to the Builder, which produces a
+// series with the given labels for each step without values.
+func (b *Builder) Absent(
+	lbls labels.Labels,
+	startTS, endTS time.Time,
+	step time.Duration,
+) *Builder {
+	return &Builder{
+		val: &Absent{
+			Table:  b.val,
+			Labels: lbls,
+			Start:  startTS,
+			End:    endTS,
+			Step:   step,
+		},
+	}
+}
+
 // Schema returns the schema of the data that will be produced by this Builder.
 func (b *Builder) Schema() *schema.Schema {
 	return b.val.Schema()
@@ -37,6 +37,14 @@ func (b *ssaBuilder) process(value Value) (Value, error) {
 		return b.processMakeTablePlan(value)
 	case *Select:
 		return b.processSelectPlan(value)
+	case *Parse:
+		return b.processParsePlan(value)
+	case *LineFormat:
+		return b.processLineFormatPlan(value)
+	case *LabelFormat:
+		return b.processLabelFormatPlan(value)
+	case *LabelProjection:
+		return b.processLabelProjectionPlan(value)
 	case *Limit:
 		return b.processLimitPlan(value)
 	case *Sort:
@@ -45,6 +53,14 @@ func (b *ssaBuilder) process(value Value) (Value, error) {
 		return b.processRangeAggregate(value)
 	case *VectorAggregation:
 		return b.processVectorAggregation(value)
+	case *Join:
+		return b.processJoin(value)
+	case *LabelReplace:
+		return b.processLabelReplace(value)
+	case *Vector:
+		return b.processVector(value)
+	case *Absent:
+		return b.processAbsent(value)
 
 	case *UnaryOp:
 		return b.processUnaryOp(value)
@@ -84,6 +100,46 @@ func (b *ssaBuilder) processSelectPlan(plan *Select) (Value, error) {
 	return plan, nil
 }
 
+func (b *ssaBuilder) processParsePlan(plan *Parse) (Value, error) {
+	if _, err := b.process(plan.Table); err != nil {
+		return nil, err
+	}
+
+	plan.id = fmt.Sprintf("%%%d", b.getID())
+	b.instructions = append(b.instructions, plan)
+	return plan, nil
+}
+
+func (b *ssaBuilder) processLineFormatPlan(plan *LineFormat) (Value, error) {
+	if _, err := b.process(plan.Table); err != nil {
+		return nil, err
+	}
+
+	plan.id = fmt.Sprintf("%%%d", b.getID())
+	b.instructions = append(b.instructions, plan)
+	return plan, nil
+}
+
+func (b *ssaBuilder) processLabelFormatPlan(plan *LabelFormat) (Value, error) {
+	if _, err := b.process(plan.Table); err != nil {
+		return nil, err
+	}
+
+	plan.id = fmt.Sprintf("%%%d", b.getID())
+	b.instructions = append(b.instructions, plan)
+	return plan, nil
+}
+
+func (b *ssaBuilder) processLabelProjectionPlan(plan *LabelProjection) (Value, error) {
+	if _, err := b.process(plan.Table); err != nil {
+		return nil, err
+	}
+
+	plan.id = fmt.Sprintf("%%%d", b.getID())
+	b.instructions = append(b.instructions, plan)
+	return plan, nil
+}
+
 func (b *ssaBuilder) processLimitPlan(plan *Limit) (Value, error) {
 	if _, err := b.process(plan.Table); err != nil {
 		return nil, err
@@ -135,6 +191,44 @@ func (b *ssaBuilder) processVectorAggregation(plan *VectorAggregation) (Value, e
 	return plan, nil
 }
 
+func (b *ssaBuilder) processJoin(plan *Join) (Value, error) {
+	if _, err := b.process(plan.Left); err != nil {
+		return nil, err
+	} else if _, err := b.process(plan.Right); err != nil {
+		return nil, err
+	}
+
+	plan.id = fmt.Sprintf("%%%d", b.getID())
+	b.instructions = append(b.instructions, plan)
+	return plan, nil
+}
+
+func (b *ssaBuilder) processLabelReplace(plan *LabelReplace) (Value, error) {
+	if _, err := b.process(plan.Table); err != nil {
+		return nil, err
+	}
+
+	plan.id = fmt.Sprintf("%%%d", b.getID())
+	b.instructions = append(b.instructions, plan)
+	return plan, nil
+}
+
+func (b *ssaBuilder) processVector(plan *Vector) (Value, error) {
+	plan.id = fmt.Sprintf("%%%d", b.getID())
+	b.instructions = append(b.instructions, plan)
+	return plan, nil
+}
+
+func (b *ssaBuilder) processAbsent(plan *Absent) (Value, error) {
+	if _, err := b.process(plan.Table); err != nil {
+		return nil, err
+	}
+
+	plan.id = fmt.Sprintf("%%%d", b.getID())
+	b.instructions = append(b.instructions, plan)
+	return plan, nil
+}
+
 func (b *ssaBuilder) processBinOp(expr *BinOp) (Value, error) {
 	if _, err := b.process(expr.Left); err != nil {
 		return nil, err
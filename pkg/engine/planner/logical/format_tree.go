@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"io"
 
+	"github.com/grafana/loki/v3/pkg/engine/internal/types"
 	"github.com/grafana/loki/v3/pkg/engine/internal/util"
 	"github.com/grafana/loki/v3/pkg/engine/planner/internal/tree"
 )
@@ -25,6 +26,14 @@ func (t *treeFormatter) convert(value Value) *tree.Node {
 		return t.convertMakeTable(value)
 	case *Select:
 		return t.convertSelect(value)
+	case *Parse:
+		return t.convertParse(value)
+	case *LineFormat:
+		return t.convertLineFormat(value)
+	case *LabelFormat:
+		return t.convertLabelFormat(value)
+	case *LabelProjection:
+		return t.convertLabelProjection(value)
 	case *Limit:
 		return t.convertLimit(value)
 	case *Sort:
@@ -33,6 +42,14 @@ func (t *treeFormatter) convert(value Value) *tree.Node {
 		return t.convertRangeAggregation(value)
 	case *VectorAggregation:
 		return t.convertVectorAggregation(value)
+	case *Join:
+		return t.convertJoin(value)
+	case *LabelReplace:
+		return t.convertLabelReplace(value)
+	case *Vector:
+		return t.convertVector(value)
+	case *Absent:
+		return t.convertAbsent(value)
 
 	case *UnaryOp:
 		return t.convertUnaryOp(value)
@@ -66,6 +83,71 @@ func (t *treeFormatter) convertSelect(ast *Select) *tree.Node {
 	return node
 }
 
+func (t *treeFormatter) convertParse(ast *Parse) *tree.Node {
+	properties := []tree.Property{
+		tree.NewProperty("table", false, ast.Table.Name()),
+		tree.NewProperty("parser", false, ast.Parser),
+	}
+	if ast.Pattern != "" {
+		properties = append(properties, tree.NewProperty("pattern", false, ast.Pattern))
+	}
+	if ast.Strict {
+		properties = append(properties, tree.NewProperty("strict", false, ast.Strict))
+	}
+	if ast.KeepEmpty {
+		properties = append(properties, tree.NewProperty("keep_empty", false, ast.KeepEmpty))
+	}
+	if len(ast.Expressions) > 0 {
+		expressions := make([]any, len(ast.Expressions))
+		for i := range ast.Expressions {
+			expressions[i] = ast.Expressions[i].String()
+		}
+		properties = append(properties, tree.NewProperty("expressions", true, expressions...))
+	}
+
+	node := tree.NewNode("PARSE", ast.Name(), properties...)
+	node.Children = append(node.Children, t.convert(ast.Table))
+	return node
+}
+
+func (t *treeFormatter) convertLineFormat(ast *LineFormat) *tree.Node {
+	node := tree.NewNode("LINE_FORMAT", ast.Name(),
+		tree.NewProperty("table", false, ast.Table.Name()),
+		tree.NewProperty("template", false, ast.Template),
+	)
+	node.Children = append(node.Children, t.convert(ast.Table))
+	return node
+}
+
+func (t *treeFormatter) convertLabelFormat(ast *LabelFormat) *tree.Node {
+	formats := make([]any, len(ast.Formats))
+	for i := range ast.Formats {
+		formats[i] = ast.Formats[i].String()
+	}
+
+	node := tree.NewNode("LABEL_FORMAT", ast.Name(),
+		tree.NewProperty("table", false, ast.Table.Name()),
+		tree.NewProperty("formats", true, formats...),
+	)
+	node.Children = append(node.Children, t.convert(ast.Table))
+	return node
+}
+
+func (t *treeFormatter) convertLabelProjection(ast *LabelProjection) *tree.Node {
+	matchers := make([]any, len(ast.Labels))
+	for i := range ast.Labels {
+		matchers[i] = ast.Labels[i].String()
+	}
+
+	node := tree.NewNode("PROJECT_LABELS", ast.Name(),
+		tree.NewProperty("table", false, ast.Table.Name()),
+		tree.NewProperty("keep", false, ast.Keep),
+		tree.NewProperty("labels", true, matchers...),
+	)
+	node.Children = append(node.Children, t.convert(ast.Table))
+	return node
+}
+
 func (t *treeFormatter) convertLimit(ast *Limit) *tree.Node {
 	node := tree.NewNode("LIMIT", ast.Name(),
 		tree.NewProperty("table", false, ast.Table.Name()),
@@ -142,6 +224,18 @@ func (t *treeFormatter) convertRangeAggregation(r *RangeAggregation) *tree.Node
 		tree.NewProperty("range", false, r.RangeInterval),
 	}
 
+	if r.Offset != 0 {
+		properties = append(properties, tree.NewProperty("offset", false, r.Offset))
+	}
+
+	if r.Operation == types.RangeAggregationTypeQuantile {
+		properties = append(properties, tree.NewProperty("parameter", false, r.Parameter))
+	}
+
+	if r.Unwrap != nil {
+		properties = append(properties, tree.NewProperty("unwrap", false, r.Unwrap.String()))
+	}
+
 	if len(r.PartitionBy) > 0 {
 		partitionBy := make([]any, len(r.PartitionBy))
 		for i := range r.PartitionBy {
@@ -166,13 +260,21 @@ func (t *treeFormatter) convertVectorAggregation(v *VectorAggregation) *tree.Nod
 		tree.NewProperty("operation", false, v.Operation),
 	}
 
-	if len(v.GroupBy) > 0 {
+	if v.Operation == types.VectorAggregationTypeTopK || v.Operation == types.VectorAggregationTypeBottomK {
+		properties = append(properties, tree.NewProperty("parameter", false, v.Parameter))
+	}
+
+	if len(v.GroupBy) > 0 || v.Without {
 		groupBy := make([]any, len(v.GroupBy))
 		for i := range v.GroupBy {
 			groupBy[i] = v.GroupBy[i].Name()
 		}
 
-		properties = append(properties, tree.NewProperty("group_by", true, groupBy...))
+		name := "group_by"
+		if v.Without {
+			name = "without"
+		}
+		properties = append(properties, tree.NewProperty(name, true, groupBy...))
 	}
 
 	node := tree.NewNode("VectorAggregation", v.Name(), properties...)
@@ -183,3 +285,79 @@ func (t *treeFormatter) convertVectorAggregation(v *VectorAggregation) *tree.Nod
 
 	return node
 }
+
+func (t *treeFormatter) convertJoin(j *Join) *tree.Node {
+	properties := []tree.Property{
+		tree.NewProperty("left", false, j.Left.Name()),
+		tree.NewProperty("right", false, j.Right.Name()),
+		tree.NewProperty("operation", false, j.Op),
+	}
+
+	if j.ReturnBool {
+		properties = append(properties, tree.NewProperty("bool", false, true))
+	}
+
+	if j.Matching != nil {
+		labels := make([]any, len(j.Matching.Labels))
+		for i := range j.Matching.Labels {
+			labels[i] = j.Matching.Labels[i].Name()
+		}
+
+		name := "ignoring"
+		if j.Matching.On {
+			name = "on"
+		}
+		properties = append(properties, tree.NewProperty(name, true, labels...))
+
+		if j.Matching.Cardinality != types.MatchOneToOne {
+			include := make([]any, len(j.Matching.Include))
+			for i := range j.Matching.Include {
+				include[i] = j.Matching.Include[i].Name()
+			}
+
+			name := "group_left"
+			if j.Matching.Cardinality == types.MatchOneToMany {
+				name = "group_right"
+			}
+			properties = append(properties, tree.NewProperty(name, true, include...))
+		}
+	}
+
+	node := tree.NewNode("Join", j.Name(), properties...)
+	node.Children = append(node.Children, t.convert(j.Left), t.convert(j.Right))
+
+	return node
+}
+
+func (t *treeFormatter) convertLabelReplace(r *LabelReplace) *tree.Node {
+	node := tree.NewNode("LabelReplace", r.Name(),
+		tree.NewProperty("table", false, r.Table.Name()),
+		tree.NewProperty("dst", false, r.Dst),
+		tree.NewProperty("src", false, r.Src),
+		tree.NewProperty("regex", false, r.Regex),
+		tree.NewProperty("replacement", false, r.Replacement),
+	)
+	node.Children = append(node.Children, t.convert(r.Table))
+	return node
+}
+
+func (t *treeFormatter) convertVector(v *Vector) *tree.Node {
+	return tree.NewNode("Vector", v.Name(),
+		tree.NewProperty("value", false, v.Value),
+		tree.NewProperty("start_ts", false, util.FormatTimeRFC3339Nano(v.Start)),
+		tree.NewProperty("end_ts", false, util.FormatTimeRFC3339Nano(v.End)),
+		tree.NewProperty("step", false, v.Step),
+	)
+}
+
+func (t *treeFormatter) convertAbsent(a *Absent) *tree.Node {
+	node := tree.NewNode("Absent", a.Name(),
+		tree.NewProperty("table", false, a.Table.Name()),
+		tree.NewProperty("labels", false, a.Labels.String()),
+		tree.NewProperty("start_ts", false, util.FormatTimeRFC3339Nano(a.Start)),
+		tree.NewProperty("end_ts", false, util.FormatTimeRFC3339Nano(a.End)),
+		tree.NewProperty("step", false, a.Step),
+	)
+	node.Children = append(node.Children, t.convert(a.Table))
+	return node
+}
@@ -130,6 +130,7 @@ func TestFormatRangeAggregationQuery(t *testing.T) {
 		time.Date(1970, 1, 1, 1, 0, 0, 0, time.UTC), // End Time
 		time.Minute,
 		time.Minute*5, // Range
+		0,             // Offset
 	)
 
 	// Convert to plan so that node IDs get populated
@@ -0,0 +1,69 @@
+package logical
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/prometheus/prometheus/model/labels"
+
+	"github.com/grafana/loki/v3/pkg/engine/internal/types"
+	"github.com/grafana/loki/v3/pkg/engine/internal/util"
+	"github.com/grafana/loki/v3/pkg/engine/planner/schema"
+)
+
+// The Absent instruction produces a single series with the value 1 at each
+// step of the query for which its table relation has no rows, such as the
+// absent_over_time function of LogQL. Absent implements both [Instruction]
+// and [Value].
+type Absent struct {
+	id string
+
+	Table Value // The table relation of the aggregated series.
+
+	// Labels are the labels of the produced series. They are derived from the
+	// equality matchers of the stream selector.
+	Labels labels.Labels
+
+	Start time.Time
+	End   time.Time
+	Step  time.Duration
+}
+
+var (
+	_ Value       = (*Absent)(nil)
+	_ Instruction = (*Absent)(nil)
+)
+
+// Name returns an identifier for the Absent operation.
+func (a *Absent) Name() string {
+	if a.id != "" {
+		return a.id
+	}
+	return fmt.Sprintf("%p", a)
+}
+
+// String returns the disassembled SSA form of the Absent instruction.
+func (a *Absent) String() string {
+	return fmt.Sprintf(
+		"ABSENT %s [labels=%s, start_ts=%s, end_ts=%s, step=%s]",
+		a.Table.Name(), a.Labels, util.FormatTimeRFC3339Nano(a.Start), util.FormatTimeRFC3339Nano(a.End), a.Step,
+	)
+}
+
+// Schema returns the schema of the Absent plan.
+func (a *Absent) Schema() *schema.Schema {
+	outputSchema := schema.Schema{
+		Columns: make([]schema.ColumnSchema, 0, a.Labels.Len()+2),
+	}
+	outputSchema.Columns = append(outputSchema.Columns,
+		schema.ColumnSchema{Name: types.ColumnNameBuiltinTimestamp, Type: schema.ValueTypeTimestamp},
+		schema.ColumnSchema{Name: types.ColumnNameGeneratedValue, Type: schema.ValueTypeFloat64},
+	)
+	a.Labels.Range(func(l labels.Label) {
+		outputSchema.Columns = append(outputSchema.Columns, schema.ColumnSchema{Name: l.Name, Type: schema.ValueTypeString})
+	})
+	return &outputSchema
+}
+
+func (a *Absent) isInstruction() {}
+func (a *Absent) isValue()       {}
@@ -0,0 +1,124 @@
+package logical
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/grafana/loki/v3/pkg/engine/internal/types"
+	"github.com/grafana/loki/v3/pkg/engine/planner/schema"
+)
+
+// Join represents a logical plan node that performs a binary operation
+// between two vectors, or between a vector and a scalar.
+//
+// The series of two vectors are matched by their labels, following the
+// vector matching rules of PromQL. A scalar operand is represented by a
+// [Literal] and is applied to every series of the other operand.
+type Join struct {
+	id string
+
+	Left, Right Value // The table relations of the operands, or a *Literal for a scalar operand.
+
+	// The binary operation to perform, e.g. division or comparison.
+	Op types.BinaryOp
+
+	// ReturnBool is true if a comparison returns 0 or 1 instead of filtering
+	// the series.
+	ReturnBool bool
+
+	// Matching defines how the series of two vectors are matched. It is nil
+	// if one of the operands is a scalar.
+	Matching *VectorMatching
+}
+
+// VectorMatching describes how the series of the two sides of a [Join] are
+// matched.
+type VectorMatching struct {
+	// Cardinality of the matching.
+	Cardinality types.MatchCardinality
+
+	// On is true if the series are matched on the given Labels only, rather
+	// than on all labels except the given Labels.
+	On     bool
+	Labels []ColumnRef
+
+	// Include holds the labels of the "one" side that are added to the result
+	// of group_left and group_right matches.
+	Include []ColumnRef
+}
+
+// String returns the properties of the vector matching.
+func (m *VectorMatching) String() string {
+	var sb strings.Builder
+
+	if m.On {
+		sb.WriteString("on=(")
+	} else {
+		sb.WriteString("ignoring=(")
+	}
+	writeColumnRefs(&sb, m.Labels)
+	sb.WriteString(")")
+
+	switch m.Cardinality {
+	case types.MatchManyToOne:
+		sb.WriteString(", group_left=(")
+		writeColumnRefs(&sb, m.Include)
+		sb.WriteString(")")
+	case types.MatchOneToMany:
+		sb.WriteString(", group_right=(")
+		writeColumnRefs(&sb, m.Include)
+		sb.WriteString(")")
+	}
+
+	return sb.String()
+}
+
+func writeColumnRefs(sb *strings.Builder, refs []ColumnRef) {
+	for i, ref := range refs {
+		if i > 0 {
+			sb.WriteString(", ")
+		}
+		sb.WriteString(ref.String())
+	}
+}
+
+var (
+	_ Value       = (*Join)(nil)
+	_ Instruction = (*Join)(nil)
+)
+
+// Name returns an identifier for the Join operation.
+func (j *Join) Name() string {
+	if j.id != "" {
+		return j.id
+	}
+	return fmt.Sprintf("%p", j)
+}
+
+// String returns the disassembled SSA form of the Join instruction.
+func (j *Join) String() string {
+	props := fmt.Sprintf("operation=%s", j.Op)
+	if j.ReturnBool {
+		props += ", bool"
+	}
+	if j.Matching != nil {
+		props += ", " + j.Matching.String()
+	}
+
+	return fmt.Sprintf("JOIN %s %s [%s]", j.Left.Name(), j.Right.Name(), props)
+}
+
+// Schema returns the schema of the join.
+func (j *Join) Schema() *schema.Schema {
+	// The label columns of the result depend on the labels of the matched
+	// series, which are only known at execution time.
+	return &schema.Schema{
+		Columns: []schema.ColumnSchema{
+			{Name: types.ColumnNameBuiltinTimestamp, Type: schema.ValueTypeTimestamp},
+			{Name: types.ColumnNameGeneratedValue, Type: schema.ValueTypeFloat64},
+		},
+	}
+}
+
+func (j *Join) isInstruction() {}
+func (j *Join) isValue()       {}
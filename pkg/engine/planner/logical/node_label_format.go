@@ -0,0 +1,82 @@
+package logical
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/grafana/loki/v3/pkg/engine/planner/schema"
+)
+
+// The LabelFormat instruction renames columns or sets columns to the result
+// of a text template for each row of a table relation, such as the
+// label_format stage of LogQL. LabelFormat implements both [Instruction] and
+// [Value].
+//
+// Formatted columns are of type [types.ColumnTypeParsed].
+type LabelFormat struct {
+	id string
+
+	Table Value // The table relation to format.
+
+	Formats []LabelFormatExpression // The formatted columns.
+}
+
+// LabelFormatExpression describes a single column formatted by a
+// [LabelFormat] instruction.
+type LabelFormatExpression struct {
+	Name string // The name of the formatted column.
+
+	// Value is the name of the renamed column if Rename is true, otherwise
+	// it is the text template of the column value.
+	Value  string
+	Rename bool
+}
+
+// String returns the string representation of the LabelFormatExpression.
+func (e LabelFormatExpression) String() string {
+	if e.Rename {
+		return fmt.Sprintf("%s=%s", e.Name, e.Value)
+	}
+	return fmt.Sprintf("%s=%s", e.Name, strconv.Quote(e.Value))
+}
+
+var (
+	_ Value       = (*LabelFormat)(nil)
+	_ Instruction = (*LabelFormat)(nil)
+)
+
+// Name returns an identifier for the LabelFormat operation.
+func (f *LabelFormat) Name() string {
+	if f.id != "" {
+		return f.id
+	}
+	return fmt.Sprintf("%p", f)
+}
+
+// String returns the disassembled SSA form of the LabelFormat instruction.
+func (f *LabelFormat) String() string {
+	formats := make([]string, len(f.Formats))
+	for i, format := range f.Formats {
+		formats[i] = format.String()
+	}
+	return fmt.Sprintf("LABEL_FORMAT %s [formats=(%s)]", f.Table.Name(), strings.Join(formats, ", "))
+}
+
+// Schema returns the schema of the LabelFormat plan.
+func (f *LabelFormat) Schema() *schema.Schema {
+	var outputSchema schema.Schema
+	if input := f.Table.Schema(); input != nil {
+		outputSchema.Columns = append(outputSchema.Columns, input.Columns...)
+	}
+	for _, format := range f.Formats {
+		outputSchema.Columns = append(outputSchema.Columns, schema.ColumnSchema{
+			Name: format.Name,
+			Type: schema.ValueTypeString,
+		})
+	}
+	return &outputSchema
+}
+
+func (f *LabelFormat) isInstruction() {}
+func (f *LabelFormat) isValue()       {}
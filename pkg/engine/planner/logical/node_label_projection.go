@@ -0,0 +1,80 @@
+package logical
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/prometheus/prometheus/model/labels"
+
+	"github.com/grafana/loki/v3/pkg/engine/planner/schema"
+)
+
+// The LabelProjection instruction keeps or drops the label, metadata and
+// parsed columns of each row of a table relation, such as the keep and drop
+// stages of LogQL. LabelProjection implements both [Instruction] and [Value].
+//
+// Since matchers select columns based on their values, the projection is
+// applied per row: the values of columns that are removed are set to NULL.
+type LabelProjection struct {
+	id string
+
+	Table Value // The table relation to project.
+
+	// Keep is true if only the columns that match Labels are kept. Otherwise
+	// the columns that match Labels are dropped.
+	Keep bool
+
+	Labels []LabelMatcher // The matchers of the projected columns.
+}
+
+// LabelMatcher selects a column of a [LabelProjection] either by its name, or
+// by its name and value if Matcher is set.
+type LabelMatcher struct {
+	Name    string
+	Matcher *labels.Matcher
+}
+
+// String returns the string representation of the LabelMatcher.
+func (m LabelMatcher) String() string {
+	if m.Matcher != nil {
+		return m.Matcher.String()
+	}
+	return m.Name
+}
+
+var (
+	_ Value       = (*LabelProjection)(nil)
+	_ Instruction = (*LabelProjection)(nil)
+)
+
+// Name returns an identifier for the LabelProjection operation.
+func (p *LabelProjection) Name() string {
+	if p.id != "" {
+		return p.id
+	}
+	return fmt.Sprintf("%p", p)
+}
+
+// String returns the disassembled SSA form of the LabelProjection instruction.
+func (p *LabelProjection) String() string {
+	matchers := make([]string, len(p.Labels))
+	for i, m := range p.Labels {
+		matchers[i] = m.String()
+	}
+
+	mode := "drop"
+	if p.Keep {
+		mode = "keep"
+	}
+	return fmt.Sprintf("PROJECT_LABELS %s [%s=(%s)]", p.Table.Name(), mode, strings.Join(matchers, ", "))
+}
+
+// Schema returns the schema of the LabelProjection plan.
+func (p *LabelProjection) Schema() *schema.Schema {
+	// Columns are only removed for some of the rows, so the schema is the same
+	// as the input table relation.
+	return p.Table.Schema()
+}
+
+func (p *LabelProjection) isInstruction() {}
+func (p *LabelProjection) isValue()       {}
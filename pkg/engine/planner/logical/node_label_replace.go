@@ -0,0 +1,69 @@
+package logical
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/grafana/loki/v3/pkg/engine/planner/schema"
+)
+
+// The LabelReplace instruction sets a column to the expansion of a
+// replacement template for each row of a table relation whose source column
+// matches a regular expression, such as the label_replace function of LogQL.
+// LabelReplace implements both [Instruction] and [Value].
+//
+// Rows that do not match are not modified. If the expansion is empty, the
+// column is set to NULL. A column that does not exist yet is added with type
+// [types.ColumnTypeParsed].
+type LabelReplace struct {
+	id string
+
+	Table Value // The table relation to replace columns of.
+
+	Dst         string // The name of the column to set.
+	Src         string // The name of the column that is matched against Regex.
+	Regex       string // The regular expression, which must match the whole value of Src.
+	Replacement string // The replacement template, which may reference capture groups of Regex.
+}
+
+var (
+	_ Value       = (*LabelReplace)(nil)
+	_ Instruction = (*LabelReplace)(nil)
+)
+
+// Name returns an identifier for the LabelReplace operation.
+func (r *LabelReplace) Name() string {
+	if r.id != "" {
+		return r.id
+	}
+	return fmt.Sprintf("%p", r)
+}
+
+// String returns the disassembled SSA form of the LabelReplace instruction.
+func (r *LabelReplace) String() string {
+	return fmt.Sprintf(
+		"LABEL_REPLACE %s [dst=%s, src=%s, regex=%s, replacement=%s]",
+		r.Table.Name(), r.Dst, r.Src, strconv.Quote(r.Regex), strconv.Quote(r.Replacement),
+	)
+}
+
+// Schema returns the schema of the LabelReplace plan.
+func (r *LabelReplace) Schema() *schema.Schema {
+	var outputSchema schema.Schema
+	if input := r.Table.Schema(); input != nil {
+		outputSchema.Columns = append(outputSchema.Columns, input.Columns...)
+	}
+	for _, col := range outputSchema.Columns {
+		if col.Name == r.Dst {
+			return &outputSchema
+		}
+	}
+	outputSchema.Columns = append(outputSchema.Columns, schema.ColumnSchema{
+		Name: r.Dst,
+		Type: schema.ValueTypeString,
+	})
+	return &outputSchema
+}
+
+func (r *LabelReplace) isInstruction() {}
+func (r *LabelReplace) isValue()       {}
@@ -0,0 +1,51 @@
+package logical
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/grafana/loki/v3/pkg/engine/planner/schema"
+)
+
+// The LineFormat instruction replaces the log line of each row of a table
+// relation with the result of a text template, such as the line_format stage
+// of LogQL. LineFormat implements both [Instruction] and [Value].
+//
+// The template has access to all columns of the row. Rows for which the
+// template fails to execute keep their log line and get the __error__ and
+// __error_details__ columns set instead.
+type LineFormat struct {
+	id string
+
+	Table Value // The table relation to format.
+
+	Template string // The text template of the new log line.
+}
+
+var (
+	_ Value       = (*LineFormat)(nil)
+	_ Instruction = (*LineFormat)(nil)
+)
+
+// Name returns an identifier for the LineFormat operation.
+func (f *LineFormat) Name() string {
+	if f.id != "" {
+		return f.id
+	}
+	return fmt.Sprintf("%p", f)
+}
+
+// String returns the disassembled SSA form of the LineFormat instruction.
+func (f *LineFormat) String() string {
+	return fmt.Sprintf("LINE_FORMAT %s [template=%s]", f.Table.Name(), strconv.Quote(f.Template))
+}
+
+// Schema returns the schema of the LineFormat plan.
+func (f *LineFormat) Schema() *schema.Schema {
+	// LineFormat only replaces the values of the message column, so the schema
+	// is the same as the input table relation.
+	return f.Table.Schema()
+}
+
+func (f *LineFormat) isInstruction() {}
+func (f *LineFormat) isValue()       {}
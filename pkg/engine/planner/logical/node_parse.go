@@ -0,0 +1,103 @@
+package logical
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/grafana/loki/v3/pkg/engine/internal/types"
+	"github.com/grafana/loki/v3/pkg/engine/planner/schema"
+)
+
+// The Parse instruction extracts columns from the log line of each row of a
+// table relation, such as the json or logfmt parser stages of LogQL. Parse
+// implements both [Instruction] and [Value].
+//
+// The extracted columns are of type [types.ColumnTypeParsed]. Rows that fail to
+// parse get the __error__ and __error_details__ columns set instead.
+type Parse struct {
+	id string
+
+	Table Value // The table relation to parse.
+
+	Parser types.ParserType // The parser used to extract columns.
+
+	// Pattern is the expression of the regexp and pattern parsers.
+	Pattern string
+
+	// Strict and KeepEmpty configure the logfmt parser.
+	Strict    bool
+	KeepEmpty bool
+
+	// Expressions limits the extracted columns of the json and logfmt parsers
+	// to the given expressions. If empty, all keys are extracted.
+	Expressions []ParseExpression
+}
+
+// ParseExpression describes a single column extracted by a [Parse] instruction.
+type ParseExpression struct {
+	Name string // The name of the extracted column.
+	Path string // The path of the value in the log line.
+}
+
+// String returns the string representation of the ParseExpression.
+func (e ParseExpression) String() string {
+	return fmt.Sprintf("%s=%s", e.Name, strconv.Quote(e.Path))
+}
+
+var (
+	_ Value       = (*Parse)(nil)
+	_ Instruction = (*Parse)(nil)
+)
+
+// Name returns an identifier for the Parse operation.
+func (p *Parse) Name() string {
+	if p.id != "" {
+		return p.id
+	}
+	return fmt.Sprintf("%p", p)
+}
+
+// String returns the disassembled SSA form of the Parse instruction.
+func (p *Parse) String() string {
+	props := fmt.Sprintf("parser=%s", p.Parser)
+	if p.Pattern != "" {
+		props += fmt.Sprintf(", pattern=%s", strconv.Quote(p.Pattern))
+	}
+	if p.Strict {
+		props += ", strict=true"
+	}
+	if p.KeepEmpty {
+		props += ", keep_empty=true"
+	}
+	if len(p.Expressions) > 0 {
+		expressions := make([]string, len(p.Expressions))
+		for i, expr := range p.Expressions {
+			expressions[i] = expr.String()
+		}
+		props += fmt.Sprintf(", expressions=(%s)", strings.Join(expressions, ", "))
+	}
+
+	return fmt.Sprintf("PARSE %s [%s]", p.Table.Name(), props)
+}
+
+// Schema returns the schema of the Parse plan.
+func (p *Parse) Schema() *schema.Schema {
+	// The columns extracted by most parsers are only known at execution time,
+	// so the schema only contains the input columns and the columns of the
+	// explicit expressions.
+	var outputSchema schema.Schema
+	if input := p.Table.Schema(); input != nil {
+		outputSchema.Columns = append(outputSchema.Columns, input.Columns...)
+	}
+	for _, expr := range p.Expressions {
+		outputSchema.Columns = append(outputSchema.Columns, schema.ColumnSchema{
+			Name: expr.Name,
+			Type: schema.ValueTypeString,
+		})
+	}
+	return &outputSchema
+}
+
+func (p *Parse) isInstruction() {}
+func (p *Parse) isValue()       {}
@@ -21,10 +21,33 @@ type RangeAggregation struct {
 	PartitionBy []ColumnRef // The columns to partition by.
 
 	Operation     types.RangeAggregationType // The type of aggregation operation to perform.
+	Parameter     float64                    // The parameter of the operation, e.g. the quantile for quantile_over_time.
+	Unwrap        *Unwrap                    // The column to take sample values from. Nil if the operation works on log lines.
 	Start         time.Time
 	End           time.Time
 	Step          time.Duration
 	RangeInterval time.Duration
+
+	// Offset shifts the windows of the aggregation back in time. The results
+	// are reported at the timestamps of the steps, as with the offset
+	// modifier of LogQL.
+	Offset time.Duration
+}
+
+// Unwrap describes how sample values are extracted from a column for range
+// aggregations over unwrapped values, such as sum_over_time.
+type Unwrap struct {
+	Column     ColumnRef            // The column that holds the values.
+	Conversion types.ConversionType // The conversion applied to the string values of the column.
+
+	// DropErrors denotes whether entries that fail the conversion are dropped.
+	// If false, the query fails when a value cannot be converted.
+	DropErrors bool
+}
+
+// String returns the string representation of the Unwrap.
+func (u *Unwrap) String() string {
+	return fmt.Sprintf("%s(%s)", u.Conversion, u.Column.String())
 }
 
 var (
@@ -43,6 +66,15 @@ func (r *RangeAggregation) Name() string {
 // String returns the disassembled SSA form of the RangeAggregation instruction.
 func (r *RangeAggregation) String() string {
 	props := fmt.Sprintf("operation=%s, start_ts=%s, end_ts=%s, step=%s, range=%s", r.Operation, util.FormatTimeRFC3339Nano(r.Start), util.FormatTimeRFC3339Nano(r.End), r.Step, r.RangeInterval)
+	if r.Offset != 0 {
+		props += fmt.Sprintf(", offset=%s", r.Offset)
+	}
+	if r.Operation == types.RangeAggregationTypeQuantile {
+		props += fmt.Sprintf(", parameter=%v", r.Parameter)
+	}
+	if r.Unwrap != nil {
+		props += fmt.Sprintf(", unwrap=%s", r.Unwrap)
+	}
 
 	if len(r.PartitionBy) > 0 {
 		partitionBy := ""
@@ -77,28 +109,32 @@ func (r *RangeAggregation) Schema() *schema.Schema {
 			Name: types.ColumnNameBuiltinTimestamp,
 			Type: schema.ValueTypeTimestamp,
 		})
-		// Using int64 since only count_over_time is supported.
 		outputSchema.Columns = append(outputSchema.Columns, schema.ColumnSchema{
 			Name: types.ColumnNameGeneratedValue,
-			Type: schema.ValueTypeInt64,
+			Type: schema.ValueTypeFloat64,
 		})
+		return &outputSchema
 	}
 
 	// If partition by is empty, we aggregate by query-time series.
 	// Schema is then comprised of:
-	// - input columns excluding message Column
+	// - input columns excluding message column and unwrapped column
 	// - aggregated value column
 	outputSchema := schema.Schema{
 		Columns: make([]schema.ColumnSchema, 0, len(r.Table.Schema().Columns)),
 	}
 	for _, col := range r.Table.Schema().Columns {
-		if col.Name != types.ColumnNameBuiltinMessage { // Exclude message column
-			outputSchema.Columns = append(outputSchema.Columns, col)
+		if col.Name == types.ColumnNameBuiltinMessage { // Exclude message column
+			continue
+		}
+		if r.Unwrap != nil && col.Name == r.Unwrap.Column.Ref.Column { // Exclude unwrapped column
+			continue
 		}
+		outputSchema.Columns = append(outputSchema.Columns, col)
 	}
 	outputSchema.Columns = append(outputSchema.Columns, schema.ColumnSchema{
 		Name: types.ColumnNameGeneratedValue,
-		Type: schema.ValueTypeInt64,
+		Type: schema.ValueTypeFloat64,
 	})
 	return &outputSchema
 }
@@ -0,0 +1,59 @@
+package logical
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/grafana/loki/v3/pkg/engine/internal/types"
+	"github.com/grafana/loki/v3/pkg/engine/internal/util"
+	"github.com/grafana/loki/v3/pkg/engine/planner/schema"
+)
+
+// The Vector instruction produces a single series without labels that has
+// the same value at each step of the query, such as the vector function of
+// LogQL. Vector does not have a table relation. Vector implements both
+// [Instruction] and [Value].
+type Vector struct {
+	id string
+
+	Value float64 // The value of the series at each step.
+
+	Start time.Time
+	End   time.Time
+	Step  time.Duration
+}
+
+var (
+	_ Value       = (*Vector)(nil)
+	_ Instruction = (*Vector)(nil)
+)
+
+// Name returns an identifier for the Vector operation.
+func (v *Vector) Name() string {
+	if v.id != "" {
+		return v.id
+	}
+	return fmt.Sprintf("%p", v)
+}
+
+// String returns the disassembled SSA form of the Vector instruction.
+func (v *Vector) String() string {
+	return fmt.Sprintf(
+		"VECTOR [value=%s, start_ts=%s, end_ts=%s, step=%s]",
+		strconv.FormatFloat(v.Value, 'g', -1, 64), util.FormatTimeRFC3339Nano(v.Start), util.FormatTimeRFC3339Nano(v.End), v.Step,
+	)
+}
+
+// Schema returns the schema of the Vector plan.
+func (v *Vector) Schema() *schema.Schema {
+	return &schema.Schema{
+		Columns: []schema.ColumnSchema{
+			{Name: types.ColumnNameBuiltinTimestamp, Type: schema.ValueTypeTimestamp},
+			{Name: types.ColumnNameGeneratedValue, Type: schema.ValueTypeFloat64},
+		},
+	}
+}
+
+func (v *Vector) isInstruction() {}
+func (v *Vector) isValue()       {}
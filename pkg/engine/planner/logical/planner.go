@@ -13,6 +13,7 @@ import (
 	"github.com/grafana/loki/v3/pkg/logql"
 	"github.com/grafana/loki/v3/pkg/logql/log"
 	"github.com/grafana/loki/v3/pkg/logql/syntax"
+	"github.com/grafana/loki/v3/pkg/logqlmodel"
 )
 
 var errUnimplemented = errors.New("query contains unimplemented features")
@@ -25,11 +26,13 @@ func BuildPlan(params logql.Params) (*Plan, error) {
 		err     error
 	)
 
+	// Sample expressions are checked first, because some of them, such as
+	// vector(), also implement [syntax.LogSelectorExpr].
 	switch e := params.GetExpression().(type) {
-	case syntax.LogSelectorExpr:
-		builder, err = buildPlanForLogQuery(e, params, false, 0)
 	case syntax.SampleExpr:
 		builder, err = buildPlanForSampleQuery(e, params)
+	case syntax.LogSelectorExpr:
+		builder, err = buildPlanForLogQuery(e, params, false, 0, 0)
 	default:
 		err = fmt.Errorf("unexpected expression type (%T)", e)
 	}
@@ -44,11 +47,20 @@ func BuildPlan(params logql.Params) (*Plan, error) {
 // buildPlanForLogQuery builds logical plan operations by traversing [syntax.LogSelectorExpr]
 // isMetricQuery should be set to true if this expr is encountered when processing a [syntax.SampleExpr].
 // rangeInterval should be set to a non-zero value if the query contains [$range].
-func buildPlanForLogQuery(expr syntax.LogSelectorExpr, params logql.Params, isMetricQuery bool, rangeInterval time.Duration) (*Builder, error) {
+// offset shifts the scanned time range of metric queries back in time.
+func buildPlanForLogQuery(expr syntax.LogSelectorExpr, params logql.Params, isMetricQuery bool, rangeInterval, offset time.Duration) (*Builder, error) {
 	var (
-		err        error
-		selector   Value
+		err      error
+		selector Value
+
+		// predicates are applied before the first parser stage.
 		predicates []Value
+		// stages are the parse, format, projection and filter stages that
+		// follow the first of these stages, in the order of the pipeline.
+		stages []Value
+		// lineRewritten is set once a stage may have changed the log line, so
+		// line filters can no longer be applied before the parser stages.
+		lineRewritten bool
 	)
 
 	// TODO(chaudum): Implement a Walk function that can return an error
@@ -61,21 +73,54 @@ func buildPlanForLogQuery(expr syntax.LogSelectorExpr, params logql.Params, isMe
 			selector = convertLabelMatchers(e.Matchers())
 			return true
 		case *syntax.LineFilterExpr:
-			predicates = append(predicates, convertLineFilterExpr(e))
+			// Line filters are evaluated before the parser stages, unless the
+			// line has been rewritten by a previous stage. This is the same as
+			// the reordering of stages in the old engine.
+			if lineRewritten {
+				stages = append(stages, convertLineFilterExpr(e))
+			} else {
+				predicates = append(predicates, convertLineFilterExpr(e))
+			}
 			// We do not want to traverse the AST further down, because line filter expressions can be nested,
 			// which would lead to multiple predicates of the same expression.
 			return false // do not traverse children
 		case *syntax.LabelFilterExpr:
 			if val, innerErr := convertLabelFilter(e.LabelFilterer); innerErr != nil {
 				err = innerErr
+			} else if len(stages) > 0 {
+				// Label filters after a parser or format stage may reference
+				// parsed or formatted columns.
+				stages = append(stages, val)
 			} else {
 				predicates = append(predicates, val)
 			}
 			return true
-		case *syntax.LineParserExpr, *syntax.LogfmtParserExpr, *syntax.LogfmtExpressionParserExpr, *syntax.JSONExpressionParserExpr,
-			*syntax.LineFmtExpr, *syntax.LabelFmtExpr,
-			*syntax.KeepLabelsExpr, *syntax.DropLabelsExpr:
-			err = errUnimplemented
+		case *syntax.LineParserExpr, *syntax.LogfmtParserExpr, *syntax.LogfmtExpressionParserExpr, *syntax.JSONExpressionParserExpr:
+			parse, innerErr := convertParserExpr(e)
+			if innerErr != nil {
+				err = innerErr
+				return false
+			}
+			// The unpack parser replaces the log line with the packed entry.
+			if parse.Parser == types.ParserTypeUnpack {
+				lineRewritten = true
+			}
+			stages = append(stages, parse)
+			return false // do not traverse children
+		case *syntax.LineFmtExpr:
+			// line_format replaces the log line, so line filters that follow
+			// it must be evaluated on the formatted line.
+			lineRewritten = true
+			stages = append(stages, &LineFormat{Template: e.Value})
+			return false // do not traverse children
+		case *syntax.LabelFmtExpr:
+			stages = append(stages, convertLabelFmtExpr(e))
+			return false // do not traverse children
+		case *syntax.KeepLabelsExpr:
+			stages = append(stages, &LabelProjection{Keep: true, Labels: convertNamedLabelMatchers(e.KeepLabels())})
+			return false // do not traverse children
+		case *syntax.DropLabelsExpr:
+			stages = append(stages, &LabelProjection{Labels: convertNamedLabelMatchers(e.DropLabels())})
 			return false // do not traverse children
 		default:
 			err = errUnimplemented
@@ -100,21 +145,23 @@ func buildPlanForLogQuery(expr syntax.LogSelectorExpr, params logql.Params, isMe
 		},
 	)
 
-	direction := params.Direction()
-	if !isMetricQuery && direction == logproto.FORWARD {
-		return nil, fmt.Errorf("forward search log queries are not supported: %w", errUnimplemented)
-	}
-
 	// SORT -> SortMerge
-	// We always sort DESC. ASC timestamp sorting is not supported for logs queries,
-	// and metric queries do not care about the direction.
-	builder = builder.Sort(*timestampColumnRef(), false, false)
+	// Log queries are sorted in the direction of the query. Metric queries do
+	// not care about the direction, so they are always sorted DESC, which is
+	// the order in which data objects store their logs.
+	ascending := !isMetricQuery && params.Direction() == logproto.FORWARD
+	builder = builder.Sort(*timestampColumnRef(), ascending, false)
 
 	// SELECT -> Filter
 	start := params.Start()
 	end := params.End()
-	// extend search by rangeInterval to be able to include entries belonging to the [$range] interval.
-	for _, value := range convertQueryRangeToPredicates(start.Add(-rangeInterval), end) {
+
+	timePredicates := convertQueryRangeToPredicates(start, end)
+	if isMetricQuery {
+		// extend search by rangeInterval to be able to include entries belonging to the [$range] interval.
+		timePredicates = convertMetricQueryRangeToPredicates(start.Add(-rangeInterval-offset), end.Add(-offset))
+	}
+	for _, value := range timePredicates {
 		builder = builder.Select(value)
 	}
 
@@ -122,6 +169,25 @@ func buildPlanForLogQuery(expr syntax.LogSelectorExpr, params logql.Params, isMe
 		builder = builder.Select(value)
 	}
 
+	// PARSE -> Parse
+	// LINE_FORMAT -> LineFormat
+	// LABEL_FORMAT -> LabelFormat
+	// PROJECT_LABELS -> LabelProjection
+	for _, stage := range stages {
+		switch stage := stage.(type) {
+		case *Parse:
+			builder = builder.Parse(stage)
+		case *LineFormat:
+			builder = builder.LineFormat(stage)
+		case *LabelFormat:
+			builder = builder.LabelFormat(stage)
+		case *LabelProjection:
+			builder = builder.ProjectLabels(stage)
+		default:
+			builder = builder.Select(stage)
+		}
+	}
+
 	// Metric queries do not apply a limit.
 	if !isMetricQuery {
 		// LIMIT -> Limit
@@ -132,81 +198,502 @@ func buildPlanForLogQuery(expr syntax.LogSelectorExpr, params logql.Params, isMe
 	return builder, nil
 }
 
+// buildPlanForSampleQuery builds logical plan operations by traversing [syntax.SampleExpr].
+// Binary operations build a plan for each of their operands, which are
+// combined by a [Join].
 func buildPlanForSampleQuery(e syntax.SampleExpr, params logql.Params) (*Builder, error) {
-	if params.Step() > 0 {
-		return nil, fmt.Errorf("only instant metric queries are supported: %w", errUnimplemented)
-	}
-
-	var (
-		err error
-
-		rangeAggType  types.RangeAggregationType
-		rangeInterval time.Duration
+	switch e := e.(type) {
+	case *syntax.RangeAggregationExpr:
+		return buildPlanForRangeAggregation(e, params)
+
+	case *syntax.VectorAggregationExpr:
+		operation := convertVectorAggregationType(e.Operation)
+		if operation == types.VectorAggregationTypeInvalid {
+			return nil, errUnimplemented
+		}
 
-		vecAggType types.VectorAggregationType
-		groupBy    []ColumnRef
-	)
+		builder, err := buildPlanForSampleQuery(e.Left, params)
+		if err != nil {
+			return nil, err
+		}
 
-	e.Walk(func(e syntax.Expr) bool {
-		switch e := e.(type) {
-		case *syntax.RangeAggregationExpr:
-			// only count operation is supported for range aggregation.
-			// offsets are not yet supported.
-			if e.Operation != syntax.OpRangeTypeCount || e.Left.Offset != 0 {
-				err = errUnimplemented
-				return false
+		var (
+			grouping []ColumnRef
+			without  bool
+		)
+		if e.Grouping != nil {
+			without = e.Grouping.Without
+			grouping = make([]ColumnRef, 0, len(e.Grouping.Groups))
+			for _, group := range e.Grouping.Groups {
+				grouping = append(grouping, *NewColumnRef(group, types.ColumnTypeAmbiguous))
 			}
+		}
 
-			rangeAggType = types.RangeAggregationTypeCount
-			rangeInterval = e.Left.Interval
-			return false // do not traverse log range query
+		return builder.GroupedVectorAggregation(grouping, without, operation, e.Params), nil
 
-		case *syntax.VectorAggregationExpr:
-			// only sum operation is supported for vector aggregation
-			// grouping by atleast one label is required.
-			if e.Operation != syntax.OpTypeSum ||
-				e.Grouping == nil || len(e.Grouping.Groups) == 0 || e.Grouping.Without {
-				err = errUnimplemented
-				return false
-			}
+	case *syntax.BinOpExpr:
+		return buildPlanForBinOpExpr(e, params)
 
-			vecAggType = types.VectorAggregationTypeSum
-			groupBy = make([]ColumnRef, 0, len(e.Grouping.Groups))
-			for _, group := range e.Grouping.Groups {
-				groupBy = append(groupBy, *NewColumnRef(group, types.ColumnTypeAmbiguous))
-			}
-
-			return true
-		default:
-			err = errUnimplemented
-			return false // do not traverse children
+	case *syntax.LabelReplaceExpr:
+		builder, err := buildPlanForSampleQuery(e.Left, params)
+		if err != nil {
+			return nil, err
 		}
-	})
-	if err != nil {
-		return nil, err
+		return builder.LabelReplace(&LabelReplace{
+			Dst:         e.Dst,
+			Src:         e.Src,
+			Regex:       e.Regex,
+			Replacement: e.Replacement,
+		}), nil
+
+	case *syntax.VectorExpr:
+		val, err := e.Value()
+		if err != nil {
+			return nil, err
+		}
+		return NewBuilder(&Vector{
+			Value: val,
+			Start: params.Start(),
+			End:   params.End(),
+			Step:  params.Step(),
+		}), nil
+
+	default:
+		return nil, errUnimplemented
 	}
+}
 
-	if rangeAggType == types.RangeAggregationTypeInvalid || vecAggType == types.VectorAggregationTypeInvalid {
+// buildPlanForRangeAggregation builds the logical plan operations of a range
+// aggregation over the log query of its range expression.
+//
+// absent_over_time is built as a count of the entries of each window, which
+// is passed to an [Absent] that produces the series for windows without
+// entries.
+func buildPlanForRangeAggregation(e *syntax.RangeAggregationExpr, params logql.Params) (*Builder, error) {
+	absent := e.Operation == syntax.OpRangeTypeAbsent
+
+	rangeAggType := types.RangeAggregationTypeCount
+	if !absent {
+		rangeAggType = convertRangeAggregationType(e.Operation)
+	}
+	if rangeAggType == types.RangeAggregationTypeInvalid {
 		return nil, errUnimplemented
 	}
+	rangeInterval := e.Left.Interval
+	offset := e.Left.Offset
+
+	// Grouping of range aggregations is only allowed for operations on unwrapped values.
+	var partitionBy []ColumnRef
+	if e.Grouping != nil {
+		if e.Grouping.Without {
+			return nil, fmt.Errorf("range aggregation without grouping is not supported: %w", errUnimplemented)
+		}
+		partitionBy = make([]ColumnRef, 0, len(e.Grouping.Groups))
+		for _, group := range e.Grouping.Groups {
+			partitionBy = append(partitionBy, *NewColumnRef(group, types.ColumnTypeAmbiguous))
+		}
+	}
 
 	logSelectorExpr, err := e.Selector()
 	if err != nil {
 		return nil, err
 	}
 
-	builder, err := buildPlanForLogQuery(logSelectorExpr, params, true, rangeInterval)
+	builder, err := buildPlanForLogQuery(logSelectorExpr, params, true, rangeInterval, offset)
 	if err != nil {
 		return nil, err
 	}
 
-	builder = builder.RangeAggregation(
-		nil, rangeAggType, params.Start(), params.End(), params.Step(), rangeInterval,
-	).VectorAggregation(groupBy, vecAggType)
+	if e.Left.Unwrap != nil {
+		unwrap, postFilters, err := convertUnwrapExpr(e.Left.Unwrap)
+		if err != nil {
+			return nil, err
+		}
 
+		// Post filters are applied before the aggregation, because apart from the
+		// __error__ label they can only reference columns that exist before unwrapping.
+		for _, value := range postFilters {
+			builder = builder.Select(value)
+		}
+
+		var parameter float64
+		if e.Params != nil {
+			parameter = *e.Params
+		}
+
+		builder = builder.UnwrapRangeAggregation(
+			partitionBy, rangeAggType, parameter, unwrap, params.Start(), params.End(), params.Step(), rangeInterval, offset,
+		)
+	} else {
+		builder = builder.RangeAggregation(
+			partitionBy, rangeAggType, params.Start(), params.End(), params.Step(), rangeInterval, offset,
+		)
+	}
+
+	if absent {
+		builder = builder.Absent(convertAbsentLabels(logSelectorExpr.Matchers()), params.Start(), params.End(), params.Step())
+	}
 	return builder, nil
 }
 
+// convertAbsentLabels returns the labels of the series produced by
+// absent_over_time. These are the labels of the equality matchers of the
+// stream selector, except for labels that are matched more than once or by
+// other matcher types, as in the old engine.
+func convertAbsentLabels(matchers []*labels.Matcher) labels.Labels {
+	var (
+		builder = labels.NewBuilder(labels.EmptyLabels())
+		empty   []string
+	)
+	for _, m := range matchers {
+		if m.Name == labels.MetricName {
+			continue
+		}
+		if m.Type == labels.MatchEqual && builder.Get(m.Name) == "" {
+			builder.Set(m.Name, m.Value)
+		} else {
+			empty = append(empty, m.Name)
+		}
+	}
+	builder.Del(empty...)
+	return builder.Labels()
+}
+
+// buildPlanForBinOpExpr builds a [Join] of the plans of the operands of a
+// binary operation. Literal operands become scalar operands of the join.
+func buildPlanForBinOpExpr(e *syntax.BinOpExpr, params logql.Params) (*Builder, error) {
+	op := convertBinOpType(e.Op)
+	if op == types.BinaryOpInvalid {
+		return nil, errUnimplemented
+	}
+
+	left, leftScalar, err := buildPlanForBinOpOperand(e.SampleExpr, params)
+	if err != nil {
+		return nil, err
+	}
+	right, rightScalar, err := buildPlanForBinOpOperand(e.RHS, params)
+	if err != nil {
+		return nil, err
+	}
+
+	// Binary operations between two literals are reduced by the parser.
+	if leftScalar && rightScalar {
+		return nil, errUnimplemented
+	}
+
+	var returnBool bool
+	if e.Opts != nil {
+		returnBool = e.Opts.ReturnBool
+	}
+
+	// The series of vectors are matched by all their labels unless the
+	// matching is specified explicitly. Scalar operands match every series.
+	var matching *VectorMatching
+	if !leftScalar && !rightScalar {
+		matching = &VectorMatching{Cardinality: types.MatchOneToOne}
+		if e.Opts != nil && e.Opts.VectorMatching != nil {
+			matching = convertVectorMatching(e.Opts.VectorMatching)
+		}
+	}
+
+	return NewBuilder(left).Join(right, op, returnBool, matching), nil
+}
+
+// buildPlanForBinOpOperand returns the value of an operand of a binary
+// operation, and whether the operand is a scalar.
+func buildPlanForBinOpOperand(e syntax.SampleExpr, params logql.Params) (Value, bool, error) {
+	if lit, ok := e.(*syntax.LiteralExpr); ok {
+		val, err := lit.Value()
+		if err != nil {
+			return nil, false, err
+		}
+		return NewLiteral(val), true, nil
+	}
+
+	builder, err := buildPlanForSampleQuery(e, params)
+	if err != nil {
+		return nil, false, err
+	}
+	return builder.Value(), false, nil
+}
+
+func convertVectorMatching(m *syntax.VectorMatching) *VectorMatching {
+	matching := &VectorMatching{
+		On:      m.On,
+		Labels:  make([]ColumnRef, 0, len(m.MatchingLabels)),
+		Include: make([]ColumnRef, 0, len(m.Include)),
+	}
+
+	switch m.Card {
+	case syntax.CardManyToOne:
+		matching.Cardinality = types.MatchManyToOne
+	case syntax.CardOneToMany:
+		matching.Cardinality = types.MatchOneToMany
+	default:
+		matching.Cardinality = types.MatchOneToOne
+	}
+
+	for _, name := range m.MatchingLabels {
+		matching.Labels = append(matching.Labels, *NewColumnRef(name, types.ColumnTypeAmbiguous))
+	}
+	for _, name := range m.Include {
+		matching.Include = append(matching.Include, *NewColumnRef(name, types.ColumnTypeAmbiguous))
+	}
+	return matching
+}
+
+func convertBinOpType(op string) types.BinaryOp {
+	switch op {
+	case syntax.OpTypeOr:
+		return types.BinaryOpOr
+	case syntax.OpTypeAnd:
+		return types.BinaryOpAnd
+	case syntax.OpTypeUnless:
+		return types.BinaryOpUnless
+	case syntax.OpTypeAdd:
+		return types.BinaryOpAdd
+	case syntax.OpTypeSub:
+		return types.BinaryOpSub
+	case syntax.OpTypeMul:
+		return types.BinaryOpMul
+	case syntax.OpTypeDiv:
+		return types.BinaryOpDiv
+	case syntax.OpTypeMod:
+		return types.BinaryOpMod
+	case syntax.OpTypePow:
+		return types.BinaryOpPow
+	case syntax.OpTypeCmpEQ:
+		return types.BinaryOpEq
+	case syntax.OpTypeNEQ:
+		return types.BinaryOpNeq
+	case syntax.OpTypeGT:
+		return types.BinaryOpGt
+	case syntax.OpTypeGTE:
+		return types.BinaryOpGte
+	case syntax.OpTypeLT:
+		return types.BinaryOpLt
+	case syntax.OpTypeLTE:
+		return types.BinaryOpLte
+	default:
+		return types.BinaryOpInvalid
+	}
+}
+
+// convertParserExpr converts a parser stage of a log pipeline into a [Parse].
+// The table relation of the returned [Parse] is not set.
+func convertParserExpr(expr syntax.Expr) (*Parse, error) {
+	switch e := expr.(type) {
+	case *syntax.LineParserExpr:
+		parse := &Parse{Pattern: e.Param}
+		switch e.Op {
+		case syntax.OpParserTypeJSON:
+			parse.Parser = types.ParserTypeJSON
+		case syntax.OpParserTypeLogfmt:
+			parse.Parser = types.ParserTypeLogfmt
+		case syntax.OpParserTypeRegexp:
+			parse.Parser = types.ParserTypeRegexp
+		case syntax.OpParserTypePattern:
+			parse.Parser = types.ParserTypePattern
+		case syntax.OpParserTypeUnpack:
+			parse.Parser = types.ParserTypeUnpack
+		default:
+			return nil, fmt.Errorf("parser %s is not supported: %w", e.Op, errUnimplemented)
+		}
+		return parse, nil
+	case *syntax.LogfmtParserExpr:
+		return &Parse{
+			Parser:    types.ParserTypeLogfmt,
+			Strict:    e.Strict,
+			KeepEmpty: e.KeepEmpty,
+		}, nil
+	case *syntax.LogfmtExpressionParserExpr:
+		return &Parse{
+			Parser:      types.ParserTypeLogfmt,
+			Strict:      e.Strict,
+			KeepEmpty:   e.KeepEmpty,
+			Expressions: convertLabelExtractionExprs(e.Expressions),
+		}, nil
+	case *syntax.JSONExpressionParserExpr:
+		return &Parse{
+			Parser:      types.ParserTypeJSON,
+			Expressions: convertLabelExtractionExprs(e.Expressions),
+		}, nil
+	default:
+		return nil, fmt.Errorf("unexpected parser expression %T: %w", expr, errUnimplemented)
+	}
+}
+
+func convertLabelExtractionExprs(exprs []log.LabelExtractionExpr) []ParseExpression {
+	expressions := make([]ParseExpression, 0, len(exprs))
+	for _, expr := range exprs {
+		expressions = append(expressions, ParseExpression{Name: expr.Identifier, Path: expr.Expression})
+	}
+	return expressions
+}
+
+// convertLabelFmtExpr converts a label_format stage of a log pipeline into a
+// [LabelFormat]. The table relation of the returned [LabelFormat] is not set.
+func convertLabelFmtExpr(expr *syntax.LabelFmtExpr) *LabelFormat {
+	formats := make([]LabelFormatExpression, 0, len(expr.Formats))
+	for _, f := range expr.Formats {
+		formats = append(formats, LabelFormatExpression{Name: f.Name, Value: f.Value, Rename: f.Rename})
+	}
+	return &LabelFormat{Formats: formats}
+}
+
+func convertNamedLabelMatchers(matchers []log.NamedLabelMatcher) []LabelMatcher {
+	result := make([]LabelMatcher, 0, len(matchers))
+	for _, m := range matchers {
+		if m.Matcher != nil {
+			result = append(result, LabelMatcher{Name: m.Matcher.Name, Matcher: m.Matcher})
+			continue
+		}
+		result = append(result, LabelMatcher{Name: m.Name})
+	}
+	return result
+}
+
+func convertVectorAggregationType(op string) types.VectorAggregationType {
+	switch op {
+	case syntax.OpTypeSum:
+		return types.VectorAggregationTypeSum
+	case syntax.OpTypeAvg:
+		return types.VectorAggregationTypeAvg
+	case syntax.OpTypeMin:
+		return types.VectorAggregationTypeMin
+	case syntax.OpTypeMax:
+		return types.VectorAggregationTypeMax
+	case syntax.OpTypeCount:
+		return types.VectorAggregationTypeCount
+	case syntax.OpTypeStddev:
+		return types.VectorAggregationTypeStddev
+	case syntax.OpTypeStdvar:
+		return types.VectorAggregationTypeStdvar
+	case syntax.OpTypeTopK:
+		return types.VectorAggregationTypeTopK
+	case syntax.OpTypeBottomK:
+		return types.VectorAggregationTypeBottomK
+	case syntax.OpTypeSort:
+		return types.VectorAggregationTypeSort
+	case syntax.OpTypeSortDesc:
+		return types.VectorAggregationTypeSortDesc
+	default:
+		return types.VectorAggregationTypeInvalid
+	}
+}
+
+func convertRangeAggregationType(op string) types.RangeAggregationType {
+	switch op {
+	case syntax.OpRangeTypeCount:
+		return types.RangeAggregationTypeCount
+	case syntax.OpRangeTypeRate:
+		return types.RangeAggregationTypeRate
+	case syntax.OpRangeTypeBytes:
+		return types.RangeAggregationTypeBytes
+	case syntax.OpRangeTypeBytesRate:
+		return types.RangeAggregationTypeBytesRate
+	case syntax.OpRangeTypeSum:
+		return types.RangeAggregationTypeSum
+	case syntax.OpRangeTypeAvg:
+		return types.RangeAggregationTypeAvg
+	case syntax.OpRangeTypeMin:
+		return types.RangeAggregationTypeMin
+	case syntax.OpRangeTypeMax:
+		return types.RangeAggregationTypeMax
+	case syntax.OpRangeTypeFirst:
+		return types.RangeAggregationTypeFirst
+	case syntax.OpRangeTypeLast:
+		return types.RangeAggregationTypeLast
+	case syntax.OpRangeTypeStddev:
+		return types.RangeAggregationTypeStddev
+	case syntax.OpRangeTypeStdvar:
+		return types.RangeAggregationTypeStdvar
+	case syntax.OpRangeTypeQuantile:
+		return types.RangeAggregationTypeQuantile
+	default:
+		return types.RangeAggregationTypeInvalid
+	}
+}
+
+func convertConversionType(op string) types.ConversionType {
+	switch op {
+	case "":
+		return types.ConversionTypeFloat
+	case syntax.OpConvBytes:
+		return types.ConversionTypeBytes
+	case syntax.OpConvDuration:
+		return types.ConversionTypeDuration
+	case syntax.OpConvDurationSeconds:
+		return types.ConversionTypeDurationSeconds
+	default:
+		return types.ConversionTypeInvalid
+	}
+}
+
+// convertUnwrapExpr converts a [syntax.UnwrapExpr] into an [Unwrap] and the
+// predicates of its post filters.
+// The post filter __error__="" is not returned as predicate, but instead denotes
+// that entries which fail the conversion are dropped.
+func convertUnwrapExpr(expr *syntax.UnwrapExpr) (*Unwrap, []Value, error) {
+	conversion := convertConversionType(expr.Operation)
+	if conversion == types.ConversionTypeInvalid {
+		return nil, nil, fmt.Errorf("unwrap conversion %s is not supported: %w", expr.Operation, errUnimplemented)
+	}
+
+	unwrap := &Unwrap{
+		Column:     *NewColumnRef(expr.Identifier, types.ColumnTypeAmbiguous),
+		Conversion: conversion,
+	}
+
+	var predicates []Value
+	for _, filter := range expr.PostFilters {
+		if isDropErrorsFilter(filter) {
+			unwrap.DropErrors = true
+			continue
+		}
+		if referencesErrorLabels(filter) {
+			return nil, nil, fmt.Errorf("post filter %s is not supported: %w", filter, errUnimplemented)
+		}
+
+		value, err := convertLabelFilter(filter)
+		if err != nil {
+			return nil, nil, err
+		}
+		predicates = append(predicates, value)
+	}
+
+	return unwrap, predicates, nil
+}
+
+// isDropErrorsFilter returns true if the label filter is __error__="".
+func isDropErrorsFilter(filter log.LabelFilterer) bool {
+	var m *labels.Matcher
+	switch f := filter.(type) {
+	case *log.StringLabelFilter:
+		m = f.Matcher
+	case *log.LineFilterLabelFilter:
+		m = f.Matcher
+	default:
+		return false
+	}
+	return m.Name == logqlmodel.ErrorLabel && m.Type == labels.MatchEqual && m.Value == ""
+}
+
+// referencesErrorLabels returns true if the label filter references the
+// __error__ or __error_details__ labels.
+func referencesErrorLabels(filter log.LabelFilterer) bool {
+	switch f := filter.(type) {
+	case *log.BinaryLabelFilter:
+		return referencesErrorLabels(f.Left) || referencesErrorLabels(f.Right)
+	case *log.StringLabelFilter:
+		return f.Name == logqlmodel.ErrorLabel || f.Name == logqlmodel.ErrorDetailsLabel
+	case *log.LineFilterLabelFilter:
+		return f.Name == logqlmodel.ErrorLabel || f.Name == logqlmodel.ErrorDetailsLabel
+	default:
+		return false
+	}
+}
+
 func convertLabelMatchers(matchers []*labels.Matcher) Value {
 	var value *BinOp
 
@@ -366,6 +853,25 @@ func convertQueryRangeToPredicates(start, end time.Time) []*BinOp {
 	}
 }
 
+// convertMetricQueryRangeToPredicates returns the timestamp predicates for
+// metric queries. Unlike log queries, range aggregations evaluate windows that
+// are open at the start and closed at the end, (start, end], which is the
+// same behaviour as the range vector iterators of the old engine.
+func convertMetricQueryRangeToPredicates(start, end time.Time) []*BinOp {
+	return []*BinOp{
+		{
+			Left:  timestampColumnRef(),
+			Right: NewLiteral(datatype.Timestamp(start.UTC().UnixNano())),
+			Op:    types.BinaryOpGt,
+		},
+		{
+			Left:  timestampColumnRef(),
+			Right: NewLiteral(datatype.Timestamp(end.UTC().UnixNano())),
+			Op:    types.BinaryOpLte,
+		},
+	}
+}
+
 func parseShards(shards []string) (*ShardInfo, error) {
 	if len(shards) == 0 {
 		return noShard, nil
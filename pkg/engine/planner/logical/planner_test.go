@@ -83,7 +83,7 @@ func TestConvertAST_Success(t *testing.T) {
 		statement: `{cluster="prod", namespace=~"loki-.*"} | foo="bar" or bar="baz" |= "metric.go" |= "foo" or "bar" !~ "(a|b|c)" `,
 		start:     3600,
 		end:       7200,
-		direction: logproto.BACKWARD, // ASC is not supported
+		direction: logproto.BACKWARD,
 		limit:     1000,
 	}
 	logicalPlan, err := BuildPlan(q)
@@ -121,6 +121,121 @@ RETURN %20
 	t.Logf("\n%s\n", sb.String())
 }
 
+func TestConvertAST_ForwardQuery_Success(t *testing.T) {
+	q := &query{
+		statement: `{cluster="prod"} |= "metric.go"`,
+		start:     3600,
+		end:       7200,
+		direction: logproto.FORWARD,
+		limit:     1000,
+	}
+	logicalPlan, err := BuildPlan(q)
+	require.NoError(t, err)
+	t.Logf("\n%s\n", logicalPlan.String())
+
+	expected := `%1 = EQ label.cluster "prod"
+%2 = MAKETABLE [selector=%1, predicates=[%8], shard=0_of_1]
+%3 = SORT %2 [column=builtin.timestamp, asc=true, nulls_first=false]
+%4 = GTE builtin.timestamp 1970-01-01T01:00:00Z
+%5 = SELECT %3 [predicate=%4]
+%6 = LT builtin.timestamp 1970-01-01T02:00:00Z
+%7 = SELECT %5 [predicate=%6]
+%8 = MATCH_STR builtin.message "metric.go"
+%9 = SELECT %7 [predicate=%8]
+%10 = LIMIT %9 [skip=0, fetch=1000]
+RETURN %10
+`
+
+	require.Equal(t, expected, logicalPlan.String())
+}
+
+func TestConvertAST_ParserStages_Success(t *testing.T) {
+	q := &query{
+		statement: `{cluster="prod"} |= "metric.go" | json | level="error" | regexp "status=(?P<status>\\d+)" |= "timeout" | unpack != "debug" | status=~"5.."`,
+		start:     3600,
+		end:       7200,
+		direction: logproto.BACKWARD,
+		limit:     1000,
+	}
+	logicalPlan, err := BuildPlan(q)
+	require.NoError(t, err)
+	t.Logf("\n%s\n", logicalPlan.String())
+
+	// Line filters are applied before the first parser, except for the ones
+	// that follow the unpack parser, which may rewrite the log line.
+	expected := `%1 = EQ label.cluster "prod"
+%2 = MAKETABLE [selector=%1, predicates=[%8, %10], shard=0_of_1]
+%3 = SORT %2 [column=builtin.timestamp, asc=false, nulls_first=false]
+%4 = GTE builtin.timestamp 1970-01-01T01:00:00Z
+%5 = SELECT %3 [predicate=%4]
+%6 = LT builtin.timestamp 1970-01-01T02:00:00Z
+%7 = SELECT %5 [predicate=%6]
+%8 = MATCH_STR builtin.message "metric.go"
+%9 = SELECT %7 [predicate=%8]
+%10 = MATCH_STR builtin.message "timeout"
+%11 = SELECT %9 [predicate=%10]
+%12 = PARSE %11 [parser=json]
+%13 = EQ ambiguous.level "error"
+%14 = SELECT %12 [predicate=%13]
+%15 = PARSE %14 [parser=regexp, pattern="status=(?P<status>\\d+)"]
+%16 = PARSE %15 [parser=unpack]
+%17 = NOT_MATCH_STR builtin.message "debug"
+%18 = SELECT %16 [predicate=%17]
+%19 = MATCH_RE ambiguous.status "5.."
+%20 = SELECT %18 [predicate=%19]
+%21 = LIMIT %20 [skip=0, fetch=1000]
+RETURN %21
+`
+
+	require.Equal(t, expected, logicalPlan.String())
+
+	var sb strings.Builder
+	PrintTree(&sb, logicalPlan.Value())
+
+	t.Logf("\n%s\n", sb.String())
+}
+
+func TestConvertAST_FormatStages_Success(t *testing.T) {
+	q := &query{
+		statement: `{cluster="prod"} |= "metric.go" | logfmt | label_format dst=src, msg="{{.level}}" | line_format "{{.msg}}" |= "error" | drop src | keep msg, level=~"warn|error"`,
+		start:     3600,
+		end:       7200,
+		direction: logproto.BACKWARD,
+		limit:     1000,
+	}
+	logicalPlan, err := BuildPlan(q)
+	require.NoError(t, err)
+	t.Logf("\n%s\n", logicalPlan.String())
+
+	// Line filters that follow line_format are applied to the formatted line.
+	expected := `%1 = EQ label.cluster "prod"
+%2 = MAKETABLE [selector=%1, predicates=[%8], shard=0_of_1]
+%3 = SORT %2 [column=builtin.timestamp, asc=false, nulls_first=false]
+%4 = GTE builtin.timestamp 1970-01-01T01:00:00Z
+%5 = SELECT %3 [predicate=%4]
+%6 = LT builtin.timestamp 1970-01-01T02:00:00Z
+%7 = SELECT %5 [predicate=%6]
+%8 = MATCH_STR builtin.message "metric.go"
+%9 = SELECT %7 [predicate=%8]
+%10 = PARSE %9 [parser=logfmt]
+%11 = LABEL_FORMAT %10 [formats=(dst=src, msg="{{.level}}")]
+%12 = LINE_FORMAT %11 [template="{{.msg}}"]
+%13 = MATCH_STR builtin.message "error"
+%14 = SELECT %12 [predicate=%13]
+%15 = PROJECT_LABELS %14 [drop=(src)]
+%16 = PROJECT_LABELS %15 [keep=(msg, level=~"warn|error")]
+%17 = LIMIT %16 [skip=0, fetch=1000]
+RETURN %17
+`
+
+	require.Equal(t, expected, logicalPlan.String())
+
+	var sb strings.Builder
+	PrintTree(&sb, logicalPlan.Value())
+
+	t.Logf("\n%s\n", sb.String())
+}
+
 func TestConvertAST_MetricQuery_Success(t *testing.T) {
 	q := &query{
 		statement: `sum by (level) (count_over_time({cluster="prod", namespace=~"loki-.*"} |= "metric.go"[5m]))`,
@@ -138,9 +253,9 @@ func TestConvertAST_MetricQuery_Success(t *testing.T) {
 %3 = AND %1 %2
 %4 = MAKETABLE [selector=%3, predicates=[%10], shard=0_of_1]
 %5 = SORT %4 [column=builtin.timestamp, asc=false, nulls_first=false]
-%6 = GTE builtin.timestamp 1970-01-01T00:55:00Z
+%6 = GT builtin.timestamp 1970-01-01T00:55:00Z
 %7 = SELECT %5 [predicate=%6]
-%8 = LT builtin.timestamp 1970-01-01T02:00:00Z
+%8 = LTE builtin.timestamp 1970-01-01T02:00:00Z
 %9 = SELECT %7 [predicate=%8]
 %10 = MATCH_STR builtin.message "metric.go"
 %11 = SELECT %9 [predicate=%10]
@@ -157,6 +272,185 @@ RETURN %13
 	t.Logf("\n%s\n", sb.String())
 }
 
+func TestConvertAST_MetricRangeQuery_Success(t *testing.T) {
+	q := &query{
+		statement: `sum by (level) (count_over_time({cluster="prod"}[5m]))`,
+		start:     3600,
+		end:       7200,
+		step:      time.Minute,
+	}
+
+	logicalPlan, err := BuildPlan(q)
+	require.NoError(t, err)
+	t.Logf("\n%s\n", logicalPlan.String())
+
+	expected := `%1 = EQ label.cluster "prod"
+%2 = MAKETABLE [selector=%1, predicates=[], shard=0_of_1]
+%3 = SORT %2 [column=builtin.timestamp, asc=false, nulls_first=false]
+%4 = GT builtin.timestamp 1970-01-01T00:55:00Z
+%5 = SELECT %3 [predicate=%4]
+%6 = LTE builtin.timestamp 1970-01-01T02:00:00Z
+%7 = SELECT %5 [predicate=%6]
+%8 = RANGE_AGGREGATION %7 [operation=count, start_ts=1970-01-01T01:00:00Z, end_ts=1970-01-01T02:00:00Z, step=1m0s, range=5m0s]
+%9 = VECTOR_AGGREGATION %8 [operation=sum, group_by=(ambiguous.level)]
+RETURN %9
+`
+
+	require.Equal(t, expected, logicalPlan.String())
+}
+
+func TestConvertAST_UnwrapMetricQuery_Success(t *testing.T) {
+	q := &query{
+		statement: `quantile_over_time(0.99, {cluster="prod"} | unwrap duration(latency) | __error__="" | level="error" [5m]) by (namespace)`,
+		start:     3600,
+		end:       7200,
+	}
+
+	logicalPlan, err := BuildPlan(q)
+	require.NoError(t, err)
+	t.Logf("\n%s\n", logicalPlan.String())
+
+	expected := `%1 = EQ label.cluster "prod"
+%2 = MAKETABLE [selector=%1, predicates=[], shard=0_of_1]
+%3 = SORT %2 [column=builtin.timestamp, asc=false, nulls_first=false]
+%4 = GT builtin.timestamp 1970-01-01T00:55:00Z
+%5 = SELECT %3 [predicate=%4]
+%6 = LTE builtin.timestamp 1970-01-01T02:00:00Z
+%7 = SELECT %5 [predicate=%6]
+%8 = EQ ambiguous.level "error"
+%9 = SELECT %7 [predicate=%8]
+%10 = RANGE_AGGREGATION %9 [partition_by=(ambiguous.namespace), operation=quantile, start_ts=1970-01-01T01:00:00Z, end_ts=1970-01-01T02:00:00Z, step=0s, range=5m0s, parameter=0.99, unwrap=duration(ambiguous.latency)]
+RETURN %10
+`
+
+	require.Equal(t, expected, logicalPlan.String())
+}
+
+func TestConvertAST_NestedVectorAggregation_Success(t *testing.T) {
+	q := &query{
+		statement: `topk(5, sum without (app) (count_over_time({cluster="prod"}[5m])))`,
+		start:     3600,
+		end:       7200,
+	}
+
+	logicalPlan, err := BuildPlan(q)
+	require.NoError(t, err)
+	t.Logf("\n%s\n", logicalPlan.String())
+
+	expected := `%1 = EQ label.cluster "prod"
+%2 = MAKETABLE [selector=%1, predicates=[], shard=0_of_1]
+%3 = SORT %2 [column=builtin.timestamp, asc=false, nulls_first=false]
+%4 = GT builtin.timestamp 1970-01-01T00:55:00Z
+%5 = SELECT %3 [predicate=%4]
+%6 = LTE builtin.timestamp 1970-01-01T02:00:00Z
+%7 = SELECT %5 [predicate=%6]
+%8 = RANGE_AGGREGATION %7 [operation=count, start_ts=1970-01-01T01:00:00Z, end_ts=1970-01-01T02:00:00Z, step=0s, range=5m0s]
+%9 = VECTOR_AGGREGATION %8 [operation=sum, without=(ambiguous.app)]
+%10 = VECTOR_AGGREGATION %9 [operation=topk, parameter=5]
+RETURN %10
+`
+
+	require.Equal(t, expected, logicalPlan.String())
+}
+
+func TestConvertAST_BinOp_Success(t *testing.T) {
+	q := &query{
+		statement: `sum by (app) (count_over_time({cluster="prod", level="error"}[5m])) / on (app) group_left sum by (app) (count_over_time({cluster="prod"}[5m])) > 0.5`,
+		start:     3600,
+		end:       7200,
+	}
+
+	logicalPlan, err := BuildPlan(q)
+	require.NoError(t, err)
+	t.Logf("\n%s\n", logicalPlan.String())
+
+	expected := `%1 = EQ label.cluster "prod"
+%2 = EQ label.level "error"
+%3 = AND %1 %2
+%4 = MAKETABLE [selector=%3, predicates=[], shard=0_of_1]
+%5 = SORT %4 [column=builtin.timestamp, asc=false, nulls_first=false]
+%6 = GT builtin.timestamp 1970-01-01T00:55:00Z
+%7 = SELECT %5 [predicate=%6]
+%8 = LTE builtin.timestamp 1970-01-01T02:00:00Z
+%9 = SELECT %7 [predicate=%8]
+%10 = RANGE_AGGREGATION %9 [operation=count, start_ts=1970-01-01T01:00:00Z, end_ts=1970-01-01T02:00:00Z, step=0s, range=5m0s]
+%11 = VECTOR_AGGREGATION %10 [operation=sum, group_by=(ambiguous.app)]
+%12 = EQ label.cluster "prod"
+%13 = MAKETABLE [selector=%12, predicates=[], shard=0_of_1]
+%14 = SORT %13 [column=builtin.timestamp, asc=false, nulls_first=false]
+%15 = GT builtin.timestamp 1970-01-01T00:55:00Z
+%16 = SELECT %14 [predicate=%15]
+%17 = LTE builtin.timestamp 1970-01-01T02:00:00Z
+%18 = SELECT %16 [predicate=%17]
+%19 = RANGE_AGGREGATION %18 [operation=count, start_ts=1970-01-01T01:00:00Z, end_ts=1970-01-01T02:00:00Z, step=0s, range=5m0s]
+%20 = VECTOR_AGGREGATION %19 [operation=sum, group_by=(ambiguous.app)]
+%21 = JOIN %11 %20 [operation=DIV, on=(ambiguous.app), group_left=()]
+%22 = JOIN %21 0.5 [operation=GT]
+RETURN %22
+`
+
+	require.Equal(t, expected, logicalPlan.String())
+}
+
+func TestConvertAST_Offset_Success(t *testing.T) {
+	q := &query{
+		statement: `count_over_time({cluster="prod"}[5m] offset 1h)`,
+		start:     7200,
+		end:       10800,
+		step:      time.Minute,
+	}
+
+	logicalPlan, err := BuildPlan(q)
+	require.NoError(t, err)
+	t.Logf("\n%s\n", logicalPlan.String())
+
+	// The scanned time range is shifted back by the offset.
+	expected := `%1 = EQ label.cluster "prod"
+%2 = MAKETABLE [selector=%1, predicates=[], shard=0_of_1]
+%3 = SORT %2 [column=builtin.timestamp, asc=false, nulls_first=false]
+%4 = GT builtin.timestamp 1970-01-01T00:55:00Z
+%5 = SELECT %3 [predicate=%4]
+%6 = LTE builtin.timestamp 1970-01-01T02:00:00Z
+%7 = SELECT %5 [predicate=%6]
+%8 = RANGE_AGGREGATION %7 [operation=count, start_ts=1970-01-01T02:00:00Z, end_ts=1970-01-01T03:00:00Z, step=1m0s, range=5m0s, offset=1h0m0s]
+RETURN %8
+`
+
+	require.Equal(t, expected, logicalPlan.String())
+}
+
+func TestConvertAST_Functions_Success(t *testing.T) {
+	q := &query{
+		statement: `label_replace(absent_over_time({cluster="prod", app=~"api.*"}[5m]), "team", "$1", "cluster", "(.*)") or vector(0)`,
+		start:     3600,
+		end:       7200,
+		step:      time.Minute,
+	}
+
+	logicalPlan, err := BuildPlan(q)
+	require.NoError(t, err)
+	t.Logf("\n%s\n", logicalPlan.String())
+
+	expected := `%1 = EQ label.cluster "prod"
+%2 = MATCH_RE label.app "api.*"
+%3 = AND %1 %2
+%4 = MAKETABLE [selector=%3, predicates=[], shard=0_of_1]
+%5 = SORT %4 [column=builtin.timestamp, asc=false, nulls_first=false]
+%6 = GT builtin.timestamp 1970-01-01T00:55:00Z
+%7 = SELECT %5 [predicate=%6]
+%8 = LTE builtin.timestamp 1970-01-01T02:00:00Z
+%9 = SELECT %7 [predicate=%8]
+%10 = RANGE_AGGREGATION %9 [operation=count, start_ts=1970-01-01T01:00:00Z, end_ts=1970-01-01T02:00:00Z, step=1m0s, range=5m0s]
+%11 = ABSENT %10 [labels={cluster="prod"}, start_ts=1970-01-01T01:00:00Z, end_ts=1970-01-01T02:00:00Z, step=1m0s]
+%12 = LABEL_REPLACE %11 [dst=team, src=cluster, regex="(.*)", replacement="$1"]
+%13 = VECTOR [value=0, start_ts=1970-01-01T01:00:00Z, end_ts=1970-01-01T02:00:00Z, step=1m0s]
+%14 = JOIN %12 %13 [operation=OR, ignoring=()]
+RETURN %14
+`
+
+	require.Equal(t, expected, logicalPlan.String())
+}
+
 func TestCanExecuteQuery(t *testing.T) {
 	for _, tt := range []struct {
 		statement string
@@ -184,33 +478,59 @@ func TestCanExecuteQuery(t *testing.T) {
 		},
 		{
 			statement: `{env="prod"} | json`,
+			expected:  true,
 		},
 		{
 			statement: `{env="prod"} | json foo="bar"`,
+			expected:  true,
 		},
 		{
 			statement: `{env="prod"} | logfmt`,
+			expected:  true,
 		},
 		{
 			statement: `{env="prod"} | logfmt foo="bar"`,
+			expected:  true,
 		},
 		{
 			statement: `{env="prod"} | pattern "<_> foo=<foo> <_>"`,
+			expected:  true,
 		},
 		{
 			statement: `{env="prod"} | regexp ".* foo=(?P<foo>.+) .*"`,
+			expected:  true,
 		},
 		{
 			statement: `{env="prod"} | unpack`,
+			expected:  true,
 		},
 		{
 			statement: `{env="prod"} |= "metrics.go" | logfmt`,
+			expected:  true,
+		},
+		{
+			statement: `{env="prod"} | logfmt --strict --keep-empty | level="error" |= "timeout"`,
+			expected:  true,
 		},
 		{
 			statement: `{env="prod"} | line_format "{.cluster}"`,
+			expected:  true,
 		},
 		{
 			statement: `{env="prod"} | label_format cluster="us"`,
+			expected:  true,
+		},
+		{
+			statement: `{env="prod"} | logfmt | keep level, status=~"5.."`,
+			expected:  true,
+		},
+		{
+			statement: `{env="prod"} | logfmt | drop __error__, __error_details__`,
+			expected:  true,
+		},
+		{
+			statement: `sum by (dst) (count_over_time({env="prod"} | json | label_format dst=src [1m]))`,
+			expected:  true,
 		},
 		{
 			statement: `{env="prod"} |= "metric.go" | retry > 2`,
@@ -220,23 +540,112 @@ func TestCanExecuteQuery(t *testing.T) {
 			expected:  true,
 		},
 		{
-			// both vector and range aggregation are required
 			statement: `count_over_time({env="prod"}[1m])`,
+			expected:  true,
 		},
 		{
-			// group by labels are required
 			statement: `sum(count_over_time({env="prod"}[1m]))`,
+			expected:  true,
+		},
+		{
+			statement: `sum without (level) (count_over_time({env="prod"}[1m]))`,
+			expected:  true,
+		},
+		{
+			statement: `topk(5, sum by (level) (count_over_time({env="prod"}[1m])))`,
+			expected:  true,
+		},
+		{
+			statement: `sort_desc(avg(rate({env="prod"}[1m])))`,
+			expected:  true,
 		},
 		{
-			// rate is not supported
 			statement: `sum by (level) (rate({env="prod"}[1m]))`,
+			expected:  true,
+		},
+		{
+			statement: `sum by (level) (bytes_rate({env="prod"}[1m]))`,
+			expected:  true,
+		},
+		{
+			statement: `sum by (level) (bytes_over_time({env="prod"}[1m]))`,
+			expected:  true,
+		},
+		{
+			statement: `sum by (level) (sum_over_time({env="prod"} | unwrap bytes(size) [1m]))`,
+			expected:  true,
+		},
+		{
+			statement: `quantile_over_time(0.99, {env="prod"} | unwrap duration(latency) | __error__="" [1m]) by (level)`,
+			expected:  true,
+		},
+		{
+			statement: `avg_over_time({env="prod"} | unwrap latency | level="error" [1m])`,
+			expected:  true,
+		},
+		{
+			// only __error__="" is supported as error filter
+			statement: `avg_over_time({env="prod"} | unwrap latency | __error__!="" [1m])`,
+		},
+		{
+			// without grouping is not supported for range aggregations
+			statement: `max_over_time({env="prod"} | unwrap latency [1m]) without (level)`,
+		},
+		{
+			// rate_counter is not supported
+			statement: `rate_counter({env="prod"} | unwrap latency [1m])`,
+		},
+		{
+			statement: `sum by (level) (count_over_time({env="prod"} | json | level="error" [1m]))`,
+			expected:  true,
+		},
+		{
+			statement: `sum(sum_over_time({env="prod"} | logfmt | unwrap bytes(size) | __error__="" [1m]))`,
+			expected:  true,
 		},
 		{
-			// max is not supported
 			statement: `max by (level) (count_over_time({env="prod"}[1m]))`,
+			expected:  true,
 		},
 		{
 			statement: `sum by (level) (count_over_time({env="prod"}[1m] offset 5m))`,
+			expected:  true,
+		},
+		{
+			statement: `label_replace(rate({env="prod"}[1m]), "dst", "$1", "src", "(.*)")`,
+			expected:  true,
+		},
+		{
+			statement: `vector(1)`,
+			expected:  true,
+		},
+		{
+			statement: `sum(rate({env="prod"}[1m])) or vector(0)`,
+			expected:  true,
+		},
+		{
+			statement: `absent_over_time({env="prod"} |= "error" [5m])`,
+			expected:  true,
+		},
+		{
+			statement: `sum(rate({env="prod"} |= "error" [1m])) / sum(rate({env="prod"}[1m]))`,
+			expected:  true,
+		},
+		{
+			statement: `count_over_time({env="prod"}[1m]) > bool 2`,
+			expected:  true,
+		},
+		{
+			statement: `2 * rate({env="prod"}[1m])`,
+			expected:  true,
+		},
+		{
+			statement: `sum by (level, app) (rate({env="prod"}[1m])) / on (level) group_left sum by (level) (rate({env="prod"}[1m]))`,
+			expected:  true,
+		},
+		{
+			statement: `count_over_time({env="prod"} |= "error" [1m]) unless count_over_time({env="prod"} |= "timeout" [1m])`,
+			expected:  true,
 		},
 	} {
 		t.Run(tt.statement, func(t *testing.T) {
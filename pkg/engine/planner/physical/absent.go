@@ -0,0 +1,42 @@
+package physical
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/prometheus/prometheus/model/labels"
+)
+
+// Absent represents an absent_over_time function in the physical plan. It
+// produces a single series with the value 1 for each step of the query for
+// which its child does not produce any rows.
+type Absent struct {
+	id string
+
+	Labels labels.Labels // Labels of the produced series.
+
+	Start time.Time
+	End   time.Time
+	Step  time.Duration // optional for instant queries
+}
+
+// ID implements the [Node] interface.
+// Returns a string that uniquely identifies the node in the plan.
+func (a *Absent) ID() string {
+	if a.id == "" {
+		return fmt.Sprintf("%p", a)
+	}
+	return a.id
+}
+
+// Type implements the [Node] interface.
+// Returns the type of the node.
+func (*Absent) Type() NodeType {
+	return NodeTypeAbsent
+}
+
+// Accept implements the [Node] interface.
+// Dispatches itself to the provided [Visitor] v
+func (a *Absent) Accept(v Visitor) error {
+	return v.VisitAbsent(a)
+}
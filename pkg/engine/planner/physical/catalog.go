@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"github.com/prometheus/prometheus/model/labels"
+	"github.com/thanos-io/objstore"
 
 	"github.com/grafana/loki/v3/pkg/dataobj/metastore"
 	"github.com/grafana/loki/v3/pkg/engine/internal/datatype"
@@ -52,6 +53,7 @@ type MetastoreCatalog struct {
 	ctx           context.Context
 	metastore     metastore.Metastore
 	catalogueType CatalogueType
+	bucket        objstore.Bucket // bucket from which statistics are read, see [MetastoreCatalog.WithStatistics]
 }
 
 type CatalogueType int
@@ -1,14 +1,23 @@
 package physical
 
 import (
+	"bytes"
+	"context"
+	"fmt"
+	"math"
 	"testing"
 	"time"
 
 	"github.com/prometheus/prometheus/model/labels"
 	"github.com/stretchr/testify/require"
+	"github.com/thanos-io/objstore"
 
+	"github.com/grafana/loki/v3/pkg/dataobj/consumer/logsobj"
 	"github.com/grafana/loki/v3/pkg/engine/internal/datatype"
 	"github.com/grafana/loki/v3/pkg/engine/internal/types"
+	"github.com/grafana/loki/v3/pkg/logproto"
+
+	"github.com/grafana/loki/pkg/push"
 )
 
 func TestCatalog_ConvertLiteral(t *testing.T) {
@@ -166,3 +175,63 @@ func TestCatalog_ExpressionToMatchers(t *testing.T) {
 		})
 	}
 }
+
+func TestMetastoreCatalog_SectionStatistics(t *testing.T) {
+	builder, err := logsobj.NewBuilder(logsobj.BuilderConfig{
+		TargetPageSize:          8_000,
+		TargetObjectSize:        math.MaxInt,
+		TargetSectionSize:       math.MaxInt,
+		BufferSize:              math.MaxInt,
+		SectionStripeMergeLimit: 2,
+	})
+	require.NoError(t, err)
+
+	start := time.Unix(1000, 0).UTC()
+	stream := logproto.Stream{Labels: `{app="a"}`}
+	for i := range 100 {
+		stream.Entries = append(stream.Entries, logproto.Entry{
+			Timestamp:          start.Add(time.Duration(i) * time.Second),
+			Line:               fmt.Sprintf("line %d", i),
+			StructuredMetadata: push.LabelsAdapter{{Name: "env", Value: fmt.Sprintf("env%d", i%4)}},
+		})
+	}
+	require.NoError(t, builder.Append(stream))
+
+	var buf bytes.Buffer
+	_, err = builder.Flush(&buf)
+	require.NoError(t, err)
+
+	bucket := objstore.NewInMemBucket()
+	require.NoError(t, bucket.Upload(context.Background(), "obj", &buf))
+
+	t.Run("without bucket", func(t *testing.T) {
+		catalog := NewMetastoreCatalog(context.Background(), nil, CatalogueTypeDirect)
+		stats, err := catalog.SectionStatistics([]SectionRef{{Location: "obj"}})
+		require.NoError(t, err)
+		require.Equal(t, []*SectionStatistics{nil}, stats)
+	})
+
+	t.Run("with bucket", func(t *testing.T) {
+		catalog := NewMetastoreCatalog(context.Background(), nil, CatalogueTypeDirect).WithStatistics(bucket)
+		stats, err := catalog.SectionStatistics([]SectionRef{{Location: "obj", Section: 0}, {Location: "obj", Section: 1}})
+		require.NoError(t, err)
+		require.Len(t, stats, 2)
+		require.Nil(t, stats[1], "object has a single logs section")
+
+		require.Equal(t, int64(100), stats[0].Rows)
+		require.NotZero(t, stats[0].UncompressedSize)
+		require.Equal(t, start, stats[0].Start)
+		require.Equal(t, start.Add(99*time.Second), stats[0].End)
+
+		env := stats[0].Columns[types.ColumnRef{Column: "env", Type: types.ColumnTypeMetadata}]
+		require.Equal(t, int64(100), env.Values)
+		require.Equal(t, int64(4), env.Cardinality)
+		require.Contains(t, stats[0].Columns, types.ColumnRef{Column: types.ColumnNameBuiltinMessage, Type: types.ColumnTypeBuiltin})
+	})
+
+	t.Run("missing object", func(t *testing.T) {
+		catalog := NewMetastoreCatalog(context.Background(), nil, CatalogueTypeDirect).WithStatistics(bucket)
+		_, err := catalog.SectionStatistics([]SectionRef{{Location: "missing"}})
+		require.Error(t, err)
+	})
+}
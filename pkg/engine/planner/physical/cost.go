@@ -0,0 +1,358 @@
+package physical
+
+import (
+	"math"
+	"time"
+
+	"github.com/dustin/go-humanize"
+
+	"github.com/grafana/loki/v3/pkg/engine/internal/datatype"
+	"github.com/grafana/loki/v3/pkg/engine/internal/types"
+	"github.com/grafana/loki/v3/pkg/engine/planner/internal/tree"
+)
+
+const (
+	// defaultSelectivity is the assumed fraction of rows that match a
+	// predicate whose selectivity cannot be estimated from statistics, such
+	// as a substring, regular expression or pattern match of the log line.
+	defaultSelectivity = 0.1
+
+	// defaultRangeSelectivity is the assumed fraction of rows that match a
+	// comparison of a column other than the timestamp with a literal.
+	defaultRangeSelectivity = 1.0 / 3
+)
+
+// Cost is the estimated cost of a node of a plan. Costs are estimated from
+// the statistics of the sections that are scanned by the descendants of the
+// node, see [StatisticsCatalog].
+type Cost struct {
+	Rows  float64 // Estimated number of rows returned by the node.
+	Bytes float64 // Estimated number of uncompressed bytes read by the scans below the node.
+}
+
+func (c Cost) properties() []tree.Property {
+	return []tree.Property{
+		tree.NewProperty("rows", false, int64(math.Round(c.Rows))),
+		tree.NewProperty("bytes", false, humanize.IBytes(uint64(c.Bytes))),
+	}
+}
+
+// statistics holds the statistics of the sections that are scanned by a
+// plan. Sections without statistics are not part of the map.
+type statistics map[SectionRef]*SectionStatistics
+
+// of returns the statistics of the section that is scanned by scan, or nil
+// if they are not available.
+func (s statistics) of(scan *DataObjScan) *SectionStatistics {
+	return s[SectionRef{Location: scan.Location, Section: scan.Section}]
+}
+
+// readStatistics reads the statistics of all sections that are scanned by
+// the plan from the catalog.
+func readStatistics(catalog StatisticsCatalog, plan *Plan) (statistics, error) {
+	var refs []SectionRef
+	seen := make(map[SectionRef]struct{})
+	for _, node := range plan.nodes.sorted() {
+		scan, ok := node.(*DataObjScan)
+		if !ok {
+			continue
+		}
+		ref := SectionRef{Location: scan.Location, Section: scan.Section}
+		if _, ok := seen[ref]; ok {
+			continue
+		}
+		seen[ref] = struct{}{}
+		refs = append(refs, ref)
+	}
+	if len(refs) == 0 {
+		return nil, nil
+	}
+
+	sections, err := catalog.SectionStatistics(refs)
+	if err != nil {
+		return nil, err
+	}
+
+	stats := make(statistics, len(refs))
+	for i, ref := range refs {
+		if sections[i] != nil {
+			stats[ref] = sections[i]
+		}
+	}
+	return stats, nil
+}
+
+// estimateCosts estimates the costs of the node and its descendants and
+// stores them in the plan. Nodes that have a scan without statistics among
+// their descendants have no cost.
+func estimateCosts(plan *Plan, node Node, stats statistics) {
+	e := &costEstimator{plan: plan, stats: stats, visited: make(map[Node]bool)}
+	e.estimate(node)
+}
+
+type costEstimator struct {
+	plan    *Plan
+	stats   statistics
+	visited map[Node]bool // whether the cost of a visited node is known
+}
+
+func (e *costEstimator) estimate(node Node) (Cost, bool) {
+	if known, ok := e.visited[node]; ok {
+		cost, _ := e.plan.Cost(node)
+		return cost, known
+	}
+
+	var input Cost
+	known := true
+	for _, child := range e.plan.Children(node) {
+		cost, ok := e.estimate(child)
+		known = known && ok
+		input.Rows += cost.Rows
+		input.Bytes += cost.Bytes
+	}
+
+	cost := input
+	switch node := node.(type) {
+	case *DataObjScan:
+		stats := e.stats.of(node)
+		if stats == nil {
+			known = false
+			break
+		}
+		matching := float64(stats.Rows)
+		for _, predicate := range node.Predicates {
+			matching *= selectivity(predicate, stats)
+		}
+		cost = Cost{Rows: matching, Bytes: scanBytes(node, stats)}
+		// A scan with a limit stops reading once it has found enough rows.
+		if limit := float64(node.Limit); limit > 0 && matching > limit {
+			cost = Cost{Rows: limit, Bytes: cost.Bytes * limit / matching}
+		}
+
+	case *Filter:
+		for _, predicate := range node.Predicates {
+			cost.Rows *= selectivity(predicate, nil)
+		}
+
+	case *Limit:
+		cost.Rows = max(0, cost.Rows-float64(node.Skip))
+		if node.Fetch > 0 {
+			cost.Rows = min(cost.Rows, float64(node.Fetch))
+		}
+
+	case *RangeAggregation:
+		// Each series of the result has at most one sample per step. The
+		// number of series is bounded by the number of scanned streams, as
+		// long as the aggregation does not partition by parsed labels.
+		series := min(input.Rows, e.countStreams(node))
+		cost.Rows = series * float64(rangeAggregationSteps(node))
+
+	case *VectorAggregation:
+		if !node.Without && len(node.GroupBy) == 0 {
+			// Aggregations without grouping return a single series.
+			for _, child := range e.plan.Children(node) {
+				if rangeAgg, ok := child.(*RangeAggregation); ok {
+					cost.Rows = min(cost.Rows, float64(rangeAggregationSteps(rangeAgg)))
+				}
+			}
+		}
+	}
+
+	e.visited[node] = known
+	if known {
+		e.plan.setCost(node, cost)
+	}
+	return cost, known
+}
+
+// countStreams returns the number of distinct streams that are scanned by
+// the descendants of node.
+func (e *costEstimator) countStreams(node Node) float64 {
+	type stream struct {
+		location DataObjLocation
+		id       int64
+	}
+	streams := make(map[stream]struct{})
+
+	var visit func(Node)
+	visit = func(n Node) {
+		if scan, ok := n.(*DataObjScan); ok {
+			for _, id := range scan.StreamIDs {
+				streams[stream{location: scan.Location, id: id}] = struct{}{}
+			}
+		}
+		for _, child := range e.plan.Children(n) {
+			visit(child)
+		}
+	}
+	visit(node)
+	return float64(len(streams))
+}
+
+// rangeAggregationSteps returns the number of steps of the range aggregation,
+// which is 1 for instant queries.
+func rangeAggregationSteps(node *RangeAggregation) int64 {
+	if node.Step <= 0 {
+		return 1
+	}
+	return int64(node.End.Sub(node.Start)/node.Step) + 1
+}
+
+// scanBytes returns the estimated number of uncompressed bytes read by scan.
+// These are the bytes of the columns that are projected or referenced by
+// the predicates of the scan, or of all columns without projections.
+func scanBytes(scan *DataObjScan, stats *SectionStatistics) float64 {
+	if len(scan.Projections) == 0 {
+		return float64(stats.UncompressedSize)
+	}
+
+	refs := make(map[types.ColumnRef]struct{})
+	for _, projection := range scan.Projections {
+		if col, ok := projection.(*ColumnExpr); ok {
+			refs[col.Ref] = struct{}{}
+		}
+	}
+	for _, predicate := range scan.Predicates {
+		walkColumns(predicate, func(col *ColumnExpr) { refs[col.Ref] = struct{}{} })
+	}
+
+	var size int64
+	read := make(map[types.ColumnRef]struct{}, len(refs))
+	for ref := range refs {
+		col, ref, ok := lookupColumn(stats, ref)
+		if _, seen := read[ref]; !ok || seen {
+			continue
+		}
+		read[ref] = struct{}{}
+		size += col.UncompressedSize
+	}
+	return float64(size)
+}
+
+// lookupColumn returns the statistics of the column that is referenced by
+// ref and the reference under which they are stored. Ambiguous references
+// are resolved to metadata columns.
+func lookupColumn(stats *SectionStatistics, ref types.ColumnRef) (ColumnStatistics, types.ColumnRef, bool) {
+	if ref.Type == types.ColumnTypeAmbiguous {
+		ref.Type = types.ColumnTypeMetadata
+	}
+	col, ok := stats.Columns[ref]
+	return col, ref, ok
+}
+
+func walkColumns(expr Expression, fn func(*ColumnExpr)) {
+	switch expr := expr.(type) {
+	case *ColumnExpr:
+		fn(expr)
+	case *UnaryExpr:
+		walkColumns(expr.Left, fn)
+	case *BinaryExpr:
+		walkColumns(expr.Left, fn)
+		walkColumns(expr.Right, fn)
+	}
+}
+
+// selectivity returns the estimated fraction of the rows of a section that
+// match the predicate. The statistics of the section may be nil, in which
+// case default selectivities are used.
+func selectivity(predicate Expression, stats *SectionStatistics) float64 {
+	switch expr := predicate.(type) {
+	case *UnaryExpr:
+		if expr.Op == types.UnaryOpNot {
+			return 1 - selectivity(expr.Left, stats)
+		}
+
+	case *BinaryExpr:
+		switch expr.Op {
+		case types.BinaryOpAnd:
+			return selectivity(expr.Left, stats) * selectivity(expr.Right, stats)
+		case types.BinaryOpOr:
+			left, right := selectivity(expr.Left, stats), selectivity(expr.Right, stats)
+			return left + right - left*right
+		case types.BinaryOpEq:
+			return equalitySelectivity(expr, stats)
+		case types.BinaryOpNeq:
+			return 1 - equalitySelectivity(expr, stats)
+		case types.BinaryOpGt, types.BinaryOpGte, types.BinaryOpLt, types.BinaryOpLte:
+			return rangeSelectivity(expr, stats)
+		case types.BinaryOpMatchSubstr, types.BinaryOpMatchRe, types.BinaryOpMatchPattern:
+			return defaultSelectivity
+		case types.BinaryOpNotMatchSubstr, types.BinaryOpNotMatchRe, types.BinaryOpNotMatchPattern:
+			return 1 - defaultSelectivity
+		}
+	}
+	return 1
+}
+
+// equalitySelectivity estimates the fraction of rows for which the column on
+// the left side of expr equals the literal on the right side. It assumes
+// that the values of the column are distributed uniformly.
+func equalitySelectivity(expr *BinaryExpr, stats *SectionStatistics) float64 {
+	col, lit, ok := comparison(expr)
+	if !ok || stats == nil || stats.Rows == 0 {
+		return defaultSelectivity
+	}
+
+	column, ref, ok := lookupColumn(stats, col.Ref)
+	if !ok && ref.Type != types.ColumnTypeMetadata {
+		return defaultSelectivity
+	} else if !ok {
+		// The section has no values for the column, so only comparisons
+		// with an empty value match.
+		if value, isString := lit.Any().(string); isString && value == "" {
+			return 1
+		}
+		return 0
+	}
+	if column.Cardinality == 0 {
+		return defaultSelectivity
+	}
+	return float64(column.Values) / float64(column.Cardinality) / float64(stats.Rows)
+}
+
+// rangeSelectivity estimates the fraction of rows for which the comparison
+// of the column on the left side of expr with the literal on the right side
+// is true. Only comparisons of timestamps are estimated from the time range
+// of the section, assuming that timestamps are distributed uniformly.
+func rangeSelectivity(expr *BinaryExpr, stats *SectionStatistics) float64 {
+	col, lit, ok := comparison(expr)
+	if !ok || stats == nil || stats.Start.IsZero() || stats.End.IsZero() ||
+		col.Ref.Column != types.ColumnNameBuiltinTimestamp || col.Ref.Type != types.ColumnTypeBuiltin {
+		return defaultRangeSelectivity
+	}
+	ts, ok := lit.Any().(datatype.Timestamp)
+	if !ok {
+		return defaultRangeSelectivity
+	}
+	t := time.Unix(0, int64(ts))
+
+	// below is the fraction of rows with timestamps before t.
+	var below float64
+	switch {
+	case !t.After(stats.Start):
+		below = 0
+	case !t.Before(stats.End):
+		below = 1
+	default:
+		below = float64(t.Sub(stats.Start)) / float64(stats.End.Sub(stats.Start))
+	}
+
+	if expr.Op == types.BinaryOpLt || expr.Op == types.BinaryOpLte {
+		return below
+	}
+	return 1 - below
+}
+
+// comparison returns the column and the literal of a comparison of a column
+// with a literal.
+func comparison(expr *BinaryExpr) (*ColumnExpr, *LiteralExpr, bool) {
+	col, ok := expr.Left.(*ColumnExpr)
+	if !ok {
+		return nil, nil, false
+	}
+	lit, ok := expr.Right.(*LiteralExpr)
+	if !ok {
+		return nil, nil, false
+	}
+	return col, lit, true
+}
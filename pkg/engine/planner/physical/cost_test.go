@@ -0,0 +1,228 @@
+package physical
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/loki/v3/pkg/engine/internal/datatype"
+	"github.com/grafana/loki/v3/pkg/engine/internal/types"
+)
+
+// statisticsCatalog is a catalog that provides the statistics of sections.
+type statisticsCatalog struct {
+	catalog
+	stats map[SectionRef]*SectionStatistics
+}
+
+// SectionStatistics implements StatisticsCatalog.
+func (c *statisticsCatalog) SectionStatistics(refs []SectionRef) ([]*SectionStatistics, error) {
+	result := make([]*SectionStatistics, len(refs))
+	for i, ref := range refs {
+		result[i] = c.stats[ref]
+	}
+	return result, nil
+}
+
+var _ StatisticsCatalog = (*statisticsCatalog)(nil)
+
+func TestSelectivity(t *testing.T) {
+	start := time.Unix(1000, 0).UTC()
+	stats := &SectionStatistics{
+		Rows:  1000,
+		Start: start,
+		End:   start.Add(100 * time.Second),
+		Columns: map[types.ColumnRef]ColumnStatistics{
+			{Column: "env", Type: types.ColumnTypeMetadata}: {Values: 500, Cardinality: 5},
+		},
+	}
+
+	env := newColumnExpr("env", types.ColumnTypeMetadata)
+	timestamp := newColumnExpr(types.ColumnNameBuiltinTimestamp, types.ColumnTypeBuiltin)
+	message := newColumnExpr(types.ColumnNameBuiltinMessage, types.ColumnTypeBuiltin)
+	at := func(seconds int64) *LiteralExpr {
+		return NewLiteral(datatype.Timestamp(start.Add(time.Duration(seconds) * time.Second).UnixNano()))
+	}
+
+	for _, tt := range []struct {
+		predicate Expression
+		stats     *SectionStatistics
+		expected  float64
+	}{
+		{predicate: &BinaryExpr{Left: env, Right: NewLiteral("prod"), Op: types.BinaryOpEq}, stats: stats, expected: 0.1},
+		{predicate: &BinaryExpr{Left: env, Right: NewLiteral("prod"), Op: types.BinaryOpNeq}, stats: stats, expected: 0.9},
+		{predicate: &BinaryExpr{Left: env, Right: NewLiteral("prod"), Op: types.BinaryOpEq}, expected: defaultSelectivity},
+		{predicate: &BinaryExpr{Left: newColumnExpr("team", types.ColumnTypeMetadata), Right: NewLiteral("a"), Op: types.BinaryOpEq}, stats: stats, expected: 0},
+		{predicate: &BinaryExpr{Left: newColumnExpr("team", types.ColumnTypeMetadata), Right: NewLiteral(""), Op: types.BinaryOpEq}, stats: stats, expected: 1},
+		{predicate: &BinaryExpr{Left: timestamp, Right: at(25), Op: types.BinaryOpGte}, stats: stats, expected: 0.75},
+		{predicate: &BinaryExpr{Left: timestamp, Right: at(25), Op: types.BinaryOpLt}, stats: stats, expected: 0.25},
+		{predicate: &BinaryExpr{Left: timestamp, Right: at(-10), Op: types.BinaryOpLt}, stats: stats, expected: 0},
+		{predicate: &BinaryExpr{Left: timestamp, Right: at(200), Op: types.BinaryOpLte}, stats: stats, expected: 1},
+		{predicate: &BinaryExpr{Left: timestamp, Right: at(25), Op: types.BinaryOpGte}, expected: defaultRangeSelectivity},
+		{predicate: &BinaryExpr{Left: message, Right: NewLiteral("error"), Op: types.BinaryOpMatchSubstr}, stats: stats, expected: defaultSelectivity},
+		{predicate: &BinaryExpr{Left: message, Right: NewLiteral("error"), Op: types.BinaryOpNotMatchRe}, stats: stats, expected: 1 - defaultSelectivity},
+		{
+			predicate: &BinaryExpr{
+				Left:  &BinaryExpr{Left: timestamp, Right: at(50), Op: types.BinaryOpGte},
+				Right: &BinaryExpr{Left: env, Right: NewLiteral("prod"), Op: types.BinaryOpEq},
+				Op:    types.BinaryOpAnd,
+			},
+			stats:    stats,
+			expected: 0.05,
+		},
+		{
+			predicate: &BinaryExpr{
+				Left:  &BinaryExpr{Left: env, Right: NewLiteral("prod"), Op: types.BinaryOpEq},
+				Right: &BinaryExpr{Left: env, Right: NewLiteral("dev"), Op: types.BinaryOpEq},
+				Op:    types.BinaryOpOr,
+			},
+			stats:    stats,
+			expected: 0.19,
+		},
+		{
+			predicate: &UnaryExpr{Left: &BinaryExpr{Left: env, Right: NewLiteral("prod"), Op: types.BinaryOpEq}, Op: types.UnaryOpNot},
+			stats:     stats,
+			expected:  0.9,
+		},
+		{predicate: env, stats: stats, expected: 1},
+	} {
+		t.Run(tt.predicate.String(), func(t *testing.T) {
+			require.InDelta(t, tt.expected, selectivity(tt.predicate, tt.stats), 1e-9)
+		})
+	}
+}
+
+func TestPlanner_OptimizeWithStatistics(t *testing.T) {
+	start := time.Unix(1000, 0).UTC()
+	stats := make(map[SectionRef]*SectionStatistics)
+	for i := range 2 {
+		for section := range 2 {
+			// Newer objects and sections hold newer logs.
+			offset := time.Duration(2*i+section) * time.Hour
+			stats[SectionRef{Location: DataObjLocation(fmt.Sprintf("obj%d", i)), Section: section}] = &SectionStatistics{
+				Rows:             1000,
+				UncompressedSize: 1 << 20,
+				Start:            start.Add(offset),
+				End:              start.Add(offset + time.Hour),
+				Columns: map[types.ColumnRef]ColumnStatistics{
+					{Column: "env", Type: types.ColumnTypeMetadata}: {Values: 1000, Cardinality: 20},
+				},
+			}
+		}
+	}
+
+	p := &Plan{}
+	limit := p.addNode(&Limit{Fetch: 10})
+	merge := p.addNode(&SortMerge{Column: newColumnExpr(types.ColumnNameBuiltinTimestamp, types.ColumnTypeBuiltin), Order: DESC})
+	require.NoError(t, p.addEdge(Edge{Parent: limit, Child: merge}))
+	addObjects(t, p, merge, 2)
+
+	lineFilter := &BinaryExpr{Left: newColumnExpr(types.ColumnNameBuiltinMessage, types.ColumnTypeBuiltin), Right: NewLiteral("error"), Op: types.BinaryOpMatchSubstr}
+	envFilter := &BinaryExpr{Left: newColumnExpr("env", types.ColumnTypeMetadata), Right: NewLiteral("prod"), Op: types.BinaryOpEq}
+	for _, node := range p.nodes.sorted() {
+		if scan, ok := node.(*DataObjScan); ok {
+			scan.Predicates = []Expression{lineFilter, envFilter}
+		}
+	}
+
+	planner := NewPlanner(NewContext(start, start.Add(4*time.Hour)), &statisticsCatalog{stats: stats})
+	require.NoError(t, planner.optimizeWithStatistics(p, limit))
+	t.Logf("Optimized plan\n%s\n", PrintAsTree(p))
+
+	// The newest object and section are scanned first.
+	require.Equal(t, []string{"obj1/1", "obj1/0", "obj0/1", "obj0/0"}, func() []string {
+		var scans []string
+		for _, object := range p.Children(merge) {
+			for _, scan := range p.Children(object) {
+				scan := scan.(*DataObjScan)
+				scans = append(scans, fmt.Sprintf("%s/%d", scan.Location, scan.Section))
+				// The predicate that matches fewer rows is evaluated first.
+				require.Equal(t, []Expression{envFilter, lineFilter}, scan.Predicates)
+			}
+		}
+		return scans
+	}())
+
+	scanCost, ok := p.Cost(p.Children(p.Children(merge)[0])[0])
+	require.True(t, ok)
+	require.InDelta(t, 5, scanCost.Rows, 1e-9) // 1000 rows * 1/20 * 0.1
+	require.InDelta(t, 1<<20, scanCost.Bytes, 1e-9)
+
+	limitCost, ok := p.Cost(limit)
+	require.True(t, ok)
+	require.Equal(t, Cost{Rows: 10, Bytes: 4 << 20}, limitCost)
+	require.Contains(t, PrintAsTree(p), "└── Cost rows=10 bytes=4.0 MiB")
+}
+
+func TestPlanner_OptimizeWithStatistics_MissingStatistics(t *testing.T) {
+	p := &Plan{}
+	merge := p.addNode(&SortMerge{Column: newColumnExpr(types.ColumnNameBuiltinTimestamp, types.ColumnTypeBuiltin), Order: DESC})
+	addObjects(t, p, merge, 1)
+
+	stats := map[SectionRef]*SectionStatistics{
+		{Location: "obj0", Section: 0}: {Rows: 10, UncompressedSize: 100},
+	}
+	planner := NewPlanner(NewContext(time.Now(), time.Now()), &statisticsCatalog{stats: stats})
+	require.NoError(t, planner.optimizeWithStatistics(p, merge))
+
+	_, ok := p.Cost(merge)
+	require.False(t, ok, "nodes with a scan without statistics must have no cost")
+	for _, scan := range p.Children(p.Children(merge)[0]) {
+		_, ok := p.Cost(scan)
+		require.Equal(t, scan.(*DataObjScan).Section == 0, ok)
+	}
+}
+
+func TestFragment_Costs(t *testing.T) {
+	buildPlan := func(sectionSize int64) (*Plan, Node) {
+		p := &Plan{}
+		vector := p.addNode(&VectorAggregation{Operation: types.VectorAggregationTypeSum})
+		rangeAgg := p.addNode(&RangeAggregation{
+			Operation: types.RangeAggregationTypeCount,
+			Start:     time.Unix(1000, 0).UTC(),
+			End:       time.Unix(2000, 0).UTC(),
+			Step:      time.Minute,
+			Range:     5 * time.Minute,
+		})
+		require.NoError(t, p.addEdge(Edge{Parent: vector, Child: rangeAgg}))
+		addObjects(t, p, rangeAgg, 8)
+
+		stats := make(statistics)
+		for _, node := range p.nodes.sorted() {
+			if scan, ok := node.(*DataObjScan); ok {
+				stats[SectionRef{Location: scan.Location, Section: scan.Section}] = &SectionStatistics{Rows: 1000, UncompressedSize: sectionSize}
+			}
+		}
+		estimateCosts(p, vector, stats)
+		return p, vector
+	}
+
+	t.Run("small sections are not distributed", func(t *testing.T) {
+		p, vector := buildPlan(1 << 20)
+		plan, err := Fragment(p, 4)
+		require.NoError(t, err)
+		require.Len(t, plan.Children(vector), 1)
+		require.IsType(t, &RangeAggregation{}, plan.Children(vector)[0])
+	})
+
+	t.Run("large sections are distributed", func(t *testing.T) {
+		p, vector := buildPlan(1 << 30)
+		plan, err := Fragment(p, 4)
+		require.NoError(t, err)
+		t.Logf("Fragmented plan\n%s\n", PrintAsTree(plan))
+
+		exchanges := plan.Children(vector)
+		require.Len(t, exchanges, 4)
+		for _, exchange := range exchanges {
+			fragment := exchange.(*Exchange).Fragment
+			for _, node := range fragment.nodes.sorted() {
+				if _, ok := node.(*DataObjScan); ok {
+					_, ok := fragment.Cost(node)
+					require.True(t, ok, "costs are copied into fragments")
+				}
+			}
+		}
+	})
+}
@@ -0,0 +1,507 @@
+package physical
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/grafana/loki/v3/pkg/engine/internal/datatype"
+	"github.com/grafana/loki/v3/pkg/engine/internal/types"
+)
+
+// MarshalPlan encodes a plan, typically a fragment created by [Fragment], so
+// that it can be sent to a different process for execution. It returns an
+// error if the plan contains nodes that cannot be encoded.
+//
+// Only the nodes that are found in fragments can be encoded: DataObjScan,
+// SortMerge, Limit, Filter, Projection, RangeAggregation, VectorAggregation
+// and Vector.
+func MarshalPlan(plan *Plan) ([]byte, error) {
+	root, err := plan.Root()
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		encoded = encodedPlan{}
+		indexes = make(map[Node]int, plan.Len())
+	)
+	// Nodes are numbered in pre-order, so that the root node is the first node.
+	var add func(n Node) error
+	add = func(n Node) error {
+		if _, ok := indexes[n]; ok {
+			return nil
+		}
+		node, err := encodeNode(n)
+		if err != nil {
+			return err
+		}
+		indexes[n] = len(encoded.Nodes)
+		encoded.Nodes = append(encoded.Nodes, node)
+
+		for _, child := range plan.Children(n) {
+			if err := add(child); err != nil {
+				return err
+			}
+			encoded.Edges = append(encoded.Edges, encodedEdge{Parent: indexes[n], Child: indexes[child]})
+		}
+		return nil
+	}
+	if err := add(root); err != nil {
+		return nil, err
+	}
+	return json.Marshal(encoded)
+}
+
+// UnmarshalPlan decodes a plan encoded with [MarshalPlan].
+func UnmarshalPlan(data []byte) (*Plan, error) {
+	var encoded encodedPlan
+	if err := json.Unmarshal(data, &encoded); err != nil {
+		return nil, fmt.Errorf("decode plan: %w", err)
+	}
+
+	plan := &Plan{}
+	nodes := make([]Node, 0, len(encoded.Nodes))
+	for _, n := range encoded.Nodes {
+		node, err := n.decode()
+		if err != nil {
+			return nil, err
+		}
+		nodes = append(nodes, plan.addNode(node))
+	}
+	for _, e := range encoded.Edges {
+		if e.Parent < 0 || e.Parent >= len(nodes) || e.Child < 0 || e.Child >= len(nodes) {
+			return nil, fmt.Errorf("invalid edge %d->%d in plan with %d nodes", e.Parent, e.Child, len(nodes))
+		}
+		if err := plan.addEdge(Edge{Parent: nodes[e.Parent], Child: nodes[e.Child]}); err != nil {
+			return nil, err
+		}
+	}
+	if _, err := plan.Root(); err != nil {
+		return nil, err
+	}
+	return plan, nil
+}
+
+type encodedPlan struct {
+	Nodes []encodedNode `json:"nodes"`
+	Edges []encodedEdge `json:"edges"` // in the order of the children of each node
+}
+
+type encodedEdge struct {
+	Parent int `json:"parent"`
+	Child  int `json:"child"`
+}
+
+// encodedNode holds exactly one encoded node, in the field of its type.
+type encodedNode struct {
+	DataObjScan       *encodedDataObjScan       `json:"dataobjscan,omitempty"`
+	SortMerge         *encodedSortMerge         `json:"sortmerge,omitempty"`
+	Limit             *encodedLimit             `json:"limit,omitempty"`
+	Filter            *encodedFilter            `json:"filter,omitempty"`
+	Projection        *encodedProjection        `json:"projection,omitempty"`
+	RangeAggregation  *encodedRangeAggregation  `json:"range_aggregation,omitempty"`
+	VectorAggregation *encodedVectorAggregation `json:"vector_aggregation,omitempty"`
+	Vector            *encodedVector            `json:"vector,omitempty"`
+}
+
+type encodedDataObjScan struct {
+	Location    string        `json:"location"`
+	Section     int           `json:"section"`
+	StreamIDs   []int64       `json:"stream_ids"`
+	Projections []encodedExpr `json:"projections,omitempty"`
+	Predicates  []encodedExpr `json:"predicates,omitempty"`
+	Direction   SortOrder     `json:"direction"`
+	Limit       uint32        `json:"limit,omitempty"`
+}
+
+type encodedSortMerge struct {
+	Column encodedExpr `json:"column"`
+	Order  SortOrder   `json:"order"`
+}
+
+type encodedLimit struct {
+	Skip  uint32 `json:"skip,omitempty"`
+	Fetch uint32 `json:"fetch,omitempty"`
+}
+
+type encodedFilter struct {
+	Predicates []encodedExpr `json:"predicates"`
+}
+
+type encodedProjection struct {
+	Columns []encodedExpr `json:"columns"`
+}
+
+type encodedRangeAggregation struct {
+	PartitionBy []encodedExpr              `json:"partition_by,omitempty"`
+	Operation   types.RangeAggregationType `json:"operation"`
+	Parameter   encodedFloat               `json:"parameter,omitempty"`
+	Unwrap      *encodedUnwrap             `json:"unwrap,omitempty"`
+	Start       time.Time                  `json:"start"`
+	End         time.Time                  `json:"end"`
+	Step        time.Duration              `json:"step,omitempty"`
+	Range       time.Duration              `json:"range"`
+	Offset      time.Duration              `json:"offset,omitempty"`
+}
+
+type encodedUnwrap struct {
+	Column     encodedExpr          `json:"column"`
+	Conversion types.ConversionType `json:"conversion"`
+	DropErrors bool                 `json:"drop_errors,omitempty"`
+}
+
+type encodedVectorAggregation struct {
+	GroupBy   []encodedExpr               `json:"group_by,omitempty"`
+	Without   bool                        `json:"without,omitempty"`
+	Operation types.VectorAggregationType `json:"operation"`
+	Parameter int                         `json:"parameter,omitempty"`
+}
+
+type encodedVector struct {
+	Value encodedFloat  `json:"value"`
+	Start time.Time     `json:"start"`
+	End   time.Time     `json:"end"`
+	Step  time.Duration `json:"step,omitempty"`
+}
+
+// encodedFloat is a float that is encoded as string, since JSON numbers cannot
+// represent NaN and infinite values.
+type encodedFloat float64
+
+func (f encodedFloat) MarshalJSON() ([]byte, error) {
+	return json.Marshal(strconv.FormatFloat(float64(f), 'g', -1, 64))
+}
+
+func (f *encodedFloat) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return err
+	}
+	*f = encodedFloat(v)
+	return nil
+}
+
+func encodeNode(n Node) (encodedNode, error) {
+	switch node := n.(type) {
+	case *DataObjScan:
+		return encodedNode{DataObjScan: &encodedDataObjScan{
+			Location:    string(node.Location),
+			Section:     node.Section,
+			StreamIDs:   node.StreamIDs,
+			Projections: encodeExprs(node.Projections),
+			Predicates:  encodeExprs(node.Predicates),
+			Direction:   node.Direction,
+			Limit:       node.Limit,
+		}}, nil
+	case *SortMerge:
+		return encodedNode{SortMerge: &encodedSortMerge{
+			Column: encodeExpr(node.Column),
+			Order:  node.Order,
+		}}, nil
+	case *Limit:
+		return encodedNode{Limit: &encodedLimit{Skip: node.Skip, Fetch: node.Fetch}}, nil
+	case *Filter:
+		return encodedNode{Filter: &encodedFilter{Predicates: encodeExprs(node.Predicates)}}, nil
+	case *Projection:
+		return encodedNode{Projection: &encodedProjection{Columns: encodeExprs(node.Columns)}}, nil
+	case *RangeAggregation:
+		encoded := &encodedRangeAggregation{
+			PartitionBy: encodeExprs(node.PartitionBy),
+			Operation:   node.Operation,
+			Parameter:   encodedFloat(node.Parameter),
+			Start:       node.Start,
+			End:         node.End,
+			Step:        node.Step,
+			Range:       node.Range,
+			Offset:      node.Offset,
+		}
+		if node.Unwrap != nil {
+			encoded.Unwrap = &encodedUnwrap{
+				Column:     encodeExpr(node.Unwrap.Column),
+				Conversion: node.Unwrap.Conversion,
+				DropErrors: node.Unwrap.DropErrors,
+			}
+		}
+		return encodedNode{RangeAggregation: encoded}, nil
+	case *VectorAggregation:
+		return encodedNode{VectorAggregation: &encodedVectorAggregation{
+			GroupBy:   encodeExprs(node.GroupBy),
+			Without:   node.Without,
+			Operation: node.Operation,
+			Parameter: node.Parameter,
+		}}, nil
+	case *Vector:
+		return encodedNode{Vector: &encodedVector{
+			Value: encodedFloat(node.Value),
+			Start: node.Start,
+			End:   node.End,
+			Step:  node.Step,
+		}}, nil
+	default:
+		return encodedNode{}, fmt.Errorf("cannot encode node of type %s", n.Type())
+	}
+}
+
+func (n encodedNode) decode() (Node, error) {
+	switch {
+	case n.DataObjScan != nil:
+		projections, err := decodeColumnExprs(n.DataObjScan.Projections)
+		if err != nil {
+			return nil, err
+		}
+		predicates, err := decodeExprs(n.DataObjScan.Predicates)
+		if err != nil {
+			return nil, err
+		}
+		return &DataObjScan{
+			Location:    DataObjLocation(n.DataObjScan.Location),
+			Section:     n.DataObjScan.Section,
+			StreamIDs:   n.DataObjScan.StreamIDs,
+			Projections: projections,
+			Predicates:  predicates,
+			Direction:   n.DataObjScan.Direction,
+			Limit:       n.DataObjScan.Limit,
+		}, nil
+	case n.SortMerge != nil:
+		column, err := n.SortMerge.Column.decodeColumn()
+		if err != nil {
+			return nil, err
+		}
+		return &SortMerge{Column: column, Order: n.SortMerge.Order}, nil
+	case n.Limit != nil:
+		return &Limit{Skip: n.Limit.Skip, Fetch: n.Limit.Fetch}, nil
+	case n.Filter != nil:
+		predicates, err := decodeExprs(n.Filter.Predicates)
+		if err != nil {
+			return nil, err
+		}
+		return &Filter{Predicates: predicates}, nil
+	case n.Projection != nil:
+		columns, err := decodeColumnExprs(n.Projection.Columns)
+		if err != nil {
+			return nil, err
+		}
+		return &Projection{Columns: columns}, nil
+	case n.RangeAggregation != nil:
+		r := n.RangeAggregation
+		partitionBy, err := decodeColumnExprs(r.PartitionBy)
+		if err != nil {
+			return nil, err
+		}
+		node := &RangeAggregation{
+			PartitionBy: partitionBy,
+			Operation:   r.Operation,
+			Parameter:   float64(r.Parameter),
+			Start:       r.Start,
+			End:         r.End,
+			Step:        r.Step,
+			Range:       r.Range,
+			Offset:      r.Offset,
+		}
+		if r.Unwrap != nil {
+			column, err := r.Unwrap.Column.decodeColumn()
+			if err != nil {
+				return nil, err
+			}
+			node.Unwrap = &Unwrap{Column: column, Conversion: r.Unwrap.Conversion, DropErrors: r.Unwrap.DropErrors}
+		}
+		return node, nil
+	case n.VectorAggregation != nil:
+		v := n.VectorAggregation
+		groupBy, err := decodeColumnExprs(v.GroupBy)
+		if err != nil {
+			return nil, err
+		}
+		return &VectorAggregation{GroupBy: groupBy, Without: v.Without, Operation: v.Operation, Parameter: v.Parameter}, nil
+	case n.Vector != nil:
+		return &Vector{Value: float64(n.Vector.Value), Start: n.Vector.Start, End: n.Vector.End, Step: n.Vector.Step}, nil
+	default:
+		return nil, fmt.Errorf("invalid encoded node without type")
+	}
+}
+
+// encodedExpr holds an encoded expression. Type defines which of the other
+// fields are set.
+type encodedExpr struct {
+	Type    ExpressionType   `json:"type"`
+	Op      uint32           `json:"op,omitempty"`
+	Left    *encodedExpr     `json:"left,omitempty"`
+	Right   *encodedExpr     `json:"right,omitempty"`
+	Column  *types.ColumnRef `json:"column,omitempty"`
+	Literal *encodedLiteral  `json:"literal,omitempty"`
+}
+
+// encodedLiteral holds the value of a literal as string, together with the
+// name of its data type.
+type encodedLiteral struct {
+	Type  string `json:"type"`
+	Value string `json:"value"`
+}
+
+func encodeExprs[E Expression](exprs []E) []encodedExpr {
+	if len(exprs) == 0 {
+		return nil
+	}
+	encoded := make([]encodedExpr, 0, len(exprs))
+	for _, expr := range exprs {
+		encoded = append(encoded, encodeExpr(expr))
+	}
+	return encoded
+}
+
+func encodeExpr(expr Expression) encodedExpr {
+	switch expr := expr.(type) {
+	case *UnaryExpr:
+		left := encodeExpr(expr.Left)
+		return encodedExpr{Type: ExprTypeUnary, Op: uint32(expr.Op), Left: &left}
+	case *BinaryExpr:
+		left, right := encodeExpr(expr.Left), encodeExpr(expr.Right)
+		return encodedExpr{Type: ExprTypeBinary, Op: uint32(expr.Op), Left: &left, Right: &right}
+	case *ColumnExpr:
+		ref := expr.Ref
+		return encodedExpr{Type: ExprTypeColumn, Column: &ref}
+	case *LiteralExpr:
+		return encodedExpr{Type: ExprTypeLiteral, Literal: encodeLiteral(expr.Literal)}
+	default:
+		panic(fmt.Sprintf("unknown expression type %T", expr))
+	}
+}
+
+func encodeLiteral(lit datatype.Literal) *encodedLiteral {
+	encoded := &encodedLiteral{Type: lit.Type().String()}
+	switch v := lit.Any().(type) {
+	case nil:
+	case bool:
+		encoded.Value = strconv.FormatBool(v)
+	case string:
+		encoded.Value = v
+	case int64:
+		encoded.Value = strconv.FormatInt(v, 10)
+	case float64:
+		encoded.Value = strconv.FormatFloat(v, 'g', -1, 64)
+	case datatype.Timestamp:
+		encoded.Value = strconv.FormatInt(int64(v), 10)
+	case datatype.Duration:
+		encoded.Value = strconv.FormatInt(int64(v), 10)
+	case datatype.Bytes:
+		encoded.Value = strconv.FormatInt(int64(v), 10)
+	default:
+		panic(fmt.Sprintf("unknown literal value type %T", v))
+	}
+	return encoded
+}
+
+func decodeExprs(encoded []encodedExpr) ([]Expression, error) {
+	if len(encoded) == 0 {
+		return nil, nil
+	}
+	exprs := make([]Expression, 0, len(encoded))
+	for _, e := range encoded {
+		expr, err := e.decode()
+		if err != nil {
+			return nil, err
+		}
+		exprs = append(exprs, expr)
+	}
+	return exprs, nil
+}
+
+func decodeColumnExprs(encoded []encodedExpr) ([]ColumnExpression, error) {
+	if len(encoded) == 0 {
+		return nil, nil
+	}
+	exprs := make([]ColumnExpression, 0, len(encoded))
+	for _, e := range encoded {
+		expr, err := e.decodeColumn()
+		if err != nil {
+			return nil, err
+		}
+		exprs = append(exprs, expr)
+	}
+	return exprs, nil
+}
+
+func (e encodedExpr) decodeColumn() (ColumnExpression, error) {
+	if e.Type != ExprTypeColumn || e.Column == nil {
+		return nil, fmt.Errorf("invalid encoded expression: expected column expression")
+	}
+	return &ColumnExpr{Ref: *e.Column}, nil
+}
+
+func (e encodedExpr) decode() (Expression, error) {
+	switch e.Type {
+	case ExprTypeUnary:
+		if e.Left == nil {
+			return nil, fmt.Errorf("invalid encoded unary expression without operand")
+		}
+		left, err := e.Left.decode()
+		if err != nil {
+			return nil, err
+		}
+		return &UnaryExpr{Left: left, Op: types.UnaryOp(e.Op)}, nil
+	case ExprTypeBinary:
+		if e.Left == nil || e.Right == nil {
+			return nil, fmt.Errorf("invalid encoded binary expression without operands")
+		}
+		left, err := e.Left.decode()
+		if err != nil {
+			return nil, err
+		}
+		right, err := e.Right.decode()
+		if err != nil {
+			return nil, err
+		}
+		return &BinaryExpr{Left: left, Right: right, Op: types.BinaryOp(e.Op)}, nil
+	case ExprTypeColumn:
+		return e.decodeColumn()
+	case ExprTypeLiteral:
+		if e.Literal == nil {
+			return nil, fmt.Errorf("invalid encoded literal expression without value")
+		}
+		return e.Literal.decode()
+	default:
+		return nil, fmt.Errorf("invalid encoded expression of type %d", e.Type)
+	}
+}
+
+func (l encodedLiteral) decode() (*LiteralExpr, error) {
+	var (
+		value datatype.LiteralType
+		err   error
+	)
+	switch l.Type {
+	case datatype.Loki.Null.String():
+		return NewLiteral(nil), nil
+	case datatype.Loki.Bool.String():
+		value, err = strconv.ParseBool(l.Value)
+	case datatype.Loki.String.String():
+		value = l.Value
+	case datatype.Loki.Integer.String():
+		value, err = strconv.ParseInt(l.Value, 10, 64)
+	case datatype.Loki.Float.String():
+		value, err = strconv.ParseFloat(l.Value, 64)
+	case datatype.Loki.Timestamp.String(), datatype.Loki.Duration.String(), datatype.Loki.Bytes.String():
+		var v int64
+		v, err = strconv.ParseInt(l.Value, 10, 64)
+		switch l.Type {
+		case datatype.Loki.Timestamp.String():
+			value = datatype.Timestamp(v)
+		case datatype.Loki.Duration.String():
+			value = datatype.Duration(v)
+		default:
+			value = datatype.Bytes(v)
+		}
+	default:
+		return nil, fmt.Errorf("invalid encoded literal of type %s", l.Type)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid encoded literal of type %s: %w", l.Type, err)
+	}
+	return NewLiteral(value), nil
+}
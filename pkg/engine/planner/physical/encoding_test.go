@@ -0,0 +1,161 @@
+package physical
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/loki/v3/pkg/engine/internal/datatype"
+	"github.com/grafana/loki/v3/pkg/engine/internal/types"
+)
+
+// requireEqualPlans compares the nodes of two plans, starting at their roots.
+func requireEqualPlans(t *testing.T, expected, actual *Plan) {
+	t.Helper()
+
+	require.Equal(t, expected.Len(), actual.Len())
+
+	expectedRoot, err := expected.Root()
+	require.NoError(t, err)
+	actualRoot, err := actual.Root()
+	require.NoError(t, err)
+
+	var walk func(e, a Node)
+	walk = func(e, a Node) {
+		require.Equal(t, e, a)
+
+		expectedChildren, actualChildren := expected.Children(e), actual.Children(a)
+		require.Len(t, actualChildren, len(expectedChildren))
+		for i := range expectedChildren {
+			walk(expectedChildren[i], actualChildren[i])
+		}
+	}
+	walk(expectedRoot, actualRoot)
+}
+
+func TestMarshalPlan(t *testing.T) {
+	t.Run("round trip", func(t *testing.T) {
+		p := &Plan{}
+		vector := p.addNode(&VectorAggregation{
+			GroupBy:   []ColumnExpression{newColumnExpr("app", types.ColumnTypeLabel)},
+			Without:   true,
+			Operation: types.VectorAggregationTypeTopK,
+			Parameter: 3,
+		})
+		rangeAgg := p.addNode(&RangeAggregation{
+			PartitionBy: []ColumnExpression{newColumnExpr("app", types.ColumnTypeLabel), newColumnExpr("env", types.ColumnTypeAmbiguous)},
+			Operation:   types.RangeAggregationTypeQuantile,
+			Parameter:   0.99,
+			Unwrap: &Unwrap{
+				Column:     newColumnExpr("latency", types.ColumnTypeMetadata),
+				Conversion: types.ConversionTypeDuration,
+				DropErrors: true,
+			},
+			Start:  time.Unix(1000, 0).UTC(),
+			End:    time.Unix(2000, 0).UTC(),
+			Step:   time.Minute,
+			Range:  5 * time.Minute,
+			Offset: time.Hour,
+		})
+		limit := p.addNode(&Limit{Skip: 5, Fetch: 10})
+		filter := p.addNode(&Filter{Predicates: []Expression{
+			&UnaryExpr{
+				Op: types.UnaryOpNot,
+				Left: &BinaryExpr{
+					Left:  newColumnExpr("level", types.ColumnTypeMetadata),
+					Right: NewLiteral("debug"),
+					Op:    types.BinaryOpEq,
+				},
+			},
+		}})
+		projection := p.addNode(&Projection{Columns: []ColumnExpression{newColumnExpr("level", types.ColumnTypeMetadata)}})
+		merge := p.addNode(&SortMerge{Column: newColumnExpr(types.ColumnNameBuiltinTimestamp, types.ColumnTypeBuiltin), Order: ASC})
+		scan1 := p.addNode(&DataObjScan{
+			Location:    "objects/00/0000",
+			Section:     1,
+			StreamIDs:   []int64{1, 2, 3},
+			Projections: []ColumnExpression{newColumnExpr(types.ColumnNameBuiltinMessage, types.ColumnTypeBuiltin)},
+			Predicates: []Expression{
+				&BinaryExpr{Left: newColumnExpr(types.ColumnNameBuiltinTimestamp, types.ColumnTypeBuiltin), Right: NewLiteral(datatype.Timestamp(1000)), Op: types.BinaryOpGte},
+				&BinaryExpr{Left: newColumnExpr("size", types.ColumnTypeMetadata), Right: NewLiteral(datatype.Bytes(1024)), Op: types.BinaryOpLt},
+				&BinaryExpr{Left: newColumnExpr("took", types.ColumnTypeMetadata), Right: NewLiteral(datatype.Duration(time.Second)), Op: types.BinaryOpGt},
+				&BinaryExpr{Left: newColumnExpr("count", types.ColumnTypeMetadata), Right: NewLiteral(int64(-7)), Op: types.BinaryOpNeq},
+				&BinaryExpr{Left: newColumnExpr("ratio", types.ColumnTypeMetadata), Right: NewLiteral(0.5), Op: types.BinaryOpLte},
+				&BinaryExpr{Left: newColumnExpr("ok", types.ColumnTypeMetadata), Right: NewLiteral(true), Op: types.BinaryOpEq},
+				&BinaryExpr{Left: newColumnExpr("missing", types.ColumnTypeMetadata), Right: NewLiteral(nil), Op: types.BinaryOpEq},
+			},
+			Direction: ASC,
+			Limit:     100,
+		})
+		scan2 := p.addNode(&DataObjScan{Location: "objects/00/0001", StreamIDs: []int64{4}})
+		for _, e := range []Edge{
+			{Parent: vector, Child: rangeAgg},
+			{Parent: rangeAgg, Child: limit},
+			{Parent: limit, Child: filter},
+			{Parent: filter, Child: projection},
+			{Parent: projection, Child: merge},
+			{Parent: merge, Child: scan1},
+			{Parent: merge, Child: scan2},
+		} {
+			require.NoError(t, p.addEdge(e))
+		}
+
+		data, err := MarshalPlan(p)
+		require.NoError(t, err)
+
+		actual, err := UnmarshalPlan(data)
+		require.NoError(t, err)
+		requireEqualPlans(t, p, actual)
+	})
+
+	t.Run("special float values", func(t *testing.T) {
+		p := &Plan{}
+		p.addNode(&Vector{Value: math.Inf(-1), Start: time.Unix(1000, 0).UTC(), End: time.Unix(2000, 0).UTC(), Step: time.Minute})
+
+		data, err := MarshalPlan(p)
+		require.NoError(t, err)
+
+		actual, err := UnmarshalPlan(data)
+		require.NoError(t, err)
+		requireEqualPlans(t, p, actual)
+	})
+
+	t.Run("fragment", func(t *testing.T) {
+		p := &Plan{}
+		merge := p.addNode(&SortMerge{Column: newColumnExpr(types.ColumnNameBuiltinTimestamp, types.ColumnTypeBuiltin), Order: DESC})
+		addObjects(t, p, merge, 4)
+
+		plan, err := Fragment(p, 2)
+		require.NoError(t, err)
+
+		for _, exchange := range plan.Children(merge) {
+			fragment := exchange.(*Exchange).Fragment
+
+			data, err := MarshalPlan(fragment)
+			require.NoError(t, err)
+
+			actual, err := UnmarshalPlan(data)
+			require.NoError(t, err)
+			requireEqualPlans(t, fragment, actual)
+		}
+
+		// plans with exchanges cannot be encoded themselves
+		_, err = MarshalPlan(plan)
+		require.ErrorContains(t, err, "cannot encode node of type Exchange")
+	})
+
+	t.Run("unsupported node", func(t *testing.T) {
+		p := &Plan{}
+		p.addNode(&Join{Operator: types.BinaryOpAdd})
+
+		_, err := MarshalPlan(p)
+		require.ErrorContains(t, err, "cannot encode node of type Join")
+	})
+
+	t.Run("invalid edge", func(t *testing.T) {
+		_, err := UnmarshalPlan([]byte(`{"nodes":[{"limit":{"fetch":1}}],"edges":[{"parent":0,"child":1}]}`))
+		require.ErrorContains(t, err, "invalid edge 0->1")
+	})
+}
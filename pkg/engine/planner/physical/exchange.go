@@ -0,0 +1,38 @@
+package physical
+
+import "fmt"
+
+// Exchange represents the boundary between two fragments of a distributed
+// physical plan. It is a leaf node of the plan that produces the results of
+// its Fragment, which is a separate plan that is executed independently,
+// typically on a different querier.
+//
+// Exchange nodes are created by [Fragment].
+type Exchange struct {
+	id string
+
+	// Fragment is the plan whose results the node produces. Fragments never
+	// contain Exchange nodes themselves.
+	Fragment *Plan
+}
+
+// ID implements the [Node] interface.
+// Returns a string that uniquely identifies the node in the plan.
+func (e *Exchange) ID() string {
+	if e.id == "" {
+		return fmt.Sprintf("%p", e)
+	}
+	return e.id
+}
+
+// Type implements the [Node] interface.
+// Returns the type of the node.
+func (*Exchange) Type() NodeType {
+	return NodeTypeExchange
+}
+
+// Accept implements the [Node] interface.
+// Dispatches itself to the provided [Visitor] v
+func (e *Exchange) Accept(v Visitor) error {
+	return v.VisitExchange(e)
+}
@@ -0,0 +1,258 @@
+package physical
+
+import (
+	"slices"
+
+	"github.com/grafana/loki/v3/pkg/engine/internal/types"
+)
+
+// Fragment cuts the plan into fragments that can be executed independently,
+// such as on different queriers, and returns the remaining plan that merges
+// their results. The fragments are referenced by [Exchange] nodes that replace
+// the inputs of the exchange points of the plan. The plan is modified in
+// place.
+//
+// Exchange points are nodes whose inputs are the scans of at least two data
+// objects. The data objects are distributed over at most n fragments:
+//
+//   - A SortMerge node of a log query becomes the root of each fragment, and
+//     the original node merges the sorted results of the fragments. A limit
+//     directly above the SortMerge node is applied to each fragment as well.
+//   - A RangeAggregation node below a VectorAggregation node is computed
+//     partially in each fragment, together with a partial VectorAggregation.
+//     The original VectorAggregation node aggregates the partial results.
+//     This requires the results of both aggregations to be combinable, for
+//     example a sum of counts. Otherwise, the node is not cut.
+//
+// If the costs of the plan have been estimated, the inputs of an exchange
+// point are only distributed over as many fragments as pay off, see
+// [fragmentCount]. Plans without exchange points are returned unchanged.
+func Fragment(plan *Plan, n int) (*Plan, error) {
+	if n < 2 {
+		return plan, nil
+	}
+	if _, err := plan.Root(); err != nil {
+		return nil, err
+	}
+
+	// Exchange points are collected before any of them is cut, since cutting
+	// modifies the plan.
+	var points []Node
+	for _, node := range plan.nodes.sorted() {
+		if isExchangePoint(plan, node) {
+			points = append(points, node)
+		}
+	}
+
+	for _, point := range points {
+		switch node := point.(type) {
+		case *SortMerge:
+			var limit *Limit
+			if parents := plan.Parents(node); len(parents) == 1 {
+				if l, ok := parents[0].(*Limit); ok && l.Fetch > 0 {
+					limit = &Limit{Fetch: l.Skip + l.Fetch}
+				}
+			}
+			k := fragmentCount(plan, node, n, func(k int) float64 {
+				cost, _ := plan.Cost(node)
+				rows := cost.Rows / float64(k)
+				if limit != nil {
+					rows = min(rows, float64(limit.Fetch))
+				}
+				return rows
+			})
+			if k < 2 {
+				continue
+			}
+			cut(plan, node, node, k, func(fragment *Plan) Node {
+				root := fragment.addNode(&SortMerge{Column: node.Column, Order: node.Order})
+				if limit == nil {
+					return root
+				}
+				fragmentLimit := fragment.addNode(&Limit{Fetch: limit.Fetch})
+				_ = fragment.addEdge(Edge{Parent: fragmentLimit, Child: root})
+				return root
+			})
+
+		case *RangeAggregation:
+			parents := plan.Parents(node)
+			if len(parents) != 1 {
+				continue
+			}
+			vector, ok := parents[0].(*VectorAggregation)
+			if !ok || !canCombinePartialAggregations(vector, node) || len(plan.Children(vector)) != 1 {
+				continue
+			}
+			// Each fragment may return partial results for all series of
+			// the aggregation.
+			k := fragmentCount(plan, node, n, func(int) float64 {
+				cost, _ := plan.Cost(vector)
+				return cost.Rows
+			})
+			if k < 2 {
+				continue
+			}
+			cut(plan, vector, node, k, func(fragment *Plan) Node {
+				partialVector := *vector
+				partialVector.id = ""
+				partialRange := *node
+				partialRange.id = ""
+
+				fragment.addNode(&partialVector)
+				fragment.addNode(&partialRange)
+				_ = fragment.addEdge(Edge{Parent: &partialVector, Child: &partialRange})
+				return &partialRange
+			})
+		}
+	}
+	return plan, nil
+}
+
+// isExchangePoint returns whether the node is a SortMerge or RangeAggregation
+// node that has only data object scans as inputs, with at least two objects.
+// The scans of each data object are merged by their own SortMerge node.
+func isExchangePoint(plan *Plan, node Node) bool {
+	switch node.(type) {
+	case *SortMerge, *RangeAggregation:
+	default:
+		return false
+	}
+
+	children := plan.Children(node)
+	if len(children) < 2 {
+		return false
+	}
+	for _, child := range children {
+		if _, ok := child.(*SortMerge); !ok {
+			return false
+		}
+		scans := plan.Children(child)
+		if len(scans) == 0 {
+			return false
+		}
+		for _, scan := range scans {
+			if _, ok := scan.(*DataObjScan); !ok {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// canCombinePartialAggregations returns whether a vector aggregation over a
+// range aggregation can be computed by aggregating partial results of both,
+// that are computed over disjoint sets of data objects. The same series may
+// be found in multiple data objects, so the partial results of a series must
+// combine like the results of different series.
+func canCombinePartialAggregations(vector *VectorAggregation, rangeAgg *RangeAggregation) bool {
+	switch vector.Operation {
+	case types.VectorAggregationTypeSum:
+		return slices.Contains([]types.RangeAggregationType{
+			types.RangeAggregationTypeCount,
+			types.RangeAggregationTypeRate,
+			types.RangeAggregationTypeBytes,
+			types.RangeAggregationTypeBytesRate,
+			types.RangeAggregationTypeSum,
+		}, rangeAgg.Operation)
+	case types.VectorAggregationTypeMin:
+		return rangeAgg.Operation == types.RangeAggregationTypeMin
+	case types.VectorAggregationTypeMax:
+		return rangeAgg.Operation == types.RangeAggregationTypeMax
+	default:
+		return false
+	}
+}
+
+const (
+	// fragmentOverheadBytes is the estimated overhead of executing a
+	// fragment on a querier, such as scheduling it and opening the data
+	// objects, expressed as the number of bytes that are read from data
+	// objects in the same time.
+	fragmentOverheadBytes = 16 << 20
+
+	// resultRowBytes is the estimated size of a row of the results of a
+	// fragment, including its labels, when it is sent to the query frontend.
+	resultRowBytes = 256
+)
+
+// fragmentCount returns the number of fragments over which the inputs of the
+// exchange point are distributed, which is at most n. Without costs, it is
+// n. Otherwise it is the number of fragments with the lowest estimated cost,
+// or 1 if distributing the inputs does not pay off.
+//
+// Executing the inputs in k fragments divides the bytes that are read by
+// each of them by k, but each fragment adds its overhead and the transfer of
+// its results, whose number of rows is returned by resultRows.
+func fragmentCount(plan *Plan, point Node, n int, resultRows func(k int) float64) int {
+	cost, ok := plan.Cost(point)
+	if !ok {
+		return n
+	}
+
+	best, bestCost := 1, cost.Bytes
+	for k := 2; k <= min(n, len(plan.Children(point))); k++ {
+		fragments := float64(k)
+		distributed := cost.Bytes/fragments + fragments*(fragmentOverheadBytes+resultRows(k)*resultRowBytes)
+		if distributed < bestCost {
+			best, bestCost = k, distributed
+		}
+	}
+	return best
+}
+
+// cut moves the inputs of the exchange point into at most n fragments. Each
+// fragment is created with newFragment, which returns the node of the
+// fragment that becomes the parent of the inputs. The exchange point and all
+// nodes between it and the target node are removed from the plan and the
+// [Exchange] nodes of the fragments become the inputs of the target node.
+func cut(plan *Plan, target, point Node, n int, newFragment func(*Plan) Node) {
+	inputs := plan.Children(point)
+	n = min(n, len(inputs))
+
+	exchanges := make([]Node, 0, n)
+	for i := range n {
+		fragment := &Plan{}
+		parent := newFragment(fragment)
+		for _, input := range inputs[i*len(inputs)/n : (i+1)*len(inputs)/n] {
+			copySubtree(fragment, plan, input)
+			_ = fragment.addEdge(Edge{Parent: parent, Child: input})
+		}
+		exchanges = append(exchanges, &Exchange{Fragment: fragment})
+	}
+
+	for _, child := range plan.Children(target) {
+		removeSubtree(plan, child)
+	}
+	for _, exchange := range exchanges {
+		plan.addNode(exchange)
+		_ = plan.addEdge(Edge{Parent: target, Child: exchange})
+	}
+}
+
+// copySubtree adds the node and all its descendants with their edges from
+// src to dst.
+func copySubtree(dst, src *Plan, node Node) {
+	dst.addNode(node)
+	if cost, ok := src.Cost(node); ok {
+		dst.setCost(node, cost)
+	}
+	for _, child := range src.Children(node) {
+		copySubtree(dst, src, child)
+		_ = dst.addEdge(Edge{Parent: node, Child: child})
+	}
+}
+
+// removeSubtree removes the node and all its descendants from the plan.
+func removeSubtree(plan *Plan, node Node) {
+	for _, child := range plan.Children(node) {
+		removeSubtree(plan, child)
+	}
+	for _, parent := range plan.Parents(node) {
+		plan.children[parent] = slices.DeleteFunc(plan.children[parent], func(n Node) bool { return n == node })
+	}
+	delete(plan.children, node)
+	delete(plan.parents, node)
+	plan.nodes.remove(node)
+	delete(plan.nodesByID, node.ID())
+	delete(plan.costs, node)
+}
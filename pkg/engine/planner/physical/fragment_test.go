@@ -0,0 +1,175 @@
+package physical
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/loki/v3/pkg/engine/internal/types"
+)
+
+// addObjects adds a SortMerge node with two DataObjScan nodes for each of the
+// objects to the plan, as children of parent.
+func addObjects(t *testing.T, p *Plan, parent Node, objects int) {
+	t.Helper()
+	for i := range objects {
+		merge := p.addNode(&SortMerge{Column: newColumnExpr(types.ColumnNameBuiltinTimestamp, types.ColumnTypeBuiltin), Order: DESC})
+		require.NoError(t, p.addEdge(Edge{Parent: parent, Child: merge}))
+		for section := range 2 {
+			scan := p.addNode(&DataObjScan{Location: DataObjLocation(fmt.Sprintf("obj%d", i)), Section: section, Direction: DESC})
+			require.NoError(t, p.addEdge(Edge{Parent: merge, Child: scan}))
+		}
+	}
+}
+
+// scannedObjects returns the locations of the DataObjScan nodes of the plan
+// and its fragments.
+func scannedObjects(p *Plan) []string {
+	var locations []string
+	for _, node := range p.nodes.sorted() {
+		switch node := node.(type) {
+		case *DataObjScan:
+			locations = append(locations, fmt.Sprintf("%s/%d", node.Location, node.Section))
+		case *Exchange:
+			locations = append(locations, scannedObjects(node.Fragment)...)
+		}
+	}
+	return locations
+}
+
+func TestFragment_LogQuery(t *testing.T) {
+	p := &Plan{}
+	limit := p.addNode(&Limit{Skip: 10, Fetch: 100})
+	merge := p.addNode(&SortMerge{Column: newColumnExpr(types.ColumnNameBuiltinTimestamp, types.ColumnTypeBuiltin), Order: DESC})
+	require.NoError(t, p.addEdge(Edge{Parent: limit, Child: merge}))
+	addObjects(t, p, merge, 5)
+	expectedObjects := scannedObjects(p)
+
+	plan, err := Fragment(p, 2)
+	require.NoError(t, err)
+	t.Logf("Fragmented plan\n%s\n", PrintAsTree(plan))
+
+	require.Equal(t, 4, plan.Len())
+	require.ElementsMatch(t, expectedObjects, scannedObjects(plan))
+
+	exchanges := plan.Children(merge)
+	require.Len(t, exchanges, 2)
+	for i, expectedObjects := range []int{2, 3} {
+		fragment := exchanges[i].(*Exchange).Fragment
+
+		root, err := fragment.Root()
+		require.NoError(t, err)
+		require.Equal(t, &Limit{Fetch: 110}, root)
+
+		children := fragment.Children(root)
+		require.Len(t, children, 1)
+		require.Equal(t, DESC, children[0].(*SortMerge).Order)
+		require.Len(t, fragment.Children(children[0]), expectedObjects)
+	}
+}
+
+func TestFragment_MetricQuery(t *testing.T) {
+	buildPlan := func(vectorOp types.VectorAggregationType, rangeOp types.RangeAggregationType, objects int) (*Plan, Node) {
+		p := &Plan{}
+		vector := p.addNode(&VectorAggregation{
+			GroupBy:   []ColumnExpression{newColumnExpr("app", types.ColumnTypeAmbiguous)},
+			Operation: vectorOp,
+		})
+		rangeAgg := p.addNode(&RangeAggregation{
+			PartitionBy: []ColumnExpression{newColumnExpr("app", types.ColumnTypeAmbiguous)},
+			Operation:   rangeOp,
+			Start:       time.Unix(1000, 0).UTC(),
+			End:         time.Unix(2000, 0).UTC(),
+			Step:        time.Minute,
+			Range:       5 * time.Minute,
+		})
+		require.NoError(t, p.addEdge(Edge{Parent: vector, Child: rangeAgg}))
+		addObjects(t, p, rangeAgg, objects)
+		return p, vector
+	}
+
+	t.Run("combinable aggregations are fragmented", func(t *testing.T) {
+		for _, tt := range []struct {
+			vectorOp types.VectorAggregationType
+			rangeOp  types.RangeAggregationType
+		}{
+			{types.VectorAggregationTypeSum, types.RangeAggregationTypeCount},
+			{types.VectorAggregationTypeSum, types.RangeAggregationTypeBytesRate},
+			{types.VectorAggregationTypeMax, types.RangeAggregationTypeMax},
+			{types.VectorAggregationTypeMin, types.RangeAggregationTypeMin},
+		} {
+			t.Run(fmt.Sprintf("%s(%s)", tt.vectorOp, tt.rangeOp), func(t *testing.T) {
+				p, vector := buildPlan(tt.vectorOp, tt.rangeOp, 3)
+				expectedObjects := scannedObjects(p)
+
+				plan, err := Fragment(p, 8)
+				require.NoError(t, err)
+				t.Logf("Fragmented plan\n%s\n", PrintAsTree(plan))
+
+				// one exchange per object, since there are less objects than fragments
+				require.Equal(t, 4, plan.Len())
+				require.ElementsMatch(t, expectedObjects, scannedObjects(plan))
+
+				exchanges := plan.Children(vector)
+				require.Len(t, exchanges, 3)
+				for _, exchange := range exchanges {
+					fragment := exchange.(*Exchange).Fragment
+
+					root, err := fragment.Root()
+					require.NoError(t, err)
+					partialVector, ok := root.(*VectorAggregation)
+					require.True(t, ok)
+					require.Equal(t, tt.vectorOp, partialVector.Operation)
+
+					children := fragment.Children(root)
+					require.Len(t, children, 1)
+					partialRange, ok := children[0].(*RangeAggregation)
+					require.True(t, ok)
+					require.Equal(t, tt.rangeOp, partialRange.Operation)
+					require.Len(t, fragment.Children(partialRange), 1)
+				}
+			})
+		}
+	})
+
+	t.Run("other aggregations are not fragmented", func(t *testing.T) {
+		for _, tt := range []struct {
+			vectorOp types.VectorAggregationType
+			rangeOp  types.RangeAggregationType
+		}{
+			{types.VectorAggregationTypeAvg, types.RangeAggregationTypeCount},
+			{types.VectorAggregationTypeCount, types.RangeAggregationTypeCount},
+			{types.VectorAggregationTypeSum, types.RangeAggregationTypeAvg},
+			{types.VectorAggregationTypeMax, types.RangeAggregationTypeCount},
+		} {
+			t.Run(fmt.Sprintf("%s(%s)", tt.vectorOp, tt.rangeOp), func(t *testing.T) {
+				p, _ := buildPlan(tt.vectorOp, tt.rangeOp, 3)
+				expected := PrintAsTree(p)
+
+				plan, err := Fragment(p, 8)
+				require.NoError(t, err)
+				require.Equal(t, expected, PrintAsTree(plan))
+			})
+		}
+	})
+
+	t.Run("single object is not fragmented", func(t *testing.T) {
+		p, _ := buildPlan(types.VectorAggregationTypeSum, types.RangeAggregationTypeCount, 1)
+		expected := PrintAsTree(p)
+
+		plan, err := Fragment(p, 8)
+		require.NoError(t, err)
+		require.Equal(t, expected, PrintAsTree(plan))
+	})
+
+	t.Run("single fragment", func(t *testing.T) {
+		p, _ := buildPlan(types.VectorAggregationTypeSum, types.RangeAggregationTypeCount, 3)
+		expected := PrintAsTree(p)
+
+		plan, err := Fragment(p, 1)
+		require.NoError(t, err)
+		require.Equal(t, expected, PrintAsTree(plan))
+	})
+}
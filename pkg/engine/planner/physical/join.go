@@ -0,0 +1,73 @@
+package physical
+
+import (
+	"fmt"
+
+	"github.com/grafana/loki/v3/pkg/engine/internal/types"
+)
+
+// Join represents a physical plan node that performs a binary operation
+// between two vectors, or between a vector and a scalar.
+//
+// A join of two vectors has two children: the left and the right operand, in
+// this order. The series of both operands are matched by their labels at each
+// timestamp as defined by VectorMatching.
+//
+// A join of a vector and a scalar has a single child. The scalar is applied to
+// every series of the child.
+type Join struct {
+	id string
+
+	// Operator defines the binary operation to perform.
+	Operator types.BinaryOp
+
+	// ReturnBool defines whether comparisons return 0 or 1 instead of
+	// filtering the series.
+	ReturnBool bool
+
+	// VectorMatching defines how the series of two vectors are matched. It is
+	// nil if one of the operands is a scalar.
+	VectorMatching *VectorMatching
+
+	// LeftScalar and RightScalar hold the value of a scalar operand. At most
+	// one of them is set.
+	LeftScalar, RightScalar *float64
+}
+
+// VectorMatching describes how the series of the two operands of a [Join]
+// are matched.
+type VectorMatching struct {
+	// Cardinality defines whether each side may have multiple series with the
+	// same matching labels.
+	Cardinality types.MatchCardinality
+
+	// On defines whether series are matched on the MatchingLabels only,
+	// instead of on all labels except the MatchingLabels.
+	On             bool
+	MatchingLabels []ColumnExpression
+
+	// Include defines the labels of the "one" side of a many-to-one or
+	// one-to-many matching that are added to the result.
+	Include []ColumnExpression
+}
+
+// ID implements the [Node] interface.
+// Returns a string that uniquely identifies the node in the plan.
+func (j *Join) ID() string {
+	if j.id == "" {
+		return fmt.Sprintf("%p", j)
+	}
+	return j.id
+}
+
+// Type implements the [Node] interface.
+// Returns the type of the node.
+func (*Join) Type() NodeType {
+	return NodeTypeJoin
+}
+
+// Accept implements the [Node] interface.
+// Dispatches itself to the provided [Visitor] v
+func (j *Join) Accept(v Visitor) error {
+	return v.VisitJoin(j)
+}
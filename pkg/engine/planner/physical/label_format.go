@@ -0,0 +1,52 @@
+package physical
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// LabelFormat represents a label_format stage in the physical plan. It renames
+// columns or sets columns of type [types.ColumnTypeParsed] to the result of a
+// text template for each input row.
+type LabelFormat struct {
+	id string
+
+	Formats []LabelFormatExpression // Formatted columns.
+}
+
+// LabelFormatExpression describes a single column that is formatted by a
+// [LabelFormat] node.
+type LabelFormatExpression struct {
+	Name   string // Name of the formatted column.
+	Value  string // Name of the renamed column if Rename is true, otherwise the text template of the value.
+	Rename bool   // Rename the column given by Value.
+}
+
+// String returns the string representation of the LabelFormatExpression.
+func (e LabelFormatExpression) String() string {
+	if e.Rename {
+		return fmt.Sprintf("%s=%s", e.Name, e.Value)
+	}
+	return fmt.Sprintf("%s=%s", e.Name, strconv.Quote(e.Value))
+}
+
+// ID implements the [Node] interface.
+// Returns a string that uniquely identifies the node in the plan.
+func (f *LabelFormat) ID() string {
+	if f.id == "" {
+		return fmt.Sprintf("%p", f)
+	}
+	return f.id
+}
+
+// Type implements the [Node] interface.
+// Returns the type of the node.
+func (*LabelFormat) Type() NodeType {
+	return NodeTypeLabelFormat
+}
+
+// Accept implements the [Node] interface.
+// Dispatches itself to the provided [Visitor] v
+func (f *LabelFormat) Accept(v Visitor) error {
+	return v.VisitLabelFormat(f)
+}
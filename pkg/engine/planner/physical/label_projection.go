@@ -0,0 +1,54 @@
+package physical
+
+import (
+	"fmt"
+
+	"github.com/prometheus/prometheus/model/labels"
+)
+
+// LabelProjection represents a keep or drop stage in the physical plan. It
+// removes label, metadata and parsed columns from each input row. Since
+// matchers select columns based on their values, removed columns are set to
+// NULL per row instead of being removed from the schema.
+type LabelProjection struct {
+	id string
+
+	Keep   bool           // Keep the matching columns instead of dropping them.
+	Labels []LabelMatcher // Matchers of the projected columns.
+}
+
+// LabelMatcher selects a column of a [LabelProjection] either by its name, or
+// by its name and value if Matcher is set.
+type LabelMatcher struct {
+	Name    string
+	Matcher *labels.Matcher
+}
+
+// String returns the string representation of the LabelMatcher.
+func (m LabelMatcher) String() string {
+	if m.Matcher != nil {
+		return m.Matcher.String()
+	}
+	return m.Name
+}
+
+// ID implements the [Node] interface.
+// Returns a string that uniquely identifies the node in the plan.
+func (f *LabelProjection) ID() string {
+	if f.id == "" {
+		return fmt.Sprintf("%p", f)
+	}
+	return f.id
+}
+
+// Type implements the [Node] interface.
+// Returns the type of the node.
+func (*LabelProjection) Type() NodeType {
+	return NodeTypeLabelProjection
+}
+
+// Accept implements the [Node] interface.
+// Dispatches itself to the provided [Visitor] v
+func (f *LabelProjection) Accept(v Visitor) error {
+	return v.VisitLabelProjection(f)
+}
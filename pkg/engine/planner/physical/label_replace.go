@@ -0,0 +1,38 @@
+package physical
+
+import (
+	"fmt"
+)
+
+// LabelReplace represents a label_replace function in the physical plan. It
+// sets the column Dst of each input row whose column Src matches Regex to the
+// expansion of Replacement.
+type LabelReplace struct {
+	id string
+
+	Dst         string // Name of the column to set.
+	Src         string // Name of the column that is matched against Regex.
+	Regex       string // Regular expression that must match the whole value of Src.
+	Replacement string // Replacement template, which may reference capture groups of Regex.
+}
+
+// ID implements the [Node] interface.
+// Returns a string that uniquely identifies the node in the plan.
+func (r *LabelReplace) ID() string {
+	if r.id == "" {
+		return fmt.Sprintf("%p", r)
+	}
+	return r.id
+}
+
+// Type implements the [Node] interface.
+// Returns the type of the node.
+func (*LabelReplace) Type() NodeType {
+	return NodeTypeLabelReplace
+}
+
+// Accept implements the [Node] interface.
+// Dispatches itself to the provided [Visitor] v
+func (r *LabelReplace) Accept(v Visitor) error {
+	return v.VisitLabelReplace(r)
+}
@@ -0,0 +1,37 @@
+package physical
+
+import (
+	"fmt"
+)
+
+// LineFormat represents a line_format stage in the physical plan. It replaces
+// the log line of each input row with the result of a text template. Rows for
+// which the template fails to execute keep their log line and have the
+// __error__ and __error_details__ columns set, the same as the line_format
+// stage of the old engine.
+type LineFormat struct {
+	id string
+
+	Template string // Text template of the new log line.
+}
+
+// ID implements the [Node] interface.
+// Returns a string that uniquely identifies the node in the plan.
+func (f *LineFormat) ID() string {
+	if f.id == "" {
+		return fmt.Sprintf("%p", f)
+	}
+	return f.id
+}
+
+// Type implements the [Node] interface.
+// Returns the type of the node.
+func (*LineFormat) Type() NodeType {
+	return NodeTypeLineFormat
+}
+
+// Accept implements the [Node] interface.
+// Dispatches itself to the provided [Visitor] v
+func (f *LineFormat) Accept(v Visitor) error {
+	return v.VisitLineFormat(f)
+}
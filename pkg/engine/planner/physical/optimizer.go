@@ -1,7 +1,9 @@
 package physical
 
 import (
+	"cmp"
 	"slices"
+	"time"
 
 	"github.com/grafana/loki/v3/pkg/engine/internal/types"
 )
@@ -63,6 +65,16 @@ func (r *predicatePushdown) applyPredicatePushdown(node Node, predicate Expressi
 			return true
 		}
 		return false
+	case *Parse:
+		// The unpack parser may replace the log line, so predicates must not
+		// be moved below it.
+		if node.Parser == types.ParserTypeUnpack {
+			return false
+		}
+	case *LineFormat, *LabelFormat, *LabelProjection:
+		// Format and projection stages change the log line and the columns of
+		// the rows, so predicates must not be moved below them.
+		return false
 	}
 	for _, child := range r.plan.Children(node) {
 		if ok := r.applyPredicatePushdown(child, predicate); !ok {
@@ -107,6 +119,10 @@ func (r *limitPushdown) applyLimitPushdown(node Node, limit uint32) bool {
 		// In case the scan node is reachable from multiple different limit nodes, we need to take the largest limit.
 		node.Limit = max(node.Limit, limit)
 		return true
+	case *Filter:
+		// Filters that have not been pushed down to the scan nodes can drop
+		// rows, so the scan nodes must not stop reading at the limit.
+		return false
 	}
 	for _, child := range r.plan.Children(node) {
 		if ok := r.applyLimitPushdown(child, limit); !ok {
@@ -127,11 +143,20 @@ type groupByPushdown struct {
 func (r *groupByPushdown) apply(node Node) bool {
 	switch node := node.(type) {
 	case *VectorAggregation:
-		if node.Operation != types.VectorAggregationTypeSum {
+		// Only the grouping labels of a sum by (...) can be pushed down.
+		// Grouping without labels or over all series requires the series labels
+		// of the range aggregation.
+		if node.Operation != types.VectorAggregationTypeSum || node.Without || len(node.GroupBy) == 0 {
 			return false
 		}
 
-		return r.applyGroupByPushdown(node, node.GroupBy)
+		anyChanged := false
+		for _, child := range r.plan.Children(node) {
+			if changed := r.applyGroupByPushdown(child, node.GroupBy); changed {
+				anyChanged = true
+			}
+		}
+		return anyChanged
 	}
 
 	return false
@@ -139,8 +164,27 @@ func (r *groupByPushdown) apply(node Node) bool {
 
 func (r *groupByPushdown) applyGroupByPushdown(node Node, groupBy []ColumnExpression) bool {
 	switch node := node.(type) {
+	case *VectorAggregation:
+		// Nested vector aggregations define the labels of their own output.
+		return false
+	case *Join:
+		// The operands of a join are matched by their labels, so their series
+		// must not be aggregated.
+		return false
+	case *LabelReplace, *Absent:
+		// The labels of the output are not the labels of the input series.
+		return false
 	case *RangeAggregation:
-		if node.Operation != types.RangeAggregationTypeCount {
+		// Pushing down the grouping labels of a sum is only correct for range aggregations
+		// where the sum of the partitioned results equals the result of the combined partitions.
+		if !isAdditiveRangeAggregation(node.Operation) {
+			return false
+		}
+
+		// Range aggregations that already define their own partitioning, such as
+		// sum_over_time(...) by (label), produce series with only these labels.
+		// Any additional partitioning would change the result.
+		if len(node.PartitionBy) > 0 {
 			return false
 		}
 
@@ -177,6 +221,22 @@ func (r *groupByPushdown) applyGroupByPushdown(node Node, groupBy []ColumnExpres
 	return anyChanged
 }
 
+// isAdditiveRangeAggregation returns true if the result of the range
+// aggregation over the union of two sets of entries equals the sum of the
+// results of each set.
+func isAdditiveRangeAggregation(op types.RangeAggregationType) bool {
+	switch op {
+	case types.RangeAggregationTypeCount,
+		types.RangeAggregationTypeRate,
+		types.RangeAggregationTypeBytes,
+		types.RangeAggregationTypeBytesRate,
+		types.RangeAggregationTypeSum:
+		return true
+	default:
+		return false
+	}
+}
+
 var _ rule = (*groupByPushdown)(nil)
 
 // projectionPushdown is a rule that pushes down column projections.
@@ -189,14 +249,23 @@ type projectionPushdown struct {
 func (r *projectionPushdown) apply(node Node) bool {
 	switch node := node.(type) {
 	case *RangeAggregation:
-		if len(node.PartitionBy) == 0 || node.Operation != types.RangeAggregationTypeCount {
+		if len(node.PartitionBy) == 0 {
 			return false
 		}
 
-		projections := make([]ColumnExpression, len(node.PartitionBy)+1)
+		projections := make([]ColumnExpression, len(node.PartitionBy), len(node.PartitionBy)+3)
 		copy(projections, node.PartitionBy)
 		// Always project timestamp column
-		projections[len(node.PartitionBy)] = &ColumnExpr{Ref: types.ColumnRef{Column: types.ColumnNameBuiltinTimestamp, Type: types.ColumnTypeBuiltin}}
+		projections = append(projections, &ColumnExpr{Ref: types.ColumnRef{Column: types.ColumnNameBuiltinTimestamp, Type: types.ColumnTypeBuiltin}})
+
+		switch {
+		case node.Unwrap != nil:
+			// Project the column that holds the sample values
+			projections = append(projections, node.Unwrap.Column)
+		case node.Operation == types.RangeAggregationTypeBytes || node.Operation == types.RangeAggregationTypeBytesRate:
+			// Project the message column to compute the size of the log lines
+			projections = append(projections, &ColumnExpr{Ref: types.ColumnRef{Column: types.ColumnNameBuiltinMessage, Type: types.ColumnTypeBuiltin}})
+		}
 
 		return r.applyProjectionPushdown(node, projections)
 	}
@@ -231,6 +300,11 @@ func (r *projectionPushdown) applyProjectionPushdown(node Node, projections []Co
 			}
 		}
 		return changed
+	case *Parse, *LineFormat, *LabelFormat, *LabelProjection:
+		// Parsers, format and projection stages require the log line and all
+		// labels of the input rows. Partition columns may also be extracted or
+		// formatted by the stages themselves.
+		return false
 	}
 
 	anyChanged := false
@@ -244,6 +318,152 @@ func (r *projectionPushdown) applyProjectionPushdown(node Node, projections []Co
 
 var _ rule = (*projectionPushdown)(nil)
 
+// predicateOrdering is a rule that orders the predicates of scan and filter
+// nodes by their estimated selectivity, so that the predicates that match the
+// fewest rows are evaluated first.
+type predicateOrdering struct {
+	plan  *Plan
+	stats statistics
+}
+
+// apply implements rule.
+func (r *predicateOrdering) apply(node Node) bool {
+	switch node := node.(type) {
+	case *DataObjScan:
+		return orderBySelectivity(node.Predicates, r.stats.of(node))
+	case *Filter:
+		return orderBySelectivity(node.Predicates, nil)
+	}
+	return false
+}
+
+// orderBySelectivity sorts the predicates in place by their estimated
+// selectivity. Predicates with the same selectivity keep their order. It
+// returns whether the order has changed.
+func orderBySelectivity(predicates []Expression, stats *SectionStatistics) bool {
+	if len(predicates) < 2 {
+		return false
+	}
+
+	selectivities := make(map[Expression]float64, len(predicates))
+	for _, predicate := range predicates {
+		selectivities[predicate] = selectivity(predicate, stats)
+	}
+	sorted := slices.Clone(predicates)
+	slices.SortStableFunc(sorted, func(a, b Expression) int {
+		return cmp.Compare(selectivities[a], selectivities[b])
+	})
+	if slices.Equal(sorted, predicates) {
+		return false
+	}
+	copy(predicates, sorted)
+	return true
+}
+
+var _ rule = (*predicateOrdering)(nil)
+
+// scanOrdering is a rule that orders the inputs of the SortMerge nodes below
+// a Limit node by the time range of the sections they scan. For descending
+// order the inputs with the newest rows come first, otherwise the inputs
+// with the oldest rows. Inputs that are likely to return the rows of the
+// result are then started first, and are cut into the first fragment of a
+// distributed query. The rule does not remove any inputs.
+type scanOrdering struct {
+	plan  *Plan
+	stats statistics
+}
+
+// apply implements rule.
+func (r *scanOrdering) apply(node Node) bool {
+	switch node.(type) {
+	case *Limit:
+		return r.orderInputs(node)
+	}
+	return false
+}
+
+func (r *scanOrdering) orderInputs(node Node) bool {
+	changed := false
+	for _, child := range r.plan.Children(node) {
+		if r.orderInputs(child) {
+			changed = true
+		}
+	}
+
+	merge, ok := node.(*SortMerge)
+	if !ok || merge.Column == nil {
+		return changed
+	}
+	if col, ok := merge.Column.(*ColumnExpr); !ok || col.Ref.Column != types.ColumnNameBuiltinTimestamp {
+		return changed
+	}
+
+	inputs := r.plan.children[merge]
+	ranges := make(map[Node][2]time.Time, len(inputs))
+	for _, input := range inputs {
+		if start, end, ok := r.timeRange(input); ok {
+			ranges[input] = [2]time.Time{start, end}
+		}
+	}
+
+	sorted := slices.Clone(inputs)
+	slices.SortStableFunc(sorted, func(a, b Node) int {
+		rangeA, okA := ranges[a]
+		rangeB, okB := ranges[b]
+		switch {
+		case !okA || !okB:
+			// Inputs with unknown time range are ordered last.
+			return cmp.Compare(boolToInt(!okA), boolToInt(!okB))
+		case merge.Order == DESC:
+			return rangeB[1].Compare(rangeA[1])
+		default:
+			return rangeA[0].Compare(rangeB[0])
+		}
+	})
+	if slices.Equal(sorted, inputs) {
+		return changed
+	}
+	r.plan.children[merge] = sorted
+	return true
+}
+
+// timeRange returns the earliest start and the latest end of the time
+// ranges of the sections scanned by the node and its descendants. The
+// boolean is false if no time range is known.
+func (r *scanOrdering) timeRange(node Node) (start, end time.Time, ok bool) {
+	if scan, isScan := node.(*DataObjScan); isScan {
+		stats := r.stats.of(scan)
+		if stats == nil || stats.Start.IsZero() || stats.End.IsZero() {
+			return time.Time{}, time.Time{}, false
+		}
+		return stats.Start, stats.End, true
+	}
+
+	for _, child := range r.plan.Children(node) {
+		childStart, childEnd, childOk := r.timeRange(child)
+		if !childOk {
+			continue
+		}
+		if !ok || childStart.Before(start) {
+			start = childStart
+		}
+		if !ok || childEnd.After(end) {
+			end = childEnd
+		}
+		ok = true
+	}
+	return start, end, ok
+}
+
+func boolToInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+var _ rule = (*scanOrdering)(nil)
+
 // optimization represents a single optimization pass and can hold multiple rules.
 type optimization struct {
 	plan  *Plan
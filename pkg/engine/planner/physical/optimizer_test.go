@@ -261,6 +261,80 @@ func TestOptimizer(t *testing.T) {
 		require.Equal(t, expected, actual)
 	})
 
+	t.Run("groupby pushdown is skipped for non-additive range aggregations", func(t *testing.T) {
+		groupBy := []ColumnExpression{
+			&ColumnExpr{Ref: types.ColumnRef{Column: "service", Type: types.ColumnTypeLabel}},
+		}
+
+		// generate plan for sum by(service) (max_over_time{...} | unwrap latency [])
+		plan := &Plan{}
+		{
+			scan1 := plan.addNode(&DataObjScan{id: "scan1"})
+			rangeAgg := plan.addNode(&RangeAggregation{
+				id:        "max_over_time",
+				Operation: types.RangeAggregationTypeMax,
+				Unwrap: &Unwrap{
+					Column:     &ColumnExpr{Ref: types.ColumnRef{Column: "latency", Type: types.ColumnTypeAmbiguous}},
+					Conversion: types.ConversionTypeFloat,
+				},
+			})
+			vectorAgg := plan.addNode(&VectorAggregation{
+				id:        "sum_of",
+				Operation: types.VectorAggregationTypeSum,
+				GroupBy:   groupBy,
+			})
+
+			_ = plan.addEdge(Edge{Parent: vectorAgg, Child: rangeAgg})
+			_ = plan.addEdge(Edge{Parent: rangeAgg, Child: scan1})
+		}
+
+		expected := PrintAsTree(plan)
+
+		optimizations := []*optimization{
+			newOptimization("group by pushdown", plan).withRules(
+				&groupByPushdown{plan: plan},
+			),
+		}
+		o := newOptimizer(plan, optimizations)
+		o.optimize(plan.Roots()[0])
+
+		require.Equal(t, expected, PrintAsTree(plan))
+	})
+
+	t.Run("groupby pushdown is skipped for grouping without labels", func(t *testing.T) {
+		plan := &Plan{}
+		{
+			scan1 := plan.addNode(&DataObjScan{id: "scan1"})
+			rangeAgg := plan.addNode(&RangeAggregation{
+				id:        "count_over_time",
+				Operation: types.RangeAggregationTypeCount,
+			})
+			vectorAgg := plan.addNode(&VectorAggregation{
+				id:        "sum_of",
+				Operation: types.VectorAggregationTypeSum,
+				GroupBy: []ColumnExpression{
+					&ColumnExpr{Ref: types.ColumnRef{Column: "level", Type: types.ColumnTypeAmbiguous}},
+				},
+				Without: true,
+			})
+
+			_ = plan.addEdge(Edge{Parent: vectorAgg, Child: rangeAgg})
+			_ = plan.addEdge(Edge{Parent: rangeAgg, Child: scan1})
+		}
+
+		expected := PrintAsTree(plan)
+
+		optimizations := []*optimization{
+			newOptimization("group by pushdown", plan).withRules(
+				&groupByPushdown{plan: plan},
+			),
+		}
+		o := newOptimizer(plan, optimizations)
+		o.optimize(plan.Roots()[0])
+
+		require.Equal(t, expected, PrintAsTree(plan))
+	})
+
 	t.Run("projection pushdown", func(t *testing.T) {
 		partitionBy := []ColumnExpression{
 			&ColumnExpr{Ref: types.ColumnRef{Column: "service", Type: types.ColumnTypeLabel}},
@@ -320,4 +394,128 @@ func TestOptimizer(t *testing.T) {
 		expected := PrintAsTree(expectedPlan)
 		require.Equal(t, expected, actual)
 	})
+
+	t.Run("predicate pushdown is stopped by unpack parser", func(t *testing.T) {
+		lineFilter := &BinaryExpr{
+			Left:  newColumnExpr(types.ColumnNameBuiltinMessage, types.ColumnTypeBuiltin),
+			Right: NewLiteral("timeout"),
+			Op:    types.BinaryOpMatchSubstr,
+		}
+
+		plan := &Plan{}
+		{
+			scan1 := plan.addNode(&DataObjScan{id: "scan1"})
+			parse := plan.addNode(&Parse{id: "parse1", Parser: types.ParserTypeUnpack})
+			filter := plan.addNode(&Filter{id: "filter1", Predicates: []Expression{lineFilter}})
+
+			_ = plan.addEdge(Edge{Parent: filter, Child: parse})
+			_ = plan.addEdge(Edge{Parent: parse, Child: scan1})
+		}
+
+		expected := PrintAsTree(plan)
+
+		optimizations := []*optimization{
+			newOptimization("predicate pushdown", plan).withRules(
+				&predicatePushdown{plan: plan},
+				&removeNoopFilter{plan: plan},
+			),
+		}
+		o := newOptimizer(plan, optimizations)
+		o.optimize(plan.Roots()[0])
+
+		require.Equal(t, expected, PrintAsTree(plan))
+	})
+
+	t.Run("predicate pushdown is stopped by line_format", func(t *testing.T) {
+		lineFilter := &BinaryExpr{
+			Left:  newColumnExpr(types.ColumnNameBuiltinMessage, types.ColumnTypeBuiltin),
+			Right: NewLiteral("timeout"),
+			Op:    types.BinaryOpMatchSubstr,
+		}
+
+		plan := &Plan{}
+		{
+			scan1 := plan.addNode(&DataObjScan{id: "scan1"})
+			format := plan.addNode(&LineFormat{id: "format1", Template: "{{.msg}}"})
+			filter := plan.addNode(&Filter{id: "filter1", Predicates: []Expression{lineFilter}})
+
+			_ = plan.addEdge(Edge{Parent: filter, Child: format})
+			_ = plan.addEdge(Edge{Parent: format, Child: scan1})
+		}
+
+		expected := PrintAsTree(plan)
+
+		optimizations := []*optimization{
+			newOptimization("predicate pushdown", plan).withRules(
+				&predicatePushdown{plan: plan},
+				&removeNoopFilter{plan: plan},
+			),
+		}
+		o := newOptimizer(plan, optimizations)
+		o.optimize(plan.Roots()[0])
+
+		require.Equal(t, expected, PrintAsTree(plan))
+	})
+
+	t.Run("limit pushdown is stopped by filter", func(t *testing.T) {
+		plan := &Plan{}
+		{
+			scan1 := plan.addNode(&DataObjScan{id: "scan1"})
+			parse := plan.addNode(&Parse{id: "parse1", Parser: types.ParserTypeJSON})
+			filter := plan.addNode(&Filter{id: "filter1", Predicates: []Expression{
+				&BinaryExpr{
+					Left:  newColumnExpr("level", types.ColumnTypeAmbiguous),
+					Right: NewLiteral("error"),
+					Op:    types.BinaryOpEq,
+				},
+			}})
+			limit := plan.addNode(&Limit{id: "limit1", Fetch: 100})
+
+			_ = plan.addEdge(Edge{Parent: limit, Child: filter})
+			_ = plan.addEdge(Edge{Parent: filter, Child: parse})
+			_ = plan.addEdge(Edge{Parent: parse, Child: scan1})
+		}
+
+		expected := PrintAsTree(plan)
+
+		optimizations := []*optimization{
+			newOptimization("limit pushdown", plan).withRules(
+				&limitPushdown{plan: plan},
+			),
+		}
+		o := newOptimizer(plan, optimizations)
+		o.optimize(plan.Roots()[0])
+
+		require.Equal(t, expected, PrintAsTree(plan))
+	})
+
+	t.Run("projection pushdown is stopped by parser", func(t *testing.T) {
+		plan := &Plan{}
+		{
+			scan1 := plan.addNode(&DataObjScan{id: "scan1"})
+			parse := plan.addNode(&Parse{id: "parse1", Parser: types.ParserTypeLogfmt})
+			rangeAgg := plan.addNode(&RangeAggregation{
+				id:        "range1",
+				Operation: types.RangeAggregationTypeCount,
+				PartitionBy: []ColumnExpression{
+					&ColumnExpr{Ref: types.ColumnRef{Column: "level", Type: types.ColumnTypeAmbiguous}},
+				},
+			})
+
+			_ = plan.addEdge(Edge{Parent: rangeAgg, Child: parse})
+			_ = plan.addEdge(Edge{Parent: parse, Child: scan1})
+		}
+
+		expected := PrintAsTree(plan)
+
+		optimizations := []*optimization{
+			newOptimization("projection pushdown", plan).withRules(
+				&projectionPushdown{plan: plan},
+			),
+		}
+		o := newOptimizer(plan, optimizations)
+		o.optimize(plan.Roots()[0])
+
+		require.Equal(t, expected, PrintAsTree(plan))
+	})
 }
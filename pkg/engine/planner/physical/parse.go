@@ -0,0 +1,56 @@
+package physical
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/grafana/loki/v3/pkg/engine/internal/types"
+)
+
+// Parse represents a parser stage in the physical plan. It extracts columns
+// of type [types.ColumnTypeParsed] from the log line of each input row.
+// Rows that fail to parse have the __error__ and __error_details__ columns
+// set, the same as the parsers of the old engine.
+type Parse struct {
+	id string
+
+	Parser types.ParserType // Parser used to extract columns.
+
+	Pattern     string            // Expression of the regexp and pattern parsers.
+	Strict      bool              // Fail on the first malformed pair of the logfmt parser.
+	KeepEmpty   bool              // Keep empty values of the logfmt parser.
+	Expressions []ParseExpression // Restricts the extracted columns of the json and logfmt parsers.
+}
+
+// ParseExpression describes a single column that is extracted by a [Parse]
+// node.
+type ParseExpression struct {
+	Name string // Name of the extracted column.
+	Path string // Path of the value in the log line.
+}
+
+// String returns the string representation of the ParseExpression.
+func (e ParseExpression) String() string {
+	return fmt.Sprintf("%s=%s", e.Name, strconv.Quote(e.Path))
+}
+
+// ID implements the [Node] interface.
+// Returns a string that uniquely identifies the node in the plan.
+func (p *Parse) ID() string {
+	if p.id == "" {
+		return fmt.Sprintf("%p", p)
+	}
+	return p.id
+}
+
+// Type implements the [Node] interface.
+// Returns the type of the node.
+func (*Parse) Type() NodeType {
+	return NodeTypeParse
+}
+
+// Accept implements the [Node] interface.
+// Dispatches itself to the provided [Visitor] v
+func (p *Parse) Accept(v Visitor) error {
+	return v.VisitParse(p)
+}
@@ -16,6 +16,15 @@ const (
 	NodeTypeLimit
 	NodeTypeRangeAggreation
 	NodeTypeVectorAggregation
+	NodeTypeParse
+	NodeTypeLineFormat
+	NodeTypeLabelFormat
+	NodeTypeLabelProjection
+	NodeTypeJoin
+	NodeTypeLabelReplace
+	NodeTypeVector
+	NodeTypeAbsent
+	NodeTypeExchange
 )
 
 func (t NodeType) String() string {
@@ -34,6 +43,24 @@ func (t NodeType) String() string {
 		return "RangeAggregation"
 	case NodeTypeVectorAggregation:
 		return "VectorAggregation"
+	case NodeTypeParse:
+		return "Parse"
+	case NodeTypeLineFormat:
+		return "LineFormat"
+	case NodeTypeLabelFormat:
+		return "LabelFormat"
+	case NodeTypeLabelProjection:
+		return "LabelProjection"
+	case NodeTypeJoin:
+		return "Join"
+	case NodeTypeLabelReplace:
+		return "LabelReplace"
+	case NodeTypeVector:
+		return "Vector"
+	case NodeTypeAbsent:
+		return "Absent"
+	case NodeTypeExchange:
+		return "Exchange"
 	default:
 		return "Undefined"
 	}
@@ -65,6 +92,15 @@ var _ Node = (*Projection)(nil)
 var _ Node = (*Limit)(nil)
 var _ Node = (*Filter)(nil)
 var _ Node = (*RangeAggregation)(nil)
+var _ Node = (*Parse)(nil)
+var _ Node = (*LineFormat)(nil)
+var _ Node = (*LabelFormat)(nil)
+var _ Node = (*LabelProjection)(nil)
+var _ Node = (*Join)(nil)
+var _ Node = (*LabelReplace)(nil)
+var _ Node = (*Vector)(nil)
+var _ Node = (*Absent)(nil)
+var _ Node = (*Exchange)(nil)
 
 func (*DataObjScan) isNode()       {}
 func (*SortMerge) isNode()         {}
@@ -73,6 +109,15 @@ func (*Limit) isNode()             {}
 func (*Filter) isNode()            {}
 func (*RangeAggregation) isNode()  {}
 func (*VectorAggregation) isNode() {}
+func (*Parse) isNode()             {}
+func (*LineFormat) isNode()        {}
+func (*LabelFormat) isNode()       {}
+func (*LabelProjection) isNode()   {}
+func (*Join) isNode()              {}
+func (*LabelReplace) isNode()      {}
+func (*Vector) isNode()            {}
+func (*Absent) isNode()            {}
+func (*Exchange) isNode()          {}
 
 // Edge is a directed connection (parent-child relation) between a two nodes.
 type Edge struct {
@@ -144,8 +189,12 @@ type Plan struct {
 	nodes nodeSet
 	// parents maps each node to a set of its parent nodes in the execution graph
 	parents map[Node]nodeSet
-	// children maps each node to a set of its child nodes in the execution graph
-	children map[Node]nodeSet
+	// children maps each node to its child nodes in the execution graph, in
+	// the order in which the edges have been added
+	children map[Node][]Node
+	// costs maps nodes to their estimated costs, if the costs of the plan
+	// have been estimated
+	costs map[Node]Cost
 }
 
 func (p *Plan) init() {
@@ -159,7 +208,7 @@ func (p *Plan) init() {
 		p.parents = make(map[Node]nodeSet)
 	}
 	if p.children == nil {
-		p.children = make(map[Node]nodeSet)
+		p.children = make(map[Node][]Node)
 	}
 }
 
@@ -180,7 +229,7 @@ func (p *Plan) addNode(n Node) Node {
 		p.parents[n] = make(nodeSet)
 	}
 	if _, ok := p.children[n]; !ok {
-		p.children[n] = make(nodeSet)
+		p.children[n] = nil
 	}
 	return n
 }
@@ -190,7 +239,8 @@ func (p *Plan) addNode(n Node) Node {
 // e.Parent becomes a parent of e.Child. Both nodes must already exist
 // in the plan. Returns an error if either node is nil or doesn't exist
 // in the plan.
-// The order of addition of edges is not preserved.
+// The children of a node are kept in the order in which the edges have been
+// added, since nodes such as [Join] depend on the order of their inputs.
 func (p *Plan) addEdge(e Edge) error {
 	if e.Parent == nil || e.Child == nil {
 		return fmt.Errorf("parent and child nodes must not be nil")
@@ -202,34 +252,59 @@ func (p *Plan) addEdge(e Edge) error {
 		return fmt.Errorf("node %s does not exist in graph", e.Child.ID())
 	}
 
-	p.children[e.Parent].add(e.Child)
+	if !slices.Contains(p.children[e.Parent], e.Child) {
+		p.children[e.Parent] = append(p.children[e.Parent], e.Child)
+	}
 	p.parents[e.Child].add(e.Parent)
 	return nil
 }
 
 // eliminateNode removes a node from the plan and reconnects its parents to its children.
 // This maintains the graph's connectivity by creating direct edges from each parent
-// to each child of the removed node. The function also cleans up all references to
-// the node in the plan's internal data structures.
+// to each child of the removed node. The children of the removed node take its
+// position among the children of each parent. The function also cleans up all
+// references to the node in the plan's internal data structures.
 func (p *Plan) eliminateNode(node Node) {
-	for _, parent := range p.Parents(node) {
-		for _, child := range p.Children(node) {
-			_ = p.addEdge(Edge{Parent: parent, Child: child})
-		}
-	}
+	children := p.Children(node)
 
 	for _, parent := range p.Parents(node) {
-		p.children[parent].remove(node)
+		siblings := p.children[parent]
+		idx := slices.Index(siblings, node)
+
+		replacement := make([]Node, 0, len(children))
+		for _, child := range children {
+			if !slices.Contains(siblings, child) {
+				replacement = append(replacement, child)
+			}
+			p.parents[child].add(parent)
+		}
+		p.children[parent] = slices.Replace(siblings, idx, idx+1, replacement...)
 		p.parents[node].remove(parent)
 	}
 
-	for _, child := range p.Children(node) {
+	for _, child := range children {
 		p.parents[child].remove(node)
-		p.children[node].remove(child)
 	}
+	delete(p.children, node)
+	delete(p.parents, node)
 
 	p.nodes.remove(node)
 	delete(p.nodesByID, node.ID())
+	delete(p.costs, node)
+}
+
+// Cost returns the estimated cost of the node. The boolean is false if the
+// cost of the node has not been estimated.
+func (p *Plan) Cost(n Node) (Cost, bool) {
+	cost, ok := p.costs[n]
+	return cost, ok
+}
+
+func (p *Plan) setCost(n Node, cost Cost) {
+	if p.costs == nil {
+		p.costs = make(map[Node]Cost)
+	}
+	p.costs[n] = cost
 }
 
 // Len returns the number of nodes in the graph
@@ -250,12 +325,13 @@ func (p *Plan) Parents(n Node) []Node {
 	return p.parents[n].sorted()
 }
 
-// Children returns all child nodes of the given node
+// Children returns all child nodes of the given node in the order in which
+// their edges have been added.
 func (p *Plan) Children(n Node) []Node {
 	if _, ok := p.children[n]; !ok {
 		return nil
 	}
-	return p.children[n].sorted()
+	return slices.Clone(p.children[n])
 }
 
 // Roots returns all nodes that have no parents
@@ -76,6 +76,25 @@ func TestPlan(t *testing.T) {
 		require.Len(t, p.Children(n), 2) // both scan nodes
 	})
 
+	t.Run("children are returned in the order of their edges", func(t *testing.T) {
+		p := &Plan{}
+		join := p.addNode(&Join{id: "join"})
+		right := p.addNode(&RangeAggregation{id: "a"})
+		left := p.addNode(&Filter{id: "b"})
+		scan := p.addNode(&DataObjScan{id: "scan"})
+		_ = p.addEdge(Edge{Parent: join, Child: left})
+		_ = p.addEdge(Edge{Parent: join, Child: right})
+		_ = p.addEdge(Edge{Parent: left, Child: scan})
+
+		require.Equal(t, []Node{left, right}, p.Children(join))
+
+		// The children of an eliminated node take its position.
+		p.eliminateNode(left)
+		require.Equal(t, []Node{scan, right}, p.Children(join))
+		require.Equal(t, []Node{join}, p.Parents(scan))
+		require.Nil(t, p.NodeByID("b"))
+	})
+
 	t.Run("graph can be walked in pre-order and post-order", func(t *testing.T) {
 		// Graph can be inspected as SVG under the following URL:
 		// https://dreampuf.github.io/GraphvizOnline/?engine=dot#digraph%20G%20%7B%0A%20%20%20%20limit1%20-%3Emerge1%3B%0A%20%20%20%20merge1%20-%3E%20filter1%3B%0A%20%20%20%20merge1%20-%3E%20merge2%3B%0A%20%20%20%20merge1%20-%3E%20proj3%3B%0A%20%20%20%20filter1%20-%3E%20proj1%3B%0A%20%20%20%20merge2%20-%3E%20proj1%3B%0A%20%20%20%20merge2%20-%3E%20proj2%3B%0A%20%20%20%20proj1%20-%3E%20scan1%3B%0A%20%20%20%20proj2%20-%3E%20scan1%3B%0A%20%20%20%20proj3%20-%3E%20scan1%3B%0A%7D
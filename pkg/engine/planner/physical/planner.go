@@ -70,6 +70,10 @@ func (pc *Context) GetResolveTimeRange() (from, through time.Time) {
 //  2. Pushdown
 //     a) Push down the limit of the Limit node to the DataObjScan nodes.
 //     b) Push down the predicate from the Filter node to the DataObjScan nodes.
+//
+// If the catalog is a [StatisticsCatalog], the predicates and scans of the
+// optimized plan are ordered by their estimated costs, and the costs are
+// stored in the plan.
 type Planner struct {
 	context *Context
 	catalog Catalog
@@ -139,6 +143,14 @@ func (p *Planner) process(inst logical.Value, ctx *Context) ([]Node, error) {
 		return p.processMakeTable(inst, ctx)
 	case *logical.Select:
 		return p.processSelect(inst, ctx)
+	case *logical.Parse:
+		return p.processParse(inst, ctx)
+	case *logical.LineFormat:
+		return p.processLineFormat(inst, ctx)
+	case *logical.LabelFormat:
+		return p.processLabelFormat(inst, ctx)
+	case *logical.LabelProjection:
+		return p.processLabelProjection(inst, ctx)
 	case *logical.Sort:
 		return p.processSort(inst, ctx)
 	case *logical.Limit:
@@ -147,6 +159,14 @@ func (p *Planner) process(inst logical.Value, ctx *Context) ([]Node, error) {
 		return p.processRangeAggregation(inst, ctx)
 	case *logical.VectorAggregation:
 		return p.processVectorAggregation(inst, ctx)
+	case *logical.Join:
+		return p.processJoin(inst, ctx)
+	case *logical.LabelReplace:
+		return p.processLabelReplace(inst, ctx)
+	case *logical.Vector:
+		return p.processVector(inst, ctx)
+	case *logical.Absent:
+		return p.processAbsent(inst, ctx)
 	}
 	return nil, nil
 }
@@ -215,6 +235,88 @@ func (p *Planner) processSelect(lp *logical.Select, ctx *Context) ([]Node, error
 	return []Node{node}, nil
 }
 
+// Convert [logical.Parse] into one [Parse] node.
+func (p *Planner) processParse(lp *logical.Parse, ctx *Context) ([]Node, error) {
+	node := &Parse{
+		Parser:    lp.Parser,
+		Pattern:   lp.Pattern,
+		Strict:    lp.Strict,
+		KeepEmpty: lp.KeepEmpty,
+	}
+	for _, expr := range lp.Expressions {
+		node.Expressions = append(node.Expressions, ParseExpression{Name: expr.Name, Path: expr.Path})
+	}
+	p.plan.addNode(node)
+	children, err := p.process(lp.Table, ctx)
+	if err != nil {
+		return nil, err
+	}
+	for i := range children {
+		if err := p.plan.addEdge(Edge{Parent: node, Child: children[i]}); err != nil {
+			return nil, err
+		}
+	}
+	return []Node{node}, nil
+}
+
+// Convert [logical.LineFormat] into one [LineFormat] node.
+func (p *Planner) processLineFormat(lp *logical.LineFormat, ctx *Context) ([]Node, error) {
+	node := &LineFormat{
+		Template: lp.Template,
+	}
+	p.plan.addNode(node)
+	children, err := p.process(lp.Table, ctx)
+	if err != nil {
+		return nil, err
+	}
+	for i := range children {
+		if err := p.plan.addEdge(Edge{Parent: node, Child: children[i]}); err != nil {
+			return nil, err
+		}
+	}
+	return []Node{node}, nil
+}
+
+// Convert [logical.LabelFormat] into one [LabelFormat] node.
+func (p *Planner) processLabelFormat(lp *logical.LabelFormat, ctx *Context) ([]Node, error) {
+	node := &LabelFormat{}
+	for _, f := range lp.Formats {
+		node.Formats = append(node.Formats, LabelFormatExpression{Name: f.Name, Value: f.Value, Rename: f.Rename})
+	}
+	p.plan.addNode(node)
+	children, err := p.process(lp.Table, ctx)
+	if err != nil {
+		return nil, err
+	}
+	for i := range children {
+		if err := p.plan.addEdge(Edge{Parent: node, Child: children[i]}); err != nil {
+			return nil, err
+		}
+	}
+	return []Node{node}, nil
+}
+
+// Convert [logical.LabelProjection] into one [LabelProjection] node.
+func (p *Planner) processLabelProjection(lp *logical.LabelProjection, ctx *Context) ([]Node, error) {
+	node := &LabelProjection{
+		Keep: lp.Keep,
+	}
+	for _, m := range lp.Labels {
+		node.Labels = append(node.Labels, LabelMatcher{Name: m.Name, Matcher: m.Matcher})
+	}
+	p.plan.addNode(node)
+	children, err := p.process(lp.Table, ctx)
+	if err != nil {
+		return nil, err
+	}
+	for i := range children {
+		if err := p.plan.addEdge(Edge{Parent: node, Child: children[i]}); err != nil {
+			return nil, err
+		}
+	}
+	return []Node{node}, nil
+}
+
 // Convert [logical.Sort] into one [SortMerge] node.
 func (p *Planner) processSort(lp *logical.Sort, ctx *Context) ([]Node, error) {
 	order := DESC
@@ -266,17 +368,36 @@ func (p *Planner) processRangeAggregation(r *logical.RangeAggregation, ctx *Cont
 		partitionBy[i] = &ColumnExpr{Ref: col.Ref}
 	}
 
+	var unwrap *Unwrap
+	if r.Unwrap != nil {
+		unwrap = &Unwrap{
+			Column:     &ColumnExpr{Ref: r.Unwrap.Column.Ref},
+			Conversion: r.Unwrap.Conversion,
+			DropErrors: r.Unwrap.DropErrors,
+		}
+	}
+
 	node := &RangeAggregation{
 		PartitionBy: partitionBy,
 		Operation:   r.Operation,
+		Parameter:   r.Parameter,
+		Unwrap:      unwrap,
 		Start:       r.Start,
 		End:         r.End,
 		Range:       r.RangeInterval,
 		Step:        r.Step,
+		Offset:      r.Offset,
 	}
 	p.plan.addNode(node)
 
-	children, err := p.process(r.Table, ctx.WithRangeInterval(r.RangeInterval))
+	// The windows of an aggregation with offset end before the time range of
+	// the query, so data objects are resolved for the shifted time range.
+	ctx = ctx.WithRangeInterval(r.RangeInterval)
+	if r.Offset != 0 {
+		ctx = ctx.WithTimeRange(ctx.from.Add(-r.Offset), ctx.through.Add(-r.Offset))
+	}
+
+	children, err := p.process(r.Table, ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -298,7 +419,122 @@ func (p *Planner) processVectorAggregation(lp *logical.VectorAggregation, ctx *C
 
 	node := &VectorAggregation{
 		GroupBy:   groupBy,
+		Without:   lp.Without,
 		Operation: lp.Operation,
+		Parameter: lp.Parameter,
+	}
+	p.plan.addNode(node)
+	children, err := p.process(lp.Table, ctx)
+	if err != nil {
+		return nil, err
+	}
+	for i := range children {
+		if err := p.plan.addEdge(Edge{Parent: node, Child: children[i]}); err != nil {
+			return nil, err
+		}
+	}
+	return []Node{node}, nil
+}
+
+// Convert [logical.Join] into one [Join] node. Each vector operand becomes a
+// child of the node, with the left operand as first child. Scalar operands
+// are stored in the node itself.
+func (p *Planner) processJoin(lp *logical.Join, ctx *Context) ([]Node, error) {
+	node := &Join{
+		Operator:   lp.Op,
+		ReturnBool: lp.ReturnBool,
+	}
+
+	if lp.Matching != nil {
+		node.VectorMatching = &VectorMatching{
+			Cardinality:    lp.Matching.Cardinality,
+			On:             lp.Matching.On,
+			MatchingLabels: make([]ColumnExpression, len(lp.Matching.Labels)),
+			Include:        make([]ColumnExpression, len(lp.Matching.Include)),
+		}
+		for i, col := range lp.Matching.Labels {
+			node.VectorMatching.MatchingLabels[i] = &ColumnExpr{Ref: col.Ref}
+		}
+		for i, col := range lp.Matching.Include {
+			node.VectorMatching.Include[i] = &ColumnExpr{Ref: col.Ref}
+		}
+	}
+	p.plan.addNode(node)
+
+	for _, operand := range []struct {
+		value  logical.Value
+		scalar **float64
+	}{
+		{value: lp.Left, scalar: &node.LeftScalar},
+		{value: lp.Right, scalar: &node.RightScalar},
+	} {
+		if lit, ok := operand.value.(*logical.Literal); ok {
+			val, ok := lit.Value().(float64)
+			if !ok {
+				return nil, fmt.Errorf("invalid scalar operand of type %s", lit.Kind())
+			}
+			*operand.scalar = &val
+			continue
+		}
+
+		children, err := p.process(operand.value, ctx)
+		if err != nil {
+			return nil, err
+		}
+		if len(children) != 1 {
+			return nil, fmt.Errorf("operand of join must be a single node, got %d", len(children))
+		}
+		if err := p.plan.addEdge(Edge{Parent: node, Child: children[0]}); err != nil {
+			return nil, err
+		}
+	}
+
+	if node.LeftScalar != nil && node.RightScalar != nil {
+		return nil, errors.New("join requires at least one vector operand")
+	}
+	return []Node{node}, nil
+}
+
+// Convert [logical.LabelReplace] into one [LabelReplace] node.
+func (p *Planner) processLabelReplace(lp *logical.LabelReplace, ctx *Context) ([]Node, error) {
+	node := &LabelReplace{
+		Dst:         lp.Dst,
+		Src:         lp.Src,
+		Regex:       lp.Regex,
+		Replacement: lp.Replacement,
+	}
+	p.plan.addNode(node)
+	children, err := p.process(lp.Table, ctx)
+	if err != nil {
+		return nil, err
+	}
+	for i := range children {
+		if err := p.plan.addEdge(Edge{Parent: node, Child: children[i]}); err != nil {
+			return nil, err
+		}
+	}
+	return []Node{node}, nil
+}
+
+// Convert [logical.Vector] into one [Vector] node.
+func (p *Planner) processVector(lp *logical.Vector, _ *Context) ([]Node, error) {
+	node := &Vector{
+		Value: lp.Value,
+		Start: lp.Start,
+		End:   lp.End,
+		Step:  lp.Step,
+	}
+	p.plan.addNode(node)
+	return []Node{node}, nil
+}
+
+// Convert [logical.Absent] into one [Absent] node.
+func (p *Planner) processAbsent(lp *logical.Absent, ctx *Context) ([]Node, error) {
+	node := &Absent{
+		Labels: lp.Labels,
+		Start:  lp.Start,
+		End:    lp.End,
+		Step:   lp.Step,
 	}
 	p.plan.addNode(node)
 	children, err := p.process(lp.Table, ctx)
@@ -339,6 +575,40 @@ func (p *Planner) Optimize(plan *Plan) (*Plan, error) {
 		if i == 1 {
 			return nil, errors.New("physical plan must only have exactly one root node")
 		}
+		if err := p.optimizeWithStatistics(plan, root); err != nil {
+			return nil, err
+		}
 	}
 	return plan, nil
 }
+
+// optimizeWithStatistics applies the cost-based optimizations to the plan
+// and estimates the costs of its nodes, if the catalog of the planner
+// provides the statistics of the scanned sections.
+func (p *Planner) optimizeWithStatistics(plan *Plan, root Node) error {
+	catalog, ok := p.catalog.(StatisticsCatalog)
+	if !ok {
+		return nil
+	}
+	stats, err := readStatistics(catalog, plan)
+	if err != nil {
+		return fmt.Errorf("reading section statistics: %w", err)
+	}
+	if len(stats) == 0 {
+		return nil
+	}
+
+	optimizations := []*optimization{
+		newOptimization("PredicateOrdering", plan).withRules(
+			&predicateOrdering{plan: plan, stats: stats},
+		),
+		newOptimization("ScanOrdering", plan).withRules(
+			&scanOrdering{plan: plan, stats: stats},
+		),
+	}
+	optimizer := newOptimizer(plan, optimizations)
+	optimizer.optimize(root)
+
+	estimateCosts(plan, root, stats)
+	return nil
+}
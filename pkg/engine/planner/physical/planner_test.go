@@ -5,6 +5,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/prometheus/prometheus/model/labels"
 	"github.com/stretchr/testify/require"
 
 	"github.com/grafana/loki/v3/pkg/engine/internal/datatype"
@@ -293,6 +294,7 @@ func TestPlanner_Convert_RangeAggregations(t *testing.T) {
 		time.Date(2023, 10, 1, 1, 0, 0, 0, time.UTC), // End Time
 		0,             // Step
 		time.Minute*5, // Range
+		0,             // Offset
 	)
 
 	logicalPlan, err := b.ToPlan()
@@ -314,3 +316,310 @@ func TestPlanner_Convert_RangeAggregations(t *testing.T) {
 	require.NoError(t, err)
 	t.Logf("Optimized plan\n%s\n", PrintAsTree(physicalPlan))
 }
+
+func TestPlanner_Convert_Parse(t *testing.T) {
+	// logical plan for { app="users" } | logfmt | level="error"
+	b := logical.NewBuilder(
+		&logical.MakeTable{
+			Selector: &logical.BinOp{
+				Left:  logical.NewColumnRef("app", types.ColumnTypeLabel),
+				Right: logical.NewLiteral("users"),
+				Op:    types.BinaryOpEq,
+			},
+			Shard: logical.NewShard(0, 1), // no sharding
+		},
+	).Sort(
+		*logical.NewColumnRef("timestamp", types.ColumnTypeBuiltin),
+		false,
+		false,
+	).Parse(
+		&logical.Parse{Parser: types.ParserTypeLogfmt, Strict: true},
+	).Select(
+		&logical.BinOp{
+			Left:  logical.NewColumnRef("level", types.ColumnTypeAmbiguous),
+			Right: logical.NewLiteral("error"),
+			Op:    types.BinaryOpEq,
+		},
+	).Limit(0, 1000)
+
+	logicalPlan, err := b.ToPlan()
+	require.NoError(t, err)
+
+	catalog := &catalog{
+		streamsByObject: map[string]objectMeta{
+			"obj1": {streamIDs: []int64{1, 2}, sections: 1},
+		},
+	}
+	planner := NewPlanner(NewContext(time.Now(), time.Now()), catalog)
+
+	physicalPlan, err := planner.Build(logicalPlan)
+	require.NoError(t, err)
+
+	physicalPlan, err = planner.Optimize(physicalPlan)
+	require.NoError(t, err)
+	t.Logf("Optimized plan\n%s\n", PrintAsTree(physicalPlan))
+
+	root, err := physicalPlan.Root()
+	require.NoError(t, err)
+	require.IsType(t, &Limit{}, root)
+
+	filter := physicalPlan.Children(root)[0]
+	require.IsType(t, &Filter{}, filter)
+
+	parse := physicalPlan.Children(filter)[0]
+	require.Equal(t, &Parse{Parser: types.ParserTypeLogfmt, Strict: true}, parse)
+
+	// The limit is not pushed down to the scan nodes, because the filter on
+	// the parsed column may drop rows.
+	for _, node := range physicalPlan.Leaves() {
+		require.IsType(t, &DataObjScan{}, node)
+		require.Equal(t, uint32(0), node.(*DataObjScan).Limit)
+	}
+}
+
+func TestPlanner_Convert_Format(t *testing.T) {
+	// logical plan for { app="users" } | label_format dst=src | line_format "{{.dst}}" | drop level
+	b := logical.NewBuilder(
+		&logical.MakeTable{
+			Selector: &logical.BinOp{
+				Left:  logical.NewColumnRef("app", types.ColumnTypeLabel),
+				Right: logical.NewLiteral("users"),
+				Op:    types.BinaryOpEq,
+			},
+			Shard: logical.NewShard(0, 1), // no sharding
+		},
+	).Sort(
+		*logical.NewColumnRef("timestamp", types.ColumnTypeBuiltin),
+		false,
+		false,
+	).LabelFormat(
+		&logical.LabelFormat{Formats: []logical.LabelFormatExpression{{Name: "dst", Value: "src", Rename: true}}},
+	).LineFormat(
+		&logical.LineFormat{Template: "{{.dst}}"},
+	).ProjectLabels(
+		&logical.LabelProjection{Labels: []logical.LabelMatcher{{Name: "level"}}},
+	).Limit(0, 1000)
+
+	logicalPlan, err := b.ToPlan()
+	require.NoError(t, err)
+
+	catalog := &catalog{
+		streamsByObject: map[string]objectMeta{
+			"obj1": {streamIDs: []int64{1, 2}, sections: 1},
+		},
+	}
+	planner := NewPlanner(NewContext(time.Now(), time.Now()), catalog)
+
+	physicalPlan, err := planner.Build(logicalPlan)
+	require.NoError(t, err)
+
+	physicalPlan, err = planner.Optimize(physicalPlan)
+	require.NoError(t, err)
+	t.Logf("Optimized plan\n%s\n", PrintAsTree(physicalPlan))
+
+	root, err := physicalPlan.Root()
+	require.NoError(t, err)
+	require.IsType(t, &Limit{}, root)
+
+	projection := physicalPlan.Children(root)[0]
+	require.Equal(t, &LabelProjection{Labels: []LabelMatcher{{Name: "level"}}}, projection)
+
+	lineFormat := physicalPlan.Children(projection)[0]
+	require.Equal(t, &LineFormat{Template: "{{.dst}}"}, lineFormat)
+
+	labelFormat := physicalPlan.Children(lineFormat)[0]
+	require.Equal(t, &LabelFormat{Formats: []LabelFormatExpression{{Name: "dst", Value: "src", Rename: true}}}, labelFormat)
+
+	// Format and projection stages do not drop rows, so the limit is pushed
+	// down to the scan nodes.
+	for _, node := range physicalPlan.Leaves() {
+		require.IsType(t, &DataObjScan{}, node)
+		require.Equal(t, uint32(1000), node.(*DataObjScan).Limit)
+	}
+}
+
+func TestPlanner_Convert_Join(t *testing.T) {
+	// logical plan for
+	// sum by (level) (count_over_time({app="users"}[5m])) / on (level) count_over_time({app="admins"}[5m]) > 2
+	rangeAggregation := func(app string) *logical.Builder {
+		return logical.NewBuilder(
+			&logical.MakeTable{
+				Selector: &logical.BinOp{
+					Left:  logical.NewColumnRef("app", types.ColumnTypeLabel),
+					Right: logical.NewLiteral(app),
+					Op:    types.BinaryOpEq,
+				},
+				Shard: logical.NewShard(0, 1), // no sharding
+			},
+		).RangeAggregation(
+			nil,
+			types.RangeAggregationTypeCount,
+			time.Date(2023, 10, 1, 0, 0, 0, 0, time.UTC), // Start Time
+			time.Date(2023, 10, 1, 1, 0, 0, 0, time.UTC), // End Time
+			0,             // Step
+			time.Minute*5, // Range
+			0,             // Offset
+		)
+	}
+
+	left := rangeAggregation("users").VectorAggregation(
+		[]logical.ColumnRef{*logical.NewColumnRef("level", types.ColumnTypeAmbiguous)},
+		types.VectorAggregationTypeSum,
+	)
+	right := rangeAggregation("admins")
+
+	b := left.Join(
+		right.Value(),
+		types.BinaryOpDiv,
+		false,
+		&logical.VectorMatching{
+			Cardinality: types.MatchOneToOne,
+			On:          true,
+			Labels:      []logical.ColumnRef{*logical.NewColumnRef("level", types.ColumnTypeAmbiguous)},
+		},
+	).Join(
+		logical.NewLiteral(float64(2)),
+		types.BinaryOpGt,
+		false,
+		nil,
+	)
+
+	logicalPlan, err := b.ToPlan()
+	require.NoError(t, err)
+
+	catalog := &catalog{
+		streamsByObject: map[string]objectMeta{
+			"obj1": {streamIDs: []int64{1, 2}, sections: 1},
+		},
+	}
+	planner := NewPlanner(NewContext(time.Now(), time.Now()), catalog)
+
+	physicalPlan, err := planner.Build(logicalPlan)
+	require.NoError(t, err)
+
+	physicalPlan, err = planner.Optimize(physicalPlan)
+	require.NoError(t, err)
+	t.Logf("Optimized plan\n%s\n", PrintAsTree(physicalPlan))
+
+	root, err := physicalPlan.Root()
+	require.NoError(t, err)
+
+	// The scalar operand is stored in the node instead of being a child.
+	scalarJoin, ok := root.(*Join)
+	require.True(t, ok)
+	require.Equal(t, types.BinaryOpGt, scalarJoin.Operator)
+	require.Nil(t, scalarJoin.VectorMatching)
+	require.Nil(t, scalarJoin.LeftScalar)
+	require.Equal(t, float64(2), *scalarJoin.RightScalar)
+
+	children := physicalPlan.Children(scalarJoin)
+	require.Len(t, children, 1)
+
+	vectorJoin, ok := children[0].(*Join)
+	require.True(t, ok)
+	require.Equal(t, &VectorMatching{
+		Cardinality:    types.MatchOneToOne,
+		On:             true,
+		MatchingLabels: []ColumnExpression{&ColumnExpr{Ref: types.ColumnRef{Column: "level", Type: types.ColumnTypeAmbiguous}}},
+		Include:        []ColumnExpression{},
+	}, vectorJoin.VectorMatching)
+
+	// The operands of the join are its children, in order.
+	operands := physicalPlan.Children(vectorJoin)
+	require.Len(t, operands, 2)
+	require.IsType(t, &VectorAggregation{}, operands[0])
+	require.IsType(t, &RangeAggregation{}, operands[1])
+}
+
+// timeRangeCatalog records the time range for which data objects are
+// resolved.
+type timeRangeCatalog struct {
+	catalog
+	from, through time.Time
+}
+
+// ResolveDataObjForShard implements Catalog.
+func (c *timeRangeCatalog) ResolveDataObjWithShard(e Expression, p []Expression, shard ShardInfo, from, through time.Time) ([]DataObjLocation, [][]int64, [][]int, error) {
+	c.from, c.through = from, through
+	return c.catalog.ResolveDataObjWithShard(e, p, shard, from, through)
+}
+
+func TestPlanner_Convert_Functions(t *testing.T) {
+	start := time.Date(2023, 10, 1, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2023, 10, 1, 1, 0, 0, 0, time.UTC)
+
+	// logical plan for
+	// label_replace(absent_over_time({app="users"}[5m] offset 1h), "dst", "$1", "app", "(.*)") or vector(0)
+	absent := logical.NewBuilder(
+		&logical.MakeTable{
+			Selector: &logical.BinOp{
+				Left:  logical.NewColumnRef("app", types.ColumnTypeLabel),
+				Right: logical.NewLiteral("users"),
+				Op:    types.BinaryOpEq,
+			},
+			Shard: logical.NewShard(0, 1), // no sharding
+		},
+	).RangeAggregation(
+		nil,
+		types.RangeAggregationTypeCount,
+		start,
+		end,
+		time.Minute,   // Step
+		time.Minute*5, // Range
+		time.Hour,     // Offset
+	).Absent(
+		labels.FromStrings("app", "users"),
+		start,
+		end,
+		time.Minute,
+	).LabelReplace(
+		&logical.LabelReplace{Dst: "dst", Src: "app", Regex: "(.*)", Replacement: "$1"},
+	)
+
+	b := absent.Join(
+		&logical.Vector{Value: 0, Start: start, End: end, Step: time.Minute},
+		types.BinaryOpOr,
+		false,
+		&logical.VectorMatching{Cardinality: types.MatchOneToOne},
+	)
+
+	logicalPlan, err := b.ToPlan()
+	require.NoError(t, err)
+
+	catalog := &timeRangeCatalog{
+		catalog: catalog{
+			streamsByObject: map[string]objectMeta{
+				"obj1": {streamIDs: []int64{1, 2}, sections: 1},
+			},
+		},
+	}
+	planner := NewPlanner(NewContext(start, end), catalog)
+
+	physicalPlan, err := planner.Build(logicalPlan)
+	require.NoError(t, err)
+
+	physicalPlan, err = planner.Optimize(physicalPlan)
+	require.NoError(t, err)
+	t.Logf("Optimized plan\n%s\n", PrintAsTree(physicalPlan))
+
+	// Data objects are resolved for the time range shifted by the offset.
+	require.Equal(t, start.Add(-time.Hour-5*time.Minute), catalog.from)
+	require.Equal(t, end.Add(-time.Hour), catalog.through)
+
+	root, err := physicalPlan.Root()
+	require.NoError(t, err)
+	require.IsType(t, &Join{}, root)
+
+	operands := physicalPlan.Children(root)
+	require.Len(t, operands, 2)
+	require.Equal(t, &LabelReplace{Dst: "dst", Src: "app", Regex: "(.*)", Replacement: "$1"}, operands[0])
+	require.Equal(t, &Vector{Value: 0, Start: start, End: end, Step: time.Minute}, operands[1])
+	require.Empty(t, physicalPlan.Children(operands[1]))
+
+	absentNode := physicalPlan.Children(operands[0])[0]
+	require.Equal(t, &Absent{Labels: labels.FromStrings("app", "users"), Start: start, End: end, Step: time.Minute}, absentNode)
+
+	rangeAggregation := physicalPlan.Children(absentNode)[0]
+	require.IsType(t, &RangeAggregation{}, rangeAggregation)
+	require.Equal(t, time.Hour, rangeAggregation.(*RangeAggregation).Offset)
+}
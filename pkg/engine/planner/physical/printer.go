@@ -6,6 +6,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/grafana/loki/v3/pkg/engine/internal/types"
 	"github.com/grafana/loki/v3/pkg/engine/planner/internal/tree"
 )
 
@@ -17,11 +18,21 @@ func BuildTree(p *Plan, n Node) *tree.Node {
 
 func toTree(p *Plan, n Node) *tree.Node {
 	root := toTreeNode(n)
+	if cost, ok := p.Cost(n); ok {
+		root.AddComment("Cost", "", cost.properties())
+	}
 	for _, child := range p.Children(n) {
 		if ch := toTree(p, child); ch != nil {
 			root.Children = append(root.Children, ch)
 		}
 	}
+	// The fragment of an exchange is printed as its child, as if it was
+	// part of the same plan.
+	if exchange, ok := n.(*Exchange); ok && exchange.Fragment != nil {
+		for _, fragmentRoot := range exchange.Fragment.Roots() {
+			root.Children = append(root.Children, toTree(exchange.Fragment, fragmentRoot))
+		}
+	}
 	return root
 }
 
@@ -53,6 +64,36 @@ func toTreeNode(n Node) *tree.Node {
 		for i := range node.Predicates {
 			treeNode.Properties = append(treeNode.Properties, tree.NewProperty(fmt.Sprintf("predicate[%d]", i), false, node.Predicates[i].String()))
 		}
+	case *Parse:
+		properties := []tree.Property{
+			tree.NewProperty("parser", false, node.Parser),
+		}
+		if node.Pattern != "" {
+			properties = append(properties, tree.NewProperty("pattern", false, node.Pattern))
+		}
+		if node.Strict {
+			properties = append(properties, tree.NewProperty("strict", false, node.Strict))
+		}
+		if node.KeepEmpty {
+			properties = append(properties, tree.NewProperty("keep_empty", false, node.KeepEmpty))
+		}
+		if len(node.Expressions) > 0 {
+			properties = append(properties, tree.NewProperty("expressions", true, toAnySlice(node.Expressions)...))
+		}
+		treeNode.Properties = properties
+	case *LineFormat:
+		treeNode.Properties = []tree.Property{
+			tree.NewProperty("template", false, node.Template),
+		}
+	case *LabelFormat:
+		treeNode.Properties = []tree.Property{
+			tree.NewProperty("formats", true, toAnySlice(node.Formats)...),
+		}
+	case *LabelProjection:
+		treeNode.Properties = []tree.Property{
+			tree.NewProperty("keep", false, node.Keep),
+			tree.NewProperty("labels", true, toAnySlice(node.Labels)...),
+		}
 	case *Limit:
 		treeNode.Properties = []tree.Property{
 			tree.NewProperty("offset", false, node.Skip),
@@ -67,10 +108,97 @@ func toTreeNode(n Node) *tree.Node {
 			tree.NewProperty("range", false, node.Range),
 		}
 
+		if node.Operation == types.RangeAggregationTypeQuantile {
+			properties = append(properties, tree.NewProperty("parameter", false, node.Parameter))
+		}
+
+		if node.Unwrap != nil {
+			properties = append(properties, tree.NewProperty("unwrap", false, node.Unwrap.String()))
+		}
+
+		if node.Offset != 0 {
+			properties = append(properties, tree.NewProperty("offset", false, node.Offset))
+		}
+
 		if len(node.PartitionBy) > 0 {
 			properties = append(properties, tree.NewProperty("partition_by", true, toAnySlice(node.PartitionBy)...))
 		}
 
+		treeNode.Properties = properties
+	case *VectorAggregation:
+		properties := []tree.Property{
+			tree.NewProperty("operation", false, node.Operation),
+		}
+
+		if node.Operation == types.VectorAggregationTypeTopK || node.Operation == types.VectorAggregationTypeBottomK {
+			properties = append(properties, tree.NewProperty("parameter", false, node.Parameter))
+		}
+
+		if node.Without {
+			properties = append(properties, tree.NewProperty("without", true, toAnySlice(node.GroupBy)...))
+		} else if len(node.GroupBy) > 0 {
+			properties = append(properties, tree.NewProperty("group_by", true, toAnySlice(node.GroupBy)...))
+		}
+
+		treeNode.Properties = properties
+	case *LabelReplace:
+		treeNode.Properties = []tree.Property{
+			tree.NewProperty("dst", false, node.Dst),
+			tree.NewProperty("src", false, node.Src),
+			tree.NewProperty("regex", false, node.Regex),
+			tree.NewProperty("replacement", false, node.Replacement),
+		}
+	case *Vector:
+		treeNode.Properties = []tree.Property{
+			tree.NewProperty("value", false, node.Value),
+			tree.NewProperty("start", false, node.Start.Format(time.RFC3339Nano)),
+			tree.NewProperty("end", false, node.End.Format(time.RFC3339Nano)),
+			tree.NewProperty("step", false, node.Step),
+		}
+	case *Absent:
+		treeNode.Properties = []tree.Property{
+			tree.NewProperty("labels", false, node.Labels.String()),
+			tree.NewProperty("start", false, node.Start.Format(time.RFC3339Nano)),
+			tree.NewProperty("end", false, node.End.Format(time.RFC3339Nano)),
+			tree.NewProperty("step", false, node.Step),
+		}
+	case *Exchange:
+		if node.Fragment != nil {
+			treeNode.Properties = []tree.Property{
+				tree.NewProperty("fragment_nodes", false, node.Fragment.Len()),
+			}
+		}
+	case *Join:
+		properties := []tree.Property{
+			tree.NewProperty("operator", false, node.Operator),
+		}
+
+		if node.ReturnBool {
+			properties = append(properties, tree.NewProperty("bool", false, true))
+		}
+
+		if node.LeftScalar != nil {
+			properties = append(properties, tree.NewProperty("left_scalar", false, *node.LeftScalar))
+		}
+		if node.RightScalar != nil {
+			properties = append(properties, tree.NewProperty("right_scalar", false, *node.RightScalar))
+		}
+
+		if matching := node.VectorMatching; matching != nil {
+			if matching.On {
+				properties = append(properties, tree.NewProperty("on", true, toAnySlice(matching.MatchingLabels)...))
+			} else {
+				properties = append(properties, tree.NewProperty("ignoring", true, toAnySlice(matching.MatchingLabels)...))
+			}
+
+			switch matching.Cardinality {
+			case types.MatchManyToOne:
+				properties = append(properties, tree.NewProperty("group_left", true, toAnySlice(matching.Include)...))
+			case types.MatchOneToMany:
+				properties = append(properties, tree.NewProperty("group_right", true, toAnySlice(matching.Include)...))
+			}
+		}
+
 		treeNode.Properties = properties
 	}
 	return treeNode
@@ -101,6 +229,75 @@ func PrintAsTree(p *Plan) string {
 	return strings.Join(results, "\n")
 }
 
+// Annotation is a named value that is attached to a node when printing a
+// plan, such as a runtime statistic collected while executing the node.
+type Annotation struct {
+	Key   string
+	Value any
+}
+
+// PrintAnnotatedAsTree converts a physical [Plan] into a human-readable tree
+// representation like [PrintAsTree]. The annotations returned by annotate for
+// each node are printed below the node as a comment with the given name.
+// Nodes without annotations are printed without comment.
+func PrintAnnotatedAsTree(p *Plan, name string, annotate func(Node) []Annotation) string {
+	results := make([]string, 0, len(p.Roots()))
+
+	for _, root := range p.Roots() {
+		sb := &strings.Builder{}
+		printer := tree.NewPrinter(sb)
+		node := toAnnotatedTree(p, root, name, annotate)
+		printer.Print(node)
+		results = append(results, sb.String())
+	}
+
+	return strings.Join(results, "\n")
+}
+
+func toAnnotatedTree(p *Plan, n Node, name string, annotate func(Node) []Annotation) *tree.Node {
+	root := toTreeNode(n)
+	if cost, ok := p.Cost(n); ok {
+		root.AddComment("Cost", "", cost.properties())
+	}
+	if annotations := annotate(n); len(annotations) > 0 {
+		properties := make([]tree.Property, 0, len(annotations))
+		for _, a := range annotations {
+			properties = append(properties, tree.NewProperty(a.Key, false, a.Value))
+		}
+		root.AddComment(name, "", properties)
+	}
+	for _, child := range p.Children(n) {
+		root.Children = append(root.Children, toAnnotatedTree(p, child, name, annotate))
+	}
+	if exchange, ok := n.(*Exchange); ok && exchange.Fragment != nil {
+		for _, fragmentRoot := range exchange.Fragment.Roots() {
+			root.Children = append(root.Children, toAnnotatedTree(exchange.Fragment, fragmentRoot, name, annotate))
+		}
+	}
+	return root
+}
+
+// NodeProperties returns the properties of n as they are printed by
+// [PrintAsTree]. The values of multi-value properties are formatted as a
+// parenthesized list.
+func NodeProperties(n Node) []Annotation {
+	properties := toTreeNode(n).Properties
+	annotations := make([]Annotation, 0, len(properties))
+	for _, p := range properties {
+		values := make([]string, 0, len(p.Values))
+		for _, v := range p.Values {
+			values = append(values, fmt.Sprintf("%v", v))
+		}
+
+		value := strings.Join(values, ", ")
+		if p.IsMultiValue {
+			value = "(" + value + ")"
+		}
+		annotations = append(annotations, Annotation{Key: p.Key, Value: value})
+	}
+	return annotations
+}
+
 func WriteMermaidFormat(w io.Writer, p *Plan) {
 	for _, root := range p.Roots() {
 		node := BuildTree(p, root)
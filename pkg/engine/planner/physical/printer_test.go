@@ -1,6 +1,10 @@
 package physical
 
-import "testing"
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
 
 func TestPrinter(t *testing.T) {
 	t.Run("simple tree", func(t *testing.T) {
@@ -50,3 +54,27 @@ func TestPrinter(t *testing.T) {
 		t.Log("\n" + repr)
 	})
 }
+
+func TestPrintAnnotatedAsTree(t *testing.T) {
+	p := &Plan{}
+	limit := p.addNode(&Limit{id: "limit", Fetch: 10})
+	scan := p.addNode(&DataObjScan{id: "scan", Location: "obj"})
+	_ = p.addEdge(Edge{Parent: limit, Child: scan})
+
+	repr := PrintAnnotatedAsTree(p, "Analyze", func(n Node) []Annotation {
+		if n.ID() != "scan" {
+			return nil
+		}
+		return []Annotation{
+			{Key: "rows_out", Value: 10},
+			{Key: "batches", Value: 1},
+		}
+	})
+
+	expected := `
+Limit <limit> offset=0 limit=10
+└── DataObjScan <scan> location=obj streams=0 section_id=0 projections=() direction=ASC limit=0
+        └── Analyze rows_out=10 batches=1
+`
+	require.Equal(t, expected, "\n"+repr)
+}
@@ -14,10 +14,25 @@ type RangeAggregation struct {
 	PartitionBy []ColumnExpression // Columns to partition the data by.
 
 	Operation types.RangeAggregationType
+	Parameter float64 // Parameter of the operation, e.g. the quantile for quantile_over_time.
+	Unwrap    *Unwrap // Column to take sample values from. Nil if the operation works on log lines.
 	Start     time.Time
 	End       time.Time
 	Step      time.Duration // optional for instant queries
 	Range     time.Duration
+	Offset    time.Duration // Shifts the windows back in time. Results are reported at the timestamps of the steps.
+}
+
+// Unwrap describes how sample values are extracted from a column.
+type Unwrap struct {
+	Column     ColumnExpression     // Column that holds the values.
+	Conversion types.ConversionType // Conversion applied to the string values of the column.
+	DropErrors bool                 // Drop entries that fail the conversion instead of failing the query.
+}
+
+// String returns the string representation of the Unwrap.
+func (u *Unwrap) String() string {
+	return fmt.Sprintf("%s(%s)", u.Conversion, u.Column)
 }
 
 func (r *RangeAggregation) ID() string {
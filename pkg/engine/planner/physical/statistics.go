@@ -0,0 +1,150 @@
+package physical
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/thanos-io/objstore"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/grafana/loki/v3/pkg/dataobj"
+	"github.com/grafana/loki/v3/pkg/dataobj/sections/logs"
+	"github.com/grafana/loki/v3/pkg/engine/internal/types"
+)
+
+// SectionStatistics holds the statistics of a logs section of a data object
+// that are used to estimate the costs of the nodes of a plan.
+type SectionStatistics struct {
+	Rows             int64 // Number of rows in the section.
+	UncompressedSize int64 // Uncompressed size of all columns of the section.
+
+	// Start and End are the timestamps of the oldest and the newest row of
+	// the section. They are zero if the range of timestamps is unknown.
+	Start, End time.Time
+
+	// Columns holds the statistics of the columns of the section by the
+	// column reference that is used in expressions. Metadata columns are
+	// referenced by their name and [types.ColumnTypeMetadata].
+	Columns map[types.ColumnRef]ColumnStatistics
+}
+
+// ColumnStatistics holds the statistics of a column of a logs section.
+type ColumnStatistics struct {
+	Values           int64 // Number of non-NULL values of the column.
+	Cardinality      int64 // Estimated number of distinct values, or 0 if unknown.
+	UncompressedSize int64 // Uncompressed size of the column.
+}
+
+// SectionRef references a logs section of a data object.
+type SectionRef struct {
+	Location DataObjLocation
+	Section  int
+}
+
+// StatisticsCatalog is a [Catalog] that also provides the statistics of the
+// sections of data objects. If the catalog of a [Planner] is a
+// StatisticsCatalog, the planner applies cost-based optimizations to the
+// plans.
+type StatisticsCatalog interface {
+	Catalog
+
+	// SectionStatistics returns the statistics of the given sections in the
+	// same order as the sections. The statistics of sections are nil if they
+	// are not available.
+	SectionStatistics([]SectionRef) ([]*SectionStatistics, error)
+}
+
+// statisticsConcurrency is the maximum number of data objects from which
+// [MetastoreCatalog] reads the statistics of sections concurrently.
+const statisticsConcurrency = 16
+
+// WithStatistics returns the catalog with a bucket from which the statistics
+// of the sections of data objects are read. The catalog only provides
+// statistics if it has a bucket, since reading them adds latency to query
+// planning.
+func (c *MetastoreCatalog) WithStatistics(bucket objstore.Bucket) *MetastoreCatalog {
+	c.bucket = bucket
+	return c
+}
+
+// SectionStatistics implements [StatisticsCatalog]. It reads the metadata of
+// the sections from the data objects in the bucket of the catalog.
+func (c *MetastoreCatalog) SectionStatistics(refs []SectionRef) ([]*SectionStatistics, error) {
+	result := make([]*SectionStatistics, len(refs))
+	if c.bucket == nil {
+		return result, nil
+	}
+
+	// Each data object is only opened once for all of its sections.
+	byLocation := make(map[DataObjLocation][]int)
+	for i, ref := range refs {
+		byLocation[ref.Location] = append(byLocation[ref.Location], i)
+	}
+
+	g, ctx := errgroup.WithContext(c.ctx)
+	g.SetLimit(statisticsConcurrency)
+	for location, indices := range byLocation {
+		g.Go(func() error {
+			obj, err := dataobj.FromBucket(ctx, c.bucket, string(location))
+			if err != nil {
+				return fmt.Errorf("opening data object %s: %w", location, err)
+			}
+
+			for idx, section := range obj.Sections().Filter(logs.CheckSection) {
+				for _, i := range indices {
+					if refs[i].Section != idx {
+						continue
+					}
+					sec, err := logs.Open(ctx, section)
+					if err != nil {
+						return fmt.Errorf("opening logs section %d of data object %s: %w", idx, location, err)
+					}
+					result[i] = logsSectionStatistics(sec)
+				}
+			}
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// logsSectionStatistics returns the statistics of a logs section from the
+// summaries of its columns.
+func logsSectionStatistics(sec *logs.Section) *SectionStatistics {
+	stats := &SectionStatistics{
+		Rows:    int64(sec.RowCount()),
+		Columns: make(map[types.ColumnRef]ColumnStatistics, len(sec.Columns())),
+	}
+
+	for _, col := range sec.Columns() {
+		summary := col.Summary()
+		stats.UncompressedSize += int64(summary.UncompressedSize)
+
+		var ref types.ColumnRef
+		switch col.Type {
+		case logs.ColumnTypeTimestamp:
+			ref = types.ColumnRef{Column: types.ColumnNameBuiltinTimestamp, Type: types.ColumnTypeBuiltin}
+			if summary.HasRange {
+				stats.Start, stats.End = time.Unix(0, summary.MinInt64).UTC(), time.Unix(0, summary.MaxInt64).UTC()
+			}
+		case logs.ColumnTypeMessage:
+			ref = types.ColumnRef{Column: types.ColumnNameBuiltinMessage, Type: types.ColumnTypeBuiltin}
+		case logs.ColumnTypeMetadata:
+			ref = types.ColumnRef{Column: col.Name, Type: types.ColumnTypeMetadata}
+		default:
+			continue
+		}
+
+		stats.Columns[ref] = ColumnStatistics{
+			Values:           int64(summary.ValuesCount),
+			Cardinality:      int64(summary.Cardinality),
+			UncompressedSize: int64(summary.UncompressedSize),
+		}
+	}
+	return stats
+}
+
+var _ StatisticsCatalog = (*MetastoreCatalog)(nil)
@@ -0,0 +1,40 @@
+package physical
+
+import (
+	"fmt"
+	"time"
+)
+
+// Vector represents a vector function in the physical plan. It produces a
+// single series without labels that has the same value at each step of the
+// query. Vector nodes do not have children.
+type Vector struct {
+	id string
+
+	Value float64 // Value of the series at each step.
+
+	Start time.Time
+	End   time.Time
+	Step  time.Duration // optional for instant queries
+}
+
+// ID implements the [Node] interface.
+// Returns a string that uniquely identifies the node in the plan.
+func (v *Vector) ID() string {
+	if v.id == "" {
+		return fmt.Sprintf("%p", v)
+	}
+	return v.id
+}
+
+// Type implements the [Node] interface.
+// Returns the type of the node.
+func (*Vector) Type() NodeType {
+	return NodeTypeVector
+}
+
+// Accept implements the [Node] interface.
+// Dispatches itself to the provided [Visitor] v
+func (v *Vector) Accept(visitor Visitor) error {
+	return visitor.VisitVector(v)
+}
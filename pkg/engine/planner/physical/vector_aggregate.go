@@ -15,8 +15,15 @@ type VectorAggregation struct {
 	// GroupBy defines the columns to group by. If empty, all rows are aggregated into a single result.
 	GroupBy []ColumnExpression
 
+	// Without inverts the grouping: rows are grouped by all their labels except
+	// the columns defined in GroupBy.
+	Without bool
+
 	// Operation defines the type of aggregation operation to perform (e.g., sum, min, max)
 	Operation types.VectorAggregationType
+
+	// Parameter defines the parameter of the operation, e.g. the number of series returned by topk.
+	Parameter int
 }
 
 // ID implements the [Node] interface.
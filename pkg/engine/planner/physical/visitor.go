@@ -12,4 +12,13 @@ type Visitor interface {
 	VisitFilter(*Filter) error
 	VisitLimit(*Limit) error
 	VisitVectorAggregation(*VectorAggregation) error
+	VisitParse(*Parse) error
+	VisitLineFormat(*LineFormat) error
+	VisitLabelFormat(*LabelFormat) error
+	VisitLabelProjection(*LabelProjection) error
+	VisitJoin(*Join) error
+	VisitLabelReplace(*LabelReplace) error
+	VisitVector(*Vector) error
+	VisitAbsent(*Absent) error
+	VisitExchange(*Exchange) error
 }
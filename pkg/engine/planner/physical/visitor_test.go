@@ -16,6 +16,15 @@ type nodeCollectVisitor struct {
 	onVisitProjection        func(*Projection) error
 	onVisitRangeAggregation  func(*RangeAggregation) error
 	onVisitVectorAggregation func(*VectorAggregation) error
+	onVisitParse             func(*Parse) error
+	onVisitLineFormat        func(*LineFormat) error
+	onVisitLabelFormat       func(*LabelFormat) error
+	onVisitLabelProjection   func(*LabelProjection) error
+	onVisitJoin              func(*Join) error
+	onVisitLabelReplace      func(*LabelReplace) error
+	onVisitVector            func(*Vector) error
+	onVisitAbsent            func(*Absent) error
+	onVisitExchange          func(*Exchange) error
 }
 
 func (v *nodeCollectVisitor) VisitDataObjScan(n *DataObjScan) error {
@@ -74,3 +83,75 @@ func (v *nodeCollectVisitor) VisitVectorAggregation(n *VectorAggregation) error
 	v.visited = append(v.visited, fmt.Sprintf("%s.%s", n.Type().String(), n.ID()))
 	return nil
 }
+
+func (v *nodeCollectVisitor) VisitParse(n *Parse) error {
+	if v.onVisitParse != nil {
+		return v.onVisitParse(n)
+	}
+	v.visited = append(v.visited, fmt.Sprintf("%s.%s", n.Type().String(), n.ID()))
+	return nil
+}
+
+func (v *nodeCollectVisitor) VisitLineFormat(n *LineFormat) error {
+	if v.onVisitLineFormat != nil {
+		return v.onVisitLineFormat(n)
+	}
+	v.visited = append(v.visited, fmt.Sprintf("%s.%s", n.Type().String(), n.ID()))
+	return nil
+}
+
+func (v *nodeCollectVisitor) VisitLabelFormat(n *LabelFormat) error {
+	if v.onVisitLabelFormat != nil {
+		return v.onVisitLabelFormat(n)
+	}
+	v.visited = append(v.visited, fmt.Sprintf("%s.%s", n.Type().String(), n.ID()))
+	return nil
+}
+
+func (v *nodeCollectVisitor) VisitLabelProjection(n *LabelProjection) error {
+	if v.onVisitLabelProjection != nil {
+		return v.onVisitLabelProjection(n)
+	}
+	v.visited = append(v.visited, fmt.Sprintf("%s.%s", n.Type().String(), n.ID()))
+	return nil
+}
+
+func (v *nodeCollectVisitor) VisitJoin(n *Join) error {
+	if v.onVisitJoin != nil {
+		return v.onVisitJoin(n)
+	}
+	v.visited = append(v.visited, fmt.Sprintf("%s.%s", n.Type().String(), n.ID()))
+	return nil
+}
+
+func (v *nodeCollectVisitor) VisitLabelReplace(n *LabelReplace) error {
+	if v.onVisitLabelReplace != nil {
+		return v.onVisitLabelReplace(n)
+	}
+	v.visited = append(v.visited, fmt.Sprintf("%s.%s", n.Type().String(), n.ID()))
+	return nil
+}
+
+func (v *nodeCollectVisitor) VisitVector(n *Vector) error {
+	if v.onVisitVector != nil {
+		return v.onVisitVector(n)
+	}
+	v.visited = append(v.visited, fmt.Sprintf("%s.%s", n.Type().String(), n.ID()))
+	return nil
+}
+
+func (v *nodeCollectVisitor) VisitAbsent(n *Absent) error {
+	if v.onVisitAbsent != nil {
+		return v.onVisitAbsent(n)
+	}
+	v.visited = append(v.visited, fmt.Sprintf("%s.%s", n.Type().String(), n.ID()))
+	return nil
+}
+
+func (v *nodeCollectVisitor) VisitExchange(n *Exchange) error {
+	if v.onVisitExchange != nil {
+		return v.onVisitExchange(n)
+	}
+	v.visited = append(v.visited, fmt.Sprintf("%s.%s", n.Type().String(), n.ID()))
+	return nil
+}
@@ -46,6 +46,7 @@ const (
 	HTTPQueryTags           = "X-Query-Tags"
 	HTTPCacheControl        = "Cache-Control"
 	HTTPCacheControlNoCache = "no-cache"
+	HTTPExplainAnalyze      = "X-Loki-Explain-Analyze"
 )
 
 var userAgent = fmt.Sprintf("loki-logcli/%s", build.Version)
@@ -90,6 +91,7 @@ type DefaultClient struct {
 	BackoffConfig    BackoffConfig
 	Compression      bool
 	EnvironmentProxy bool
+	ExplainAnalyze   bool
 }
 
 // Query uses the /api/v1/query endpoint to execute an instant query
@@ -406,10 +408,16 @@ func (c *DefaultClient) getHTTPRequestHeader() (http.Header, error) {
 		h.Set(HTTPScopeOrgID, c.OrgID)
 	}
 
-	if c.NoCache {
+	// Cached results do not contain runtime statistics, so they are skipped
+	// when analyzing a query.
+	if c.NoCache || c.ExplainAnalyze {
 		h.Set(HTTPCacheControl, HTTPCacheControlNoCache)
 	}
 
+	if c.ExplainAnalyze {
+		h.Set(HTTPExplainAnalyze, "true")
+	}
+
 	if c.QueryTags != "" {
 		h.Set(HTTPQueryTags, c.QueryTags)
 	}
@@ -59,6 +59,12 @@ func Test_getHTTPRequestHeader(t *testing.T) {
 		}, http.Header{
 			"Authorization": []string{"Bearer " + "secureToken"},
 		}, false},
+		{"explain-analyze", DefaultClient{
+			ExplainAnalyze: true,
+		}, http.Header{
+			"X-Loki-Explain-Analyze": []string{"true"},
+			"Cache-Control":          []string{"no-cache"},
+		}, false},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -17,6 +17,7 @@ import (
 	"github.com/grafana/loki/v3/pkg/logproto"
 	"github.com/grafana/loki/v3/pkg/logql"
 	logqllog "github.com/grafana/loki/v3/pkg/logql/log"
+	"github.com/grafana/loki/v3/pkg/logql/syntax"
 	"github.com/grafana/loki/v3/pkg/util/log"
 	"github.com/grafana/loki/v3/pkg/util/marshal"
 	"github.com/grafana/loki/v3/pkg/util/validation"
@@ -43,6 +44,9 @@ type FileClient struct {
 	labelValues []string
 	orgID       string
 	engine      logql.Engine
+
+	// streams is set when lines are routed into streams by their labels.
+	streams *streamsQuerier
 }
 
 // NewFileClient returns the new instance of FileClient for the given `io.ReadCloser`
@@ -61,6 +65,55 @@ func NewFileClient(r io.ReadCloser) *FileClient {
 	}
 }
 
+// NewFileClientWithConfig returns a FileClient for the given `io.ReadCloser`
+// which splits the lines into streams as configured by cfg.
+func NewFileClientWithConfig(r io.ReadCloser, cfg FileConfig) (*FileClient, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	if !cfg.multiStream() {
+		return NewFileClient(r), nil
+	}
+
+	q := &streamsQuerier{r: r, cfg: cfg}
+	eng := logql.NewEngine(logql.EngineOpts{}, q, &limiter{n: defaultMetricSeriesLimit}, log.Logger)
+	return &FileClient{
+		r:       r,
+		orgID:   defaultOrgID,
+		engine:  eng,
+		streams: q,
+	}, nil
+}
+
+// streamLabels returns the label sets of the streams of the input.
+func (f *FileClient) streamLabels() ([]loghttp.LabelSet, error) {
+	if f.streams == nil {
+		lbs := make(loghttp.LabelSet)
+		for i := 0; i < min(len(f.labels), len(f.labelValues)); i++ {
+			lbs[f.labels[i]] = f.labelValues[i]
+		}
+		return []loghttp.LabelSet{lbs}, nil
+	}
+
+	streams, err := f.streams.load()
+	if err != nil {
+		return nil, err
+	}
+	sets := make([]loghttp.LabelSet, 0, len(streams))
+	for _, s := range streams {
+		lbls, err := syntax.ParseLabels(s.Labels)
+		if err != nil {
+			return nil, err
+		}
+		lbs := make(loghttp.LabelSet, lbls.Len())
+		lbls.Range(func(l labels.Label) {
+			lbs[l.Name] = l.Value
+		})
+		sets = append(sets, lbs)
+	}
+	return sets, nil
+}
+
 func (f *FileClient) Query(q string, limit int, t time.Time, direction logproto.Direction, _ bool) (*loghttp.QueryResponse, error) {
 	ctx := context.Background()
 
@@ -145,38 +198,67 @@ func (f *FileClient) QueryRange(queryStr string, limit int, start, end time.Time
 }
 
 func (f *FileClient) ListLabelNames(_ bool, _, _ time.Time) (*loghttp.LabelResponse, error) {
+	if f.streams == nil {
+		return &loghttp.LabelResponse{
+			Status: loghttp.QueryStatusSuccess,
+			Data:   f.labels,
+		}, nil
+	}
+
+	sets, err := f.streamLabels()
+	if err != nil {
+		return nil, err
+	}
+	names := map[string]struct{}{}
+	for _, lbs := range sets {
+		for name := range lbs {
+			names[name] = struct{}{}
+		}
+	}
 	return &loghttp.LabelResponse{
 		Status: loghttp.QueryStatusSuccess,
-		Data:   f.labels,
+		Data:   sortedKeys(names),
 	}, nil
 }
 
 func (f *FileClient) ListLabelValues(name string, _ bool, _, _ time.Time) (*loghttp.LabelResponse, error) {
-	i := sort.SearchStrings(f.labels, name)
-	if i < 0 {
-		return &loghttp.LabelResponse{}, nil
+	if f.streams == nil {
+		i := sort.SearchStrings(f.labels, name)
+		if i < 0 {
+			return &loghttp.LabelResponse{}, nil
+		}
+
+		return &loghttp.LabelResponse{
+			Status: loghttp.QueryStatusSuccess,
+			Data:   []string{f.labelValues[i]},
+		}, nil
 	}
 
+	sets, err := f.streamLabels()
+	if err != nil {
+		return nil, err
+	}
+	values := map[string]struct{}{}
+	for _, lbs := range sets {
+		if value, ok := lbs[name]; ok {
+			values[value] = struct{}{}
+		}
+	}
 	return &loghttp.LabelResponse{
 		Status: loghttp.QueryStatusSuccess,
-		Data:   []string{f.labelValues[i]},
+		Data:   sortedKeys(values),
 	}, nil
 }
 
 func (f *FileClient) Series(_ []string, _, _ time.Time, _ bool) (*loghttp.SeriesResponse, error) {
-	m := len(f.labels)
-	if m > len(f.labelValues) {
-		m = len(f.labelValues)
-	}
-
-	lbs := make(loghttp.LabelSet)
-	for i := 0; i < m; i++ {
-		lbs[f.labels[i]] = f.labelValues[i]
+	sets, err := f.streamLabels()
+	if err != nil {
+		return nil, err
 	}
 
 	return &loghttp.SeriesResponse{
 		Status: loghttp.QueryStatusSuccess,
-		Data:   []loghttp.LabelSet{lbs},
+		Data:   sets,
 	}, nil
 }
 
@@ -214,6 +296,15 @@ func (f *FileClient) GetDetectedFields(
 	return nil, ErrNotSupported
 }
 
+func sortedKeys(m map[string]struct{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
 type limiter struct {
 	n int
 }
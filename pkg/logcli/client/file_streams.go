@@ -0,0 +1,469 @@
+package client
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cespare/xxhash/v2"
+	"github.com/go-logfmt/logfmt"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+
+	"github.com/grafana/loki/v3/pkg/iter"
+	"github.com/grafana/loki/v3/pkg/logproto"
+	"github.com/grafana/loki/v3/pkg/logql"
+	"github.com/grafana/loki/v3/pkg/logql/syntax"
+)
+
+// Input formats of FileClient.
+const (
+	// FileFormatRaw reads a log line per line of input.
+	FileFormatRaw = "raw"
+	// FileFormatJSONL reads the JSON lines written by the jsonl output, and
+	// keeps the timestamp and labels of each line.
+	FileFormatJSONL = "jsonl"
+)
+
+// Timestamp layouts for Unix timestamps, as used by the timestamp stage of
+// Promtail.
+const (
+	layoutUnix   = "Unix"
+	layoutUnixMs = "UnixMs"
+	layoutUnixUs = "UnixUs"
+	layoutUnixNs = "UnixNs"
+)
+
+// FileConfig configures how FileClient splits its input into streams.
+//
+// By default, all lines are part of a single stream, the stream selector of
+// queries is ignored and lines are timestamped at the time they are read.
+// When lines are read as JSON lines or labels are extracted from them, each
+// line is routed into the stream of its labels, and the stream selector of
+// queries selects streams.
+type FileConfig struct {
+	// Format is the format of the input, FileFormatRaw or FileFormatJSONL.
+	Format string
+
+	// LabelsRegexp extracts a label from each named group of the regexp.
+	LabelsRegexp *regexp.Regexp
+	// JSONLabels extracts a label from each key of lines in JSON.
+	JSONLabels []string
+	// LogfmtLabels extracts a label from each key of lines in logfmt.
+	LogfmtLabels []string
+
+	// TimestampField is the named group of LabelsRegexp, or the JSON or
+	// logfmt key, with the timestamp of each line. Lines without the field
+	// have the timestamp of the previous line.
+	TimestampField string
+	// TimestampLayout is the Go time layout of TimestampField, or one of
+	// Unix, UnixMs, UnixUs and UnixNs. It defaults to RFC3339Nano.
+	TimestampLayout string
+}
+
+// Validate returns an error if the config is invalid.
+func (cfg *FileConfig) Validate() error {
+	switch cfg.Format {
+	case "", FileFormatRaw, FileFormatJSONL:
+	default:
+		return fmt.Errorf("unknown input format %q", cfg.Format)
+	}
+
+	extractors := 0
+	if cfg.LabelsRegexp != nil {
+		extractors++
+	}
+	if len(cfg.JSONLabels) > 0 {
+		extractors++
+	}
+	if len(cfg.LogfmtLabels) > 0 {
+		extractors++
+	}
+	if extractors > 1 {
+		return errors.New("labels can be extracted with either a regexp, JSON keys or logfmt keys")
+	}
+
+	for _, name := range slices.Concat(cfg.JSONLabels, cfg.LogfmtLabels) {
+		if !model.LabelName(name).IsValidLegacy() {
+			return fmt.Errorf("%q is not a valid label name", name)
+		}
+	}
+
+	if cfg.TimestampField == "" {
+		return nil
+	}
+	if extractors == 0 {
+		return errors.New("the timestamp field requires a labels regexp, JSON keys or logfmt keys")
+	}
+	if cfg.LabelsRegexp != nil && cfg.LabelsRegexp.SubexpIndex(cfg.TimestampField) < 0 {
+		return fmt.Errorf("the labels regexp has no group named %q", cfg.TimestampField)
+	}
+	return nil
+}
+
+// multiStream returns whether lines are routed into streams by their labels.
+func (cfg *FileConfig) multiStream() bool {
+	return cfg.Format == FileFormatJSONL || cfg.LabelsRegexp != nil || len(cfg.JSONLabels) > 0 || len(cfg.LogfmtLabels) > 0
+}
+
+// streamsQuerier is a logql.Querier for the streams of the lines read from r.
+// The input is read once, on the first query.
+type streamsQuerier struct {
+	r   io.Reader
+	cfg FileConfig
+
+	once    sync.Once
+	streams []logproto.Stream
+	err     error
+}
+
+func (q *streamsQuerier) load() ([]logproto.Stream, error) {
+	q.once.Do(func() {
+		q.streams, q.err = readStreams(io.LimitReader(q.r, defaultMaxFileSize), q.cfg, time.Now())
+	})
+	return q.streams, q.err
+}
+
+// selectStreams returns the streams matching the matchers.
+func (q *streamsQuerier) selectStreams(matchers []*labels.Matcher) ([]logproto.Stream, error) {
+	streams, err := q.load()
+	if err != nil {
+		return nil, err
+	}
+
+	var selected []logproto.Stream
+outer:
+	for _, s := range streams {
+		lbls, err := syntax.ParseLabels(s.Labels)
+		if err != nil {
+			return nil, err
+		}
+		for _, m := range matchers {
+			if !m.Matches(lbls.Get(m.Name)) {
+				continue outer
+			}
+		}
+		selected = append(selected, s)
+	}
+	return selected, nil
+}
+
+func (q *streamsQuerier) SelectLogs(_ context.Context, params logql.SelectLogParams) (iter.EntryIterator, error) {
+	expr, err := params.LogSelector()
+	if err != nil {
+		return nil, fmt.Errorf("failed to extract selector for logs: %w", err)
+	}
+	pipeline, err := expr.Pipeline()
+	if err != nil {
+		return nil, fmt.Errorf("failed to extract pipeline for logs: %w", err)
+	}
+	streams, err := q.selectStreams(expr.Matchers())
+	if err != nil {
+		return nil, err
+	}
+
+	results := map[uint64]*logproto.Stream{}
+	for _, s := range streams {
+		lbls, err := syntax.ParseLabels(s.Labels)
+		if err != nil {
+			return nil, err
+		}
+		sp := pipeline.ForStream(lbls)
+		for _, e := range s.Entries {
+			line, parsedLabels, ok := sp.ProcessString(e.Timestamp.UnixNano(), e.Line, labels.EmptyLabels())
+			if !ok {
+				continue
+			}
+			result, ok := results[parsedLabels.Hash()]
+			if !ok {
+				result = &logproto.Stream{Labels: parsedLabels.String()}
+				results[parsedLabels.Hash()] = result
+			}
+			result.Entries = append(result.Entries, logproto.Entry{Timestamp: e.Timestamp, Line: line})
+		}
+	}
+
+	its := make([]iter.EntryIterator, 0, len(results))
+	for _, s := range results {
+		it := iter.NewTimeRangedIterator(iter.NewStreamIterator(*s), params.Start, params.End)
+		if params.Direction == logproto.BACKWARD {
+			if it, err = iter.NewEntryReversedIter(it); err != nil {
+				return nil, err
+			}
+		}
+		its = append(its, it)
+	}
+	return iter.NewSortEntryIterator(its, params.Direction), nil
+}
+
+func (q *streamsQuerier) SelectSamples(_ context.Context, params logql.SelectSampleParams) (iter.SampleIterator, error) {
+	selector, err := params.LogSelector()
+	if err != nil {
+		return nil, fmt.Errorf("failed to extract selector for samples: %w", err)
+	}
+	expr, err := params.Expr()
+	if err != nil {
+		return nil, err
+	}
+	extractors, err := expr.Extractors()
+	if err != nil {
+		return nil, fmt.Errorf("failed to extract samples: %w", err)
+	}
+	streams, err := q.selectStreams(selector.Matchers())
+	if err != nil {
+		return nil, err
+	}
+
+	results := map[string]*logproto.Series{}
+	for _, s := range streams {
+		lbls, err := syntax.ParseLabels(s.Labels)
+		if err != nil {
+			return nil, err
+		}
+		for _, extractor := range extractors {
+			se := extractor.ForStream(lbls)
+			for _, e := range s.Entries {
+				samples, ok := se.ProcessString(e.Timestamp.UnixNano(), e.Line, labels.EmptyLabels())
+				if !ok {
+					continue
+				}
+				for _, sample := range samples {
+					key := sample.Labels.String()
+					series, ok := results[key]
+					if !ok {
+						series = &logproto.Series{Labels: key, StreamHash: se.BaseLabels().Hash()}
+						results[key] = series
+					}
+					series.Samples = append(series.Samples, logproto.Sample{
+						Timestamp: e.Timestamp.UnixNano(),
+						Value:     sample.Value,
+						Hash:      xxhash.Sum64String(e.Line),
+					})
+				}
+			}
+		}
+	}
+
+	series := make([]logproto.Series, 0, len(results))
+	for _, s := range results {
+		sort.Sort(s)
+		series = append(series, *s)
+	}
+	return iter.NewTimeRangedSampleIterator(
+		iter.NewMultiSeriesIterator(series),
+		params.Start.UnixNano(),
+		params.End.UnixNano()+1,
+	), nil
+}
+
+// readStreams reads the lines of r into streams sorted by their labels, with
+// entries sorted by timestamp. Lines without a timestamp are timestamped at
+// now, one nanosecond apart to keep their order.
+func readStreams(r io.Reader, cfg FileConfig, now time.Time) ([]logproto.Stream, error) {
+	streams := map[string]*logproto.Stream{}
+	var (
+		n      int
+		lastTS time.Time
+	)
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, defaultMaxFileSize)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		n++
+
+		lbls, ts, line, err := parseLine(cfg, line)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", n, err)
+		}
+		switch {
+		case !ts.IsZero():
+			lastTS = ts
+		case cfg.TimestampField != "":
+			if lastTS.IsZero() {
+				return nil, fmt.Errorf("line %d: missing timestamp field %q", n, cfg.TimestampField)
+			}
+			ts = lastTS
+		default:
+			ts = now.Add(time.Duration(n))
+		}
+
+		if lbls.Get(defaultLabelKey) == "" {
+			b := labels.NewBuilder(lbls)
+			b.Set(defaultLabelKey, defaultLabelValue)
+			lbls = b.Labels()
+		}
+
+		key := lbls.String()
+		s, ok := streams[key]
+		if !ok {
+			s = &logproto.Stream{Labels: key, Hash: lbls.Hash()}
+			streams[key] = s
+		}
+		s.Entries = append(s.Entries, logproto.Entry{Timestamp: ts, Line: line})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	result := make([]logproto.Stream, 0, len(streams))
+	for _, s := range streams {
+		sort.SliceStable(s.Entries, func(i, j int) bool {
+			return s.Entries[i].Timestamp.Before(s.Entries[j].Timestamp)
+		})
+		result = append(result, *s)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Labels < result[j].Labels
+	})
+	return result, nil
+}
+
+// jsonlEntry is a line written by the jsonl output.
+type jsonlEntry struct {
+	Timestamp time.Time         `json:"timestamp"`
+	Line      string            `json:"line"`
+	Labels    map[string]string `json:"labels"`
+}
+
+// parseLine returns the labels, timestamp and log line of a line of input.
+// The timestamp is zero if the line has none.
+func parseLine(cfg FileConfig, line string) (labels.Labels, time.Time, string, error) {
+	b := labels.NewBuilder(labels.EmptyLabels())
+	var ts time.Time
+
+	if cfg.Format == FileFormatJSONL {
+		var e jsonlEntry
+		if err := json.Unmarshal([]byte(line), &e); err != nil {
+			return labels.EmptyLabels(), ts, "", fmt.Errorf("parsing JSON line: %w", err)
+		}
+		for name, value := range e.Labels {
+			b.Set(name, value)
+		}
+		ts, line = e.Timestamp, e.Line
+	}
+
+	fields, err := extractFields(cfg, line)
+	if err != nil {
+		return labels.EmptyLabels(), ts, "", err
+	}
+	for name, value := range fields {
+		if name == cfg.TimestampField || value == "" {
+			continue
+		}
+		b.Set(name, value)
+	}
+
+	if value, ok := fields[cfg.TimestampField]; ok && cfg.TimestampField != "" {
+		if ts, err = parseTimestamp(cfg.TimestampLayout, value); err != nil {
+			return labels.EmptyLabels(), ts, "", err
+		}
+	}
+
+	return b.Labels(), ts, line, nil
+}
+
+// extractFields returns the labels and timestamp field extracted from line.
+func extractFields(cfg FileConfig, line string) (map[string]string, error) {
+	fields := map[string]string{}
+	wanted := func(name string, keys []string) bool {
+		return name == cfg.TimestampField || slices.Contains(keys, name)
+	}
+
+	switch {
+	case cfg.LabelsRegexp != nil:
+		match := cfg.LabelsRegexp.FindStringSubmatch(line)
+		if match == nil {
+			return fields, nil
+		}
+		for i, name := range cfg.LabelsRegexp.SubexpNames() {
+			if name != "" {
+				fields[name] = match[i]
+			}
+		}
+
+	case len(cfg.JSONLabels) > 0:
+		var obj map[string]json.RawMessage
+		if err := json.Unmarshal([]byte(line), &obj); err != nil {
+			// Lines which are not JSON objects have no labels.
+			return fields, nil
+		}
+		for name, raw := range obj {
+			if !wanted(name, cfg.JSONLabels) {
+				continue
+			}
+			var s string
+			if err := json.Unmarshal(raw, &s); err == nil {
+				fields[name] = s
+			} else if !bytes.Equal(raw, []byte("null")) {
+				fields[name] = string(raw)
+			}
+		}
+
+	case len(cfg.LogfmtLabels) > 0:
+		dec := logfmt.NewDecoder(strings.NewReader(line))
+		for dec.ScanRecord() {
+			for dec.ScanKeyval() {
+				if name := string(dec.Key()); wanted(name, cfg.LogfmtLabels) {
+					fields[name] = string(dec.Value())
+				}
+			}
+		}
+		// Lines which are not valid logfmt keep the fields decoded before
+		// the error.
+	}
+	return fields, nil
+}
+
+// parseTimestamp parses value with layout, which is a Go time layout or one
+// of the Unix layouts.
+func parseTimestamp(layout, value string) (time.Time, error) {
+	var scale time.Duration
+	switch layout {
+	case "":
+		layout = time.RFC3339Nano
+	case layoutUnix:
+		scale = time.Second
+	case layoutUnixMs:
+		scale = time.Millisecond
+	case layoutUnixUs:
+		scale = time.Microsecond
+	case layoutUnixNs:
+		scale = time.Nanosecond
+	}
+
+	if scale == 0 {
+		ts, err := time.Parse(layout, value)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("parsing timestamp: %w", err)
+		}
+		return ts, nil
+	}
+
+	if scale == time.Second && strings.Contains(value, ".") {
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("parsing timestamp: %w", err)
+		}
+		return time.Unix(0, int64(f*float64(time.Second))), nil
+	}
+	n, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("parsing timestamp: %w", err)
+	}
+	return time.Unix(0, n*int64(scale)), nil
+}
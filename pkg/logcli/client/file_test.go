@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"errors"
 	"io"
+	"regexp"
 	"sort"
 	"strings"
 	"testing"
@@ -221,3 +222,138 @@ func assertStreams(t *testing.T, result loghttp.ResultValue, logLines []string)
 		assert.Equal(t, entry.Line, logLines[i])
 	}
 }
+
+func TestFileClient_LabelsRegexp(t *testing.T) {
+	input := strings.Join([]string{
+		`2024-01-01T00:00:01Z host-a GET /`,
+		`2024-01-01T00:00:02Z host-b GET /metrics`,
+		`2024-01-01T00:00:03Z host-a POST /push`,
+		`	continuation of the previous line`,
+	}, "\n")
+	c, err := NewFileClientWithConfig(io.NopCloser(strings.NewReader(input)), FileConfig{
+		LabelsRegexp:   regexp.MustCompile(`^(?P<ts>\S+) (?P<host>\S+) (?P<method>\S+)`),
+		TimestampField: "ts",
+	})
+	require.NoError(t, err)
+
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	resp, err := c.QueryRange(`sum by (host) (count_over_time({source="logcli"}[1m]))`, 0, start, start.Add(time.Minute), logproto.FORWARD, time.Minute, 0, true)
+	require.NoError(t, err)
+
+	matrix, ok := resp.Data.Result.(loghttp.Matrix)
+	require.True(t, ok)
+	got := map[string]float64{}
+	for _, s := range matrix {
+		got[string(s.Metric["host"])] = float64(s.Values[len(s.Values)-1].Value)
+	}
+	// The continuation line has no labels and the timestamp of the previous line.
+	assert.Equal(t, map[string]float64{"host-a": 2, "host-b": 1, "": 1}, got)
+
+	resp, err = c.QueryRange(`{host="host-a", method="POST"}`, 10, start, start.Add(time.Minute), logproto.FORWARD, 0, 0, true)
+	require.NoError(t, err)
+	assertStreams(t, resp.Data.Result, []string{`2024-01-01T00:00:03Z host-a POST /push`})
+
+	values, err := c.ListLabelValues("host", true, start, start)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"host-a", "host-b"}, values.Data)
+
+	names, err := c.ListLabelNames(true, start, start)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"host", "method", defaultLabelKey}, names.Data)
+}
+
+func TestFileClient_LogfmtLabels(t *testing.T) {
+	input := strings.Join([]string{
+		`level=info msg="loki started" ts=1704067201`,
+		`level=error msg="failed" ts=1704067202`,
+		`level=error msg="failed again" ts=1704067203`,
+	}, "\n")
+	c, err := NewFileClientWithConfig(io.NopCloser(strings.NewReader(input)), FileConfig{
+		LogfmtLabels:    []string{"level"},
+		TimestampField:  "ts",
+		TimestampLayout: "Unix",
+	})
+	require.NoError(t, err)
+
+	ts := time.Unix(1704067203, 0)
+	resp, err := c.Query(`sum by (level) (count_over_time({level="error"}[1m]))`, 0, ts, logproto.FORWARD, true)
+	require.NoError(t, err)
+
+	vector, ok := resp.Data.Result.(loghttp.Vector)
+	require.True(t, ok)
+	require.Len(t, vector, 1)
+	assert.Equal(t, "error", string(vector[0].Metric["level"]))
+	assert.Equal(t, 2.0, float64(vector[0].Value))
+}
+
+func TestFileClient_JSONL(t *testing.T) {
+	input := strings.Join([]string{
+		`{"labels":{"app":"foo","env":"prod"},"line":"foo 1","timestamp":"2024-01-01T00:00:01Z"}`,
+		`{"labels":{"app":"bar","env":"prod"},"line":"bar 1","timestamp":"2024-01-01T00:00:02Z"}`,
+		`{"labels":{"app":"foo","env":"prod"},"line":"foo 2","timestamp":"2024-01-01T00:00:03Z"}`,
+	}, "\n")
+	c, err := NewFileClientWithConfig(io.NopCloser(strings.NewReader(input)), FileConfig{Format: FileFormatJSONL})
+	require.NoError(t, err)
+
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	resp, err := c.QueryRange(`{app="foo"}`, 10, start, start.Add(time.Minute), logproto.BACKWARD, 0, 0, true)
+	require.NoError(t, err)
+
+	streams, ok := resp.Data.Result.(loghttp.Streams)
+	require.True(t, ok)
+	require.Len(t, streams, 1)
+	assert.Equal(t, loghttp.LabelSet{"app": "foo", "env": "prod", defaultLabelKey: defaultLabelValue}, streams[0].Labels)
+	assert.Equal(t, []loghttp.Entry{
+		{Timestamp: start.Add(3 * time.Second), Line: "foo 2"},
+		{Timestamp: start.Add(time.Second), Line: "foo 1"},
+	}, streams[0].Entries)
+
+	series, err := c.Series(nil, start, start, true)
+	require.NoError(t, err)
+	assert.Len(t, series.Data, 2)
+}
+
+func TestFileConfig_Validate(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		cfg  FileConfig
+		err  string
+	}{
+		{name: "default"},
+		{name: "unknown format", cfg: FileConfig{Format: "csv"}, err: "unknown input format"},
+		{name: "several extractors", cfg: FileConfig{JSONLabels: []string{"a"}, LogfmtLabels: []string{"b"}}, err: "either"},
+		{name: "invalid label", cfg: FileConfig{JSONLabels: []string{"a-b"}}, err: "not a valid label name"},
+		{name: "timestamp without extractor", cfg: FileConfig{TimestampField: "ts"}, err: "requires"},
+		{name: "missing group", cfg: FileConfig{LabelsRegexp: regexp.MustCompile(`(?P<host>\S+)`), TimestampField: "ts"}, err: "no group"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.cfg.Validate()
+			if tc.err == "" {
+				require.NoError(t, err)
+				return
+			}
+			require.ErrorContains(t, err, tc.err)
+		})
+	}
+}
+
+func TestParseTimestamp(t *testing.T) {
+	expected := time.Date(2024, 1, 1, 0, 0, 1, 500000000, time.UTC)
+	for _, tc := range []struct {
+		layout, value string
+	}{
+		{"", "2024-01-01T00:00:01.5Z"},
+		{time.DateTime + ".000", "2024-01-01 00:00:01.500"},
+		{"Unix", "1704067201.5"},
+		{"UnixMs", "1704067201500"},
+		{"UnixUs", "1704067201500000"},
+		{"UnixNs", "1704067201500000000"},
+	} {
+		ts, err := parseTimestamp(tc.layout, tc.value)
+		require.NoError(t, err)
+		assert.True(t, expected.Equal(ts), "%s: got %s", tc.value, ts)
+	}
+
+	_, err := parseTimestamp("Unix", "yesterday")
+	require.Error(t, err)
+}
@@ -28,19 +28,7 @@ type FieldsQuery struct {
 
 // DoQuery executes the query and prints out the results
 func (q *FieldsQuery) Do(c client.Client, outputMode string) {
-	var resp *loghttp.DetectedFieldsResponse
-	var err error
-
-	resp, err = c.GetDetectedFields(
-		q.QueryString,
-		q.FieldName,
-		q.Limit,
-		q.LineLimit,
-		q.Start,
-		q.End,
-		q.Step,
-		q.Quiet,
-	)
+	resp, err := q.Fetch(c)
 	if err != nil {
 		log.Fatalf("Error doing request: %+v", err)
 	}
@@ -70,3 +58,18 @@ func (q *FieldsQuery) Do(c client.Client, outputMode string) {
 		fmt.Println(strings.Join(output, "\n"))
 	}
 }
+
+// Fetch executes the query and returns the detected fields, or the values of
+// FieldName if set.
+func (q *FieldsQuery) Fetch(c client.Client) (*loghttp.DetectedFieldsResponse, error) {
+	return c.GetDetectedFields(
+		q.QueryString,
+		q.FieldName,
+		q.Limit,
+		q.LineLimit,
+		q.Start,
+		q.End,
+		q.Step,
+		q.Quiet,
+	)
+}
@@ -0,0 +1,285 @@
+// Package diff compares the results of a LogQL query run against two Loki
+// endpoints, or over two time ranges.
+package diff
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/prometheus/common/model"
+
+	"github.com/grafana/loki/v3/pkg/logcli/client"
+	"github.com/grafana/loki/v3/pkg/loghttp"
+	"github.com/grafana/loki/v3/pkg/logproto"
+	"github.com/grafana/loki/v3/pkg/util/comparator"
+)
+
+// Query holds the settings of a diff.
+type Query struct {
+	QueryString string
+	Start       time.Time
+	End         time.Time
+	Limit       int
+	Forward     bool
+	Step        time.Duration
+	Interval    time.Duration
+
+	// Offset moves the time range of the query against the second client back
+	// by Offset. The timestamps of its results are moved forward by Offset
+	// before comparing them.
+	Offset time.Duration
+
+	// NameA and NameB name the clients in the output.
+	NameA string
+	NameB string
+
+	// Options configures the comparison of sample values.
+	Options comparator.SampleComparisonOptions
+}
+
+// Mismatch is a sample or log entry which differs between the results. A and
+// B are the value or log line of each result, and are empty if the result has
+// no sample or entry at the timestamp.
+type Mismatch struct {
+	Labels    string
+	Timestamp time.Time
+	A, B      string
+}
+
+// Result is the comparison of the results of a query.
+type Result struct {
+	NameA, NameB string
+	ResultType   loghttp.ResultType
+
+	// Series is the number of series or streams of both results.
+	Series int
+	// Values is the number of samples or log entries of both results.
+	Values int
+	// OnlyA and OnlyB are the labels of the series or streams which are only
+	// part of one result.
+	OnlyA, OnlyB []string
+	// Mismatches are the samples or log entries which differ between series
+	// or streams of both results.
+	Mismatches []Mismatch
+}
+
+// Equal returns whether the results are equal.
+func (r *Result) Equal() bool {
+	return len(r.OnlyA) == 0 && len(r.OnlyB) == 0 && len(r.Mismatches) == 0
+}
+
+// Do runs the query against both clients and compares the results.
+func (q *Query) Do(a, b client.Client) (*Result, error) {
+	respA, err := q.queryRange(a, 0)
+	if err != nil {
+		return nil, fmt.Errorf("querying %s: %w", q.NameA, err)
+	}
+	respB, err := q.queryRange(b, q.Offset)
+	if err != nil {
+		return nil, fmt.Errorf("querying %s: %w", q.NameB, err)
+	}
+	return q.compare(respA, respB)
+}
+
+func (q *Query) queryRange(c client.Client, offset time.Duration) (*loghttp.QueryResponse, error) {
+	direction := logproto.BACKWARD
+	if q.Forward {
+		direction = logproto.FORWARD
+	}
+	return c.QueryRange(q.QueryString, q.Limit, q.Start.Add(-offset), q.End.Add(-offset), direction, q.Step, q.Interval, true)
+}
+
+func (q *Query) compare(a, b *loghttp.QueryResponse) (*Result, error) {
+	if a.Data.ResultType != b.Data.ResultType {
+		return nil, fmt.Errorf("%s returned a %s but %s returned a %s", q.NameA, a.Data.ResultType, q.NameB, b.Data.ResultType)
+	}
+
+	r := &Result{NameA: q.NameA, NameB: q.NameB, ResultType: a.Data.ResultType}
+	switch resultA := a.Data.Result.(type) {
+	case loghttp.Matrix:
+		q.compareMatrix(r, resultA, b.Data.Result.(loghttp.Matrix))
+	case loghttp.Streams:
+		q.compareStreams(r, resultA, b.Data.Result.(loghttp.Streams))
+	default:
+		return nil, fmt.Errorf("unsupported result type %s", a.Data.ResultType)
+	}
+
+	sort.Strings(r.OnlyA)
+	sort.Strings(r.OnlyB)
+	sort.SliceStable(r.Mismatches, func(i, j int) bool {
+		if r.Mismatches[i].Labels != r.Mismatches[j].Labels {
+			return r.Mismatches[i].Labels < r.Mismatches[j].Labels
+		}
+		return r.Mismatches[i].Timestamp.Before(r.Mismatches[j].Timestamp)
+	})
+	return r, nil
+}
+
+// skip returns whether the sample or entry at ts is outside of the window
+// compared by the options.
+func (q *Query) skip(ts time.Time) bool {
+	return q.Options.SkipSample(ts, q.End)
+}
+
+func (q *Query) compareMatrix(r *Result, a, b loghttp.Matrix) {
+	seriesB := make(map[model.Fingerprint]model.SampleStream, len(b))
+	for _, s := range b {
+		seriesB[s.Metric.Fingerprint()] = s
+	}
+
+	for _, sa := range a {
+		r.Series++
+		r.Values += len(sa.Values)
+
+		sb, ok := seriesB[sa.Metric.Fingerprint()]
+		if !ok {
+			r.OnlyA = append(r.OnlyA, sa.Metric.String())
+			continue
+		}
+		delete(seriesB, sa.Metric.Fingerprint())
+		r.Values += len(sb.Values)
+
+		valuesB := make(map[model.Time]model.SampleValue, len(sb.Values))
+		for _, v := range sb.Values {
+			valuesB[v.Timestamp.Add(q.Offset)] = v.Value
+		}
+		for _, va := range sa.Values {
+			vb, ok := valuesB[va.Timestamp]
+			delete(valuesB, va.Timestamp)
+			ts := va.Timestamp.Time().UTC()
+			if q.skip(ts) {
+				continue
+			}
+			if !ok {
+				r.Mismatches = append(r.Mismatches, Mismatch{Labels: sa.Metric.String(), Timestamp: ts, A: va.Value.String()})
+			} else if !comparator.CompareSampleValue(va.Value, vb, q.Options) {
+				r.Mismatches = append(r.Mismatches, Mismatch{Labels: sa.Metric.String(), Timestamp: ts, A: va.Value.String(), B: vb.String()})
+			}
+		}
+		for t, vb := range valuesB {
+			if ts := t.Time().UTC(); !q.skip(ts) {
+				r.Mismatches = append(r.Mismatches, Mismatch{Labels: sa.Metric.String(), Timestamp: ts, B: vb.String()})
+			}
+		}
+	}
+
+	for _, sb := range seriesB {
+		r.Series++
+		r.Values += len(sb.Values)
+		r.OnlyB = append(r.OnlyB, sb.Metric.String())
+	}
+}
+
+// entryKey identifies a log entry of a stream.
+type entryKey struct {
+	ts   int64
+	line string
+}
+
+func (q *Query) compareStreams(r *Result, a, b loghttp.Streams) {
+	streamsB := make(map[string]loghttp.Stream, len(b))
+	for _, s := range b {
+		streamsB[s.Labels.String()] = s
+	}
+
+	for _, sa := range a {
+		lbls := sa.Labels.String()
+		r.Series++
+		r.Values += len(sa.Entries)
+
+		sb, ok := streamsB[lbls]
+		if !ok {
+			r.OnlyA = append(r.OnlyA, lbls)
+			continue
+		}
+		delete(streamsB, lbls)
+		r.Values += len(sb.Entries)
+
+		// Entries are compared as multisets, since the order of entries with
+		// the same timestamp is undefined.
+		entriesB := make(map[entryKey]int, len(sb.Entries))
+		for _, e := range sb.Entries {
+			entriesB[entryKey{e.Timestamp.Add(q.Offset).UnixNano(), e.Line}]++
+		}
+		for _, e := range sa.Entries {
+			key := entryKey{e.Timestamp.UnixNano(), e.Line}
+			if entriesB[key] > 0 {
+				entriesB[key]--
+				continue
+			}
+			if !q.skip(e.Timestamp) {
+				r.Mismatches = append(r.Mismatches, Mismatch{Labels: lbls, Timestamp: e.Timestamp, A: e.Line})
+			}
+		}
+		for _, e := range sb.Entries {
+			ts := e.Timestamp.Add(q.Offset)
+			key := entryKey{ts.UnixNano(), e.Line}
+			if entriesB[key] == 0 {
+				continue
+			}
+			entriesB[key]--
+			if !q.skip(ts) {
+				r.Mismatches = append(r.Mismatches, Mismatch{Labels: lbls, Timestamp: ts, B: e.Line})
+			}
+		}
+	}
+
+	for lbls, sb := range streamsB {
+		r.Series++
+		r.Values += len(sb.Entries)
+		r.OnlyB = append(r.OnlyB, lbls)
+	}
+}
+
+// Print prints a summary of the result followed by at most maxMismatches
+// mismatches, or all mismatches if maxMismatches is zero.
+func (r *Result) Print(w io.Writer, maxMismatches int) {
+	kind, values := "series", "samples"
+	if r.ResultType == loghttp.ResultTypeStream {
+		kind, values = "streams", "entries"
+	}
+
+	if r.Equal() {
+		fmt.Fprintf(w, "Results of %s and %s are equal: %d %s, %d %s\n", r.NameA, r.NameB, r.Series, kind, r.Values, values)
+		return
+	}
+
+	fmt.Fprintf(w, "Results of %s and %s differ:\n", r.NameA, r.NameB)
+	fmt.Fprintf(w, "  %s: %d, %d only in %s, %d only in %s\n", kind, r.Series, len(r.OnlyA), r.NameA, len(r.OnlyB), r.NameB)
+	fmt.Fprintf(w, "  %s: %d, %d mismatches\n", values, r.Values, len(r.Mismatches))
+
+	printLabels := func(name string, lbls []string) {
+		if len(lbls) == 0 {
+			return
+		}
+		fmt.Fprintf(w, "\nOnly in %s:\n", name)
+		for _, l := range lbls {
+			fmt.Fprintf(w, "  %s\n", l)
+		}
+	}
+	printLabels(r.NameA, r.OnlyA)
+	printLabels(r.NameB, r.OnlyB)
+
+	if len(r.Mismatches) == 0 {
+		return
+	}
+	fmt.Fprintf(w, "\nMismatches:\n")
+	for i, m := range r.Mismatches {
+		if maxMismatches > 0 && i == maxMismatches {
+			fmt.Fprintf(w, "  ... and %d more\n", len(r.Mismatches)-i)
+			break
+		}
+		fmt.Fprintf(w, "  %s %s\n", m.Labels, m.Timestamp.UTC().Format(time.RFC3339Nano))
+		fmt.Fprintf(w, "    %s: %s\n", r.NameA, missing(m.A))
+		fmt.Fprintf(w, "    %s: %s\n", r.NameB, missing(m.B))
+	}
+}
+
+func missing(s string) string {
+	if s == "" {
+		return "<missing>"
+	}
+	return s
+}
@@ -0,0 +1,173 @@
+package diff
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/loki/v3/pkg/logcli/client"
+	"github.com/grafana/loki/v3/pkg/loghttp"
+	"github.com/grafana/loki/v3/pkg/logproto"
+	"github.com/grafana/loki/v3/pkg/util/comparator"
+)
+
+var ts = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func TestDiff_Matrix(t *testing.T) {
+	a := &fakeClient{result: loghttp.Matrix{
+		series(model.Metric{"app": "foo"}, 1, 2, 3),
+		series(model.Metric{"app": "bar"}, 1),
+	}}
+	b := &fakeClient{result: loghttp.Matrix{
+		series(model.Metric{"app": "foo"}, 1, 2.05, 4),
+		series(model.Metric{"app": "baz"}, 1),
+	}}
+	q := newTestQuery()
+	q.Options = comparator.SampleComparisonOptions{Tolerance: 0.1}
+
+	r, err := q.Do(a, b)
+	require.NoError(t, err)
+	require.False(t, r.Equal())
+	require.Equal(t, 3, r.Series)
+	require.Equal(t, 8, r.Values)
+	require.Equal(t, []string{`{app="bar"}`}, r.OnlyA)
+	require.Equal(t, []string{`{app="baz"}`}, r.OnlyB)
+	// The second sample is equal within the tolerance.
+	require.Equal(t, []Mismatch{
+		{Labels: `{app="foo"}`, Timestamp: ts.Add(2 * time.Minute), A: "3", B: "4"},
+	}, r.Mismatches)
+
+	var buf bytes.Buffer
+	r.Print(&buf, 0)
+	require.Equal(t, `Results of a and b differ:
+  series: 3, 1 only in a, 1 only in b
+  samples: 8, 1 mismatches
+
+Only in a:
+  {app="bar"}
+
+Only in b:
+  {app="baz"}
+
+Mismatches:
+  {app="foo"} 2024-01-01T00:02:00Z
+    a: 3
+    b: 4
+`, buf.String())
+}
+
+func TestDiff_MatrixSkipRecentSamples(t *testing.T) {
+	a := &fakeClient{result: loghttp.Matrix{series(model.Metric{"app": "foo"}, 1, 2, 3)}}
+	b := &fakeClient{result: loghttp.Matrix{series(model.Metric{"app": "foo"}, 1, 2)}}
+	q := newTestQuery()
+	q.End = ts.Add(2 * time.Minute)
+	q.Options = comparator.SampleComparisonOptions{SkipRecentSamples: time.Minute}
+
+	r, err := q.Do(a, b)
+	require.NoError(t, err)
+	require.True(t, r.Equal())
+}
+
+func TestDiff_Offset(t *testing.T) {
+	a := &fakeClient{result: loghttp.Matrix{series(model.Metric{"app": "foo"}, 1, 2)}}
+	b := &fakeClient{result: loghttp.Matrix{{
+		Metric: model.Metric{"app": "foo"},
+		Values: []model.SamplePair{
+			{Timestamp: model.TimeFromUnixNano(ts.Add(-24 * time.Hour).UnixNano()), Value: 1},
+			{Timestamp: model.TimeFromUnixNano(ts.Add(-24*time.Hour + time.Minute).UnixNano()), Value: 2},
+		},
+	}}}
+	q := newTestQuery()
+	q.Offset = 24 * time.Hour
+
+	r, err := q.Do(a, b)
+	require.NoError(t, err)
+	require.True(t, r.Equal())
+	// The second query is moved back by the offset.
+	require.Equal(t, []time.Time{ts}, a.starts)
+	require.Equal(t, []time.Time{ts.Add(-24 * time.Hour)}, b.starts)
+
+	// Samples of the second result are moved forward by the offset, so a
+	// result differs from itself.
+	r, err = q.Do(a, a)
+	require.NoError(t, err)
+	require.False(t, r.Equal())
+	require.Len(t, r.Mismatches, 4)
+}
+
+func TestDiff_Streams(t *testing.T) {
+	a := &fakeClient{result: loghttp.Streams{
+		{Labels: loghttp.LabelSet{"app": "foo"}, Entries: []loghttp.Entry{
+			{Timestamp: ts, Line: "1"},
+			{Timestamp: ts, Line: "2"},
+			{Timestamp: ts.Add(time.Second), Line: "3"},
+		}},
+	}}
+	b := &fakeClient{result: loghttp.Streams{
+		{Labels: loghttp.LabelSet{"app": "foo"}, Entries: []loghttp.Entry{
+			// Entries with the same timestamp may be in any order.
+			{Timestamp: ts, Line: "2"},
+			{Timestamp: ts, Line: "1"},
+			{Timestamp: ts.Add(time.Second), Line: "three"},
+		}},
+	}}
+
+	r, err := newTestQuery().Do(a, b)
+	require.NoError(t, err)
+	require.Equal(t, []Mismatch{
+		{Labels: `{app="foo"}`, Timestamp: ts.Add(time.Second), A: "3"},
+		{Labels: `{app="foo"}`, Timestamp: ts.Add(time.Second), B: "three"},
+	}, r.Mismatches)
+
+	var buf bytes.Buffer
+	r.Print(&buf, 1)
+	require.Contains(t, buf.String(), "  entries: 6, 2 mismatches\n")
+	require.Contains(t, buf.String(), "    b: <missing>\n  ... and 1 more\n")
+}
+
+func TestDiff_ResultTypes(t *testing.T) {
+	a := &fakeClient{result: loghttp.Matrix{}}
+	b := &fakeClient{result: loghttp.Streams{}}
+
+	_, err := newTestQuery().Do(a, b)
+	require.ErrorContains(t, err, "a returned a matrix but b returned a streams")
+}
+
+func newTestQuery() *Query {
+	return &Query{
+		QueryString: `{app=~".+"}`,
+		Start:       ts,
+		End:         ts.Add(time.Hour),
+		Limit:       100,
+		Step:        time.Minute,
+		NameA:       "a",
+		NameB:       "b",
+	}
+}
+
+// series returns a series with a sample per minute from ts.
+func series(metric model.Metric, values ...float64) model.SampleStream {
+	s := model.SampleStream{Metric: metric}
+	for i, v := range values {
+		s.Values = append(s.Values, model.SamplePair{
+			Timestamp: model.TimeFromUnixNano(ts.Add(time.Duration(i) * time.Minute).UnixNano()),
+			Value:     model.SampleValue(v),
+		})
+	}
+	return s
+}
+
+type fakeClient struct {
+	client.Client
+
+	result loghttp.ResultValue
+	starts []time.Time
+}
+
+func (c *fakeClient) QueryRange(_ string, _ int, start, _ time.Time, _ logproto.Direction, _, _ time.Duration, _ bool) (*loghttp.QueryResponse, error) {
+	c.starts = append(c.starts, start)
+	return &loghttp.QueryResponse{Data: loghttp.QueryResponseData{ResultType: c.result.Type(), Result: c.result}}, nil
+}
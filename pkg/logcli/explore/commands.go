@@ -0,0 +1,216 @@
+package explore
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/grafana/loki/v3/pkg/logcli/client"
+	"github.com/grafana/loki/v3/pkg/logcli/detected"
+	"github.com/grafana/loki/v3/pkg/logcli/volume"
+	"github.com/grafana/loki/v3/pkg/loghttp"
+	"github.com/grafana/loki/v3/pkg/logproto"
+)
+
+// volumeLimit is the maximum number of series of volume queries. The
+// histogram sums the volume of all returned series.
+const volumeLimit = 1000
+
+// Messages sent by commands when requests to Loki complete. Messages with a
+// generation older than the current generation of the explorer are results of
+// superseded queries and are dropped.
+type (
+	labelNamesMsg struct {
+		names []string
+	}
+
+	labelValuesMsg struct {
+		name   string
+		values []string
+	}
+
+	logsMsg struct {
+		gen     int
+		entries []entry
+		next    *page
+	}
+
+	metricsMsg struct {
+		gen    int
+		matrix loghttp.Matrix
+	}
+
+	volumeMsg struct {
+		gen    int
+		matrix loghttp.Matrix
+	}
+
+	fieldsMsg struct {
+		field string
+		resp  *loghttp.DetectedFieldsResponse
+	}
+
+	// queryErrMsg is sent when a query or its volume query fails.
+	queryErrMsg struct {
+		gen int
+		err error
+	}
+
+	// errMsg is sent when listing labels or detecting fields fails.
+	errMsg struct {
+		err error
+	}
+)
+
+// entry is a log entry with the labels of its stream.
+type entry struct {
+	labels loghttp.LabelSet
+	loghttp.Entry
+}
+
+func (e entry) key() string {
+	return fmt.Sprintf("%d %s %s", e.Timestamp.UnixNano(), e.labels, e.Line)
+}
+
+// page is a page of log results. Entries sharing the timestamp of the last
+// entry of the previous page are returned again by the query of the next
+// page, so they are skipped.
+type page struct {
+	start, end time.Time
+	skip       map[string]struct{}
+}
+
+func fetchLabelNames(c client.Client, q *Query) tea.Cmd {
+	return func() tea.Msg {
+		resp, err := c.ListLabelNames(true, q.Start, q.End)
+		if err != nil {
+			return errMsg{err: fmt.Errorf("listing label names: %w", err)}
+		}
+		return labelNamesMsg{names: resp.Data}
+	}
+}
+
+func fetchLabelValues(c client.Client, q *Query, name string) tea.Cmd {
+	return func() tea.Msg {
+		resp, err := c.ListLabelValues(name, true, q.Start, q.End)
+		if err != nil {
+			return errMsg{err: fmt.Errorf("listing values of label %s: %w", name, err)}
+		}
+		return labelValuesMsg{name: name, values: resp.Data}
+	}
+}
+
+func fetchLogs(c client.Client, q *Query, gen int, query string, p page) tea.Cmd {
+	return func() tea.Msg {
+		direction := logproto.BACKWARD
+		if q.Forward {
+			direction = logproto.FORWARD
+		}
+
+		resp, err := c.QueryRange(query, q.Limit+len(p.skip), p.start, p.end, direction, 0, 0, true)
+		if err != nil {
+			return queryErrMsg{gen: gen, err: err}
+		}
+		streams, ok := resp.Data.Result.(loghttp.Streams)
+		if !ok {
+			return queryErrMsg{gen: gen, err: fmt.Errorf("unexpected result type %s for log query", resp.Data.ResultType)}
+		}
+
+		var entries []entry
+		for _, s := range streams {
+			for _, e := range s.Entries {
+				e := entry{labels: s.Labels, Entry: e}
+				if _, ok := p.skip[e.key()]; !ok {
+					entries = append(entries, e)
+				}
+			}
+		}
+		sort.SliceStable(entries, func(i, j int) bool {
+			if q.Forward {
+				return entries[i].Timestamp.Before(entries[j].Timestamp)
+			}
+			return entries[i].Timestamp.After(entries[j].Timestamp)
+		})
+		if len(entries) > q.Limit {
+			entries = entries[:q.Limit]
+		}
+
+		msg := logsMsg{gen: gen, entries: entries}
+		if len(entries) == q.Limit {
+			msg.next = nextPage(p, entries, q.Forward)
+		}
+		return msg
+	}
+}
+
+// nextPage returns the page following p, which ended with entries.
+func nextPage(p page, entries []entry, forward bool) *page {
+	last := entries[len(entries)-1].Timestamp
+	next := &page{start: p.start, end: p.end, skip: make(map[string]struct{})}
+	if forward {
+		next.start = last
+	} else {
+		// The end of range queries is exclusive.
+		next.end = last.Add(time.Nanosecond)
+	}
+	for _, e := range entries {
+		if e.Timestamp.Equal(last) {
+			next.skip[e.key()] = struct{}{}
+		}
+	}
+	return next
+}
+
+func fetchMetrics(c client.Client, q *Query, gen int, query string) tea.Cmd {
+	return func() tea.Msg {
+		resp, err := c.QueryRange(query, q.Limit, q.Start, q.End, logproto.BACKWARD, q.step(), 0, true)
+		if err != nil {
+			return queryErrMsg{gen: gen, err: err}
+		}
+		matrix, ok := resp.Data.Result.(loghttp.Matrix)
+		if !ok {
+			return queryErrMsg{gen: gen, err: fmt.Errorf("unexpected result type %s for metric query", resp.Data.ResultType)}
+		}
+		return metricsMsg{gen: gen, matrix: matrix}
+	}
+}
+
+func fetchVolume(c client.Client, q *Query, gen int, selector string) tea.Cmd {
+	return func() tea.Msg {
+		resp, err := c.GetVolumeRange(&volume.Query{
+			QueryString: selector,
+			Start:       q.Start,
+			End:         q.End,
+			Step:        q.step(),
+			Quiet:       true,
+			Limit:       volumeLimit,
+		})
+		if err != nil {
+			return queryErrMsg{gen: gen, err: fmt.Errorf("querying volume: %w", err)}
+		}
+		matrix, _ := resp.Data.Result.(loghttp.Matrix)
+		return volumeMsg{gen: gen, matrix: matrix}
+	}
+}
+
+func fetchFields(c client.Client, q *Query, query, field string) tea.Cmd {
+	return func() tea.Msg {
+		fq := &detected.FieldsQuery{
+			QueryString: query,
+			Start:       q.Start,
+			End:         q.End,
+			Limit:       q.FieldLimit,
+			LineLimit:   q.LineLimit,
+			Step:        q.step(),
+			Quiet:       true,
+			FieldName:   field,
+		}
+		resp, err := fq.Fetch(c)
+		if err != nil {
+			return errMsg{err: fmt.Errorf("detecting fields: %w", err)}
+		}
+		return fieldsMsg{field: field, resp: resp}
+	}
+}
@@ -0,0 +1,48 @@
+// Package explore implements an interactive terminal UI to browse labels,
+// edit LogQL queries and page through their results.
+package explore
+
+import (
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/grafana/loki/v3/pkg/logcli/client"
+)
+
+// histogramBuckets is the number of steps of the time range of the explorer
+// when no step is set.
+const histogramBuckets = 100
+
+// Query holds the settings of the explorer.
+type Query struct {
+	// QueryString is the initial query, which may be empty.
+	QueryString string
+	Start       time.Time
+	End         time.Time
+	// Limit is the number of log entries per page and the maximum number of
+	// series of metric queries.
+	Limit   int
+	Forward bool
+	// Step is the resolution of metric queries and of the volume histogram.
+	// If zero, the time range is split into histogramBuckets steps.
+	Step time.Duration
+
+	// FieldLimit and LineLimit configure detected fields queries.
+	FieldLimit int
+	LineLimit  int
+}
+
+func (q *Query) step() time.Duration {
+	if q.Step > 0 {
+		return q.Step
+	}
+	step := q.End.Sub(q.Start) / histogramBuckets
+	return max(step.Round(time.Second), time.Second)
+}
+
+// Run starts the explorer and blocks until it exits.
+func Run(c client.Client, q *Query) error {
+	_, err := tea.NewProgram(newExplorer(c, q), tea.WithAltScreen()).Run()
+	return err
+}
@@ -0,0 +1,320 @@
+package explore
+
+import (
+	"sort"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/loki/v3/pkg/logcli/client"
+	"github.com/grafana/loki/v3/pkg/logcli/volume"
+	"github.com/grafana/loki/v3/pkg/loghttp"
+	"github.com/grafana/loki/v3/pkg/logproto"
+)
+
+func TestAddMatcher(t *testing.T) {
+	for _, tc := range []struct {
+		query, expected string
+	}{
+		{``, `{app="foo"}`},
+		{`{env="prod"}`, `{env="prod", app="foo"}`},
+		{`{app="bar", env="prod"} |= "error"`, `{env="prod", app="foo"} |= "error"`},
+		{`sum(rate({env="prod"}[1m]))`, `sum(rate({env="prod", app="foo"}[1m]))`},
+	} {
+		t.Run(tc.query, func(t *testing.T) {
+			query, err := addMatcher(tc.query, "app", "foo")
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, query)
+		})
+	}
+
+	_, err := addMatcher(`{env="prod"`, "app", "foo")
+	require.Error(t, err)
+}
+
+func TestStreamSelector(t *testing.T) {
+	selector, err := streamSelector(`sum by (level) (count_over_time({env="prod"} | logfmt [5m]))`)
+	require.NoError(t, err)
+	require.Equal(t, `{env="prod"}`, selector)
+}
+
+func TestMetricQuery(t *testing.T) {
+	query, err := metricQuery(`{env="prod"} |= "error"`, time.Minute)
+	require.NoError(t, err)
+	require.Equal(t, `sum(count_over_time({env="prod"} |= "error" [1m0s]))`, query)
+
+	kind, err := validate(query)
+	require.NoError(t, err)
+	require.Equal(t, kindMetrics, kind)
+
+	query, err = metricQuery(`rate({env="prod"}[1m])`, time.Minute)
+	require.NoError(t, err)
+	require.Equal(t, `rate({env="prod"}[1m])`, query)
+}
+
+func TestSparkline(t *testing.T) {
+	require.Equal(t, "▁▄█", sparkline([]float64{0, 1, 2}, 10))
+	// Values are summed per block when there are more values than blocks.
+	require.Equal(t, "▁█", sparkline([]float64{0, 1, 2, 2}, 2))
+	require.Equal(t, "█ █", sparkline([]float64{1, nan(), 1}, 3))
+	require.Equal(t, "", sparkline(nil, 3))
+}
+
+func TestModel_LabelBrowser(t *testing.T) {
+	c := &fakeClient{
+		names:  []string{"app", "env"},
+		values: map[string][]string{"env": {"dev", "prod"}},
+	}
+	m := newTestModel(c, "")
+
+	exec(m, fetchLabelNames(c, m.q))
+	require.Equal(t, []string{"app", "env"}, m.labelItems())
+
+	m.setFocus(focusLabels)
+	press(m, "down")
+	press(m, "enter")
+	require.Equal(t, "env", m.labelName)
+	require.Equal(t, []string{"dev", "prod"}, m.labelItems())
+
+	// Selecting a value adds it to the query and runs it.
+	press(m, "down")
+	press(m, "enter")
+	require.Equal(t, `{env="prod"}`, m.input.Value())
+	require.Equal(t, []string{`{env="prod"}`}, c.queries)
+
+	// Going back lists the label names with the cursor on the label.
+	press(m, "esc")
+	require.Equal(t, "", m.labelName)
+	require.Equal(t, 1, m.labelCursor)
+}
+
+func TestModel_Validation(t *testing.T) {
+	m := newTestModel(&fakeClient{}, "")
+
+	for _, r := range `{app="foo"` {
+		m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+	}
+	require.Error(t, m.inputErr)
+
+	// Invalid queries are not run.
+	require.Nil(t, press(m, "enter"))
+
+	m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("}")})
+	require.NoError(t, m.inputErr)
+}
+
+func TestModel_LogPages(t *testing.T) {
+	ts := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	c := &fakeClient{
+		streams: loghttp.Streams{
+			{Labels: loghttp.LabelSet{"app": "foo"}, Entries: []loghttp.Entry{
+				{Timestamp: ts, Line: "1"},
+				{Timestamp: ts.Add(time.Second), Line: "2"},
+				{Timestamp: ts.Add(time.Second), Line: "3"},
+				{Timestamp: ts.Add(2 * time.Second), Line: "4"},
+			}},
+		},
+		volume: loghttp.Matrix{{Values: []model.SamplePair{{Timestamp: model.TimeFromUnix(ts.Unix()), Value: 100}}}},
+	}
+	m := newTestModel(c, `{app="foo"}`)
+	m.q.Limit = 2
+
+	press(m, "enter")
+	require.Equal(t, []string{"4", "2"}, lines(m.entries))
+	require.NotNil(t, m.next)
+	require.NotNil(t, m.volume)
+
+	// The next page repeats the entries at the timestamp of the last entry of
+	// the page, which are skipped.
+	m.setFocus(focusResults)
+	press(m, "n")
+	require.Equal(t, []string{"3", "1"}, lines(m.entries))
+	require.Len(t, m.pages, 2)
+	require.Contains(t, m.View(), "Page 2, 2 entries")
+
+	press(m, "n")
+	require.Empty(t, m.entries)
+	require.Nil(t, m.next)
+
+	press(m, "p")
+	press(m, "p")
+	require.Equal(t, []string{"4", "2"}, lines(m.entries))
+	require.Len(t, m.pages, 1)
+}
+
+func TestModel_MetricView(t *testing.T) {
+	c := &fakeClient{
+		matrix: loghttp.Matrix{{Metric: model.Metric{"app": "foo"}, Values: []model.SamplePair{{Value: 1}}}},
+	}
+	m := newTestModel(c, `{app="foo"}`)
+
+	press(m, "ctrl+t")
+	require.Equal(t, kindMetrics, m.mode)
+	require.Equal(t, c.matrix, m.matrix)
+	require.Equal(t, []string{`sum(count_over_time({app="foo"} [1m0s]))`}, c.queries)
+
+	// Metric queries can't be shown in the log view.
+	m.input.SetValue(`rate({app="foo"}[1m])`)
+	press(m, "ctrl+t")
+	require.Equal(t, kindLogs, m.mode)
+	require.ErrorIs(t, m.err, errMetricQueryInLogsView)
+}
+
+func TestModel_DetectedFields(t *testing.T) {
+	c := &fakeClient{
+		fields: map[string]*loghttp.DetectedFieldsResponse{
+			"":      {Fields: []loghttp.DetectedField{{Label: "level", Cardinality: 2}}},
+			"level": {Values: []string{"info", "error"}},
+		},
+	}
+	m := newTestModel(c, `{app="foo"}`)
+
+	press(m, "ctrl+f")
+	require.Error(t, m.err, "fields need a query to be run first")
+	require.False(t, m.showFields)
+
+	press(m, "enter")
+	press(m, "ctrl+f")
+	require.True(t, m.showFields)
+	require.Equal(t, c.fields[""], m.fields)
+
+	press(m, "enter")
+	require.Equal(t, "level", m.fieldName)
+	require.Equal(t, []string{"info", "error"}, m.fieldValues)
+
+	press(m, "esc")
+	press(m, "esc")
+	require.False(t, m.showFields)
+}
+
+func newTestModel(c client.Client, query string) *explorer {
+	m := newExplorer(c, &Query{
+		QueryString: query,
+		Start:       time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		End:         time.Date(2024, 1, 1, 1, 0, 0, 0, time.UTC),
+		Limit:       100,
+		Step:        time.Minute,
+	})
+	m.Update(tea.WindowSizeMsg{Width: 120, Height: 40})
+	return m
+}
+
+// press sends a key to m and runs the resulting commands.
+func press(m *explorer, key string) tea.Cmd {
+	var msg tea.KeyMsg
+	switch key {
+	case "enter":
+		msg = tea.KeyMsg{Type: tea.KeyEnter}
+	case "esc":
+		msg = tea.KeyMsg{Type: tea.KeyEsc}
+	case "down":
+		msg = tea.KeyMsg{Type: tea.KeyDown}
+	case "ctrl+t":
+		msg = tea.KeyMsg{Type: tea.KeyCtrlT}
+	case "ctrl+f":
+		msg = tea.KeyMsg{Type: tea.KeyCtrlF}
+	default:
+		msg = tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(key)}
+	}
+
+	_, cmd := m.Update(msg)
+	exec(m, cmd)
+	return cmd
+}
+
+// exec runs cmd and sends its messages to m until no commands are left.
+func exec(m *explorer, cmd tea.Cmd) {
+	if cmd == nil {
+		return
+	}
+	msg := cmd()
+	if batch, ok := msg.(tea.BatchMsg); ok {
+		for _, cmd := range batch {
+			exec(m, cmd)
+		}
+		return
+	}
+	_, cmd = m.Update(msg)
+	exec(m, cmd)
+}
+
+func lines(entries []entry) []string {
+	var lines []string
+	for _, e := range entries {
+		lines = append(lines, e.Line)
+	}
+	return lines
+}
+
+func nan() float64 {
+	var zero float64
+	return zero / zero
+}
+
+type fakeClient struct {
+	client.Client
+
+	names   []string
+	values  map[string][]string
+	streams loghttp.Streams
+	matrix  loghttp.Matrix
+	volume  loghttp.Matrix
+	fields  map[string]*loghttp.DetectedFieldsResponse
+
+	queries []string
+}
+
+func (c *fakeClient) ListLabelNames(_ bool, _, _ time.Time) (*loghttp.LabelResponse, error) {
+	return &loghttp.LabelResponse{Data: c.names}, nil
+}
+
+func (c *fakeClient) ListLabelValues(name string, _ bool, _, _ time.Time) (*loghttp.LabelResponse, error) {
+	return &loghttp.LabelResponse{Data: c.values[name]}, nil
+}
+
+func (c *fakeClient) QueryRange(query string, limit int, start, end time.Time, direction logproto.Direction, _, _ time.Duration, _ bool) (*loghttp.QueryResponse, error) {
+	c.queries = append(c.queries, query)
+	if kind, _ := validate(query); kind == kindMetrics {
+		return &loghttp.QueryResponse{Data: loghttp.QueryResponseData{ResultType: loghttp.ResultTypeMatrix, Result: c.matrix}}, nil
+	}
+
+	type streamEntry struct {
+		stream int
+		loghttp.Entry
+	}
+	var entries []streamEntry
+	for i, s := range c.streams {
+		for _, e := range s.Entries {
+			if !e.Timestamp.Before(start) && e.Timestamp.Before(end) {
+				entries = append(entries, streamEntry{i, e})
+			}
+		}
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		if direction == logproto.FORWARD {
+			return entries[i].Timestamp.Before(entries[j].Timestamp)
+		}
+		return entries[i].Timestamp.After(entries[j].Timestamp)
+	})
+	entries = entries[:min(limit, len(entries))]
+
+	streams := make(loghttp.Streams, len(c.streams))
+	for i, s := range c.streams {
+		streams[i].Labels = s.Labels
+	}
+	for _, e := range entries {
+		streams[e.stream].Entries = append(streams[e.stream].Entries, e.Entry)
+	}
+	return &loghttp.QueryResponse{Data: loghttp.QueryResponseData{ResultType: loghttp.ResultTypeStream, Result: streams}}, nil
+}
+
+func (c *fakeClient) GetVolumeRange(_ *volume.Query) (*loghttp.QueryResponse, error) {
+	return &loghttp.QueryResponse{Data: loghttp.QueryResponseData{ResultType: loghttp.ResultTypeMatrix, Result: c.volume}}, nil
+}
+
+func (c *fakeClient) GetDetectedFields(_, fieldName string, _, _ int, _, _ time.Time, _ time.Duration, _ bool) (*loghttp.DetectedFieldsResponse, error) {
+	return c.fields[fieldName], nil
+}
@@ -0,0 +1,549 @@
+package explore
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/grafana/loki/v3/pkg/logcli/client"
+	"github.com/grafana/loki/v3/pkg/loghttp"
+)
+
+// labelsWidth is the width of the label browser, including its border.
+const labelsWidth = 32
+
+// headerHeight is the number of lines above the panes: the title, the query
+// editor, the validation result and the histogram.
+const headerHeight = 4
+
+// footerHeight is the number of lines below the panes: the status and key
+// help.
+const footerHeight = 2
+
+type focus int
+
+const (
+	focusQuery focus = iota
+	focusLabels
+	focusResults
+	numFocus
+)
+
+var errMetricQueryInLogsView = errors.New("metric queries can only be shown in the metric view, press ctrl+t to switch")
+
+// explorer is the state of the terminal UI.
+type explorer struct {
+	client client.Client
+	q      *Query
+
+	width, height int
+	focus         focus
+	// mode is the kind of results shown. Log queries are wrapped in a
+	// count_over_time aggregation when shown in the metric view.
+	mode queryKind
+
+	// Label browser, which lists label names, or the values of labelName if
+	// it is set.
+	labelNames  []string
+	labelName   string
+	labelValues []string
+	labelCursor int
+
+	input    textinput.Model
+	inputErr error
+
+	// gen is incremented for every query run, to drop results of superseded
+	// queries.
+	gen     int
+	query   string
+	loading bool
+	err     error
+	results viewport.Model
+
+	// Log view results. pages holds the pages visited, the last one being
+	// the current page.
+	pages   []page
+	next    *page
+	entries []entry
+	volume  loghttp.Matrix
+
+	// Metric view results.
+	matrix loghttp.Matrix
+
+	// Detected fields, shown instead of the panes when showFields is set.
+	// fieldValues holds the values of fieldName if it is set.
+	showFields  bool
+	fields      *loghttp.DetectedFieldsResponse
+	fieldName   string
+	fieldValues []string
+	fieldCursor int
+}
+
+func newExplorer(c client.Client, q *Query) *explorer {
+	input := textinput.New()
+	input.Prompt = "> "
+	input.Placeholder = `{app="foo"} |= "error"`
+	input.SetValue(q.QueryString)
+	input.Focus()
+
+	m := &explorer{
+		client:  c,
+		q:       q,
+		input:   input,
+		results: viewport.New(0, 0),
+	}
+	m.validate()
+	return m
+}
+
+func (m *explorer) Init() tea.Cmd {
+	cmds := []tea.Cmd{textinput.Blink, fetchLabelNames(m.client, m.q)}
+	if m.input.Value() != "" && m.inputErr == nil {
+		kind, _ := validate(m.input.Value())
+		m.mode = kind
+		cmds = append(cmds, m.run())
+	}
+	return tea.Batch(cmds...)
+}
+
+func (m *explorer) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width, m.height = msg.Width, msg.Height
+		m.input.Width = max(msg.Width-len(m.input.Prompt)-1, 0)
+		m.results.Width = max(msg.Width-labelsWidth-2, 0)
+		m.results.Height = m.bodyHeight()
+		m.renderResults()
+		return m, nil
+
+	case tea.KeyMsg:
+		return m, m.handleKey(msg)
+
+	case labelNamesMsg:
+		m.labelNames = msg.names
+		return m, nil
+
+	case labelValuesMsg:
+		m.labelName, m.labelValues, m.labelCursor = msg.name, msg.values, 0
+		return m, nil
+
+	case logsMsg:
+		if msg.gen != m.gen {
+			return m, nil
+		}
+		m.loading = false
+		m.entries, m.next = msg.entries, msg.next
+		m.renderResults()
+		return m, nil
+
+	case volumeMsg:
+		if msg.gen == m.gen {
+			m.volume = msg.matrix
+		}
+		return m, nil
+
+	case metricsMsg:
+		if msg.gen != m.gen {
+			return m, nil
+		}
+		m.loading = false
+		m.matrix = msg.matrix
+		m.renderResults()
+		return m, nil
+
+	case fieldsMsg:
+		if msg.field == "" {
+			m.fields = msg.resp
+		} else {
+			m.fieldName, m.fieldValues = msg.field, msg.resp.Values
+		}
+		return m, nil
+
+	case queryErrMsg:
+		if msg.gen != m.gen {
+			return m, nil
+		}
+		m.loading = false
+		m.err = msg.err
+		return m, nil
+
+	case errMsg:
+		m.err = msg.err
+		return m, nil
+	}
+
+	var cmd tea.Cmd
+	m.input, cmd = m.input.Update(msg)
+	return m, cmd
+}
+
+func (m *explorer) handleKey(msg tea.KeyMsg) tea.Cmd {
+	switch msg.String() {
+	case "ctrl+c":
+		return tea.Quit
+	case "ctrl+t":
+		if m.mode == kindLogs {
+			m.mode = kindMetrics
+		} else {
+			m.mode = kindLogs
+		}
+		return m.run()
+	case "ctrl+f":
+		return m.openFields()
+	}
+
+	if m.showFields {
+		return m.handleFieldsKey(msg)
+	}
+
+	switch msg.String() {
+	case "tab":
+		m.setFocus((m.focus + 1) % numFocus)
+		return nil
+	case "shift+tab":
+		m.setFocus((m.focus + numFocus - 1) % numFocus)
+		return nil
+	}
+
+	switch m.focus {
+	case focusQuery:
+		if msg.Type == tea.KeyEnter {
+			return m.run()
+		}
+		var cmd tea.Cmd
+		m.input, cmd = m.input.Update(msg)
+		m.validate()
+		return cmd
+
+	case focusLabels:
+		return m.handleLabelsKey(msg)
+
+	case focusResults:
+		switch msg.String() {
+		case "q":
+			return tea.Quit
+		case "n", "right":
+			return m.nextPage()
+		case "p", "left":
+			return m.prevPage()
+		}
+		var cmd tea.Cmd
+		m.results, cmd = m.results.Update(msg)
+		return cmd
+	}
+	return nil
+}
+
+func (m *explorer) handleLabelsKey(msg tea.KeyMsg) tea.Cmd {
+	items := m.labelItems()
+
+	switch msg.String() {
+	case "q":
+		return tea.Quit
+	case "up", "k":
+		m.labelCursor = max(m.labelCursor-1, 0)
+	case "down", "j":
+		m.labelCursor = min(m.labelCursor+1, max(len(items)-1, 0))
+	case "esc", "backspace", "left", "h":
+		if m.labelName != "" {
+			m.labelCursor = indexOf(m.labelNames, m.labelName)
+			m.labelName, m.labelValues = "", nil
+		}
+	case "enter", "right", "l":
+		if len(items) == 0 {
+			return nil
+		}
+		if m.labelName == "" {
+			return fetchLabelValues(m.client, m.q, items[m.labelCursor])
+		}
+
+		query, err := addMatcher(m.input.Value(), m.labelName, items[m.labelCursor])
+		if err != nil {
+			m.err = err
+			return nil
+		}
+		m.input.SetValue(query)
+		m.validate()
+		return m.run()
+	}
+	return nil
+}
+
+func (m *explorer) handleFieldsKey(msg tea.KeyMsg) tea.Cmd {
+	switch msg.String() {
+	case "esc", "q":
+		if m.fieldName != "" {
+			m.fieldName, m.fieldValues = "", nil
+		} else {
+			m.showFields = false
+		}
+	case "up", "k":
+		m.fieldCursor = max(m.fieldCursor-1, 0)
+	case "down", "j":
+		if m.fields != nil {
+			m.fieldCursor = min(m.fieldCursor+1, max(len(m.fields.Fields)-1, 0))
+		}
+	case "enter":
+		if m.fieldName == "" && m.fields != nil && len(m.fields.Fields) > 0 {
+			return fetchFields(m.client, m.q, m.query, m.fields.Fields[m.fieldCursor].Label)
+		}
+	}
+	return nil
+}
+
+func (m *explorer) setFocus(f focus) {
+	m.focus = f
+	if f == focusQuery {
+		m.input.Focus()
+	} else {
+		m.input.Blur()
+	}
+}
+
+// validate parses the query being edited, so that errors are shown while
+// typing.
+func (m *explorer) validate() {
+	m.inputErr = nil
+	if m.input.Value() != "" {
+		_, m.inputErr = validate(m.input.Value())
+	}
+}
+
+// run runs the query of the editor in the current view mode.
+func (m *explorer) run() tea.Cmd {
+	value := m.input.Value()
+	kind, err := validate(value)
+	if err != nil {
+		m.inputErr = err
+		return nil
+	}
+
+	m.gen++
+	m.err = nil
+	m.entries, m.next, m.volume, m.matrix = nil, nil, nil, nil
+
+	if m.mode == kindMetrics {
+		query, err := metricQuery(value, m.q.step())
+		if err != nil {
+			m.err = err
+			return nil
+		}
+		m.query, m.loading = query, true
+		return fetchMetrics(m.client, m.q, m.gen, query)
+	}
+
+	if kind == kindMetrics {
+		m.err = errMetricQueryInLogsView
+		m.renderResults()
+		return nil
+	}
+	m.query, m.loading = value, true
+	m.pages = []page{{start: m.q.Start, end: m.q.End}}
+
+	cmds := []tea.Cmd{fetchLogs(m.client, m.q, m.gen, value, m.pages[0])}
+	if selector, err := streamSelector(value); err == nil {
+		cmds = append(cmds, fetchVolume(m.client, m.q, m.gen, selector))
+	}
+	return tea.Batch(cmds...)
+}
+
+func (m *explorer) nextPage() tea.Cmd {
+	if m.mode != kindLogs || m.next == nil || m.loading {
+		return nil
+	}
+	m.pages = append(m.pages, *m.next)
+	m.loading = true
+	return fetchLogs(m.client, m.q, m.gen, m.query, *m.next)
+}
+
+func (m *explorer) prevPage() tea.Cmd {
+	if m.mode != kindLogs || len(m.pages) < 2 || m.loading {
+		return nil
+	}
+	m.pages = m.pages[:len(m.pages)-1]
+	m.loading = true
+	return fetchLogs(m.client, m.q, m.gen, m.query, m.pages[len(m.pages)-1])
+}
+
+// openFields shows the fields detected in the logs of the last log query.
+func (m *explorer) openFields() tea.Cmd {
+	if m.query == "" || m.mode != kindLogs {
+		m.err = errors.New("run a log query to detect its fields")
+		return nil
+	}
+	m.showFields = true
+	m.fields, m.fieldName, m.fieldValues, m.fieldCursor = nil, "", nil, 0
+	return fetchFields(m.client, m.q, m.query, "")
+}
+
+func (m *explorer) labelItems() []string {
+	if m.labelName != "" {
+		return m.labelValues
+	}
+	return m.labelNames
+}
+
+func (m *explorer) bodyHeight() int {
+	// Panes have a border on top and bottom.
+	return max(m.height-headerHeight-footerHeight-2, 1)
+}
+
+func (m *explorer) renderResults() {
+	switch {
+	case m.err == errMetricQueryInLogsView:
+		m.results.SetContent("")
+	case m.mode == kindMetrics:
+		m.results.SetContent(renderMatrix(m.matrix, m.q.Start, m.q.End, m.q.step(), m.results.Width))
+	default:
+		m.results.SetContent(renderLogs(m.entries, time.Local, m.results.Width))
+	}
+	m.results.GotoTop()
+}
+
+func (m *explorer) View() string {
+	if m.width == 0 {
+		return ""
+	}
+
+	header := []string{
+		m.titleView(),
+		m.input.View(),
+		m.validationView(),
+		m.histogramView(),
+	}
+
+	var body string
+	if m.showFields {
+		body = focusedPaneStyle.Width(m.width - 2).Height(m.bodyHeight()).Render(m.fieldsView())
+	} else {
+		body = lipgloss.JoinHorizontal(lipgloss.Top,
+			m.paneStyle(focusLabels).Width(labelsWidth-2).Height(m.bodyHeight()).Render(m.labelsView()),
+			m.paneStyle(focusResults).Height(m.bodyHeight()).Render(m.results.View()),
+		)
+	}
+
+	return lipgloss.JoinVertical(lipgloss.Left,
+		strings.Join(header, "\n"),
+		body,
+		m.statusView(),
+		dimStyle.Render(m.helpView()),
+	)
+}
+
+func (m *explorer) paneStyle(f focus) lipgloss.Style {
+	if m.focus == f {
+		return focusedPaneStyle
+	}
+	return paneStyle
+}
+
+func (m *explorer) titleView() string {
+	mode := "logs"
+	if m.mode == kindMetrics {
+		mode = "metrics"
+	}
+	return titleStyle.Render("logcli explore") + dimStyle.Render(fmt.Sprintf("  %s to %s  [%s view]",
+		m.q.Start.Local().Format(time.DateTime), m.q.End.Local().Format(time.DateTime), mode))
+}
+
+func (m *explorer) validationView() string {
+	switch {
+	case m.input.Value() == "":
+		return dimStyle.Render("Enter a LogQL query, or select labels with tab.")
+	case m.inputErr != nil:
+		return errorStyle.Render(m.inputErr.Error())
+	}
+	if kind, _ := validate(m.input.Value()); kind == kindMetrics {
+		return validStyle.Render("✓ metric query")
+	}
+	return validStyle.Render("✓ log query")
+}
+
+func (m *explorer) histogramView() string {
+	if m.mode != kindLogs || m.volume == nil {
+		return ""
+	}
+	return histogram(m.volume, m.q.Start, m.q.End, m.q.step(), m.width)
+}
+
+func (m *explorer) labelsView() string {
+	title := "Labels"
+	if m.labelName != "" {
+		title = m.labelName
+	}
+
+	items := m.labelItems()
+	height := m.bodyHeight() - 1
+	// Scroll the list so that the cursor is visible.
+	offset := max(m.labelCursor-height+1, 0)
+
+	lines := []string{titleStyle.Render(title)}
+	for i := offset; i < len(items) && i < offset+height; i++ {
+		item := lipgloss.NewStyle().MaxWidth(labelsWidth - 4).Render(items[i])
+		if i == m.labelCursor && m.focus == focusLabels {
+			lines = append(lines, selectedStyle.Render("> "+item))
+		} else {
+			lines = append(lines, "  "+item)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (m *explorer) fieldsView() string {
+	switch {
+	case m.fieldName != "":
+		return titleStyle.Render("Values of "+m.fieldName) + "\n" + strings.Join(m.fieldValues, "\n")
+	case m.fields == nil:
+		return dimStyle.Render("Detecting fields...")
+	}
+	return titleStyle.Render("Detected fields") + "\n" + renderFields(m.fields, m.fieldCursor)
+}
+
+func (m *explorer) statusView() string {
+	switch {
+	case m.err != nil:
+		return errorStyle.Render(m.err.Error())
+	case m.loading:
+		return dimStyle.Render("Loading...")
+	case m.mode == kindLogs && len(m.pages) > 0:
+		status := fmt.Sprintf("Page %d, %d entries", len(m.pages), len(m.entries))
+		if m.next != nil {
+			status += ", more on the next page"
+		}
+		return dimStyle.Render(status)
+	case m.mode == kindMetrics && m.query != "":
+		return dimStyle.Render(fmt.Sprintf("%d series of %s", len(m.matrix), m.query))
+	}
+	return ""
+}
+
+func (m *explorer) helpView() string {
+	if m.showFields {
+		return "↑/↓ select • enter values • esc back • ctrl+c quit"
+	}
+	help := "tab focus • ctrl+t logs/metrics • ctrl+f detected fields • ctrl+c quit"
+	switch m.focus {
+	case focusQuery:
+		help = "enter run • " + help
+	case focusLabels:
+		help = "↑/↓ select • enter add matcher • esc back • " + help
+	case focusResults:
+		help = "↑/↓ scroll • n/p next/previous page • " + help
+	}
+	return help
+}
+
+func indexOf(items []string, item string) int {
+	for i, it := range items {
+		if it == item {
+			return i
+		}
+	}
+	return 0
+}
@@ -0,0 +1,177 @@
+package explore
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/dustin/go-humanize"
+
+	"github.com/grafana/loki/v3/pkg/loghttp"
+)
+
+var sparkBlocks = []rune("▁▂▃▄▅▆▇█")
+
+var (
+	titleStyle     = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("12"))
+	dimStyle       = lipgloss.NewStyle().Foreground(lipgloss.Color("243"))
+	errorStyle     = lipgloss.NewStyle().Foreground(lipgloss.Color("9"))
+	validStyle     = lipgloss.NewStyle().Foreground(lipgloss.Color("42"))
+	histogramStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("62"))
+	selectedStyle  = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("12"))
+	timestampStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("33"))
+	labelsStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color("243"))
+
+	paneStyle = lipgloss.NewStyle().
+			BorderStyle(lipgloss.RoundedBorder()).
+			BorderForeground(lipgloss.Color("240"))
+	focusedPaneStyle = paneStyle.BorderForeground(lipgloss.Color("62"))
+)
+
+// sparkline renders values as a line of width blocks, resampling values by
+// summing the values falling into each block. Missing values are NaN and
+// rendered as spaces.
+func sparkline(values []float64, width int) string {
+	if width <= 0 || len(values) == 0 {
+		return ""
+	}
+
+	buckets := make([]float64, min(width, len(values)))
+	for i := range buckets {
+		buckets[i] = math.NaN()
+	}
+	for i, v := range values {
+		if math.IsNaN(v) {
+			continue
+		}
+		b := i * len(buckets) / len(values)
+		if math.IsNaN(buckets[b]) {
+			buckets[b] = 0
+		}
+		buckets[b] += v
+	}
+
+	lo, hi := math.Inf(1), math.Inf(-1)
+	for _, v := range buckets {
+		if !math.IsNaN(v) {
+			lo, hi = math.Min(lo, v), math.Max(hi, v)
+		}
+	}
+
+	var sb strings.Builder
+	for _, v := range buckets {
+		switch {
+		case math.IsNaN(v):
+			sb.WriteRune(' ')
+		case hi == lo:
+			sb.WriteRune(sparkBlocks[len(sparkBlocks)-1])
+		default:
+			idx := int((v - lo) / (hi - lo) * float64(len(sparkBlocks)-1))
+			sb.WriteRune(sparkBlocks[idx])
+		}
+	}
+	return sb.String()
+}
+
+// histogram sums the volume of all series of matrix per step of the time
+// range [start, end) and renders them as a sparkline.
+func histogram(matrix loghttp.Matrix, start, end time.Time, step time.Duration, width int) string {
+	steps := int(end.Sub(start)/step) + 1
+	values := make([]float64, steps)
+
+	var total float64
+	for _, series := range matrix {
+		for _, sample := range series.Values {
+			i := int(sample.Timestamp.Time().Sub(start) / step)
+			if i >= 0 && i < steps {
+				values[i] += float64(sample.Value)
+				total += float64(sample.Value)
+			}
+		}
+	}
+
+	summary := fmt.Sprintf(" %s", humanize.Bytes(uint64(total)))
+	return histogramStyle.Render(sparkline(values, width-len(summary))) + dimStyle.Render(summary)
+}
+
+// renderLogs renders entries with their timestamps and labels.
+func renderLogs(entries []entry, tz *time.Location, width int) string {
+	if len(entries) == 0 {
+		return dimStyle.Render("No logs found.")
+	}
+
+	lines := make([]string, len(entries))
+	for i, e := range entries {
+		line := fmt.Sprintf("%s %s %s",
+			timestampStyle.Render(e.Timestamp.In(tz).Format(time.RFC3339Nano)),
+			labelsStyle.Render(e.labels.String()),
+			e.Line,
+		)
+		lines[i] = lipgloss.NewStyle().MaxWidth(width).Render(line)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// renderMatrix renders a row per series with a sparkline of its samples over
+// the time range and its minimum, maximum and last value.
+func renderMatrix(matrix loghttp.Matrix, start, end time.Time, step time.Duration, width int) string {
+	if len(matrix) == 0 {
+		return dimStyle.Render("No series found.")
+	}
+
+	sorted := make(loghttp.Matrix, len(matrix))
+	copy(sorted, matrix)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Metric.String() < sorted[j].Metric.String() })
+
+	steps := int(end.Sub(start)/step) + 1
+	sparkWidth := min(width/2, steps)
+
+	var sb strings.Builder
+	for i, series := range sorted {
+		values := make([]float64, steps)
+		for j := range values {
+			values[j] = math.NaN()
+		}
+		lo, hi := math.Inf(1), math.Inf(-1)
+		for _, sample := range series.Values {
+			v := float64(sample.Value)
+			lo, hi = math.Min(lo, v), math.Max(hi, v)
+			if j := int(sample.Timestamp.Time().Sub(start) / step); j >= 0 && j < steps {
+				values[j] = v
+			}
+		}
+
+		if i > 0 {
+			sb.WriteString("\n")
+		}
+		sb.WriteString(labelsStyle.Render(series.Metric.String()))
+		sb.WriteString("\n  ")
+		sb.WriteString(histogramStyle.Render(sparkline(values, sparkWidth)))
+		if n := len(series.Values); n > 0 {
+			sb.WriteString(dimStyle.Render(fmt.Sprintf(" min %g max %g last %g", lo, hi, float64(series.Values[n-1].Value))))
+		}
+	}
+	return sb.String()
+}
+
+// renderFields renders detected fields with their type and cardinality.
+func renderFields(resp *loghttp.DetectedFieldsResponse, cursor int) string {
+	if len(resp.Fields) == 0 {
+		return dimStyle.Render("No fields detected.")
+	}
+
+	lines := make([]string, len(resp.Fields))
+	for i, f := range resp.Fields {
+		line := fmt.Sprintf("%-40s %-10s %d", f.Label, f.Type, f.Cardinality)
+		if i == cursor {
+			line = selectedStyle.Render("> " + line)
+		} else {
+			line = "  " + line
+		}
+		lines[i] = line
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,102 @@
+package explore
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/prometheus/prometheus/model/labels"
+
+	"github.com/grafana/loki/v3/pkg/logql/syntax"
+)
+
+// queryKind is the kind of a LogQL query.
+type queryKind int
+
+const (
+	kindLogs queryKind = iota
+	kindMetrics
+)
+
+// validate parses query and returns its kind.
+func validate(query string) (queryKind, error) {
+	expr, err := syntax.ParseExpr(query)
+	if err != nil {
+		return 0, err
+	}
+	if _, ok := expr.(syntax.SampleExpr); ok {
+		return kindMetrics, nil
+	}
+	return kindLogs, nil
+}
+
+// addMatcher returns query with the equality matcher name="value" added to
+// its stream selectors, replacing any matchers for the same label. An empty
+// query is replaced by a stream selector with the matcher.
+func addMatcher(query, name, value string) (string, error) {
+	matcher, err := labels.NewMatcher(labels.MatchEqual, name, value)
+	if err != nil {
+		return "", err
+	}
+	if query == "" {
+		return syntax.MatchersString([]*labels.Matcher{matcher}), nil
+	}
+
+	expr, err := syntax.ParseExpr(query)
+	if err != nil {
+		return "", fmt.Errorf("fix the query before adding labels: %w", err)
+	}
+
+	var found bool
+	expr.Walk(func(e syntax.Expr) bool {
+		if m, ok := e.(*syntax.MatchersExpr); ok {
+			found = true
+			mts := m.Mts[:0]
+			for _, mt := range m.Mts {
+				if mt.Name != name {
+					mts = append(mts, mt)
+				}
+			}
+			m.Mts = append(mts, matcher)
+		}
+		return true
+	})
+	if !found {
+		return "", errors.New("query has no stream selector")
+	}
+	return expr.String(), nil
+}
+
+// streamSelector returns the first stream selector of query, which is used for
+// volume queries.
+func streamSelector(query string) (string, error) {
+	expr, err := syntax.ParseExpr(query)
+	if err != nil {
+		return "", err
+	}
+
+	var selector string
+	expr.Walk(func(e syntax.Expr) bool {
+		if m, ok := e.(*syntax.MatchersExpr); ok && selector == "" {
+			selector = m.String()
+		}
+		return selector == ""
+	})
+	if selector == "" {
+		return "", errors.New("query has no stream selector")
+	}
+	return selector, nil
+}
+
+// metricQuery returns a metric query counting the lines of the log query per
+// step. Metric queries are returned unchanged.
+func metricQuery(query string, step time.Duration) (string, error) {
+	kind, err := validate(query)
+	if err != nil {
+		return "", err
+	}
+	if kind == kindMetrics {
+		return query, nil
+	}
+	return fmt.Sprintf("sum(count_over_time(%s [%s]))", query, step), nil
+}
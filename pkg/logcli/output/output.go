@@ -39,6 +39,20 @@ type LogOutputOptions struct {
 	NoLabels        bool
 	ColoredOutput   bool
 	TimestampFormat string
+	// TableBufferRows is the number of rows csv, parquet and arrow outputs
+	// buffer to determine the columns of the table. DefaultTableBufferRows is
+	// used if not set.
+	TableBufferRows int
+}
+
+// IsTableMode returns whether the output mode writes a table with a column per
+// label, whose columns are only known once the first results have been seen.
+func IsTableMode(mode string) bool {
+	switch mode {
+	case "csv", "parquet", "arrow":
+		return true
+	}
+	return false
 }
 
 // NewLogOutput creates a log output based on the input mode and options
@@ -67,6 +81,12 @@ func NewLogOutput(w io.Writer, mode string, options *LogOutputOptions) (LogOutpu
 			w:       w,
 			options: options,
 		}, nil
+	case "csv":
+		return newTableOutput(w, options, csvFormat{}), nil
+	case "parquet":
+		return newTableOutput(w, options, parquetFormat{}), nil
+	case "arrow":
+		return newTableOutput(w, options, arrowFormat{}), nil
 	default:
 		return nil, fmt.Errorf("unknown log output mode '%s'", mode)
 	}
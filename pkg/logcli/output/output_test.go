@@ -23,6 +23,14 @@ func TestNewLogOutput(t *testing.T) {
 	assert.NoError(t, err)
 	assert.IsType(t, &RawOutput{nil, options}, out)
 
+	for _, mode := range []string{"csv", "parquet", "arrow"} {
+		out, err = NewLogOutput(nil, mode, options)
+		assert.NoError(t, err)
+		assert.IsType(t, &TableOutput{}, out)
+		assert.True(t, IsTableMode(mode))
+	}
+	assert.False(t, IsTableMode("jsonl"))
+
 	out, err = NewLogOutput(nil, "unknown", options)
 	assert.Error(t, err)
 	assert.Nil(t, out)
@@ -0,0 +1,460 @@
+package output
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+
+	"github.com/grafana/loki/v3/pkg/loghttp"
+)
+
+// Names of the columns which don't hold labels or structured metadata.
+const (
+	timestampColumn = "timestamp"
+	lineColumn      = "line"
+	valueColumn     = "value"
+)
+
+// EntryOutput is implemented by log outputs which write the structured
+// metadata of entries in addition to their timestamp and line.
+type EntryOutput interface {
+	LogOutput
+	FormatAndPrintEntry(lbls loghttp.LabelSet, entry loghttp.Entry)
+}
+
+// MetricOutput is implemented by log outputs which write the results of
+// metric queries, which are otherwise printed as JSON.
+type MetricOutput interface {
+	LogOutput
+	WriteMatrix(matrix loghttp.Matrix)
+	WriteVector(vector loghttp.Vector)
+	WriteScalar(scalar loghttp.Scalar)
+}
+
+// Close writes the results buffered by out, if it buffers results. Outputs
+// with a column per label buffer rows until their columns are known.
+func Close(out LogOutput) error {
+	if closer, ok := out.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+// Err returns the error which made out stop writing results, if it can fail
+// before it is closed. Queries should stop once it returns an error, as the
+// following results are dropped.
+func Err(out LogOutput) error {
+	if o, ok := out.(interface{ Err() error }); ok {
+		return o.Err()
+	}
+	return nil
+}
+
+// DefaultTableBufferRows is the default number of rows buffered by table
+// outputs to determine the columns of the table.
+const DefaultTableBufferRows = 64 * 1024
+
+// tableReadRows is the number of rows per batch read from part files.
+const tableReadRows = 1024
+
+// tableFormat writes and reads tables in a file format.
+type tableFormat interface {
+	// newWriter starts writing a table with the given columns to w.
+	// Timestamps of text formats are written in timezone.
+	newWriter(w io.Writer, columns []tableColumn, timezone *time.Location) (tableWriter, error)
+	newReader(r io.ReaderAt, size int64) (tableReader, error)
+}
+
+// tableWriter writes the rows of a table in a file format.
+type tableWriter interface {
+	// write writes the rows of t, which has the columns the writer was
+	// created with.
+	write(t *table) error
+	// close writes the end of the file.
+	close() error
+}
+
+// tableReader reads the rows of a table in batches.
+type tableReader interface {
+	columns() []tableColumn
+	// next returns the next batch of rows, with values ordered like the
+	// columns of the reader, or io.EOF after the last batch.
+	next() ([][]any, error)
+	close() error
+}
+
+// TableOutput writes logs and samples as the rows of a table in a columnar
+// file format. Logs have a timestamp and line column, and samples have a
+// timestamp and value column. Labels and structured metadata are written as
+// additional columns.
+//
+// Since all formats write their columns before any row, the first rows are
+// buffered until LogOutputOptions.TableBufferRows rows were added or the
+// output is closed, and their columns are the columns of the table. The
+// following rows are written in batches of the same size. Writing fails if a
+// row has a column which is not in the table; the columns of part files added
+// with MergePartColumns are always in the table. Once writing failed, rows are
+// dropped, and the error is returned by Err and Close.
+type TableOutput struct {
+	w       io.Writer
+	options *LogOutputOptions
+	format  tableFormat
+
+	// Parallel queries write to the same output when not using part files.
+	mtx sync.Mutex
+	// table buffers the rows which haven't been written yet.
+	table *table
+	// writer is set once the columns of the table were written.
+	writer  tableWriter
+	columns int
+	err     error
+}
+
+var (
+	_ EntryOutput  = (*TableOutput)(nil)
+	_ MetricOutput = (*TableOutput)(nil)
+)
+
+func newTableOutput(w io.Writer, options *LogOutputOptions, format tableFormat) *TableOutput {
+	return &TableOutput{
+		w:       w,
+		options: options,
+		format:  format,
+		table:   newTable(),
+	}
+}
+
+// FormatAndPrintln adds a log entry without structured metadata to the table.
+func (o *TableOutput) FormatAndPrintln(ts time.Time, lbls loghttp.LabelSet, _ int, line string) {
+	o.FormatAndPrintEntry(lbls, loghttp.Entry{Timestamp: ts, Line: line})
+}
+
+// FormatAndPrintEntry adds a log entry to the table.
+func (o *TableOutput) FormatAndPrintEntry(lbls loghttp.LabelSet, entry loghttp.Entry) {
+	o.mtx.Lock()
+	defer o.mtx.Unlock()
+
+	if o.err != nil {
+		return
+	}
+	row := o.table.newRow()
+	row.set(timestampColumn, columnTimestamp, entry.Timestamp)
+	row.set(lineColumn, columnString, entry.Line)
+	if !o.options.NoLabels {
+		row.setLabels(lbls)
+	}
+	entry.StructuredMetadata.Range(func(l labels.Label) {
+		row.setExtra(l.Name, l.Value)
+	})
+	o.err = o.flush(false)
+}
+
+// WriteMatrix adds a row per sample of matrix to the table.
+func (o *TableOutput) WriteMatrix(matrix loghttp.Matrix) {
+	o.mtx.Lock()
+	defer o.mtx.Unlock()
+
+	for _, stream := range matrix {
+		for _, sample := range stream.Values {
+			o.addSample(stream.Metric, sample.Timestamp, sample.Value)
+		}
+	}
+}
+
+// WriteVector adds a row per sample of vector to the table.
+func (o *TableOutput) WriteVector(vector loghttp.Vector) {
+	o.mtx.Lock()
+	defer o.mtx.Unlock()
+
+	for _, sample := range vector {
+		o.addSample(sample.Metric, sample.Timestamp, sample.Value)
+	}
+}
+
+// WriteScalar adds the scalar as a row to the table.
+func (o *TableOutput) WriteScalar(scalar loghttp.Scalar) {
+	o.mtx.Lock()
+	defer o.mtx.Unlock()
+
+	o.addSample(nil, scalar.Timestamp, scalar.Value)
+}
+
+func (o *TableOutput) addSample(metric model.Metric, ts model.Time, value model.SampleValue) {
+	if o.err != nil {
+		return
+	}
+	row := o.table.newRow()
+	row.set(timestampColumn, columnTimestamp, ts.Time())
+	row.set(valueColumn, columnFloat, float64(value))
+	if !o.options.NoLabels {
+		lbls := make(loghttp.LabelSet, len(metric))
+		for name, value := range metric {
+			lbls[string(name)] = string(value)
+		}
+		row.setLabels(lbls)
+	}
+	o.err = o.flush(false)
+}
+
+// MergePartColumns adds the columns of a part file written by a TableOutput
+// of the same format to the table, without reading its rows. Adding the
+// columns of all part files before merging them makes sure that the table
+// has the union of their columns, however many rows they have.
+func (o *TableOutput) MergePartColumns(r io.ReaderAt, size int64) error {
+	reader, err := o.format.newReader(r, size)
+	if err != nil {
+		return fmt.Errorf("reading part file: %w", err)
+	}
+	defer reader.close()
+
+	o.mtx.Lock()
+	defer o.mtx.Unlock()
+	if o.writer != nil {
+		return errors.New("columns of part files must be merged before any row is written")
+	}
+	_, err = o.table.addColumns(reader.columns())
+	return err
+}
+
+// MergePart adds the rows of a part file written by a TableOutput of the
+// same format to the table, reading them in batches. Part files may have
+// different columns.
+func (o *TableOutput) MergePart(r io.ReaderAt, size int64) error {
+	reader, err := o.format.newReader(r, size)
+	if err != nil {
+		return fmt.Errorf("reading part file: %w", err)
+	}
+	defer reader.close()
+
+	o.mtx.Lock()
+	defer o.mtx.Unlock()
+	if o.err != nil {
+		return o.err
+	}
+
+	mapping, err := o.table.addColumns(reader.columns())
+	if err != nil {
+		return err
+	}
+	for {
+		rows, err := reader.next()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return fmt.Errorf("reading part file: %w", err)
+		}
+
+		o.table.appendRows(mapping, rows)
+		if o.err = o.flush(false); o.err != nil {
+			return o.err
+		}
+	}
+}
+
+// flush writes the buffered rows once TableBufferRows rows were buffered, or
+// when closing the output. The columns of the table are written with the
+// first rows.
+func (o *TableOutput) flush(closing bool) error {
+	bufferRows := o.options.TableBufferRows
+	if bufferRows <= 0 {
+		bufferRows = DefaultTableBufferRows
+	}
+	// Rows with a column which is not in the table fail as soon as they are
+	// added, so that queries can stop.
+	if o.writer != nil && len(o.table.columns) > o.columns {
+		return fmt.Errorf("column %q first appeared after the first %d rows, which determine the columns of the output", o.table.columns[o.columns].name, bufferRows)
+	}
+	if len(o.table.rows) < bufferRows && !closing {
+		return nil
+	}
+
+	if o.writer == nil {
+		if len(o.table.rows) == 0 {
+			return nil
+		}
+		writer, err := o.format.newWriter(o.w, o.table.columns, o.options.Timezone)
+		if err != nil {
+			return err
+		}
+		o.writer = writer
+		o.columns = len(o.table.columns)
+	}
+
+	err := o.writer.write(o.table)
+	clear(o.table.rows)
+	o.table.rows = o.table.rows[:0]
+	return err
+}
+
+// Close writes the buffered rows and the end of the file to the writer of
+// the output. Nothing is written if no rows were added.
+func (o *TableOutput) Close() error {
+	o.mtx.Lock()
+	defer o.mtx.Unlock()
+
+	err := o.err
+	if err == nil {
+		err = o.flush(true)
+	}
+	if err == nil && o.writer != nil {
+		err = o.writer.close()
+	}
+
+	o.table = newTable()
+	o.writer = nil
+	o.columns = 0
+	o.err = nil
+	return err
+}
+
+// Err returns the error which made the output stop writing rows, if any.
+func (o *TableOutput) Err() error {
+	o.mtx.Lock()
+	defer o.mtx.Unlock()
+	return o.err
+}
+
+// WithWriter returns a copy of the LogOutput with the writer set to the given
+// writer and an empty table.
+func (o *TableOutput) WithWriter(w io.Writer) LogOutput {
+	return newTableOutput(w, o.options, o.format)
+}
+
+type columnType int
+
+const (
+	columnString columnType = iota
+	columnTimestamp
+	columnFloat
+)
+
+func (ty columnType) String() string {
+	switch ty {
+	case columnString:
+		return "string"
+	case columnTimestamp:
+		return "timestamp"
+	case columnFloat:
+		return "float"
+	}
+	return fmt.Sprintf("columnType(%d)", int(ty))
+}
+
+type tableColumn struct {
+	name string
+	typ  columnType
+}
+
+// table holds rows of values by column. Values are strings, times or floats
+// depending on the type of their column, or nil if a row has no value for a
+// column.
+type table struct {
+	columns []tableColumn
+	index   map[string]int
+	rows    [][]any
+}
+
+func newTable() *table {
+	return &table{index: make(map[string]int)}
+}
+
+func (t *table) column(name string, typ columnType) (int, error) {
+	if i, ok := t.index[name]; ok {
+		if t.columns[i].typ != typ {
+			return 0, fmt.Errorf("column %q has type %s, got %s", name, t.columns[i].typ, typ)
+		}
+		return i, nil
+	}
+
+	t.columns = append(t.columns, tableColumn{name: name, typ: typ})
+	t.index[name] = len(t.columns) - 1
+	return len(t.columns) - 1, nil
+}
+
+func (t *table) newRow() tableRow {
+	t.rows = append(t.rows, nil)
+	return tableRow{t: t, row: len(t.rows) - 1}
+}
+
+// value returns the value of the column at index col in row, or nil if the
+// row has no value for it.
+func (t *table) value(row, col int) any {
+	if col >= len(t.rows[row]) {
+		return nil
+	}
+	return t.rows[row][col]
+}
+
+// addColumns adds the given columns to t, returning the index of each of
+// them in t.
+func (t *table) addColumns(columns []tableColumn) ([]int, error) {
+	mapping := make([]int, len(columns))
+	for i, col := range columns {
+		idx, err := t.column(col.name, col.typ)
+		if err != nil {
+			return nil, err
+		}
+		mapping[i] = idx
+	}
+	return mapping, nil
+}
+
+// appendRows appends rows to t, whose values are mapped to the columns of t
+// with the mapping returned by addColumns.
+func (t *table) appendRows(mapping []int, rows [][]any) {
+	for _, values := range rows {
+		row := make([]any, len(t.columns))
+		for i, v := range values {
+			row[mapping[i]] = v
+		}
+		t.rows = append(t.rows, row)
+	}
+}
+
+type tableRow struct {
+	t   *table
+	row int
+}
+
+// set sets the value of a column of the row. The fixed columns of the table
+// are always set before any other column, so their types never conflict.
+func (r tableRow) set(name string, typ columnType, value any) {
+	col, _ := r.t.column(name, typ)
+	values := r.t.rows[r.row]
+	for len(values) <= col {
+		values = append(values, nil)
+	}
+	values[col] = value
+	r.t.rows[r.row] = values
+}
+
+// setLabels sets a string column per label, in sorted order so that columns
+// are created in the same order for every output.
+func (r tableRow) setLabels(lbls loghttp.LabelSet) {
+	names := make([]string, 0, len(lbls))
+	for name := range lbls {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		r.setExtra(name, lbls[name])
+	}
+}
+
+// setExtra sets a string column for a label or structured metadata. If the
+// name conflicts with a fixed column, the column is suffixed with
+// "_extracted", like labels conflicting with stream labels in LogQL.
+func (r tableRow) setExtra(name, value string) {
+	switch name {
+	case timestampColumn, lineColumn, valueColumn:
+		name += "_extracted"
+	}
+	r.set(name, columnString, value)
+}
@@ -0,0 +1,164 @@
+package output
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/apache/arrow-go/v18/arrow"
+	"github.com/apache/arrow-go/v18/arrow/array"
+	"github.com/apache/arrow-go/v18/arrow/ipc"
+	"github.com/apache/arrow-go/v18/arrow/memory"
+)
+
+// arrowRecordRows is the maximum number of rows per record batch of Arrow IPC
+// files.
+const arrowRecordRows = 64 * 1024
+
+// arrowFormat writes tables in the Arrow IPC file format, also known as
+// Feather V2. Timestamps are stored with nanosecond precision in UTC.
+type arrowFormat struct{}
+
+var arrowTimestamp = &arrow.TimestampType{Unit: arrow.Nanosecond, TimeZone: "UTC"}
+
+func (arrowFormat) newWriter(w io.Writer, columns []tableColumn, _ *time.Location) (tableWriter, error) {
+	fields := make([]arrow.Field, len(columns))
+	for i, col := range columns {
+		field := arrow.Field{Name: col.name, Nullable: col.name != timestampColumn}
+		switch col.typ {
+		case columnTimestamp:
+			field.Type = arrowTimestamp
+		case columnFloat:
+			field.Type = arrow.PrimitiveTypes.Float64
+		default:
+			field.Type = arrow.BinaryTypes.String
+		}
+		fields[i] = field
+	}
+	schema := arrow.NewSchema(fields, nil)
+
+	writer, err := ipc.NewFileWriter(w, ipc.WithSchema(schema), ipc.WithAllocator(memory.DefaultAllocator))
+	if err != nil {
+		return nil, err
+	}
+	return &arrowWriter{
+		writer:  writer,
+		builder: array.NewRecordBuilder(memory.DefaultAllocator, schema),
+		columns: len(columns),
+	}, nil
+}
+
+type arrowWriter struct {
+	writer  *ipc.FileWriter
+	builder *array.RecordBuilder
+	columns int
+}
+
+func (w *arrowWriter) write(t *table) error {
+	for start := 0; start < len(t.rows); start += arrowRecordRows {
+		end := min(start+arrowRecordRows, len(t.rows))
+		for row := start; row < end; row++ {
+			for i := 0; i < w.columns; i++ {
+				switch v := t.value(row, i).(type) {
+				case nil:
+					w.builder.Field(i).AppendNull()
+				case string:
+					w.builder.Field(i).(*array.StringBuilder).Append(v)
+				case time.Time:
+					w.builder.Field(i).(*array.TimestampBuilder).Append(arrow.Timestamp(v.UnixNano()))
+				case float64:
+					w.builder.Field(i).(*array.Float64Builder).Append(v)
+				}
+			}
+		}
+
+		record := w.builder.NewRecord()
+		err := w.writer.Write(record)
+		record.Release()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (w *arrowWriter) close() error {
+	w.builder.Release()
+	return w.writer.Close()
+}
+
+func (arrowFormat) newReader(r io.ReaderAt, size int64) (tableReader, error) {
+	reader, err := ipc.NewFileReader(io.NewSectionReader(r, 0, size), ipc.WithAllocator(memory.DefaultAllocator))
+	if err != nil {
+		return nil, err
+	}
+
+	fields := reader.Schema().Fields()
+	cols := make([]tableColumn, len(fields))
+	for i, field := range fields {
+		var typ columnType
+		switch field.Type.ID() {
+		case arrow.TIMESTAMP:
+			typ = columnTimestamp
+		case arrow.FLOAT64:
+			typ = columnFloat
+		case arrow.STRING:
+			typ = columnString
+		default:
+			_ = reader.Close()
+			return nil, fmt.Errorf("unsupported type %s of column %q", field.Type, field.Name)
+		}
+		cols[i] = tableColumn{name: field.Name, typ: typ}
+	}
+	return &arrowReader{reader: reader, cols: cols}, nil
+}
+
+// arrowReader reads a record batch at a time.
+type arrowReader struct {
+	reader *ipc.FileReader
+	cols   []tableColumn
+	record int
+}
+
+func (r *arrowReader) columns() []tableColumn {
+	return r.cols
+}
+
+func (r *arrowReader) next() ([][]any, error) {
+	if r.record >= r.reader.NumRecords() {
+		return nil, io.EOF
+	}
+	record, err := r.reader.Record(r.record)
+	if err != nil {
+		return nil, err
+	}
+	r.record++
+
+	rows := make([][]any, record.NumRows())
+	for row := range rows {
+		values := make([]any, len(r.cols))
+		for col, arr := range record.Columns() {
+			if arr.IsNull(row) {
+				continue
+			}
+			switch arr := arr.(type) {
+			case *array.Timestamp:
+				unit := arr.DataType().(*arrow.TimestampType).Unit
+				values[col] = arr.Value(row).ToTime(unit).UTC()
+			case *array.Float64:
+				values[col] = arr.Value(row)
+			case *array.String:
+				// Values reference the memory of the record, which is
+				// reused for the next record.
+				values[col] = strings.Clone(arr.Value(row))
+			}
+		}
+		rows[row] = values
+	}
+	return rows, nil
+}
+
+func (r *arrowReader) close() error {
+	return r.reader.Close()
+}
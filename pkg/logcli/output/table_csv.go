@@ -0,0 +1,134 @@
+package output
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+)
+
+// csvFormat writes tables as CSV with a header row. Timestamps are written in
+// RFC3339 format with nanoseconds, and missing values as empty fields.
+type csvFormat struct{}
+
+func (csvFormat) newWriter(w io.Writer, columns []tableColumn, timezone *time.Location) (tableWriter, error) {
+	cw := csv.NewWriter(w)
+
+	record := make([]string, len(columns))
+	for i, col := range columns {
+		record[i] = col.name
+	}
+	if err := cw.Write(record); err != nil {
+		return nil, err
+	}
+	return &csvWriter{cw: cw, record: record, timezone: timezone}, nil
+}
+
+type csvWriter struct {
+	cw       *csv.Writer
+	record   []string
+	timezone *time.Location
+}
+
+func (w *csvWriter) write(t *table) error {
+	record := w.record
+	for row := range t.rows {
+		for i := range record {
+			switch v := t.value(row, i).(type) {
+			case nil:
+				record[i] = ""
+			case string:
+				record[i] = v
+			case time.Time:
+				record[i] = v.In(w.timezone).Format(time.RFC3339Nano)
+			case float64:
+				record[i] = strconv.FormatFloat(v, 'g', -1, 64)
+			}
+		}
+		if err := w.cw.Write(record); err != nil {
+			return err
+		}
+	}
+
+	w.cw.Flush()
+	return w.cw.Error()
+}
+
+func (w *csvWriter) close() error {
+	return nil
+}
+
+func (csvFormat) newReader(r io.ReaderAt, size int64) (tableReader, error) {
+	cr := csv.NewReader(io.NewSectionReader(r, 0, size))
+	cr.ReuseRecord = true
+
+	header, err := cr.Read()
+	if err == io.EOF {
+		return &csvReader{cr: cr}, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	cols := make([]tableColumn, len(header))
+	for i, name := range header {
+		typ := columnString
+		switch name {
+		case timestampColumn:
+			typ = columnTimestamp
+		case valueColumn:
+			typ = columnFloat
+		}
+		cols[i] = tableColumn{name: name, typ: typ}
+	}
+	return &csvReader{cr: cr, cols: cols}, nil
+}
+
+type csvReader struct {
+	cr   *csv.Reader
+	cols []tableColumn
+}
+
+func (r *csvReader) columns() []tableColumn {
+	return r.cols
+}
+
+func (r *csvReader) next() ([][]any, error) {
+	var rows [][]any
+	for len(rows) < tableReadRows {
+		record, err := r.cr.Read()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+
+		values := make([]any, len(record))
+		for i, field := range record {
+			switch {
+			case r.cols[i].typ == columnTimestamp:
+				if values[i], err = time.Parse(time.RFC3339Nano, field); err != nil {
+					return nil, fmt.Errorf("parsing timestamp: %w", err)
+				}
+			case r.cols[i].typ == columnFloat:
+				if values[i], err = strconv.ParseFloat(field, 64); err != nil {
+					return nil, fmt.Errorf("parsing value: %w", err)
+				}
+			case field != "" || r.cols[i].name == lineColumn:
+				// Labels and structured metadata can't have empty values, so
+				// empty fields are missing values, except for log lines.
+				values[i] = field
+			}
+		}
+		rows = append(rows, values)
+	}
+
+	if len(rows) == 0 {
+		return nil, io.EOF
+	}
+	return rows, nil
+}
+
+func (r *csvReader) close() error {
+	return nil
+}
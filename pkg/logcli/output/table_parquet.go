@@ -0,0 +1,178 @@
+package output
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/parquet-go/parquet-go"
+)
+
+// parquetFormat writes tables as Parquet files. Timestamps are stored with
+// nanosecond precision. Parquet orders the columns of a file by name.
+type parquetFormat struct{}
+
+func (parquetFormat) newWriter(w io.Writer, columns []tableColumn, _ *time.Location) (tableWriter, error) {
+	group := make(parquet.Group, len(columns))
+	for _, col := range columns {
+		var node parquet.Node
+		switch col.typ {
+		case columnTimestamp:
+			node = parquet.Timestamp(parquet.Nanosecond)
+		case columnFloat:
+			node = parquet.Leaf(parquet.DoubleType)
+		default:
+			node = parquet.String()
+		}
+		if col.name != timestampColumn {
+			node = parquet.Optional(node)
+		}
+		group[col.name] = node
+	}
+	schema := parquet.NewSchema("results", group)
+
+	// Leaf columns of the schema are ordered by name, not by their order in
+	// the table, so map each leaf to its table column.
+	var (
+		tableColumns     = make([]int, len(columns))
+		definitionLevels = make([]int, len(columns))
+	)
+	for i, col := range columns {
+		leaf, ok := schema.Lookup(col.name)
+		if !ok {
+			return nil, fmt.Errorf("column %q missing from parquet schema", col.name)
+		}
+		tableColumns[leaf.ColumnIndex] = i
+		definitionLevels[leaf.ColumnIndex] = leaf.MaxDefinitionLevel
+	}
+
+	return &parquetWriter{
+		writer:           parquet.NewWriter(w, schema),
+		tableColumns:     tableColumns,
+		definitionLevels: definitionLevels,
+		rows:             make([]parquet.Row, 0, 1024),
+	}, nil
+}
+
+type parquetWriter struct {
+	writer           *parquet.Writer
+	tableColumns     []int
+	definitionLevels []int
+	rows             []parquet.Row
+}
+
+func (w *parquetWriter) write(t *table) error {
+	for row := range t.rows {
+		values := make(parquet.Row, len(w.tableColumns))
+		for i, col := range w.tableColumns {
+			var value parquet.Value
+			switch v := t.value(row, col).(type) {
+			case nil:
+				values[i] = parquet.NullValue().Level(0, 0, i)
+				continue
+			case string:
+				value = parquet.ByteArrayValue([]byte(v))
+			case time.Time:
+				value = parquet.Int64Value(v.UnixNano())
+			case float64:
+				value = parquet.DoubleValue(v)
+			}
+			values[i] = value.Level(0, w.definitionLevels[i], i)
+		}
+
+		w.rows = append(w.rows, values)
+		if len(w.rows) == cap(w.rows) {
+			if err := w.flush(); err != nil {
+				return err
+			}
+		}
+	}
+	return w.flush()
+}
+
+func (w *parquetWriter) flush() error {
+	_, err := w.writer.WriteRows(w.rows)
+	clear(w.rows)
+	w.rows = w.rows[:0]
+	return err
+}
+
+func (w *parquetWriter) close() error {
+	return w.writer.Close()
+}
+
+func (parquetFormat) newReader(r io.ReaderAt, size int64) (tableReader, error) {
+	f, err := parquet.OpenFile(r, size)
+	if err != nil {
+		return nil, err
+	}
+
+	schema := f.Schema()
+	cols := make([]tableColumn, 0, len(schema.Columns()))
+	for _, path := range schema.Columns() {
+		leaf, _ := schema.Lookup(path...)
+
+		typ := columnString
+		switch logicalType := leaf.Node.Type().LogicalType(); {
+		case logicalType != nil && logicalType.Timestamp != nil:
+			typ = columnTimestamp
+		case leaf.Node.Type().Kind() == parquet.Double:
+			typ = columnFloat
+		}
+		cols = append(cols, tableColumn{name: path[len(path)-1], typ: typ})
+	}
+
+	return &parquetReader{
+		reader: parquet.NewReader(f),
+		cols:   cols,
+		rows:   make([]parquet.Row, tableReadRows),
+	}, nil
+}
+
+type parquetReader struct {
+	reader *parquet.Reader
+	cols   []tableColumn
+	rows   []parquet.Row
+}
+
+func (r *parquetReader) columns() []tableColumn {
+	return r.cols
+}
+
+func (r *parquetReader) next() ([][]any, error) {
+	n, err := r.reader.ReadRows(r.rows)
+	if n == 0 {
+		if err == nil || errors.Is(err, io.EOF) {
+			return nil, io.EOF
+		}
+		return nil, err
+	}
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+
+	rows := make([][]any, n)
+	for i, row := range r.rows[:n] {
+		values := make([]any, len(r.cols))
+		for _, v := range row {
+			if v.IsNull() {
+				continue
+			}
+			switch r.cols[v.Column()].typ {
+			case columnTimestamp:
+				values[v.Column()] = time.Unix(0, v.Int64()).UTC()
+			case columnFloat:
+				values[v.Column()] = v.Double()
+			default:
+				values[v.Column()] = string(v.ByteArray())
+			}
+		}
+		rows[i] = values
+	}
+	return rows, nil
+}
+
+func (r *parquetReader) close() error {
+	return r.reader.Close()
+}
@@ -0,0 +1,225 @@
+package output
+
+import (
+	"bytes"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/loki/v3/pkg/loghttp"
+)
+
+var tableFormats = map[string]tableFormat{
+	"csv":     csvFormat{},
+	"parquet": parquetFormat{},
+	"arrow":   arrowFormat{},
+}
+
+func TestTableOutput_Logs(t *testing.T) {
+	ts := time.Date(2024, 1, 2, 3, 4, 5, 6, time.UTC)
+
+	for name, format := range tableFormats {
+		t.Run(name, func(t *testing.T) {
+			var buf bytes.Buffer
+			out := newTableOutput(&buf, &LogOutputOptions{Timezone: time.UTC}, format)
+
+			out.FormatAndPrintEntry(loghttp.LabelSet{"app": "foo", "line": "conflict"}, loghttp.Entry{
+				Timestamp:          ts,
+				Line:               "first",
+				StructuredMetadata: labels.FromStrings("trace_id", "abc"),
+			})
+			out.FormatAndPrintln(ts.Add(time.Second), loghttp.LabelSet{"app": "bar", "env": "prod"}, 0, "")
+			require.NoError(t, out.Close())
+
+			tbl := readTable(t, format, buf.Bytes())
+			require.Equal(t, map[string]columnType{
+				"timestamp":      columnTimestamp,
+				"line":           columnString,
+				"app":            columnString,
+				"line_extracted": columnString,
+				"trace_id":       columnString,
+				"env":            columnString,
+			}, columnTypes(tbl))
+			require.Equal(t, []map[string]any{
+				{"timestamp": ts, "line": "first", "app": "foo", "line_extracted": "conflict", "trace_id": "abc"},
+				{"timestamp": ts.Add(time.Second), "line": "", "app": "bar", "env": "prod"},
+			}, rowsByName(tbl))
+		})
+	}
+}
+
+func TestTableOutput_Samples(t *testing.T) {
+	ts := model.TimeFromUnix(1700000000)
+
+	for name, format := range tableFormats {
+		t.Run(name, func(t *testing.T) {
+			var buf bytes.Buffer
+			out := newTableOutput(&buf, &LogOutputOptions{Timezone: time.UTC, NoLabels: true}, format)
+
+			out.WriteMatrix(loghttp.Matrix{{
+				Metric: model.Metric{"app": "foo"},
+				Values: []model.SamplePair{{Timestamp: ts, Value: 1}, {Timestamp: ts.Add(time.Minute), Value: 2.5}},
+			}})
+			require.NoError(t, out.Close())
+
+			tbl := readTable(t, format, buf.Bytes())
+			require.Equal(t, []map[string]any{
+				{"timestamp": ts.Time().UTC(), "value": 1.0},
+				{"timestamp": ts.Add(time.Minute).Time().UTC(), "value": 2.5},
+			}, rowsByName(tbl))
+		})
+	}
+}
+
+func TestTableOutput_MergePart(t *testing.T) {
+	ts := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	options := &LogOutputOptions{Timezone: time.UTC}
+
+	for name, format := range tableFormats {
+		t.Run(name, func(t *testing.T) {
+			var merged bytes.Buffer
+			out := newTableOutput(&merged, options, format)
+
+			// Parts written by outputs created with WithWriter have different
+			// columns.
+			parts := []loghttp.LabelSet{{"app": "foo"}, {"env": "prod"}}
+			for i, lbls := range parts {
+				var part bytes.Buffer
+				partOut := out.WithWriter(&part).(*TableOutput)
+				partOut.FormatAndPrintln(ts.Add(time.Duration(i)*time.Second), lbls, 0, "line")
+				require.NoError(t, partOut.Close())
+
+				require.NoError(t, out.MergePart(bytes.NewReader(part.Bytes()), int64(part.Len())))
+			}
+			require.Zero(t, merged.Len())
+			require.NoError(t, out.Close())
+
+			tbl := readTable(t, format, merged.Bytes())
+			require.Equal(t, []map[string]any{
+				{"timestamp": ts, "line": "line", "app": "foo"},
+				{"timestamp": ts.Add(time.Second), "line": "line", "env": "prod"},
+			}, rowsByName(tbl))
+		})
+	}
+}
+
+func TestTableOutput_MergePartColumns(t *testing.T) {
+	ts := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	// Rows are written as soon as they are added.
+	options := &LogOutputOptions{Timezone: time.UTC, TableBufferRows: 1}
+
+	for name, format := range tableFormats {
+		t.Run(name, func(t *testing.T) {
+			var merged bytes.Buffer
+			out := newTableOutput(&merged, options, format)
+
+			var parts [][]byte
+			for i, lbls := range []loghttp.LabelSet{{"app": "foo"}, {"env": "prod"}} {
+				var part bytes.Buffer
+				partOut := out.WithWriter(&part).(*TableOutput)
+				partOut.FormatAndPrintln(ts.Add(time.Duration(i)*time.Second), lbls, 0, "line")
+				require.NoError(t, partOut.Close())
+				parts = append(parts, part.Bytes())
+			}
+
+			for _, part := range parts {
+				require.NoError(t, out.MergePartColumns(bytes.NewReader(part), int64(len(part))))
+			}
+			for _, part := range parts {
+				require.NoError(t, out.MergePart(bytes.NewReader(part), int64(len(part))))
+			}
+			require.NoError(t, out.Close())
+
+			tbl := readTable(t, format, merged.Bytes())
+			require.Equal(t, []map[string]any{
+				{"timestamp": ts, "line": "line", "app": "foo"},
+				{"timestamp": ts.Add(time.Second), "line": "line", "env": "prod"},
+			}, rowsByName(tbl))
+		})
+	}
+}
+
+func TestTableOutput_Streaming(t *testing.T) {
+	ts := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	var buf bytes.Buffer
+	out := newTableOutput(&buf, &LogOutputOptions{Timezone: time.UTC, TableBufferRows: 2}, csvFormat{})
+
+	out.FormatAndPrintln(ts, loghttp.LabelSet{"app": "foo"}, 0, "first")
+	require.Zero(t, buf.Len())
+
+	// The columns are determined by the first rows.
+	out.FormatAndPrintln(ts.Add(time.Second), loghttp.LabelSet{"app": "bar"}, 0, "second")
+	require.Equal(t, "timestamp,line,app\n"+
+		"2024-01-02T03:04:05Z,first,foo\n"+
+		"2024-01-02T03:04:06Z,second,bar\n", buf.String())
+
+	// A new column fails as soon as its row is added, and the following rows
+	// are dropped.
+	out.FormatAndPrintln(ts.Add(2*time.Second), loghttp.LabelSet{"app": "foo", "env": "prod"}, 0, "third")
+	require.ErrorContains(t, Err(out), `column "env" first appeared after the first 2 rows`)
+
+	out.FormatAndPrintln(ts.Add(3*time.Second), loghttp.LabelSet{"app": "foo"}, 0, "fourth")
+	require.Len(t, out.table.rows, 1)
+	require.ErrorContains(t, out.Close(), `column "env" first appeared after the first 2 rows`)
+}
+
+func TestTableOutput_CloseEmpty(t *testing.T) {
+	var buf bytes.Buffer
+	out := newTableOutput(&buf, &LogOutputOptions{Timezone: time.UTC}, csvFormat{})
+	require.NoError(t, out.Close())
+	require.Zero(t, buf.Len())
+}
+
+func TestTable_ColumnTypeConflict(t *testing.T) {
+	tbl := newTable()
+	_, err := tbl.column("value", columnFloat)
+	require.NoError(t, err)
+
+	_, err = tbl.addColumns([]tableColumn{{name: "value", typ: columnString}})
+	require.Error(t, err)
+}
+
+// readTable reads all rows of a table written in format.
+func readTable(t *testing.T, format tableFormat, data []byte) *table {
+	reader, err := format.newReader(bytes.NewReader(data), int64(len(data)))
+	require.NoError(t, err)
+	defer reader.close()
+
+	tbl := newTable()
+	mapping, err := tbl.addColumns(reader.columns())
+	require.NoError(t, err)
+	for {
+		rows, err := reader.next()
+		if err == io.EOF {
+			return tbl
+		}
+		require.NoError(t, err)
+		tbl.appendRows(mapping, rows)
+	}
+}
+
+func columnTypes(t *table) map[string]columnType {
+	types := make(map[string]columnType, len(t.columns))
+	for _, col := range t.columns {
+		types[col.name] = col.typ
+	}
+	return types
+}
+
+func rowsByName(t *table) []map[string]any {
+	rows := make([]map[string]any, len(t.rows))
+	for row := range t.rows {
+		rows[row] = make(map[string]any)
+		for i, col := range t.columns {
+			if v := t.value(row, i); v != nil {
+				rows[row][col.name] = v
+			}
+		}
+	}
+	return rows
+}
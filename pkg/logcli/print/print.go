@@ -3,12 +3,15 @@ package print
 import (
 	"encoding/json"
 	"fmt"
+	"io"
 	"log"
 	"os"
 	"sort"
 	"strings"
 	"text/tabwriter"
+	"time"
 
+	"github.com/dustin/go-humanize"
 	"github.com/fatih/color"
 
 	"github.com/grafana/loki/v3/pkg/logcli/output"
@@ -50,10 +53,22 @@ func (r *QueryResultPrinter) PrintResult(value loghttp.ResultValue, out output.L
 	case logqlmodel.ValueTypeStreams:
 		length, entry = r.printStream(value.(loghttp.Streams), out, lastEntry)
 	case loghttp.ResultTypeScalar:
+		if mo, ok := out.(output.MetricOutput); ok {
+			mo.WriteScalar(value.(loghttp.Scalar))
+			break
+		}
 		printScalar(value.(loghttp.Scalar))
 	case loghttp.ResultTypeMatrix:
+		if mo, ok := out.(output.MetricOutput); ok {
+			mo.WriteMatrix(value.(loghttp.Matrix))
+			break
+		}
 		printMatrix(value.(loghttp.Matrix))
 	case loghttp.ResultTypeVector:
+		if mo, ok := out.(output.MetricOutput); ok {
+			mo.WriteVector(value.(loghttp.Vector))
+			break
+		}
 		printVector(value.(loghttp.Vector))
 	default:
 		log.Fatalf("Unable to print unsupported type: %v", value.Type())
@@ -62,7 +77,15 @@ func (r *QueryResultPrinter) PrintResult(value loghttp.ResultValue, out output.L
 }
 
 func (r *QueryResultPrinter) printStream(streams loghttp.Streams, out output.LogOutput, lastEntry []*loghttp.Entry) (int, []*loghttp.Entry) {
+	// Outputs writing entries with their structured metadata write labels as
+	// columns, so common labels are always included.
+	entryOut, writesEntries := out.(output.EntryOutput)
+	includeCommonLabels := r.IncludeCommonLabels || writesEntries
+
 	common := commonLabels(streams)
+	if includeCommonLabels {
+		common = nil
+	}
 
 	// Remove the labels we want to show from common
 	if len(r.ShowLabelsKey) > 0 {
@@ -87,7 +110,7 @@ func (r *QueryResultPrinter) printStream(streams loghttp.Streams, out output.Log
 	for i, s := range streams {
 		ls := s.Labels
 		// Remove common labels
-		if !r.IncludeCommonLabels {
+		if !includeCommonLabels {
 			ls = subtract(s.Labels, common)
 		}
 
@@ -149,7 +172,11 @@ func (r *QueryResultPrinter) printStream(streams loghttp.Streams, out output.Log
 				continue
 			}
 		}
-		out.FormatAndPrintln(e.entry.Timestamp, e.labels, maxLabelsLen, e.entry.Line)
+		if writesEntries {
+			entryOut.FormatAndPrintEntry(e.labels, e.entry)
+		} else {
+			out.FormatAndPrintln(e.entry.Timestamp, e.labels, maxLabelsLen, e.entry.Line)
+		}
 		printed++
 	}
 
@@ -217,6 +244,47 @@ func (r *QueryResultPrinter) PrintStats(stats stats.Result) {
 	stats.Log(kvLogger{Writer: writer})
 }
 
+// PrintAnalysis prints the query plans annotated with runtime statistics that
+// are returned for queries with explain analyze.
+func (r *QueryResultPrinter) PrintAnalysis(analysis []*stats.Analysis) {
+	for _, a := range analysis {
+		WriteAnalysis(os.Stderr, a)
+	}
+}
+
+// WriteAnalysis writes the plan of the analyzed query as a tree, in which each
+// node is followed by its runtime statistics.
+func WriteAnalysis(w io.Writer, a *stats.Analysis) {
+	fmt.Fprintf(w, "Analysis of query %s from %s to %s:\n", a.Query,
+		time.Unix(0, a.Start).UTC().Format(time.RFC3339Nano), time.Unix(0, a.End).UTC().Format(time.RFC3339Nano))
+	for _, node := range a.Plan {
+		writeAnalysisNode(w, node, "", "")
+	}
+}
+
+func writeAnalysisNode(w io.Writer, node *stats.AnalysisNode, prefix, childPrefix string) {
+	var sb strings.Builder
+	sb.WriteString(node.Type)
+	for _, p := range node.Properties {
+		fmt.Fprintf(&sb, " %s=%s", p.Key, p.Value)
+	}
+
+	fmt.Fprintf(&sb, " [rows_in=%d rows_out=%d batches=%d", node.RowsIn, node.RowsOut, node.Batches)
+	if node.BytesRead > 0 || node.PagesPruned > 0 {
+		fmt.Fprintf(&sb, " bytes_read=%s pages_pruned=%d", humanize.Bytes(uint64(node.BytesRead)), node.PagesPruned)
+	}
+	fmt.Fprintf(&sb, " wall_time=%s]", time.Duration(node.WallTime*float64(time.Second)))
+
+	fmt.Fprintf(w, "%s%s\n", prefix, sb.String())
+	for i, child := range node.Children {
+		if i == len(node.Children)-1 {
+			writeAnalysisNode(w, child, childPrefix+"└── ", childPrefix+"    ")
+		} else {
+			writeAnalysisNode(w, child, childPrefix+"├── ", childPrefix+"│   ")
+		}
+	}
+}
+
 func matchLabels(on bool, l loghttp.LabelSet, names []string) loghttp.LabelSet {
 	return util.MatchLabels(on, l, names)
 }
@@ -1,12 +1,15 @@
 package print
 
 import (
+	"bytes"
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 
 	"github.com/grafana/loki/v3/pkg/loghttp"
+	"github.com/grafana/loki/v3/pkg/logqlmodel/stats"
 	"github.com/grafana/loki/v3/pkg/util/marshal"
 )
 
@@ -148,3 +151,27 @@ func mustParseLabels(t *testing.T, s string) loghttp.LabelSet {
 
 	return l
 }
+
+func TestWriteAnalysis(t *testing.T) {
+	var buf bytes.Buffer
+	WriteAnalysis(&buf, &stats.Analysis{
+		Query: `sum(count_over_time({app="foo"}[5m]))`,
+		Start: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC).UnixNano(),
+		End:   time.Date(2024, 1, 1, 1, 0, 0, 0, time.UTC).UnixNano(),
+		Plan: []*stats.AnalysisNode{{
+			Type:       "VectorAggregation",
+			Properties: []stats.AnalysisProperty{{Key: "operation", Value: "sum"}},
+			RowsIn:     20, RowsOut: 10, Batches: 1, WallTime: 0.5,
+			Children: []*stats.AnalysisNode{
+				{Type: "DataObjScan", RowsOut: 10, Batches: 1, BytesRead: 2000, PagesPruned: 3, WallTime: 0.2},
+				{Type: "DataObjScan", RowsOut: 10, Batches: 1, BytesRead: 1000, WallTime: 0.1},
+			},
+		}},
+	})
+
+	require.Equal(t, `Analysis of query sum(count_over_time({app="foo"}[5m])) from 2024-01-01T00:00:00Z to 2024-01-01T01:00:00Z:
+VectorAggregation operation=sum [rows_in=20 rows_out=10 batches=1 wall_time=500ms]
+├── DataObjScan [rows_in=0 rows_out=10 batches=1 bytes_read=2.0 kB pages_pruned=3 wall_time=200ms]
+└── DataObjScan [rows_in=0 rows_out=10 batches=1 bytes_read=1.0 kB pages_pruned=0 wall_time=100ms]
+`, buf.String())
+}
@@ -0,0 +1,366 @@
+package query
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/grafana/dskit/tenant"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/thanos-io/objstore/providers/filesystem"
+
+	"github.com/grafana/loki/v3/pkg/dataobj/metastore"
+	dataobj_querier "github.com/grafana/loki/v3/pkg/dataobj/querier"
+	"github.com/grafana/loki/v3/pkg/logcli/output"
+	"github.com/grafana/loki/v3/pkg/logql"
+	"github.com/grafana/loki/v3/pkg/loki"
+	"github.com/grafana/loki/v3/pkg/storage"
+	"github.com/grafana/loki/v3/pkg/storage/chunk/client/local"
+	"github.com/grafana/loki/v3/pkg/storage/config"
+	"github.com/grafana/loki/v3/pkg/storage/stores/shipper/indexshipper"
+	"github.com/grafana/loki/v3/pkg/storage/types"
+	"github.com/grafana/loki/v3/pkg/util/constants"
+	util_log "github.com/grafana/loki/v3/pkg/util/log"
+	"github.com/grafana/loki/v3/pkg/validation"
+)
+
+// dataObjectMagic is the magic number at the start of data objects.
+var dataObjectMagic = []byte("THOR")
+
+// tsdbTableName matches the names of TSDB index tables, which are a prefix
+// followed by the number of the period since the Unix epoch.
+var tsdbTableName = regexp.MustCompile(`^(.*?)(\d+)$`)
+
+// localDirKind is the kind of data stored in a local directory.
+type localDirKind int
+
+const (
+	localDirUnknown localDirKind = iota
+	// localDirDataObjects is a directory of data objects, such as an exported
+	// data object bucket.
+	localDirDataObjects
+	// localDirChunks is a filesystem chunk store with a TSDB index, laid out
+	// like the directory of the filesystem object store.
+	localDirChunks
+)
+
+// DoLocalDirQuery executes the query against a local directory of data
+// objects, or a filesystem chunk store with a TSDB index, without any Loki
+// services.
+func (q *Query) DoLocalDirQuery(out output.LogOutput, statistics bool, orgID string) error {
+	kind, err := detectLocalDir(q.LocalDir)
+	if err != nil {
+		return err
+	}
+
+	conf, err := defaultLocalConfig()
+	if err != nil {
+		return err
+	}
+	limits, err := validation.NewOverrides(conf.LimitsConfig, nil)
+	if err != nil {
+		return err
+	}
+
+	var querier logql.Querier
+	switch kind {
+	case localDirDataObjects:
+		if orgID == "" {
+			orgID = dataObjectTenant(q.LocalDir)
+		}
+		querier, err = newDataObjectQuerier(q.LocalDir)
+	case localDirChunks:
+		if orgID == "" {
+			orgID = "fake"
+		}
+		var cleanup func()
+		querier, cleanup, err = newChunkStoreQuerier(conf, limits, q.LocalDir, orgID)
+		if cleanup != nil {
+			defer cleanup()
+		}
+	default:
+		return fmt.Errorf("%s contains neither data objects nor a chunk store with a TSDB index", q.LocalDir)
+	}
+	if err != nil {
+		return err
+	}
+
+	eng := logql.NewEngine(conf.Querier.Engine, querier, limits, util_log.Logger)
+	return q.execLocal(eng, out, statistics, orgID)
+}
+
+// defaultLocalConfig returns a Loki configuration with default values.
+func defaultLocalConfig() (loki.Config, error) {
+	var conf loki.Config
+	fs := flag.NewFlagSet("local", flag.ContinueOnError)
+	conf.RegisterFlags(fs)
+	return conf, fs.Parse(nil)
+}
+
+// detectLocalDir returns the kind of data stored in dir. Directories with an
+// index directory of TSDB tables are chunk stores; directories with data
+// objects anywhere below them are data object directories.
+func detectLocalDir(dir string) (localDirKind, error) {
+	fi, err := os.Stat(dir)
+	if err != nil {
+		return localDirUnknown, err
+	}
+	if !fi.IsDir() {
+		return localDirUnknown, fmt.Errorf("%s is not a directory", dir)
+	}
+
+	if tables, err := indexTables(dir); err == nil && len(tables) > 0 {
+		return localDirChunks, nil
+	}
+
+	kind := localDirUnknown
+	err = filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		if ok, err := isDataObject(p); err != nil {
+			return err
+		} else if ok {
+			kind = localDirDataObjects
+			return filepath.SkipAll
+		}
+		return nil
+	})
+	return kind, err
+}
+
+func isDataObject(name string) (bool, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return false, err
+	}
+	defer f.Close()
+
+	magic := make([]byte, len(dataObjectMagic))
+	if _, err := io.ReadFull(f, magic); err == io.EOF || err == io.ErrUnexpectedEOF {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	return bytes.Equal(magic, dataObjectMagic), nil
+}
+
+// dataObjectTenant returns the tenant of a data object directory with the
+// objects of a single tenant, or "fake" otherwise.
+func dataObjectTenant(dir string) string {
+	entries, _ := os.ReadDir(dir)
+
+	var tenants []string
+	for _, e := range entries {
+		if id, ok := strings.CutPrefix(e.Name(), "tenant-"); ok && e.IsDir() {
+			tenants = append(tenants, id)
+		}
+	}
+	if len(tenants) == 1 {
+		return tenants[0]
+	}
+	return "fake"
+}
+
+// newDataObjectQuerier returns a querier for the data objects in dir. If dir
+// has a metastore for the tenant of a query, it is used to find the objects of
+// the query. Otherwise, all objects of the tenant are queried, or all objects
+// in dir if it has no tenant directories.
+func newDataObjectQuerier(dir string) (logql.Querier, error) {
+	bucket, err := filesystem.NewBucket(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	meta := &dirMetastore{
+		dir:    dir,
+		object: metastore.NewObjectMetastore(bucket, util_log.Logger, prometheus.NewRegistry()),
+	}
+	return dataobj_querier.NewStore(bucket, util_log.Logger, meta), nil
+}
+
+// dirMetastore is a metastore.Metastore for a local directory of data objects,
+// which may not have metastore objects. Only DataObjects is used by the data
+// object querier.
+type dirMetastore struct {
+	metastore.Metastore
+
+	dir    string
+	object *metastore.ObjectMetastore
+}
+
+// DataObjects returns the paths of the data objects of the tenant of ctx
+// between start and end if the tenant has a metastore, or the paths of all
+// data objects of the tenant otherwise.
+func (m *dirMetastore) DataObjects(ctx context.Context, start, end time.Time, matchers ...*labels.Matcher) ([]string, error) {
+	tenantID, err := tenant.TenantID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	tenantDir := "tenant-" + tenantID
+	if exists(filepath.Join(m.dir, tenantDir, "metastore")) {
+		return m.object.DataObjects(ctx, start, end, matchers...)
+	}
+
+	root := m.dir
+	if exists(filepath.Join(m.dir, tenantDir)) {
+		root = filepath.Join(m.dir, tenantDir)
+	}
+
+	var paths []string
+	err = filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			// Metastore objects are data objects too, but they only index
+			// the data objects of a tenant.
+			if d.Name() == "metastore" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if ok, err := isDataObject(p); err != nil || !ok {
+			return err
+		}
+		rel, err := filepath.Rel(m.dir, p)
+		if err != nil {
+			return err
+		}
+		paths = append(paths, filepath.ToSlash(rel))
+		return nil
+	})
+	return paths, err
+}
+
+func exists(name string) bool {
+	_, err := os.Stat(name)
+	return err == nil
+}
+
+// newChunkStoreQuerier returns a querier for the filesystem chunk store in
+// dir. The schema is loaded from a schema config file in dir if there is one,
+// or inferred from the names of the TSDB index tables otherwise. The returned
+// function removes the local cache of the index.
+func newChunkStoreQuerier(conf loki.Config, limits *validation.Overrides, dir, orgID string) (logql.Querier, func(), error) {
+	conf.StorageConfig.FSConfig.Directory = dir
+	objectClient, err := local.NewFSObjectClient(conf.StorageConfig.FSConfig)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	schema, err := getLatestConfig(objectClient, orgID)
+	if err != nil {
+		if schema, err = inferSchemaConfig(dir); err != nil {
+			return nil, nil, err
+		}
+	}
+	conf.SchemaConfig = *schema
+	if err := conf.SchemaConfig.Validate(); err != nil {
+		return nil, nil, err
+	}
+
+	cacheDir, err := os.MkdirTemp("", "logcli-index-cache")
+	if err != nil {
+		return nil, nil, err
+	}
+	cleanup := func() { _ = os.RemoveAll(cacheDir) }
+
+	conf.StorageConfig.TSDBShipperConfig.Mode = indexshipper.ModeReadOnly
+	conf.StorageConfig.TSDBShipperConfig.IndexGatewayClientConfig.Disabled = true
+	conf.StorageConfig.TSDBShipperConfig.ActiveIndexDirectory = filepath.Join(cacheDir, "active")
+	conf.StorageConfig.TSDBShipperConfig.CacheLocation = filepath.Join(cacheDir, "cache")
+
+	cm := storage.NewClientMetrics()
+	querier, err := storage.NewStore(conf.StorageConfig, conf.ChunkStoreConfig, conf.SchemaConfig, limits, cm, prometheus.NewRegistry(), util_log.Logger, constants.Loki)
+	if err != nil {
+		cleanup()
+		return nil, nil, err
+	}
+	return querier, cleanup, nil
+}
+
+// indexTables returns the names of the TSDB index tables of the chunk store
+// in dir.
+func indexTables(dir string) ([]string, error) {
+	entries, err := os.ReadDir(filepath.Join(dir, "index"))
+	if err != nil {
+		return nil, err
+	}
+
+	var tables []string
+	for _, e := range entries {
+		if e.IsDir() && tsdbTableName.MatchString(e.Name()) {
+			tables = append(tables, e.Name())
+		}
+	}
+	return tables, nil
+}
+
+// inferSchemaConfig returns a schema config for the chunk store in dir with a
+// single v13 TSDB period starting at the day of its oldest index table. Index
+// tables must have a period of 24h, which is the only period supported by the
+// TSDB index.
+func inferSchemaConfig(dir string) (*config.SchemaConfig, error) {
+	tables, err := indexTables(dir)
+	if err != nil {
+		return nil, fmt.Errorf("listing index tables: %w", err)
+	}
+	if len(tables) == 0 {
+		return nil, errors.New("no index tables found")
+	}
+
+	var (
+		prefix string
+		first  = -1
+	)
+	sort.Strings(tables)
+	for _, table := range tables {
+		match := tsdbTableName.FindStringSubmatch(table)
+		if prefix != "" && match[1] != prefix {
+			return nil, fmt.Errorf("index tables with different prefixes %q and %q, add a schema config file to the directory", prefix, match[1])
+		}
+		prefix = match[1]
+
+		n, err := strconv.Atoi(match[2])
+		if err != nil {
+			return nil, fmt.Errorf("parsing index table %s: %w", table, err)
+		}
+		if first == -1 || n < first {
+			first = n
+		}
+	}
+
+	from := model.TimeFromUnix(int64(first) * int64(config.ObjectStorageIndexRequiredPeriod/time.Second))
+	return &config.SchemaConfig{
+		Configs: []config.PeriodConfig{{
+			From:       config.DayTime{Time: from},
+			IndexType:  types.TSDBType,
+			ObjectType: types.StorageTypeFileSystem,
+			Schema:     "v13",
+			IndexTables: config.IndexPeriodicTableConfig{
+				PathPrefix: path.Clean("index") + "/",
+				PeriodicTableConfig: config.PeriodicTableConfig{
+					Prefix: prefix,
+					Period: config.ObjectStorageIndexRequiredPeriod,
+				},
+			},
+			RowShards: 16,
+		}},
+	}, nil
+}
@@ -0,0 +1,152 @@
+package query
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+	"github.com/thanos-io/objstore/providers/filesystem"
+
+	"github.com/grafana/loki/v3/pkg/dataobj/consumer/logsobj"
+	"github.com/grafana/loki/v3/pkg/dataobj/metastore"
+	"github.com/grafana/loki/v3/pkg/dataobj/uploader"
+	"github.com/grafana/loki/v3/pkg/logcli/output"
+	"github.com/grafana/loki/v3/pkg/logproto"
+	"github.com/grafana/loki/v3/pkg/storage/config"
+)
+
+func TestDoLocalDirQuery_DataObjects(t *testing.T) {
+	ts := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	streams := []logproto.Stream{
+		{Labels: `{app="foo"}`, Entries: []logproto.Entry{
+			{Timestamp: ts, Line: "foo 1"},
+			{Timestamp: ts.Add(2 * time.Second), Line: "foo 2"},
+		}},
+		{Labels: `{app="bar"}`, Entries: []logproto.Entry{
+			{Timestamp: ts.Add(time.Second), Line: "bar 1"},
+		}},
+	}
+
+	for _, withMetastore := range []bool{true, false} {
+		name := "without metastore"
+		if withMetastore {
+			name = "with metastore"
+		}
+		t.Run(name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeDataObject(t, dir, "tenant", withMetastore, streams...)
+
+			var buf bytes.Buffer
+			out, err := output.NewLogOutput(&buf, "raw", &output.LogOutputOptions{Timezone: time.UTC})
+			require.NoError(t, err)
+
+			q := &Query{
+				QueryString: `{app=~"foo|bar"} != "2"`,
+				Start:       ts.Add(-time.Minute),
+				End:         ts.Add(time.Minute),
+				Limit:       10,
+				Forward:     true,
+				Quiet:       true,
+				LocalDir:    dir,
+			}
+			// The tenant is inferred from the tenant directory.
+			require.NoError(t, q.DoLocalDirQuery(out, false, ""))
+			require.Equal(t, "foo 1\nbar 1\n", buf.String())
+		})
+	}
+}
+
+func TestDetectLocalDir(t *testing.T) {
+	dir := t.TempDir()
+	kind, err := detectLocalDir(dir)
+	require.NoError(t, err)
+	require.Equal(t, localDirUnknown, kind)
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("TH"), 0o644))
+	kind, err = detectLocalDir(dir)
+	require.NoError(t, err)
+	require.Equal(t, localDirUnknown, kind)
+
+	writeDataObject(t, dir, "tenant", false, logproto.Stream{
+		Labels:  `{app="foo"}`,
+		Entries: []logproto.Entry{{Timestamp: time.Unix(0, 0), Line: "foo"}},
+	})
+	kind, err = detectLocalDir(dir)
+	require.NoError(t, err)
+	require.Equal(t, localDirDataObjects, kind)
+
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "index", "index_19723"), 0o755))
+	kind, err = detectLocalDir(dir)
+	require.NoError(t, err)
+	require.Equal(t, localDirChunks, kind)
+}
+
+func TestInferSchemaConfig(t *testing.T) {
+	dir := t.TempDir()
+	for _, table := range []string{"loki_index_19724", "loki_index_19723"} {
+		require.NoError(t, os.MkdirAll(filepath.Join(dir, "index", table), 0o755))
+	}
+
+	schema, err := inferSchemaConfig(dir)
+	require.NoError(t, err)
+	require.NoError(t, schema.Validate())
+	require.Len(t, schema.Configs, 1)
+
+	period := schema.Configs[0]
+	require.Equal(t, "2024-01-01", period.From.String())
+	require.Equal(t, "loki_index_", period.IndexTables.Prefix)
+	require.Equal(t, "index/", period.IndexTables.PathPrefix)
+	require.Equal(t, config.ObjectStorageIndexRequiredPeriod, period.IndexTables.Period)
+	require.Equal(t, "loki_index_19723", period.IndexTables.PeriodicTableConfig.TableFor(model.TimeFromUnix(time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC).Unix())))
+
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "index", "other_19723"), 0o755))
+	_, err = inferSchemaConfig(dir)
+	require.ErrorContains(t, err, "different prefixes")
+}
+
+// writeDataObject writes a data object with streams to the data object
+// bucket in dir, optionally indexing it in the metastore of the tenant.
+func writeDataObject(t *testing.T, dir, tenantID string, withMetastore bool, streams ...logproto.Stream) {
+	t.Helper()
+
+	bucket, err := filesystem.NewBucket(dir)
+	require.NoError(t, err)
+	defer bucket.Close()
+
+	builder, err := logsobj.NewBuilder(logsobj.BuilderConfig{
+		TargetPageSize:          1024 * 1024,
+		TargetObjectSize:        10 * 1024 * 1024,
+		TargetSectionSize:       1024 * 1024,
+		BufferSize:              1024 * 1024,
+		SectionStripeMergeLimit: 2,
+	})
+	require.NoError(t, err)
+	for _, s := range streams {
+		require.NoError(t, builder.Append(s))
+	}
+
+	var buf bytes.Buffer
+	stats, err := builder.Flush(&buf)
+	require.NoError(t, err)
+
+	up := uploader.New(uploader.Config{SHAPrefixSize: 2}, bucket, tenantID, log.NewNopLogger())
+	require.NoError(t, up.RegisterMetrics(prometheus.NewRegistry()))
+	path, err := up.Upload(context.Background(), &buf)
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(path, "tenant-"+tenantID+"/"))
+
+	if withMetastore {
+		require.NoError(t, os.MkdirAll(filepath.Join(dir, "tenant-"+tenantID, "metastore"), 0o755))
+		meta := metastore.NewUpdater(metastore.UpdaterConfig{}, bucket, tenantID, log.NewNopLogger())
+		require.NoError(t, meta.RegisterMetrics(prometheus.NewRegistry()))
+		require.NoError(t, meta.Update(context.Background(), path, stats.MinTimestamp, stats.MaxTimestamp))
+	}
+}
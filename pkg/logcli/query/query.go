@@ -56,6 +56,9 @@ type Query struct {
 	LocalConfig            string
 	FetchSchemaFromStorage bool
 	SchemaStore            string
+	// LocalDir is a directory of data objects, or a filesystem chunk store
+	// with a TSDB index, to execute the query against.
+	LocalDir string
 
 	// Parallelization parameters.
 
@@ -96,6 +99,13 @@ func (q *Query) DoQuery(c client.Client, out output.LogOutput, statistics bool)
 		return
 	}
 
+	if q.LocalDir != "" {
+		if err := q.DoLocalDirQuery(out, statistics, c.GetOrgID()); err != nil {
+			log.Fatalf("Query failed: %+v", err)
+		}
+		return
+	}
+
 	d := q.resultsDirection()
 
 	var resp *loghttp.QueryResponse
@@ -129,7 +139,11 @@ func (q *Query) DoQuery(c client.Client, out output.LogOutput, statistics bool)
 		if statistics {
 			result.PrintStats(resp.Data.Statistics)
 		}
+		result.PrintAnalysis(resp.Data.Statistics.Analysis)
 		_, _ = result.PrintResult(resp.Data.Result, out, nil)
+		if err := output.Err(out); err != nil {
+			log.Fatalf("Writing results failed: %+v", err)
+		}
 	} else {
 		unlimited := q.Limit == 0
 
@@ -160,8 +174,14 @@ func (q *Query) DoQuery(c client.Client, out output.LogOutput, statistics bool)
 			if statistics {
 				result.PrintStats(resp.Data.Statistics)
 			}
+			result.PrintAnalysis(resp.Data.Statistics.Analysis)
 
 			resultLength, lastEntry = result.PrintResult(resp.Data.Result, out, lastEntry)
+			// Outputs which failed drop the following results, so don't query
+			// the following batches.
+			if err := output.Err(out); err != nil {
+				log.Fatalf("Writing results failed: %+v", err)
+			}
 			// Was not a log stream query, or no results, no more batching
 			if resultLength <= 0 {
 				break
@@ -203,6 +223,11 @@ func (q *Query) DoQuery(c client.Client, out output.LogOutput, statistics bool)
 	}
 
 	if partFile != nil {
+		// Outputs buffering results only write them when closed, which must
+		// happen before the part file is finalized.
+		if err := output.Close(out); err != nil {
+			log.Fatalln(err)
+		}
 		if err := partFile.Finalize(); err != nil {
 			log.Fatalln(err)
 		}
@@ -298,25 +323,39 @@ func (q *Query) parallelJobs() []*parallelJob {
 	return jobs
 }
 
-// Waits for each job to finish in order, reads the part file and copies it to stdout
-func (q *Query) mergeJobs(jobs []*parallelJob) error {
+// partMerger is implemented by outputs which can't concatenate part files,
+// such as columnar file formats. The columns of all part files are merged
+// before their rows, since the columns are written before any row.
+type partMerger interface {
+	MergePartColumns(r io.ReaderAt, size int64) error
+	MergePart(r io.ReaderAt, size int64) error
+}
+
+// Waits for each job to finish in order, reads the part file and copies it to stdout,
+// or merges it into out if out can't concatenate part files.
+func (q *Query) mergeJobs(jobs []*parallelJob, out output.LogOutput) error {
 	if !q.MergeParts {
 		return nil
 	}
 
+	merger, _ := out.(partMerger)
+	if merger != nil {
+		// The columns of the output are only known once all jobs finished.
+		for _, job := range jobs {
+			<-job.done
+
+			if err := readPart(job.q.outputFilename(), merger.MergePartColumns); err != nil {
+				return err
+			}
+		}
+	}
+
 	for _, job := range jobs {
 		// wait for the next job to finish
 		<-job.done
 
-		f, err := os.Open(job.q.outputFilename())
-		if err != nil {
-			return fmt.Errorf("open file error: %w", err)
-		}
-		defer f.Close()
-
-		_, err = io.Copy(os.Stdout, f)
-		if err != nil {
-			return fmt.Errorf("copying file error: %w", err)
+		if err := mergePart(job.q.outputFilename(), merger); err != nil {
+			return err
 		}
 
 		if !q.KeepParts {
@@ -330,6 +369,38 @@ func (q *Query) mergeJobs(jobs []*parallelJob) error {
 	return nil
 }
 
+func mergePart(filename string, merger partMerger) error {
+	if merger != nil {
+		return readPart(filename, merger.MergePart)
+	}
+
+	f, err := os.Open(filename)
+	if err != nil {
+		return fmt.Errorf("open file error: %w", err)
+	}
+	defer f.Close()
+
+	if _, err := io.Copy(os.Stdout, f); err != nil {
+		return fmt.Errorf("copying file error: %w", err)
+	}
+	return nil
+}
+
+// readPart calls read with the content of the part file.
+func readPart(filename string, read func(r io.ReaderAt, size int64) error) error {
+	f, err := os.Open(filename)
+	if err != nil {
+		return fmt.Errorf("open file error: %w", err)
+	}
+	defer f.Close()
+
+	fi, err := f.Stat()
+	if err != nil {
+		return fmt.Errorf("stat file error: %w", err)
+	}
+	return read(f, fi.Size())
+}
+
 // Starts `ParallelMaxWorkers` number of workers to process all of the `parallelJob`s
 // This function is non-blocking. The caller should `Wait` on the returned `WaitGroup`.
 func (q *Query) startWorkers(
@@ -375,7 +446,7 @@ func (q *Query) DoQueryParallel(c client.Client, out output.LogOutput, statistic
 
 	wg := q.startWorkers(jobs, c, out, statistics)
 
-	if err := q.mergeJobs(jobs); err != nil {
+	if err := q.mergeJobs(jobs, out); err != nil {
 		log.Fatalf("Merging part files error: %s\n", err)
 	}
 
@@ -479,6 +550,12 @@ func (q *Query) DoLocalQuery(out output.LogOutput, statistics bool, orgID string
 	}
 
 	eng := logql.NewEngine(conf.Querier.Engine, querier, limits, util_log.Logger)
+	return q.execLocal(eng, out, statistics, orgID)
+}
+
+// execLocal executes the query with an engine running in logcli and prints
+// the results.
+func (q *Query) execLocal(eng logql.Engine, out output.LogOutput, statistics bool, orgID string) error {
 	var query logql.Query
 
 	if q.isInstant() {
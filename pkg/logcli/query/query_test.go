@@ -896,3 +896,47 @@ func TestParallelJobs(t *testing.T) {
 		)
 	}
 }
+
+func TestMergeJobs_TableOutput(t *testing.T) {
+	prefix := filepath.Join(t.TempDir(), "query")
+	ts := mustParseTime("2023-02-10 15:00:00")
+
+	var buf bytes.Buffer
+	out, err := output.NewLogOutput(&buf, "csv", &output.LogOutputOptions{Timezone: time.UTC})
+	require.NoError(t, err)
+
+	var jobs []*parallelJob
+	for i, lbls := range []loghttp.LabelSet{{"app": "foo"}, {"env": "prod"}} {
+		job := newParallelJob(&Query{
+			Start:          ts.Add(time.Duration(i) * time.Minute),
+			End:            ts.Add(time.Duration(i+1) * time.Minute),
+			PartPathPrefix: prefix,
+		})
+
+		f, err := os.Create(job.q.outputFilename())
+		require.NoError(t, err)
+		partOut := out.WithWriter(f)
+		partOut.FormatAndPrintln(job.q.Start, lbls, 0, fmt.Sprintf("line %d", i))
+		require.NoError(t, output.Close(partOut))
+		require.NoError(t, f.Close())
+
+		close(job.done)
+		jobs = append(jobs, job)
+	}
+
+	q := &Query{MergeParts: true}
+	require.NoError(t, q.mergeJobs(jobs, out))
+	require.NoError(t, output.Close(out))
+
+	// The merged file has a single header with the columns of all parts.
+	require.Equal(t, strings.Join([]string{
+		"timestamp,line,app,env",
+		"2023-02-10T15:00:00Z,line 0,foo,",
+		"2023-02-10T15:01:00Z,line 1,,prod",
+		"",
+	}, "\n"), buf.String())
+
+	for _, job := range jobs {
+		require.NoFileExists(t, job.q.outputFilename())
+	}
+}
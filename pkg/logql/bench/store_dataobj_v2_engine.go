@@ -1,6 +1,7 @@
 package bench
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"path/filepath"
@@ -14,6 +15,15 @@ import (
 
 var errStoreUnimplemented = errors.New("store does not implement this operation")
 
+// engineNoLimits disables the limits of the new engine that are not part of
+// [logql.Limits].
+type engineNoLimits struct {
+	logql.Limits
+}
+
+func (engineNoLimits) QueryEngineAggregationMemoryLimit(_ context.Context, _ string) int { return 0 }
+func (engineNoLimits) QueryEngineAggregationSpillLimit(_ context.Context, _ string) int  { return 0 }
+
 // DataObjV2EngineStore uses the new pkg/engine/engine.New for querying.
 // It assumes the engine can read the "new dataobj format" (e.g. Parquet)
 // from the provided dataDir via a filesystem objstore.Bucket.
@@ -61,7 +71,7 @@ func dataobjV2StoreWithOpts(dataDir string, tenantID string, engineOpts logql.En
 	// or derived from the bucket structure if it's multi-tenant aware.
 	// This might require adjustment based on how pkg/engine/engine actually handles multi-tenancy
 	// with a generic objstore.Bucket.
-	queryEngine := engine.New(engineOpts, bucketClient, logql.NoLimits, nil, logger)
+	queryEngine := engine.New(engineOpts, bucketClient, engineNoLimits{logql.NoLimits}, nil, logger)
 
 	return &DataObjV2EngineStore{
 		engine:   queryEngine,
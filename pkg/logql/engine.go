@@ -35,6 +35,7 @@ import (
 	"github.com/grafana/loki/v3/pkg/logqlmodel/stats"
 	"github.com/grafana/loki/v3/pkg/util"
 	"github.com/grafana/loki/v3/pkg/util/constants"
+	"github.com/grafana/loki/v3/pkg/util/flagext"
 	"github.com/grafana/loki/v3/pkg/util/httpreq"
 	logutil "github.com/grafana/loki/v3/pkg/util/log"
 	"github.com/grafana/loki/v3/pkg/util/server"
@@ -167,6 +168,30 @@ type EngineOpts struct {
 	// Batch size of the v2 execution engine.
 	BatchSize int `yaml:"batch_size" category:"experimental"`
 
+	// SpillPath is the local directory to which the v2 execution engine
+	// spills the state of aggregations that exceed their memory limit.
+	SpillPath string `yaml:"spill_path" category:"experimental"`
+
+	// DistributedFragments is the number of fragments into which the query
+	// frontend cuts the physical plans of the v2 execution engine. The
+	// fragments are executed by the queriers.
+	DistributedFragments int `yaml:"distributed_fragments" category:"experimental"`
+
+	// MaxFragmentResultSize is the maximum size of the encoded results of a
+	// fragment that a querier returns to the query frontend. The results are
+	// held in memory until they are sent.
+	MaxFragmentResultSize flagext.ByteSize `yaml:"max_fragment_result_size" category:"experimental"`
+
+	// ScanWorkers is the maximum number of data object scans of a query of
+	// the v2 execution engine that read concurrently. Sections are split
+	// into row ranges that are scanned concurrently if it is greater than 1.
+	ScanWorkers int `yaml:"scan_workers" category:"experimental"`
+
+	// EnableCostBasedOptimizer enables the cost-based optimizations of the
+	// physical plans of the v2 execution engine, which use the statistics of
+	// the columns of the scanned sections of data objects.
+	EnableCostBasedOptimizer bool `yaml:"enable_cost_based_optimizer" category:"experimental"`
+
 	// CataloguePath is the path to the catalogue in the object store.
 	CataloguePath string `yaml:"-" doc:"hidden" category:"experimental"`
 }
@@ -176,6 +201,12 @@ func (opts *EngineOpts) RegisterFlagsWithPrefix(prefix string, f *flag.FlagSet)
 	f.IntVar(&opts.MaxCountMinSketchHeapSize, prefix+"max-count-min-sketch-heap-size", 10_000, "The maximum number of labels the heap of a topk query using a count min sketch can track.")
 	f.BoolVar(&opts.EnableV2Engine, prefix+"enable-v2-engine", false, "Experimental: Enable next generation query engine for supported queries.")
 	f.IntVar(&opts.BatchSize, prefix+"batch-size", 100, "Experimental: Batch size of the next generation query engine.")
+	f.StringVar(&opts.SpillPath, prefix+"spill-path", "", "Experimental: Local directory to which the next generation query engine spills the state of aggregations that exceed the per-tenant aggregation memory limit. Defaults to the temporary directory of the operating system.")
+	f.IntVar(&opts.DistributedFragments, prefix+"distributed-fragments", 0, "Experimental: Number of fragments into which the query frontend cuts the physical plans of the next generation query engine. The fragments are scheduled on the queriers and their results are merged by the query frontend. 0 disables distributed execution.")
+	opts.MaxFragmentResultSize = 100 << 20
+	f.Var(&opts.MaxFragmentResultSize, prefix+"max-fragment-result-size", "Experimental: Maximum size of the encoded results of a fragment of a distributed query of the next generation query engine, which a querier returns to the query frontend. Queries whose fragments exceed the limit fail.")
+	f.IntVar(&opts.ScanWorkers, prefix+"scan-workers", 0, "Experimental: Maximum number of data object scans of a query of the next generation query engine that read concurrently. If greater than 1, large sections of data objects are split into row ranges that are scanned concurrently. 0 disables the limit and the splitting of sections.")
+	f.BoolVar(&opts.EnableCostBasedOptimizer, prefix+"enable-cost-based-optimizer", false, "Experimental: Estimate the costs of the physical plans of the next generation query engine from the column statistics of the scanned sections of data objects. The costs are used to order predicates and scans and to decide whether distributed execution pays off. Reading the statistics adds latency to query planning.")
 	f.StringVar(&opts.CataloguePath, prefix+"catalogue-path", "", "The path to the catalogue in the object store.")
 	// Log executing query by default
 	opts.LogExecutingQuery = true
@@ -852,6 +852,9 @@ func newDropLabelsExpr(dropLabels []log.NamedLabelMatcher) *DropLabelsExpr {
 
 func (e *DropLabelsExpr) Shardable(_ bool) bool { return true }
 
+// DropLabels returns the matchers of the labels that are dropped.
+func (e *DropLabelsExpr) DropLabels() []log.NamedLabelMatcher { return e.dropLabels }
+
 func (e *DropLabelsExpr) Stage() (log.Stage, error) {
 	return log.NewDropLabels(e.dropLabels), nil
 }
@@ -892,6 +895,9 @@ func newKeepLabelsExpr(keepLabels []log.NamedLabelMatcher) *KeepLabelsExpr {
 
 func (e *KeepLabelsExpr) Shardable(_ bool) bool { return true }
 
+// KeepLabels returns the matchers of the labels that are kept.
+func (e *KeepLabelsExpr) KeepLabels() []log.NamedLabelMatcher { return e.keepLabels }
+
 func (e *KeepLabelsExpr) Stage() (log.Stage, error) {
 	return log.NewKeepLabels(e.keepLabels), nil
 }
@@ -359,3 +359,14 @@ func TestCaches(t *testing.T) {
 		},
 	}, statsCtx.Caches())
 }
+
+func TestResult_MergeAnalysis(t *testing.T) {
+	a := &Analysis{Query: `{app="foo"}`, Plan: []*AnalysisNode{{Type: "DataObjScan", RowsOut: 10}}}
+	b := &Analysis{Query: `{app="bar"}`, Plan: []*AnalysisNode{{Type: "DataObjScan", RowsOut: 20}}}
+
+	var res Result
+	res.Merge(Result{Analysis: []*Analysis{a}})
+	res.Merge(Result{})
+	res.Merge(Result{Analysis: []*Analysis{b}})
+	require.Equal(t, []*Analysis{a, b}, res.Analysis)
+}
@@ -33,6 +33,10 @@ type Result struct {
 	Ingester Ingester `protobuf:"bytes,3,opt,name=ingester,proto3" json:"ingester"`
 	Caches   Caches   `protobuf:"bytes,4,opt,name=caches,proto3" json:"cache"`
 	Index    Index    `protobuf:"bytes,5,opt,name=index,proto3" json:"index"`
+	// Analysis holds the analyzed query plans of the queries executed by the
+	// new query engine. It is only set for queries that request it with the
+	// X-Loki-Explain-Analyze header.
+	Analysis []*Analysis `protobuf:"bytes,6,rep,name=analysis,proto3" json:"analysis,omitempty"`
 }
 
 func (m *Result) Reset()      { *m = Result{} }
@@ -102,6 +106,253 @@ func (m *Result) GetIndex() Index {
 	return Index{}
 }
 
+func (m *Result) GetAnalysis() []*Analysis {
+	if m != nil {
+		return m.Analysis
+	}
+	return nil
+}
+
+// Analysis is the physical plan of a query executed by the new query engine,
+// annotated with the runtime statistics of each node.
+type Analysis struct {
+	// Query is the LogQL query of the plan.
+	Query string `protobuf:"bytes,1,opt,name=query,proto3" json:"query"`
+	// Start and end of the time range of the query in Unix nanoseconds.
+	Start int64 `protobuf:"varint,2,opt,name=start,proto3" json:"start"`
+	End   int64 `protobuf:"varint,3,opt,name=end,proto3" json:"end"`
+	// Plan are the root nodes of the plan.
+	Plan []*AnalysisNode `protobuf:"bytes,4,rep,name=plan,proto3" json:"plan"`
+}
+
+func (m *Analysis) Reset()      { *m = Analysis{} }
+func (*Analysis) ProtoMessage() {}
+func (*Analysis) Descriptor() ([]byte, []int) {
+	return fileDescriptor_6cdfe5d2aea33ebb, []int{1}
+}
+func (m *Analysis) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *Analysis) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_Analysis.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *Analysis) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Analysis.Merge(m, src)
+}
+func (m *Analysis) XXX_Size() int {
+	return m.Size()
+}
+func (m *Analysis) XXX_DiscardUnknown() {
+	xxx_messageInfo_Analysis.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Analysis proto.InternalMessageInfo
+
+func (m *Analysis) GetQuery() string {
+	if m != nil {
+		return m.Query
+	}
+	return ""
+}
+
+func (m *Analysis) GetStart() int64 {
+	if m != nil {
+		return m.Start
+	}
+	return 0
+}
+
+func (m *Analysis) GetEnd() int64 {
+	if m != nil {
+		return m.End
+	}
+	return 0
+}
+
+func (m *Analysis) GetPlan() []*AnalysisNode {
+	if m != nil {
+		return m.Plan
+	}
+	return nil
+}
+
+// AnalysisNode is a node of an analyzed physical plan.
+type AnalysisNode struct {
+	// Type of the node, such as DataObjScan.
+	Type string `protobuf:"bytes,1,opt,name=type,proto3" json:"type"`
+	// Properties of the node as they are printed by explain.
+	Properties []AnalysisProperty `protobuf:"bytes,2,rep,name=properties,proto3" json:"properties,omitempty"`
+	// Number of rows returned by the children of the node.
+	RowsIn int64 `protobuf:"varint,3,opt,name=rowsIn,proto3" json:"rowsIn"`
+	// Number of rows returned by the node.
+	RowsOut int64 `protobuf:"varint,4,opt,name=rowsOut,proto3" json:"rowsOut"`
+	// Number of batches returned by the node.
+	Batches int64 `protobuf:"varint,5,opt,name=batches,proto3" json:"batches"`
+	// Number of bytes read from object storage.
+	BytesRead int64 `protobuf:"varint,6,opt,name=bytesRead,proto3" json:"bytesRead"`
+	// Number of pages skipped because of predicates.
+	PagesPruned int64 `protobuf:"varint,7,opt,name=pagesPruned,proto3" json:"pagesPruned"`
+	// Time spent reading from the node, including the time spent in its
+	// children, in seconds.
+	WallTime float64         `protobuf:"fixed64,8,opt,name=wallTime,proto3" json:"wallTime"`
+	Children []*AnalysisNode `protobuf:"bytes,9,rep,name=children,proto3" json:"children,omitempty"`
+}
+
+func (m *AnalysisNode) Reset()      { *m = AnalysisNode{} }
+func (*AnalysisNode) ProtoMessage() {}
+func (*AnalysisNode) Descriptor() ([]byte, []int) {
+	return fileDescriptor_6cdfe5d2aea33ebb, []int{2}
+}
+func (m *AnalysisNode) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *AnalysisNode) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_AnalysisNode.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *AnalysisNode) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_AnalysisNode.Merge(m, src)
+}
+func (m *AnalysisNode) XXX_Size() int {
+	return m.Size()
+}
+func (m *AnalysisNode) XXX_DiscardUnknown() {
+	xxx_messageInfo_AnalysisNode.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_AnalysisNode proto.InternalMessageInfo
+
+func (m *AnalysisNode) GetType() string {
+	if m != nil {
+		return m.Type
+	}
+	return ""
+}
+
+func (m *AnalysisNode) GetProperties() []AnalysisProperty {
+	if m != nil {
+		return m.Properties
+	}
+	return nil
+}
+
+func (m *AnalysisNode) GetRowsIn() int64 {
+	if m != nil {
+		return m.RowsIn
+	}
+	return 0
+}
+
+func (m *AnalysisNode) GetRowsOut() int64 {
+	if m != nil {
+		return m.RowsOut
+	}
+	return 0
+}
+
+func (m *AnalysisNode) GetBatches() int64 {
+	if m != nil {
+		return m.Batches
+	}
+	return 0
+}
+
+func (m *AnalysisNode) GetBytesRead() int64 {
+	if m != nil {
+		return m.BytesRead
+	}
+	return 0
+}
+
+func (m *AnalysisNode) GetPagesPruned() int64 {
+	if m != nil {
+		return m.PagesPruned
+	}
+	return 0
+}
+
+func (m *AnalysisNode) GetWallTime() float64 {
+	if m != nil {
+		return m.WallTime
+	}
+	return 0
+}
+
+func (m *AnalysisNode) GetChildren() []*AnalysisNode {
+	if m != nil {
+		return m.Children
+	}
+	return nil
+}
+
+type AnalysisProperty struct {
+	Key   string `protobuf:"bytes,1,opt,name=key,proto3" json:"key"`
+	Value string `protobuf:"bytes,2,opt,name=value,proto3" json:"value"`
+}
+
+func (m *AnalysisProperty) Reset()      { *m = AnalysisProperty{} }
+func (*AnalysisProperty) ProtoMessage() {}
+func (*AnalysisProperty) Descriptor() ([]byte, []int) {
+	return fileDescriptor_6cdfe5d2aea33ebb, []int{3}
+}
+func (m *AnalysisProperty) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *AnalysisProperty) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_AnalysisProperty.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *AnalysisProperty) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_AnalysisProperty.Merge(m, src)
+}
+func (m *AnalysisProperty) XXX_Size() int {
+	return m.Size()
+}
+func (m *AnalysisProperty) XXX_DiscardUnknown() {
+	xxx_messageInfo_AnalysisProperty.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_AnalysisProperty proto.InternalMessageInfo
+
+func (m *AnalysisProperty) GetKey() string {
+	if m != nil {
+		return m.Key
+	}
+	return ""
+}
+
+func (m *AnalysisProperty) GetValue() string {
+	if m != nil {
+		return m.Value
+	}
+	return ""
+}
+
 type Caches struct {
 	Chunk               Cache `protobuf:"bytes,1,opt,name=chunk,proto3" json:"chunk"`
 	Index               Cache `protobuf:"bytes,2,opt,name=index,proto3" json:"index"`
@@ -116,7 +367,7 @@ type Caches struct {
 func (m *Caches) Reset()      { *m = Caches{} }
 func (*Caches) ProtoMessage() {}
 func (*Caches) Descriptor() ([]byte, []int) {
-	return fileDescriptor_6cdfe5d2aea33ebb, []int{1}
+	return fileDescriptor_6cdfe5d2aea33ebb, []int{4}
 }
 func (m *Caches) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -237,7 +488,7 @@ type Summary struct {
 func (m *Summary) Reset()      { *m = Summary{} }
 func (*Summary) ProtoMessage() {}
 func (*Summary) Descriptor() ([]byte, []int) {
-	return fileDescriptor_6cdfe5d2aea33ebb, []int{2}
+	return fileDescriptor_6cdfe5d2aea33ebb, []int{5}
 }
 func (m *Summary) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -367,7 +618,7 @@ type Index struct {
 func (m *Index) Reset()      { *m = Index{} }
 func (*Index) ProtoMessage() {}
 func (*Index) Descriptor() ([]byte, []int) {
-	return fileDescriptor_6cdfe5d2aea33ebb, []int{3}
+	return fileDescriptor_6cdfe5d2aea33ebb, []int{6}
 }
 func (m *Index) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -431,7 +682,7 @@ type Querier struct {
 func (m *Querier) Reset()      { *m = Querier{} }
 func (*Querier) ProtoMessage() {}
 func (*Querier) Descriptor() ([]byte, []int) {
-	return fileDescriptor_6cdfe5d2aea33ebb, []int{4}
+	return fileDescriptor_6cdfe5d2aea33ebb, []int{7}
 }
 func (m *Querier) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -482,7 +733,7 @@ type Ingester struct {
 func (m *Ingester) Reset()      { *m = Ingester{} }
 func (*Ingester) ProtoMessage() {}
 func (*Ingester) Descriptor() ([]byte, []int) {
-	return fileDescriptor_6cdfe5d2aea33ebb, []int{5}
+	return fileDescriptor_6cdfe5d2aea33ebb, []int{8}
 }
 func (m *Ingester) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -568,7 +819,7 @@ type Store struct {
 func (m *Store) Reset()      { *m = Store{} }
 func (*Store) ProtoMessage() {}
 func (*Store) Descriptor() ([]byte, []int) {
-	return fileDescriptor_6cdfe5d2aea33ebb, []int{6}
+	return fileDescriptor_6cdfe5d2aea33ebb, []int{9}
 }
 func (m *Store) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -690,7 +941,7 @@ type Dataobj struct {
 func (m *Dataobj) Reset()      { *m = Dataobj{} }
 func (*Dataobj) ProtoMessage() {}
 func (*Dataobj) Descriptor() ([]byte, []int) {
-	return fileDescriptor_6cdfe5d2aea33ebb, []int{7}
+	return fileDescriptor_6cdfe5d2aea33ebb, []int{10}
 }
 func (m *Dataobj) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -827,7 +1078,7 @@ type Chunk struct {
 func (m *Chunk) Reset()      { *m = Chunk{} }
 func (*Chunk) ProtoMessage() {}
 func (*Chunk) Descriptor() ([]byte, []int) {
-	return fileDescriptor_6cdfe5d2aea33ebb, []int{8}
+	return fileDescriptor_6cdfe5d2aea33ebb, []int{11}
 }
 func (m *Chunk) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -933,7 +1184,7 @@ type Cache struct {
 func (m *Cache) Reset()      { *m = Cache{} }
 func (*Cache) ProtoMessage() {}
 func (*Cache) Descriptor() ([]byte, []int) {
-	return fileDescriptor_6cdfe5d2aea33ebb, []int{9}
+	return fileDescriptor_6cdfe5d2aea33ebb, []int{12}
 }
 func (m *Cache) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -1020,6 +1271,9 @@ func (m *Cache) GetQueryLengthServed() int64 {
 
 func init() {
 	proto.RegisterType((*Result)(nil), "stats.Result")
+	proto.RegisterType((*Analysis)(nil), "stats.Analysis")
+	proto.RegisterType((*AnalysisNode)(nil), "stats.AnalysisNode")
+	proto.RegisterType((*AnalysisProperty)(nil), "stats.AnalysisProperty")
 	proto.RegisterType((*Caches)(nil), "stats.Caches")
 	proto.RegisterType((*Summary)(nil), "stats.Summary")
 	proto.RegisterType((*Index)(nil), "stats.Index")
@@ -1034,109 +1288,128 @@ func init() {
 func init() { proto.RegisterFile("pkg/logqlmodel/stats/stats.proto", fileDescriptor_6cdfe5d2aea33ebb) }
 
 var fileDescriptor_6cdfe5d2aea33ebb = []byte{
-	// 1632 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x94, 0x58, 0xcd, 0x6f, 0xdc, 0x44,
-	0x14, 0xcf, 0x66, 0xeb, 0x4d, 0x3a, 0xf9, 0x6a, 0x27, 0x29, 0xdd, 0xd2, 0xb2, 0x4e, 0x97, 0x56,
-	0x14, 0x21, 0x65, 0x55, 0x8a, 0x84, 0x40, 0x54, 0x02, 0x27, 0x44, 0xaa, 0x94, 0x8a, 0xf0, 0x02,
-	0x02, 0xc1, 0xc9, 0xb1, 0x27, 0x1b, 0xb7, 0x5e, 0x7b, 0x63, 0x8f, 0xd3, 0x46, 0x42, 0x82, 0x3f,
-	0x81, 0x3b, 0xe2, 0xc0, 0x05, 0x71, 0xe1, 0xc4, 0x01, 0x71, 0xe6, 0xd2, 0x63, 0x8f, 0x3d, 0x59,
-	0x34, 0xbd, 0x20, 0x9f, 0x7a, 0xe6, 0x84, 0xe6, 0xcd, 0xac, 0xbf, 0x77, 0xb3, 0xbd, 0x64, 0xfd,
-	0x7e, 0xef, 0xf7, 0xde, 0x8c, 0x9f, 0xdf, 0x7b, 0xf3, 0x26, 0x64, 0x7d, 0xf8, 0xb0, 0xdf, 0x73,
-	0xfd, 0xfe, 0x91, 0x3b, 0xf0, 0x6d, 0xe6, 0xf6, 0x42, 0x6e, 0xf2, 0x50, 0xfe, 0xdd, 0x18, 0x06,
-	0x3e, 0xf7, 0xa9, 0x86, 0xc2, 0xeb, 0x6b, 0x7d, 0xbf, 0xef, 0x23, 0xd2, 0x13, 0x4f, 0x52, 0xd9,
-	0xfd, 0x75, 0x96, 0xb4, 0x80, 0x85, 0x91, 0xcb, 0xe9, 0x07, 0x64, 0x2e, 0x8c, 0x06, 0x03, 0x33,
-	0x38, 0x69, 0x37, 0xd6, 0x1b, 0xb7, 0x16, 0xde, 0x5d, 0xde, 0x90, 0x6e, 0xf6, 0x24, 0x6a, 0xac,
-	0x3c, 0x89, 0xf5, 0x99, 0x24, 0xd6, 0x47, 0x34, 0x18, 0x3d, 0x08, 0xd3, 0xa3, 0x88, 0x05, 0x0e,
-	0x0b, 0xda, 0xb3, 0x05, 0xd3, 0xcf, 0x25, 0x9a, 0x99, 0x2a, 0x1a, 0x8c, 0x1e, 0xe8, 0x5d, 0x32,
-	0xef, 0x78, 0x7d, 0x16, 0x72, 0x16, 0xb4, 0x9b, 0x68, 0xbb, 0xa2, 0x6c, 0xef, 0x29, 0xd8, 0xb8,
-	0xa0, 0x8c, 0x53, 0x22, 0xa4, 0x4f, 0xf4, 0x3d, 0xd2, 0xb2, 0x4c, 0xeb, 0x90, 0x85, 0xed, 0x73,
-	0x68, 0xbc, 0xa4, 0x8c, 0x37, 0x11, 0x34, 0x96, 0x94, 0xa9, 0x86, 0x24, 0x50, 0x5c, 0x7a, 0x9b,
-	0x68, 0x8e, 0x67, 0xb3, 0xc7, 0x6d, 0x0d, 0x8d, 0x16, 0xd3, 0x15, 0x6d, 0xf6, 0x38, 0xb3, 0x41,
-	0x0a, 0xc8, 0x9f, 0xee, 0x4f, 0xe7, 0x48, 0x6b, 0x33, 0xb5, 0xb6, 0x0e, 0x23, 0xef, 0xa1, 0x0a,
-	0xd3, 0x62, 0x7e, 0xc9, 0xdc, 0x8a, 0x82, 0x02, 0xf2, 0x27, 0x5b, 0x70, 0x76, 0x92, 0x49, 0x7e,
-	0x41, 0xf1, 0x66, 0x01, 0x7e, 0x18, 0x15, 0x96, 0xa2, 0xcd, 0xb2, 0xb2, 0x51, 0x1c, 0x50, 0xbf,
-	0x74, 0x93, 0x2c, 0x20, 0x4d, 0x7e, 0x53, 0x15, 0x94, 0xa2, 0xe9, 0xaa, 0x32, 0xcd, 0x13, 0x21,
-	0x2f, 0xd0, 0x6d, 0xb2, 0x78, 0xec, 0xbb, 0xd1, 0x80, 0x29, 0x2f, 0x5a, 0x8d, 0x97, 0x35, 0xe5,
-	0xa5, 0xc0, 0x84, 0x82, 0x24, 0xfc, 0x84, 0xe2, 0x2b, 0x8f, 0x76, 0xd3, 0x9a, 0xe4, 0x27, 0xcf,
-	0x84, 0x82, 0x24, 0x5e, 0xca, 0x35, 0xf7, 0x99, 0xab, 0xdc, 0xcc, 0x4d, 0x7a, 0xa9, 0x1c, 0x11,
-	0xf2, 0x02, 0xfd, 0x96, 0xac, 0x3a, 0x5e, 0xc8, 0x4d, 0x8f, 0xdf, 0x67, 0x3c, 0x70, 0x2c, 0xe5,
-	0x6c, 0xbe, 0xc6, 0xd9, 0x55, 0xe5, 0xac, 0xce, 0x00, 0xea, 0xc0, 0xee, 0x5f, 0x2d, 0x32, 0xa7,
-	0xca, 0x84, 0x7e, 0x49, 0x2e, 0xef, 0x9f, 0x70, 0x16, 0xee, 0x06, 0xbe, 0xc5, 0xc2, 0x90, 0xd9,
-	0xbb, 0x2c, 0xd8, 0x63, 0x96, 0xef, 0xd9, 0x98, 0x30, 0x4d, 0xe3, 0x6a, 0x12, 0xeb, 0xe3, 0x28,
-	0x30, 0x4e, 0x21, 0xdc, 0xba, 0x8e, 0x57, 0xeb, 0x76, 0x36, 0x73, 0x3b, 0x86, 0x02, 0xe3, 0x14,
-	0xf4, 0x1e, 0x59, 0xe5, 0x3e, 0x37, 0x5d, 0xa3, 0xb0, 0x2c, 0xe6, 0x5c, 0xd3, 0xb8, 0x2c, 0x82,
-	0x50, 0xa3, 0x86, 0x3a, 0x30, 0x75, 0xb5, 0x53, 0x58, 0x0a, 0x73, 0x30, 0xef, 0xaa, 0xa8, 0x86,
-	0x3a, 0x90, 0xde, 0x22, 0xf3, 0xec, 0x31, 0xb3, 0xbe, 0x70, 0x06, 0x0c, 0xb3, 0xaf, 0x61, 0x2c,
-	0x8a, 0x06, 0x30, 0xc2, 0x20, 0x7d, 0xa2, 0xef, 0x90, 0xf3, 0x47, 0x11, 0x8b, 0x18, 0x52, 0x5b,
-	0x48, 0x5d, 0x4a, 0x62, 0x3d, 0x03, 0x21, 0x7b, 0xa4, 0x1b, 0x84, 0x84, 0xd1, 0xbe, 0x6c, 0x3d,
-	0x21, 0xe6, 0x51, 0xd3, 0x58, 0x4e, 0x62, 0x3d, 0x87, 0x42, 0xee, 0x99, 0xee, 0x90, 0x35, 0xdc,
-	0xdd, 0xa7, 0x1e, 0x97, 0xe9, 0xc8, 0xa3, 0xc0, 0x63, 0x36, 0x26, 0x4d, 0xd3, 0x68, 0x27, 0xb1,
-	0x5e, 0xab, 0x87, 0x5a, 0x94, 0x76, 0x49, 0x2b, 0x1c, 0xba, 0x0e, 0x0f, 0xdb, 0xe7, 0xd1, 0x9e,
-	0x88, 0xfa, 0x95, 0x08, 0xa8, 0x5f, 0xe4, 0x1c, 0x9a, 0x81, 0x1d, 0xb6, 0x49, 0x8e, 0x83, 0x08,
-	0xa8, 0xdf, 0x74, 0x57, 0xbb, 0x7e, 0xc8, 0xb7, 0x1d, 0x97, 0xb3, 0x00, 0xa3, 0xd7, 0x5e, 0x28,
-	0xed, 0xaa, 0xa4, 0x87, 0x5a, 0x94, 0x7e, 0x4f, 0x6e, 0x22, 0xbe, 0xc7, 0x83, 0xc8, 0xe2, 0x51,
-	0xc0, 0xec, 0xfb, 0x8c, 0x9b, 0xb6, 0xc9, 0xcd, 0x52, 0x4a, 0x2c, 0xa2, 0xfb, 0xb7, 0x93, 0x58,
-	0x9f, 0xce, 0x00, 0xa6, 0xa3, 0x75, 0xff, 0x6b, 0x10, 0x0d, 0x3b, 0x2f, 0xbd, 0x4d, 0x16, 0xd0,
-	0x64, 0x53, 0xf4, 0xcc, 0x50, 0x55, 0xcb, 0x8a, 0xa8, 0xea, 0x1c, 0x0c, 0x79, 0x81, 0x7e, 0x4c,
-	0x2e, 0x0c, 0xd3, 0x17, 0x52, 0x76, 0xb2, 0x1c, 0xd6, 0x92, 0x58, 0xaf, 0xe8, 0xa0, 0x82, 0xd0,
-	0x0f, 0xc9, 0xb2, 0x8c, 0xeb, 0x56, 0x14, 0x98, 0xdc, 0xf1, 0x3d, 0x95, 0xfb, 0x34, 0x89, 0xf5,
-	0x92, 0x06, 0x4a, 0xb2, 0x58, 0x3d, 0x0a, 0x99, 0x6d, 0xb8, 0xbe, 0x3f, 0x90, 0x4e, 0xe5, 0x39,
-	0x34, 0x2f, 0x57, 0x2f, 0xeb, 0xa0, 0x82, 0x74, 0x3f, 0x22, 0x73, 0xea, 0x8c, 0x14, 0x67, 0x44,
-	0xc8, 0xfd, 0x80, 0x95, 0x8e, 0x95, 0x3d, 0x81, 0x65, 0x67, 0x04, 0x52, 0x40, 0xfe, 0x74, 0x7f,
-	0x9f, 0x25, 0xf3, 0xf7, 0xb2, 0xa3, 0x70, 0x11, 0x23, 0x03, 0x4c, 0x34, 0x31, 0xd9, 0x6c, 0x34,
-	0xe3, 0x82, 0xe8, 0xad, 0x79, 0x1c, 0x0a, 0x12, 0xdd, 0x26, 0x34, 0x17, 0xcf, 0xfb, 0x26, 0x47,
-	0x5b, 0x19, 0xc2, 0xd7, 0x92, 0x58, 0xaf, 0xd1, 0x42, 0x0d, 0x96, 0xae, 0x6e, 0xa0, 0x1c, 0xaa,
-	0x20, 0x66, 0xab, 0x2b, 0x1c, 0x0a, 0x92, 0x08, 0x7e, 0x56, 0xfe, 0x7b, 0xcc, 0xe3, 0xaa, 0x5b,
-	0x60, 0xf0, 0x8b, 0x1a, 0x28, 0xc9, 0x59, 0xbc, 0xb4, 0xa9, 0xe3, 0xf5, 0xa7, 0x46, 0x34, 0xd4,
-	0xa7, 0x0b, 0xab, 0xb4, 0x60, 0x07, 0x2a, 0xdb, 0xb2, 0x85, 0x53, 0x0d, 0x94, 0x64, 0xfa, 0x19,
-	0xb9, 0x94, 0x43, 0xb6, 0xfc, 0x47, 0x9e, 0xeb, 0x9b, 0x76, 0x1a, 0xb5, 0x2b, 0x49, 0xac, 0xd7,
-	0x13, 0xa0, 0x1e, 0x16, 0xdf, 0xc0, 0x2a, 0x60, 0xd8, 0xcc, 0x9a, 0xd9, 0x37, 0xa8, 0x6a, 0xa1,
-	0x06, 0xa3, 0x16, 0xb9, 0x22, 0x3a, 0xd7, 0x09, 0xb0, 0x03, 0x16, 0x30, 0xcf, 0x62, 0x76, 0x56,
-	0x7c, 0xed, 0x25, 0xcc, 0xcb, 0x9b, 0x49, 0xac, 0x5f, 0x1f, 0x4b, 0x1a, 0x55, 0x28, 0x8c, 0xf7,
-	0x93, 0x4d, 0x3f, 0xa5, 0xd9, 0x42, 0x60, 0x63, 0xa6, 0x9f, 0xd1, 0xfb, 0x01, 0x3b, 0x08, 0xb7,
-	0x19, 0xb7, 0x0e, 0xd3, 0xbe, 0x9e, 0x7f, 0xbf, 0x82, 0x16, 0x6a, 0x30, 0xfa, 0x35, 0x69, 0x5b,
-	0x3e, 0xa6, 0xbb, 0xe3, 0x7b, 0x9b, 0xbe, 0xc7, 0x03, 0xdf, 0xdd, 0x31, 0x39, 0xf3, 0xac, 0x13,
-	0x6c, 0xfd, 0x4d, 0xe3, 0x5a, 0x12, 0xeb, 0x63, 0x39, 0x30, 0x56, 0x43, 0x6d, 0x72, 0x6d, 0xe8,
-	0x0c, 0x99, 0x38, 0x24, 0xbf, 0x0a, 0xcc, 0xe1, 0x90, 0x05, 0xb2, 0x40, 0x99, 0x2d, 0x5b, 0xab,
-	0x3c, 0x2a, 0xd6, 0x93, 0x58, 0x9f, 0xc8, 0x83, 0x89, 0x5a, 0x31, 0x26, 0x8b, 0xe8, 0xfa, 0xfb,
-	0x0f, 0xd4, 0xd8, 0x31, 0x1a, 0x93, 0xb7, 0x24, 0x9a, 0x8d, 0xc9, 0x8a, 0x06, 0xa3, 0x87, 0xee,
-	0x2f, 0xf3, 0x64, 0x4e, 0xb1, 0x70, 0xb3, 0x01, 0xdb, 0x0d, 0x98, 0xed, 0x58, 0x26, 0x67, 0x5b,
-	0xcc, 0xf2, 0x07, 0xc3, 0x40, 0xf6, 0x5c, 0xff, 0xd1, 0xa8, 0x6f, 0xca, 0xcd, 0x4e, 0xe0, 0xc1,
-	0x44, 0x2d, 0xed, 0x93, 0x37, 0xc6, 0xe9, 0xb1, 0x81, 0xab, 0x6c, 0xbf, 0x9e, 0xc4, 0xfa, 0x64,
-	0x22, 0x4c, 0x56, 0xd3, 0x9f, 0x1b, 0xa4, 0x37, 0x8e, 0x31, 0xe6, 0xf0, 0x50, 0xb5, 0x71, 0x27,
-	0x89, 0xf5, 0x57, 0x35, 0x85, 0x57, 0x35, 0xa0, 0x9b, 0xe4, 0xa2, 0x38, 0x34, 0x52, 0x1b, 0x8c,
-	0xb1, 0x6c, 0x53, 0x97, 0x92, 0x58, 0xaf, 0x2a, 0xa1, 0x0a, 0xd1, 0x07, 0xa4, 0x53, 0x00, 0xab,
-	0xe1, 0x94, 0xe5, 0xd0, 0x4d, 0x62, 0xfd, 0x0c, 0x26, 0x9c, 0xa1, 0xa7, 0xdf, 0x91, 0x1b, 0x05,
-	0xc6, 0xb8, 0x20, 0xca, 0x92, 0xb9, 0x95, 0xc4, 0xfa, 0x54, 0x7c, 0x98, 0x8a, 0x25, 0x3a, 0x6b,
-	0x76, 0xc6, 0x62, 0xac, 0xe6, 0xb2, 0xce, 0x5a, 0xd4, 0x40, 0x49, 0x16, 0x87, 0xc8, 0xd0, 0xec,
-	0xb3, 0x70, 0xcf, 0x32, 0xbd, 0x6c, 0xce, 0xc2, 0x43, 0x24, 0x8f, 0x43, 0x41, 0xa2, 0x77, 0xc9,
-	0x0a, 0xca, 0xb9, 0x4e, 0x2c, 0x07, 0xac, 0xd5, 0x24, 0xd6, 0xcb, 0x2a, 0x28, 0x03, 0x62, 0x9c,
-	0x2a, 0x41, 0x32, 0x3c, 0x24, 0x1b, 0xa7, 0xea, 0xf4, 0x50, 0x8b, 0x8a, 0x19, 0x46, 0xe0, 0xa3,
-	0x63, 0x70, 0x21, 0x9b, 0x61, 0x72, 0x30, 0xe4, 0x85, 0xf4, 0x08, 0x16, 0x21, 0xf8, 0xe4, 0xd8,
-	0x74, 0x5c, 0x73, 0xdf, 0x65, 0x6a, 0xdc, 0xca, 0x8e, 0xe0, 0x82, 0x16, 0x6a, 0xb0, 0xee, 0x1f,
-	0x1a, 0xd1, 0xb0, 0x0d, 0x8b, 0x6f, 0x70, 0xc8, 0x4c, 0x5b, 0xf6, 0x64, 0x7c, 0x99, 0xdc, 0xb1,
-	0x5a, 0xd4, 0x40, 0x49, 0x2e, 0xd8, 0xca, 0xe6, 0xa7, 0xd5, 0xd8, 0xca, 0x76, 0x57, 0x92, 0x45,
-	0xa9, 0xd8, 0x95, 0xc4, 0x6e, 0x65, 0xa5, 0x52, 0x51, 0x42, 0x15, 0x2a, 0x3b, 0xc9, 0x37, 0xe0,
-	0x8a, 0x13, 0xb9, 0x8d, 0x2a, 0x24, 0x72, 0xa2, 0xbc, 0x8f, 0xf9, 0x2c, 0x27, 0xca, 0xbb, 0x28,
-	0x03, 0xc2, 0x1c, 0x03, 0xbc, 0x15, 0x0d, 0x5d, 0xcc, 0xf6, 0x30, 0x9f, 0x52, 0x25, 0x15, 0x94,
-	0x01, 0xcc, 0xc8, 0xd2, 0x70, 0x4e, 0x72, 0x19, 0x59, 0x9a, 0xcb, 0xcb, 0x00, 0x1d, 0x92, 0xf5,
-	0x34, 0xb0, 0xe3, 0x8a, 0x57, 0x26, 0xd6, 0x8d, 0x24, 0xd6, 0xcf, 0xe4, 0xc2, 0x99, 0x0c, 0x7a,
-	0x42, 0xde, 0xb4, 0xa7, 0x68, 0xbb, 0x32, 0x27, 0xdf, 0x4a, 0x62, 0x7d, 0x1a, 0x3a, 0x4c, 0x43,
-	0xea, 0xfe, 0xdd, 0x24, 0x1a, 0x5e, 0xbb, 0x45, 0xf5, 0x33, 0x79, 0x65, 0xda, 0xf6, 0x23, 0xaf,
-	0x30, 0xc0, 0xe6, 0x71, 0x28, 0x48, 0x62, 0x06, 0x67, 0xa3, 0x8b, 0xd6, 0x51, 0x24, 0x46, 0x61,
-	0x39, 0x88, 0x69, 0x72, 0x06, 0x2f, 0xeb, 0xa0, 0x82, 0xd0, 0xf7, 0xc9, 0x92, 0xc2, 0x70, 0x36,
-	0x94, 0x97, 0x5f, 0xcd, 0xb8, 0x98, 0xc4, 0x7a, 0x51, 0x01, 0x45, 0x51, 0x18, 0xe2, 0x6d, 0x1d,
-	0x98, 0xc5, 0x9c, 0xe3, 0xf4, 0xaa, 0x8b, 0x86, 0x05, 0x05, 0x14, 0x45, 0x71, 0x69, 0x45, 0x00,
-	0x27, 0x5e, 0x59, 0x5e, 0x78, 0x69, 0x4d, 0x41, 0xc8, 0x1e, 0xc5, 0x5d, 0x38, 0x90, 0x7b, 0x95,
-	0xb5, 0xa4, 0xc9, 0xbb, 0xf0, 0x08, 0x83, 0xf4, 0x49, 0x04, 0xd0, 0xce, 0x4f, 0x90, 0x73, 0x59,
-	0xfb, 0xcc, 0xe3, 0x50, 0x90, 0x44, 0xbd, 0xe1, 0xb4, 0xb7, 0xc3, 0xbc, 0x3e, 0x3f, 0xdc, 0x63,
-	0xc1, 0x71, 0xda, 0x79, 0xb1, 0xde, 0x2a, 0x4a, 0xa8, 0x42, 0x06, 0x7b, 0xfa, 0xbc, 0x33, 0xf3,
-	0xec, 0x79, 0x67, 0xe6, 0xe5, 0xf3, 0x4e, 0xe3, 0x87, 0xd3, 0x4e, 0xe3, 0xb7, 0xd3, 0x4e, 0xe3,
-	0xc9, 0x69, 0xa7, 0xf1, 0xf4, 0xb4, 0xd3, 0xf8, 0xe7, 0xb4, 0xd3, 0xf8, 0xf7, 0xb4, 0x33, 0xf3,
-	0xf2, 0xb4, 0xd3, 0xf8, 0xf1, 0x45, 0x67, 0xe6, 0xe9, 0x8b, 0xce, 0xcc, 0xb3, 0x17, 0x9d, 0x99,
-	0x6f, 0x7a, 0x7d, 0x87, 0x1f, 0x46, 0xfb, 0x1b, 0x96, 0x3f, 0xe8, 0xf5, 0x03, 0xf3, 0xc0, 0xf4,
-	0xcc, 0x9e, 0xeb, 0x3f, 0x74, 0x7a, 0xc7, 0x77, 0x7a, 0x75, 0xff, 0xd7, 0xdc, 0x6f, 0xe1, 0x7f,
-	0x2d, 0xef, 0xfc, 0x1f, 0x00, 0x00, 0xff, 0xff, 0x3b, 0x4c, 0x10, 0xe6, 0xf6, 0x14, 0x00, 0x00,
+	// 1932 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x94, 0x58, 0xcf, 0x6f, 0x1c, 0x49,
+	0x15, 0xf6, 0x78, 0xdc, 0x33, 0x93, 0xb2, 0x9d, 0x64, 0xcb, 0x5e, 0xd2, 0x61, 0xc3, 0xb4, 0x77,
+	0xd8, 0x88, 0x20, 0x50, 0xac, 0x90, 0x95, 0x10, 0x88, 0x95, 0xd8, 0xb1, 0x31, 0x8a, 0x94, 0xec,
+	0x9a, 0x67, 0x56, 0x20, 0x38, 0xb5, 0xbb, 0x5f, 0xc6, 0xbd, 0xee, 0xe9, 0x9e, 0x74, 0x57, 0x3b,
+	0x19, 0x09, 0x09, 0xfe, 0x04, 0xce, 0x20, 0x0e, 0xdc, 0xb8, 0x70, 0xe2, 0x80, 0x38, 0x73, 0xc9,
+	0x31, 0xc7, 0x3d, 0xb5, 0x88, 0x73, 0x41, 0xcd, 0x65, 0xcf, 0x9c, 0x56, 0xf5, 0xaa, 0xfa, 0xe7,
+	0xf4, 0x4c, 0xbc, 0x97, 0xe9, 0x7a, 0xdf, 0xfb, 0x5e, 0x55, 0xf5, 0xeb, 0xf7, 0x5e, 0xbd, 0x1a,
+	0xb6, 0x37, 0x3b, 0x9f, 0xec, 0xfb, 0xe1, 0xe4, 0x99, 0x3f, 0x0d, 0x5d, 0xf4, 0xf7, 0x63, 0x61,
+	0x8b, 0x58, 0xfd, 0xde, 0x9f, 0x45, 0xa1, 0x08, 0xb9, 0x41, 0xc2, 0x37, 0x77, 0x27, 0xe1, 0x24,
+	0x24, 0x64, 0x5f, 0x8e, 0x94, 0x72, 0xf4, 0xbf, 0x75, 0xd6, 0x03, 0x8c, 0x13, 0x5f, 0xf0, 0x1f,
+	0xb1, 0x7e, 0x9c, 0x4c, 0xa7, 0x76, 0x34, 0x37, 0x3b, 0x7b, 0x9d, 0x7b, 0x9b, 0x3f, 0xb8, 0x7e,
+	0x5f, 0x4d, 0x73, 0xa2, 0xd0, 0xf1, 0x8d, 0x97, 0xa9, 0xb5, 0x96, 0xa5, 0x56, 0x4e, 0x83, 0x7c,
+	0x20, 0x4d, 0x9f, 0x25, 0x18, 0x79, 0x18, 0x99, 0xeb, 0x35, 0xd3, 0x5f, 0x28, 0xb4, 0x34, 0xd5,
+	0x34, 0xc8, 0x07, 0xfc, 0x23, 0x36, 0xf0, 0x82, 0x09, 0xc6, 0x02, 0x23, 0xb3, 0x4b, 0xb6, 0x37,
+	0xb4, 0xed, 0x23, 0x0d, 0x8f, 0x6f, 0x6a, 0xe3, 0x82, 0x08, 0xc5, 0x88, 0x7f, 0xc8, 0x7a, 0x8e,
+	0xed, 0x9c, 0x61, 0x6c, 0x6e, 0x90, 0xf1, 0xb6, 0x36, 0x3e, 0x20, 0x70, 0xbc, 0xad, 0x4d, 0x0d,
+	0x22, 0x81, 0xe6, 0xf2, 0x07, 0xcc, 0xf0, 0x02, 0x17, 0x5f, 0x98, 0x06, 0x19, 0x6d, 0x15, 0x2b,
+	0xba, 0xf8, 0xa2, 0xb4, 0x21, 0x0a, 0xa8, 0x07, 0x3f, 0x60, 0x03, 0x3b, 0xb0, 0xfd, 0x79, 0xec,
+	0xc5, 0x66, 0x6f, 0xaf, 0x5b, 0xd9, 0xe7, 0xc7, 0x1a, 0x1e, 0x7f, 0x23, 0x4b, 0x2d, 0x9e, 0x93,
+	0xbe, 0x1f, 0x4e, 0x3d, 0x81, 0xd3, 0x99, 0x98, 0x43, 0x61, 0x38, 0xfa, 0x53, 0x87, 0x0d, 0x72,
+	0x3a, 0xb7, 0x98, 0x21, 0x9d, 0xa0, 0xbc, 0x7d, 0x6d, 0x7c, 0x4d, 0x2e, 0x49, 0x00, 0xa8, 0x87,
+	0x24, 0xc4, 0xc2, 0x8e, 0x04, 0xf9, 0xb4, 0xab, 0x08, 0x04, 0x80, 0x7a, 0xf0, 0xdb, 0xac, 0x8b,
+	0x81, 0x4b, 0x6e, 0xeb, 0x8e, 0xfb, 0x59, 0x6a, 0x49, 0x11, 0xe4, 0x0f, 0x7f, 0xc0, 0x36, 0x66,
+	0xbe, 0x1d, 0x98, 0x1b, 0xb4, 0xd5, 0x9d, 0xc6, 0x56, 0x3f, 0x09, 0x5d, 0x1c, 0x0f, 0xb2, 0xd4,
+	0x22, 0x12, 0xd0, 0xef, 0xe8, 0x65, 0x97, 0x6d, 0x55, 0x09, 0xfc, 0x0e, 0xdb, 0x10, 0xf3, 0x19,
+	0xea, 0xfd, 0x11, 0x5d, 0xca, 0x40, 0xbf, 0xfc, 0x33, 0xc6, 0x66, 0x51, 0x38, 0xc3, 0x48, 0x78,
+	0x18, 0x9b, 0xeb, 0xb4, 0xce, 0xad, 0xc6, 0x3a, 0xc7, 0x8a, 0x30, 0x1f, 0xdf, 0xd1, 0x3e, 0xdd,
+	0x2d, 0x4d, 0x2a, 0x0e, 0xaa, 0x4c, 0xc4, 0x47, 0xac, 0x17, 0x85, 0xcf, 0xe3, 0x47, 0x81, 0x7e,
+	0x2d, 0x96, 0xa5, 0x96, 0x46, 0x40, 0x3f, 0xf9, 0x5d, 0xd6, 0x97, 0xa3, 0x4f, 0x13, 0x41, 0x5f,
+	0xbd, 0x3b, 0xde, 0x94, 0xa1, 0xa5, 0x21, 0xc8, 0x07, 0x92, 0x76, 0x6a, 0x0b, 0x0a, 0x0e, 0xa3,
+	0xa4, 0x69, 0x08, 0xf2, 0x01, 0xff, 0x1e, 0xbb, 0x76, 0x3a, 0x17, 0x18, 0x03, 0xda, 0xae, 0xd9,
+	0x23, 0xe2, 0x76, 0x96, 0x5a, 0x25, 0x08, 0xe5, 0x90, 0x3f, 0x60, 0x9b, 0x33, 0x7b, 0x82, 0xf1,
+	0x71, 0x94, 0x04, 0xe8, 0x9a, 0x7d, 0xa2, 0xdf, 0xc8, 0x52, 0xab, 0x0a, 0x43, 0x55, 0xe0, 0xf7,
+	0xd8, 0xe0, 0xb9, 0xed, 0xfb, 0xbf, 0xf4, 0xa6, 0x68, 0x0e, 0xf6, 0x3a, 0xf7, 0x3a, 0xe3, 0x2d,
+	0x19, 0xcc, 0x39, 0x06, 0xc5, 0x88, 0xff, 0x9c, 0x0d, 0x9c, 0x33, 0xcf, 0x77, 0x23, 0x0c, 0xcc,
+	0x6b, 0xcb, 0x3f, 0x1c, 0xc5, 0x59, 0x4e, 0xac, 0xc6, 0x59, 0x8e, 0x8d, 0x3e, 0x61, 0x37, 0x9b,
+	0x9f, 0x40, 0x06, 0xcb, 0x39, 0xe6, 0xc1, 0x46, 0xc1, 0x72, 0x8e, 0x73, 0x90, 0x3f, 0x32, 0xd0,
+	0x2e, 0x6c, 0x3f, 0x41, 0x73, 0xbd, 0x8c, 0x44, 0x02, 0x40, 0x3d, 0x46, 0x7f, 0xde, 0x60, 0xbd,
+	0x83, 0x22, 0x75, 0x9c, 0xb3, 0x24, 0x38, 0x37, 0x3b, 0xb5, 0xd4, 0x21, 0x6d, 0x25, 0xdd, 0x24,
+	0x05, 0xd4, 0xa3, 0xcc, 0xb6, 0xf5, 0x55, 0x26, 0xb5, 0x6c, 0xfb, 0x90, 0xf5, 0x22, 0xaa, 0x4a,
+	0x66, 0xb7, 0xc5, 0xe6, 0xba, 0xb6, 0xd1, 0x1c, 0xd0, 0x4f, 0x7e, 0xc0, 0x36, 0x89, 0xa6, 0x0a,
+	0x9a, 0xb9, 0xd1, 0x62, 0xba, 0xa3, 0x4d, 0xab, 0x44, 0xa8, 0x0a, 0xfc, 0x88, 0x6d, 0x5d, 0x84,
+	0x7e, 0x32, 0x45, 0x3d, 0x8b, 0xd1, 0x32, 0xcb, 0xae, 0x9e, 0xa5, 0xc6, 0x84, 0x9a, 0x24, 0xe7,
+	0x89, 0x31, 0xf2, 0x30, 0xdf, 0x4d, 0x6f, 0xd5, 0x3c, 0x55, 0x26, 0xd4, 0x24, 0xf9, 0x52, 0xbe,
+	0x7d, 0x8a, 0xbe, 0x9e, 0xa6, 0xbf, 0xea, 0xa5, 0x2a, 0x44, 0xa8, 0x0a, 0xfc, 0xb7, 0x6c, 0xc7,
+	0x0b, 0x62, 0x61, 0x07, 0xe2, 0x09, 0x8a, 0xc8, 0x73, 0xf4, 0x64, 0x83, 0x96, 0xc9, 0xde, 0xd3,
+	0x93, 0xb5, 0x19, 0x40, 0x1b, 0x38, 0xfa, 0x57, 0x8f, 0xf5, 0xf5, 0x19, 0xc1, 0x3f, 0x63, 0xb7,
+	0x28, 0x59, 0x8e, 0xa3, 0xd0, 0xc1, 0x38, 0x46, 0xf7, 0x18, 0xa3, 0x13, 0x74, 0xc2, 0xc0, 0xa5,
+	0x80, 0xe9, 0x8e, 0xdf, 0xcb, 0x52, 0x6b, 0x19, 0x05, 0x96, 0x29, 0xe4, 0xb4, 0xbe, 0x17, 0xb4,
+	0x4e, 0xbb, 0x5e, 0x4e, 0xbb, 0x84, 0x02, 0xcb, 0x14, 0xfc, 0x11, 0xdb, 0x11, 0xa1, 0xb0, 0xfd,
+	0x71, 0x6d, 0x59, 0x5d, 0x79, 0x6e, 0x49, 0x27, 0xb4, 0xa8, 0xa1, 0x0d, 0x2c, 0xa6, 0x7a, 0x5c,
+	0x5b, 0xca, 0xdc, 0x68, 0x4c, 0x55, 0x57, 0x43, 0x1b, 0x28, 0x0b, 0x06, 0xbe, 0x40, 0x87, 0x0a,
+	0x86, 0x51, 0x16, 0x8c, 0x1c, 0x83, 0x62, 0x24, 0x4b, 0xd7, 0xb3, 0x04, 0x13, 0x24, 0x6a, 0x8f,
+	0xa8, 0x54, 0xba, 0x0a, 0x10, 0xca, 0x21, 0xbf, 0xcf, 0x58, 0x9c, 0x9c, 0xaa, 0x73, 0x37, 0xd6,
+	0x95, 0xeb, 0x7a, 0x96, 0x5a, 0x15, 0x14, 0x2a, 0x63, 0xfe, 0x98, 0xed, 0xd2, 0xee, 0x7e, 0x16,
+	0x08, 0xd2, 0xa1, 0x48, 0x22, 0x59, 0xf3, 0x06, 0x64, 0x69, 0xca, 0x6a, 0xde, 0xa6, 0x87, 0x56,
+	0x54, 0xd6, 0xf5, 0x78, 0xe6, 0x7b, 0x22, 0x36, 0xaf, 0x95, 0x75, 0x5d, 0x21, 0xa0, 0x9f, 0xc4,
+	0x39, 0xb3, 0x23, 0x37, 0x36, 0x59, 0x85, 0x43, 0x08, 0xe8, 0x67, 0xb1, 0xab, 0xe3, 0x30, 0x16,
+	0x47, 0x9e, 0x2f, 0x30, 0x22, 0xef, 0x99, 0x9b, 0x8d, 0x5d, 0x35, 0xf4, 0xd0, 0x8a, 0xf2, 0xdf,
+	0xb3, 0xbb, 0x84, 0x9f, 0x88, 0x28, 0x71, 0x44, 0x12, 0xa1, 0xfb, 0x04, 0x85, 0xed, 0xda, 0xc2,
+	0x6e, 0x84, 0xc4, 0x16, 0x4d, 0xff, 0xdd, 0x2c, 0xb5, 0xae, 0x66, 0x00, 0x57, 0xa3, 0x8d, 0xfe,
+	0xdf, 0x61, 0x06, 0xb5, 0x1d, 0xf2, 0x64, 0x21, 0x93, 0x03, 0x59, 0x33, 0x63, 0xb3, 0x53, 0x9e,
+	0x2c, 0x15, 0x18, 0xaa, 0x02, 0xff, 0x29, 0xbb, 0x39, 0x2b, 0x5e, 0x48, 0xdb, 0xa9, 0x74, 0xd8,
+	0xcd, 0x52, 0x6b, 0x41, 0x07, 0x0b, 0x08, 0xff, 0x31, 0xbb, 0xae, 0xfc, 0x7a, 0x98, 0x44, 0xb6,
+	0xf0, 0xc2, 0xfc, 0xd4, 0xe5, 0x59, 0x6a, 0x35, 0x34, 0xd0, 0x90, 0xe5, 0xea, 0x49, 0x8c, 0xee,
+	0xd8, 0x0f, 0xc3, 0xa9, 0x9a, 0x54, 0x35, 0x61, 0x03, 0xb5, 0x7a, 0x53, 0x07, 0x0b, 0xc8, 0xe8,
+	0x27, 0xac, 0xaf, 0x1b, 0x44, 0x79, 0x46, 0xc4, 0x22, 0x8c, 0xb0, 0x71, 0xac, 0x9c, 0x48, 0xac,
+	0x3c, 0x23, 0x88, 0x02, 0xea, 0x31, 0xfa, 0xfb, 0x3a, 0x1b, 0x3c, 0x2a, 0xfb, 0xc0, 0x2d, 0xf2,
+	0x0c, 0xa0, 0x2c, 0x62, 0xaa, 0xd8, 0x18, 0xe3, 0x9b, 0xb2, 0xb6, 0x56, 0x71, 0xa8, 0x49, 0xfc,
+	0x88, 0xf1, 0x8a, 0x3f, 0x9f, 0x50, 0x43, 0x90, 0x57, 0x14, 0x3a, 0x65, 0x17, 0xb5, 0xd0, 0x82,
+	0x15, 0xab, 0x8f, 0x75, 0xbb, 0xa1, 0x9c, 0x58, 0xae, 0xae, 0x71, 0xa8, 0x49, 0xd2, 0xf9, 0x65,
+	0xfa, 0x9f, 0x60, 0x90, 0x77, 0x33, 0xe4, 0xfc, 0xba, 0x06, 0x1a, 0x72, 0xe9, 0x2f, 0xe3, 0xca,
+	0xfe, 0xfa, 0xa7, 0xc1, 0x0c, 0xd2, 0x17, 0x0b, 0xeb, 0xb0, 0xc0, 0xa7, 0x66, 0xa7, 0xb1, 0x70,
+	0xa1, 0x81, 0x86, 0xcc, 0x3f, 0x65, 0xef, 0x56, 0x90, 0xc3, 0xf0, 0x79, 0xe0, 0x87, 0xb6, 0x5b,
+	0x78, 0xed, 0x76, 0x96, 0x5a, 0xed, 0x04, 0x68, 0x87, 0xe5, 0x37, 0x70, 0x6a, 0x18, 0x15, 0xb3,
+	0x6e, 0xf9, 0x0d, 0x16, 0xb5, 0xd0, 0x82, 0x71, 0x87, 0xdd, 0x56, 0xdd, 0x33, 0x3e, 0xc5, 0x08,
+	0x03, 0x07, 0xdd, 0x32, 0xf9, 0xcc, 0x6d, 0x8a, 0xcb, 0xbb, 0x59, 0x6a, 0xbd, 0xbf, 0x94, 0x94,
+	0x67, 0x28, 0x2c, 0x9f, 0xa7, 0xec, 0x7e, 0x1a, 0xbd, 0x85, 0xc4, 0x96, 0x74, 0x3f, 0xf9, 0xfb,
+	0x01, 0x3e, 0x8d, 0x8f, 0x50, 0x38, 0x67, 0x45, 0x5d, 0xaf, 0xbe, 0x5f, 0x4d, 0x0b, 0x2d, 0x18,
+	0xff, 0x35, 0x33, 0x9d, 0x90, 0xc2, 0xdd, 0x0b, 0x83, 0x83, 0x30, 0x10, 0x51, 0xe8, 0x3f, 0xb6,
+	0x05, 0x06, 0xce, 0x5c, 0x77, 0xad, 0x77, 0xb2, 0xd4, 0x5a, 0xca, 0x81, 0xa5, 0x1a, 0xee, 0xb2,
+	0x3b, 0x33, 0x6f, 0x86, 0xf2, 0x90, 0xfc, 0x55, 0x64, 0xcf, 0x66, 0x18, 0xa9, 0x04, 0x45, 0x57,
+	0x95, 0x56, 0x75, 0x54, 0xec, 0x65, 0xa9, 0xb5, 0x92, 0x07, 0x2b, 0xb5, 0xf2, 0x8e, 0x28, 0xbd,
+	0x1b, 0x9e, 0x7e, 0x6e, 0x0e, 0x6a, 0x77, 0xc4, 0x43, 0x85, 0x96, 0x77, 0x44, 0x4d, 0x83, 0x7c,
+	0x30, 0xfa, 0xeb, 0x80, 0xf5, 0x35, 0x8b, 0x36, 0x1b, 0xe1, 0x71, 0x84, 0xae, 0xe7, 0xd8, 0x02,
+	0x0f, 0xd1, 0x09, 0xa7, 0xb3, 0x48, 0xd5, 0xdc, 0xf0, 0x79, 0x5e, 0x37, 0xd5, 0x66, 0x57, 0xf0,
+	0x60, 0xa5, 0x96, 0x4f, 0xd8, 0xb7, 0x96, 0xe9, 0xa9, 0x80, 0xeb, 0x68, 0x7f, 0x3f, 0x4b, 0xad,
+	0xd5, 0x44, 0x58, 0xad, 0xe6, 0x7f, 0xe9, 0xb0, 0xfd, 0x65, 0x8c, 0x25, 0x87, 0x87, 0xce, 0x8d,
+	0x87, 0x59, 0x6a, 0x7d, 0x5d, 0x53, 0xf8, 0xba, 0x06, 0xfc, 0x80, 0xbd, 0x23, 0x0f, 0x8d, 0xc2,
+	0x86, 0x7c, 0xac, 0xca, 0xd4, 0xbb, 0x59, 0x6a, 0x2d, 0x2a, 0x61, 0x11, 0xe2, 0x9f, 0xb3, 0x61,
+	0x0d, 0x5c, 0x74, 0xa7, 0x4a, 0x87, 0x51, 0x96, 0x5a, 0x6f, 0x61, 0xc2, 0x5b, 0xf4, 0xfc, 0x77,
+	0xec, 0x83, 0x1a, 0x63, 0x99, 0x13, 0x55, 0xca, 0xdc, 0xcb, 0x52, 0xeb, 0x4a, 0x7c, 0xb8, 0x12,
+	0x4b, 0x56, 0xd6, 0xf2, 0x8c, 0x25, 0x5f, 0xf5, 0xcb, 0xca, 0x5a, 0xd7, 0x40, 0x43, 0x96, 0x87,
+	0x08, 0x5d, 0x1b, 0x4f, 0x1c, 0x3b, 0x28, 0xfb, 0x2c, 0x3a, 0x44, 0xaa, 0x38, 0xd4, 0x24, 0xfe,
+	0x11, 0xbb, 0x41, 0x72, 0xa5, 0x12, 0xab, 0x06, 0x6b, 0x27, 0x4b, 0xad, 0xa6, 0x0a, 0x9a, 0x80,
+	0x6c, 0xa7, 0x1a, 0x90, 0x72, 0x0f, 0x2b, 0xdb, 0xa9, 0x36, 0x3d, 0xb4, 0xa2, 0xf9, 0xed, 0x38,
+	0x3f, 0x06, 0x37, 0xeb, 0xb7, 0x63, 0x0d, 0x43, 0x55, 0x28, 0x8e, 0x60, 0xe9, 0x82, 0x8f, 0x2f,
+	0x6c, 0xcf, 0xb7, 0x4f, 0x7d, 0x34, 0xb7, 0xca, 0xf2, 0xb8, 0xa8, 0x85, 0x16, 0x6c, 0xf4, 0x0f,
+	0x83, 0x19, 0x54, 0x86, 0xe5, 0x37, 0x38, 0x43, 0xdb, 0x25, 0x41, 0xbd, 0x4c, 0xe5, 0x58, 0xad,
+	0x6b, 0xa0, 0x21, 0xd7, 0x6c, 0x55, 0xf1, 0x33, 0x5a, 0x6c, 0x49, 0x03, 0x0d, 0x59, 0xa6, 0x8a,
+	0xbb, 0x10, 0xd8, 0xbd, 0x32, 0x55, 0x16, 0x94, 0xb0, 0x08, 0x35, 0x27, 0xa9, 0x16, 0xe0, 0x85,
+	0x49, 0xd4, 0x36, 0x16, 0x21, 0x19, 0x13, 0xcd, 0x7d, 0x0c, 0xca, 0x98, 0x68, 0xee, 0xa2, 0x09,
+	0x48, 0x73, 0x72, 0xf0, 0x61, 0x32, 0xf3, 0x29, 0xda, 0xe3, 0x6a, 0x48, 0x35, 0x54, 0xd0, 0x04,
+	0x28, 0x22, 0x1b, 0xcd, 0x39, 0xab, 0x44, 0x64, 0x5d, 0x05, 0x4d, 0x80, 0xcf, 0xd8, 0x5e, 0xe1,
+	0xd8, 0x65, 0xc9, 0xab, 0x02, 0xeb, 0x83, 0x2c, 0xb5, 0xde, 0xca, 0x85, 0xb7, 0x32, 0xf8, 0x9c,
+	0x7d, 0xdb, 0xbd, 0x42, 0xd9, 0x55, 0x31, 0xf9, 0x9d, 0x2c, 0xb5, 0xae, 0x42, 0x87, 0xab, 0x90,
+	0x46, 0xff, 0xee, 0x32, 0x83, 0xae, 0xdd, 0x32, 0xfb, 0x51, 0x5d, 0x99, 0x8e, 0xc2, 0x24, 0xa8,
+	0x35, 0xb0, 0x55, 0x1c, 0x6a, 0x92, 0xec, 0xc1, 0x31, 0xbf, 0x68, 0x3d, 0x4b, 0x30, 0x16, 0xba,
+	0x11, 0x33, 0x54, 0x0f, 0xde, 0xd4, 0xc1, 0x02, 0xc2, 0x7f, 0xc8, 0xb6, 0x35, 0x46, 0xbd, 0xa1,
+	0xba, 0xfc, 0x1a, 0xe3, 0x77, 0xb2, 0xd4, 0xaa, 0x2b, 0xa0, 0x2e, 0x4a, 0x43, 0xfd, 0xb7, 0x98,
+	0x83, 0xde, 0x45, 0x71, 0xd5, 0x25, 0xc3, 0x9a, 0x02, 0xea, 0x62, 0xf1, 0x7f, 0x1b, 0x75, 0xbc,
+	0x46, 0xe3, 0xff, 0x36, 0x09, 0x42, 0x39, 0x94, 0x77, 0xe1, 0x48, 0xed, 0x55, 0xe5, 0x92, 0xa1,
+	0xee, 0xc2, 0x39, 0x06, 0xc5, 0x48, 0x3a, 0xd0, 0xad, 0x76, 0x90, 0xfd, 0xb2, 0x7c, 0x56, 0x71,
+	0xa8, 0x49, 0x32, 0xdf, 0xa8, 0xdb, 0x7b, 0x8c, 0xc1, 0x44, 0x9c, 0x9d, 0x60, 0x74, 0x51, 0x54,
+	0x5e, 0xca, 0xb7, 0x05, 0x25, 0x2c, 0x42, 0x63, 0x7c, 0xf5, 0x7a, 0xb8, 0xf6, 0xc5, 0xeb, 0xe1,
+	0xda, 0x97, 0xaf, 0x87, 0x9d, 0x3f, 0x5c, 0x0e, 0x3b, 0x7f, 0xbb, 0x1c, 0x76, 0x5e, 0x5e, 0x0e,
+	0x3b, 0xaf, 0x2e, 0x87, 0x9d, 0xff, 0x5c, 0x0e, 0x3b, 0xff, 0xbd, 0x1c, 0xae, 0x7d, 0x79, 0x39,
+	0xec, 0xfc, 0xf1, 0xcd, 0x70, 0xed, 0xd5, 0x9b, 0xe1, 0xda, 0x17, 0x6f, 0x86, 0x6b, 0xbf, 0xd9,
+	0x9f, 0x78, 0xe2, 0x2c, 0x39, 0xbd, 0xef, 0x84, 0xd3, 0xfd, 0x49, 0x64, 0x3f, 0xb5, 0x03, 0x7b,
+	0xdf, 0x0f, 0xcf, 0xbd, 0xfd, 0x8b, 0x87, 0xfb, 0x6d, 0x7f, 0xea, 0x9f, 0xf6, 0xe8, 0x2f, 0xfb,
+	0x87, 0x5f, 0x0d, 0x00, 0x86, 0x6a, 0x1c, 0xd6, 0xf3, 0x17, 0x00, 0x00,
 }
 
 func (this *Result) Equal(that interface{}) bool {
@@ -1173,6 +1446,137 @@ func (this *Result) Equal(that interface{}) bool {
 	if !this.Index.Equal(&that1.Index) {
 		return false
 	}
+	if len(this.Analysis) != len(that1.Analysis) {
+		return false
+	}
+	for i := range this.Analysis {
+		if !this.Analysis[i].Equal(that1.Analysis[i]) {
+			return false
+		}
+	}
+	return true
+}
+func (this *Analysis) Equal(that interface{}) bool {
+	if that == nil {
+		return this == nil
+	}
+
+	that1, ok := that.(*Analysis)
+	if !ok {
+		that2, ok := that.(Analysis)
+		if ok {
+			that1 = &that2
+		} else {
+			return false
+		}
+	}
+	if that1 == nil {
+		return this == nil
+	} else if this == nil {
+		return false
+	}
+	if this.Query != that1.Query {
+		return false
+	}
+	if this.Start != that1.Start {
+		return false
+	}
+	if this.End != that1.End {
+		return false
+	}
+	if len(this.Plan) != len(that1.Plan) {
+		return false
+	}
+	for i := range this.Plan {
+		if !this.Plan[i].Equal(that1.Plan[i]) {
+			return false
+		}
+	}
+	return true
+}
+func (this *AnalysisNode) Equal(that interface{}) bool {
+	if that == nil {
+		return this == nil
+	}
+
+	that1, ok := that.(*AnalysisNode)
+	if !ok {
+		that2, ok := that.(AnalysisNode)
+		if ok {
+			that1 = &that2
+		} else {
+			return false
+		}
+	}
+	if that1 == nil {
+		return this == nil
+	} else if this == nil {
+		return false
+	}
+	if this.Type != that1.Type {
+		return false
+	}
+	if len(this.Properties) != len(that1.Properties) {
+		return false
+	}
+	for i := range this.Properties {
+		if !this.Properties[i].Equal(&that1.Properties[i]) {
+			return false
+		}
+	}
+	if this.RowsIn != that1.RowsIn {
+		return false
+	}
+	if this.RowsOut != that1.RowsOut {
+		return false
+	}
+	if this.Batches != that1.Batches {
+		return false
+	}
+	if this.BytesRead != that1.BytesRead {
+		return false
+	}
+	if this.PagesPruned != that1.PagesPruned {
+		return false
+	}
+	if this.WallTime != that1.WallTime {
+		return false
+	}
+	if len(this.Children) != len(that1.Children) {
+		return false
+	}
+	for i := range this.Children {
+		if !this.Children[i].Equal(that1.Children[i]) {
+			return false
+		}
+	}
+	return true
+}
+func (this *AnalysisProperty) Equal(that interface{}) bool {
+	if that == nil {
+		return this == nil
+	}
+
+	that1, ok := that.(*AnalysisProperty)
+	if !ok {
+		that2, ok := that.(AnalysisProperty)
+		if ok {
+			that1 = &that2
+		} else {
+			return false
+		}
+	}
+	if that1 == nil {
+		return this == nil
+	} else if this == nil {
+		return false
+	}
+	if this.Key != that1.Key {
+		return false
+	}
+	if this.Value != that1.Value {
+		return false
+	}
 	return true
 }
 func (this *Caches) Equal(that interface{}) bool {
@@ -1572,17 +1976,72 @@ func (this *Result) GoString() string {
 	if this == nil {
 		return "nil"
 	}
-	s := make([]string, 0, 9)
+	s := make([]string, 0, 10)
 	s = append(s, "&stats.Result{")
 	s = append(s, "Summary: "+strings.Replace(this.Summary.GoString(), `&`, ``, 1)+",\n")
 	s = append(s, "Querier: "+strings.Replace(this.Querier.GoString(), `&`, ``, 1)+",\n")
 	s = append(s, "Ingester: "+strings.Replace(this.Ingester.GoString(), `&`, ``, 1)+",\n")
 	s = append(s, "Caches: "+strings.Replace(this.Caches.GoString(), `&`, ``, 1)+",\n")
 	s = append(s, "Index: "+strings.Replace(this.Index.GoString(), `&`, ``, 1)+",\n")
+	if this.Analysis != nil {
+		s = append(s, "Analysis: "+fmt.Sprintf("%#v", this.Analysis)+",\n")
+	}
 	s = append(s, "}")
 	return strings.Join(s, "")
 }
-func (this *Caches) GoString() string {
+func (this *Analysis) GoString() string {
+	if this == nil {
+		return "nil"
+	}
+	s := make([]string, 0, 8)
+	s = append(s, "&stats.Analysis{")
+	s = append(s, "Query: "+fmt.Sprintf("%#v", this.Query)+",\n")
+	s = append(s, "Start: "+fmt.Sprintf("%#v", this.Start)+",\n")
+	s = append(s, "End: "+fmt.Sprintf("%#v", this.End)+",\n")
+	if this.Plan != nil {
+		s = append(s, "Plan: "+fmt.Sprintf("%#v", this.Plan)+",\n")
+	}
+	s = append(s, "}")
+	return strings.Join(s, "")
+}
+func (this *AnalysisNode) GoString() string {
+	if this == nil {
+		return "nil"
+	}
+	s := make([]string, 0, 13)
+	s = append(s, "&stats.AnalysisNode{")
+	s = append(s, "Type: "+fmt.Sprintf("%#v", this.Type)+",\n")
+	if this.Properties != nil {
+		vs := make([]AnalysisProperty, len(this.Properties))
+		for i := range vs {
+			vs[i] = this.Properties[i]
+		}
+		s = append(s, "Properties: "+fmt.Sprintf("%#v", vs)+",\n")
+	}
+	s = append(s, "RowsIn: "+fmt.Sprintf("%#v", this.RowsIn)+",\n")
+	s = append(s, "RowsOut: "+fmt.Sprintf("%#v", this.RowsOut)+",\n")
+	s = append(s, "Batches: "+fmt.Sprintf("%#v", this.Batches)+",\n")
+	s = append(s, "BytesRead: "+fmt.Sprintf("%#v", this.BytesRead)+",\n")
+	s = append(s, "PagesPruned: "+fmt.Sprintf("%#v", this.PagesPruned)+",\n")
+	s = append(s, "WallTime: "+fmt.Sprintf("%#v", this.WallTime)+",\n")
+	if this.Children != nil {
+		s = append(s, "Children: "+fmt.Sprintf("%#v", this.Children)+",\n")
+	}
+	s = append(s, "}")
+	return strings.Join(s, "")
+}
+func (this *AnalysisProperty) GoString() string {
+	if this == nil {
+		return "nil"
+	}
+	s := make([]string, 0, 6)
+	s = append(s, "&stats.AnalysisProperty{")
+	s = append(s, "Key: "+fmt.Sprintf("%#v", this.Key)+",\n")
+	s = append(s, "Value: "+fmt.Sprintf("%#v", this.Value)+",\n")
+	s = append(s, "}")
+	return strings.Join(s, "")
+}
+func (this *Caches) GoString() string {
 	if this == nil {
 		return "nil"
 	}
@@ -1759,6 +2218,20 @@ func (m *Result) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	_ = i
 	var l int
 	_ = l
+	if len(m.Analysis) > 0 {
+		for iNdEx := len(m.Analysis) - 1; iNdEx >= 0; iNdEx-- {
+			{
+				size, err := m.Analysis[iNdEx].MarshalToSizedBuffer(dAtA[:i])
+				if err != nil {
+					return 0, err
+				}
+				i -= size
+				i = encodeVarintStats(dAtA, i, uint64(size))
+			}
+			i--
+			dAtA[i] = 0x32
+		}
+	}
 	{
 		size, err := m.Index.MarshalToSizedBuffer(dAtA[:i])
 		if err != nil {
@@ -1812,6 +2285,186 @@ func (m *Result) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	return len(dAtA) - i, nil
 }
 
+func (m *Analysis) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *Analysis) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *Analysis) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if len(m.Plan) > 0 {
+		for iNdEx := len(m.Plan) - 1; iNdEx >= 0; iNdEx-- {
+			{
+				size, err := m.Plan[iNdEx].MarshalToSizedBuffer(dAtA[:i])
+				if err != nil {
+					return 0, err
+				}
+				i -= size
+				i = encodeVarintStats(dAtA, i, uint64(size))
+			}
+			i--
+			dAtA[i] = 0x22
+		}
+	}
+	if m.End != 0 {
+		i = encodeVarintStats(dAtA, i, uint64(m.End))
+		i--
+		dAtA[i] = 0x18
+	}
+	if m.Start != 0 {
+		i = encodeVarintStats(dAtA, i, uint64(m.Start))
+		i--
+		dAtA[i] = 0x10
+	}
+	if len(m.Query) > 0 {
+		i -= len(m.Query)
+		copy(dAtA[i:], m.Query)
+		i = encodeVarintStats(dAtA, i, uint64(len(m.Query)))
+		i--
+		dAtA[i] = 0xa
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *AnalysisNode) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *AnalysisNode) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *AnalysisNode) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if len(m.Children) > 0 {
+		for iNdEx := len(m.Children) - 1; iNdEx >= 0; iNdEx-- {
+			{
+				size, err := m.Children[iNdEx].MarshalToSizedBuffer(dAtA[:i])
+				if err != nil {
+					return 0, err
+				}
+				i -= size
+				i = encodeVarintStats(dAtA, i, uint64(size))
+			}
+			i--
+			dAtA[i] = 0x4a
+		}
+	}
+	if m.WallTime != 0 {
+		i -= 8
+		encoding_binary.LittleEndian.PutUint64(dAtA[i:], uint64(math.Float64bits(float64(m.WallTime))))
+		i--
+		dAtA[i] = 0x41
+	}
+	if m.PagesPruned != 0 {
+		i = encodeVarintStats(dAtA, i, uint64(m.PagesPruned))
+		i--
+		dAtA[i] = 0x38
+	}
+	if m.BytesRead != 0 {
+		i = encodeVarintStats(dAtA, i, uint64(m.BytesRead))
+		i--
+		dAtA[i] = 0x30
+	}
+	if m.Batches != 0 {
+		i = encodeVarintStats(dAtA, i, uint64(m.Batches))
+		i--
+		dAtA[i] = 0x28
+	}
+	if m.RowsOut != 0 {
+		i = encodeVarintStats(dAtA, i, uint64(m.RowsOut))
+		i--
+		dAtA[i] = 0x20
+	}
+	if m.RowsIn != 0 {
+		i = encodeVarintStats(dAtA, i, uint64(m.RowsIn))
+		i--
+		dAtA[i] = 0x18
+	}
+	if len(m.Properties) > 0 {
+		for iNdEx := len(m.Properties) - 1; iNdEx >= 0; iNdEx-- {
+			{
+				size, err := m.Properties[iNdEx].MarshalToSizedBuffer(dAtA[:i])
+				if err != nil {
+					return 0, err
+				}
+				i -= size
+				i = encodeVarintStats(dAtA, i, uint64(size))
+			}
+			i--
+			dAtA[i] = 0x12
+		}
+	}
+	if len(m.Type) > 0 {
+		i -= len(m.Type)
+		copy(dAtA[i:], m.Type)
+		i = encodeVarintStats(dAtA, i, uint64(len(m.Type)))
+		i--
+		dAtA[i] = 0xa
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *AnalysisProperty) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *AnalysisProperty) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *AnalysisProperty) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if len(m.Value) > 0 {
+		i -= len(m.Value)
+		copy(dAtA[i:], m.Value)
+		i = encodeVarintStats(dAtA, i, uint64(len(m.Value)))
+		i--
+		dAtA[i] = 0x12
+	}
+	if len(m.Key) > 0 {
+		i -= len(m.Key)
+		copy(dAtA[i:], m.Key)
+		i = encodeVarintStats(dAtA, i, uint64(len(m.Key)))
+		i--
+		dAtA[i] = 0xa
+	}
+	return len(dAtA) - i, nil
+}
+
 func (m *Caches) Marshal() (dAtA []byte, err error) {
 	size := m.Size()
 	dAtA = make([]byte, size)
@@ -2458,6 +3111,97 @@ func (m *Result) Size() (n int) {
 	n += 1 + l + sovStats(uint64(l))
 	l = m.Index.Size()
 	n += 1 + l + sovStats(uint64(l))
+	if len(m.Analysis) > 0 {
+		for _, e := range m.Analysis {
+			l = e.Size()
+			n += 1 + l + sovStats(uint64(l))
+		}
+	}
+	return n
+}
+
+func (m *Analysis) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	l = len(m.Query)
+	if l > 0 {
+		n += 1 + l + sovStats(uint64(l))
+	}
+	if m.Start != 0 {
+		n += 1 + sovStats(uint64(m.Start))
+	}
+	if m.End != 0 {
+		n += 1 + sovStats(uint64(m.End))
+	}
+	if len(m.Plan) > 0 {
+		for _, e := range m.Plan {
+			l = e.Size()
+			n += 1 + l + sovStats(uint64(l))
+		}
+	}
+	return n
+}
+
+func (m *AnalysisNode) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	l = len(m.Type)
+	if l > 0 {
+		n += 1 + l + sovStats(uint64(l))
+	}
+	if len(m.Properties) > 0 {
+		for _, e := range m.Properties {
+			l = e.Size()
+			n += 1 + l + sovStats(uint64(l))
+		}
+	}
+	if m.RowsIn != 0 {
+		n += 1 + sovStats(uint64(m.RowsIn))
+	}
+	if m.RowsOut != 0 {
+		n += 1 + sovStats(uint64(m.RowsOut))
+	}
+	if m.Batches != 0 {
+		n += 1 + sovStats(uint64(m.Batches))
+	}
+	if m.BytesRead != 0 {
+		n += 1 + sovStats(uint64(m.BytesRead))
+	}
+	if m.PagesPruned != 0 {
+		n += 1 + sovStats(uint64(m.PagesPruned))
+	}
+	if m.WallTime != 0 {
+		n += 9
+	}
+	if len(m.Children) > 0 {
+		for _, e := range m.Children {
+			l = e.Size()
+			n += 1 + l + sovStats(uint64(l))
+		}
+	}
+	return n
+}
+
+func (m *AnalysisProperty) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	l = len(m.Key)
+	if l > 0 {
+		n += 1 + l + sovStats(uint64(l))
+	}
+	l = len(m.Value)
+	if l > 0 {
+		n += 1 + l + sovStats(uint64(l))
+	}
 	return n
 }
 
@@ -2744,12 +3488,75 @@ func (this *Result) String() string {
 	if this == nil {
 		return "nil"
 	}
+	repeatedStringForAnalysis := "[]*Analysis{"
+	for _, f := range this.Analysis {
+		repeatedStringForAnalysis += strings.Replace(f.String(), "Analysis", "Analysis", 1) + ","
+	}
+	repeatedStringForAnalysis += "}"
 	s := strings.Join([]string{`&Result{`,
 		`Summary:` + strings.Replace(strings.Replace(this.Summary.String(), "Summary", "Summary", 1), `&`, ``, 1) + `,`,
 		`Querier:` + strings.Replace(strings.Replace(this.Querier.String(), "Querier", "Querier", 1), `&`, ``, 1) + `,`,
 		`Ingester:` + strings.Replace(strings.Replace(this.Ingester.String(), "Ingester", "Ingester", 1), `&`, ``, 1) + `,`,
 		`Caches:` + strings.Replace(strings.Replace(this.Caches.String(), "Caches", "Caches", 1), `&`, ``, 1) + `,`,
 		`Index:` + strings.Replace(strings.Replace(this.Index.String(), "Index", "Index", 1), `&`, ``, 1) + `,`,
+		`Analysis:` + repeatedStringForAnalysis + `,`,
+		`}`,
+	}, "")
+	return s
+}
+func (this *Analysis) String() string {
+	if this == nil {
+		return "nil"
+	}
+	repeatedStringForPlan := "[]*AnalysisNode{"
+	for _, f := range this.Plan {
+		repeatedStringForPlan += strings.Replace(f.String(), "AnalysisNode", "AnalysisNode", 1) + ","
+	}
+	repeatedStringForPlan += "}"
+	s := strings.Join([]string{`&Analysis{`,
+		`Query:` + fmt.Sprintf("%v", this.Query) + `,`,
+		`Start:` + fmt.Sprintf("%v", this.Start) + `,`,
+		`End:` + fmt.Sprintf("%v", this.End) + `,`,
+		`Plan:` + repeatedStringForPlan + `,`,
+		`}`,
+	}, "")
+	return s
+}
+func (this *AnalysisNode) String() string {
+	if this == nil {
+		return "nil"
+	}
+	repeatedStringForProperties := "[]AnalysisProperty{"
+	for _, f := range this.Properties {
+		repeatedStringForProperties += strings.Replace(strings.Replace(f.String(), "AnalysisProperty", "AnalysisProperty", 1), `&`, ``, 1) + ","
+	}
+	repeatedStringForProperties += "}"
+	repeatedStringForChildren := "[]*AnalysisNode{"
+	for _, f := range this.Children {
+		repeatedStringForChildren += strings.Replace(f.String(), "AnalysisNode", "AnalysisNode", 1) + ","
+	}
+	repeatedStringForChildren += "}"
+	s := strings.Join([]string{`&AnalysisNode{`,
+		`Type:` + fmt.Sprintf("%v", this.Type) + `,`,
+		`Properties:` + repeatedStringForProperties + `,`,
+		`RowsIn:` + fmt.Sprintf("%v", this.RowsIn) + `,`,
+		`RowsOut:` + fmt.Sprintf("%v", this.RowsOut) + `,`,
+		`Batches:` + fmt.Sprintf("%v", this.Batches) + `,`,
+		`BytesRead:` + fmt.Sprintf("%v", this.BytesRead) + `,`,
+		`PagesPruned:` + fmt.Sprintf("%v", this.PagesPruned) + `,`,
+		`WallTime:` + fmt.Sprintf("%v", this.WallTime) + `,`,
+		`Children:` + repeatedStringForChildren + `,`,
+		`}`,
+	}, "")
+	return s
+}
+func (this *AnalysisProperty) String() string {
+	if this == nil {
+		return "nil"
+	}
+	s := strings.Join([]string{`&AnalysisProperty{`,
+		`Key:` + fmt.Sprintf("%v", this.Key) + `,`,
+		`Value:` + fmt.Sprintf("%v", this.Value) + `,`,
 		`}`,
 	}, "")
 	return s
@@ -3105,6 +3912,573 @@ func (m *Result) Unmarshal(dAtA []byte) error {
 				return err
 			}
 			iNdEx = postIndex
+		case 6:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Analysis", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowStats
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthStats
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthStats
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Analysis = append(m.Analysis, &Analysis{})
+			if err := m.Analysis[len(m.Analysis)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipStats(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthStats
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthStats
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *Analysis) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowStats
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: Analysis: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: Analysis: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Query", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowStats
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthStats
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthStats
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Query = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 2:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Start", wireType)
+			}
+			m.Start = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowStats
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Start |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 3:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field End", wireType)
+			}
+			m.End = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowStats
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.End |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 4:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Plan", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowStats
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthStats
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthStats
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Plan = append(m.Plan, &AnalysisNode{})
+			if err := m.Plan[len(m.Plan)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipStats(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthStats
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthStats
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *AnalysisNode) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowStats
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: AnalysisNode: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: AnalysisNode: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Type", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowStats
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthStats
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthStats
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Type = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Properties", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowStats
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthStats
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthStats
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Properties = append(m.Properties, AnalysisProperty{})
+			if err := m.Properties[len(m.Properties)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 3:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field RowsIn", wireType)
+			}
+			m.RowsIn = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowStats
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.RowsIn |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 4:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field RowsOut", wireType)
+			}
+			m.RowsOut = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowStats
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.RowsOut |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 5:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Batches", wireType)
+			}
+			m.Batches = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowStats
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Batches |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 6:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field BytesRead", wireType)
+			}
+			m.BytesRead = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowStats
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.BytesRead |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 7:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field PagesPruned", wireType)
+			}
+			m.PagesPruned = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowStats
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.PagesPruned |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 8:
+			if wireType != 1 {
+				return fmt.Errorf("proto: wrong wireType = %d for field WallTime", wireType)
+			}
+			var v uint64
+			if (iNdEx + 8) > l {
+				return io.ErrUnexpectedEOF
+			}
+			v = uint64(encoding_binary.LittleEndian.Uint64(dAtA[iNdEx:]))
+			iNdEx += 8
+			m.WallTime = float64(math.Float64frombits(v))
+		case 9:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Children", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowStats
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthStats
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthStats
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Children = append(m.Children, &AnalysisNode{})
+			if err := m.Children[len(m.Children)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipStats(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthStats
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthStats
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *AnalysisProperty) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowStats
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: AnalysisProperty: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: AnalysisProperty: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Key", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowStats
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthStats
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthStats
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Key = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Value", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowStats
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthStats
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthStats
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Value = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
 			skippy, err := skipStats(dAtA[iNdEx:])
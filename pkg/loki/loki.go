@@ -37,6 +37,7 @@ import (
 	"github.com/grafana/loki/v3/pkg/compactor"
 	compactorclient "github.com/grafana/loki/v3/pkg/compactor/client"
 	"github.com/grafana/loki/v3/pkg/compactor/deletion"
+	dataobjcompactor "github.com/grafana/loki/v3/pkg/dataobj/compactor"
 	dataobjconfig "github.com/grafana/loki/v3/pkg/dataobj/config"
 	"github.com/grafana/loki/v3/pkg/dataobj/consumer"
 	dataobjindex "github.com/grafana/loki/v3/pkg/dataobj/index"
@@ -419,6 +420,7 @@ type Loki struct {
 	MemberlistKV              *memberlist.KVInitService
 	compactor                 *compactor.Compactor
 	QueryFrontEndMiddleware   queryrangebase.Middleware
+	fragmentRoundTripper      *deferredGRPCRoundTripper
 	queryScheduler            *scheduler.Scheduler
 	querySchedulerRingManager *lokiring.RingManager
 	usageReport               *analytics.Reporter
@@ -429,6 +431,7 @@ type Loki struct {
 	blockScheduler            *blockscheduler.BlockScheduler
 	dataObjConsumer           *consumer.Service
 	dataObjIndexBuilder       *dataobjindex.Builder
+	dataObjCompactor          *dataobjcompactor.Compactor
 
 	ClientMetrics       storage.ClientMetrics
 	deleteClientMetrics *deletion.DeleteRequestClientMetrics
@@ -772,6 +775,7 @@ func (t *Loki) setupModuleManager() error {
 	mm.RegisterModule(UI, t.initUI)
 	mm.RegisterModule(DataObjConsumer, t.initDataObjConsumer)
 	mm.RegisterModule(DataObjIndexBuilder, t.initDataObjIndexBuilder)
+	mm.RegisterModule(DataObjCompactor, t.initDataObjCompactor)
 
 	mm.RegisterModule(All, nil)
 	mm.RegisterModule(Read, nil)
@@ -817,8 +821,9 @@ func (t *Loki) setupModuleManager() error {
 		BlockBuilder:             {PartitionRing, Store, Server, UI},
 		BlockScheduler:           {Server, UI},
 		DataObjExplorer:          {Server, UI},
-		DataObjConsumer:          {PartitionRing, Server, UI},
+		DataObjConsumer:          {PartitionRing, Server, Overrides, UI},
 		DataObjIndexBuilder:      {Server, UI},
+		DataObjCompactor:         {Server, Overrides},
 
 		Read:    {QueryFrontend, Querier},
 		Write:   {Ingester, Distributor, PatternIngester},
@@ -831,6 +836,12 @@ func (t *Loki) setupModuleManager() error {
 		deps[All] = append(deps[All], IngestLimits, IngestLimitsFrontend)
 	}
 
+	// Retention of data objects is applied during the retention phase of the
+	// compactor, which must run in the same process.
+	if t.Cfg.DataObj.Compactor.RetentionEnabled {
+		deps[DataObjCompactor] = append(deps[DataObjCompactor], Compactor)
+	}
+
 	if t.Cfg.Querier.PerRequestLimitsEnabled {
 		level.Debug(util_log.Logger).Log("msg", "per-query request limits support enabled")
 		mm.RegisterModule(QueryLimiter, t.initQueryLimiter, modules.UserInvisibleModule)
@@ -20,6 +20,7 @@ import (
 	"github.com/go-kit/log"
 	"github.com/go-kit/log/level"
 	"github.com/grafana/dskit/dns"
+	"github.com/grafana/dskit/httpgrpc"
 	"github.com/grafana/dskit/kv"
 	"github.com/grafana/dskit/kv/codec"
 	"github.com/grafana/dskit/kv/memberlist"
@@ -52,12 +53,14 @@ import (
 	"github.com/grafana/loki/v3/pkg/compactor/client/grpc"
 	"github.com/grafana/loki/v3/pkg/compactor/deletion"
 	"github.com/grafana/loki/v3/pkg/compactor/generationnumber"
+	dataobjcompactor "github.com/grafana/loki/v3/pkg/dataobj/compactor"
 	"github.com/grafana/loki/v3/pkg/dataobj/consumer"
 	"github.com/grafana/loki/v3/pkg/dataobj/explorer"
 	dataobjindex "github.com/grafana/loki/v3/pkg/dataobj/index"
 	"github.com/grafana/loki/v3/pkg/dataobj/metastore"
 	dataobjquerier "github.com/grafana/loki/v3/pkg/dataobj/querier"
 	"github.com/grafana/loki/v3/pkg/distributor"
+	"github.com/grafana/loki/v3/pkg/engine"
 	"github.com/grafana/loki/v3/pkg/indexgateway"
 	"github.com/grafana/loki/v3/pkg/ingester"
 	"github.com/grafana/loki/v3/pkg/kafka/partition"
@@ -160,6 +163,7 @@ const (
 	DataObjExplorer          = "dataobj-explorer"
 	DataObjConsumer          = "dataobj-consumer"
 	DataObjIndexBuilder      = "dataobj-index-builder"
+	DataObjCompactor         = "dataobj-compactor"
 	UI                       = "ui"
 	All                      = "all"
 	Read                     = "read"
@@ -612,7 +616,7 @@ func (t *Loki) initQuerier() (services.Service, error) {
 	toMerge := []middleware.Interface{
 		httpreq.ExtractQueryMetricsMiddleware(),
 		httpreq.ExtractQueryTagsMiddleware(),
-		httpreq.PropagateHeadersMiddleware(httpreq.LokiEncodingFlagsHeader, httpreq.LokiDisablePipelineWrappersHeader),
+		httpreq.PropagateHeadersMiddleware(httpreq.LokiEncodingFlagsHeader, httpreq.LokiDisablePipelineWrappersHeader, httpreq.LokiExplainAnalyzeHeader),
 		serverutil.RecoveryHTTPMiddleware,
 		t.HTTPAuthMiddleware,
 		serverutil.NewPrepopulateMiddleware(),
@@ -1189,6 +1193,21 @@ func (i ingesterQueryOptions) QueryIngestersWithin() time.Duration {
 	return i.Config.QueryIngestersWithin
 }
 
+// deferredGRPCRoundTripper is a [queryrange.GRPCRoundTripper] whose target
+// is set after it is created. The engine of the query frontend middleware
+// sends fragments through the query frontend, which is initialized after the
+// middleware.
+type deferredGRPCRoundTripper struct {
+	rt queryrange.GRPCRoundTripper
+}
+
+func (d *deferredGRPCRoundTripper) RoundTripGRPC(ctx context.Context, req *httpgrpc.HTTPRequest) (*httpgrpc.HTTPResponse, error) {
+	if d.rt == nil {
+		return nil, errors.New("query frontend is not initialized")
+	}
+	return d.rt.RoundTripGRPC(ctx, req)
+}
+
 func (t *Loki) initQueryFrontendMiddleware() (_ services.Service, err error) {
 	level.Debug(util_log.Logger).Log("msg", "initializing query frontend tripperware")
 
@@ -1205,8 +1224,31 @@ func (t *Loki) initQueryFrontendMiddleware() (_ services.Service, err error) {
 		}
 	}
 
+	queryRangeCfg := t.Cfg.QueryRange
+	if t.Cfg.Querier.Engine.EnableV2Engine && t.Cfg.Querier.Engine.DistributedFragments > 0 {
+		// Fragments are sent through the query frontend, which is not
+		// available when the queries are sent to a downstream URL.
+		if t.Cfg.Frontend.DownstreamURL == "" {
+			store, err := t.createDataObjBucket("dataobj-query-frontend")
+			if err != nil {
+				return nil, err
+			}
+
+			logger := log.With(util_log.Logger, "component", "query-frontend")
+			// The prefix avoids conflicts with the metrics of the engine of
+			// the querier when both run in the same process.
+			reg := prometheus.WrapRegistererWithPrefix("query_frontend_", prometheus.DefaultRegisterer)
+			t.fragmentRoundTripper = &deferredGRPCRoundTripper{}
+			eng := engine.NewDistributed(t.Cfg.Querier.Engine, store, t.Overrides, queryrange.NewFragmentRunner(t.fragmentRoundTripper), reg, logger)
+			queryRangeCfg.EngineV2 = queryrange.NewEngineV2Middleware(eng, logger)
+			level.Info(util_log.Logger).Log("msg", "distributing queries of the new query engine across queriers", "fragments", t.Cfg.Querier.Engine.DistributedFragments)
+		} else {
+			level.Warn(util_log.Logger).Log("msg", "distributed query fragments require a query frontend with query scheduler or queriers connecting to it")
+		}
+	}
+
 	middleware, stopper, err := queryrange.NewMiddleware(
-		t.Cfg.QueryRange,
+		queryRangeCfg,
 		t.Cfg.Querier.Engine,
 		ingesterQueryOptions{t.Cfg.Querier},
 		util_log.Logger,
@@ -1313,7 +1355,16 @@ func (t *Loki) initQueryFrontend() (_ services.Service, err error) {
 		level.Debug(util_log.Logger).Log("msg", "no query frontend configured")
 	}
 
-	roundTripper := queryrange.NewSerializeRoundTripper(t.QueryFrontEndMiddleware.Wrap(frontendTripper), queryrange.DefaultCodec, t.Cfg.Frontend.SupportParquetEncoding)
+	if t.fragmentRoundTripper != nil {
+		if frontendV1 != nil {
+			t.fragmentRoundTripper.rt = frontendV1
+		} else if frontendV2 != nil {
+			t.fragmentRoundTripper.rt = frontendV2
+		}
+	}
+
+	tripper := t.QueryFrontEndMiddleware.Wrap(frontendTripper)
+	roundTripper := queryrange.NewSerializeRoundTripper(tripper, queryrange.DefaultCodec, t.Cfg.Frontend.SupportParquetEncoding)
 
 	frontendHandler := transport.NewHandler(t.Cfg.Frontend.Handler, roundTripper, util_log.Logger, prometheus.DefaultRegisterer, t.Cfg.MetricsNamespace)
 	if t.Cfg.Frontend.CompressResponses {
@@ -1323,7 +1374,7 @@ func (t *Loki) initQueryFrontend() (_ services.Service, err error) {
 	// TODO: add SerializeHTTPHandler
 	toMerge := []middleware.Interface{
 		httpreq.ExtractQueryTagsMiddleware(),
-		httpreq.PropagateHeadersMiddleware(httpreq.LokiActorPathHeader, httpreq.LokiEncodingFlagsHeader, httpreq.LokiDisablePipelineWrappersHeader),
+		httpreq.PropagateHeadersMiddleware(httpreq.LokiActorPathHeader, httpreq.LokiEncodingFlagsHeader, httpreq.LokiDisablePipelineWrappersHeader, httpreq.LokiExplainAnalyzeHeader),
 		serverutil.RecoveryHTTPMiddleware,
 		t.HTTPAuthMiddleware,
 		queryrange.StatsHTTPMiddleware,
@@ -2201,6 +2252,7 @@ func (t *Loki) initDataObjConsumer() (services.Service, error) {
 		t.Cfg.KafkaConfig,
 		t.Cfg.DataObj.Consumer,
 		t.Cfg.DataObj.Metastore,
+		t.Overrides,
 		t.Cfg.Distributor.TenantTopic.TopicPrefix,
 		store,
 		t.Cfg.Ingester.LifecyclerConfig.ID,
@@ -2235,6 +2287,36 @@ func (t *Loki) initDataObjIndexBuilder() (services.Service, error) {
 	return t.dataObjIndexBuilder, err
 }
 
+func (t *Loki) initDataObjCompactor() (services.Service, error) {
+	store, err := t.createDataObjBucket("dataobj-compactor")
+	if err != nil {
+		return nil, err
+	}
+
+	level.Info(util_log.Logger).Log("msg", "initializing dataobj compactor")
+	t.dataObjCompactor, err = dataobjcompactor.New(
+		t.Cfg.DataObj.Compactor,
+		t.Cfg.DataObj.Metastore,
+		t.Cfg.DataObj.Index,
+		t.Overrides,
+		store,
+		prometheus.DefaultRegisterer,
+		util_log.Logger,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if t.Cfg.DataObj.Compactor.RetentionEnabled {
+		if t.compactor == nil {
+			return nil, errors.New("dataobj-compactor.retention-enabled requires the compactor to run in the same process")
+		}
+		t.compactor.RegisterRetentionApplier("dataobj", t.dataObjCompactor)
+	}
+
+	return t.dataObjCompactor, nil
+}
+
 func (t *Loki) createDataObjBucket(clientName string) (objstore.Bucket, error) {
 	schema, err := t.Cfg.SchemaConfig.SchemaForTime(model.Now())
 	if err != nil {
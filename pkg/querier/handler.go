@@ -131,6 +131,13 @@ func (h *Handler) Do(ctx context.Context, req queryrangebase.Request) (queryrang
 		}
 
 		return &queryrange.DetectedLabelsResponse{Response: result}, nil
+	case *queryrange.FragmentRequest:
+		result, err := h.api.FragmentHandler(ctx, concrete)
+		if err != nil {
+			return nil, err
+		}
+
+		return &queryrange.FragmentResponse{Data: result}, nil
 	default:
 		return nil, fmt.Errorf("unsupported query type %T", req)
 	}
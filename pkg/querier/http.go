@@ -1,6 +1,7 @@
 package querier
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 	"net/http"
@@ -26,6 +27,7 @@ import (
 	"golang.org/x/sync/errgroup"
 
 	"github.com/grafana/loki/v3/pkg/engine"
+	"github.com/grafana/loki/v3/pkg/engine/planner/physical"
 	"github.com/grafana/loki/v3/pkg/loghttp"
 	"github.com/grafana/loki/v3/pkg/logproto"
 	"github.com/grafana/loki/v3/pkg/logql"
@@ -55,8 +57,8 @@ type QuerierAPI struct {
 	querier  Querier
 	cfg      Config
 	limits   querier_limits.Limits
-	engineV1 logql.Engine // Loki's current query engine
-	engineV2 logql.Engine // Loki's next generation query engine
+	engineV1 logql.Engine        // Loki's current query engine
+	engineV2 *engine.QueryEngine // Loki's next generation query engine
 	logger   log.Logger
 }
 
@@ -86,7 +88,7 @@ func (q *QuerierAPI) RangeQueryHandler(ctx context.Context, req *queryrange.Loki
 		return result, err
 	}
 
-	if q.cfg.Engine.EnableV2Engine && hasDataObjectsAvailable(params.Start(), params.End()) {
+	if q.cfg.Engine.EnableV2Engine && engine.HasDataObjectsAvailable(params.Start(), params.End()) {
 		query := q.engineV2.Query(params)
 		result, err = query.Exec(ctx)
 		if err == nil {
@@ -103,12 +105,6 @@ func (q *QuerierAPI) RangeQueryHandler(ctx context.Context, req *queryrange.Loki
 	return query.Exec(ctx)
 }
 
-func hasDataObjectsAvailable(_, end time.Time) bool {
-	// Data objects in object storage lag behind 20-30 minutes.
-	// We are generous and only enable v2 engine queries that end earlier than 1 hour ago, to ensure data objects are available.
-	return end.Before(time.Now().Add(-1 * time.Hour))
-}
-
 // InstantQueryHandler is a http.HandlerFunc for instant queries.
 func (q *QuerierAPI) InstantQueryHandler(ctx context.Context, req *queryrange.LokiInstantRequest) (logqlmodel.Result, error) {
 	// do not allow log selector expression (aka log query) as instant query
@@ -125,7 +121,7 @@ func (q *QuerierAPI) InstantQueryHandler(ctx context.Context, req *queryrange.Lo
 		return logqlmodel.Result{}, err
 	}
 
-	if q.cfg.Engine.EnableV2Engine && hasDataObjectsAvailable(params.Start(), params.End()) {
+	if q.cfg.Engine.EnableV2Engine && engine.HasDataObjectsAvailable(params.Start(), params.End()) {
 		query := q.engineV2.Query(params)
 		result, err := query.Exec(ctx)
 		if err == nil {
@@ -144,6 +140,48 @@ func (q *QuerierAPI) InstantQueryHandler(ctx context.Context, req *queryrange.Lo
 	return query.Exec(ctx)
 }
 
+// FragmentHandler executes a fragment of a physical plan of the new query
+// engine, which is sent by a query frontend that distributes queries across
+// queriers, and returns its encoded results.
+func (q *QuerierAPI) FragmentHandler(ctx context.Context, req *queryrange.FragmentRequest) ([]byte, error) {
+	if !q.cfg.Engine.EnableV2Engine {
+		return nil, httpgrpc.Errorf(http.StatusBadRequest, "fragments require the new query engine to be enabled")
+	}
+
+	fragment, err := physical.UnmarshalPlan(req.Plan)
+	if err != nil {
+		return nil, httpgrpc.Errorf(http.StatusBadRequest, "%s", err.Error())
+	}
+
+	// The results are sent in a single response through the query scheduler,
+	// so the batches are written to a buffer as they are read, until they
+	// exceed the maximum size.
+	buf := &limitedBuffer{limit: q.cfg.Engine.MaxFragmentResultSize.Val()}
+	if err := q.engineV2.ExecuteFragment(ctx, fragment, buf); err != nil {
+		if errors.Is(err, errFragmentResultTooLarge) {
+			return nil, httpgrpc.Errorf(http.StatusRequestEntityTooLarge, "fragment results exceed the maximum size of %d bytes (-querier.engine.max-fragment-result-size)", buf.limit)
+		}
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+var errFragmentResultTooLarge = errors.New("fragment results too large")
+
+// limitedBuffer is a [bytes.Buffer] that fails writes that would grow it
+// beyond limit. A limit of 0 disables the limit.
+type limitedBuffer struct {
+	bytes.Buffer
+	limit int
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if b.limit > 0 && b.Len()+len(p) > b.limit {
+		return 0, errFragmentResultTooLarge
+	}
+	return b.Buffer.Write(p)
+}
+
 // LabelHandler is a http.HandlerFunc for handling label queries.
 func (q *QuerierAPI) LabelHandler(ctx context.Context, req *logproto.LabelRequest) (*logproto.LabelResponse, error) {
 	timer := prometheus.NewTimer(logql.QueryTime.WithLabelValues(logql.QueryTypeLabels))
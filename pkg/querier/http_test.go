@@ -12,13 +12,19 @@ import (
 	"github.com/pkg/errors"
 	"github.com/stretchr/testify/mock"
 
+	"github.com/grafana/loki/v3/pkg/engine/planner/logical"
+	"github.com/grafana/loki/v3/pkg/engine/planner/physical"
 	"github.com/grafana/loki/v3/pkg/loghttp"
 	"github.com/grafana/loki/v3/pkg/logproto"
+	"github.com/grafana/loki/v3/pkg/logql"
 	"github.com/grafana/loki/v3/pkg/logqlmodel"
+	"github.com/grafana/loki/v3/pkg/querier/queryrange"
 	"github.com/grafana/loki/v3/pkg/util/constants"
+	"github.com/grafana/loki/v3/pkg/util/flagext"
 	"github.com/grafana/loki/v3/pkg/validation"
 
 	"github.com/go-kit/log"
+	"github.com/grafana/dskit/httpgrpc"
 	"github.com/grafana/dskit/user"
 	"github.com/prometheus/common/model"
 	"github.com/stretchr/testify/require"
@@ -768,3 +774,48 @@ func TestPatternsHandlerDisabled(t *testing.T) {
 		})
 	}
 }
+
+func TestFragmentHandler(t *testing.T) {
+	limits, err := validation.NewOverrides(defaultLimitsTestConfig(), nil)
+	require.NoError(t, err)
+
+	params, err := logql.NewLiteralParams(`vector(1)`, time.Unix(0, 0), time.Unix(60, 0), 30*time.Second, 0, logproto.FORWARD, 0, nil, nil)
+	require.NoError(t, err)
+	logicalPlan, err := logical.BuildPlan(params)
+	require.NoError(t, err)
+	fragment, err := physical.NewPlanner(physical.NewContext(params.Start(), params.End()), nil).Build(logicalPlan)
+	require.NoError(t, err)
+	encoded, err := physical.MarshalPlan(fragment)
+	require.NoError(t, err)
+
+	ctx := user.InjectOrgID(context.Background(), "user")
+	req := &queryrange.FragmentRequest{Plan: encoded}
+
+	for _, tc := range []struct {
+		name    string
+		maxSize int
+		err     bool
+	}{
+		{name: "unlimited", maxSize: 0},
+		{name: "below limit", maxSize: 1 << 20},
+		{name: "above limit", maxSize: 16, err: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := mockQuerierConfig()
+			cfg.Engine.EnableV2Engine = true
+			cfg.Engine.BatchSize = 100
+			cfg.Engine.MaxFragmentResultSize = flagext.ByteSize(tc.maxSize)
+			api := NewQuerierAPI(cfg, nil, limits, nil, nil, log.NewNopLogger())
+
+			data, err := api.FragmentHandler(ctx, req)
+			if tc.err {
+				resp, ok := httpgrpc.HTTPResponseFromError(err)
+				require.True(t, ok)
+				require.Equal(t, int32(http.StatusRequestEntityTooLarge), resp.Code)
+				return
+			}
+			require.NoError(t, err)
+			require.NotEmpty(t, data)
+		})
+	}
+}
@@ -21,4 +21,6 @@ type Limits interface {
 	MaxStreamsMatchersPerQuery(context.Context, string) int
 	MaxConcurrentTailRequests(context.Context, string) int
 	MaxEntriesLimitPerQuery(context.Context, string) int
+	QueryEngineAggregationMemoryLimit(context.Context, string) int
+	QueryEngineAggregationSpillLimit(context.Context, string) int
 }
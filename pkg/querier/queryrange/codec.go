@@ -507,6 +507,11 @@ func (Codec) DecodeHTTPGrpcRequest(ctx context.Context, r *httpgrpc.HTTPRequest)
 		httpreq.InjectHeader(ctx, httpreq.LokiDisablePipelineWrappersHeader, disableWrappers)
 	}
 
+	// Add explain analyze
+	if explainAnalyze := httpReq.Header.Get(httpreq.LokiExplainAnalyzeHeader); explainAnalyze != "" {
+		ctx = httpreq.InjectHeader(ctx, httpreq.LokiExplainAnalyzeHeader, explainAnalyze)
+	}
+
 	// Add query metrics
 	if queueTimeHeader := httpReq.Header.Get(string(httpreq.QueryQueueTimeHTTPHeader)); queueTimeHeader != "" {
 		queueTime, err := time.ParseDuration(queueTimeHeader)
@@ -650,6 +655,8 @@ func (Codec) DecodeHTTPGrpcRequest(ctx context.Context, r *httpgrpc.HTTPRequest)
 			DetectedLabelsRequest: *req,
 			path:                  httpReq.URL.Path,
 		}, ctx, err
+	case FragmentOp:
+		return &FragmentRequest{Plan: r.Body}, ctx, nil
 	default:
 		return nil, ctx, httpgrpc.Errorf(http.StatusBadRequest, "%s", fmt.Sprintf("unknown request path in HTTP gRPC decode: %s", r.Url))
 	}
@@ -669,6 +676,10 @@ func (Codec) DecodeHTTPGrpcResponse(r *httpgrpc.HTTPResponse, req queryrangebase
 }
 
 func (Codec) EncodeHTTPGrpcResponse(_ context.Context, req *httpgrpc.HTTPRequest, res queryrangebase.Response) (*httpgrpc.HTTPResponse, error) {
+	if res, ok := res.(*FragmentResponse); ok {
+		return encodeFragmentResponse(res), nil
+	}
+
 	version := loghttp.GetVersion(req.Url)
 	var buf bytes.Buffer
 
@@ -716,6 +727,11 @@ func (c Codec) EncodeRequest(ctx context.Context, r queryrangebase.Request) (*ht
 		header.Set(httpreq.LokiDisablePipelineWrappersHeader, disableWrappers)
 	}
 
+	// Add explain analyze
+	if explainAnalyze := httpreq.ExtractHeader(ctx, httpreq.LokiExplainAnalyzeHeader); explainAnalyze != "" {
+		header.Set(httpreq.LokiExplainAnalyzeHeader, explainAnalyze)
+	}
+
 	// Add limits
 	if limits := querylimits.ExtractQueryLimitsContext(ctx); limits != nil {
 		err := querylimits.InjectQueryLimitsHeader(&header, limits)
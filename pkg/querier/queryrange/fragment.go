@@ -0,0 +1,257 @@
+package queryrange
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/grafana/dskit/httpgrpc"
+	"github.com/grafana/dskit/tenant"
+	"github.com/grafana/dskit/user"
+	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/grafana/loki/v3/pkg/engine"
+	"github.com/grafana/loki/v3/pkg/engine/executor"
+	"github.com/grafana/loki/v3/pkg/engine/planner/physical"
+	"github.com/grafana/loki/v3/pkg/logql"
+	"github.com/grafana/loki/v3/pkg/querier/queryrange/queryrangebase"
+	"github.com/grafana/loki/v3/pkg/util/spanlogger"
+)
+
+// FragmentPath is the path of the internal requests that execute a fragment
+// of a physical plan of the new query engine on a querier. Requests with this
+// path are only sent through the query scheduler and never served over HTTP.
+const FragmentPath = "/loki/api/v1/fragment"
+
+const (
+	// fragmentPlanContentType is the content type of the body of fragment
+	// requests, which holds a plan encoded with [physical.MarshalPlan].
+	fragmentPlanContentType = "application/json"
+	// fragmentResultsContentType is the content type of the body of fragment
+	// responses, which holds the length-prefixed Arrow IPC streams written by
+	// [executor.WriteFragmentResults].
+	fragmentResultsContentType = "application/octet-stream"
+)
+
+// FragmentRequest is a request to execute a fragment of a physical plan of
+// the new query engine. The fragment is encoded with [physical.MarshalPlan].
+type FragmentRequest struct {
+	Plan []byte
+}
+
+var _ queryrangebase.Request = (*FragmentRequest)(nil)
+
+func (r *FragmentRequest) Reset() { *r = FragmentRequest{} }
+func (r *FragmentRequest) String() string {
+	return fmt.Sprintf("FragmentRequest{%d bytes}", len(r.Plan))
+}
+func (*FragmentRequest) ProtoMessage() {}
+
+// GetStart implements queryrangebase.Request. Fragments have no time range
+// of their own, the time range is part of the encoded plan.
+func (*FragmentRequest) GetStart() time.Time { return time.Time{} }
+
+// GetEnd implements queryrangebase.Request.
+func (*FragmentRequest) GetEnd() time.Time { return time.Time{} }
+
+// GetStep implements queryrangebase.Request.
+func (*FragmentRequest) GetStep() int64 { return 0 }
+
+// GetQuery implements queryrangebase.Request.
+func (*FragmentRequest) GetQuery() string { return "" }
+
+// GetCachingOptions implements queryrangebase.Request. Results of fragments
+// are never cached.
+func (*FragmentRequest) GetCachingOptions() (res queryrangebase.CachingOptions) {
+	res.Disabled = true
+	return
+}
+
+// WithStartEnd implements queryrangebase.Request. The request is returned
+// unchanged.
+func (r *FragmentRequest) WithStartEnd(_, _ time.Time) queryrangebase.Request { return r }
+
+// WithQuery implements queryrangebase.Request. The request is returned
+// unchanged.
+func (r *FragmentRequest) WithQuery(_ string) queryrangebase.Request { return r }
+
+// LogToSpan implements queryrangebase.Request.
+func (r *FragmentRequest) LogToSpan(sp trace.Span) {
+	sp.SetAttributes(attribute.Int("plan_bytes", len(r.Plan)))
+}
+
+// FragmentResponse holds the results of a fragment, encoded with
+// [executor.WriteFragmentResults].
+type FragmentResponse struct {
+	Data    []byte
+	Headers []queryrangebase.PrometheusResponseHeader
+}
+
+var _ queryrangebase.Response = (*FragmentResponse)(nil)
+
+func (r *FragmentResponse) Reset() { *r = FragmentResponse{} }
+func (r *FragmentResponse) String() string {
+	return fmt.Sprintf("FragmentResponse{%d bytes}", len(r.Data))
+}
+func (*FragmentResponse) ProtoMessage() {}
+
+// GetHeaders implements queryrangebase.Response.
+func (r *FragmentResponse) GetHeaders() []*queryrangebase.PrometheusResponseHeader {
+	headers := make([]*queryrangebase.PrometheusResponseHeader, 0, len(r.Headers))
+	for i := range r.Headers {
+		headers = append(headers, &r.Headers[i])
+	}
+	return headers
+}
+
+// WithHeaders implements queryrangebase.Response.
+func (r *FragmentResponse) WithHeaders(h []queryrangebase.PrometheusResponseHeader) queryrangebase.Response {
+	r.Headers = h
+	return r
+}
+
+// SetHeader implements queryrangebase.Response.
+func (r *FragmentResponse) SetHeader(name, value string) {
+	r.Headers = setHeader(r.Headers, name, value)
+}
+
+// encodeFragmentResponse returns the HTTP response of a fragment. Its body
+// holds the encoded results as they are.
+func encodeFragmentResponse(res *FragmentResponse) *httpgrpc.HTTPResponse {
+	httpRes := &httpgrpc.HTTPResponse{
+		Code: int32(http.StatusOK),
+		Body: res.Data,
+		Headers: []*httpgrpc.Header{
+			{Key: "Content-Type", Values: []string{fragmentResultsContentType}},
+		},
+	}
+	for _, h := range res.Headers {
+		httpRes.Headers = append(httpRes.Headers, &httpgrpc.Header{Key: h.Name, Values: h.Values})
+	}
+	return httpRes
+}
+
+// GRPCRoundTripper round trips HTTP requests that are converted to protobuf
+// messages, for example through the query scheduler.
+type GRPCRoundTripper interface {
+	RoundTripGRPC(context.Context, *httpgrpc.HTTPRequest) (*httpgrpc.HTTPResponse, error)
+}
+
+// fragmentRunner is an [executor.FragmentRunner] that sends the fragments of
+// a plan to the queriers.
+type fragmentRunner struct {
+	rt GRPCRoundTripper
+}
+
+// NewFragmentRunner returns an [executor.FragmentRunner] that executes
+// fragments on the queriers by sending them through rt, which is typically a
+// query frontend.
+func NewFragmentRunner(rt GRPCRoundTripper) executor.FragmentRunner {
+	return &fragmentRunner{rt: rt}
+}
+
+// RunFragment implements [executor.FragmentRunner].
+func (r *fragmentRunner) RunFragment(ctx context.Context, fragment *physical.Plan) (io.ReadCloser, error) {
+	plan, err := physical.MarshalPlan(fragment)
+	if err != nil {
+		return nil, err
+	}
+
+	tenantIDs, err := tenant.TenantIDs(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	req := &httpgrpc.HTTPRequest{
+		Method: http.MethodPost,
+		Url:    FragmentPath,
+		Body:   plan,
+		Headers: []*httpgrpc.Header{
+			{Key: "Content-Type", Values: []string{fragmentPlanContentType}},
+			{Key: "Accept", Values: []string{fragmentResultsContentType}},
+			{Key: user.OrgIDHeaderName, Values: []string{tenant.JoinTenantIDs(tenantIDs)}},
+		},
+	}
+
+	res, err := r.rt.RoundTripGRPC(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if res.Code/100 != 2 {
+		return nil, httpgrpc.ErrorFromHTTPResponse(res)
+	}
+	// Only bodies that hold fragment results are decoded, and not responses
+	// that were encoded by the codec of other requests.
+	if contentType := fragmentHeader(res, "Content-Type"); contentType != fragmentResultsContentType {
+		return nil, fmt.Errorf("unexpected content type %q of fragment results", contentType)
+	}
+	return io.NopCloser(bytes.NewReader(res.Body)), nil
+}
+
+func fragmentHeader(res *httpgrpc.HTTPResponse, key string) string {
+	for _, h := range res.Headers {
+		if http.CanonicalHeaderKey(h.Key) == key && len(h.Values) > 0 {
+			return h.Values[0]
+		}
+	}
+	return ""
+}
+
+// NewEngineV2Middleware returns a middleware that executes range and instant
+// queries with the given new query engine, typically an engine created with
+// [engine.NewDistributed] that executes the fragments of the queries on the
+// queriers. Queries that are not supported by the engine, and queries for
+// which data objects are not available yet, are passed to the next handler.
+func NewEngineV2Middleware(eng logql.Engine, logger log.Logger) queryrangebase.Middleware {
+	return queryrangebase.MiddlewareFunc(func(next queryrangebase.Handler) queryrangebase.Handler {
+		return engineV2Middleware{
+			next:   next,
+			engine: eng,
+			logger: logger,
+		}
+	})
+}
+
+type engineV2Middleware struct {
+	next   queryrangebase.Handler
+	engine logql.Engine
+	logger log.Logger
+}
+
+func (m engineV2Middleware) Do(ctx context.Context, r queryrangebase.Request) (queryrangebase.Response, error) {
+	switch r.(type) {
+	case *LokiRequest, *LokiInstantRequest:
+	default:
+		return m.next.Do(ctx, r)
+	}
+
+	if !engine.HasDataObjectsAvailable(r.GetStart(), r.GetEnd()) {
+		return m.next.Do(ctx, r)
+	}
+
+	params, err := ParamsFromRequest(r)
+	if err != nil {
+		return nil, err
+	}
+
+	logger := spanlogger.FromContext(ctx, m.logger)
+	defer logger.Finish()
+
+	result, err := m.engine.Query(params).Exec(ctx)
+	if errors.Is(err, engine.ErrNotSupported) {
+		level.Warn(logger).Log("msg", "falling back to legacy query engine", "err", err)
+		return m.next.Do(ctx, r)
+	}
+	if err != nil {
+		level.Error(logger).Log("msg", "query execution failed with new query engine", "err", err)
+		return nil, errors.Wrap(err, "failed with new execution engine")
+	}
+	return ResultToResponse(result, params)
+}
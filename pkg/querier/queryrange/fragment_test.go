@@ -0,0 +1,95 @@
+package queryrange
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/grafana/dskit/httpgrpc"
+	"github.com/grafana/dskit/user"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/loki/v3/pkg/engine/planner/physical"
+)
+
+// codecRoundTripper passes requests to a handler like a querier does when it
+// receives them from the query scheduler.
+type codecRoundTripper struct {
+	handler func(ctx context.Context, req *FragmentRequest) (*FragmentResponse, error)
+	// contentType overrides the content type of the response if set.
+	contentType string
+}
+
+func (rt codecRoundTripper) RoundTripGRPC(ctx context.Context, req *httpgrpc.HTTPRequest) (*httpgrpc.HTTPResponse, error) {
+	decoded, ctx, err := DefaultCodec.DecodeHTTPGrpcRequest(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	res, err := rt.handler(ctx, decoded.(*FragmentRequest))
+	if err != nil {
+		return &httpgrpc.HTTPResponse{Code: http.StatusInternalServerError, Body: []byte(err.Error())}, nil
+	}
+	httpRes, err := DefaultCodec.EncodeHTTPGrpcResponse(ctx, req, res)
+	if err != nil || rt.contentType == "" {
+		return httpRes, err
+	}
+	httpRes.Headers = []*httpgrpc.Header{{Key: "Content-Type", Values: []string{rt.contentType}}}
+	return httpRes, nil
+}
+
+func TestFragmentRunner(t *testing.T) {
+	ctx := user.InjectOrgID(context.Background(), "fake")
+
+	fragment, err := physical.UnmarshalPlan([]byte(`{"nodes":[{"limit":{"fetch":10}}]}`))
+	require.NoError(t, err)
+
+	t.Run("success", func(t *testing.T) {
+		runner := NewFragmentRunner(codecRoundTripper{
+			handler: func(ctx context.Context, req *FragmentRequest) (*FragmentResponse, error) {
+				orgID, err := user.ExtractOrgID(ctx)
+				require.NoError(t, err)
+				require.Equal(t, "fake", orgID)
+
+				plan, err := physical.UnmarshalPlan(req.Plan)
+				require.NoError(t, err)
+				root, err := plan.Root()
+				require.NoError(t, err)
+				require.Equal(t, &physical.Limit{Fetch: 10}, root)
+
+				return &FragmentResponse{Data: []byte{0, 1, 2, 3}}, nil
+			},
+		})
+
+		r, err := runner.RunFragment(ctx, fragment)
+		require.NoError(t, err)
+		defer r.Close()
+
+		data, err := io.ReadAll(r)
+		require.NoError(t, err)
+		require.Equal(t, []byte{0, 1, 2, 3}, data)
+	})
+
+	t.Run("unexpected content type", func(t *testing.T) {
+		runner := NewFragmentRunner(codecRoundTripper{
+			handler: func(context.Context, *FragmentRequest) (*FragmentResponse, error) {
+				return &FragmentResponse{}, nil
+			},
+			contentType: "application/json",
+		})
+
+		_, err := runner.RunFragment(ctx, fragment)
+		require.ErrorContains(t, err, `unexpected content type "application/json"`)
+	})
+
+	t.Run("failure", func(t *testing.T) {
+		runner := NewFragmentRunner(codecRoundTripper{
+			handler: func(context.Context, *FragmentRequest) (*FragmentResponse, error) {
+				return nil, io.ErrUnexpectedEOF
+			},
+		})
+
+		_, err := runner.RunFragment(ctx, fragment)
+		require.ErrorContains(t, err, io.ErrUnexpectedEOF.Error())
+	})
+}
@@ -336,6 +336,11 @@ func (Codec) QueryRequestUnwrap(ctx context.Context, req *QueryRequest) (queryra
 		ctx = httpreq.InjectHeader(ctx, httpreq.LokiDisablePipelineWrappersHeader, disableWrappers)
 	}
 
+	// Add explain analyze
+	if explainAnalyze, ok := req.Metadata[httpreq.LokiExplainAnalyzeHeader]; ok {
+		ctx = httpreq.InjectHeader(ctx, httpreq.LokiExplainAnalyzeHeader, explainAnalyze)
+	}
+
 	// Add limits
 	if encodedLimits, ok := req.Metadata[querylimits.HTTPHeaderQueryLimitsKey]; ok {
 		limits, err := querylimits.UnmarshalQueryLimits([]byte(encodedLimits))
@@ -453,6 +458,12 @@ func (Codec) QueryRequestWrap(ctx context.Context, r queryrangebase.Request) (*Q
 		result.Metadata[httpreq.LokiDisablePipelineWrappersHeader] = disableWrappers
 	}
 
+	// Keep explain analyze
+	explainAnalyze := httpreq.ExtractHeader(ctx, httpreq.LokiExplainAnalyzeHeader)
+	if explainAnalyze != "" {
+		result.Metadata[httpreq.LokiExplainAnalyzeHeader] = explainAnalyze
+	}
+
 	// Add limits
 	limits := querylimits.ExtractQueryLimitsContext(ctx)
 	if limits != nil {
@@ -59,6 +59,11 @@ type Config struct {
 	SeriesCacheConfig            SeriesCacheConfig        `yaml:"series_results_cache" doc:"description=If series_results_cache is not configured and cache_series_results is true, the config for the results cache is used."`
 	CacheLabelResults            bool                     `yaml:"cache_label_results"`
 	LabelsCacheConfig            LabelsCacheConfig        `yaml:"label_results_cache" doc:"description=If label_results_cache is not configured and cache_label_results is true, the config for the results cache is used."`
+
+	// EngineV2 executes log and metric queries with the new query engine
+	// after they passed the limits and validation, see [NewEngineV2Middleware].
+	// It is not used if nil.
+	EngineV2 base.Middleware `yaml:"-"`
 }
 
 // RegisterFlags adds the flags required to configure this flag set.
@@ -610,6 +615,7 @@ const (
 	DetectedFieldsOp = "detected_fields"
 	PatternsQueryOp  = "patterns"
 	DetectedLabelsOp = "detected_labels"
+	FragmentOp       = "fragment"
 )
 
 func getOperation(path string) string {
@@ -636,6 +642,8 @@ func getOperation(path string) string {
 		return PatternsQueryOp
 	case strings.HasSuffix(path, "/detected_labels"):
 		return DetectedLabelsOp
+	case strings.HasSuffix(path, "/fragment"):
+		return FragmentOp
 	case strings.HasSuffix(path, "/values"):
 		if strings.Contains(path, "/label") {
 			return LabelNamesOp
@@ -665,9 +673,17 @@ func NewLogFilterTripperware(cfg Config, engineOpts logql.EngineOpts, log log.Lo
 			StatsCollectorMiddleware(),
 			NewLimitsMiddleware(limits),
 			NewQuerySizeLimiterMiddleware(schema.Configs, engineOpts, log, limits, statsHandler),
+		}
+
+		if cfg.EngineV2 != nil {
+			queryRangeMiddleware = append(queryRangeMiddleware, cfg.EngineV2)
+		}
+
+		queryRangeMiddleware = append(
+			queryRangeMiddleware,
 			base.InstrumentMiddleware("split_by_interval", metrics.InstrumentMiddlewareMetrics),
 			SplitByIntervalMiddleware(schema.Configs, limits, merger, newDefaultSplitter(limits, iqo), metrics.SplitByMetrics),
-		}
+		)
 
 		if cfg.CacheResults {
 			queryCacheMiddleware := NewLogResultCache(
@@ -735,9 +751,17 @@ func NewLimitedTripperware(cfg Config, engineOpts logql.EngineOpts, log log.Logg
 		queryRangeMiddleware := []base.Middleware{
 			StatsCollectorMiddleware(),
 			NewLimitsMiddleware(limits),
+		}
+
+		if cfg.EngineV2 != nil {
+			queryRangeMiddleware = append(queryRangeMiddleware, cfg.EngineV2)
+		}
+
+		queryRangeMiddleware = append(
+			queryRangeMiddleware,
 			base.InstrumentMiddleware("split_by_interval", metrics.InstrumentMiddlewareMetrics),
 			SplitByIntervalMiddleware(schema.Configs, WithMaxParallelism(limits, limitedQuerySplits), merger, newDefaultSplitter(limits, iqo), metrics.SplitByMetrics),
-		}
+		)
 
 		if cfg.ShardedQueries {
 			queryRangeMiddleware = append(queryRangeMiddleware,
@@ -992,6 +1016,14 @@ func NewMetricTripperware(cfg Config, engineOpts logql.EngineOpts, log log.Logge
 		queryRangeMiddleware = append(
 			queryRangeMiddleware,
 			NewQuerySizeLimiterMiddleware(schema.Configs, engineOpts, log, limits, statsHandler),
+		)
+
+		if cfg.EngineV2 != nil {
+			queryRangeMiddleware = append(queryRangeMiddleware, cfg.EngineV2)
+		}
+
+		queryRangeMiddleware = append(
+			queryRangeMiddleware,
 			base.InstrumentMiddleware("split_by_interval", metrics.InstrumentMiddlewareMetrics),
 			SplitByIntervalMiddleware(schema.Configs, limits, merger, newMetricQuerySplitter(limits, iqo), metrics.SplitByMetrics),
 		)
@@ -1108,9 +1140,17 @@ func NewInstantMetricTripperware(
 			StatsCollectorMiddleware(),
 			NewLimitsMiddleware(limits),
 			NewQuerySizeLimiterMiddleware(schema.Configs, engineOpts, log, limits, statsHandler),
-			NewSplitByRangeMiddleware(log, engineOpts, limits, cfg.InstantMetricQuerySplitAlign, metrics.MiddlewareMapperMetrics.rangeMapper),
 		}
 
+		if cfg.EngineV2 != nil {
+			queryRangeMiddleware = append(queryRangeMiddleware, cfg.EngineV2)
+		}
+
+		queryRangeMiddleware = append(
+			queryRangeMiddleware,
+			NewSplitByRangeMiddleware(log, engineOpts, limits, cfg.InstantMetricQuerySplitAlign, metrics.MiddlewareMapperMetrics.rangeMapper),
+		)
+
 		if cfg.CacheInstantMetricResults {
 			queryRangeMiddleware = append(
 				queryRangeMiddleware,
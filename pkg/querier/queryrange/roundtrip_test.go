@@ -1031,6 +1031,60 @@ func TestTripperware_EntriesLimit(t *testing.T) {
 	require.False(t, called)
 }
 
+func TestTripperware_EngineV2(t *testing.T) {
+	var engineCalls int
+	cfg := testConfig
+	cfg.EngineV2 = base.MiddlewareFunc(func(base.Handler) base.Handler {
+		return base.HandlerFunc(func(_ context.Context, r base.Request) (base.Response, error) {
+			engineCalls++
+			return &LokiResponse{
+				Status:    loghttp.QueryStatusSuccess,
+				Direction: r.(*LokiRequest).Direction,
+				Limit:     r.(*LokiRequest).Limit,
+				Version:   uint32(loghttp.VersionV1),
+				Data:      LokiData{ResultType: loghttp.ResultTypeStream},
+			}, nil
+		})
+	})
+	tpw, stopper, err := NewMiddleware(cfg, testEngineOpts, nil, util_log.Logger, fakeLimits{maxEntriesLimitPerQuery: 5000, maxQueryParallelism: 1}, config.SchemaConfig{Configs: testSchemas}, nil, false, nil, constants.Loki)
+	if stopper != nil {
+		defer stopper.Stop()
+	}
+	require.NoError(t, err)
+
+	lreq := &LokiRequest{
+		Query:     `{app="foo"}`,
+		Limit:     10000,
+		StartTs:   testTime.Add(-6 * time.Hour),
+		EndTs:     testTime,
+		Direction: logproto.FORWARD,
+		Path:      "/loki/api/v1/query_range",
+		Plan: &plan.QueryPlan{
+			AST: syntax.MustParseExpr(`{app="foo"}`),
+		},
+	}
+
+	ctx := user.InjectOrgID(context.Background(), "1")
+
+	called := false
+	h := base.HandlerFunc(func(context.Context, base.Request) (base.Response, error) {
+		called = true
+		return nil, nil
+	})
+
+	// Queries that exceed the limits are rejected before they are executed
+	// with the new engine.
+	_, err = tpw.Wrap(h).Do(ctx, lreq)
+	require.Error(t, err)
+	require.Equal(t, 0, engineCalls)
+
+	lreq.Limit = 1000
+	_, err = tpw.Wrap(h).Do(ctx, lreq)
+	require.NoError(t, err)
+	require.Equal(t, 1, engineCalls)
+	require.False(t, called)
+}
+
 func TestTripperware_RequiredLabels(t *testing.T) {
 	const noErr = ""
 
@@ -20,6 +20,9 @@ type MockLimits struct {
 	EnableMultiVariantQueriesVal  bool
 	MetricAggregationEnabledVal   bool
 	PatternPersistenceEnabledVal  bool
+
+	QueryEngineAggregationMemoryLimitVal int
+	QueryEngineAggregationSpillLimitVal  int
 }
 
 func (m *MockLimits) EnableMultiVariantQueries(_ string) bool {
@@ -58,6 +61,14 @@ func (m *MockLimits) MaxStreamsMatchersPerQuery(_ context.Context, _ string) int
 	return m.MaxStreamsMatchersPerQueryVal
 }
 
+func (m *MockLimits) QueryEngineAggregationMemoryLimit(_ context.Context, _ string) int {
+	return m.QueryEngineAggregationMemoryLimitVal
+}
+
+func (m *MockLimits) QueryEngineAggregationSpillLimit(_ context.Context, _ string) int {
+	return m.QueryEngineAggregationSpillLimitVal
+}
+
 func (m *MockLimits) BlockedQueries(_ context.Context, _ string) []*validation.BlockedQuery {
 	return nil
 }
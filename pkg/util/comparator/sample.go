@@ -0,0 +1,52 @@
+// Package comparator compares the samples of query results, for tools
+// comparing the results of the same query.
+package comparator
+
+import (
+	"math"
+	"time"
+
+	"github.com/prometheus/common/model"
+)
+
+// SampleComparisonOptions configures how samples are compared.
+type SampleComparisonOptions struct {
+	Tolerance         float64
+	UseRelativeError  bool
+	SkipRecentSamples time.Duration
+	SkipSamplesBefore time.Time
+}
+
+// SkipSample returns whether a sample at sampleTime of a query evaluated at
+// evaluationTime is outside of the compared window.
+func (opts *SampleComparisonOptions) SkipSample(sampleTime, evaluationTime time.Time) bool {
+	// Skip if sample is too old
+	if !opts.SkipSamplesBefore.IsZero() && sampleTime.Before(opts.SkipSamplesBefore) {
+		return true
+	}
+
+	// Skip if sample is too recent
+	if opts.SkipRecentSamples > 0 && sampleTime.After(evaluationTime.Add(-opts.SkipRecentSamples)) {
+		return true
+	}
+	return false
+}
+
+// CompareSampleValue returns whether two sample values are equal within the
+// tolerance of opts. NaN and infinite values are equal to themselves.
+func CompareSampleValue(first, second model.SampleValue, opts SampleComparisonOptions) bool {
+	f := float64(first)
+	s := float64(second)
+
+	if (math.IsNaN(f) && math.IsNaN(s)) ||
+		(math.IsInf(f, 1) && math.IsInf(s, 1)) ||
+		(math.IsInf(f, -1) && math.IsInf(s, -1)) {
+		return true
+	} else if opts.Tolerance <= 0 {
+		return math.Float64bits(f) == math.Float64bits(s)
+	}
+	if opts.UseRelativeError && s != 0 {
+		return math.Abs(f-s)/math.Abs(s) <= opts.Tolerance
+	}
+	return math.Abs(f-s) <= opts.Tolerance
+}
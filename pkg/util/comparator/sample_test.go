@@ -0,0 +1,45 @@
+package comparator
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompareSampleValue(t *testing.T) {
+	for _, tc := range []struct {
+		name          string
+		first, second float64
+		opts          SampleComparisonOptions
+		equal         bool
+	}{
+		{name: "equal", first: 1, second: 1, equal: true},
+		{name: "different", first: 1, second: 1.01},
+		{name: "NaN", first: math.NaN(), second: math.NaN(), equal: true},
+		{name: "+Inf", first: math.Inf(1), second: math.Inf(1), equal: true},
+		{name: "opposite Inf", first: math.Inf(1), second: math.Inf(-1)},
+		{name: "within tolerance", first: 1, second: 1.01, opts: SampleComparisonOptions{Tolerance: 0.1}, equal: true},
+		{name: "outside tolerance", first: 1, second: 1.2, opts: SampleComparisonOptions{Tolerance: 0.1}},
+		{name: "within relative error", first: 100, second: 105, opts: SampleComparisonOptions{Tolerance: 0.1, UseRelativeError: true}, equal: true},
+		{name: "outside relative error", first: 1, second: 1.2, opts: SampleComparisonOptions{Tolerance: 0.1, UseRelativeError: true}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.equal, CompareSampleValue(model.SampleValue(tc.first), model.SampleValue(tc.second), tc.opts))
+		})
+	}
+}
+
+func TestSampleComparisonOptions_SkipSample(t *testing.T) {
+	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	opts := SampleComparisonOptions{
+		SkipRecentSamples: time.Minute,
+		SkipSamplesBefore: now.Add(-time.Hour),
+	}
+
+	require.True(t, opts.SkipSample(now.Add(-2*time.Hour), now))
+	require.False(t, opts.SkipSample(now.Add(-30*time.Minute), now))
+	require.True(t, opts.SkipSample(now.Add(-30*time.Second), now))
+}
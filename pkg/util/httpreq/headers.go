@@ -15,6 +15,11 @@ var (
 	LokiActorPathHeader               = "X-Loki-Actor-Path"
 	LokiDisablePipelineWrappersHeader = "X-Loki-Disable-Pipeline-Wrappers"
 
+	// LokiExplainAnalyzeHeader is the name of the header that requests the
+	// query plan of the new query engine annotated with the runtime
+	// statistics of each node. Its value must be "true".
+	LokiExplainAnalyzeHeader = "X-Loki-Explain-Analyze"
+
 	// LokiActorPathDelimiter is the delimiter used to serialise the hierarchy of the actor.
 	LokiActorPathDelimiter = "|"
 )
@@ -5,6 +5,7 @@ import (
 	bloomplanner "github.com/grafana/loki/v3/pkg/bloombuild/planner"
 	"github.com/grafana/loki/v3/pkg/bloomgateway"
 	"github.com/grafana/loki/v3/pkg/compactor"
+	dataobj_consumer "github.com/grafana/loki/v3/pkg/dataobj/consumer"
 	"github.com/grafana/loki/v3/pkg/distributor"
 	"github.com/grafana/loki/v3/pkg/indexgateway"
 	"github.com/grafana/loki/v3/pkg/ingester"
@@ -32,4 +33,5 @@ type CombinedLimits interface {
 	bloombuilder.Limits
 	pattern.Limits
 	bucket.SSEConfigProvider
+	dataobj_consumer.Limits
 }
@@ -21,6 +21,7 @@ import (
 
 	"github.com/grafana/loki/v3/pkg/compactor/deletionmode"
 	"github.com/grafana/loki/v3/pkg/compression"
+	"github.com/grafana/loki/v3/pkg/dataobj"
 	"github.com/grafana/loki/v3/pkg/distributor/shardstreams"
 	"github.com/grafana/loki/v3/pkg/loghttp/push"
 	"github.com/grafana/loki/v3/pkg/logql"
@@ -128,6 +129,9 @@ type Limits struct {
 	QueryReadyIndexNumDays     int              `yaml:"query_ready_index_num_days" json:"query_ready_index_num_days"`
 	QueryTimeout               model.Duration   `yaml:"query_timeout" json:"query_timeout"`
 
+	QueryEngineAggregationMemoryLimit flagext.ByteSize `yaml:"query_engine_aggregation_memory_limit" json:"query_engine_aggregation_memory_limit" category:"experimental"`
+	QueryEngineAggregationSpillLimit  flagext.ByteSize `yaml:"query_engine_aggregation_spill_limit" json:"query_engine_aggregation_spill_limit" category:"experimental"`
+
 	// Query frontend enforced limits. The default is actually parameterized by the queryrange config.
 	QuerySplitDuration               model.Duration   `yaml:"split_queries_by_interval" json:"split_queries_by_interval"`
 	MetadataQuerySplitDuration       model.Duration   `yaml:"split_metadata_queries_by_interval" json:"split_metadata_queries_by_interval"`
@@ -242,6 +246,11 @@ type Limits struct {
 
 	IngestionPartitionsTenantShardSize int `yaml:"ingestion_partitions_tenant_shard_size" json:"ingestion_partitions_tenant_shard_size" category:"experimental"`
 
+	DataObjLogsCompressionCodec    string `yaml:"dataobj_logs_compression_codec" json:"dataobj_logs_compression_codec" category:"experimental"`
+	DataObjLogsCompressionLevel    int    `yaml:"dataobj_logs_compression_level" json:"dataobj_logs_compression_level" category:"experimental"`
+	DataObjStreamsCompressionCodec string `yaml:"dataobj_streams_compression_codec" json:"dataobj_streams_compression_codec" category:"experimental"`
+	DataObjStreamsCompressionLevel int    `yaml:"dataobj_streams_compression_level" json:"dataobj_streams_compression_level" category:"experimental"`
+
 	ShardAggregations []string `yaml:"shard_aggregations,omitempty" json:"shard_aggregations,omitempty" doc:"description=List of LogQL vector and range aggregations that should be sharded."`
 
 	PatternIngesterTokenizableJSONFieldsDefault dskit_flagext.StringSliceCSV `yaml:"pattern_ingester_tokenizable_json_fields_default" json:"pattern_ingester_tokenizable_json_fields_default" doc:"hidden"`
@@ -339,6 +348,9 @@ func (l *Limits) RegisterFlags(f *flag.FlagSet) {
 	_ = l.QueryTimeout.Set(DefaultPerTenantQueryTimeout)
 	f.Var(&l.QueryTimeout, "querier.query-timeout", "Timeout when querying backends (ingesters or storage) during the execution of a query request. When a specific per-tenant timeout is used, the global timeout is ignored.")
 
+	f.Var(&l.QueryEngineAggregationMemoryLimit, "querier.engine-aggregation-memory-limit", "Experimental: Maximum memory the aggregations of a single query may use for their state when the query is executed by the new query engine. Above this limit, the state of the aggregations is spilled to local disk. The default value of 0 disables spilling.")
+	f.Var(&l.QueryEngineAggregationSpillLimit, "querier.engine-aggregation-spill-limit", "Experimental: Maximum number of bytes the aggregations of a single query may spill to local disk when the query is executed by the new query engine. Queries that exceed the limit fail. The default value of 0 does not set a limit.")
+
 	_ = l.MaxQueryLookback.Set("0s")
 	f.Var(&l.MaxQueryLookback, "querier.max-query-lookback", "Limit how far back in time series data and metadata can be queried, up until lookback duration ago. This limit is enforced in the query frontend, the querier and the ruler. If the requested time range is outside the allowed range, the request will not fail, but will be modified to only query data within the allowed time range. The default value of 0 does not set a limit.")
 	f.IntVar(&l.MaxQueryParallelism, "querier.max-query-parallelism", 32, "Maximum number of queries that will be scheduled in parallel by the frontend.")
@@ -461,6 +473,11 @@ func (l *Limits) RegisterFlags(f *flag.FlagSet) {
 
 	f.IntVar(&l.IngestionPartitionsTenantShardSize, "limits.ingestion-partition-tenant-shard-size", 0, "The number of partitions a tenant's data should be sharded to when using kafka ingestion. Tenants are sharded across partitions using shuffle-sharding. 0 disables shuffle sharding and tenant is sharded across all partitions.")
 
+	f.StringVar(&l.DataObjLogsCompressionCodec, "limits.dataobj-logs-compression-codec", "", "Experimental. The compression codec to use for the pages of the logs sections of the tenant's data objects. Empty uses -dataobj-consumer.logs-compression.codec.")
+	f.IntVar(&l.DataObjLogsCompressionLevel, "limits.dataobj-logs-compression-level", 0, "Experimental. The compression level to use for the pages of the logs sections of the tenant's data objects. 0 uses the default level of the codec. Only used if -limits.dataobj-logs-compression-codec is set.")
+	f.StringVar(&l.DataObjStreamsCompressionCodec, "limits.dataobj-streams-compression-codec", "", "Experimental. The compression codec to use for the pages of the streams sections of the tenant's data objects. Empty uses -dataobj-consumer.streams-compression.codec.")
+	f.IntVar(&l.DataObjStreamsCompressionLevel, "limits.dataobj-streams-compression-level", 0, "Experimental. The compression level to use for the pages of the streams sections of the tenant's data objects. 0 uses the default level of the codec. Only used if -limits.dataobj-streams-compression-codec is set.")
+
 	_ = l.PatternIngesterTokenizableJSONFieldsDefault.Set("log,message,msg,msg_,_msg,content")
 	f.Var(&l.PatternIngesterTokenizableJSONFieldsDefault, "limits.pattern-ingester-tokenizable-json-fields", "List of JSON fields that should be tokenized in the pattern ingester.")
 	f.Var(&l.PatternIngesterTokenizableJSONFieldsAppend, "limits.pattern-ingester-tokenizable-json-fields-append", "List of JSON fields that should be appended to the default list of tokenizable fields in the pattern ingester.")
@@ -575,6 +592,17 @@ func (l *Limits) Validate() error {
 		return err
 	}
 
+	if cfg := l.dataObjLogsCompression(); cfg.Codec != "" {
+		if err := cfg.Validate(); err != nil {
+			return errors.Wrap(err, "invalid dataobj logs compression")
+		}
+	}
+	if cfg := l.dataObjStreamsCompression(); cfg.Codec != "" {
+		if err := cfg.Validate(); err != nil {
+			return errors.Wrap(err, "invalid dataobj streams compression")
+		}
+	}
+
 	if l.TSDBMaxBytesPerShard <= 0 {
 		return errors.New("querier.tsdb-max-bytes-per-shard must be greater than 0")
 	}
@@ -711,6 +739,18 @@ func (o *Overrides) MaxQuerySeries(_ context.Context, userID string) int {
 	return o.getOverridesForUser(userID).MaxQuerySeries
 }
 
+// QueryEngineAggregationMemoryLimit returns the maximum memory the aggregations
+// of a query may use before their state is spilled to disk.
+func (o *Overrides) QueryEngineAggregationMemoryLimit(_ context.Context, userID string) int {
+	return o.getOverridesForUser(userID).QueryEngineAggregationMemoryLimit.Val()
+}
+
+// QueryEngineAggregationSpillLimit returns the maximum number of bytes the
+// aggregations of a query may spill to disk.
+func (o *Overrides) QueryEngineAggregationSpillLimit(_ context.Context, userID string) int {
+	return o.getOverridesForUser(userID).QueryEngineAggregationSpillLimit.Val()
+}
+
 // MaxQueryRange returns the limit for the max [range] value that can be in a range query
 func (o *Overrides) MaxQueryRange(_ context.Context, userID string) time.Duration {
 	return time.Duration(o.getOverridesForUser(userID).MaxQueryRange)
@@ -873,6 +913,28 @@ func (o *Overrides) IngestionPartitionsTenantShardSize(userID string) int {
 	return o.getOverridesForUser(userID).IngestionPartitionsTenantShardSize
 }
 
+// DataObjLogsCompression returns the compression of the logs sections of the
+// data objects of the tenant. The codec is empty if the tenant has no
+// override.
+func (o *Overrides) DataObjLogsCompression(userID string) dataobj.CompressionConfig {
+	return o.getOverridesForUser(userID).dataObjLogsCompression()
+}
+
+// DataObjStreamsCompression returns the compression of the streams sections
+// of the data objects of the tenant. The codec is empty if the tenant has no
+// override.
+func (o *Overrides) DataObjStreamsCompression(userID string) dataobj.CompressionConfig {
+	return o.getOverridesForUser(userID).dataObjStreamsCompression()
+}
+
+func (l *Limits) dataObjLogsCompression() dataobj.CompressionConfig {
+	return dataobj.CompressionConfig{Codec: l.DataObjLogsCompressionCodec, Level: l.DataObjLogsCompressionLevel}
+}
+
+func (l *Limits) dataObjStreamsCompression() dataobj.CompressionConfig {
+	return dataobj.CompressionConfig{Codec: l.DataObjStreamsCompressionCodec, Level: l.DataObjStreamsCompressionLevel}
+}
+
 // RulerMaxRulesPerRuleGroup returns the maximum number of rules per rule group for a given user.
 func (o *Overrides) RulerMaxRulesPerRuleGroup(userID string) int {
 	return o.getOverridesForUser(userID).RulerMaxRulesPerRuleGroup
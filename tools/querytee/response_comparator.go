@@ -3,7 +3,6 @@ package querytee
 import (
 	"encoding/json"
 	"fmt"
-	"math"
 	"strings"
 	"time"
 
@@ -13,11 +12,12 @@ import (
 	"github.com/prometheus/common/model"
 
 	"github.com/grafana/loki/v3/pkg/loghttp"
+	"github.com/grafana/loki/v3/pkg/util/comparator"
 	util_log "github.com/grafana/loki/v3/pkg/util/log"
 )
 
 // SamplesComparatorFunc helps with comparing different types of samples coming from /api/v1/query and /api/v1/query_range routes.
-type SamplesComparatorFunc func(expected, actual json.RawMessage, evaluationTime time.Time, opts SampleComparisonOptions) (*ComparisonSummary, error)
+type SamplesComparatorFunc func(expected, actual json.RawMessage, evaluationTime time.Time, opts comparator.SampleComparisonOptions) (*ComparisonSummary, error)
 
 type SamplesResponse struct {
 	Status string
@@ -27,27 +27,7 @@ type SamplesResponse struct {
 	}
 }
 
-type SampleComparisonOptions struct {
-	Tolerance         float64
-	UseRelativeError  bool
-	SkipRecentSamples time.Duration
-	SkipSamplesBefore time.Time
-}
-
-func (opts *SampleComparisonOptions) SkipSample(sampleTime, evaluationTime time.Time) bool {
-	// Skip if sample is too old
-	if !opts.SkipSamplesBefore.IsZero() && sampleTime.Before(opts.SkipSamplesBefore) {
-		return true
-	}
-
-	// Skip if sample is too recent
-	if opts.SkipRecentSamples > 0 && sampleTime.After(evaluationTime.Add(-opts.SkipRecentSamples)) {
-		return true
-	}
-	return false
-}
-
-func NewSamplesComparator(opts SampleComparisonOptions) *SamplesComparator {
+func NewSamplesComparator(opts comparator.SampleComparisonOptions) *SamplesComparator {
 	return &SamplesComparator{
 		opts: opts,
 		sampleTypesComparator: map[string]SamplesComparatorFunc{
@@ -60,7 +40,7 @@ func NewSamplesComparator(opts SampleComparisonOptions) *SamplesComparator {
 }
 
 type SamplesComparator struct {
-	opts                  SampleComparisonOptions
+	opts                  comparator.SampleComparisonOptions
 	sampleTypesComparator map[string]SamplesComparatorFunc
 }
 
@@ -98,7 +78,7 @@ func (s *SamplesComparator) Compare(expectedResponse, actualResponse []byte, eva
 	return comparator(expected.Data.Result, actual.Data.Result, evaluationTime, s.opts)
 }
 
-func compareMatrix(expectedRaw, actualRaw json.RawMessage, evaluationTime time.Time, opts SampleComparisonOptions) (*ComparisonSummary, error) {
+func compareMatrix(expectedRaw, actualRaw json.RawMessage, evaluationTime time.Time, opts comparator.SampleComparisonOptions) (*ComparisonSummary, error) {
 	var expected, actual model.Matrix
 
 	err := json.Unmarshal(expectedRaw, &expected)
@@ -153,7 +133,7 @@ func compareMatrix(expectedRaw, actualRaw json.RawMessage, evaluationTime time.T
 	return nil, nil
 }
 
-func compareMatrixSamples(expected, actual *model.SampleStream, opts SampleComparisonOptions) error {
+func compareMatrixSamples(expected, actual *model.SampleStream, opts comparator.SampleComparisonOptions) error {
 	expectedEntriesCount := len(expected.Values)
 	actualEntriesCount := len(actual.Values)
 
@@ -200,7 +180,7 @@ func filterSamplesOutsideWindow(matrix model.Matrix, skipSample func(time.Time)
 	return result
 }
 
-func compareVector(expectedRaw, actualRaw json.RawMessage, evaluationTime time.Time, opts SampleComparisonOptions) (*ComparisonSummary, error) {
+func compareVector(expectedRaw, actualRaw json.RawMessage, evaluationTime time.Time, opts comparator.SampleComparisonOptions) (*ComparisonSummary, error) {
 	var expected, actual model.Vector
 
 	err := json.Unmarshal(expectedRaw, &expected)
@@ -282,7 +262,7 @@ func compareVector(expectedRaw, actualRaw json.RawMessage, evaluationTime time.T
 	return &ComparisonSummary{missingMetrics: len(missingMetrics)}, err
 }
 
-func compareScalar(expectedRaw, actualRaw json.RawMessage, evaluationTime time.Time, opts SampleComparisonOptions) (*ComparisonSummary, error) {
+func compareScalar(expectedRaw, actualRaw json.RawMessage, evaluationTime time.Time, opts comparator.SampleComparisonOptions) (*ComparisonSummary, error) {
 	var expected, actual model.Scalar
 	err := json.Unmarshal(expectedRaw, &expected)
 	if err != nil {
@@ -307,35 +287,18 @@ func compareScalar(expectedRaw, actualRaw json.RawMessage, evaluationTime time.T
 	}, opts)
 }
 
-func compareSamplePair(expected, actual model.SamplePair, opts SampleComparisonOptions) error {
+func compareSamplePair(expected, actual model.SamplePair, opts comparator.SampleComparisonOptions) error {
 	if expected.Timestamp != actual.Timestamp {
 		return fmt.Errorf("expected timestamp %v but got %v", expected.Timestamp, actual.Timestamp)
 	}
-	if !compareSampleValue(expected.Value, actual.Value, opts) {
+	if !comparator.CompareSampleValue(expected.Value, actual.Value, opts) {
 		return fmt.Errorf("expected value %s for timestamp %v but got %s", expected.Value, expected.Timestamp, actual.Value)
 	}
 
 	return nil
 }
 
-func compareSampleValue(first, second model.SampleValue, opts SampleComparisonOptions) bool {
-	f := float64(first)
-	s := float64(second)
-
-	if (math.IsNaN(f) && math.IsNaN(s)) ||
-		(math.IsInf(f, 1) && math.IsInf(s, 1)) ||
-		(math.IsInf(f, -1) && math.IsInf(s, -1)) {
-		return true
-	} else if opts.Tolerance <= 0 {
-		return math.Float64bits(f) == math.Float64bits(s)
-	}
-	if opts.UseRelativeError && s != 0 {
-		return math.Abs(f-s)/math.Abs(s) <= opts.Tolerance
-	}
-	return math.Abs(f-s) <= opts.Tolerance
-}
-
-func compareStreams(expectedRaw, actualRaw json.RawMessage, evaluationTime time.Time, opts SampleComparisonOptions) (*ComparisonSummary, error) {
+func compareStreams(expectedRaw, actualRaw json.RawMessage, evaluationTime time.Time, opts comparator.SampleComparisonOptions) (*ComparisonSummary, error) {
 	var expected, actual loghttp.Streams
 
 	err := jsoniter.Unmarshal(expectedRaw, &expected)
@@ -8,6 +8,8 @@ import (
 
 	"github.com/prometheus/common/model"
 	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/loki/v3/pkg/util/comparator"
 )
 
 func TestCompareMatrix(t *testing.T) {
@@ -93,7 +95,7 @@ func TestCompareMatrix(t *testing.T) {
 		},
 	} {
 		t.Run(tc.name, func(t *testing.T) {
-			_, err := compareMatrix(tc.expected, tc.actual, time.Now(), SampleComparisonOptions{})
+			_, err := compareMatrix(tc.expected, tc.actual, time.Now(), comparator.SampleComparisonOptions{})
 			if tc.err == nil {
 				require.NoError(t, err)
 				return
@@ -189,7 +191,7 @@ func TestCompareMatrix_SamplesOutsideComparableWindow(t *testing.T) {
 		},
 	} {
 		t.Run(tc.name, func(t *testing.T) {
-			_, err := compareMatrix(tc.expected, tc.actual, tc.evaluationTime, SampleComparisonOptions{
+			_, err := compareMatrix(tc.expected, tc.actual, tc.evaluationTime, comparator.SampleComparisonOptions{
 				SkipSamplesBefore: tc.skipSamplesBefore,
 				SkipRecentSamples: tc.skipRecentSamples,
 			})
@@ -276,7 +278,7 @@ func TestCompareVector(t *testing.T) {
 		},
 	} {
 		t.Run(tc.name, func(t *testing.T) {
-			_, err := compareVector(tc.expected, tc.actual, time.Now(), SampleComparisonOptions{})
+			_, err := compareVector(tc.expected, tc.actual, time.Now(), comparator.SampleComparisonOptions{})
 			if tc.err == nil {
 				require.NoError(t, err)
 				return
@@ -313,7 +315,7 @@ func TestCompareScalar(t *testing.T) {
 		},
 	} {
 		t.Run(tc.name, func(t *testing.T) {
-			_, err := compareScalar(tc.expected, tc.actual, time.Now(), SampleComparisonOptions{})
+			_, err := compareScalar(tc.expected, tc.actual, time.Now(), comparator.SampleComparisonOptions{})
 			if tc.err == nil {
 				require.NoError(t, err)
 				return
@@ -503,7 +505,7 @@ func TestCompareSamplesResponse(t *testing.T) {
 		},
 	} {
 		t.Run(tc.name, func(t *testing.T) {
-			samplesComparator := NewSamplesComparator(SampleComparisonOptions{
+			samplesComparator := NewSamplesComparator(comparator.SampleComparisonOptions{
 				Tolerance:         tc.tolerance,
 				UseRelativeError:  tc.useRelativeError,
 				SkipRecentSamples: tc.skipRecentSamples,
@@ -601,7 +603,7 @@ func TestCompareStreams(t *testing.T) {
 		},
 	} {
 		t.Run(tc.name, func(t *testing.T) {
-			_, err := compareStreams(tc.expected, tc.actual, time.Now(), SampleComparisonOptions{Tolerance: 0})
+			_, err := compareStreams(tc.expected, tc.actual, time.Now(), comparator.SampleComparisonOptions{Tolerance: 0})
 			if tc.err == nil {
 				require.NoError(t, err)
 				return
@@ -685,7 +687,7 @@ func TestCompareStreams_SamplesOutsideComparableWindow(t *testing.T) {
 		},
 	} {
 		t.Run(tc.name, func(t *testing.T) {
-			summary, err := compareStreams(tc.expected, tc.actual, tc.evaluationTime, SampleComparisonOptions{
+			summary, err := compareStreams(tc.expected, tc.actual, tc.evaluationTime, comparator.SampleComparisonOptions{
 				SkipSamplesBefore: tc.skipSamplesBefore,
 				SkipRecentSamples: tc.skipRecentSamples,
 			})